@@ -17,14 +17,21 @@ limitations under the License.
 package rest
 
 import (
+	"crypto/rand"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"golang.org/x/net/context"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"sort"
+	"strings"
 	"sync"
-
-	"github.com/gorilla/mux"
+	"sync/atomic"
+	"time"
 )
 
 type HandleMethod string
@@ -32,6 +39,7 @@ type HandleMethod string
 const (
 	defaultLogPrefix     = "rest "
 	defaultDocsDirectory = "_docs/"
+	defaultLimit         = 100
 
 	// Handler names
 	HandleCreate     HandleMethod = "create"
@@ -40,8 +48,21 @@ const (
 	HandleDelete                  = "delete"
 	HandleReadList                = "readList"
 	HandleUpdateList              = "updateList"
+	HandlePatch                   = "patch"
+	HandleSnapshot                = "snapshot"
+	HandleRestore                 = "restore"
+	HandleBatch                   = "batch"
+	HandleEvents                  = "events"
+	HandleChanges                 = "changes"
+	HandleSearch                  = "search"
+	HandleWebSocket               = "websocket"
+	HandleGraphQL                 = "graphql"
 )
 
+// ErrorFormatProblem is the ErrorFormat for serializing error responses as RFC 7807
+// problem details with the application/problem+json media type.
+const ErrorFormatProblem = "problem+json"
+
 // Address is the address and port to bind to (e.g. ":8080").
 type Address string
 
@@ -69,6 +90,325 @@ type Configuration struct {
 	Logger        StdLogger
 	GenerateDocs  bool
 	DocsDirectory string
+
+	// DefaultLimit is the limit passed to read list handlers when the client doesn't
+	// specify one. If zero, a limit of 100 is used. ResourceHandlers can override this
+	// by implementing LimitedResourceHandler.
+	DefaultLimit int
+
+	// MaxLimit is the largest limit passed to read list handlers. Larger requested
+	// limits are clamped to it. If zero, limits are unbounded. ResourceHandlers can
+	// override this by implementing LimitedResourceHandler.
+	MaxLimit int
+
+	// RejectOversizedLimits causes read list requests with a limit larger than the
+	// maximum to be rejected with a 400 instead of being clamped.
+	RejectOversizedLimits bool
+
+	// AllowEmptyBody causes requests with empty bodies to be treated as having empty
+	// payloads instead of being rejected with a 400. This is useful when operations
+	// don't need payloads, e.g. creates which generate the entire resource.
+	AllowEmptyBody bool
+
+	// DedupWindow enables detection of duplicate non-idempotent requests, i.e. POST and
+	// PATCH requests with the same identity, method, URL, and body within the window.
+	// Duplicates aren't passed to the ResourceHandler; instead the prior response is
+	// sent with a "duplicate" marker. This protects backends from double submissions by
+	// clients which retry without idempotency keys. Zero disables detection.
+	DedupWindow time.Duration
+
+	// DedupIdentity returns the identity of the client making the request for duplicate
+	// detection. Defaults to the Authorization header, falling back to the client's
+	// address.
+	DedupIdentity func(*http.Request) string
+
+	// Cache enables caching of successful read and read list responses. Cached
+	// responses are keyed by resource, path, version, query, tenant, and identity, and
+	// are invalidated when the resource is created, updated, or deleted. Implement
+	// GenerationalCache so responses read before an invalidation aren't cached after
+	// it. If nil, responses aren't cached.
+	Cache Cache
+
+	// CacheTTL is how long responses are cached for. ResourceHandlers can override it by
+	// implementing CachedResourceHandler. If zero, only responses of those handlers are
+	// cached.
+	CacheTTL time.Duration
+
+	// CacheIdentity returns the identity of the client making the request, so cached
+	// responses are only served to the client they were cached for. Defaults to the
+	// Authorization header. The ID and roles of the request's Identity are always
+	// included.
+	CacheIdentity func(*http.Request) string
+
+	// ConcurrencyLimit is the maximum number of requests to each resource which are
+	// handled at once. Excess requests are shed with 503 Service Unavailable and a
+	// Retry-After header, so slow handlers can't exhaust the server. Zero is unlimited.
+	ConcurrencyLimit int
+
+	// ConcurrencyLimits overrides ConcurrencyLimit for resources, keyed by resource
+	// name, e.g. "widgets", and adds limits for operations, keyed by route name, e.g.
+	// "widgets:readList". Requests must be within both the resource and operation
+	// limits. Zero is unlimited.
+	ConcurrencyLimits map[string]int
+
+	// ConcurrencyRetryAfter is the delay clients are asked to wait before retrying
+	// requests shed by concurrency limits. Defaults to one second.
+	ConcurrencyRetryAfter time.Duration
+
+	// Tenancy enables multi-tenancy, identifying and validating the tenant of requests
+	// to ResourceHandler endpoints. If nil, requests aren't associated with tenants.
+	Tenancy *Tenancy
+
+	// Quotas limits the number of requests and bandwidth of each client to
+	// ResourceHandler endpoints over fixed windows of time. Usage is reported with
+	// response headers and can be read at /api/:version/usage with
+	// API#RegisterUsageResource. If nil, clients aren't limited.
+	Quotas *Quotas
+
+	// CircuitBreakers attaches circuit breakers to resources with fragile backends,
+	// keyed by resource name, so repeated failures fail fast with 503 Service
+	// Unavailable instead of piling up timeouts. Their state is available through
+	// API's CircuitBreakerStats.
+	CircuitBreakers map[string]CircuitBreaker
+
+	// Links adds a "links" section to successful responses from ResourceHandler
+	// endpoints containing URLs of the resource, its collection, adjacent pages, and
+	// relations declared by handlers implementing LinkedResourceHandler.
+	Links bool
+
+	// ErrorFormat is the format error responses are serialized in regardless of the
+	// requested format, so clients requesting e.g. CSV exports still get machine-readable
+	// errors. It's either the format of a registered ResponseSerializer or
+	// ErrorFormatProblem. If empty, errors are serialized in the requested format.
+	ErrorFormat string
+
+	// RedactErrors sanitizes error responses for production. Server errors, such as
+	// errors returned by ResourceHandlers which aren't an Error, are sent to clients as
+	// a generic message with an error ID, identified by CodeInternalError, and logged
+	// with the ID and their full detail. Invalid field errors don't echo the values sent
+	// by the client. Retryable errors and other client errors are sent as is.
+	RedactErrors bool
+
+	// ErrorReporter is notified of panics and server error responses with the request
+	// metadata, stack trace, and RequestContext, e.g. to send them to an error tracking
+	// service. If set, panics in endpoints are recovered and result in 500 Internal
+	// Server Error responses.
+	ErrorReporter ErrorReporter
+
+	// Envelope builds the response bodies sent to clients from the default envelope,
+	// e.g. to rename its keys, add metadata, or disable it with NoEnvelope. If nil, the
+	// default envelope is sent.
+	Envelope EnvelopeBuilder
+
+	// ExternalBaseURL is the base URL clients use to reach the API, e.g.
+	// "https://api.example.com". If set, its scheme and host are used when building
+	// URLs such as next page links and its path is prepended to request paths.
+	ExternalBaseURL string
+
+	// TrustedProxies is a list of IP addresses or CIDR ranges of proxies whose
+	// X-Forwarded-Proto and X-Forwarded-Host headers are honored when building URLs.
+	TrustedProxies []string
+
+	// DeprecatedParams maps deprecated query parameter names to the names of their
+	// replacements. Deprecated parameters are aliased to their replacements and a
+	// warning is returned to clients using them. ResourceHandlers can declare additional
+	// deprecated parameters by implementing DeprecatedParamsResourceHandler.
+	DeprecatedParams map[string]string
+
+	// DeprecatedVersions maps deprecated API versions to their Deprecation. Responses
+	// to requests for deprecated versions include Deprecation and Sunset headers.
+	// ResourceHandlers can deprecate versions of their resource by implementing
+	// DeprecatedResourceHandler.
+	DeprecatedVersions map[string]Deprecation
+
+	// DeprecationWarnings adds a warning to the response messages and a Warning header
+	// for requests of deprecated versions.
+	DeprecationWarnings bool
+
+	// ServerTiming adds a Server-Timing header to responses of resource endpoints with
+	// the time spent authenticating, in the ResourceHandler, applying Rules, and
+	// serializing, so it's visible in browser developer tools. The timings are recorded
+	// in API#TimingStats regardless.
+	ServerTiming bool
+
+	// EventHeartbeat is the interval between heartbeats sent to clients streaming
+	// resource events. If zero, heartbeats are sent every 15 seconds.
+	EventHeartbeat time.Duration
+
+	// WebSocket enables the WebSocket endpoint, /api/:version/ws, which clients use to
+	// subscribe to the events of resources whose handlers implement
+	// EventsResourceHandler.
+	WebSocket bool
+
+	// GraphQL enables the GraphQL endpoint, /api/:version/graphql, which exposes
+	// queries and mutations of the registered resources, along with
+	// /api/:version/graphql/schema, which serves its schema. Operations go through the
+	// same authentication, Rules, hooks, and middleware as REST requests. GraphQL
+	// requests are themselves subject to the ConcurrencyLimit and Tenancy, and are
+	// timed, under the "graphql" name.
+	GraphQL bool
+
+	// GraphQLMaxFields is the maximum number of top-level fields of a GraphQL
+	// operation, each of which is performed as a resource operation. Requests with more
+	// are rejected with 400 Bad Request. If zero, 20 are allowed.
+	GraphQLMaxFields int
+
+	// BasePath is the path prefix of resource endpoints, replacing "/api" in the
+	// default /api/v{version}/resourceName layout, e.g. "/internal/api", or "/" for
+	// /v{version}/resourceName. Endpoints of ResourceHandlers with custom URIs aren't
+	// affected.
+	BasePath string
+
+	// VersionlessPaths removes the version from resource endpoint paths, e.g.
+	// /api/resourceName. Requests are then served with the version selected by the
+	// VersionHeader or VendorMediaType, or the DefaultVersion.
+	VersionlessPaths bool
+
+	// DefaultVersion is the version of requests which don't specify one.
+	DefaultVersion string
+
+	// VersionHeader is the request header selecting the API version when the path
+	// doesn't contain one, e.g. "X-API-Version".
+	VersionHeader string
+
+	// VendorMediaType is the vendor media type whose Accept header parameters select the
+	// API version when neither the path nor VersionHeader contains one. For example,
+	// "vnd.myapp" selects version 2 for application/vnd.myapp.v2+json and
+	// application/vnd.myapp+json; version=2.
+	VendorMediaType string
+
+	// StrictSlash redirects requests whose paths only differ from a route's by a
+	// trailing slash to the route's path. Otherwise, they aren't found.
+	StrictSlash bool
+
+	// MountPrefix is the path prefix the API's routes are registered under, e.g. "/rest"
+	// to serve /rest/api/v1/foo, so the API can be embedded in an existing
+	// http.ServeMux or router alongside other handlers. URLs built by the API, such as
+	// pagination links, include it.
+	MountPrefix string
+
+	// Router dispatches requests to the API's routes. If nil, a Router backed by
+	// gorilla/mux is used. Per-version ResourceHandler registration and StrictSlash are
+	// only supported by the default Router.
+	Router Router
+
+	// TLSConfig configures the TLS connections served by StartTLS, e.g. the minimum
+	// TLS version or client certificate verification. If nil, the net/http defaults are
+	// used.
+	TLSConfig *tls.Config
+
+	// DefaultFormat is the response format used when requests don't specify one with
+	// the "format" query parameter. If empty, "json" is used.
+	DefaultFormat string
+
+	// RangeRequests honors Range headers of GET requests for successful responses,
+	// responding with 206 Partial Content and the requested byte ranges of the
+	// serialized response, so large responses such as exports can be downloaded
+	// resumably. Responses include a strong ETag for clients to send in If-Range.
+	// Content returned by ResourceHandlers always honors Range headers.
+	RangeRequests bool
+
+	// UploadMemory is the number of bytes of the files uploaded with a
+	// multipart/form-data request which are held in memory. The remainder is spilled to
+	// temporary files. If zero, 32 MB are held in memory.
+	UploadMemory int64
+
+	// MethodOverride allows clients which can only send GET and POST requests, e.g.
+	// behind restrictive proxies, to make PUT, PATCH, and DELETE requests. POST requests
+	// with the method in the X-HTTP-Method-Override header, or the "_method" query
+	// parameter or form field, are handled as requests with that method.
+	MethodOverride bool
+
+	// MaxBodySize is the maximum size, in bytes, of request bodies. Requests with larger
+	// bodies are rejected with 413 Request Entity Too Large. If zero, the size isn't
+	// limited.
+	MaxBodySize int64
+
+	// ReadTimeout, WriteTimeout, and IdleTimeout configure the corresponding timeouts of
+	// the servers started by the API. If zero, there's no timeout.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+
+	// LifecycleTimeout bounds the initialization of ResourceHandlers, and closing them
+	// when they're unregistered. If zero, it's 30 seconds.
+	LifecycleTimeout time.Duration
+
+	// RouteDebug enables the routes endpoint under the DebugPrefix, /debug/routes by
+	// default, which lists the API's routes. Requests are authenticated with
+	// DebugAuthenticate if it's configured.
+	RouteDebug bool
+
+	// DebugPrefix is the path prefix of the profiling and expvar endpoints bound by
+	// EnableDebug. If empty, "/debug" is used.
+	DebugPrefix string
+
+	// DebugAuthenticate authenticates requests to the endpoints bound by EnableDebug and
+	// EnableExplorer, returning an error if the request isn't authorized. It's required
+	// to enable them.
+	DebugAuthenticate func(*http.Request) error
+
+	// LazyRoutes defers compiling the routes of registered handlers until the API first
+	// serves a request or is started, which speeds up startup of APIs registering many
+	// ResourceHandlers. Routes are compiled in registration order, so matching is the
+	// same as with eager compilation.
+	LazyRoutes bool
+
+	// ConfirmationSecret is the key used to sign confirmation tokens for two-phase
+	// destructive operations. If empty, a random key is generated when the API is
+	// created, so it must be set when tokens are verified by multiple API instances.
+	ConfirmationSecret []byte
+
+	// ConfirmationTTL is how long confirmation tokens for two-phase destructive
+	// operations are valid for. If zero, tokens are valid for 5 minutes.
+	ConfirmationTTL time.Duration
+
+	// CursorCodec encodes the pagination cursors returned by ResourceHandlers before
+	// they're placed in "next" links and decodes those passed back by clients, which
+	// are rejected with 400 Bad Request if they were tampered with. See
+	// NewSigningCursorCodec and NewEncryptingCursorCodec. If nil, cursors are sent as
+	// is.
+	CursorCodec CursorCodec
+
+	// Webhooks are notified of successful creates, updates, and deletes. Webhooks can
+	// also be registered through the API using API#RegisterWebhooksResource.
+	Webhooks []Webhook
+
+	// WebhookSecret is the key used to sign webhook notifications with HMAC-SHA256. The
+	// signature is sent in the X-Webhook-Signature header. If empty, notifications
+	// aren't signed.
+	WebhookSecret []byte
+
+	// WebhookQueue queues webhook deliveries. If nil, deliveries are made in the
+	// background and retried with exponential backoff.
+	WebhookQueue WebhookQueue
+
+	// WebhookAttempts is the number of times the default WebhookQueue attempts a
+	// delivery. If zero, deliveries are attempted 5 times.
+	WebhookAttempts int
+
+	// WebhookBackoff is the delay before the default WebhookQueue's first retry of a
+	// failed delivery, doubling for each subsequent retry. If zero, it's one second.
+	WebhookBackoff time.Duration
+
+	// PayloadMiddleware transform the request payloads of all ResourceHandlers after
+	// they're deserialized and before inbound Rules are applied. ResourceHandlers can
+	// add their own by implementing PayloadMiddlewareResourceHandler.
+	PayloadMiddleware []PayloadMiddleware
+
+	// ResponseMiddleware operate, in order, on serialized responses before they're
+	// written to clients.
+	ResponseMiddleware []ResponseMiddleware
+
+	// Translator translates the messages of error responses, including validation
+	// errors, into the language requested by the client's Accept-Language header. Only
+	// errors with codes, e.g. created with Error#WithCode, are translated. If nil,
+	// messages aren't translated.
+	Translator Translator
+
+	// DefaultLanguage is the language error messages are translated into when none of
+	// the languages accepted by the client have a translation, e.g. "en".
+	DefaultLanguage string
 }
 
 // Debugf prints the formatted string to the Configuration Logger if Debug is enabled.
@@ -86,6 +426,7 @@ func NewConfiguration() *Configuration {
 		Logger:        logger,
 		GenerateDocs:  true,
 		DocsDirectory: defaultDocsDirectory,
+		DefaultLimit:  defaultLimit,
 	}
 }
 
@@ -96,8 +437,8 @@ type MiddlewareError struct {
 	Response []byte
 }
 
-// Middleware can be passed in to API#Start and API#StartTLS and will be
-// invoked on every request to a route handled by the API. Returns a
+// Middleware can be passed in to API#Start, API#StartTLS, API#Serve, or WithMiddleware
+// and will be invoked on every request to a route handled by the API. Returns a
 // MiddlewareError if the request should be terminated.
 type Middleware func(w http.ResponseWriter, r *http.Request) *MiddlewareError
 
@@ -150,11 +491,41 @@ type API interface {
 	// provided Middleware will be invoked for every request handled by the API.
 	StartTLS(Address, FilePath, FilePath, ...Middleware) error
 
+	// Serve begins serving requests accepted on the provided Listener, e.g. a Unix domain
+	// socket or a listener inherited through systemd socket activation. This will block
+	// unless it fails, in which case an error will be returned. This will validate any
+	// defined Rules. If any Rules are invalid, it will panic. Any provided Middleware will
+	// be invoked for every request handled by the API.
+	Serve(net.Listener, ...Middleware) error
+
+	// Shutdown gracefully shuts down the servers started by the API. It stops accepting
+	// connections, ends event streams, and waits for in-flight requests to complete
+	// until the Context is done, in which case its error is returned. ResourceHandlers
+	// which implement CloseResourceHandler are then closed. Once the API is shut down,
+	// Start, StartTLS, and Serve return http.ErrServerClosed.
+	Shutdown(context.Context) error
+
 	// RegisterResourceHandler binds the provided ResourceHandler to the appropriate REST
 	// endpoints and applies any specified middleware. Endpoints will have the following
-	// base URL: /api/:version/resourceName.
+	// base URL: /api/:version/resourceName. It can be called while the API is serving
+	// requests.
 	RegisterResourceHandler(ResourceHandler, ...RequestMiddleware)
 
+	// UnregisterResourceHandler unbinds the REST endpoints of the named resource,
+	// including those of ResourceHandlers registered for specific versions. It can be
+	// called while the API is serving requests. Initialized ResourceHandlers which
+	// implement CloseResourceHandler are closed. An error is returned if the resource
+	// isn't registered, the configured Router doesn't support unregistering, or a
+	// ResourceHandler fails to close.
+	UnregisterResourceHandler(string) error
+
+	// RegisterResourceHandlerForVersions binds the provided ResourceHandler to the REST
+	// endpoints of its resource for only the given versions, allowing a different
+	// ResourceHandler to serve each version of the resource. Requests for versions
+	// without a registered ResourceHandler receive 410 Gone if the version precedes all
+	// registered versions and 404 Not Found otherwise.
+	RegisterResourceHandlerForVersions(ResourceHandler, ...string)
+
 	// RegisterHandlerFunc binds the http.HandlerFunc to the provided URI and applies any
 	// specified middleware.
 	RegisterHandlerFunc(string, http.HandlerFunc, ...RequestMiddleware)
@@ -167,6 +538,23 @@ type API interface {
 	// prefix and applies any specified middleware.
 	RegisterPathPrefix(string, http.HandlerFunc, ...RequestMiddleware)
 
+	// UsePlugin installs the Plugin, which hooks into handler registration, requests,
+	// startup, shutdown, and documentation generation. Plugins should be used before
+	// registering ResourceHandlers. It returns an error if a plugin with the same name
+	// is already used or installation fails.
+	UsePlugin(Plugin) error
+
+	// Handler returns an http.Handler serving the API with the provided Middleware
+	// applied, for embedding the API in an existing server instead of starting its own
+	// listener. Like Start, it validates any defined Rules, panicking if any are invalid.
+	// ResourceHandlers are initialized when the first request is handled.
+	Handler(...Middleware) http.Handler
+
+	// Dispatch performs the GatewayRequest against the registered ResourceHandler as if
+	// it were received over HTTP, so gateways for other transports, e.g. gRPC, share
+	// routing, authentication, Rules, and hooks with the HTTP path.
+	Dispatch(context.Context, GatewayRequest) (GatewayResponse, error)
+
 	// RegisterResponseSerializer registers the provided ResponseSerializer with the given
 	// format. If the format has already been registered, it will be overwritten.
 	RegisterResponseSerializer(string, ResponseSerializer)
@@ -185,14 +573,112 @@ type API interface {
 	// ResourceHandlers returns a slice containing the registered ResourceHandlers.
 	ResourceHandlers() []ResourceHandler
 
-	// Validate will validate the Rules configured for this API. It returns nil
-	// if all Rules are valid, otherwise returns the first encountered
+	// RegisterWebhooksResource registers the webhooks resource, which is used to
+	// register, list, and unregister webhooks at /api/:version/webhooks, and applies
+	// any specified middleware. Middleware should be used to restrict access to it.
+	RegisterWebhooksResource(...RequestMiddleware)
+
+	// RegisterConfig registers the enabled resources described by the
+	// RegistrationConfig, bound to the ResourceHandlers and RequestMiddleware with the
+	// names it uses. The whole config is validated first, so if an error is returned,
+	// none of the resources are registered.
+	RegisterConfig(*RegistrationConfig, Bindings) error
+
+	// RegisterUsageResource registers the usage resource, which reports the quota usage
+	// of the client making the request at /api/:version/usage, and applies any
+	// specified middleware. Requests to it aren't counted against quotas. An error is
+	// returned if Quotas aren't configured.
+	RegisterUsageResource(...RequestMiddleware) error
+
+	// DeliverWebhook posts the WebhookDelivery's notification to its webhook. It's used
+	// by custom WebhookQueues to make deliveries and returns an error if the delivery
+	// failed.
+	DeliverWebhook(WebhookDelivery) error
+
+	// Publish sends the Event to clients of its Tenant streaming the named resource's
+	// events. It's a no-op if there are no such clients.
+	Publish(string, Event)
+
+	// DisconnectStats returns the number of responses aborted mid-write because the
+	// client disconnected, grouped by resource and response size bucket.
+	DisconnectStats() []DisconnectStats
+
+	// EnableDebug binds profiling endpoints compatible with net/http/pprof, and an
+	// endpoint serving memory statistics like expvar, under the configured DebugPrefix,
+	// authenticated with DebugAuthenticate. An error is returned if DebugAuthenticate
+	// isn't configured.
+	EnableDebug() error
+
+	// EnableExplorer binds an HTML API explorer at /api/explorer, generated from the
+	// registered routes and Rules and authenticated with DebugAuthenticate. An error is
+	// returned if DebugAuthenticate isn't configured.
+	EnableExplorer() error
+
+	// Routes returns the routes registered with the API in the order they're matched.
+	Routes() []RouteInfo
+
+	// URLFor returns the URL path of the named resource's endpoint for the operation,
+	// version, and resource ID, which is ignored by endpoints not taking one. The path
+	// includes the MountPrefix and base path. An error is returned if the resource
+	// doesn't have an endpoint for the operation or the ID is required but missing.
+	URLFor(resource string, operation HandleMethod, version, id string) (*url.URL, error)
+
+	// DeprecationStats returns the number of requests made for deprecated versions,
+	// grouped by resource and version.
+	DeprecationStats() []DeprecationStats
+
+	// CircuitBreakerStats returns the state and request counts of the circuit breakers
+	// attached to resources, ordered by resource.
+	CircuitBreakerStats() []CircuitBreakerStats
+
+	// RetryStats returns the number of responses for retryable errors, e.g. those
+	// created with RetryableError, grouped by resource and status.
+	RetryStats() []RetryStats
+
+	// TenantStats returns the number of requests made by each tenant to each resource
+	// when Tenancy is configured.
+	TenantStats() []TenantStats
+
+	// TimingStats returns the time spent authenticating, in ResourceHandlers, applying
+	// Rules, and serializing responses, grouped by resource and phase.
+	TimingStats() []TimingStats
+
+	// Validate will validate the Rules and version ranges configured for this API.
+	// It returns nil if all are valid, otherwise returns the first encountered
 	// validation error.
 	Validate() error
 
 	// responseSerializer returns a ResponseSerializer for the given format type. If the
 	// format is not implemented, the returned serializer will be nil and the error set.
 	responseSerializer(string) (ResponseSerializer, error)
+
+	// operations returns the store of asynchronous operations started by the API.
+	operations() *operationStore
+
+	// events returns the broker of events published with the API.
+	events() *eventBroker
+
+	// retries returns the metrics of responses for retryable errors.
+	retries() *retryMetrics
+
+	// confirmationSecret returns the key used to sign confirmation tokens.
+	confirmationSecret() []byte
+
+	// webhooks returns the registry of webhooks notified of mutations.
+	webhooks() *webhookRegistry
+
+	// webhookQueue returns the WebhookQueue used to deliver webhook notifications.
+	webhookQueue() WebhookQueue
+
+	// shuttingDown returns a channel which is closed when the API is shut down.
+	shuttingDown() <-chan struct{}
+
+	// activeRouter returns the Router currently dispatching requests.
+	activeRouter() Router
+
+	// resourceHandler returns the first ResourceHandler registered for the named
+	// resource.
+	resourceHandler(string) (ResourceHandler, bool)
 }
 
 // RequestMiddleware is a function that returns a Handler wrapping the provided Handler.
@@ -203,7 +689,10 @@ type RequestMiddleware func(http.Handler) http.Handler
 func newAuthMiddleware(authenticate func(*http.Request) error) RequestMiddleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if err := authenticate(r); err != nil {
+			stop := requestTimingFor(r).start(TimingAuth)
+			err := authenticate(r)
+			stop()
+			if err != nil {
 				w.WriteHeader(http.StatusUnauthorized)
 				w.Write([]byte(err.Error()))
 				return
@@ -214,16 +703,14 @@ func newAuthMiddleware(authenticate func(*http.Request) error) RequestMiddleware
 }
 
 // newVersionMiddleware checks the request version against all valid versions.
-func newVersionMiddleware(validVersions []string) RequestMiddleware {
+func newVersionMiddleware(config *Configuration, validVersions []string) RequestMiddleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			requestVersion := mux.Vars(r)["version"]
+			requestVersion := requestVersion(r, config)
 
-			for _, v := range validVersions {
-				if requestVersion == v {
-					next.ServeHTTP(w, r)
-					return
-				}
+			if hasVersion(validVersions, requestVersion) {
+				next.ServeHTTP(w, r)
+				return
 			}
 
 			w.WriteHeader(http.StatusBadRequest)
@@ -235,32 +722,106 @@ func newVersionMiddleware(validVersions []string) RequestMiddleware {
 // muxAPI is an implementation of the API interface which relies on the gorilla/mux
 // package to handle request dispatching (see http://www.gorillatoolkit.org/pkg/mux).
 type muxAPI struct {
-	config             *Configuration
-	router             *mux.Router
-	mu                 sync.RWMutex
-	handler            *requestHandler
-	serializerRegistry map[string]ResponseSerializer
-	resourceHandlers   []ResourceHandler
+	config          *Configuration
+	routes          Router
+	entry           http.Handler
+	mu              sync.RWMutex
+	handler         *requestHandler
+	state           atomic.Value
+	routeInfos      []RouteInfo
+	disconnects     *disconnectMetrics
+	deprecations    *deprecationMetrics
+	operationStore  *operationStore
+	eventBroker     *eventBroker
+	secret          []byte
+	webhookRegistry *webhookRegistry
+	webhookClient   *http.Client
+	memoryQueue     WebhookQueue
+	dedup           *dedupStore
+	limiter         *concurrencyLimiter
+	breakers        *circuitBreakers
+	retryMetrics    *retryMetrics
+	tenants         *tenantMetrics
+	timings         *timingMetrics
+	quotaStore      QuotaStore
+	routesMu        sync.Mutex
+	bindings        []routeBinding
+	pendingRoutes   []routeBinding
+	compiled        int32
+	serversMu       sync.Mutex
+	servers         map[*http.Server]bool
+	closing         chan struct{}
+	lifecycleMu     sync.Mutex
+	initialized     []ResourceHandler
+	handlersStarted int32
 }
 
-// NewAPI returns a newly allocated API instance.
-func NewAPI(config *Configuration) API {
-	r := mux.NewRouter()
+// NewAPI returns a newly allocated API instance. The Options are applied to the
+// Configuration, which may be nil to configure the API with Options alone.
+func NewAPI(config *Configuration, opts ...Option) API {
+	o := applyOptions(config, opts)
+	config = o.config
+	routes := config.Router
+	if routes == nil {
+		routes = newDefaultRouter(config)
+	}
 	restAPI := &muxAPI{
-		config:             config,
-		router:             r,
-		serializerRegistry: map[string]ResponseSerializer{"json": &jsonSerializer{}},
-		resourceHandlers:   make([]ResourceHandler, 0),
+		config:          config,
+		routes:          routes,
+		disconnects:     newDisconnectMetrics(),
+		deprecations:    newDeprecationMetrics(),
+		operationStore:  newOperationStore(),
+		eventBroker:     newEventBroker(),
+		dedup:           newDedupStore(),
+		limiter:         newConcurrencyLimiter(),
+		breakers:        newCircuitBreakers(),
+		retryMetrics:    newRetryMetrics(),
+		tenants:         newTenantMetrics(),
+		timings:         newTimingMetrics(),
+		secret:          make([]byte, 32),
+		webhookRegistry: newWebhookRegistry(config.Webhooks),
+		webhookClient:   &http.Client{Timeout: webhookTimeout},
+		servers:         map[*http.Server]bool{},
+		closing:         make(chan struct{}),
+	}
+	if _, err := rand.Read(restAPI.secret); err != nil {
+		panic(fmt.Sprintf("Failed to generate confirmation secret: %s", err))
 	}
-	restAPI.handler = &requestHandler{restAPI, r}
+	if config.Quotas != nil {
+		restAPI.quotaStore = newQuotaStore(config.Quotas)
+	}
+	restAPI.state.Store(newAPIState())
+	restAPI.handler = &requestHandler{restAPI}
+	restAPI.memoryQueue = newMemoryWebhookQueue(config, restAPI.DeliverWebhook, restAPI.handler.logf)
+	restAPI.registerOperationsRoute()
+	if config.WebSocket {
+		restAPI.registerWebSocketRoute()
+	}
+	if config.GraphQL {
+		restAPI.registerGraphQLRoute()
+	}
+	if config.RouteDebug {
+		restAPI.registerRoutesRoute()
+	}
+	for format, serializer := range o.serializers {
+		restAPI.RegisterResponseSerializer(format, serializer)
+	}
+	middleware := o.middleware
+	if config.MethodOverride {
+		middleware = append([]Middleware{newMethodOverrideMiddleware()}, middleware...)
+	}
+	if config.MaxBodySize > 0 {
+		middleware = append([]Middleware{newBodyLimitMiddleware(config.MaxBodySize)}, middleware...)
+	}
+	restAPI.entry = wrapMiddleware(http.HandlerFunc(restAPI.dispatch), middleware...)
 	return restAPI
 }
 
 // Start begins serving requests. This will block unless it fails, in which case an error will be
 // returned.
 func (r *muxAPI) Start(addr Address, middleware ...Middleware) error {
-	r.preprocess()
-	return http.ListenAndServe(string(addr), wrapMiddleware(r.router, middleware...))
+	server := r.newServer(string(addr), middleware)
+	return r.serve(server, func() error { return server.ListenAndServe() })
 }
 
 // StartTLS begins serving requests received over HTTPS connections. This will block unless it
@@ -269,8 +830,108 @@ func (r *muxAPI) Start(addr Address, middleware ...Middleware) error {
 // authority, the certFile should be the concatenation of the server's certificate followed by
 // the CA's certificate.
 func (r *muxAPI) StartTLS(addr Address, certFile, keyFile FilePath, middleware ...Middleware) error {
+	server := r.newServer(string(addr), middleware)
+	server.TLSConfig = r.config.TLSConfig
+	return r.serve(server, func() error {
+		return server.ListenAndServeTLS(string(certFile), string(keyFile))
+	})
+}
+
+// Serve begins serving requests accepted on the provided Listener. This will block
+// unless it fails, in which case an error will be returned. The Listener is closed when
+// the API stops serving.
+func (r *muxAPI) Serve(listener net.Listener, middleware ...Middleware) error {
+	defer listener.Close()
+	server := r.newServer("", middleware)
+	return r.serve(server, func() error { return server.Serve(listener) })
+}
+
+// newServer returns an http.Server for the address serving the API with the Middleware
+// applied and the configured timeouts.
+func (r *muxAPI) newServer(addr string, middleware []Middleware) *http.Server {
+	return &http.Server{
+		Addr:         addr,
+		Handler:      wrapMiddleware(r.entry, middleware...),
+		ReadTimeout:  r.config.ReadTimeout,
+		WriteTimeout: r.config.WriteTimeout,
+		IdleTimeout:  r.config.IdleTimeout,
+	}
+}
+
+// serve runs the server with the provided function, which blocks until the server
+// fails or is shut down. Rules are validated, routes compiled, plugins started, and
+// ResourceHandlers initialized beforehand, and plugins are shut down afterwards.
+func (r *muxAPI) serve(server *http.Server, run func() error) error {
 	r.preprocess()
-	return http.ListenAndServeTLS(string(addr), string(certFile), string(keyFile), wrapMiddleware(r.router, middleware...))
+	r.compileRoutes()
+	if !r.trackServer(server) {
+		return http.ErrServerClosed
+	}
+	defer r.untrackServer(server)
+	if err := r.startupPlugins(); err != nil {
+		return err
+	}
+	defer r.shutdownPlugins()
+	if err := r.initHandlers(); err != nil {
+		return err
+	}
+	return run()
+}
+
+// trackServer adds the server to those shut down by Shutdown. It returns false if the
+// API has already been shut down.
+func (r *muxAPI) trackServer(server *http.Server) bool {
+	r.serversMu.Lock()
+	defer r.serversMu.Unlock()
+	select {
+	case <-r.closing:
+		return false
+	default:
+	}
+	r.servers[server] = true
+	return true
+}
+
+// untrackServer removes the server from those shut down by Shutdown.
+func (r *muxAPI) untrackServer(server *http.Server) {
+	r.serversMu.Lock()
+	defer r.serversMu.Unlock()
+	delete(r.servers, server)
+}
+
+// Shutdown gracefully shuts down the servers started by the API. It stops accepting
+// connections, ends event streams, and waits for in-flight requests to complete until
+// the Context is done, in which case its error is returned. ResourceHandlers which
+// implement CloseResourceHandler are then closed. Once the API is shut down, Start,
+// StartTLS, and Serve return http.ErrServerClosed.
+func (r *muxAPI) Shutdown(ctx context.Context) error {
+	r.serversMu.Lock()
+	select {
+	case <-r.closing:
+	default:
+		close(r.closing)
+	}
+	servers := make([]*http.Server, 0, len(r.servers))
+	for server := range r.servers {
+		servers = append(servers, server)
+	}
+	r.serversMu.Unlock()
+
+	var err error
+	for _, server := range servers {
+		if shutdownErr := server.Shutdown(ctx); shutdownErr != nil && err == nil {
+			err = shutdownErr
+		}
+	}
+	if closeErr := r.closeHandlers(ctx); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// shuttingDown returns a channel which is closed when the API is shut down.
+func (r *muxAPI) shuttingDown() <-chan struct{} {
+	return r.closing
 }
 
 // preprocess performs any necessary preprocessing before the server can be started, including
@@ -280,14 +941,40 @@ func (r *muxAPI) preprocess() {
 	if r.config.GenerateDocs {
 		if err := newDocGenerator().generateDocs(r); err != nil {
 			log.Printf("documentation could not be generated: %v", err)
+		} else if err := r.generatePluginDocs(r.config.DocsDirectory); err != nil {
+			log.Printf("documentation could not be generated: %v", err)
 		}
 	}
 }
 
-// Check the route for an error and log the error if it exists.
-func (r *muxAPI) checkRoute(handler, method, uri string, route *mux.Route) {
-	err := route.GetError()
+// layoutURI returns the URI laid out according to the Configuration's BasePath and
+// VersionlessPaths. Only URIs using the default /api/v{version}/ layout are changed.
+func (r *muxAPI) layoutURI(uri string) string {
+	prefix := fmt.Sprintf("/api/v{%s:[^/]+}", versionKey)
+	if !strings.HasPrefix(uri, prefix) {
+		return uri
+	}
+
+	base := r.apiBase()
+	if r.config.Tenancy != nil && r.config.Tenancy.Source == TenantPathPrefix {
+		base = fmt.Sprintf("/{%s:[^/]+}", tenantVar) + strings.TrimSuffix(base, "/")
+	}
+	if !r.config.VersionlessPaths {
+		base += fmt.Sprintf("/v{%s:[^/]+}", versionKey)
+	}
+	return base + uri[len(prefix):]
+}
 
+// apiBase returns the base path of resource endpoints, excluding the version.
+func (r *muxAPI) apiBase() string {
+	if r.config.BasePath != "" {
+		return strings.TrimSuffix(r.config.BasePath, "/")
+	}
+	return "/api"
+}
+
+// Check the error binding the route and log it if it exists.
+func (r *muxAPI) checkRoute(handler, method, uri string, err error) {
 	if err != nil {
 		log.Printf("Failed to setup route %s with %v", uri, err)
 	} else {
@@ -295,129 +982,308 @@ func (r *muxAPI) checkRoute(handler, method, uri string, route *mux.Route) {
 	}
 }
 
+// mountPrefix returns the configured MountPrefix without a trailing slash.
+func (r *muxAPI) mountPrefix() string {
+	return strings.TrimSuffix(r.config.MountPrefix, "/")
+}
+
+// bind binds the handler on the router to requests with the method, or any method if
+// it's empty, whose paths match the URI template under the MountPrefix. The route is
+// named, unless the name is empty, and recorded for Routes.
+func (r *muxAPI) bind(router Router, name, method, uri string, handler http.Handler) {
+	uri = r.mountPrefix() + uri
+	var methods []string
+	if method != "" {
+		methods = []string{method}
+	}
+	handler = r.handler.recoverPanics(handler)
+	if _, ok := router.(*GorillaRouter); !ok {
+		handler = withRouteVars(router, name, handler)
+	}
+	r.checkRoute(name, method, uri, router.Handle(name, uri, methods, handler))
+	r.recordRoute(router, name, methods, uri)
+}
+
+// recordRoute records the route bound on the router for Routes.
+func (r *muxAPI) recordRoute(router Router, name string, methods []string, uri string) {
+	info := RouteInfo{Path: uri, Methods: methods}
+	if parts := strings.SplitN(name, ":", 2); len(parts) == 2 {
+		info.Resource, info.Operation = parts[0], parts[1]
+	} else {
+		info.Operation = parts[0]
+	}
+	if g, ok := router.(*GorillaRouter); ok {
+		info.Versions = g.versions
+	}
+
+	r.mu.Lock()
+	r.routeInfos = append(r.routeInfos, info)
+	r.mu.Unlock()
+}
+
 // RegisterResourceHandler binds the provided ResourceHandler to the appropriate REST endpoints and
 // applies any specified middleware. Endpoints will have the following base URL:
-// /api/:version/resourceName.
+// /api/:version/resourceName. If the API is serving requests, the routes are bound to a
+// new Router which atomically replaces the active one.
 func (r *muxAPI) RegisterResourceHandler(h ResourceHandler, middleware ...RequestMiddleware) {
-	h = resourceHandlerProxy{h}
+	h = newResourceHandlerProxy(h)
+	if err := r.addResourceHandler(h); err != nil {
+		log.Printf("Failed to register %s: %s", h.ResourceName(), err)
+		return
+	}
+	middleware = r.resourceMiddleware(h, middleware)
+	r.addRoutes(h, func(routes Router) { r.bindResourceRoutes(routes, h, middleware) })
+}
+
+// resourceMiddleware returns the provided middleware along with the middleware the
+// framework applies to the ResourceHandler's endpoints.
+func (r *muxAPI) resourceMiddleware(h ResourceHandler,
+	middleware []RequestMiddleware) []RequestMiddleware {
+
 	resource := h.ResourceName()
+	if r.config.DedupWindow > 0 {
+		// Applied after authentication so unauthenticated requests aren't recorded.
+		middleware = append([]RequestMiddleware{newDedupMiddleware(r.config, r.dedup)},
+			middleware...)
+	}
+	if r.config.Cache != nil {
+		middleware = append([]RequestMiddleware{newCacheMiddleware(r.config, h, r.handler.logf)},
+			middleware...)
+	}
+	// Plugins are passed the user-provided handler unless it's a TypedResourceHandler,
+	// which isn't a ResourceHandler.
+	plugged, ok := unwrapResourceHandler(h).(ResourceHandler)
+	if !ok {
+		plugged = h
+	}
+	middleware = append(middleware, r.pluginMiddleware(plugged)...)
+	if r.config.CursorCodec != nil {
+		// Applied after authentication so unauthenticated requests are rejected first.
+		middleware = append(middleware, newCursorMiddleware(r.config.CursorCodec,
+			r.handler, resource))
+	}
+	if r.config.Quotas != nil && !isUsageResourceHandler(h) {
+		// Applied after authentication so requests are counted against the identity
+		// they authenticated as.
+		middleware = append(middleware, newQuotaMiddleware(r.config.Quotas, r.quotaStore,
+			r.handler, resource))
+	}
 	middleware = append(middleware, newAuthMiddleware(h.Authenticate))
 	if validVersions := h.ValidVersions(); validVersions != nil {
-		middleware = append(middleware, newVersionMiddleware(validVersions))
+		middleware = append(middleware, newVersionMiddleware(r.config, validVersions))
 	}
+	middleware = append(middleware, newDisconnectMiddleware(resource, r.disconnects))
+	if params := deprecatedParams(r.config, h); len(params) > 0 {
+		middleware = append(middleware, newDeprecatedParamsMiddleware(params))
+	}
+	middleware = append(middleware, newDeprecationMiddleware(r.config, h, r.deprecations))
+	if settings, ok := r.config.CircuitBreakers[resource]; ok {
+		middleware = append(middleware, newCircuitBreakerMiddleware(
+			r.breakers.get(resource, settings), r.handler))
+	}
+	return r.endpointMiddleware(resource, middleware)
+}
 
-	// Some browsers don't support PUT and DELETE, so allow method overriding.
-	// POST requests with X-HTTP-Method-Override=PUT/DELETE will route to the
-	// respective handlers.
+// endpointMiddleware returns the provided middleware along with the middleware the
+// framework applies to each request to the named endpoint, whether or not it's served
+// by a ResourceHandler, i.e. concurrency limits, tenancy, and timing.
+func (r *muxAPI) endpointMiddleware(name string,
+	middleware []RequestMiddleware) []RequestMiddleware {
+
+	if concurrencyLimited(r.config) {
+		// Applied before other middleware so excess requests are shed before doing any
+		// work.
+		middleware = append(middleware, newConcurrencyMiddleware(r.config, r.limiter,
+			r.handler, name))
+	}
+	if r.config.Tenancy != nil {
+		// Applied first so requests are limited per tenant and requests without a valid
+		// tenant aren't authenticated.
+		middleware = append(middleware, newTenantMiddleware(r.config.Tenancy, r.tenants,
+			r.handler, name))
+	}
+	// Applied before all other middleware so they and the endpoint can time phases.
+	middleware = append(middleware, newTimingMiddleware(r.timings, name))
+	return middleware
+}
 
-	route := r.router.Handle(
-		h.ReadListURI(), applyMiddleware(r.handler.handleReadList(h), middleware),
-	).Methods("POST").Headers("X-HTTP-Method-Override", "GET").Name(resource + ":readListOverride")
-	r.checkRoute("read list override", h.ReadListURI(), "OVERRIDE-GET", route)
+// bindResourceRoutes binds the provided ResourceHandler to its REST endpoints on the
+// router with the given middleware applied.
+func (r *muxAPI) bindResourceRoutes(router Router, h ResourceHandler,
+	middleware []RequestMiddleware) {
 
-	route = r.router.Handle(
-		h.ReadURI(), applyMiddleware(r.handler.handleRead(h), middleware),
-	).Methods("POST").Headers("X-HTTP-Method-Override", "GET").Name(resource + ":readOverride")
-	r.checkRoute("read override", h.ReadURI(), "OVERRIDE-GET", route)
+	resource := h.ResourceName()
 
-	route = r.router.Handle(
-		h.UpdateListURI(), applyMiddleware(r.handler.handleUpdateList(h), middleware),
-	).Methods("POST").Headers("X-HTTP-Method-Override", "PUT").Name(resource + ":updateListOverride")
-	r.checkRoute("update list override", h.UpdateListURI(), "OVERRIDE-PUT", route)
+	// Registered before the read endpoint so they aren't matched as a resource ID.
+	if eventsEnabled(h) {
+		r.registerEventsRoute(router, h, middleware)
+	}
+	if changeLog, ok := unwrapResourceHandler(h).(ChangeLog); ok {
+		r.registerChangesRoute(router, h, changeLog, middleware)
+	}
+	if searcher, ok := unwrapResourceHandler(h).(SearchResourceHandler); ok {
+		r.registerSearchRoute(router, h, searcher, middleware)
+	}
+
+	create := applyMiddleware(r.handler.handleCreate(h), middleware)
+	readList := applyMiddleware(r.handler.handleReadList(h), middleware)
+	read := applyMiddleware(r.handler.handleRead(h), middleware)
+	updateList := applyMiddleware(r.handler.handleUpdateList(h), middleware)
+	update := applyMiddleware(r.handler.handleUpdate(h), middleware)
+	patch := applyMiddleware(r.handler.handlePatch(h), middleware)
+	del := applyMiddleware(r.handler.handleDelete(h), middleware)
+
+	r.bind(router, resource+":"+string(HandleCreate), "POST", r.layoutURI(h.CreateURI()), create)
+	r.bind(router, resource+":"+string(HandleReadList), "GET", r.layoutURI(h.ReadListURI()), readList)
+	r.bind(router, resource+":"+string(HandleRead), "GET", r.layoutURI(h.ReadURI()), read)
+	r.bind(router, resource+":"+string(HandleUpdateList), "PUT", r.layoutURI(h.UpdateListURI()), updateList)
+	r.bind(router, resource+":"+string(HandleUpdate), "PUT", r.layoutURI(h.UpdateURI()), update)
+	r.bind(router, resource+":"+string(HandlePatch), "PATCH", r.layoutURI(h.UpdateURI()), patch)
+	r.bind(router, resource+":"+string(HandleDelete), "DELETE", r.layoutURI(h.DeleteURI()), del)
+	r.bind(router, resource+":"+string(HandleBatch), "POST", r.layoutURI(h.ReadListURI())+"/batch",
+		applyMiddleware(r.handler.handleBatch(h), middleware))
+
+	if _, ok := unwrapResourceHandler(h).(SnapshotResourceHandler); ok {
+		r.registerSnapshotRoutes(router, h, middleware)
+	}
+}
 
-	route = r.router.Handle(
-		h.UpdateURI(), applyMiddleware(r.handler.handleUpdate(h), middleware),
-	).Methods("POST").Headers("X-HTTP-Method-Override", "PUT").Name(resource + ":updateOverride")
-	r.checkRoute("update override", h.UpdateURI(), "OVERRIDE-PUT", route)
+// registerSnapshotRoutes binds the snapshot and restore admin endpoints for the
+// provided ResourceHandler, which must implement SnapshotResourceHandler, on the router.
+func (r *muxAPI) registerSnapshotRoutes(router Router, h ResourceHandler,
+	middleware []RequestMiddleware) {
 
-	route = r.router.Handle(
-		h.DeleteURI(), applyMiddleware(r.handler.handleDelete(h), middleware),
-	).Methods("POST").Headers("X-HTTP-Method-Override", "DELETE").Name(resource + ":deleteOverride")
-	r.checkRoute("delete override", h.DeleteURI(), "OVERRIDE-DELETE", route)
+	resource := h.ResourceName()
+	r.bind(router, resource+":"+string(HandleSnapshot), "POST", r.layoutURI(h.ReadURI())+"/snapshots",
+		applyMiddleware(r.handler.handleSnapshot(h), middleware))
+	r.bind(router, resource+":"+string(HandleRestore), "POST", r.layoutURI(h.ReadURI())+"/restore",
+		applyMiddleware(r.handler.handleRestore(h), middleware))
+}
 
-	// These return a Route which has a GetError command. Probably should check
-	// that and log it if it fails :)
-	r.router.Handle(
-		h.CreateURI(), applyMiddleware(r.handler.handleCreate(h), middleware),
-	).Methods("POST").Name(resource + ":" + string(HandleCreate))
-	r.checkRoute("create", h.CreateURI(), "POST", route)
+// registerEventsRoute binds the endpoint streaming the events of the provided
+// ResourceHandler, which must implement EventsResourceHandler, on the router.
+func (r *muxAPI) registerEventsRoute(router Router, h ResourceHandler,
+	middleware []RequestMiddleware) {
 
-	r.router.Handle(
-		h.ReadListURI(), applyMiddleware(r.handler.handleReadList(h), middleware),
-	).Methods("GET").Name(resource + ":" + string(HandleReadList))
-	r.checkRoute("read list", h.ReadListURI(), "GET", route)
+	r.bind(router, h.ResourceName()+":"+string(HandleEvents), "GET",
+		r.layoutURI(h.ReadListURI())+"/events", applyMiddleware(r.handler.handleEvents(h), middleware))
+}
 
-	r.router.Handle(
-		h.ReadURI(), applyMiddleware(r.handler.handleRead(h), middleware),
-	).Methods("GET").Name(resource + ":" + string(HandleRead))
-	r.checkRoute("read", h.ReadURI(), "GET", route)
+// registerChangesRoute binds the endpoint serving the changes recorded in the
+// ChangeLog of the provided ResourceHandler on the router.
+func (r *muxAPI) registerChangesRoute(router Router, h ResourceHandler, changeLog ChangeLog,
+	middleware []RequestMiddleware) {
 
-	r.router.Handle(
-		h.UpdateListURI(), applyMiddleware(r.handler.handleUpdateList(h), middleware),
-	).Methods("PUT").Name(resource + ":" + string(HandleUpdateList))
-	r.checkRoute("update list", h.UpdateListURI(), "PUT", route)
+	r.bind(router, h.ResourceName()+":"+string(HandleChanges), "GET",
+		r.layoutURI(h.ReadListURI())+"/changes",
+		applyMiddleware(r.handler.handleChanges(h, changeLog), middleware))
+}
 
-	r.router.Handle(
-		h.UpdateURI(), applyMiddleware(r.handler.handleUpdate(h), middleware),
-	).Methods("PUT").Name(resource + ":" + string(HandleUpdate))
-	r.checkRoute("update", h.UpdateURI(), "PUT", route)
+// registerSearchRoute binds the endpoint searching the resources of the provided
+// ResourceHandler on the router.
+func (r *muxAPI) registerSearchRoute(router Router, h ResourceHandler,
+	searcher SearchResourceHandler, middleware []RequestMiddleware) {
 
-	r.router.Handle(
-		h.DeleteURI(), applyMiddleware(r.handler.handleDelete(h), middleware),
-	).Methods("DELETE").Name(resource + ":" + string(HandleDelete))
-	r.checkRoute("delete", h.DeleteURI(), "DELETE", route)
+	r.bind(router, h.ResourceName()+":"+string(HandleSearch), "GET",
+		r.layoutURI(h.ReadListURI())+"/search",
+		applyMiddleware(r.handler.handleSearch(h, searcher), middleware))
+}
 
-	r.resourceHandlers = append(r.resourceHandlers, h)
+// registerOperationsRoute binds the endpoint serving the status of asynchronous
+// operations started by ResourceHandlers returning an AsyncResult.
+func (r *muxAPI) registerOperationsRoute() {
+	uri := r.layoutURI(fmt.Sprintf("/api/v{%s:[^/]+}/%s/{%s}", versionKey, operationsResource,
+		resourceIDKey))
+	r.addRoutes(nil, func(routes Router) {
+		r.bind(routes, operationsResource+":"+string(HandleRead), "GET", uri,
+			r.handler.handleReadOperation())
+	})
+}
+
+// registerWebSocketRoute binds the WebSocket endpoint used to subscribe to resource
+// events.
+func (r *muxAPI) registerWebSocketRoute() {
+	uri := r.layoutURI(fmt.Sprintf("/api/v{%s:[^/]+}/ws", versionKey))
+	r.addRoutes(nil, func(routes Router) {
+		r.bind(routes, string(HandleWebSocket), "GET", uri, r.handler.handleWebSocket())
+	})
 }
 
 // RegisterHandlerFunc binds the http.HandlerFunc to the provided URI and applies any
 // specified middleware.
 func (r *muxAPI) RegisterHandlerFunc(uri string, handlerfunc http.HandlerFunc,
 	middleware ...RequestMiddleware) {
-	r.router.Handle(uri, applyMiddleware(http.HandlerFunc(handlerfunc), middleware))
+	r.addRoutes(nil, func(routes Router) {
+		r.bind(routes, "", "", uri, applyMiddleware(http.HandlerFunc(handlerfunc), middleware))
+	})
 }
 
 // RegisterHandler binds the http.Handler to the provided URI and applies any specified
 // middleware.
 func (r *muxAPI) RegisterHandler(uri string, handler http.Handler, middleware ...RequestMiddleware) {
-	r.router.Handle(uri, applyMiddleware(handler, middleware))
+	r.addRoutes(nil, func(routes Router) {
+		r.bind(routes, "", "", uri, applyMiddleware(handler, middleware))
+	})
 }
 
 // RegisterPathPrefix binds the http.HandlerFunc to URIs matched by the given path
 // prefix and applies any specified middleware.
 func (r *muxAPI) RegisterPathPrefix(uri string, handler http.HandlerFunc,
 	middleware ...RequestMiddleware) {
-	r.router.PathPrefix(uri).Handler(applyMiddleware(handler, middleware))
+	r.addRoutes(nil, func(routes Router) {
+		uri := r.mountPrefix() + uri
+		var h http.Handler = applyMiddleware(handler, middleware)
+		if _, ok := routes.(*GorillaRouter); !ok {
+			h = withRouteVars(routes, "", h)
+		}
+		r.checkRoute("prefix", "", uri, routes.HandlePrefix(uri, h))
+		r.recordRoute(routes, "", nil, uri)
+	})
 }
 
-// ServeHTTP handles an HTTP request.
+// ServeHTTP handles an HTTP request. ResourceHandlers are initialized before the first
+// request if the API isn't serving already, and requests fail with 503 Service
+// Unavailable until they're initialized.
 func (r *muxAPI) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	r.router.ServeHTTP(w, req)
+	r.compileRoutes()
+	if err := r.initHandlers(); err != nil {
+		r.handler.logf("%s", err)
+		r.handler.sendResponse(r.handler.newContext(w, req).setError(
+			ServiceUnavailable("Resource handlers failed to initialize")))
+		return
+	}
+	r.entry.ServeHTTP(w, req)
+}
+
+// dispatch dispatches the request with the active Router.
+func (r *muxAPI) dispatch(w http.ResponseWriter, req *http.Request) {
+	r.activeRouter().ServeHTTP(w, req)
+}
+
+// Handler returns an http.Handler serving the API with the provided Middleware applied.
+func (r *muxAPI) Handler(middleware ...Middleware) http.Handler {
+	r.preprocess()
+	return wrapMiddleware(r, middleware...)
 }
 
 // RegisterResponseSerializer registers the provided ResponseSerializer with the given format. If the
 // format has already been registered, it will be overwritten.
 func (r *muxAPI) RegisterResponseSerializer(format string, serializer ResponseSerializer) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	r.serializerRegistry[format] = serializer
+	r.updateState(func(state *apiState) { state.serializers[format] = serializer })
 }
 
 // UnregisterResponseSerializer unregisters the ResponseSerializer with the provided format. If the
 // format hasn't been registered, this is a no-op.
 func (r *muxAPI) UnregisterResponseSerializer(format string) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	delete(r.serializerRegistry, format)
+	r.updateState(func(state *apiState) { delete(state.serializers, format) })
 }
 
 // AvailableFormats returns a slice containing all of the available serialization formats
 // currently available.
 func (r *muxAPI) AvailableFormats() []string {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	formats := make([]string, 0, len(r.serializerRegistry))
-	for format := range r.serializerRegistry {
+	serializers := r.loadState().serializers
+	formats := make([]string, 0, len(serializers))
+	for format := range serializers {
 		formats = append(formats, format)
 	}
 	sort.Strings(formats)
@@ -426,7 +1292,116 @@ func (r *muxAPI) AvailableFormats() []string {
 
 // ResourceHandlers returns a slice containing the registered ResourceHandlers.
 func (r *muxAPI) ResourceHandlers() []ResourceHandler {
-	return r.resourceHandlers
+	return append([]ResourceHandler(nil), r.loadState().handlers...)
+}
+
+// DisconnectStats returns the number of responses aborted mid-write because the client
+// disconnected, grouped by resource and response size bucket.
+func (r *muxAPI) DisconnectStats() []DisconnectStats {
+	return r.disconnects.snapshot()
+}
+
+// DeprecationStats returns the number of requests made for deprecated versions, grouped
+// by resource and version.
+func (r *muxAPI) DeprecationStats() []DeprecationStats {
+	return r.deprecations.snapshot()
+}
+
+// CircuitBreakerStats returns the state and request counts of the circuit breakers
+// attached to resources, ordered by resource.
+func (r *muxAPI) CircuitBreakerStats() []CircuitBreakerStats {
+	return r.breakers.snapshot()
+}
+
+// RetryStats returns the number of responses for retryable errors, e.g. those created
+// with RetryableError, grouped by resource and status.
+func (r *muxAPI) RetryStats() []RetryStats {
+	return r.retryMetrics.snapshot()
+}
+
+// TenantStats returns the number of requests made by each tenant to each resource when
+// Tenancy is configured.
+func (r *muxAPI) TenantStats() []TenantStats {
+	return r.tenants.snapshot()
+}
+
+// TimingStats returns the time spent authenticating, in ResourceHandlers, applying
+// Rules, and serializing responses, grouped by resource and phase.
+func (r *muxAPI) TimingStats() []TimingStats {
+	return r.timings.snapshot()
+}
+
+// RegisterWebhooksResource registers the webhooks resource, which is used to register,
+// list, and unregister webhooks at /api/:version/webhooks, and applies any specified
+// middleware. Middleware should be used to restrict access to it.
+func (r *muxAPI) RegisterWebhooksResource(middleware ...RequestMiddleware) {
+	r.RegisterResourceHandler(&webhookResourceHandler{registry: r.webhookRegistry}, middleware...)
+}
+
+// RegisterUsageResource registers the usage resource, which reports the quota usage of
+// the client making the request at /api/:version/usage, and applies any specified
+// middleware. Requests to it aren't counted against quotas. An error is returned if
+// Quotas aren't configured.
+func (r *muxAPI) RegisterUsageResource(middleware ...RequestMiddleware) error {
+	if r.config.Quotas == nil {
+		return errors.New("Quotas must be configured to register the usage resource")
+	}
+	r.RegisterResourceHandler(&usageResourceHandler{
+		quotas: r.config.Quotas,
+		store:  r.quotaStore,
+		logf:   r.handler.logf,
+	}, middleware...)
+	return nil
+}
+
+// DeliverWebhook posts the WebhookDelivery's notification to its webhook. It's used by
+// custom WebhookQueues to make deliveries and returns an error if the delivery failed.
+func (r *muxAPI) DeliverWebhook(delivery WebhookDelivery) error {
+	return deliverWebhook(r.webhookClient, r.config.WebhookSecret, delivery)
+}
+
+// webhooks returns the registry of webhooks notified of mutations.
+func (r *muxAPI) webhooks() *webhookRegistry {
+	return r.webhookRegistry
+}
+
+// webhookQueue returns the WebhookQueue used to deliver webhook notifications,
+// preferring the one set in the Configuration.
+func (r *muxAPI) webhookQueue() WebhookQueue {
+	if r.config.WebhookQueue != nil {
+		return r.config.WebhookQueue
+	}
+	return r.memoryQueue
+}
+
+// Publish sends the Event to clients of its Tenant streaming the named resource's events.
+// It's a no-op if there are no such clients.
+func (r *muxAPI) Publish(resource string, event Event) {
+	r.eventBroker.publish(resource, event)
+}
+
+// events returns the broker of events published with the API.
+func (r *muxAPI) events() *eventBroker {
+	return r.eventBroker
+}
+
+// retries returns the metrics of responses for retryable errors.
+func (r *muxAPI) retries() *retryMetrics {
+	return r.retryMetrics
+}
+
+// operations returns the store of asynchronous operations started by the API.
+func (r *muxAPI) operations() *operationStore {
+	return r.operationStore
+}
+
+// confirmationSecret returns the key used to sign confirmation tokens, preferring the
+// one set in the Configuration.
+func (r *muxAPI) confirmationSecret() []byte {
+	if len(r.config.ConfirmationSecret) > 0 {
+		return r.config.ConfirmationSecret
+	}
+	return r.secret
 }
 
 // Configuration returns the API Configuration.
@@ -434,11 +1409,12 @@ func (r *muxAPI) Configuration() *Configuration {
 	return r.config
 }
 
-// Validate will validate the Rules configured for this API. It returns nil if
-// all Rules are valid, otherwise returns the first encountered validation
+// Validate will validate the Rules and version ranges configured for this API. It
+// returns nil if all are valid, otherwise returns the first encountered validation
 // error.
 func (r *muxAPI) Validate() error {
-	for _, handler := range r.resourceHandlers {
+	handlers := r.ResourceHandlers()
+	for _, handler := range handlers {
 		rules := handler.Rules()
 		if rules == nil || rules.Size() == 0 {
 			continue
@@ -448,6 +1424,32 @@ func (r *muxAPI) Validate() error {
 			return err
 		}
 	}
+
+	for _, handler := range handlers {
+		if err := validateVersions(handler.ValidVersions()); err != nil {
+			return err
+		}
+	}
+	for _, versions := range r.loadState().versions {
+		if err := validateVersions(versions); err != nil {
+			return err
+		}
+	}
+
+	for _, handler := range handlers {
+		s, ok := unwrapResourceHandler(handler).(SchemaResourceHandler)
+		if !ok {
+			continue
+		}
+		for _, rules := range s.Schemas() {
+			if rules == nil || rules.Size() == 0 {
+				continue
+			}
+			if err := rules.Validate(); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
@@ -463,9 +1465,7 @@ func (r *muxAPI) validateRulesOrPanic() {
 // responseSerializer returns a ResponseSerializer for the given format type. If the format
 // is not implemented, the returned serializer will be nil and the error set.
 func (r *muxAPI) responseSerializer(format string) (ResponseSerializer, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	if serializer, ok := r.serializerRegistry[format]; ok {
+	if serializer, ok := r.loadState().serializers[format]; ok {
 		return serializer, nil
 	}
 	return nil, fmt.Errorf("Format not implemented: %s", format)
@@ -23,6 +23,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -128,10 +129,17 @@ func (m *MockResourceHandler) Rules() Rules {
 	return nil
 }
 
+// muxRouter returns the gorilla/mux Router of the API's active default Router.
+// This is purely for testing purposes and shouldn't be used elsewhere.
+func (r *muxAPI) muxRouter() *mux.Router {
+	return r.activeRouter().(*GorillaRouter).Mux()
+}
+
 // getRouteHandler returns the http.Handler for the API route with the given name.
 // This is purely for testing purposes and shouldn't be used elsewhere.
 func (r *muxAPI) getRouteHandler(name string) (http.Handler, error) {
-	route := r.router.Get(name)
+	r.compileRoutes()
+	route := r.muxRouter().Get(name)
 	if route == nil {
 		return nil, fmt.Errorf("No API route with name %s", name)
 	}
@@ -376,7 +384,7 @@ func TestHandleReadListHappyPath(t *testing.T) {
 	handler.Mock.AssertExpectations(t)
 	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
 	assert.Equal(
-		`{"messages":[],"next":"http://foo.com?next=cursor123","reason":"OK","results":[{"foo":"hello"}],"status":200}`,
+		`{"messages":[],"next":"http://foo.com/api/v0.1/foo?next=cursor123","reason":"OK","results":[{"foo":"hello"}],"status":200}`,
 		resp.Body.String(),
 		"Incorrect response string",
 	)
@@ -885,7 +893,7 @@ func TestOutboundRulesDontApplyOnError(t *testing.T) {
 }
 
 // Ensures that outbound rules are not applied if a nil resource is returned by
-// handler.
+// handler and that the read responds with 404.
 func TestOutboundRulesDontApplyOnNilResource(t *testing.T) {
 	assert := assert.New(t)
 	handler := new(MockResourceHandler)
@@ -912,7 +920,35 @@ func TestOutboundRulesDontApplyOnNilResource(t *testing.T) {
 
 	handler.Mock.AssertExpectations(t)
 	assert.Equal(
-		`{"messages":[],"reason":"OK","result":null,"status":200}`,
+		`{"messages":["No foo"],"reason":"Not Found","status":404}`,
+		resp.Body.String(),
+		"Incorrect response string",
+	)
+}
+
+// Ensures that ReadResource returning a nil resource without an error responds with
+// 404.
+func TestReadNilResourceNotFound(t *testing.T) {
+	assert := assert.New(t)
+	handler := new(MockResourceHandler)
+	api := NewAPI(&Configuration{})
+
+	handler.On("ResourceName").Return("foo")
+	handler.On("Authenticate").Return(nil)
+	handler.On("ValidVersions").Return(nil)
+	handler.On("Rules").Return(&rules{})
+	handler.On("ReadResource").Return(nil, nil)
+
+	api.RegisterResourceHandler(handler)
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/1", nil)
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusNotFound, resp.Code, "Incorrect response code")
+	assert.Equal(
+		`{"messages":["No foo 1"],"reason":"Not Found","status":404}`,
 		resp.Body.String(),
 		"Incorrect response string",
 	)
@@ -1150,3 +1186,127 @@ func TestVersionMiddleware(t *testing.T) {
 	assert.Equal(w.Code, http.StatusBadRequest)
 	assert.NotContains(w.Body.String(), "foo")
 }
+
+// Ensures that routes aren't compiled until the API first serves a request when
+// LazyRoutes is enabled, and that they're then matched in registration order.
+func TestLazyRoutes(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{LazyRoutes: true})
+	api.RegisterHandlerFunc("/api/v1/foo/special", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("special"))
+	})
+	api.RegisterResourceHandler(&headerResourceHandler{})
+
+	assert.Nil(api.(*muxAPI).muxRouter().Get("foo:read"))
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/special", nil)
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal("special", resp.Body.String())
+	assert.NotNil(api.(*muxAPI).muxRouter().Get("foo:read"))
+
+	req, _ = http.NewRequest("GET", "http://foo.com/api/v1/foo/1", nil)
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+}
+
+// Ensures that lazily compiled routes are correct for many resources and versions.
+func TestLazyRoutesSoak(t *testing.T) {
+	assert := assert.New(t)
+
+	report, err := Soak(SoakConfig{Resources: 100, Configuration: &Configuration{LazyRoutes: true}})
+
+	assert.NoError(err)
+	assert.Equal(600, report.Requests)
+}
+
+// Ensures that the API can be embedded in an http.ServeMux under a mount prefix, with
+// URLs built by the API including the prefix.
+func TestMountPrefix(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{MountPrefix: "/rest/"})
+	api.RegisterResourceHandler(&linkedResourceHandler{})
+
+	serveMux := http.NewServeMux()
+	serveMux.Handle("/rest/", api.Handler())
+	serveMux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/rest/api/v1/foo?limit=1", nil)
+	resp := httptest.NewRecorder()
+	serveMux.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal(
+		`{"messages":[],"next":"http://foo.com/rest/api/v1/foo?limit=1\u0026next=abc","reason":"OK","results":[{"id":"1"}],"status":200}`,
+		resp.Body.String(),
+		"Incorrect response string",
+	)
+
+	req, _ = http.NewRequest("GET", "http://foo.com/api/v1/foo", nil)
+	resp = httptest.NewRecorder()
+	serveMux.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusNotFound, resp.Code, "Incorrect response code")
+
+	req, _ = http.NewRequest("GET", "http://foo.com/health", nil)
+	resp = httptest.NewRecorder()
+	serveMux.ServeHTTP(resp, req)
+
+	assert.Equal("ok", resp.Body.String())
+}
+
+// Ensures that resource endpoints are laid out under the configured base path and that
+// URLs built by the API respect the layout.
+func TestBasePath(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{BasePath: "/internal/api/", Links: true})
+	api.RegisterResourceHandler(&linkedResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/internal/api/v2/foo?limit=1", nil)
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), `"next":"http://foo.com/internal/api/v2/foo?limit=1\u0026next=abc"`)
+	assert.Contains(resp.Body.String(), `"collection":"http://foo.com/internal/api/v2/foo"`)
+
+	req, _ = http.NewRequest("GET", "http://foo.com/api/v2/foo", nil)
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusNotFound, resp.Code, "Incorrect response code")
+}
+
+// Ensures that versions can be left out of endpoint paths, with requests served with
+// the default version, and that trailing slashes are redirected when configured.
+func TestVersionlessPaths(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{
+		BasePath:         "/",
+		VersionlessPaths: true,
+		DefaultVersion:   "3",
+		StrictSlash:      true,
+	})
+	api.RegisterResourceHandler(&gatewayResourceHandler{})
+
+	req, _ := http.NewRequest("POST", "http://foo.com/foo", bytes.NewBufferString(`{"foo": 1}`))
+	req.Header.Set("Authorization", "secret")
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusCreated, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), `"version":"3"`)
+
+	req, _ = http.NewRequest("GET", "http://foo.com/foo/1/", nil)
+	req.Header.Set("Authorization", "secret")
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusMovedPermanently, resp.Code, "Incorrect response code")
+	assert.Equal("http://foo.com/foo/1", resp.Header().Get("Location"))
+}
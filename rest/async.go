@@ -0,0 +1,304 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	gcontext "github.com/gorilla/context"
+)
+
+const (
+	// operationsResource is the name of the resource serving the status of asynchronous
+	// operations.
+	operationsResource = "operations"
+
+	// operationRetention is how long completed operations remain available.
+	operationRetention = time.Hour
+)
+
+// OperationStatus is the state of an asynchronous operation.
+type OperationStatus string
+
+// OperationStatus constants define the states of an asynchronous operation.
+const (
+	OperationPending   OperationStatus = "pending"
+	OperationRunning   OperationStatus = "running"
+	OperationSucceeded OperationStatus = "succeeded"
+	OperationFailed    OperationStatus = "failed"
+)
+
+// Operation describes the progress of an asynchronous operation. It's served by the
+// operations endpoint:
+//
+//	GET /api/:version/operations/{resource_id}
+//
+// Requests for an operation are authenticated by the ResourceHandler which started it,
+// and only the identity and tenant which started it may read it.
+type Operation struct {
+	ID       string          `json:"id"`
+	Resource string          `json:"resource"`
+	Status   OperationStatus `json:"status"`
+	Progress float64         `json:"progress"`
+	Result   Resource        `json:"result,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	Created  time.Time       `json:"created"`
+	Updated  time.Time       `json:"updated"`
+
+	// identity and tenant are the identity ID and tenant of the request which started
+	// the operation, if any.
+	identity string
+	tenant   string
+}
+
+// OperationReporter is used by an asynchronous operation to report its progress and
+// completion.
+type OperationReporter interface {
+	// Progress reports the fraction of the operation which is complete, from 0 to 1.
+	Progress(float64)
+
+	// Complete reports that the operation succeeded with the given resource.
+	Complete(Resource)
+
+	// Fail reports that the operation failed with the given error.
+	Fail(error)
+}
+
+// AsyncResult can be returned as the resource from CreateResource or UpdateResource to
+// perform a long-running operation asynchronously. The framework responds with 202
+// Accepted and a Location header pointing at the operation's status, then calls Run
+// in a new goroutine. Run must report completion or failure using the
+// OperationReporter. If Run returns without doing so, the operation succeeds without a
+// result.
+type AsyncResult struct {
+	// Run performs the operation, reporting its progress to the OperationReporter.
+	Run func(OperationReporter)
+}
+
+// operationStore holds the status of asynchronous operations. It's safe for concurrent
+// use.
+type operationStore struct {
+	mu         sync.RWMutex
+	operations map[string]*Operation
+}
+
+// newOperationStore returns a newly allocated operationStore.
+func newOperationStore() *operationStore {
+	return &operationStore{operations: map[string]*Operation{}}
+}
+
+// create adds a pending Operation for the resource, started by the identity and tenant,
+// and returns a copy of it. Completed operations past their retention are evicted.
+func (s *operationStore) create(resource, identity, tenant string) (Operation, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return Operation{}, err
+	}
+
+	now := time.Now().UTC()
+	op := &Operation{
+		ID:       hex.EncodeToString(id),
+		Resource: resource,
+		Status:   OperationPending,
+		Created:  now,
+		Updated:  now,
+		identity: identity,
+		tenant:   tenant,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, existing := range s.operations {
+		if existing.done() && now.Sub(existing.Updated) > operationRetention {
+			delete(s.operations, id)
+		}
+	}
+	s.operations[op.ID] = op
+	return *op, nil
+}
+
+// get returns a copy of the Operation with the given ID.
+func (s *operationStore) get(id string) (Operation, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	op, ok := s.operations[id]
+	if !ok {
+		return Operation{}, false
+	}
+	return *op, true
+}
+
+// update applies the function to the Operation with the given ID unless it's already
+// done.
+func (s *operationStore) update(id string, f func(*Operation)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if op, ok := s.operations[id]; ok && !op.done() {
+		f(op)
+		op.Updated = time.Now().UTC()
+	}
+}
+
+// done returns true if the Operation succeeded or failed.
+func (o *Operation) done() bool {
+	return o.Status == OperationSucceeded || o.Status == OperationFailed
+}
+
+// operationReporter is the OperationReporter for an Operation in an operationStore. It
+// applies outbound Rules to the result and redacts errors like those of responses.
+type operationReporter struct {
+	store   *operationStore
+	id      string
+	rules   Rules
+	version string
+	redact  func(error) error
+}
+
+// Progress reports the fraction of the operation which is complete, from 0 to 1.
+func (r *operationReporter) Progress(progress float64) {
+	r.store.update(r.id, func(op *Operation) {
+		op.Status = OperationRunning
+		op.Progress = progress
+	})
+}
+
+// Complete reports that the operation succeeded with the given resource.
+func (r *operationReporter) Complete(resource Resource) {
+	resource = applyOutboundRules(resource, r.rules, r.version)
+	r.store.update(r.id, func(op *Operation) {
+		op.Status = OperationSucceeded
+		op.Progress = 1
+		op.Result = resource
+	})
+}
+
+// Fail reports that the operation failed with the given error. If RedactErrors is
+// configured, server errors are logged and reported as a generic message with an error
+// ID.
+func (r *operationReporter) Fail(err error) {
+	message := r.redact(err).Error()
+	r.store.update(r.id, func(op *Operation) {
+		op.Status = OperationFailed
+		op.Error = message
+	})
+}
+
+// startOperation starts the AsyncResult in a new goroutine and sets the pending
+// Operation as the result on the RequestContext with a 202 status. The Location header
+// is set to the operation's status URL.
+func (h requestHandler) startOperation(ctx RequestContext, handler ResourceHandler,
+	async *AsyncResult) RequestContext {
+
+	identity := ""
+	if id := ctx.Identity(); id != nil {
+		identity = id.ID
+	}
+	store := h.operations()
+	op, err := store.create(handler.ResourceName(), identity, ctx.Tenant())
+	if err != nil {
+		return ctx.setError(InternalServerError(fmt.Sprintf("Failed to start operation: %s", err)))
+	}
+
+	reporter := &operationReporter{
+		store:   store,
+		id:      op.ID,
+		rules:   visibleRules(ctx, handler.Rules()),
+		version: ctx.Version(),
+		redact: func(err error) error {
+			return h.redactError(ctx, err)
+		},
+	}
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				reporter.Fail(fmt.Errorf("Operation panicked: %v", r))
+			}
+		}()
+		async.Run(reporter)
+		reporter.Complete(nil)
+	}()
+
+	if location, err := ctx.BuildURL(operationsResource, HandleRead,
+		RouteVars{resourceIDKey: op.ID}); err == nil {
+		ctx.ResponseWriter().Header().Set("Location", location.String())
+	}
+
+	ctx = ctx.setResult(op)
+	return ctx.setStatus(http.StatusAccepted)
+}
+
+// handleReadOperation returns a Handler which serves the status of the asynchronous
+// operation with the requested ID. Operations which the client may not read are
+// reported as not found.
+func (h requestHandler) handleReadOperation() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := h.newContext(w, r)
+		op, ok := h.operations().get(ctx.ResourceID())
+		var err error
+		if ok {
+			err = h.authorizeOperation(r, op)
+		} else {
+			err = ResourceNotFound(fmt.Sprintf("No operation %s", ctx.ResourceID()))
+		}
+		if err != nil {
+			ctx = ctx.setError(err)
+		} else {
+			ctx = ctx.setResult(op)
+		}
+		ctx = ctx.setStatus(http.StatusOK)
+
+		h.sendResponse(ctx)
+	})
+}
+
+// authorizeOperation returns an error if the request may not read the Operation. The
+// operations endpoint isn't bound with the middleware of the resource which started
+// the operation, so the request's tenant is resolved and it's authenticated by the
+// resource's ResourceHandler before its identity and tenant are checked against the
+// operation's.
+func (h requestHandler) authorizeOperation(r *http.Request, op Operation) error {
+	notFound := ResourceNotFound(fmt.Sprintf("No operation %s", op.ID))
+	if tenancy := h.Configuration().Tenancy; tenancy != nil {
+		tenant, err := resolveTenant(tenancy, r)
+		if err != nil {
+			return err
+		}
+		if tenant != op.tenant {
+			return notFound
+		}
+		gcontext.Set(r, tenantKey, tenant)
+	}
+
+	handler, ok := resourceHandlerNamed(h, op.Resource)
+	if !ok {
+		return notFound
+	}
+	if err := handler.Authenticate(r); err != nil {
+		return UnauthorizedRequest(err.Error())
+	}
+	if op.identity != "" {
+		if identity := requestIdentity(r); identity == nil || identity.ID != op.identity {
+			return notFound
+		}
+	}
+	return nil
+}
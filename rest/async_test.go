@@ -0,0 +1,272 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type asyncResourceHandler struct {
+	BaseResourceHandler
+	proceed chan bool
+	done    chan bool
+	err     error
+}
+
+func (a *asyncResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (a *asyncResourceHandler) CreateResource(ctx RequestContext, data Payload,
+	version string) (Resource, error) {
+
+	return &AsyncResult{Run: func(reporter OperationReporter) {
+		defer close(a.done)
+		reporter.Progress(0.5)
+		a.done <- true
+		<-a.proceed
+		if a.err != nil {
+			reporter.Fail(a.err)
+			return
+		}
+		reporter.Complete(&TestResource{Foo: "hello"})
+	}}, nil
+}
+
+// readOperation returns the Operation served at the given URL.
+func readOperation(api API, url string) (int, Operation) {
+	req, _ := http.NewRequest("GET", url, nil)
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	var body struct {
+		Result Operation `json:"result"`
+	}
+	json.Unmarshal(resp.Body.Bytes(), &body)
+	return resp.Code, body.Result
+}
+
+// Ensures that creates returning an AsyncResult respond with 202 and a Location
+// serving the operation's progress and result.
+func TestHandleCreateAsync(t *testing.T) {
+	assert := assert.New(t)
+	handler := &asyncResourceHandler{proceed: make(chan bool), done: make(chan bool)}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo", bytes.NewBufferString("{}"))
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusAccepted, resp.Code, "Incorrect response code")
+	location := resp.Header().Get("Location")
+	assert.True(strings.HasPrefix(location, "http://foo.com/api/v1/operations/"), location)
+
+	<-handler.done
+	code, op := readOperation(api, location)
+	assert.Equal(http.StatusOK, code)
+	assert.Equal("foo", op.Resource)
+	assert.Equal(OperationRunning, op.Status)
+	assert.Equal(0.5, op.Progress)
+
+	handler.proceed <- true
+	<-handler.done
+	_, op = readOperation(api, location)
+	assert.Equal(OperationSucceeded, op.Status)
+	assert.Equal(map[string]interface{}{"foo": "hello"}, op.Result)
+}
+
+// Ensures that failed asynchronous operations report their error.
+func TestHandleCreateAsyncFailed(t *testing.T) {
+	assert := assert.New(t)
+	handler := &asyncResourceHandler{
+		proceed: make(chan bool), done: make(chan bool), err: errors.New("boom"),
+	}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo", bytes.NewBufferString("{}"))
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	<-handler.done
+	handler.proceed <- true
+	<-handler.done
+	_, op := readOperation(api, resp.Header().Get("Location"))
+	assert.Equal(OperationFailed, op.Status)
+	assert.Equal("boom", op.Error)
+}
+
+// Ensures that the errors of failed asynchronous operations are redacted when
+// RedactErrors is configured, while client errors are reported as is.
+func TestHandleCreateAsyncFailedRedacted(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{RedactErrors: true})
+	handler := &asyncResourceHandler{}
+	api.RegisterResourceHandler(handler)
+
+	for _, err := range []error{
+		errors.New("dial tcp 10.0.0.5:5432: connection refused"),
+		UnprocessableRequest("Foo is locked"),
+	} {
+		handler.proceed, handler.done, handler.err = make(chan bool), make(chan bool), err
+		req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo",
+			bytes.NewBufferString("{}"))
+		resp := httptest.NewRecorder()
+		api.ServeHTTP(resp, req)
+
+		<-handler.done
+		handler.proceed <- true
+		<-handler.done
+		_, op := readOperation(api, resp.Header().Get("Location"))
+		assert.Equal(OperationFailed, op.Status)
+		if _, ok := err.(Error); ok {
+			assert.Equal("Foo is locked", op.Error)
+		} else {
+			assert.NotContains(op.Error, "10.0.0.5")
+			assert.Contains(op.Error, "Internal error (error ID ")
+		}
+	}
+}
+
+// Ensures that reading an unknown operation returns a 404.
+func TestHandleReadOperationNotFound(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+
+	code, _ := readOperation(api, "http://foo.com/api/v1/operations/missing")
+
+	assert.Equal(http.StatusNotFound, code)
+}
+
+type unauthorizedAsyncResourceHandler struct {
+	asyncResourceHandler
+	authErr error
+}
+
+func (u *unauthorizedAsyncResourceHandler) Authenticate(r *http.Request) error {
+	return u.authErr
+}
+
+// Ensures that reading an operation is authenticated by the ResourceHandler which
+// started it.
+func TestHandleReadOperationUnauthenticated(t *testing.T) {
+	assert := assert.New(t)
+	handler := &unauthorizedAsyncResourceHandler{asyncResourceHandler: asyncResourceHandler{
+		proceed: make(chan bool), done: make(chan bool),
+	}}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo", bytes.NewBufferString("{}"))
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	<-handler.done
+	location := resp.Header().Get("Location")
+
+	handler.authErr = errors.New("Not authorized")
+	code, _ := readOperation(api, location)
+	assert.Equal(http.StatusUnauthorized, code)
+
+	handler.proceed <- true
+	<-handler.done
+}
+
+type identifiedAsyncResourceHandler struct {
+	asyncResourceHandler
+}
+
+func (i *identifiedAsyncResourceHandler) Authenticate(r *http.Request) error {
+	user := r.Header.Get("X-User")
+	if user == "" {
+		return errors.New("Missing user")
+	}
+	SetIdentity(r, &Identity{ID: user})
+	return nil
+}
+
+// Ensures that operations can only be read by the identity which started them.
+func TestHandleReadOperationIdentity(t *testing.T) {
+	assert := assert.New(t)
+	handler := &identifiedAsyncResourceHandler{asyncResourceHandler{
+		proceed: make(chan bool), done: make(chan bool),
+	}}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo", bytes.NewBufferString("{}"))
+	req.Header.Set("X-User", "alice")
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	<-handler.done
+	location := resp.Header().Get("Location")
+
+	read := func(user string) int {
+		req, _ := http.NewRequest("GET", location, nil)
+		if user != "" {
+			req.Header.Set("X-User", user)
+		}
+		resp := httptest.NewRecorder()
+		api.ServeHTTP(resp, req)
+		return resp.Code
+	}
+	assert.Equal(http.StatusOK, read("alice"))
+	assert.Equal(http.StatusNotFound, read("bob"))
+	assert.Equal(http.StatusUnauthorized, read(""))
+
+	handler.proceed <- true
+	<-handler.done
+}
+
+// Ensures that operations can only be read by the tenant which started them.
+func TestHandleReadOperationTenant(t *testing.T) {
+	assert := assert.New(t)
+	handler := &asyncResourceHandler{proceed: make(chan bool), done: make(chan bool)}
+	api := NewAPI(&Configuration{Tenancy: &Tenancy{Source: TenantHeader}})
+	api.RegisterResourceHandler(handler)
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo", bytes.NewBufferString("{}"))
+	req.Header.Set("X-Tenant-ID", "acme")
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	<-handler.done
+	location := resp.Header().Get("Location")
+
+	read := func(tenant string) int {
+		req, _ := http.NewRequest("GET", location, nil)
+		if tenant != "" {
+			req.Header.Set("X-Tenant-ID", tenant)
+		}
+		resp := httptest.NewRecorder()
+		api.ServeHTTP(resp, req)
+		return resp.Code
+	}
+	assert.Equal(http.StatusOK, read("acme"))
+	assert.Equal(http.StatusNotFound, read("other"))
+	assert.Equal(http.StatusBadRequest, read(""))
+
+	handler.proceed <- true
+	<-handler.done
+}
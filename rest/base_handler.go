@@ -19,6 +19,7 @@ package rest
 import (
 	"fmt"
 	"net/http"
+	"sync"
 )
 
 // BaseResourceHandler is a base implementation of ResourceHandler with stubs for the
@@ -154,6 +155,37 @@ func (b BaseResourceHandler) Rules() Rules {
 // as REST URIs.
 type resourceHandlerProxy struct {
 	ResourceHandler
+	rules *proxiedRules
+}
+
+// proxiedRules holds the compiled Rules of a proxied ResourceHandler.
+type proxiedRules struct {
+	once  sync.Once
+	rules Rules
+}
+
+// newResourceHandlerProxy returns a resourceHandlerProxy for the ResourceHandler which
+// compiles its Rules once, so they're applied without per-request reflection lookups.
+func newResourceHandlerProxy(handler ResourceHandler) resourceHandlerProxy {
+	return resourceHandlerProxy{ResourceHandler: handler, rules: &proxiedRules{}}
+}
+
+// unwrapResourceHandler returns the ResourceHandler proxied by a resourceHandlerProxy
+// or the given handler if it isn't proxied. Handlers registered from a ResourceConfig
+// are unwrapped too, as are handlers wrapped with Typed, which are unwrapped to their
+// TypedResourceHandler. This allows optional interfaces implemented by the
+// user-provided handler to be detected.
+func unwrapResourceHandler(handler ResourceHandler) interface{} {
+	if proxy, ok := handler.(resourceHandlerProxy); ok {
+		handler = proxy.ResourceHandler
+	}
+	if declared, ok := handler.(*declaredResourceHandler); ok {
+		handler = declared.ResourceHandler
+	}
+	if typed, ok := handler.(typedAdapter); ok {
+		return typed.typedHandler()
+	}
+	return handler
 }
 
 // ResourceName returns the wrapped ResourceHandler's resource name. If the proxied
@@ -228,3 +260,14 @@ func (r resourceHandlerProxy) DeleteURI() string {
 	}
 	return uri
 }
+
+// Rules returns the proxied ResourceHandler's Rules. If the proxy was created with
+// newResourceHandlerProxy, they're compiled the first time they're requested and the
+// compiled Rules are returned from then on.
+func (r resourceHandlerProxy) Rules() Rules {
+	if r.rules == nil {
+		return r.ResourceHandler.Rules()
+	}
+	r.rules.once.Do(func() { r.rules.rules = compileRules(r.ResourceHandler.Rules()) })
+	return r.rules.rules
+}
@@ -33,7 +33,7 @@ func (t TestDefaultHandler) ResourceName() string {
 // Ensures that CreateURI falls back to the correct default.
 func TestCreateURIDefault(t *testing.T) {
 	assert := assert.New(t)
-	proxy := resourceHandlerProxy{TestDefaultHandler{}}
+	proxy := resourceHandlerProxy{ResourceHandler: TestDefaultHandler{}}
 
 	assert.Equal("/api/v{version:[^/]+}/foo", proxy.CreateURI())
 }
@@ -41,7 +41,7 @@ func TestCreateURIDefault(t *testing.T) {
 // Ensures that ReadURI falls back to the correct default.
 func TestReadURIDefault(t *testing.T) {
 	assert := assert.New(t)
-	proxy := resourceHandlerProxy{TestDefaultHandler{}}
+	proxy := resourceHandlerProxy{ResourceHandler: TestDefaultHandler{}}
 
 	assert.Equal("/api/v{version:[^/]+}/foo/{resource_id}", proxy.ReadURI())
 }
@@ -49,7 +49,7 @@ func TestReadURIDefault(t *testing.T) {
 // Ensures that ReadListURI falls back to the correct default.
 func TestReadListURIDefault(t *testing.T) {
 	assert := assert.New(t)
-	proxy := resourceHandlerProxy{TestDefaultHandler{}}
+	proxy := resourceHandlerProxy{ResourceHandler: TestDefaultHandler{}}
 
 	assert.Equal("/api/v{version:[^/]+}/foo", proxy.ReadListURI())
 }
@@ -57,7 +57,7 @@ func TestReadListURIDefault(t *testing.T) {
 // Ensures that UpdateURI falls back to the correct default.
 func TestUpdateURIDefault(t *testing.T) {
 	assert := assert.New(t)
-	proxy := resourceHandlerProxy{TestDefaultHandler{}}
+	proxy := resourceHandlerProxy{ResourceHandler: TestDefaultHandler{}}
 
 	assert.Equal("/api/v{version:[^/]+}/foo/{resource_id}", proxy.UpdateURI())
 }
@@ -65,7 +65,7 @@ func TestUpdateURIDefault(t *testing.T) {
 // Ensures that DeleteURI falls back to the correct default.
 func TestDeleteURIDefault(t *testing.T) {
 	assert := assert.New(t)
-	proxy := resourceHandlerProxy{TestDefaultHandler{}}
+	proxy := resourceHandlerProxy{ResourceHandler: TestDefaultHandler{}}
 
 	assert.Equal("/api/v{version:[^/]+}/foo/{resource_id}", proxy.DeleteURI())
 }
@@ -101,7 +101,7 @@ func (t TestHandler) DeleteURI() string {
 // Ensures that CreateURI returns the custom URI.
 func TestCreateURICustom(t *testing.T) {
 	assert := assert.New(t)
-	proxy := resourceHandlerProxy{TestHandler{}}
+	proxy := resourceHandlerProxy{ResourceHandler: TestHandler{}}
 
 	assert.Equal("/api/{version}/create_foo", proxy.CreateURI())
 }
@@ -109,7 +109,7 @@ func TestCreateURICustom(t *testing.T) {
 // Ensures that ReadURI returns the custom URI.
 func TestReadURICustom(t *testing.T) {
 	assert := assert.New(t)
-	proxy := resourceHandlerProxy{TestHandler{}}
+	proxy := resourceHandlerProxy{ResourceHandler: TestHandler{}}
 
 	assert.Equal("/api/{version}/read_foo/{resource_id}", proxy.ReadURI())
 }
@@ -117,7 +117,7 @@ func TestReadURICustom(t *testing.T) {
 // Ensures that ReadListURI returns the custom URI.
 func TestReadListURICustom(t *testing.T) {
 	assert := assert.New(t)
-	proxy := resourceHandlerProxy{TestHandler{}}
+	proxy := resourceHandlerProxy{ResourceHandler: TestHandler{}}
 
 	assert.Equal("/api/{version}/read_foo", proxy.ReadListURI())
 }
@@ -125,7 +125,7 @@ func TestReadListURICustom(t *testing.T) {
 // Ensures that UpdateURI returns the custom URI.
 func TestUpdateURICustom(t *testing.T) {
 	assert := assert.New(t)
-	proxy := resourceHandlerProxy{TestHandler{}}
+	proxy := resourceHandlerProxy{ResourceHandler: TestHandler{}}
 
 	assert.Equal("/api/{version}/update_foo/{resource_id}", proxy.UpdateURI())
 }
@@ -133,7 +133,7 @@ func TestUpdateURICustom(t *testing.T) {
 // Ensures that DeleteURI returns the custom URI.
 func TestDeleteURICustom(t *testing.T) {
 	assert := assert.New(t)
-	proxy := resourceHandlerProxy{TestHandler{}}
+	proxy := resourceHandlerProxy{ResourceHandler: TestHandler{}}
 
 	assert.Equal("/api/{version}/delete_foo/{resource_id}", proxy.DeleteURI())
 }
@@ -0,0 +1,274 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+)
+
+const (
+	// batchMethodKey is the name of the batch operation field specifying the method.
+	batchMethodKey = "method"
+
+	// batchIDKey is the name of the batch operation field specifying the resource ID.
+	batchIDKey = "id"
+
+	// batchDataKey is the name of the batch operation field containing the payload.
+	batchDataKey = "data"
+)
+
+// BatchOperation is a single operation in a batch request.
+type BatchOperation struct {
+	// Method is the operation to perform: HandleCreate, HandleRead, HandleUpdate, or
+	// HandleDelete.
+	Method HandleMethod
+
+	// ID is the ID of the resource to operate on. It's empty for creates.
+	ID string
+
+	// Data is the operation payload with inbound Rules applied. It's empty for reads
+	// and deletes.
+	Data Payload
+}
+
+// BatchResult is the outcome of a single BatchOperation.
+type BatchResult struct {
+	// Resource is the resource resulting from the operation, if any.
+	Resource Resource
+
+	// Status is the HTTP status code of the operation. If zero, the status is derived
+	// from Err or the operation method.
+	Status int
+
+	// Err is the error (or nil) of the operation.
+	Err error
+}
+
+// BatchResourceHandler can be implemented by a ResourceHandler to perform batch
+// operations in bulk, e.g. in a single database transaction. ResourceHandlers which
+// don't implement it have batch operations dispatched to their CRUD methods one at a
+// time.
+type BatchResourceHandler interface {
+	// BatchResources performs the given operations. It returns a BatchResult for each
+	// BatchOperation, in the same order, or an error (or nil) if the batch failed
+	// entirely.
+	BatchResources(RequestContext, []BatchOperation, string) ([]BatchResult, error)
+}
+
+// handleBatch returns a Handler which will deserialize the batch operations in the
+// request payload, dispatch them to the handler, and then serialize and dispatch a
+// multi-status response containing the status and result of each operation. The
+// payload is an array of operations of the form
+//
+//	{"method": "update", "id": "1", "data": {...}}
+func (h requestHandler) handleBatch(handler ResourceHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := h.newContext(w, r)
+		version := ctx.Version()
+
+		if err := checkDryRun(ctx, handler); err != nil {
+			h.sendResponse(ctx.setError(err))
+			return
+		}
+
+		inbound, err := inboundRules(ctx, handler)
+		if err != nil {
+			h.sendResponse(ctx.setError(err))
+			return
+		}
+
+		body, err := h.requestBody(ctx)
+		if err != nil {
+			h.sendResponse(ctx.setError(err))
+			return
+		}
+
+		data, err := decodePayloadSlice(body)
+		if err != nil {
+			// Payload decoding failed.
+			h.sendResponse(ctx.setError(BadRequest(err.Error())))
+			return
+		}
+
+		limits := resolveListLimits(h.Configuration(), handler)
+		if limits.maxLimit > 0 && len(data) > limits.maxLimit {
+			h.sendResponse(ctx.setError(BadRequest(fmt.Sprintf(
+				"Number of operations exceeds maximum of %d", limits.maxLimit))))
+			return
+		}
+
+		operations, err := decodeBatchOperations(ctx, data,
+			payloadMiddleware(h.Configuration(), handler), payloadJSONSchema(ctx, handler),
+			inbound, version)
+		if err == nil {
+			err = checkBatchOperations(handler, operations)
+		}
+		if err != nil {
+			h.sendResponse(ctx.setError(err))
+			return
+		}
+
+		var batchResults []BatchResult
+		if bulk, ok := unwrapResourceHandler(handler).(BatchResourceHandler); ok {
+			if batchResults, err = bulk.BatchResources(ctx, operations, version); err != nil {
+				h.sendResponse(ctx.setError(err))
+				return
+			}
+		} else {
+			batchResults = make([]BatchResult, len(operations))
+			for i, op := range operations {
+				batchResults[i] = dispatchBatchOperation(ctx, handler, op, version)
+			}
+		}
+
+		rules := visibleRules(ctx, handler.Rules())
+		results := make([]Payload, len(operations))
+		for i, op := range operations {
+			batchResult := BatchResult{Err: InternalServerError("Missing batch result")}
+			if i < len(batchResults) {
+				batchResult = batchResults[i]
+			}
+			redacted := batchResult
+			redacted.Err = h.redactError(ctx, batchResult.Err)
+			results[i] = batchResultPayload(op, redacted, rules, version)
+			if batchResult.Err == nil && op.Method != HandleRead {
+				h.notifyWebhooks(ctx, handler, op.Method, op.ID, batchResult.Resource)
+			}
+		}
+
+		ctx = ctx.setResult(results)
+		ctx = ctx.setStatus(http.StatusMultiStatus)
+
+		h.sendResponse(ctx)
+	})
+}
+
+// decodeBatchOperations returns the BatchOperations described by the payloads with
+// PayloadMiddleware and inbound Rules applied to their data, which is validated against
+// the JSONSchema if there is one. An error is returned if any of the operations are
+// invalid.
+func decodeBatchOperations(ctx RequestContext, data []Payload, middleware []PayloadMiddleware,
+	schema *JSONSchema, rules Rules, version string) ([]BatchOperation, error) {
+
+	operations := make([]BatchOperation, len(data))
+	for i, p := range data {
+		method, err := p.GetString(batchMethodKey)
+		if err != nil {
+			return nil, BadRequest(fmt.Sprintf("Operation %d: missing method", i))
+		}
+
+		op := BatchOperation{Method: HandleMethod(method), Data: Payload{}}
+		switch op.Method {
+		case HandleCreate, HandleRead, HandleUpdate, HandleDelete:
+		default:
+			return nil, BadRequest(fmt.Sprintf("Operation %d: invalid method %q", i, method))
+		}
+
+		if op.Method != HandleCreate {
+			if op.ID, err = p.GetString(batchIDKey); err != nil || op.ID == "" {
+				return nil, BadRequest(fmt.Sprintf("Operation %d: missing id", i))
+			}
+		}
+
+		if op.Method == HandleCreate || op.Method == HandleUpdate {
+			opData, err := p.GetMap(batchDataKey)
+			if err != nil {
+				return nil, BadRequest(fmt.Sprintf("Operation %d: missing data", i))
+			}
+			if op.Data, err = applyPayloadMiddleware(ctx, middleware, Payload(opData)); err != nil {
+				return nil, err
+			}
+			if err := validatePayload(schema, op.Data); err != nil {
+				return nil, UnprocessableRequest(fmt.Sprintf("Operation %d: %s", i, err))
+			}
+			if op.Data, err = applyInboundRules(op.Data, rules, version); err != nil {
+				// Type coercion failed.
+				return nil, UnprocessableRequest(fmt.Sprintf("Operation %d: %s", i, err))
+			}
+		}
+
+		operations[i] = op
+	}
+	return operations, nil
+}
+
+// checkBatchOperations returns a 405 error if any of the BatchOperations isn't enabled
+// for the handler.
+func checkBatchOperations(handler ResourceHandler, operations []BatchOperation) error {
+	for i, op := range operations {
+		if !operationEnabled(handler, op.Method) {
+			return MethodNotAllowed(fmt.Sprintf("Operation %d: %s is disabled for %s", i,
+				op.Method, handler.ResourceName()))
+		}
+	}
+	return nil
+}
+
+// dispatchBatchOperation performs the BatchOperation using the handler's CRUD methods.
+func dispatchBatchOperation(ctx RequestContext, handler ResourceHandler, op BatchOperation,
+	version string) BatchResult {
+
+	var resource Resource
+	var err error
+	switch op.Method {
+	case HandleCreate:
+		resource, err = createResource(ctx, handler, op.Data, version)
+	case HandleRead:
+		resource, err = readResource(ctx, handler, op.ID, version)
+	case HandleUpdate:
+		resource, err = updateResource(ctx, handler, op.ID, op.Data, version)
+	case HandleDelete:
+		resource, err = deleteResource(ctx, handler, op.ID, version)
+	}
+	return BatchResult{Resource: resource, Err: err}
+}
+
+// batchResultPayload returns the Payload describing the outcome of a BatchOperation,
+// applying outbound Rules to its resource.
+func batchResultPayload(op BatchOperation, r BatchResult, rules Rules, version string) Payload {
+	s := r.Status
+	if s == 0 {
+		s = batchStatus(op, r)
+	}
+
+	payload := Payload{status: s, reason: http.StatusText(s)}
+	if op.ID != "" {
+		payload[batchIDKey] = op.ID
+	}
+
+	if r.Err != nil {
+		payload[reason] = r.Err.Error()
+	} else if r.Resource != nil {
+		payload[result] = applyOutboundRules(r.Resource, rules, version)
+	}
+	return payload
+}
+
+// batchStatus returns the default HTTP status code for the BatchResult.
+func batchStatus(op BatchOperation, r BatchResult) int {
+	if r.Err != nil {
+		return errorStatus(r.Err)
+	}
+	if op.Method == HandleCreate {
+		if r.Resource == nil {
+			return http.StatusNoContent
+		}
+		return http.StatusCreated
+	}
+	return http.StatusOK
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type batchResourceHandler struct {
+	BaseResourceHandler
+}
+
+func (b *batchResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (b *batchResourceHandler) CreateResource(ctx RequestContext, data Payload,
+	version string) (Resource, error) {
+
+	foo, _ := data.GetString("foo")
+	return &TestResource{Foo: foo}, nil
+}
+
+func (b *batchResourceHandler) UpdateResource(ctx RequestContext, id string, data Payload,
+	version string) (Resource, error) {
+
+	if id != "1" {
+		return nil, ResourceNotFound("No foo " + id)
+	}
+	foo, _ := data.GetString("foo")
+	return &TestResource{Foo: foo}, nil
+}
+
+type bulkResourceHandler struct {
+	batchResourceHandler
+	operations []BatchOperation
+}
+
+func (b *bulkResourceHandler) BatchResources(ctx RequestContext, operations []BatchOperation,
+	version string) ([]BatchResult, error) {
+
+	b.operations = operations
+	return []BatchResult{{Status: http.StatusAccepted}}, nil
+}
+
+// Ensures that batch operations are dispatched to the handler's CRUD methods and a
+// multi-status response with per-operation results is returned.
+func TestHandleBatch(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&batchResourceHandler{})
+	batchHandler, _ := api.(*muxAPI).getRouteHandler("foo:batch")
+
+	body := `[{"method": "create", "data": {"foo": "a"}},` +
+		`{"method": "update", "id": "2", "data": {"foo": "b"}},` +
+		`{"method": "delete", "id": "1"}]`
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo/batch", bytes.NewBufferString(body))
+	resp := httptest.NewRecorder()
+
+	batchHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusMultiStatus, resp.Code, "Incorrect response code")
+	assert.Equal(
+		`{"messages":[],"reason":"Multi-Status","results":[`+
+			`{"reason":"Created","result":{"foo":"a"},"status":201},`+
+			`{"id":"2","reason":"No foo 2","status":404},`+
+			`{"id":"1","reason":"DeleteResource not implemented","status":405}],"status":207}`,
+		resp.Body.String(),
+	)
+}
+
+// Ensures that batch operations are passed to a BatchResourceHandler in bulk.
+func TestHandleBatchBulk(t *testing.T) {
+	assert := assert.New(t)
+	handler := &bulkResourceHandler{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+	batchHandler, _ := api.(*muxAPI).getRouteHandler("foo:batch")
+
+	body := `[{"method": "read", "id": "1"}]`
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo/batch", bytes.NewBufferString(body))
+	resp := httptest.NewRecorder()
+
+	batchHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusMultiStatus, resp.Code, "Incorrect response code")
+	assert.Equal([]BatchOperation{{Method: HandleRead, ID: "1", Data: Payload{}}}, handler.operations)
+	assert.Equal(
+		`{"messages":[],"reason":"Multi-Status","results":[`+
+			`{"id":"1","reason":"Accepted","status":202}],"status":207}`,
+		resp.Body.String(),
+	)
+}
+
+// Ensures that batch requests containing invalid operations are rejected.
+func TestHandleBatchInvalidOperation(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&batchResourceHandler{})
+	batchHandler, _ := api.(*muxAPI).getRouteHandler("foo:batch")
+
+	body := `[{"method": "update", "data": {"foo": "b"}}]`
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo/batch", bytes.NewBufferString(body))
+	resp := httptest.NewRecorder()
+
+	batchHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusBadRequest, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), "Operation 0: missing id")
+}
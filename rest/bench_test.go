@@ -0,0 +1,235 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+type benchResource struct {
+	ID    string
+	Name  string
+	Count int
+	Tags  []string
+}
+
+type benchResourceHandler struct {
+	BaseResourceHandler
+	resources []Resource
+}
+
+func newBenchResourceHandler() *benchResourceHandler {
+	resources := make([]Resource, 20)
+	for i := range resources {
+		resources[i] = &benchResource{ID: fmt.Sprint(i), Name: "foo", Count: i, Tags: []string{"a", "b"}}
+	}
+	return &benchResourceHandler{resources: resources}
+}
+
+func (b *benchResourceHandler) ResourceName() string {
+	return "widgets"
+}
+
+func (b *benchResourceHandler) Rules() Rules {
+	return NewRules((*benchResource)(nil),
+		&Rule{Field: "ID", FieldAlias: "id", Type: String, OutputOnly: true},
+		&Rule{Field: "Name", FieldAlias: "name", Type: String, Required: true},
+		&Rule{Field: "Count", FieldAlias: "count", Type: Int},
+		&Rule{Field: "Tags", FieldAlias: "tags", Type: Slice},
+	)
+}
+
+func (b *benchResourceHandler) CreateResource(ctx RequestContext, data Payload,
+	version string) (Resource, error) {
+
+	return &benchResource{ID: "1", Name: data["name"].(string)}, nil
+}
+
+func (b *benchResourceHandler) ReadResourceList(ctx RequestContext, limit int,
+	cursor string, version string) ([]Resource, string, error) {
+
+	resources := make([]Resource, len(b.resources))
+	copy(resources, b.resources)
+	return resources, "", nil
+}
+
+func (b *benchResourceHandler) ReadResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	return b.resources[0], nil
+}
+
+func (b *benchResourceHandler) UpdateResource(ctx RequestContext, id string, data Payload,
+	version string) (Resource, error) {
+
+	return &benchResource{ID: id, Name: data["name"].(string)}, nil
+}
+
+func (b *benchResourceHandler) DeleteResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	return b.resources[0], nil
+}
+
+// quietLog discards the standard logger's output, e.g. of discarded fields, until the
+// returned function is called.
+func quietLog() func() {
+	log.SetOutput(ioutil.Discard)
+	return func() { log.SetOutput(os.Stderr) }
+}
+
+// benchmarkRequest measures serving requests with the method, URL, and body by an API
+// with the benchResourceHandler registered.
+func benchmarkRequest(b *testing.B, method, url, contentType string, body []byte) {
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(newBenchResourceHandler())
+	defer quietLog()()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req, _ := http.NewRequest(method, url, bytes.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		resp := httptest.NewRecorder()
+		api.ServeHTTP(resp, req)
+		if resp.Code >= http.StatusBadRequest {
+			b.Fatalf("Request failed with %d: %s", resp.Code, resp.Body)
+		}
+	}
+}
+
+// BenchmarkCreate measures serving create requests.
+func BenchmarkCreate(b *testing.B) {
+	benchmarkRequest(b, "POST", "http://foo.com/api/v1/widgets", "",
+		[]byte(`{"name": "foo", "count": 1, "tags": ["a"]}`))
+}
+
+// BenchmarkRead measures serving read requests.
+func BenchmarkRead(b *testing.B) {
+	benchmarkRequest(b, "GET", "http://foo.com/api/v1/widgets/1", "", nil)
+}
+
+// BenchmarkReadList measures serving read list requests returning 20 resources.
+func BenchmarkReadList(b *testing.B) {
+	benchmarkRequest(b, "GET", "http://foo.com/api/v1/widgets", "", nil)
+}
+
+// BenchmarkUpdate measures serving update requests.
+func BenchmarkUpdate(b *testing.B) {
+	benchmarkRequest(b, "PUT", "http://foo.com/api/v1/widgets/1", "",
+		[]byte(`{"name": "foo", "count": 1, "tags": ["a"]}`))
+}
+
+// BenchmarkPatch measures serving JSON Merge Patch requests.
+func BenchmarkPatch(b *testing.B) {
+	benchmarkRequest(b, "PATCH", "http://foo.com/api/v1/widgets/1",
+		"application/merge-patch+json", []byte(`{"name": "bar"}`))
+}
+
+// BenchmarkDelete measures serving delete requests.
+func BenchmarkDelete(b *testing.B) {
+	benchmarkRequest(b, "DELETE", "http://foo.com/api/v1/widgets/1", "", nil)
+}
+
+// BenchmarkRouting measures matching requests to the routes of 50 ResourceHandlers.
+func BenchmarkRouting(b *testing.B) {
+	api := NewAPI(&Configuration{}).(*muxAPI)
+	for i := 0; i < 50; i++ {
+		api.RegisterResourceHandler(hotResourceHandler{name: fmt.Sprintf("resource%d", i)})
+	}
+	router := api.muxRouter()
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/resource49/1", nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var match mux.RouteMatch
+		if !router.Match(req, &match) {
+			b.Fatal("Route not matched")
+		}
+	}
+}
+
+// BenchmarkSerializeList measures serializing a response with 20 resources to JSON.
+func BenchmarkSerializeList(b *testing.B) {
+	handler := newBenchResourceHandler()
+	rules := compileRules(handler.Rules())
+	resources := make([]Resource, len(handler.resources))
+	for i, resource := range handler.resources {
+		resources[i] = applyOutboundRules(resource, rules, "1")
+	}
+	w := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resp := response{Status: http.StatusOK, Payload: getEnvelope(), pooled: true}
+		resp.Payload[status] = http.StatusOK
+		resp.Payload[results] = resources
+		out := serializeResponse(w, resp, jsonSerializer{})
+		releaseResponse(resp, out, jsonSerializer{})
+	}
+}
+
+// requestAllocations are the allocation budgets of requests served by an API with the
+// benchResourceHandler registered. They're a guard against performance regressions in
+// the request path, set above the baseline documented in the README to leave room for
+// differences between Go releases.
+var requestAllocations = []struct {
+	method string
+	url    string
+	body   string
+	allocs float64
+}{
+	{"POST", "http://foo.com/api/v1/widgets", `{"name": "foo"}`, 130},
+	{"GET", "http://foo.com/api/v1/widgets/1", "", 105},
+	{"GET", "http://foo.com/api/v1/widgets", "", 600},
+	{"PUT", "http://foo.com/api/v1/widgets/1", `{"name": "foo"}`, 130},
+	{"DELETE", "http://foo.com/api/v1/widgets/1", "", 105},
+}
+
+// Ensures that requests don't allocate more than their budgets.
+func TestRequestAllocations(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping allocation budgets in short mode")
+	}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(newBenchResourceHandler())
+	defer quietLog()()
+
+	for _, budget := range requestAllocations {
+		allocs := testing.AllocsPerRun(100, func() {
+			req, _ := http.NewRequest(budget.method, budget.url, bytes.NewBufferString(budget.body))
+			api.ServeHTTP(httptest.NewRecorder(), req)
+		})
+		if allocs > budget.allocs {
+			t.Errorf("%s %s allocated %.0f times per request, budget is %.0f",
+				budget.method, budget.url, allocs, budget.allocs)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import "reflect"
+
+// BindingResourceHandler can be implemented by a ResourceHandler to opt into payload
+// binding. Create and update payloads, after PayloadMiddleware and inbound Rules are
+// applied, are decoded into a new instance of the Rules' resource type using the
+// Rules' field aliases. A pointer to it is passed to CreateBoundResource or
+// UpdateBoundResource instead of passing the Payload to CreateResource or
+// UpdateResource. Payloads which can't be decoded fail with 422 Unprocessable Entity.
+type BindingResourceHandler interface {
+	// CreateBoundResource creates the resource pointed to by the value and returns it
+	// or an error if the create failed.
+	CreateBoundResource(RequestContext, interface{}, string) (Resource, error)
+
+	// UpdateBoundResource updates the resource with the given ID from the resource
+	// pointed to by the value and returns it or an error if the update failed.
+	UpdateBoundResource(RequestContext, string, interface{}, string) (Resource, error)
+}
+
+// bindPayload returns a pointer to a new instance of the ResourceHandler's resource
+// type with the payload decoded into it.
+func bindPayload(handler ResourceHandler, data Payload) (interface{}, error) {
+	rules := handler.Rules()
+	if rules == nil || rules.ResourceType() == nil ||
+		rules.ResourceType().Kind() != reflect.Struct {
+		return nil, InternalServerError("Payload binding requires Rules for a struct resource")
+	}
+
+	resource := reflect.New(rules.ResourceType()).Interface()
+	if err := data.Decode(resource, rules); err != nil {
+		return nil, UnprocessableRequest(err.Error())
+	}
+	return resource, nil
+}
+
+// invokeCreate creates the resource with the ResourceHandler, binding the payload if
+// it implements BindingResourceHandler.
+func invokeCreate(ctx RequestContext, handler ResourceHandler, data Payload,
+	version string) (Resource, error) {
+
+	binder, ok := unwrapResourceHandler(handler).(BindingResourceHandler)
+	if !ok {
+		return handler.CreateResource(ctx, data, version)
+	}
+	resource, err := bindPayload(handler, data)
+	if err != nil {
+		return nil, err
+	}
+	return binder.CreateBoundResource(ctx, resource, version)
+}
+
+// invokeUpdate updates the resource with the ResourceHandler, binding the payload if it
+// implements BindingResourceHandler.
+func invokeUpdate(ctx RequestContext, handler ResourceHandler, id string, data Payload,
+	version string) (Resource, error) {
+
+	binder, ok := unwrapResourceHandler(handler).(BindingResourceHandler)
+	if !ok {
+		return handler.UpdateResource(ctx, id, data, version)
+	}
+	resource, err := bindPayload(handler, data)
+	if err != nil {
+		return nil, err
+	}
+	return binder.UpdateBoundResource(ctx, id, resource, version)
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type boundWidget struct {
+	ID    string
+	Name  string
+	Count int
+}
+
+type bindingResourceHandler struct {
+	BaseResourceHandler
+}
+
+func (b *bindingResourceHandler) ResourceName() string {
+	return "widgets"
+}
+
+func (b *bindingResourceHandler) Rules() Rules {
+	return NewRules((*boundWidget)(nil),
+		&Rule{Field: "ID", FieldAlias: "id", OutputOnly: true},
+		&Rule{Field: "Name", FieldAlias: "name", Type: String},
+		&Rule{Field: "Count", FieldAlias: "count", Type: Int},
+	)
+}
+
+func (b *bindingResourceHandler) CreateBoundResource(ctx RequestContext, resource interface{},
+	version string) (Resource, error) {
+
+	widget := resource.(*boundWidget)
+	widget.ID = "1"
+	return widget, nil
+}
+
+func (b *bindingResourceHandler) UpdateBoundResource(ctx RequestContext, id string,
+	resource interface{}, version string) (Resource, error) {
+
+	widget := resource.(*boundWidget)
+	widget.ID = id
+	widget.Count++
+	return widget, nil
+}
+
+// Ensures that payloads are bound to the resource type for BindingResourceHandlers.
+func TestPayloadBinding(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&bindingResourceHandler{})
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/widgets",
+		bytes.NewBufferString(`{"name": "foo", "count": 2}`))
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusCreated, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), `"result":{"count":2,"id":"1","name":"foo"}`)
+
+	req, _ = http.NewRequest("PUT", "http://foo.com/api/v1/widgets/7",
+		bytes.NewBufferString(`{"name": "bar", "count": 2}`))
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), `"result":{"count":3,"id":"7","name":"bar"}`)
+}
+
+type unboundResourceHandler struct {
+	bindingResourceHandler
+}
+
+func (u *unboundResourceHandler) Rules() Rules {
+	return &rules{}
+}
+
+// Ensures that binding fails if the Rules don't specify a resource type.
+func TestPayloadBindingWithoutResourceType(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&unboundResourceHandler{})
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/widgets",
+		bytes.NewBufferString(`{"name": "foo"}`))
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusInternalServerError, resp.Code, "Incorrect response code")
+}
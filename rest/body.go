@@ -0,0 +1,72 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// requestBody returns the request body. An error is returned if the body is empty
+// and the Configuration doesn't allow empty bodies. Multipart bodies are parsed into the
+// request's form instead, so nil is returned for them.
+func (h requestHandler) requestBody(ctx RequestContext) ([]byte, error) {
+	if multipartRequest(ctx) {
+		return nil, h.parseMultipartForm(ctx)
+	}
+
+	body := ctx.Body().Bytes()
+	if len(bytes.TrimSpace(body)) == 0 && !h.Configuration().AllowEmptyBody {
+		return nil, BadRequest("Request body is empty")
+	}
+	return body, nil
+}
+
+// payloadError returns an error describing where decoding the JSON payload failed,
+// or the error itself if the position isn't known.
+func payloadError(payload []byte, err error) error {
+	var offset int64
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		offset = e.Offset
+	case *json.UnmarshalTypeError:
+		offset = e.Offset
+	default:
+		return err
+	}
+
+	// The offset is just past the byte where decoding failed.
+	line, column := payloadPosition(payload, offset-1)
+	return fmt.Errorf("Malformed request body at line %d, column %d (offset %d): %s",
+		line, column, offset, err)
+}
+
+// payloadPosition returns the 1-based line and column of the byte at the index in the
+// payload.
+func payloadPosition(payload []byte, index int64) (int, int) {
+	if index < 0 {
+		index = 0
+	}
+	if index > int64(len(payload)) {
+		index = int64(len(payload))
+	}
+	preceding := payload[:index]
+	line := bytes.Count(preceding, []byte("\n")) + 1
+	column := len(preceding) - bytes.LastIndexByte(preceding, '\n')
+	return line, column
+}
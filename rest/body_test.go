@@ -0,0 +1,98 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Ensures that malformed request bodies are rejected with the position of the error.
+func TestHandleCreateMalformedBody(t *testing.T) {
+	assert := assert.New(t)
+	handler := &dryRunResourceHandler{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+	createHandler, _ := api.(*muxAPI).getRouteHandler("foo:create")
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo",
+		bytes.NewBufferString("{\n  \"foo\": bar\n}"))
+	resp := httptest.NewRecorder()
+
+	createHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusBadRequest, resp.Code, "Incorrect response code")
+	assert.Equal(
+		`{"messages":["Malformed request body at line 2, column 10 (offset 12): invalid character 'b' looking for beginning of value"],"reason":"Bad Request","status":400}`,
+		resp.Body.String(),
+		"Incorrect response string",
+	)
+}
+
+// Ensures that empty request bodies are rejected unless they're allowed.
+func TestHandleCreateEmptyBody(t *testing.T) {
+	assert := assert.New(t)
+	handler := &dryRunResourceHandler{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+	createHandler, _ := api.(*muxAPI).getRouteHandler("foo:create")
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo", nil)
+	resp := httptest.NewRecorder()
+
+	createHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusBadRequest, resp.Code, "Incorrect response code")
+	assert.Equal(
+		`{"messages":["Request body is empty"],"reason":"Bad Request","status":400}`,
+		resp.Body.String(),
+		"Incorrect response string",
+	)
+
+	api = NewAPI(&Configuration{AllowEmptyBody: true})
+	api.RegisterResourceHandler(handler)
+	createHandler, _ = api.(*muxAPI).getRouteHandler("foo:create")
+
+	req, _ = http.NewRequest("POST", "http://foo.com/api/v1/foo", nil)
+	resp = httptest.NewRecorder()
+
+	createHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusCreated, resp.Code, "Incorrect response code")
+}
+
+// Ensures that payloadPosition returns the line and column of a byte index.
+func TestPayloadPosition(t *testing.T) {
+	assert := assert.New(t)
+	payload := []byte("{\n  \"a\": 1,\n  x\n}")
+
+	line, column := payloadPosition(payload, 1)
+	assert.Equal(1, line)
+	assert.Equal(2, column)
+
+	line, column = payloadPosition(payload, 15)
+	assert.Equal(3, line)
+	assert.Equal(4, column)
+
+	line, column = payloadPosition(payload, 100)
+	assert.Equal(4, line)
+	assert.Equal(2, column)
+}
@@ -0,0 +1,361 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gcontext "github.com/gorilla/context"
+)
+
+const (
+	// cacheHeader is the response header indicating whether a cacheable response was
+	// served from the cache.
+	cacheHeader = "X-Cache"
+
+	// memoryCacheSweepInterval is the minimum interval between removals of expired
+	// entries from a MemoryCache.
+	memoryCacheSweepInterval = time.Minute
+)
+
+// Cache stores serialized responses of ResourceHandler reads. Implementations must be
+// safe for concurrent use. Errors returned by a Cache are logged and the request is
+// served without it.
+type Cache interface {
+	// Get returns the value cached for the resource under the key, if any.
+	Get(resource, key string) ([]byte, bool, error)
+
+	// Set caches the value for the resource under the key until the TTL elapses.
+	Set(resource, key string, value []byte, ttl time.Duration) error
+
+	// Invalidate removes all values cached for the resource.
+	Invalidate(resource string) error
+}
+
+// GenerationalCache can be implemented by a Cache to prevent responses read before the
+// resource was invalidated from being cached after it. The generation of the resource is
+// taken when a cache miss is passed on, and the response is only cached if the resource
+// hasn't been invalidated since. MemoryCache and RedisCache implement it.
+type GenerationalCache interface {
+	Cache
+
+	// Generation returns the resource's current generation, which changes each time
+	// it's invalidated.
+	Generation(resource string) (string, error)
+
+	// SetIfGeneration caches the value like Set if the resource is still in the
+	// generation. Otherwise, it's discarded.
+	SetIfGeneration(resource, key, generation string, value []byte, ttl time.Duration) error
+}
+
+// CachedResourceHandler can be implemented by a ResourceHandler to override the
+// Configuration's CacheTTL for its read and read list responses.
+type CachedResourceHandler interface {
+	// CacheTTL returns how long the resource's responses are cached for. Zero disables
+	// caching of the resource.
+	CacheTTL() time.Duration
+}
+
+// cacheTTL returns how long the ResourceHandler's responses are cached for.
+func cacheTTL(config *Configuration, handler ResourceHandler) time.Duration {
+	if cached, ok := unwrapResourceHandler(handler).(CachedResourceHandler); ok {
+		return cached.CacheTTL()
+	}
+	return config.CacheTTL
+}
+
+// cacheIdentity returns the identity of the client making the request, along with the
+// ID and sorted roles of its Identity if it has one, since clients sharing credentials
+// may authenticate as different identities.
+func cacheIdentity(config *Configuration, r *http.Request) string {
+	client := r.Header.Get("Authorization")
+	if config.CacheIdentity != nil {
+		client = config.CacheIdentity(r)
+	}
+	identity := requestIdentity(r)
+	if identity == nil {
+		return client
+	}
+	roles := append([]string{}, identity.Roles...)
+	sort.Strings(roles)
+	return strings.Join(append([]string{client, identity.ID}, roles...), "\x00")
+}
+
+// cacheKey returns the key the response to the request is cached under. It covers the
+// path, which contains the resource ID, along with the version, query, requested media
+// type, and the tenant and identity of the client.
+func cacheKey(config *Configuration, r *http.Request) string {
+	hash := sha256.New()
+	for _, part := range []string{
+		resolvedTenant(r),
+		cacheIdentity(config, r),
+		requestVersion(r, config),
+		r.URL.Path,
+		r.URL.Query().Encode(),
+		r.Header.Get("Accept"),
+	} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// cacheableRoute returns true if the request was dispatched to a read or read list
+// endpoint.
+func cacheableRoute(r *http.Request) bool {
+	name := routeName(r)
+	return strings.HasSuffix(name, ":"+string(HandleRead)) ||
+		strings.HasSuffix(name, ":"+string(HandleReadList))
+}
+
+// cachedResponse is the representation of a response stored in a Cache.
+type cachedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// pendingCache associates a request with the Cache entry its response is stored under,
+// or, if the key is empty, with the resource invalidated by its response. The
+// generation is the resource's generation when the request missed the cache if the
+// Cache is a GenerationalCache.
+type pendingCache struct {
+	cache      Cache
+	resource   string
+	key        string
+	generation string
+	ttl        time.Duration
+}
+
+// begin takes the generation of the resource for a read which missed the cache if the
+// Cache is a GenerationalCache. It returns false if the generation can't be read, in
+// which case the response isn't cached since it could be stale by then.
+func (p *pendingCache) begin(logf func(string, ...interface{})) bool {
+	generational, ok := p.cache.(GenerationalCache)
+	if !ok {
+		return true
+	}
+	generation, err := generational.Generation(p.resource)
+	if err != nil {
+		logf("Failed to read generation of cached %s: %s", p.resource, err)
+		return false
+	}
+	p.generation = generation
+	return true
+}
+
+// newCacheMiddleware returns a RequestMiddleware which serves read and read list
+// requests for the ResourceHandler from the Cache. Misses are passed on and their
+// responses cached when sent. Other requests invalidate the resource's cached
+// responses if they succeed. Requests with a no-cache Cache-Control header bypass the
+// Cache, but their responses are still cached.
+func newCacheMiddleware(config *Configuration, handler ResourceHandler,
+	logf func(string, ...interface{})) RequestMiddleware {
+
+	resource := handler.ResourceName()
+	ttl := cacheTTL(config, handler)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == "GET" && cacheableRoute(r):
+				key := cacheKey(config, r)
+				if !strings.Contains(r.Header.Get("Cache-Control"), "no-cache") {
+					value, ok, err := config.Cache.Get(resource, key)
+					if err != nil {
+						logf("Failed to read %s from cache: %s", resource, err)
+					} else if ok && replayCached(w, value) {
+						return
+					}
+				}
+				w.Header().Set(cacheHeader, "MISS")
+				pending := &pendingCache{cache: config.Cache, resource: resource, key: key, ttl: ttl}
+				if pending.begin(logf) {
+					gcontext.Set(r, cacheEntryKey, pending)
+				}
+			case r.Method != "GET" && r.Method != "HEAD" && r.Method != "OPTIONS":
+				gcontext.Set(r, cacheEntryKey, &pendingCache{cache: config.Cache, resource: resource})
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// recordCache caches the response to a read request, or invalidates the cached
+// responses of the resource mutated by a successful request, if the request is being
+// tracked. Only 200 responses are cached, and dry runs don't invalidate.
+func (h requestHandler) recordCache(ctx RequestContext, out *OutboundResponse) {
+	pending, ok := ctx.Value(cacheEntryKey).(*pendingCache)
+	if !ok {
+		return
+	}
+
+	if pending.key == "" {
+		if out.Status >= http.StatusBadRequest || ctx.DryRun() {
+			return
+		}
+		if err := pending.cache.Invalidate(pending.resource); err != nil {
+			h.logf("Failed to invalidate cached %s: %s", pending.resource, err)
+		}
+		return
+	}
+
+	if out.Status != http.StatusOK {
+		return
+	}
+	header := cloneHeader(out.Header)
+	header.Del(cacheHeader)
+	for _, key := range quotaHeaders {
+		header.Del(key)
+	}
+	value, err := json.Marshal(cachedResponse{Status: out.Status, Header: header, Body: out.Body})
+	if err == nil {
+		if generational, ok := pending.cache.(GenerationalCache); ok {
+			err = generational.SetIfGeneration(pending.resource, pending.key,
+				pending.generation, value, pending.ttl)
+		} else {
+			err = pending.cache.Set(pending.resource, pending.key, value, pending.ttl)
+		}
+	}
+	if err != nil {
+		h.logf("Failed to cache %s: %s", pending.resource, err)
+	}
+}
+
+// replayCached writes the cached response. It returns false if the value can't be
+// decoded, in which case nothing is written.
+func replayCached(w http.ResponseWriter, value []byte) bool {
+	var cached cachedResponse
+	if err := json.Unmarshal(value, &cached); err != nil {
+		return false
+	}
+
+	header := w.Header()
+	for key, values := range cached.Header {
+		header[key] = values
+	}
+	header.Set(cacheHeader, "HIT")
+	w.WriteHeader(cached.Status)
+	w.Write(cached.Body)
+	return true
+}
+
+// memoryCacheEntry is a value stored in a MemoryCache.
+type memoryCacheEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// MemoryCache is a GenerationalCache which stores values in memory. Expired values are
+// removed periodically. It's safe for concurrent use.
+type MemoryCache struct {
+	mu          sync.Mutex
+	resources   map[string]map[string]memoryCacheEntry
+	generations map[string]uint64
+	swept       time.Time
+}
+
+// NewMemoryCache returns a newly allocated MemoryCache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{
+		resources:   map[string]map[string]memoryCacheEntry{},
+		generations: map[string]uint64{},
+	}
+}
+
+// Get returns the value cached for the resource under the key if it hasn't expired.
+func (c *MemoryCache) Get(resource, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.resources[resource][key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+// Set caches the value for the resource under the key until the TTL elapses.
+func (c *MemoryCache) Set(resource, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(resource, key, value, ttl)
+	return nil
+}
+
+// Generation returns the number of times the resource has been invalidated.
+func (c *MemoryCache) Generation(resource string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return strconv.FormatUint(c.generations[resource], 10), nil
+}
+
+// SetIfGeneration caches the value for the resource under the key until the TTL
+// elapses if the resource hasn't been invalidated since the generation.
+func (c *MemoryCache) SetIfGeneration(resource, key, generation string, value []byte,
+	ttl time.Duration) error {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if strconv.FormatUint(c.generations[resource], 10) == generation {
+		c.set(resource, key, value, ttl)
+	}
+	return nil
+}
+
+// set caches the value for the resource under the key until the TTL elapses. The lock
+// must be held.
+func (c *MemoryCache) set(resource, key string, value []byte, ttl time.Duration) {
+	now := time.Now()
+	if now.Sub(c.swept) > memoryCacheSweepInterval {
+		c.sweep(now)
+	}
+	if _, ok := c.resources[resource]; !ok {
+		c.resources[resource] = map[string]memoryCacheEntry{}
+	}
+	c.resources[resource][key] = memoryCacheEntry{value: value, expires: now.Add(ttl)}
+}
+
+// Invalidate removes all values cached for the resource and increments its generation.
+func (c *MemoryCache) Invalidate(resource string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.resources, resource)
+	c.generations[resource]++
+	return nil
+}
+
+// sweep removes the expired values. The lock must be held.
+func (c *MemoryCache) sweep(now time.Time) {
+	for resource, entries := range c.resources {
+		for key, entry := range entries {
+			if now.After(entry.expires) {
+				delete(entries, key)
+			}
+		}
+		if len(entries) == 0 {
+			delete(c.resources, resource)
+		}
+	}
+	c.swept = now
+}
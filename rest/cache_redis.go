@@ -0,0 +1,315 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultRedisPrefix is the prefix of the keys stored by a RedisCache if one isn't
+	// configured.
+	defaultRedisPrefix = "rest:"
+
+	// defaultRedisTimeout is the timeout for connecting to Redis and executing commands
+	// if one isn't configured.
+	defaultRedisTimeout = 5 * time.Second
+
+	// defaultRedisMaxIdle is the number of idle connections kept by a RedisCache if a
+	// limit isn't configured.
+	defaultRedisMaxIdle = 8
+)
+
+// redisError is an error reply sent by Redis.
+type redisError string
+
+// Error returns the error message sent by Redis.
+func (e redisError) Error() string {
+	return string(e)
+}
+
+// RedisCache is a GenerationalCache which stores values in Redis, so they're shared by
+// API instances. Each resource has a generation counter which is part of the keys of its
+// values. Invalidating the resource increments the counter, orphaning its values until
+// they expire. It's safe for concurrent use.
+type RedisCache struct {
+	// Addr is the host:port address of the Redis server.
+	Addr string
+
+	// Password authenticates connections if it's not empty.
+	Password string
+
+	// DB is the database selected by connections.
+	DB int
+
+	// Prefix is the prefix of the stored keys. If empty, "rest:" is used.
+	Prefix string
+
+	// Timeout is the timeout for connecting and executing commands. If zero, it's five
+	// seconds.
+	Timeout time.Duration
+
+	// MaxIdle is the number of idle connections kept for reuse. If zero, 8 are kept.
+	MaxIdle int
+
+	mu   sync.Mutex
+	idle []*redisConn
+}
+
+// NewRedisCache returns a RedisCache for the Redis server at the address.
+func NewRedisCache(addr string) *RedisCache {
+	return &RedisCache{Addr: addr}
+}
+
+// Get returns the value cached for the resource under the key, if any.
+func (c *RedisCache) Get(resource, key string) ([]byte, bool, error) {
+	valueKey, err := c.valueKey(resource, key)
+	if err != nil {
+		return nil, false, err
+	}
+	reply, err := c.do("GET", valueKey)
+	if err != nil {
+		return nil, false, err
+	}
+	value, ok := reply.([]byte)
+	return value, ok, nil
+}
+
+// Set caches the value for the resource under the key until the TTL elapses.
+func (c *RedisCache) Set(resource, key string, value []byte, ttl time.Duration) error {
+	generation, err := c.Generation(resource)
+	if err != nil {
+		return err
+	}
+	return c.SetIfGeneration(resource, key, generation, value, ttl)
+}
+
+// SetIfGeneration caches the value for the resource under the key in the generation
+// until the TTL elapses. If the resource has been invalidated since, the value is
+// orphaned like those cached before the invalidation.
+func (c *RedisCache) SetIfGeneration(resource, key, generation string, value []byte,
+	ttl time.Duration) error {
+
+	valueKey := c.generationValueKey(resource, key, generation)
+	millis := int64(ttl / time.Millisecond)
+	if millis <= 0 {
+		return nil
+	}
+	_, err := c.do("SET", valueKey, string(value), "PX", strconv.FormatInt(millis, 10))
+	return err
+}
+
+// Invalidate increments the resource's generation, orphaning its cached values.
+func (c *RedisCache) Invalidate(resource string) error {
+	_, err := c.do("INCR", c.generationKey(resource))
+	return err
+}
+
+// generationKey returns the key of the resource's generation counter.
+func (c *RedisCache) generationKey(resource string) string {
+	return c.prefix() + "generation:" + resource
+}
+
+// Generation returns the resource's generation counter.
+func (c *RedisCache) Generation(resource string) (string, error) {
+	reply, err := c.do("GET", c.generationKey(resource))
+	if err != nil {
+		return "", err
+	}
+	if value, ok := reply.([]byte); ok {
+		return string(value), nil
+	}
+	return "0", nil
+}
+
+// valueKey returns the key of the value cached for the resource under the key in the
+// resource's current generation.
+func (c *RedisCache) valueKey(resource, key string) (string, error) {
+	generation, err := c.Generation(resource)
+	if err != nil {
+		return "", err
+	}
+	return c.generationValueKey(resource, key, generation), nil
+}
+
+// generationValueKey returns the key of the value cached for the resource under the key
+// in the generation.
+func (c *RedisCache) generationValueKey(resource, key, generation string) string {
+	return c.prefix() + resource + ":" + generation + ":" + key
+}
+
+// prefix returns the prefix of the stored keys.
+func (c *RedisCache) prefix() string {
+	if c.Prefix != "" {
+		return c.Prefix
+	}
+	return defaultRedisPrefix
+}
+
+// timeout returns the timeout for connecting and executing commands.
+func (c *RedisCache) timeout() time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+	return defaultRedisTimeout
+}
+
+// do executes the command on an idle connection, or a new one if there are none, and
+// returns the reply. Connections are discarded if they fail.
+func (c *RedisCache) do(args ...string) (interface{}, error) {
+	conn, err := c.conn()
+	if err != nil {
+		return nil, err
+	}
+	reply, err := conn.do(c.timeout(), args...)
+	if _, ok := err.(redisError); err != nil && !ok {
+		conn.Close()
+		return nil, err
+	}
+	c.release(conn)
+	return reply, err
+}
+
+// conn returns an idle connection, or a new authenticated connection to the selected
+// database if there are none.
+func (c *RedisCache) conn() (*redisConn, error) {
+	c.mu.Lock()
+	if n := len(c.idle); n > 0 {
+		conn := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+		return conn, nil
+	}
+	c.mu.Unlock()
+
+	netConn, err := net.DialTimeout("tcp", c.Addr, c.timeout())
+	if err != nil {
+		return nil, err
+	}
+	conn := &redisConn{Conn: netConn, reader: bufio.NewReader(netConn)}
+	if c.Password != "" {
+		if _, err := conn.do(c.timeout(), "AUTH", c.Password); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if c.DB != 0 {
+		if _, err := conn.do(c.timeout(), "SELECT", strconv.Itoa(c.DB)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// release returns the connection to the idle connections, closing it if there are
+// already enough.
+func (c *RedisCache) release(conn *redisConn) {
+	maxIdle := c.MaxIdle
+	if maxIdle <= 0 {
+		maxIdle = defaultRedisMaxIdle
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.idle) >= maxIdle {
+		conn.Close()
+		return
+	}
+	c.idle = append(c.idle, conn)
+}
+
+// redisConn is a connection to a Redis server speaking the RESP protocol.
+type redisConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// do sends the command and reads its reply within the timeout.
+func (c *redisConn) do(timeout time.Duration, args ...string) (interface{}, error) {
+	if err := c.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	if _, err := c.Write(encodeRedisCommand(args)); err != nil {
+		return nil, err
+	}
+	return readRedisReply(c.reader)
+}
+
+// encodeRedisCommand returns the RESP encoding of the command, an array of bulk
+// strings.
+func encodeRedisCommand(args []string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&buf, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	return buf.Bytes()
+}
+
+// readRedisReply reads a RESP reply. Simple strings are returned as strings, integers
+// as int64s, bulk strings as []byte, arrays as []interface{}, and nulls as nil. Error
+// replies are returned as a redisError.
+func readRedisReply(reader *bufio.Reader) (interface{}, error) {
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r"))
+	if len(line) == 0 {
+		return nil, errors.New("Empty Redis reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return string(line[1:]), nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(string(line[1:]), 10, 64)
+	case '$':
+		n, err := strconv.Atoi(string(line[1:]))
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		value := make([]byte, n+2)
+		if _, err := io.ReadFull(reader, value); err != nil {
+			return nil, err
+		}
+		return value[:n], nil
+	case '*':
+		n, err := strconv.Atoi(string(line[1:]))
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		values := make([]interface{}, n)
+		for i := range values {
+			if values[i], err = readRedisReply(reader); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("Invalid Redis reply: %q", line)
+}
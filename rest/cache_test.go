@@ -0,0 +1,348 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type cachedResourceHandler struct {
+	BaseResourceHandler
+	mu    sync.Mutex
+	reads int
+	ttl   time.Duration
+}
+
+func (c *cachedResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (c *cachedResourceHandler) CacheTTL() time.Duration {
+	return c.ttl
+}
+
+func (c *cachedResourceHandler) ReadResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reads++
+	return Payload{"id": id, "reads": c.reads}, nil
+}
+
+func (c *cachedResourceHandler) UpdateResource(ctx RequestContext, id string, data Payload,
+	version string) (Resource, error) {
+
+	return data, nil
+}
+
+type identityCachedResourceHandler struct {
+	cachedResourceHandler
+}
+
+func (i *identityCachedResourceHandler) Authenticate(r *http.Request) error {
+	SetIdentity(r, &Identity{ID: r.Header.Get("X-User"), Roles: r.Header["X-Role"]})
+	return nil
+}
+
+// serveCached sends the request with the Authorization header and returns the
+// response.
+func serveCached(api API, method, url, auth string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, url, bytes.NewBufferString(`{"foo": "bar"}`))
+	req.Header.Set("Authorization", auth)
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	return resp
+}
+
+// Ensures that read responses are served from the Cache until the resource is
+// mutated, and aren't shared between identities.
+func TestCacheReads(t *testing.T) {
+	assert := assert.New(t)
+	handler := &cachedResourceHandler{ttl: time.Minute}
+	api := NewAPI(&Configuration{Cache: NewMemoryCache()})
+	api.RegisterResourceHandler(handler)
+	url := "http://foo.com/api/v1/foo/1"
+
+	first := serveCached(api, "GET", url, "alice")
+	assert.Equal(http.StatusOK, first.Code)
+	assert.Equal("MISS", first.Header().Get(cacheHeader))
+
+	resp := serveCached(api, "GET", url, "alice")
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal("HIT", resp.Header().Get(cacheHeader))
+	assert.Equal(first.Body.String(), resp.Body.String())
+	assert.Equal(first.Header().Get("Content-Type"), resp.Header().Get("Content-Type"))
+	assert.Equal(1, handler.reads)
+
+	resp = serveCached(api, "GET", url+"?fields=id", "alice")
+	assert.Equal("MISS", resp.Header().Get(cacheHeader))
+
+	resp = serveCached(api, "GET", url, "bob")
+	assert.Equal("MISS", resp.Header().Get(cacheHeader))
+	assert.Equal(3, handler.reads)
+
+	resp = serveCached(api, "PUT", url, "alice")
+	assert.Equal(http.StatusOK, resp.Code)
+
+	resp = serveCached(api, "GET", url, "alice")
+	assert.Equal("MISS", resp.Header().Get(cacheHeader))
+	assert.Equal(4, handler.reads)
+}
+
+// Ensures that read responses aren't shared between tenants.
+func TestCacheTenants(t *testing.T) {
+	assert := assert.New(t)
+	handler := &cachedResourceHandler{ttl: time.Minute}
+	api := NewAPI(&Configuration{
+		Cache:   NewMemoryCache(),
+		Tenancy: &Tenancy{Source: TenantHeader},
+	})
+	api.RegisterResourceHandler(handler)
+
+	for _, tenant := range []string{"a", "a", "b"} {
+		req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/1", nil)
+		req.Header.Set("Authorization", "alice")
+		req.Header.Set("X-Tenant-ID", tenant)
+		api.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.Equal(2, handler.reads)
+}
+
+// Ensures that read responses aren't shared between identities authenticated with the
+// same Authorization header, and that the order of roles doesn't matter.
+func TestCacheIdentities(t *testing.T) {
+	assert := assert.New(t)
+	handler := &identityCachedResourceHandler{cachedResourceHandler{ttl: time.Minute}}
+	api := NewAPI(&Configuration{Cache: NewMemoryCache()})
+	api.RegisterResourceHandler(handler)
+
+	for _, identity := range []struct {
+		user  string
+		roles []string
+	}{
+		{"alice", []string{"admin", "user"}},
+		{"alice", []string{"user", "admin"}},
+		{"bob", []string{"admin", "user"}},
+		{"alice", []string{"user"}},
+	} {
+		req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/1", nil)
+		req.Header.Set("Authorization", "shared")
+		req.Header.Set("X-User", identity.user)
+		req.Header["X-Role"] = identity.roles
+		api.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.Equal(3, handler.reads)
+}
+
+// Ensures that responses aren't cached for resources with a zero TTL.
+func TestCacheDisabledForResource(t *testing.T) {
+	assert := assert.New(t)
+	handler := &cachedResourceHandler{}
+	api := NewAPI(&Configuration{Cache: NewMemoryCache(), CacheTTL: time.Minute})
+	api.RegisterResourceHandler(handler)
+
+	serveCached(api, "GET", "http://foo.com/api/v1/foo/1", "")
+	resp := serveCached(api, "GET", "http://foo.com/api/v1/foo/1", "")
+
+	assert.Equal("MISS", resp.Header().Get(cacheHeader))
+	assert.Equal(2, handler.reads)
+}
+
+// Ensures that MemoryCache expires and invalidates values.
+func TestMemoryCache(t *testing.T) {
+	assert := assert.New(t)
+	cache := NewMemoryCache()
+
+	assert.NoError(cache.Set("foo", "a", []byte("1"), time.Minute))
+	assert.NoError(cache.Set("foo", "b", []byte("2"), -time.Second))
+	assert.NoError(cache.Set("bar", "a", []byte("3"), time.Minute))
+
+	value, ok, err := cache.Get("foo", "a")
+	assert.NoError(err)
+	assert.True(ok)
+	assert.Equal([]byte("1"), value)
+
+	_, ok, _ = cache.Get("foo", "b")
+	assert.False(ok)
+
+	assert.NoError(cache.Invalidate("foo"))
+	_, ok, _ = cache.Get("foo", "a")
+	assert.False(ok)
+	_, ok, _ = cache.Get("bar", "a")
+	assert.True(ok)
+}
+
+// fakeRedis serves the GET, SET, and INCR commands of the Redis protocol from memory.
+func fakeRedis(t *testing.T) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	values := map[string]string{}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				for {
+					reply, err := readRedisReply(reader)
+					if err != nil {
+						return
+					}
+					args := reply.([]interface{})
+					mu.Lock()
+					switch cmd := string(args[0].([]byte)); cmd {
+					case "GET":
+						if value, ok := values[string(args[1].([]byte))]; ok {
+							conn.Write([]byte("$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n"))
+						} else {
+							conn.Write([]byte("$-1\r\n"))
+						}
+					case "SET":
+						values[string(args[1].([]byte))] = string(args[2].([]byte))
+						conn.Write([]byte("+OK\r\n"))
+					case "INCR":
+						n, _ := strconv.Atoi(values[string(args[1].([]byte))])
+						values[string(args[1].([]byte))] = strconv.Itoa(n + 1)
+						conn.Write([]byte(":" + strconv.Itoa(n+1) + "\r\n"))
+					default:
+						conn.Write([]byte("-ERR unknown command '" + cmd + "'\r\n"))
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+	}()
+	return listener
+}
+
+// Ensures that RedisCache stores values and invalidates them by generation.
+func TestRedisCache(t *testing.T) {
+	assert := assert.New(t)
+	listener := fakeRedis(t)
+	defer listener.Close()
+	cache := NewRedisCache(listener.Addr().String())
+
+	_, ok, err := cache.Get("foo", "a")
+	assert.NoError(err)
+	assert.False(ok)
+
+	assert.NoError(cache.Set("foo", "a", []byte("1\r\n2"), time.Minute))
+	value, ok, err := cache.Get("foo", "a")
+	assert.NoError(err)
+	assert.True(ok)
+	assert.Equal([]byte("1\r\n2"), value)
+
+	assert.NoError(cache.Invalidate("foo"))
+	_, ok, err = cache.Get("foo", "a")
+	assert.NoError(err)
+	assert.False(ok)
+
+	_, err = cache.do("FLUSHALL")
+	assert.Equal(redisError("ERR unknown command 'FLUSHALL'"), err)
+	assert.Len(cache.idle, 1)
+}
+
+type blockingCachedResourceHandler struct {
+	cachedResourceHandler
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingCachedResourceHandler) ReadResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	resource, err := b.cachedResourceHandler.ReadResource(ctx, id, version)
+	select {
+	case b.entered <- struct{}{}:
+		<-b.release
+	default:
+	}
+	return resource, err
+}
+
+// Ensures that a response read before the resource is invalidated isn't cached when it's
+// sent after the invalidation.
+func TestCacheInvalidatedDuringRead(t *testing.T) {
+	assert := assert.New(t)
+	handler := &blockingCachedResourceHandler{
+		cachedResourceHandler: cachedResourceHandler{ttl: time.Minute},
+		entered:               make(chan struct{}),
+		release:               make(chan struct{}),
+	}
+	api := NewAPI(&Configuration{Cache: NewMemoryCache()})
+	api.RegisterResourceHandler(handler)
+	url := "http://foo.com/api/v1/foo/1"
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- serveCached(api, "GET", url, "alice")
+	}()
+	<-handler.entered
+
+	assert.Equal(http.StatusOK, serveCached(api, "PUT", url, "alice").Code)
+	close(handler.release)
+	assert.Equal("MISS", (<-done).Header().Get(cacheHeader))
+
+	resp := serveCached(api, "GET", url, "alice")
+	assert.Equal("MISS", resp.Header().Get(cacheHeader))
+	assert.Equal(2, handler.reads)
+}
+
+// Ensures that MemoryCache and RedisCache discard values of invalidated generations.
+func TestCacheSetIfGeneration(t *testing.T) {
+	assert := assert.New(t)
+	listener := fakeRedis(t)
+	defer listener.Close()
+
+	for _, cache := range []GenerationalCache{
+		NewMemoryCache(),
+		NewRedisCache(listener.Addr().String()),
+	} {
+		generation, err := cache.Generation("foo")
+		assert.NoError(err)
+		assert.NoError(cache.Invalidate("foo"))
+		assert.NoError(cache.SetIfGeneration("foo", "a", generation, []byte("1"), time.Minute))
+		_, ok, _ := cache.Get("foo", "a")
+		assert.False(ok)
+
+		generation, _ = cache.Generation("foo")
+		assert.NoError(cache.SetIfGeneration("foo", "a", generation, []byte("2"), time.Minute))
+		value, ok, _ := cache.Get("foo", "a")
+		assert.True(ok)
+		assert.Equal([]byte("2"), value)
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+)
+
+// Types of the Changes recorded in a ChangeLog.
+const (
+	ChangeCreated = "created"
+	ChangeUpdated = "updated"
+	ChangeDeleted = "deleted"
+)
+
+// Change is a record of a resource being created, updated, or deleted.
+type Change struct {
+	// Sequence is the token identifying the change's position in the ChangeLog.
+	// Clients resume the feed after the change by passing it as the "next" query
+	// parameter.
+	Sequence string
+
+	// Type is the kind of change, i.e. ChangeCreated, ChangeUpdated, or ChangeDeleted.
+	Type string
+
+	// ID is the id of the changed resource.
+	ID string
+
+	// Resource is a snapshot of the resource after the change, if any. Outbound Rules
+	// are applied to it for the requested version.
+	Resource Resource
+}
+
+// ChangeLog can be implemented by a ResourceHandler to serve a feed of the changes to
+// its resources, which sync clients use to replicate them incrementally. If
+// implemented, the following endpoint is registered relative to the handler's read
+// list URI:
+//
+//	GET /api/:version/resourceName/changes
+//
+// The response contains the change records in order, and the "next" URL resumes the
+// feed after the last of them. It's always present, so clients poll it for further
+// changes.
+type ChangeLog interface {
+	// Changes returns up to limit changes recorded after the sequence token, oldest
+	// first, along with the sequence token to resume from. The feed starts from the
+	// first change if the token is empty. An empty returned token resumes from the same
+	// position.
+	Changes(ctx RequestContext, since string, limit int, version string) ([]Change, string, error)
+}
+
+// changeRecord returns the Payload sent to clients for the Change.
+func changeRecord(change Change, rules Rules, version string) Payload {
+	return Payload{
+		"sequence": change.Sequence,
+		"type":     change.Type,
+		"id":       change.ID,
+		"resource": applyOutboundRules(change.Resource, rules, version),
+	}
+}
+
+// handleChanges returns a Handler which serves the changes recorded in the
+// ResourceHandler's ChangeLog after the sequence token passed as the "next" query
+// parameter.
+func (h requestHandler) handleChanges(handler ResourceHandler, changeLog ChangeLog) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := h.newContext(w, r)
+		ctx = ctx.setLimits(resolveListLimits(h.Configuration(), handler))
+		version := ctx.Version()
+		rules := visibleRules(ctx, handler.Rules())
+
+		since := ctx.Cursor()
+		changes, next, err := changeLog.Changes(ctx, since, ctx.Limit(), version)
+		if next == "" {
+			next = since
+		}
+
+		records := make([]Payload, 0, len(changes))
+		if err == nil {
+			for _, change := range changes {
+				records = append(records, changeRecord(change, rules, version))
+			}
+		}
+
+		ctx = ctx.setResult(records)
+		ctx = ctx.setCursor(next)
+		ctx = ctx.setError(err)
+		ctx = ctx.setStatus(http.StatusOK)
+
+		if err == nil {
+			setLinkHeader(ctx)
+		}
+
+		h.sendResponse(ctx)
+	})
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type changeLogResourceHandler struct {
+	BaseResourceHandler
+	changes []Change
+}
+
+func (c *changeLogResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (c *changeLogResourceHandler) Rules() Rules {
+	return NewRules((*TestResource)(nil), &Rule{Field: "Foo", FieldAlias: "foo"})
+}
+
+func (c *changeLogResourceHandler) Changes(ctx RequestContext, since string, limit int,
+	version string) ([]Change, string, error) {
+
+	start := 0
+	if since != "" {
+		sequence, err := strconv.Atoi(since)
+		if err != nil {
+			return nil, "", BadRequest("Invalid sequence " + since)
+		}
+		start = sequence
+	}
+
+	changes := c.changes[start:]
+	if len(changes) > limit {
+		changes = changes[:limit]
+	}
+	if len(changes) == 0 {
+		return changes, "", nil
+	}
+	return changes, changes[len(changes)-1].Sequence, nil
+}
+
+// getPayload requests the URL and returns the response and decoded payload.
+func getPayload(t *testing.T, api API, rawURL string) (*httptest.ResponseRecorder, Payload) {
+	req, _ := http.NewRequest("GET", rawURL, nil)
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	var payload Payload
+	if err := json.Unmarshal(resp.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("Invalid response: %s", resp.Body.String())
+	}
+	return resp, payload
+}
+
+// Ensures that the change feed serves changes in order with a next URL which resumes
+// after the last of them.
+func TestHandleChanges(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&changeLogResourceHandler{changes: []Change{
+		{Sequence: "1", Type: ChangeCreated, ID: "a", Resource: &TestResource{Foo: "hello"}},
+		{Sequence: "2", Type: ChangeUpdated, ID: "a", Resource: &TestResource{Foo: "world"}},
+		{Sequence: "3", Type: ChangeDeleted, ID: "a"},
+	}})
+
+	resp, payload := getPayload(t, api, "http://example.com/api/v1/foo/changes?limit=2")
+
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal([]interface{}{
+		map[string]interface{}{"sequence": "1", "type": "created", "id": "a",
+			"resource": map[string]interface{}{"foo": "hello"}},
+		map[string]interface{}{"sequence": "2", "type": "updated", "id": "a",
+			"resource": map[string]interface{}{"foo": "world"}},
+	}, payload["results"])
+	next, _ := url.Parse(payload["next"].(string))
+	assert.Equal("2", next.Query().Get("next"))
+	assert.Contains(resp.Header().Get("Link"), `rel="next"`)
+
+	resp, payload = getPayload(t, api, payload["next"].(string))
+
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal([]interface{}{
+		map[string]interface{}{"sequence": "3", "type": "deleted", "id": "a", "resource": nil},
+	}, payload["results"])
+	next, _ = url.Parse(payload["next"].(string))
+	assert.Equal("3", next.Query().Get("next"))
+
+	resp, payload = getPayload(t, api, payload["next"].(string))
+
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal([]interface{}{}, payload["results"])
+	next, _ = url.Parse(payload["next"].(string))
+	assert.Equal("3", next.Query().Get("next"))
+}
+
+// Ensures that ChangeLog errors are returned to the client.
+func TestHandleChangesError(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&changeLogResourceHandler{})
+
+	resp, payload := getPayload(t, api, "http://example.com/api/v1/foo/changes?next=bad")
+
+	assert.Equal(http.StatusBadRequest, resp.Code)
+	assert.Equal([]interface{}{"Invalid sequence bad"}, payload["messages"])
+}
+
+// Ensures that the change feed is only registered for handlers implementing ChangeLog.
+func TestHandleChangesNotRegistered(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&eventsResourceHandler{})
+
+	assert.NotNil(api.(*muxAPI).muxRouter().Get("foo:" + string(HandleEvents)))
+	assert.Nil(api.(*muxAPI).muxRouter().Get("foo:" + string(HandleChanges)))
+}
@@ -0,0 +1,356 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for CircuitBreaker settings which aren't configured.
+const (
+	defaultCircuitErrorThreshold   = 0.5
+	defaultCircuitMinRequests      = 10
+	defaultCircuitWindow           = 10 * time.Second
+	defaultCircuitOpenTimeout      = 30 * time.Second
+	defaultCircuitHalfOpenRequests = 1
+)
+
+// CircuitState is the state of a resource's circuit breaker.
+type CircuitState string
+
+// Circuit breaker states.
+const (
+	// CircuitClosed is the state in which requests are handled normally.
+	CircuitClosed CircuitState = "closed"
+
+	// CircuitOpen is the state in which requests fail fast with 503 Service
+	// Unavailable.
+	CircuitOpen CircuitState = "open"
+
+	// CircuitHalfOpen is the state in which a limited number of probe requests are
+	// handled to determine whether the resource has recovered.
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// CircuitBreaker configures the circuit breaker of a resource with a fragile backend.
+// When enough of the resource's requests fail or are slow, the circuit opens and
+// requests fail fast with 503 Service Unavailable rather than piling up timeouts. After
+// OpenTimeout, probe requests are let through, closing the circuit if they succeed.
+type CircuitBreaker struct {
+	// ErrorThreshold is the fraction of requests within the Window which must fail to
+	// open the circuit. Requests fail if they respond with a 5xx status or exceed the
+	// LatencyThreshold. Defaults to 0.5.
+	ErrorThreshold float64
+
+	// LatencyThreshold is the duration after which requests are considered failed,
+	// even if they succeed. Zero disables it.
+	LatencyThreshold time.Duration
+
+	// MinRequests is the number of requests which must be made within the Window
+	// before the circuit can open. Defaults to 10.
+	MinRequests int
+
+	// Window is the interval over which request failures are counted. Defaults to 10
+	// seconds.
+	Window time.Duration
+
+	// OpenTimeout is how long the circuit stays open before probe requests are let
+	// through. Defaults to 30 seconds.
+	OpenTimeout time.Duration
+
+	// HalfOpenRequests is the number of probe requests which must succeed to close the
+	// circuit. Defaults to 1.
+	HalfOpenRequests int
+
+	// OnStateChange, if set, is called when the circuit changes state, e.g. to record
+	// metrics or alert. It's called synchronously and must not block.
+	OnStateChange func(resource string, from, to CircuitState)
+}
+
+// CircuitBreakerStats contains the state and request counts of a resource's circuit
+// breaker.
+type CircuitBreakerStats struct {
+	// Resource is the name of the resource the circuit breaker is attached to.
+	Resource string
+
+	// State is the current state of the circuit.
+	State CircuitState
+
+	// Requests is the number of requests handled within the current window.
+	Requests int64
+
+	// Failures is the number of requests which failed within the current window.
+	Failures int64
+
+	// Rejected is the total number of requests which failed fast.
+	Rejected int64
+
+	// Opened is the number of times the circuit has opened.
+	Opened int64
+
+	// LastStateChange is when the circuit last changed state, or the zero time if it
+	// never has.
+	LastStateChange time.Time
+}
+
+// circuitBreaker is the state of a resource's circuit breaker. It's safe for
+// concurrent use.
+type circuitBreaker struct {
+	resource    string
+	settings    CircuitBreaker
+	mu          sync.Mutex
+	stats       CircuitBreakerStats
+	windowStart time.Time
+	openedAt    time.Time
+	probes      int
+	successes   int
+}
+
+// newCircuitBreaker returns a closed circuitBreaker for the resource with defaults
+// applied to the settings.
+func newCircuitBreaker(resource string, settings CircuitBreaker) *circuitBreaker {
+	if settings.ErrorThreshold <= 0 {
+		settings.ErrorThreshold = defaultCircuitErrorThreshold
+	}
+	if settings.MinRequests <= 0 {
+		settings.MinRequests = defaultCircuitMinRequests
+	}
+	if settings.Window <= 0 {
+		settings.Window = defaultCircuitWindow
+	}
+	if settings.OpenTimeout <= 0 {
+		settings.OpenTimeout = defaultCircuitOpenTimeout
+	}
+	if settings.HalfOpenRequests <= 0 {
+		settings.HalfOpenRequests = defaultCircuitHalfOpenRequests
+	}
+	return &circuitBreaker{
+		resource: resource,
+		settings: settings,
+		stats:    CircuitBreakerStats{Resource: resource, State: CircuitClosed},
+	}
+}
+
+// allow returns true if a request may be handled. If the circuit is open, it returns
+// false along with the time remaining until probe requests are let through.
+func (c *circuitBreaker) allow(now time.Time) (bool, time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stats.State == CircuitOpen {
+		remaining := c.settings.OpenTimeout - now.Sub(c.openedAt)
+		if remaining > 0 {
+			c.stats.Rejected++
+			return false, remaining
+		}
+		c.setState(CircuitHalfOpen, now)
+	}
+
+	if c.stats.State == CircuitHalfOpen {
+		if c.probes >= c.settings.HalfOpenRequests {
+			c.stats.Rejected++
+			return false, 0
+		}
+		c.probes++
+	}
+	return true, 0
+}
+
+// record records the outcome of a request which was allowed, opening or closing the
+// circuit as needed.
+func (c *circuitBreaker) record(failed bool, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.stats.State {
+	case CircuitHalfOpen:
+		if failed {
+			c.open(now)
+			return
+		}
+		c.successes++
+		if c.successes >= c.settings.HalfOpenRequests {
+			c.setState(CircuitClosed, now)
+		}
+
+	case CircuitClosed:
+		if now.Sub(c.windowStart) >= c.settings.Window {
+			c.resetWindow(now)
+		}
+		c.stats.Requests++
+		if failed {
+			c.stats.Failures++
+		}
+		if c.stats.Requests >= int64(c.settings.MinRequests) &&
+			float64(c.stats.Failures)/float64(c.stats.Requests) >= c.settings.ErrorThreshold {
+			c.open(now)
+		}
+	}
+}
+
+// open opens the circuit.
+func (c *circuitBreaker) open(now time.Time) {
+	c.openedAt = now
+	c.stats.Opened++
+	c.setState(CircuitOpen, now)
+}
+
+// setState transitions the circuit to the state, resetting the request counts and
+// notifying OnStateChange. The caller must hold the lock.
+func (c *circuitBreaker) setState(state CircuitState, now time.Time) {
+	from := c.stats.State
+	c.stats.State = state
+	c.stats.LastStateChange = now
+	c.probes, c.successes = 0, 0
+	c.resetWindow(now)
+	if c.settings.OnStateChange != nil {
+		c.settings.OnStateChange(c.resource, from, state)
+	}
+}
+
+// resetWindow starts a new window of request counts.
+func (c *circuitBreaker) resetWindow(now time.Time) {
+	c.windowStart = now
+	c.stats.Requests, c.stats.Failures = 0, 0
+}
+
+// snapshot returns the current CircuitBreakerStats.
+func (c *circuitBreaker) snapshot() CircuitBreakerStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// circuitBreakers contains the circuit breakers of resources, keyed by resource name.
+// It's safe for concurrent use.
+type circuitBreakers struct {
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+// newCircuitBreakers returns a newly allocated circuitBreakers.
+func newCircuitBreakers() *circuitBreakers {
+	return &circuitBreakers{breakers: map[string]*circuitBreaker{}}
+}
+
+// get returns the resource's circuit breaker, creating it with the settings if needed,
+// so that handlers registered for the same resource share its state.
+func (c *circuitBreakers) get(resource string, settings CircuitBreaker) *circuitBreaker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	breaker, ok := c.breakers[resource]
+	if !ok {
+		breaker = newCircuitBreaker(resource, settings)
+		c.breakers[resource] = breaker
+	}
+	return breaker
+}
+
+// snapshot returns the stats of the circuit breakers ordered by resource.
+func (c *circuitBreakers) snapshot() []CircuitBreakerStats {
+	c.mu.Lock()
+	breakers := make([]*circuitBreaker, 0, len(c.breakers))
+	for _, breaker := range c.breakers {
+		breakers = append(breakers, breaker)
+	}
+	c.mu.Unlock()
+
+	stats := make([]CircuitBreakerStats, len(breakers))
+	for i, breaker := range breakers {
+		stats[i] = breaker.snapshot()
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Resource < stats[j].Resource })
+	return stats
+}
+
+// statusResponseWriter wraps an http.ResponseWriter to record the response status.
+type statusResponseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status and writes it to the wrapped http.ResponseWriter.
+func (s *statusResponseWriter) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+// Write writes the data to the wrapped http.ResponseWriter, recording an implicit 200
+// status if one hasn't been written.
+func (s *statusResponseWriter) Write(p []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(p)
+}
+
+// Flush flushes the wrapped http.ResponseWriter if it supports flushing.
+func (s *statusResponseWriter) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Push pushes the target with the wrapped http.ResponseWriter if it supports server
+// push.
+func (s *statusResponseWriter) Push(target string, opts *http.PushOptions) error {
+	if pusher, ok := s.ResponseWriter.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// newCircuitBreakerMiddleware returns a RequestMiddleware which fails requests fast
+// with 503 Service Unavailable while the resource's circuit is open, and records the
+// outcome of requests which are handled. Event streams aren't subject to the breaker
+// since they're long-lived.
+func newCircuitBreakerMiddleware(breaker *circuitBreaker, h *requestHandler) RequestMiddleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasSuffix(routeName(r), ":"+string(HandleEvents)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			if ok, retryAfter := breaker.allow(start); !ok {
+				h.sendResponse(h.newContext(w, r).setError(ServiceUnavailable(
+					fmt.Sprintf("%s is temporarily unavailable", breaker.resource)).
+					WithRetryAfter(retryAfter)))
+				return
+			}
+
+			writer := &statusResponseWriter{ResponseWriter: w}
+			defer func() {
+				// Recorded even if the handler panics so probe requests are accounted.
+				end := time.Now()
+				threshold := breaker.settings.LatencyThreshold
+				failed := writer.status == 0 || writer.status >= http.StatusInternalServerError ||
+					(threshold > 0 && end.Sub(start) > threshold)
+				breaker.record(failed, end)
+			}()
+			next.ServeHTTP(writer, r)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fragileResourceHandler struct {
+	BaseResourceHandler
+	failing bool
+	delay   time.Duration
+}
+
+func (f *fragileResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (f *fragileResourceHandler) ReadResource(ctx RequestContext, id,
+	version string) (Resource, error) {
+
+	time.Sleep(f.delay)
+	if f.failing {
+		return nil, InternalServerError("Backend unavailable")
+	}
+	return Payload{"id": id}, nil
+}
+
+// readFoo serves a read request for foo and returns the response.
+func readFoo(api API) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "http://example.com/api/v1/foo/1", nil)
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	return resp
+}
+
+// Ensures that the circuit opens after repeated failures, fails fast while open, and
+// closes once a probe request succeeds.
+func TestCircuitBreaker(t *testing.T) {
+	assert := assert.New(t)
+	transitions := []CircuitState{}
+	handler := &fragileResourceHandler{failing: true}
+	api := NewAPI(&Configuration{CircuitBreakers: map[string]CircuitBreaker{
+		"foo": {
+			MinRequests: 2,
+			OpenTimeout: 50 * time.Millisecond,
+			OnStateChange: func(resource string, from, to CircuitState) {
+				assert.Equal("foo", resource)
+				transitions = append(transitions, to)
+			},
+		},
+	}})
+	api.RegisterResourceHandler(handler)
+
+	assert.Equal(http.StatusInternalServerError, readFoo(api).Code)
+	assert.Equal(http.StatusInternalServerError, readFoo(api).Code)
+
+	resp := readFoo(api)
+	assert.Equal(http.StatusServiceUnavailable, resp.Code)
+	assert.Equal("1", resp.Header().Get("Retry-After"))
+	assert.Contains(resp.Body.String(), "foo is temporarily unavailable")
+	assert.Equal([]CircuitBreakerStats{{
+		Resource: "foo", State: CircuitOpen, Rejected: 1, Opened: 1,
+		LastStateChange: api.CircuitBreakerStats()[0].LastStateChange,
+	}}, api.CircuitBreakerStats())
+
+	// A failed probe reopens the circuit.
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(http.StatusInternalServerError, readFoo(api).Code)
+	assert.Equal(http.StatusServiceUnavailable, readFoo(api).Code)
+
+	handler.failing = false
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(http.StatusOK, readFoo(api).Code)
+	assert.Equal(http.StatusOK, readFoo(api).Code)
+
+	assert.Equal([]CircuitState{CircuitOpen, CircuitHalfOpen, CircuitOpen, CircuitHalfOpen,
+		CircuitClosed}, transitions)
+	stats := api.CircuitBreakerStats()[0]
+	assert.Equal(CircuitClosed, stats.State)
+	assert.Equal(int64(1), stats.Requests)
+	assert.Equal(int64(2), stats.Opened)
+}
+
+// Ensures that slow requests count as failures when a latency threshold is set.
+func TestCircuitBreakerLatencyThreshold(t *testing.T) {
+	assert := assert.New(t)
+	handler := &fragileResourceHandler{delay: 5 * time.Millisecond}
+	api := NewAPI(&Configuration{CircuitBreakers: map[string]CircuitBreaker{
+		"foo": {MinRequests: 1, LatencyThreshold: time.Millisecond},
+	}})
+	api.RegisterResourceHandler(handler)
+
+	assert.Equal(http.StatusOK, readFoo(api).Code)
+	assert.Equal(http.StatusServiceUnavailable, readFoo(api).Code)
+	assert.Equal("30", readFoo(api).Header().Get("Retry-After"))
+}
+
+// Ensures that the circuit only opens once the error threshold is reached and is only
+// attached to configured resources.
+func TestCircuitBreakerErrorThreshold(t *testing.T) {
+	assert := assert.New(t)
+	breaker := newCircuitBreaker("foo", CircuitBreaker{ErrorThreshold: 0.6, MinRequests: 4})
+	now := time.Now()
+
+	for _, failed := range []bool{true, false, true, false, true} {
+		ok, _ := breaker.allow(now)
+		assert.True(ok)
+		breaker.record(failed, now)
+	}
+	assert.Equal(CircuitOpen, breaker.snapshot().State)
+
+	// Failures outside the window aren't counted.
+	breaker = newCircuitBreaker("foo", CircuitBreaker{MinRequests: 2, Window: time.Second})
+	breaker.record(true, now)
+	breaker.record(true, now.Add(2*time.Second))
+	assert.Equal(CircuitClosed, breaker.snapshot().State)
+
+	api := NewAPI(&Configuration{CircuitBreakers: map[string]CircuitBreaker{"bar": {}}})
+	api.RegisterResourceHandler(&fragileResourceHandler{})
+	assert.Empty(api.CircuitBreakerStats())
+}
@@ -193,8 +193,8 @@ var do = func(c *http.Client, method, url string, body interface{}, header http.
 	}
 	defer resp.Body.Close()
 
-	// Don't try to decode the response on 404.
-	if resp.StatusCode == http.StatusNotFound {
+	// Don't try to decode the response on 404 or 429, which may come from a proxy.
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusTooManyRequests {
 		return &Response{
 			Status:   resp.StatusCode,
 			Reason:   http.StatusText(resp.StatusCode),
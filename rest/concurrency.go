@@ -0,0 +1,137 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultConcurrencyRetryAfter is the delay clients are asked to wait before retrying
+// requests shed by concurrency limits if one isn't configured.
+const defaultConcurrencyRetryAfter = time.Second
+
+// concurrencyLimiter tracks the in-flight requests of resources and operations using a
+// semaphore for each. It's safe for concurrent use.
+type concurrencyLimiter struct {
+	mu         sync.Mutex
+	semaphores map[string]chan struct{}
+}
+
+// newConcurrencyLimiter returns a newly allocated concurrencyLimiter.
+func newConcurrencyLimiter() *concurrencyLimiter {
+	return &concurrencyLimiter{semaphores: map[string]chan struct{}{}}
+}
+
+// semaphore returns the semaphore for the key, creating it with the limit if needed.
+func (c *concurrencyLimiter) semaphore(key string, limit int) chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sem, ok := c.semaphores[key]
+	if !ok {
+		sem = make(chan struct{}, limit)
+		c.semaphores[key] = sem
+	}
+	return sem
+}
+
+// acquire reserves an in-flight request for each of the keys with a limit, returning a
+// function which releases them. It returns false without reserving any if one of the
+// keys is at its limit.
+func (c *concurrencyLimiter) acquire(limits map[string]int) (func(), bool) {
+	acquired := make([]chan struct{}, 0, len(limits))
+	release := func() {
+		for _, sem := range acquired {
+			<-sem
+		}
+	}
+
+	for key, limit := range limits {
+		if limit <= 0 {
+			continue
+		}
+		sem := c.semaphore(key, limit)
+		select {
+		case sem <- struct{}{}:
+			acquired = append(acquired, sem)
+		default:
+			release()
+			return nil, false
+		}
+	}
+	return release, true
+}
+
+// concurrencyLimits returns the limits which apply to requests of the resource's route,
+// keyed by resource and route name. The resource's limit defaults to the configured
+// ConcurrencyLimit, while the route only has a limit if one is configured for it. If
+// the tenant isn't empty, the Tenancy's ConcurrencyLimit applies to the tenant's
+// requests to the resource.
+func concurrencyLimits(config *Configuration, resource, route, tenant string) map[string]int {
+	limits := map[string]int{resource: config.ConcurrencyLimit}
+	if limit, ok := config.ConcurrencyLimits[resource]; ok {
+		limits[resource] = limit
+	}
+	if limit, ok := config.ConcurrencyLimits[route]; ok && route != resource {
+		limits[route] = limit
+	}
+	if tenant != "" && config.Tenancy != nil {
+		limits["tenant:"+tenant+":"+resource] = config.Tenancy.ConcurrencyLimit
+	}
+	return limits
+}
+
+// concurrencyRetryAfter returns the delay clients are asked to wait before retrying
+// shed requests.
+func concurrencyRetryAfter(config *Configuration) time.Duration {
+	if config.ConcurrencyRetryAfter > 0 {
+		return config.ConcurrencyRetryAfter
+	}
+	return defaultConcurrencyRetryAfter
+}
+
+// newConcurrencyMiddleware returns a RequestMiddleware which limits the number of
+// in-flight requests to the resource and its operations. Requests exceeding a limit
+// are shed with 503 Service Unavailable and a Retry-After header rather than queueing,
+// so slow handlers can't exhaust the server and take down healthy resources.
+func newConcurrencyMiddleware(config *Configuration, limiter *concurrencyLimiter,
+	h *requestHandler, resource string) RequestMiddleware {
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			release, ok := limiter.acquire(concurrencyLimits(config, resource, routeName(r),
+				resolvedTenant(r)))
+			if !ok {
+				h.sendResponse(h.newContext(w, r).setError(ServiceUnavailable(
+					fmt.Sprintf("Too many concurrent requests for %s", resource)).
+					WithRetryAfter(concurrencyRetryAfter(config))))
+				return
+			}
+			defer release()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// concurrencyLimited returns true if the Configuration limits the in-flight requests
+// of any resource or operation.
+func concurrencyLimited(config *Configuration) bool {
+	return config.ConcurrencyLimit > 0 || len(config.ConcurrencyLimits) > 0 ||
+		config.Tenancy != nil && config.Tenancy.ConcurrencyLimit > 0
+}
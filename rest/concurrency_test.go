@@ -0,0 +1,129 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type blockingResourceHandler struct {
+	BaseResourceHandler
+	started chan bool
+	release chan bool
+}
+
+func (s *blockingResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (s *blockingResourceHandler) ReadResource(ctx RequestContext, id,
+	version string) (Resource, error) {
+
+	s.started <- true
+	<-s.release
+	return Payload{"id": id}, nil
+}
+
+func (s *blockingResourceHandler) ReadResourceList(ctx RequestContext, limit int,
+	cursor, version string) ([]Resource, string, error) {
+
+	return []Resource{}, "", nil
+}
+
+// serveBlockedRead starts a read request which blocks until the handler is released,
+// returning a WaitGroup which is done when it completes.
+func serveBlockedRead(api API, handler *blockingResourceHandler) *sync.WaitGroup {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		req, _ := http.NewRequest("GET", "http://example.com/api/v1/foo/1", nil)
+		api.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+	<-handler.started
+	return &wg
+}
+
+// Ensures that requests exceeding a resource's concurrency limit are shed with a 503
+// and Retry-After header until in-flight requests complete.
+func TestConcurrencyLimit(t *testing.T) {
+	assert := assert.New(t)
+	handler := &blockingResourceHandler{started: make(chan bool), release: make(chan bool)}
+	api := NewAPI(&Configuration{ConcurrencyLimit: 1, ConcurrencyRetryAfter: 1500 * time.Millisecond})
+	api.RegisterResourceHandler(handler)
+	wg := serveBlockedRead(api, handler)
+
+	req, _ := http.NewRequest("GET", "http://example.com/api/v1/foo", nil)
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusServiceUnavailable, resp.Code)
+	assert.Equal("2", resp.Header().Get("Retry-After"))
+	assert.Contains(resp.Body.String(), "Too many concurrent requests for foo")
+
+	handler.release <- true
+	wg.Wait()
+
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code)
+}
+
+// Ensures that operation concurrency limits only shed requests of the operation.
+func TestConcurrencyLimitOperation(t *testing.T) {
+	assert := assert.New(t)
+	handler := &blockingResourceHandler{started: make(chan bool), release: make(chan bool)}
+	api := NewAPI(&Configuration{ConcurrencyLimits: map[string]int{"foo:read": 1}})
+	api.RegisterResourceHandler(handler)
+	wg := serveBlockedRead(api, handler)
+	defer wg.Wait()
+	defer func() { handler.release <- true }()
+
+	req, _ := http.NewRequest("GET", "http://example.com/api/v1/foo", nil)
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code)
+
+	req, _ = http.NewRequest("GET", "http://example.com/api/v1/foo/2", nil)
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusServiceUnavailable, resp.Code)
+	assert.Equal("1", resp.Header().Get("Retry-After"))
+}
+
+// Ensures that concurrencyLimits resolves resource and operation limits.
+func TestConcurrencyLimits(t *testing.T) {
+	assert := assert.New(t)
+	config := &Configuration{
+		ConcurrencyLimit:  10,
+		ConcurrencyLimits: map[string]int{"bar": 0, "foo:create": 2},
+	}
+
+	assert.Equal(map[string]int{"foo": 10, "foo:create": 2},
+		concurrencyLimits(config, "foo", "foo:create", ""))
+	assert.Equal(map[string]int{"foo": 10}, concurrencyLimits(config, "foo", "foo:read", ""))
+	assert.Equal(map[string]int{"bar": 0}, concurrencyLimits(config, "bar", "bar:read", ""))
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// confirmKey is the name of the query string variable echoing a confirmation token.
+	confirmKey = "confirm"
+
+	// confirmHeader is the request header echoing a confirmation token.
+	confirmHeader = "X-Confirmation-Token"
+
+	// defaultConfirmationTTL is how long confirmation tokens are valid for if a TTL
+	// isn't configured.
+	defaultConfirmationTTL = 5 * time.Minute
+)
+
+// Confirmation is returned by the first call of a two-phase destructive operation. It
+// describes the impact of the operation and contains the token which must be echoed
+// back to proceed.
+type Confirmation struct {
+	Token   string    `json:"token"`
+	Expires time.Time `json:"expires"`
+	Impact  Resource  `json:"impact"`
+}
+
+// ConfirmedDeleteResourceHandler can be implemented by a ResourceHandler to require
+// two-phase confirmation of deletes. The first delete request responds with 428
+// Precondition Required and a Confirmation describing the impact of the delete. The
+// delete only proceeds when the Confirmation token is echoed back, using the "confirm"
+// query parameter or X-Confirmation-Token header, before it expires.
+type ConfirmedDeleteResourceHandler interface {
+	// DeleteImpact describes the impact of deleting the resource with the given ID,
+	// e.g. the number of dependent resources which would also be deleted. It returns an
+	// error if the resource can't be deleted.
+	DeleteImpact(RequestContext, string, string) (Resource, error)
+}
+
+// confirmationTTL returns how long confirmation tokens are valid for.
+func confirmationTTL(config *Configuration) time.Duration {
+	if config.ConfirmationTTL > 0 {
+		return config.ConfirmationTTL
+	}
+	return defaultConfirmationTTL
+}
+
+// confirmationTarget returns the target of the request's operation, which binds
+// confirmation tokens to the resource as well as the tenant and Identity of the client
+// they were issued to, so they can't be replayed by other clients.
+func confirmationTarget(ctx RequestContext, handler ResourceHandler) string {
+	identity := ""
+	if id := ctx.Identity(); id != nil {
+		identity = id.ID
+	}
+	return ctx.Tenant() + "\n" + identity + "\n" + handler.ResourceName() + "/" +
+		ctx.ResourceID()
+}
+
+// confirmationToken returns a token confirming the operation on the target which
+// expires at the given time. The token is signed with the secret so it can't be forged.
+func confirmationToken(secret []byte, operation, target string, expires time.Time) string {
+	expiresStr := strconv.FormatInt(expires.Unix(), 10)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(operation + "\n" + target + "\n" + expiresStr))
+	return expiresStr + "." + hex.EncodeToString(mac.Sum(nil))
+}
+
+// validConfirmationToken returns true if the token confirms the operation on the target
+// and hasn't expired.
+func validConfirmationToken(secret []byte, token, operation, target string, now time.Time) bool {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 {
+		return false
+	}
+	expiresUnix, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return false
+	}
+	expires := time.Unix(expiresUnix, 0)
+	if now.After(expires) {
+		return false
+	}
+	expected := confirmationToken(secret, operation, target, expires)
+	return hmac.Equal([]byte(token), []byte(expected))
+}
+
+// requestConfirmationToken returns the confirmation token echoed back by the client.
+func requestConfirmationToken(ctx RequestContext) string {
+	if token, ok := ctx.Value(confirmKey).(string); ok && token != "" {
+		return token
+	}
+	return ctx.Header().Get(confirmHeader)
+}
+
+// confirmDelete implements the two-phase confirmation of deletes. If the request
+// carries a valid confirmation token, true is returned and the delete can proceed.
+// Otherwise, the RequestContext is set up with either a Confirmation or an error to be
+// sent to the client and false is returned.
+func (h requestHandler) confirmDelete(ctx RequestContext, handler ResourceHandler,
+	confirmer ConfirmedDeleteResourceHandler) (RequestContext, bool) {
+
+	secret := h.confirmationSecret()
+	target := confirmationTarget(ctx, handler)
+	now := time.Now()
+
+	if token := requestConfirmationToken(ctx); token != "" {
+		if validConfirmationToken(secret, token, string(HandleDelete), target, now) {
+			return ctx, true
+		}
+		return ctx.setError(CustomError(
+			"Confirmation token is invalid or expired", http.StatusPreconditionFailed)), false
+	}
+
+	impact, err := confirmer.DeleteImpact(ctx, ctx.ResourceID(), ctx.Version())
+	if err != nil {
+		return ctx.setError(err), false
+	}
+
+	expires := now.Add(confirmationTTL(h.Configuration())).Truncate(time.Second)
+	ctx = ctx.setResult(&Confirmation{
+		Token:   confirmationToken(secret, string(HandleDelete), target, expires),
+		Expires: expires.UTC(),
+		Impact:  impact,
+	})
+	ctx.AddMessage(fmt.Sprintf("Repeat the request with the %s parameter to confirm", confirmKey))
+	return ctx.setStatus(http.StatusPreconditionRequired), false
+}
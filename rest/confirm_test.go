@@ -0,0 +1,142 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type confirmedDeleteResourceHandler struct {
+	BaseResourceHandler
+	deleted []string
+}
+
+func (c *confirmedDeleteResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (c *confirmedDeleteResourceHandler) DeleteImpact(ctx RequestContext, id,
+	version string) (Resource, error) {
+
+	return Payload{"children": 3}, nil
+}
+
+func (c *confirmedDeleteResourceHandler) DeleteResource(ctx RequestContext, id,
+	version string) (Resource, error) {
+
+	c.deleted = append(c.deleted, id)
+	return nil, nil
+}
+
+// Ensures that deletes requiring confirmation only proceed when a valid token is
+// echoed back.
+func TestHandleDeleteConfirmation(t *testing.T) {
+	assert := assert.New(t)
+	handler := &confirmedDeleteResourceHandler{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+
+	req, _ := http.NewRequest("DELETE", "http://foo.com/api/v1/foo/1", nil)
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusPreconditionRequired, resp.Code, "Incorrect response code")
+	assert.Empty(handler.deleted)
+	var body struct {
+		Result Confirmation `json:"result"`
+	}
+	assert.Nil(json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.Equal(map[string]interface{}{"children": float64(3)}, body.Result.Impact)
+	assert.NotEmpty(body.Result.Token)
+
+	req, _ = http.NewRequest("DELETE", "http://foo.com/api/v1/foo/2", nil)
+	req.Header.Set(confirmHeader, body.Result.Token)
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusPreconditionFailed, resp.Code, "Token is for another resource")
+	assert.Empty(handler.deleted)
+
+	req, _ = http.NewRequest("DELETE", "http://foo.com/api/v1/foo/1?confirm="+body.Result.Token, nil)
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal([]string{"1"}, handler.deleted)
+}
+
+type identityConfirmedDeleteResourceHandler struct {
+	confirmedDeleteResourceHandler
+}
+
+func (i *identityConfirmedDeleteResourceHandler) Authenticate(r *http.Request) error {
+	SetIdentity(r, &Identity{ID: r.Header.Get("X-User")})
+	return nil
+}
+
+// Ensures that confirmation tokens are only valid for the tenant and Identity they were
+// issued to.
+func TestHandleDeleteConfirmationClient(t *testing.T) {
+	assert := assert.New(t)
+	handler := &identityConfirmedDeleteResourceHandler{}
+	api := NewAPI(&Configuration{Tenancy: &Tenancy{Source: TenantHeader}})
+	api.RegisterResourceHandler(handler)
+	send := func(tenant, user, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("DELETE", "http://foo.com/api/v1/foo/1", nil)
+		req.Header.Set("X-Tenant-ID", tenant)
+		req.Header.Set("X-User", user)
+		req.Header.Set(confirmHeader, token)
+		resp := httptest.NewRecorder()
+		api.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := send("a", "alice", "")
+	assert.Equal(http.StatusPreconditionRequired, resp.Code)
+	var body struct {
+		Result Confirmation `json:"result"`
+	}
+	assert.Nil(json.Unmarshal(resp.Body.Bytes(), &body))
+
+	assert.Equal(http.StatusPreconditionFailed, send("a", "bob", body.Result.Token).Code)
+	assert.Equal(http.StatusPreconditionFailed, send("b", "alice", body.Result.Token).Code)
+	assert.Empty(handler.deleted)
+
+	assert.Equal(http.StatusOK, send("a", "alice", body.Result.Token).Code)
+	assert.Equal([]string{"1"}, handler.deleted)
+}
+
+// Ensures that confirmation tokens are only valid for their operation, target, and TTL.
+func TestValidConfirmationToken(t *testing.T) {
+	assert := assert.New(t)
+	secret := []byte("secret")
+	now := time.Now()
+	token := confirmationToken(secret, "delete", "foo/1", now.Add(time.Minute))
+
+	assert.True(validConfirmationToken(secret, token, "delete", "foo/1", now))
+	assert.False(validConfirmationToken(secret, token, "delete", "foo/2", now))
+	assert.False(validConfirmationToken(secret, token, "update", "foo/1", now))
+	assert.False(validConfirmationToken([]byte("other"), token, "delete", "foo/1", now))
+	assert.False(validConfirmationToken(secret, token, "delete", "foo/1", now.Add(2*time.Minute)))
+	assert.False(validConfirmationToken(secret, "garbage", "delete", "foo/1", now))
+}
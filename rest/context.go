@@ -20,9 +20,13 @@ import (
 	"bytes"
 	"fmt"
 	"io/ioutil"
+	"mime"
+	"net"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	gcontext "github.com/gorilla/context"
 	"github.com/gorilla/mux"
@@ -45,10 +49,37 @@ const (
 	// limitKey is the name of the query string variable for the results limit.
 	limitKey = "limit"
 
+	// pageKey is the name of the query string variable for the results page when using
+	// OffsetPagination.
+	pageKey = "page"
+
+	// perPageKey is the name of the query string variable for the number of results per
+	// page when using OffsetPagination.
+	perPageKey = "per_page"
+
+	// dryRunKey is the name of the query string variable requesting a dry run.
+	dryRunKey = "dry_run"
+
 	requestKey int = iota
 	statusKey
 	errorKey
 	resultKey
+	paginationKey
+	totalCountKey
+	limitsKey
+	configurationKey
+	preImageKey
+	resourceErrorsKey
+	deprecationWarningsKey
+	responseStatusKey
+	responseHeaderKey
+	duplicateEntryKey
+	cacheEntryKey
+	routeVarsKey
+	tenantKey
+	identityKey
+	requestCursorKey
+	requestTimingKey
 )
 
 // RequestContext contains the context information for the current HTTP request. It's a wrapper
@@ -85,18 +116,42 @@ type RequestContext interface {
 	// All URL variables should be named in the vars map.
 	BuildURL(resourceName string, method HandleMethod, vars RouteVars) (*url.URL, error)
 
-	// ResponseFormat returns the response format for the request, defaulting to "json" if
-	// one is not specified using the "format" query parameter.
+	// ResponseFormat returns the response format for the request, defaulting to the
+	// configured DefaultFormat, or "json", if one is not specified using the "format"
+	// query parameter.
 	ResponseFormat() string
 
 	// ResourceID returns the resource id for the request, defaulting to an empty string if
 	// there isn't one.
 	ResourceID() string
 
-	// Version returns the API version for the request, defaulting to an empty string if
-	// one is not specified in the request path.
+	// Version returns the API version for the request, defaulting to the configured
+	// DefaultVersion if one is not specified in the request path, VersionHeader, or
+	// Accept header.
 	Version() string
 
+	// SemanticVersion returns the API version for the request parsed as a
+	// SemanticVersion, or false if it isn't one.
+	SemanticVersion() (SemanticVersion, bool)
+
+	// VersionIn returns true if the API version for the request is in the version
+	// range, e.g. ">=1.2 <2", so handlers can branch on versions without comparing
+	// strings. It returns false if the version or range is invalid.
+	VersionIn(versionRange string) bool
+
+	// Tenant returns the tenant of the request identified with the Configuration's
+	// Tenancy, or an empty string if tenancy isn't configured.
+	Tenant() string
+
+	// Identity returns the authenticated Identity attached to the request with
+	// SetIdentity, or nil if there isn't one.
+	Identity() *Identity
+
+	// CheckTenant returns a 404 Not Found error if the tenant, e.g. the owner of a
+	// resource looked up by ID, isn't the request's tenant. Handlers use it to reject
+	// IDs belonging to other tenants without revealing that they exist.
+	CheckTenant(tenant string) error
+
 	// Status returns the current HTTP status code that will be returned for the request,
 	// defaulting to 200 if one hasn't been set yet.
 	Status() int
@@ -104,6 +159,13 @@ type RequestContext interface {
 	// setStatus sets the HTTP status code to be returned for the request.
 	setStatus(int) RequestContext
 
+	// SetResponseStatus sets the HTTP status code returned for a successful request,
+	// overriding the default for the operation, e.g. 202 Accepted for work which
+	// completes later, 200 instead of 201 for a create which updated an existing
+	// resource, or 206 Partial Content for partial results. It has no effect if the
+	// request fails.
+	SetResponseStatus(int)
+
 	// Error returns the current error for the request or nil if no errors have been set.
 	Error() error
 
@@ -123,9 +185,98 @@ type RequestContext interface {
 	// setCursor sets the current result cursor for the request.
 	setCursor(string) RequestContext
 
-	// Limit returns the maximum number of results that should be fetched.
+	// Limit returns the maximum number of results that should be fetched. This is
+	// the "limit" query parameter clamped to the maximum limit, defaulting to the
+	// configured default limit.
 	Limit() int
 
+	// setLimits sets the default and maximum limits for the request.
+	setLimits(listLimits) RequestContext
+
+	// limitExceeded returns true if the client requested more results than the
+	// maximum limit allows.
+	limitExceeded() bool
+
+	// Pagination returns the PaginationStrategy used for the request, defaulting to
+	// CursorPagination.
+	Pagination() PaginationStrategy
+
+	// setPagination sets the PaginationStrategy used for the request.
+	setPagination(PaginationStrategy) RequestContext
+
+	// Page returns the requested page of results when using OffsetPagination,
+	// defaulting to 1 if one is not specified using the "page" query parameter.
+	Page() int
+
+	// PerPage returns the number of results per page when using OffsetPagination,
+	// defaulting to Limit if one is not specified using the "per_page" query parameter.
+	PerPage() int
+
+	// Offset returns the number of results preceding the requested page when using
+	// OffsetPagination.
+	Offset() int
+
+	// TotalCount returns the total number of results set by the request handler, if
+	// any.
+	TotalCount() (int, bool)
+
+	// SetTotalCount sets the total number of results available for the request. When
+	// using OffsetPagination, this is included in the response and used to determine
+	// whether there is a next page.
+	SetTotalCount(int)
+
+	// PrevURL returns the URL to use to request the previous page of results when using
+	// OffsetPagination. If there is no previous page or the URL fails to be built, an
+	// empty string is returned with the error set.
+	PrevURL() (string, error)
+
+	// SelfURL returns the URL of the current request. If the URL fails to be built, an
+	// empty string is returned with the error set.
+	SelfURL() (string, error)
+
+	// PreImage returns the resource as it existed before the request modified it. This
+	// is only available for update and delete requests to ResourceHandlers which
+	// implement ReadBeforeWriteResourceHandler.
+	PreImage() (Resource, bool)
+
+	// setPreImage sets the pre-image of the resource for the request.
+	setPreImage(Resource) RequestContext
+
+	// Schema returns the name of the request payload schema specified by the "schema"
+	// parameter of the request Content-Type, e.g. "foo.v2" for
+	// "application/json; schema=foo.v2", or an empty string if there isn't one.
+	Schema() string
+
+	// DryRun returns true if the client requested that a mutation be validated and
+	// reported without being persisted, using the "dry_run" query parameter or the
+	// "Prefer: handling=dry-run" header.
+	DryRun() bool
+
+	// Preconditions returns the parsed conditional headers of the request, i.e.
+	// If-Match, If-None-Match, If-Modified-Since, and If-Unmodified-Since.
+	Preconditions() Preconditions
+
+	// CheckPreconditions sets the ETag and Last-Modified response headers for the
+	// current resource, if given, and evaluates the request's Preconditions against
+	// them. A 304 Not Modified or 412 Precondition Failed Error is returned if they
+	// fail, which can be returned by the ResourceHandler as is.
+	CheckPreconditions(etag string, modified time.Time) error
+
+	// setResourceErrors sets the errors for individual resources, keyed by ID, which
+	// failed in a request operating on multiple resources.
+	setResourceErrors(map[string]error) RequestContext
+
+	// resourceErrors returns the errors for individual resources, keyed by ID, which
+	// failed in a request operating on multiple resources.
+	resourceErrors() map[string]error
+
+	// setConfiguration sets the API Configuration for the request.
+	setConfiguration(*Configuration) RequestContext
+
+	// configuration returns the API Configuration for the request, defaulting to an
+	// empty Configuration if one hasn't been set.
+	configuration() *Configuration
+
 	// Messages returns all of the messages set by the request handler to be included in
 	// the response.
 	Messages() []string
@@ -136,6 +287,48 @@ type RequestContext interface {
 	// Header returns the header key-value pairs for the request.
 	Header() http.Header
 
+	// Query returns the first value of the query string parameter, or an empty string if
+	// it isn't set.
+	Query(string) string
+
+	// QueryInt returns the query string parameter as an int, or the default if it isn't
+	// set. A BadRequest Error is returned if it isn't an integer.
+	QueryInt(string, int) (int, error)
+
+	// QueryBool returns the query string parameter as a bool, or the default if it isn't
+	// set. A BadRequest Error is returned if it isn't a boolean.
+	QueryBool(string, bool) (bool, error)
+
+	// QueryTime returns the query string parameter as a time, or the default if it isn't
+	// set. A BadRequest Error is returned if it isn't an RFC 3339 timestamp.
+	QueryTime(string, time.Time) (time.Time, error)
+
+	// Cookie returns the named cookie sent with the request, if any.
+	Cookie(string) (*http.Cookie, bool)
+
+	// PathVar returns the named variable of the request path, e.g. "resource_id", if
+	// the matched route has one.
+	PathVar(string) (string, bool)
+
+	// Files returns the files uploaded in the named form field of a multipart/form-data
+	// request.
+	Files(string) []*UploadedFile
+
+	// File returns the first file uploaded in the named form field of a
+	// multipart/form-data request, if any.
+	File(string) (*UploadedFile, bool)
+
+	// SetHeader sets the response header entry associated with the key to the value,
+	// replacing any existing values. Headers are written with the response, including
+	// error responses, and take precedence over those set by the framework.
+	SetHeader(string, string)
+
+	// AddHeader adds the value to the response header entry associated with the key.
+	AddHeader(string, string)
+
+	// responseHeader returns the response headers set with SetHeader and AddHeader.
+	responseHeader() http.Header
+
 	// Body returns a buffer containing the raw body of the request.
 	Body() *bytes.Buffer
 
@@ -151,7 +344,7 @@ type gorillaRequestContext struct {
 	req      *http.Request
 	body     *bytes.Buffer
 	writer   http.ResponseWriter
-	router   *mux.Router
+	router   Router
 	messages []string
 }
 
@@ -176,12 +369,13 @@ func NewContext(parent context.Context, req *http.Request, writer http.ResponseW
 		gcontext.Set(req, key, val)
 	}
 
-	for key, value := range mux.Vars(req) {
+	for key, value := range pathVars(req) {
 		gcontext.Set(req, key, value)
 	}
 
+	// Multipart bodies are left unread so uploaded files can be streamed.
 	var body []byte
-	if req.Body != nil {
+	if req.Body != nil && !isMultipart(req.Header) {
 		bytes, err := ioutil.ReadAll(req.Body)
 		if err == nil {
 			body = bytes
@@ -198,6 +392,17 @@ func NewContext(parent context.Context, req *http.Request, writer http.ResponseW
 func NewContextWithRouter(parent context.Context, req *http.Request, writer http.ResponseWriter,
 	router *mux.Router) RequestContext {
 
+	if router == nil {
+		return NewContext(parent, req, writer)
+	}
+	return newContextWithRouter(parent, req, writer, NewGorillaRouter(router))
+}
+
+// newContextWithRouter returns a RequestContext populated with parameters from the
+// request path and query string which builds URLs with the Router.
+func newContextWithRouter(parent context.Context, req *http.Request, writer http.ResponseWriter,
+	router Router) RequestContext {
+
 	context := NewContext(parent, req, writer)
 	context.(*gorillaRequestContext).router = router
 	return context
@@ -236,9 +441,13 @@ func (ctx *gorillaRequestContext) ValueWithDefault(key, defaultVal interface{})
 	return value
 }
 
-// ResponseFormat returns the response format for the request, defaulting to "json"
-// if one is not specified using the "format" query parameter.
+// ResponseFormat returns the response format for the request, defaulting to the
+// configured DefaultFormat, or "json", if one is not specified using the "format"
+// query parameter.
 func (ctx *gorillaRequestContext) ResponseFormat() string {
+	if format := ctx.configuration().DefaultFormat; format != "" {
+		return ctx.ValueWithDefault(formatKey, format).(string)
+	}
 	return ctx.ValueWithDefault(formatKey, "json").(string)
 }
 
@@ -248,18 +457,73 @@ func (ctx *gorillaRequestContext) ResourceID() string {
 	return ctx.ValueWithDefault(resourceIDKey, "").(string)
 }
 
-// Version returns the API version for the request, defaulting to an empty string
-// if one is not specified in the request path.
+// Version returns the API version for the request, defaulting to the configured
+// DefaultVersion if one is not specified in the request path, VersionHeader, or
+// Accept header.
 func (ctx *gorillaRequestContext) Version() string {
-	return ctx.ValueWithDefault(versionKey, "").(string)
+	if version := ctx.ValueWithDefault(versionKey, "").(string); version != "" {
+		return version
+	}
+	return headerVersion(ctx.Header(), ctx.configuration())
+}
+
+// SemanticVersion returns the API version for the request parsed as a SemanticVersion,
+// or false if it isn't one.
+func (ctx *gorillaRequestContext) SemanticVersion() (SemanticVersion, bool) {
+	version, err := ParseSemanticVersion(ctx.Version())
+	return version, err == nil
+}
+
+// VersionIn returns true if the API version for the request is in the version range,
+// e.g. ">=1.2 <2", so handlers can branch on versions without comparing strings. It
+// returns false if the version or range is invalid.
+func (ctx *gorillaRequestContext) VersionIn(versionRange string) bool {
+	r, ok := cachedVersionRange(versionRange)
+	return ok && r.Contains(ctx.Version())
+}
+
+// Tenant returns the tenant of the request identified with the Configuration's
+// Tenancy, or an empty string if tenancy isn't configured.
+func (ctx *gorillaRequestContext) Tenant() string {
+	tenant, _ := ctx.Value(tenantKey).(string)
+	return tenant
+}
+
+// Identity returns the authenticated Identity attached to the request with SetIdentity,
+// or nil if there isn't one.
+func (ctx *gorillaRequestContext) Identity() *Identity {
+	identity, _ := ctx.Value(identityKey).(*Identity)
+	return identity
+}
+
+// CheckTenant returns a 404 Not Found error if the tenant isn't the request's tenant.
+func (ctx *gorillaRequestContext) CheckTenant(tenant string) error {
+	if tenant != ctx.Tenant() {
+		return ResourceNotFound("Resource not found")
+	}
+	return nil
 }
 
 // Status returns the current HTTP status code that will be returned for the request,
-// defaulting to 200 if one hasn't been set yet.
+// defaulting to 200 if one hasn't been set yet. A status set by the handler with
+// SetResponseStatus takes precedence.
 func (ctx *gorillaRequestContext) Status() int {
+	if status, ok := ctx.Value(responseStatusKey).(int); ok {
+		return status
+	}
 	return ctx.ValueWithDefault(statusKey, http.StatusOK).(int)
 }
 
+// SetResponseStatus sets the HTTP status code returned for a successful request,
+// overriding the default for the operation. It has no effect if the request fails.
+func (ctx *gorillaRequestContext) SetResponseStatus(status int) {
+	// The status is stored on the request so that it's visible to the framework
+	// regardless of which derived context the handler sets it on.
+	if r, ok := ctx.Request(); ok {
+		gcontext.Set(r, responseStatusKey, status)
+	}
+}
+
 // setStatus sets the HTTP status code to be returned for the request.
 func (ctx *gorillaRequestContext) setStatus(status int) RequestContext {
 	return ctx.WithValue(statusKey, status)
@@ -294,12 +558,22 @@ func (ctx *gorillaRequestContext) setResult(result interface{}) RequestContext {
 // Cursor returns the current result cursor for the request, defaulting to an empty
 // string if one hasn't been set.
 func (ctx *gorillaRequestContext) Cursor() string {
+	// Cursors decoded with the configured CursorCodec and result cursors take
+	// precedence over the query string.
+	if cursor, ok := ctx.Value(requestCursorKey).(string); ok {
+		return cursor
+	}
 	return ctx.ValueWithDefault(cursorKey, "").(string)
 }
 
 // setCursor sets the current result cursor for the request.
 func (ctx *gorillaRequestContext) setCursor(cursor string) RequestContext {
-	return ctx.WithValue(cursorKey, cursor)
+	// The cursor requested with the query string is stored on the request, which takes
+	// precedence over context values, so the result cursor replaces it there.
+	if r, ok := ctx.Request(); ok {
+		gcontext.Set(r, requestCursorKey, cursor)
+	}
+	return ctx
 }
 
 // Header returns the header key-value pairs for the request.
@@ -312,6 +586,124 @@ func (ctx *gorillaRequestContext) Header() http.Header {
 	return req.Header
 }
 
+// Query returns the first value of the query string parameter, or an empty string if it
+// isn't set.
+func (ctx *gorillaRequestContext) Query(key string) string {
+	req, ok := ctx.Request()
+	if !ok {
+		return ""
+	}
+	return req.URL.Query().Get(key)
+}
+
+// QueryInt returns the query string parameter as an int, or the default if it isn't
+// set. A BadRequest Error is returned if it isn't an integer.
+func (ctx *gorillaRequestContext) QueryInt(key string, defaultValue int) (int, error) {
+	valueStr := ctx.Query(key)
+	if valueStr == "" {
+		return defaultValue, nil
+	}
+	value, err := strconv.Atoi(valueStr)
+	if err != nil {
+		return defaultValue, BadRequest(fmt.Sprintf("Query parameter %s must be an integer", key))
+	}
+	return value, nil
+}
+
+// QueryBool returns the query string parameter as a bool, or the default if it isn't
+// set. A BadRequest Error is returned if it isn't a boolean.
+func (ctx *gorillaRequestContext) QueryBool(key string, defaultValue bool) (bool, error) {
+	valueStr := ctx.Query(key)
+	if valueStr == "" {
+		return defaultValue, nil
+	}
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		return defaultValue, BadRequest(fmt.Sprintf("Query parameter %s must be a boolean", key))
+	}
+	return value, nil
+}
+
+// QueryTime returns the query string parameter as a time, or the default if it isn't
+// set. A BadRequest Error is returned if it isn't an RFC 3339 timestamp.
+func (ctx *gorillaRequestContext) QueryTime(key string, defaultValue time.Time) (time.Time, error) {
+	valueStr := ctx.Query(key)
+	if valueStr == "" {
+		return defaultValue, nil
+	}
+	value, err := time.Parse(time.RFC3339, valueStr)
+	if err != nil {
+		return defaultValue, BadRequest(
+			fmt.Sprintf("Query parameter %s must be an RFC 3339 timestamp", key))
+	}
+	return value, nil
+}
+
+// Cookie returns the named cookie sent with the request, if any.
+func (ctx *gorillaRequestContext) Cookie(name string) (*http.Cookie, bool) {
+	req, ok := ctx.Request()
+	if !ok {
+		return nil, false
+	}
+	cookie, err := req.Cookie(name)
+	return cookie, err == nil
+}
+
+// PathVar returns the named variable of the request path, if the matched route has one.
+func (ctx *gorillaRequestContext) PathVar(name string) (string, bool) {
+	req, ok := ctx.Request()
+	if !ok {
+		return "", false
+	}
+	value, ok := pathVars(req)[name]
+	return value, ok
+}
+
+// Files returns the files uploaded in the named form field of a multipart/form-data
+// request.
+func (ctx *gorillaRequestContext) Files(field string) []*UploadedFile {
+	req, ok := ctx.Request()
+	if !ok {
+		return nil
+	}
+	return uploadedFiles(req, field)
+}
+
+// File returns the first file uploaded in the named form field of a
+// multipart/form-data request, if any.
+func (ctx *gorillaRequestContext) File(field string) (*UploadedFile, bool) {
+	files := ctx.Files(field)
+	if len(files) == 0 {
+		return nil, false
+	}
+	return files[0], true
+}
+
+// SetHeader sets the response header entry associated with the key to the value,
+// replacing any existing values.
+func (ctx *gorillaRequestContext) SetHeader(key, value string) {
+	ctx.responseHeader().Set(key, value)
+}
+
+// AddHeader adds the value to the response header entry associated with the key.
+func (ctx *gorillaRequestContext) AddHeader(key, value string) {
+	ctx.responseHeader().Add(key, value)
+}
+
+// responseHeader returns the response headers set with SetHeader and AddHeader.
+func (ctx *gorillaRequestContext) responseHeader() http.Header {
+	if header, ok := ctx.Value(responseHeaderKey).(http.Header); ok {
+		return header
+	}
+	header := http.Header{}
+	// The headers are stored on the request so that they're visible to the framework
+	// regardless of which derived context the handler sets them on.
+	if r, ok := ctx.Request(); ok {
+		gcontext.Set(r, responseHeaderKey, header)
+	}
+	return header
+}
+
 // Body returns a buffer containing the raw body of the request.
 func (ctx *gorillaRequestContext) Body() *bytes.Buffer {
 	return ctx.body
@@ -326,48 +718,384 @@ func (ctx *gorillaRequestContext) Request() (*http.Request, bool) {
 	return req, ok
 }
 
-// Limit returns the maximum number of results that should be fetched.
+// Limit returns the maximum number of results that should be fetched. This is the
+// "limit" query parameter clamped to the maximum limit, defaulting to the configured
+// default limit.
 func (ctx *gorillaRequestContext) Limit() int {
-	limitStr := ctx.ValueWithDefault(limitKey, "100")
-	limit, err := strconv.Atoi(limitStr.(string))
+	limits := ctx.limits()
+	limit, ok := ctx.requestedInt(limitKey)
+	if !ok {
+		return limits.defaultLimit
+	}
+	return limits.clamp(limit)
+}
+
+// setLimits sets the default and maximum limits for the request.
+func (ctx *gorillaRequestContext) setLimits(limits listLimits) RequestContext {
+	return ctx.WithValue(limitsKey, limits)
+}
+
+// limits returns the default and maximum limits for the request.
+func (ctx *gorillaRequestContext) limits() listLimits {
+	if limits, ok := ctx.Value(limitsKey).(listLimits); ok {
+		return limits
+	}
+	return listLimits{defaultLimit: defaultLimit}
+}
+
+// limitExceeded returns true if the client requested more results than the maximum
+// limit allows.
+func (ctx *gorillaRequestContext) limitExceeded() bool {
+	limits := ctx.limits()
+	if limits.maxLimit <= 0 {
+		return false
+	}
+
+	key := limitKey
+	if ctx.Pagination() == OffsetPagination {
+		key = perPageKey
+	}
+	requested, ok := ctx.requestedInt(key)
+	return ok && requested > limits.maxLimit
+}
+
+// requestedInt returns the integer value of the given query string variable. If the
+// variable isn't set or isn't an integer, false is returned.
+func (ctx *gorillaRequestContext) requestedInt(key string) (int, bool) {
+	str, ok := ctx.Value(key).(string)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(str)
 	if err != nil {
-		limit = 100
+		return 0, false
+	}
+	return n, true
+}
+
+// Pagination returns the PaginationStrategy used for the request, defaulting to
+// CursorPagination.
+func (ctx *gorillaRequestContext) Pagination() PaginationStrategy {
+	return ctx.ValueWithDefault(paginationKey, CursorPagination).(PaginationStrategy)
+}
+
+// setPagination sets the PaginationStrategy used for the request.
+func (ctx *gorillaRequestContext) setPagination(strategy PaginationStrategy) RequestContext {
+	return ctx.WithValue(paginationKey, strategy)
+}
+
+// Page returns the requested page of results when using OffsetPagination, defaulting
+// to 1 if one is not specified using the "page" query parameter.
+func (ctx *gorillaRequestContext) Page() int {
+	pageStr, ok := ctx.ValueWithDefault(pageKey, "1").(string)
+	if !ok {
+		return 1
+	}
+	p, err := strconv.Atoi(pageStr)
+	if err != nil || p < 1 {
+		p = 1
+	}
+	return p
+}
+
+// PerPage returns the number of results per page when using OffsetPagination,
+// defaulting to Limit if one is not specified using the "per_page" query parameter.
+func (ctx *gorillaRequestContext) PerPage() int {
+	n, ok := ctx.requestedInt(perPageKey)
+	if !ok || n < 1 {
+		return ctx.Limit()
+	}
+	return ctx.limits().clamp(n)
+}
+
+// Offset returns the number of results preceding the requested page when using
+// OffsetPagination.
+func (ctx *gorillaRequestContext) Offset() int {
+	return (ctx.Page() - 1) * ctx.PerPage()
+}
+
+// TotalCount returns the total number of results set by the request handler, if any.
+func (ctx *gorillaRequestContext) TotalCount() (int, bool) {
+	count, ok := ctx.Value(totalCountKey).(int)
+	return count, ok
+}
+
+// SetTotalCount sets the total number of results available for the request. When using
+// OffsetPagination, this is included in the response and used to determine whether
+// there is a next page.
+func (ctx *gorillaRequestContext) SetTotalCount(count int) {
+	// The count is stored on the request so that it's visible to the framework
+	// regardless of which derived context the handler sets it on.
+	if r, ok := ctx.Request(); ok {
+		gcontext.Set(r, totalCountKey, count)
 	}
-	return limit
 }
 
 // NextURL returns the URL to use to request the next page of results using the current
 // cursor. If there is no cursor for this request or the URL fails to be built, an empty
-// string is returned with the error set.
+// string is returned with the error set. When using OffsetPagination, the URL points
+// to the next page if the total count is known and there are more results.
 func (ctx *gorillaRequestContext) NextURL() (string, error) {
+	if ctx.Pagination() == OffsetPagination {
+		total, ok := ctx.TotalCount()
+		if !ok {
+			return "", fmt.Errorf("Unable to build next url: no total count")
+		}
+		if ctx.Page()*ctx.PerPage() >= total {
+			return "", fmt.Errorf("Unable to build next url: no more results")
+		}
+		return ctx.pageURL(ctx.Page()+1, "next")
+	}
+
 	cursor := ctx.Cursor()
 	if cursor == "" {
 		return "", fmt.Errorf("Unable to build next url: no cursor")
 	}
 
-	r, ok := ctx.Request()
-	if !ok {
-		return "", fmt.Errorf("Unable to build next url: no request")
+	u, err := ctx.requestURL("next")
+	if err != nil {
+		return "", err
+	}
+	r, _ := ctx.Request()
+	if cursor, err = encodeCursor(ctx.configuration(), r, cursor); err != nil {
+		return "", err
 	}
 
-	var scheme string
-	scheme = r.URL.Scheme
-	if scheme == "" {
-		scheme = "http"
+	q := u.Query()
+	q.Set(cursorKey, cursor)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// PrevURL returns the URL to use to request the previous page of results when using
+// OffsetPagination. If there is no previous page or the URL fails to be built, an
+// empty string is returned with the error set.
+func (ctx *gorillaRequestContext) PrevURL() (string, error) {
+	if ctx.Pagination() != OffsetPagination {
+		return "", fmt.Errorf("Unable to build prev url: not using offset pagination")
+	}
+	if ctx.Page() <= 1 {
+		return "", fmt.Errorf("Unable to build prev url: no previous page")
 	}
+	return ctx.pageURL(ctx.Page()-1, "prev")
+}
 
-	urlStr := fmt.Sprintf("%s://%s%s", scheme, r.Host, r.RequestURI)
-	u, err := url.Parse(urlStr)
+// SelfURL returns the URL of the current request. If the URL fails to be built, an
+// empty string is returned with the error set.
+func (ctx *gorillaRequestContext) SelfURL() (string, error) {
+	u, err := ctx.requestURL("self")
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// pageURL returns the URL of the current request with the page and per_page query
+// parameters set for OffsetPagination. The name is used to describe the URL in errors.
+func (ctx *gorillaRequestContext) pageURL(page int, name string) (string, error) {
+	u, err := ctx.requestURL(name)
 	if err != nil {
-		return "", fmt.Errorf("Unable to build next url: %s", urlStr)
+		return "", err
 	}
 
 	q := u.Query()
-	q.Set("next", cursor)
+	q.Set(pageKey, strconv.Itoa(page))
+	q.Set(perPageKey, strconv.Itoa(ctx.PerPage()))
 	u.RawQuery = q.Encode()
 	return u.String(), nil
 }
 
+// requestURL returns the URL of the current request as seen by the client. The name is
+// used to describe the URL in errors.
+func (ctx *gorillaRequestContext) requestURL(name string) (*url.URL, error) {
+	r, ok := ctx.Request()
+	if !ok {
+		return nil, fmt.Errorf("Unable to build %s url: no request", name)
+	}
+
+	// RequestURI contains the path and query as sent by the client, which may differ
+	// from the URL if it was rewritten by middleware.
+	requestURI := r.RequestURI
+	if requestURI == "" {
+		requestURI = r.URL.RequestURI()
+	}
+
+	base := ctx.baseURL(r)
+	urlStr := fmt.Sprintf("%s://%s%s%s", base.Scheme, base.Host, base.Path, requestURI)
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to build %s url: %s", name, urlStr)
+	}
+
+	return u, nil
+}
+
+// baseURL returns the scheme, host, and path prefix clients use to reach the API. This
+// is the configured external base URL, if any. Otherwise, it's derived from the request,
+// honoring forwarded headers set by trusted proxies.
+func (ctx *gorillaRequestContext) baseURL(r *http.Request) *url.URL {
+	config := ctx.configuration()
+	if config.ExternalBaseURL != "" {
+		if base, err := url.Parse(config.ExternalBaseURL); err == nil && base.Host != "" {
+			base.Path = strings.TrimSuffix(base.Path, "/")
+			return base
+		}
+	}
+
+	base := &url.URL{Scheme: "http", Host: r.Host}
+	if r.TLS != nil {
+		base.Scheme = "https"
+	}
+
+	if isTrustedProxy(r.RemoteAddr, config.TrustedProxies) {
+		if proto := firstHeaderValue(r.Header, "X-Forwarded-Proto"); proto != "" {
+			base.Scheme = proto
+		}
+		if host := firstHeaderValue(r.Header, "X-Forwarded-Host"); host != "" {
+			base.Host = host
+		}
+	}
+
+	return base
+}
+
+// PreImage returns the resource as it existed before the request modified it. This is
+// only available for update and delete requests to ResourceHandlers which implement
+// ReadBeforeWriteResourceHandler.
+func (ctx *gorillaRequestContext) PreImage() (Resource, bool) {
+	preImage, ok := ctx.Value(preImageKey).(preImageValue)
+	return preImage.resource, ok
+}
+
+// setPreImage sets the pre-image of the resource for the request.
+func (ctx *gorillaRequestContext) setPreImage(resource Resource) RequestContext {
+	return ctx.WithValue(preImageKey, preImageValue{resource})
+}
+
+// Schema returns the name of the request payload schema specified by the "schema"
+// parameter of the request Content-Type, e.g. "foo.v2" for
+// "application/json; schema=foo.v2", or an empty string if there isn't one.
+func (ctx *gorillaRequestContext) Schema() string {
+	_, params, err := mime.ParseMediaType(ctx.Header().Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return params[schemaParam]
+}
+
+// DryRun returns true if the client requested that a mutation be validated and reported
+// without being persisted, using the "dry_run" query parameter or the
+// "Prefer: handling=dry-run" header.
+func (ctx *gorillaRequestContext) DryRun() bool {
+	if dryRunStr, ok := ctx.Value(dryRunKey).(string); ok {
+		dryRun, err := strconv.ParseBool(dryRunStr)
+		return err == nil && dryRun
+	}
+
+	for _, prefer := range ctx.Header()["Prefer"] {
+		for _, preference := range strings.FieldsFunc(prefer, func(r rune) bool {
+			return r == ',' || r == ';'
+		}) {
+			if strings.EqualFold(strings.TrimSpace(preference), dryRunPreference) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Preconditions returns the parsed conditional headers of the request, i.e. If-Match,
+// If-None-Match, If-Modified-Since, and If-Unmodified-Since.
+func (ctx *gorillaRequestContext) Preconditions() Preconditions {
+	method := ""
+	if req, ok := ctx.Request(); ok {
+		method = req.Method
+	}
+	return parsePreconditions(method, ctx.Header())
+}
+
+// CheckPreconditions sets the ETag and Last-Modified response headers for the current
+// resource, if given, and evaluates the request's Preconditions against them. A 304 Not
+// Modified or 412 Precondition Failed Error is returned if they fail, which can be
+// returned by the ResourceHandler as is.
+func (ctx *gorillaRequestContext) CheckPreconditions(etag string, modified time.Time) error {
+	if etag != "" {
+		ctx.SetHeader("ETag", etag)
+	}
+	if !modified.IsZero() {
+		ctx.SetHeader("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+	return ctx.Preconditions().Check(etag, modified)
+}
+
+// preImageValue wraps a pre-image so that nil resources can be distinguished from a
+// missing pre-image.
+type preImageValue struct {
+	resource Resource
+}
+
+// setResourceErrors sets the errors for individual resources, keyed by ID, which failed
+// in a request operating on multiple resources.
+func (ctx *gorillaRequestContext) setResourceErrors(errs map[string]error) RequestContext {
+	return ctx.WithValue(resourceErrorsKey, errs)
+}
+
+// resourceErrors returns the errors for individual resources, keyed by ID, which failed
+// in a request operating on multiple resources.
+func (ctx *gorillaRequestContext) resourceErrors() map[string]error {
+	errs, _ := ctx.Value(resourceErrorsKey).(map[string]error)
+	return errs
+}
+
+// setConfiguration sets the API Configuration for the request.
+func (ctx *gorillaRequestContext) setConfiguration(config *Configuration) RequestContext {
+	return ctx.WithValue(configurationKey, config)
+}
+
+// configuration returns the API Configuration for the request, defaulting to an empty
+// Configuration if one hasn't been set.
+func (ctx *gorillaRequestContext) configuration() *Configuration {
+	if config, ok := ctx.Value(configurationKey).(*Configuration); ok && config != nil {
+		return config
+	}
+	return &Configuration{}
+}
+
+// isTrustedProxy returns true if the remote address matches one of the trusted proxy
+// IP addresses or CIDR ranges.
+func isTrustedProxy(remoteAddr string, trustedProxies []string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, proxy := range trustedProxies {
+		if _, network, err := net.ParseCIDR(proxy); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+		} else if proxyIP := net.ParseIP(proxy); proxyIP != nil && proxyIP.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// firstHeaderValue returns the first comma-separated value of the header, which is
+// the value set by the proxy closest to the client.
+func firstHeaderValue(header http.Header, key string) string {
+	value := header.Get(key)
+	if i := strings.Index(value, ","); i >= 0 {
+		value = value[:i]
+	}
+	return strings.TrimSpace(value)
+}
+
 // RouteVars is a map of URL route variables to values.
 //
 //     vars = RouteVars{"category": "widgets", "resource_id": "42"}
@@ -392,25 +1120,27 @@ func (ctx *gorillaRequestContext) BuildURL(resourceName string,
 			resourceName)
 	}
 
-	routeName := resourceName + ":" + string(method)
-	route := ctx.router.Get(routeName)
+	if ctx.router == nil {
+		return nil, fmt.Errorf("unable to build URL for resource name %q: no router available",
+			resourceName)
+	}
 
-	// Transform RouteVars map to list of key, val pairs for Gorilla's API
-	pairs := make([]string, (len(vars)*2)+2)
+	routeVars := map[string]string{}
 	for key, val := range vars {
-		pairs = append(pairs, key, val)
+		routeVars[key] = val
+	}
+	routeVars[versionKey] = ctx.Version()
+	if tenant := ctx.Tenant(); tenant != "" {
+		routeVars[tenantVar] = tenant
 	}
-	pairs = append(pairs, "version", ctx.Version())
-	url, err := route.URL(pairs...)
+	url, err := ctx.router.URL(resourceName+":"+string(method), routeVars)
 	if err != nil {
 		return nil, err
 	}
-	url.Host = r.Host
-
-	url.Scheme = "http"
-	if r.TLS != nil {
-		url.Scheme += "s"
-	}
+	base := ctx.baseURL(r)
+	url.Scheme = base.Scheme
+	url.Host = base.Host
+	url.Path = base.Path + url.Path
 
 	return url, nil
 }
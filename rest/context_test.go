@@ -23,8 +23,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	gContext "github.com/gorilla/context"
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -172,7 +174,7 @@ func TestBuildURL(t *testing.T) {
 	gContext.Set(req, "version", "1")
 
 	writer := httptest.NewRecorder()
-	ctx := NewContextWithRouter(nil, req, writer, api.(*muxAPI).router)
+	ctx := NewContextWithRouter(nil, req, writer, api.(*muxAPI).muxRouter())
 
 	url, _ := ctx.BuildURL("widgets", HandleCreate, nil)
 	assert.Equal(url.String(), "http://example.com/api/v1/widgets")
@@ -195,3 +197,211 @@ func TestBuildURL(t *testing.T) {
 		"category": "anvils"})
 	assert.Equal(url.String(), "https://example.com/api/v2/acme/anvils/resources")
 }
+
+// Ensures that Page and PerPage return their defaults when not specified.
+func TestPageDefaults(t *testing.T) {
+	assert := assert.New(t)
+	req, _ := http.NewRequest("GET", "http://example.com/foo", nil)
+	writer := httptest.NewRecorder()
+	ctx := NewContext(nil, req, writer)
+
+	assert.Equal(1, ctx.Page())
+	assert.Equal(100, ctx.PerPage())
+	assert.Equal(0, ctx.Offset())
+}
+
+// Ensures that Page, PerPage, and Offset are parsed from the query string.
+func TestPageAndPerPage(t *testing.T) {
+	assert := assert.New(t)
+	req, _ := http.NewRequest("GET", "http://example.com/foo?page=3&per_page=20", nil)
+	writer := httptest.NewRecorder()
+	ctx := NewContext(nil, req, writer)
+
+	assert.Equal(3, ctx.Page())
+	assert.Equal(20, ctx.PerPage())
+	assert.Equal(40, ctx.Offset())
+}
+
+// Ensures that invalid page values fall back to the defaults.
+func TestPageBadValue(t *testing.T) {
+	assert := assert.New(t)
+	req, _ := http.NewRequest("GET", "http://example.com/foo?page=-1&per_page=blah", nil)
+	writer := httptest.NewRecorder()
+	ctx := NewContext(nil, req, writer)
+
+	assert.Equal(1, ctx.Page())
+	assert.Equal(100, ctx.PerPage())
+}
+
+// Ensures that the total count set by a handler is visible on derived contexts.
+func TestSetTotalCount(t *testing.T) {
+	assert := assert.New(t)
+	req, _ := http.NewRequest("GET", "http://example.com/foo", nil)
+	writer := httptest.NewRecorder()
+	ctx := NewContext(nil, req, writer)
+
+	_, ok := ctx.TotalCount()
+	assert.False(ok)
+
+	derived := ctx.setStatus(http.StatusOK)
+	derived.SetTotalCount(42)
+
+	count, ok := ctx.TotalCount()
+	assert.True(ok)
+	assert.Equal(42, count)
+}
+
+// Ensures that NextURL and PrevURL build page URLs when using OffsetPagination.
+func TestOffsetPaginationURLs(t *testing.T) {
+	assert := assert.New(t)
+	req, _ := http.NewRequest("GET", "http://example.com/api/v1/foo?page=2&per_page=10", nil)
+	req.RequestURI = "/api/v1/foo?page=2&per_page=10"
+	writer := httptest.NewRecorder()
+	ctx := NewContext(nil, req, writer).setPagination(OffsetPagination)
+
+	_, err := ctx.NextURL()
+	assert.Error(err, "Next URL requires a total count")
+
+	ctx.SetTotalCount(25)
+	nextURL, err := ctx.NextURL()
+	assert.Nil(err)
+	assert.Equal("http://example.com/api/v1/foo?page=3&per_page=10", nextURL)
+
+	prevURL, err := ctx.PrevURL()
+	assert.Nil(err)
+	assert.Equal("http://example.com/api/v1/foo?page=1&per_page=10", prevURL)
+
+	ctx.SetTotalCount(20)
+	_, err = ctx.NextURL()
+	assert.Error(err, "No next page on the last page")
+}
+
+// Ensures that the configured default limit is used when no limit is requested and
+// that requested limits are clamped to the maximum.
+func TestLimitWithLimits(t *testing.T) {
+	assert := assert.New(t)
+	req, _ := http.NewRequest("GET", "http://example.com/foo", nil)
+	writer := httptest.NewRecorder()
+	ctx := NewContext(nil, req, writer)
+	ctx = ctx.setLimits(listLimits{defaultLimit: 25, maxLimit: 50})
+
+	assert.Equal(25, ctx.Limit())
+	assert.False(ctx.limitExceeded())
+
+	ctx = ctx.WithValue(limitKey, "40")
+	assert.Equal(40, ctx.Limit())
+	assert.False(ctx.limitExceeded())
+
+	ctx = ctx.WithValue(limitKey, "1000000")
+	assert.Equal(50, ctx.Limit())
+	assert.True(ctx.limitExceeded())
+}
+
+// Ensures that NextURL preserves the request path and existing query parameters.
+func TestNextURLPreservesRequest(t *testing.T) {
+	assert := assert.New(t)
+	req, _ := http.NewRequest("GET", "http://example.com/api/v1/foo?filter=bar&limit=5", nil)
+	writer := httptest.NewRecorder()
+	ctx := NewContext(nil, req, writer).setCursor("abc")
+
+	nextURL, err := ctx.NextURL()
+
+	assert.Nil(err)
+	assert.Equal("http://example.com/api/v1/foo?filter=bar&limit=5&next=abc", nextURL)
+}
+
+// Ensures that NextURL honors forwarded headers only from trusted proxies.
+func TestNextURLForwardedHeaders(t *testing.T) {
+	assert := assert.New(t)
+	req, _ := http.NewRequest("GET", "http://internal:8080/api/v1/foo", nil)
+	req.RequestURI = "/api/v1/foo"
+	req.RemoteAddr = "10.1.2.3:5555"
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "api.example.com, internal")
+	writer := httptest.NewRecorder()
+
+	ctx := NewContext(nil, req, writer).setCursor("abc")
+	nextURL, _ := ctx.NextURL()
+	assert.Equal("http://internal:8080/api/v1/foo?next=abc", nextURL)
+
+	ctx = ctx.setConfiguration(&Configuration{TrustedProxies: []string{"10.0.0.0/8"}})
+	nextURL, _ = ctx.NextURL()
+	assert.Equal("https://api.example.com/api/v1/foo?next=abc", nextURL)
+
+	ctx = ctx.setConfiguration(&Configuration{TrustedProxies: []string{"10.1.2.4"}})
+	nextURL, _ = ctx.NextURL()
+	assert.Equal("http://internal:8080/api/v1/foo?next=abc", nextURL)
+}
+
+// Ensures that NextURL and BuildURL use the configured external base URL.
+func TestExternalBaseURL(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{ExternalBaseURL: "https://example.com/prefix/"})
+	api.RegisterResourceHandler(TestResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://internal/api/v1/widgets", nil)
+	gContext.Set(req, "version", "1")
+	writer := httptest.NewRecorder()
+	ctx := api.(*muxAPI).handler.newContext(writer, req).setCursor("abc")
+
+	nextURL, _ := ctx.NextURL()
+	assert.Equal("https://example.com/prefix/api/v1/widgets?next=abc", nextURL)
+
+	url, _ := ctx.BuildURL("widgets", HandleRead, RouteVars{"resource_id": "1"})
+	assert.Equal("https://example.com/prefix/api/v1/widgets/1", url.String())
+}
+
+// Ensures that the typed query accessors convert parameters and fall back to defaults.
+func TestQueryAccessors(t *testing.T) {
+	assert := assert.New(t)
+	req, _ := http.NewRequest("GET",
+		"http://example.com/foo?n=5&ok=true&since=2015-01-02T03:04:05Z&bad=x", nil)
+	ctx := NewContext(nil, req, httptest.NewRecorder())
+
+	assert.Equal("5", ctx.Query("n"))
+	assert.Equal("", ctx.Query("missing"))
+
+	n, err := ctx.QueryInt("n", 1)
+	assert.NoError(err)
+	assert.Equal(5, n)
+	n, err = ctx.QueryInt("missing", 1)
+	assert.NoError(err)
+	assert.Equal(1, n)
+	n, err = ctx.QueryInt("bad", 1)
+	assert.Equal(BadRequest("Query parameter bad must be an integer"), err)
+	assert.Equal(1, n)
+
+	ok, err := ctx.QueryBool("ok", false)
+	assert.NoError(err)
+	assert.True(ok)
+	_, err = ctx.QueryBool("bad", false)
+	assert.Error(err)
+
+	since, err := ctx.QueryTime("since", time.Time{})
+	assert.NoError(err)
+	assert.Equal(time.Date(2015, 1, 2, 3, 4, 5, 0, time.UTC), since)
+	_, err = ctx.QueryTime("bad", time.Time{})
+	assert.Error(err)
+}
+
+// Ensures that cookies and path variables are accessible from the context.
+func TestCookieAndPathVar(t *testing.T) {
+	assert := assert.New(t)
+	req, _ := http.NewRequest("GET", "http://example.com/foo/1", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	req = mux.SetURLVars(req, map[string]string{"resource_id": "1"})
+	ctx := NewContext(nil, req, httptest.NewRecorder())
+
+	cookie, ok := ctx.Cookie("session")
+	if assert.True(ok) {
+		assert.Equal("abc", cookie.Value)
+	}
+	_, ok = ctx.Cookie("missing")
+	assert.False(ok)
+
+	id, ok := ctx.PathVar("resource_id")
+	assert.True(ok)
+	assert.Equal("1", id)
+	_, ok = ctx.PathVar("missing")
+	assert.False(ok)
+}
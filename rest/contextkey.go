@@ -0,0 +1,96 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"sync"
+)
+
+// contextKeyNames holds the names of the ContextKeys which have been created so that
+// duplicates are detected.
+var contextKeyNames = struct {
+	sync.Mutex
+	names map[string]bool
+}{names: map[string]bool{}}
+
+// contextKeyID is the key values are stored under on a RequestContext. Keys are
+// compared by identity, so values stored with one ContextKey can never be read or
+// overwritten with another.
+type contextKeyID struct {
+	name string
+}
+
+// ContextKey is a typed key for storing values on a RequestContext. Unlike untyped
+// keys passed to RequestContext's WithValue and Value methods, values are retrieved
+// with their static type and keys from different packages can't collide. Create keys
+// once, typically as package-level variables, with NewContextKey, e.g.
+//
+//	var userKey = rest.NewContextKey[*User]("myapp.user")
+//
+//	ctx = userKey.Set(ctx, user)
+//	user, ok := userKey.Get(ctx)
+type ContextKey[T any] struct {
+	id *contextKeyID
+}
+
+// NewContextKey returns a new ContextKey with the given name. Names should be qualified,
+// e.g. with a package name, and must be unique. It panics if a ContextKey with the name
+// was already created.
+func NewContextKey[T any](name string) ContextKey[T] {
+	contextKeyNames.Lock()
+	defer contextKeyNames.Unlock()
+	if contextKeyNames.names[name] {
+		panic(fmt.Sprintf("rest: duplicate context key %q", name))
+	}
+	contextKeyNames.names[name] = true
+	return ContextKey[T]{id: &contextKeyID{name: name}}
+}
+
+// Name returns the name of the ContextKey.
+func (k ContextKey[T]) Name() string {
+	return k.id.name
+}
+
+// String returns a string representation of the ContextKey.
+func (k ContextKey[T]) String() string {
+	return "rest.ContextKey(" + k.id.name + ")"
+}
+
+// Set returns a new RequestContext with the value stored for the ContextKey.
+func (k ContextKey[T]) Set(ctx RequestContext, value T) RequestContext {
+	return ctx.WithValue(k.id, value)
+}
+
+// Get returns the value stored for the ContextKey on the RequestContext and true, or
+// the zero value and false if there isn't one.
+func (k ContextKey[T]) Get(ctx RequestContext) (T, bool) {
+	value, ok := ctx.Value(k.id).(T)
+	return value, ok
+}
+
+// GetOrDefault returns the value stored for the ContextKey on the RequestContext, or
+// the provided default if there isn't one.
+func (k ContextKey[T]) GetOrDefault(ctx RequestContext, defaultVal T) T {
+	if value, ok := k.Get(ctx); ok {
+		return value
+	}
+	return defaultVal
+}
@@ -0,0 +1,59 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Ensures that values stored with a ContextKey are retrieved with their type.
+func TestContextKey(t *testing.T) {
+	assert := assert.New(t)
+	countKey := NewContextKey[int]("rest_test.count")
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo", nil)
+	ctx := NewContext(nil, req, httptest.NewRecorder())
+
+	_, ok := countKey.Get(ctx)
+	assert.False(ok)
+	assert.Equal(5, countKey.GetOrDefault(ctx, 5))
+
+	ctx = countKey.Set(ctx, 42)
+	count, ok := countKey.Get(ctx)
+	assert.True(ok)
+	assert.Equal(42, count)
+	assert.Equal("rest_test.count", countKey.Name())
+
+	// Untyped keys with the same name don't collide.
+	assert.Nil(ctx.Value("rest_test.count"))
+}
+
+// Ensures that NewContextKey panics if the name is already used.
+func TestNewContextKeyDuplicate(t *testing.T) {
+	assert := assert.New(t)
+	NewContextKey[string]("rest_test.duplicate")
+
+	assert.Panics(func() {
+		NewContextKey[int]("rest_test.duplicate")
+	})
+}
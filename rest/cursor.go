@@ -0,0 +1,164 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	gcontext "github.com/gorilla/context"
+)
+
+// errInvalidCursor is returned by CursorCodecs for cursors which weren't issued by them.
+var errInvalidCursor = errors.New("invalid cursor")
+
+// CursorCodec converts the pagination cursors returned by ResourceHandlers into the
+// opaque cursors placed in "next" links, and back again when clients pass them in the
+// "next" query parameter. This keeps backend cursors, like row keys, from leaking to
+// clients and prevents clients from forging them. Cursors are scoped to the resource
+// they were issued for.
+type CursorCodec interface {
+	// EncodeCursor returns the cursor sent to clients for the handler's cursor.
+	EncodeCursor(resource, cursor string) (string, error)
+
+	// DecodeCursor returns the handler's cursor for the cursor sent by a client, or an
+	// error if it wasn't issued for the resource or was tampered with.
+	DecodeCursor(resource, cursor string) (string, error)
+}
+
+// signingCursorCodec is a CursorCodec which signs cursors with HMAC-SHA256.
+type signingCursorCodec struct {
+	key []byte
+}
+
+// NewSigningCursorCodec returns a CursorCodec which signs cursors with HMAC-SHA256
+// using the key, so they can't be forged. The handler's cursor remains readable by
+// clients. The key should be at least 32 random bytes and shared by every instance of
+// the API.
+func NewSigningCursorCodec(key []byte) CursorCodec {
+	return &signingCursorCodec{key: key}
+}
+
+// EncodeCursor returns the cursor followed by its signature.
+func (s *signingCursorCodec) EncodeCursor(resource, cursor string) (string, error) {
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(cursor))
+	return encoded + "." + s.sign(resource, encoded), nil
+}
+
+// DecodeCursor returns the signed cursor if its signature is valid for the resource.
+func (s *signingCursorCodec) DecodeCursor(resource, cursor string) (string, error) {
+	parts := strings.SplitN(cursor, ".", 2)
+	if len(parts) != 2 || !hmac.Equal([]byte(parts[1]), []byte(s.sign(resource, parts[0]))) {
+		return "", errInvalidCursor
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", errInvalidCursor
+	}
+	return string(decoded), nil
+}
+
+// sign returns the signature of the encoded cursor for the resource.
+func (s *signingCursorCodec) sign(resource, encoded string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(resource + "\n" + encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// encryptingCursorCodec is a CursorCodec which encrypts cursors with AES-GCM.
+type encryptingCursorCodec struct {
+	aead cipher.AEAD
+}
+
+// NewEncryptingCursorCodec returns a CursorCodec which encrypts cursors with AES-GCM
+// using the key, so they can't be read or forged by clients. The key must be 16, 24, or
+// 32 random bytes, selecting AES-128, AES-192, or AES-256, and shared by every instance
+// of the API. An error is returned if the key is invalid.
+func NewEncryptingCursorCodec(key []byte) (CursorCodec, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid cursor key: %s", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptingCursorCodec{aead: aead}, nil
+}
+
+// EncodeCursor returns the cursor encrypted with a random nonce, which precedes it.
+func (e *encryptingCursorCodec) EncodeCursor(resource, cursor string) (string, error) {
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := e.aead.Seal(nonce, nonce, []byte(cursor), []byte(resource))
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// DecodeCursor returns the decrypted cursor if it was encrypted for the resource.
+func (e *encryptingCursorCodec) DecodeCursor(resource, cursor string) (string, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(sealed) < e.aead.NonceSize() {
+		return "", errInvalidCursor
+	}
+	nonce, ciphertext := sealed[:e.aead.NonceSize()], sealed[e.aead.NonceSize():]
+	decoded, err := e.aead.Open(nil, nonce, ciphertext, []byte(resource))
+	if err != nil {
+		return "", errInvalidCursor
+	}
+	return string(decoded), nil
+}
+
+// newCursorMiddleware returns a RequestMiddleware which decodes the cursor passed by
+// the client with the CursorCodec so it's available with RequestContext's Cursor.
+// Requests with cursors which can't be decoded are rejected with 400 Bad Request.
+func newCursorMiddleware(codec CursorCodec, h *requestHandler,
+	resource string) RequestMiddleware {
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if cursor := r.URL.Query().Get(cursorKey); cursor != "" {
+				decoded, err := codec.DecodeCursor(resource, cursor)
+				if err != nil {
+					h.sendResponse(h.newContext(w, r).setError(BadRequest("Invalid cursor")))
+					return
+				}
+				gcontext.Set(r, requestCursorKey, decoded)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// encodeCursor returns the cursor to send to the client for the request's cursor,
+// encoded with the configured CursorCodec if there is one.
+func encodeCursor(config *Configuration, r *http.Request, cursor string) (string, error) {
+	if config.CursorCodec == nil {
+		return cursor, nil
+	}
+	resource := strings.SplitN(routeName(r), ":", 2)[0]
+	return config.CursorCodec.EncodeCursor(resource, cursor)
+}
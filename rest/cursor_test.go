@@ -0,0 +1,156 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testCursorKey = []byte("0123456789abcdef0123456789abcdef")
+
+// Ensures that signed cursors round trip and are rejected if they're tampered with or
+// used for another resource.
+func TestSigningCursorCodec(t *testing.T) {
+	assert := assert.New(t)
+	codec := NewSigningCursorCodec(testCursorKey)
+
+	cursor, err := codec.EncodeCursor("foo", "row:42")
+	assert.NoError(err)
+	assert.NotContains(cursor, "row:42")
+
+	decoded, err := codec.DecodeCursor("foo", cursor)
+	assert.NoError(err)
+	assert.Equal("row:42", decoded)
+
+	_, err = codec.DecodeCursor("bar", cursor)
+	assert.Error(err)
+
+	forged, _ := NewSigningCursorCodec([]byte("other")).EncodeCursor("foo", "row:1")
+	tampered := base64.RawURLEncoding.EncodeToString([]byte("row:43")) +
+		cursor[strings.Index(cursor, "."):]
+	for _, c := range []string{"row:42", "", forged, cursor + "x", tampered} {
+		_, err = codec.DecodeCursor("foo", c)
+		assert.Error(err, c)
+	}
+}
+
+// Ensures that encrypted cursors round trip, aren't readable, and are rejected if
+// they're tampered with or used for another resource.
+func TestEncryptingCursorCodec(t *testing.T) {
+	assert := assert.New(t)
+	codec, err := NewEncryptingCursorCodec(testCursorKey)
+	if !assert.NoError(err) {
+		return
+	}
+
+	cursor, err := codec.EncodeCursor("foo", "row:42")
+	assert.NoError(err)
+	other, _ := codec.EncodeCursor("foo", "row:42")
+	assert.NotEqual(cursor, other)
+
+	decoded, err := codec.DecodeCursor("foo", cursor)
+	assert.NoError(err)
+	assert.Equal("row:42", decoded)
+
+	_, err = codec.DecodeCursor("bar", cursor)
+	assert.Error(err)
+	for _, c := range []string{"row:42", "", "AAAA", cursor[:len(cursor)-2] + "AA"} {
+		_, err = codec.DecodeCursor("foo", c)
+		assert.Error(err, c)
+	}
+
+	_, err = NewEncryptingCursorCodec([]byte("short"))
+	assert.Error(err)
+}
+
+type cursorResourceHandler struct {
+	BaseResourceHandler
+	cursors []string
+}
+
+func (c *cursorResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (c *cursorResourceHandler) Authenticate(r *http.Request) error {
+	if r.Header.Get("Authorization") == "" {
+		return UnauthorizedRequest("Not authorized")
+	}
+	return nil
+}
+
+func (c *cursorResourceHandler) ReadResourceList(ctx RequestContext, limit int,
+	cursor string, version string) ([]Resource, string, error) {
+
+	c.cursors = append(c.cursors, cursor)
+	return []Resource{}, "row:42", nil
+}
+
+// serveCursor performs an authorized list request with the cursor and returns the
+// response.
+func serveCursor(api API, cursor string) *httptest.ResponseRecorder {
+	u := "http://foo.com/api/v1/foo"
+	if cursor != "" {
+		u += "?next=" + url.QueryEscape(cursor)
+	}
+	req, _ := http.NewRequest("GET", u, nil)
+	req.Header.Set("Authorization", "secret")
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	return resp
+}
+
+// Ensures that cursors are encoded in next links and decoded before they're passed to
+// handlers, and that tampered cursors are rejected with 400 after authentication.
+func TestCursorCodecPagination(t *testing.T) {
+	assert := assert.New(t)
+	handler := &cursorResourceHandler{}
+	api := NewAPI(&Configuration{CursorCodec: NewSigningCursorCodec(testCursorKey)})
+	api.RegisterResourceHandler(handler)
+
+	resp := serveCursor(api, "")
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.NotContains(resp.Body.String(), "row:42")
+	var payload map[string]interface{}
+	json.Unmarshal(resp.Body.Bytes(), &payload)
+	next, err := url.Parse(payload["next"].(string))
+	if !assert.NoError(err) {
+		return
+	}
+
+	resp = serveCursor(api, next.Query().Get("next"))
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal([]string{"", "row:42"}, handler.cursors)
+
+	resp = serveCursor(api, "row:43")
+	assert.Equal(http.StatusBadRequest, resp.Code)
+	assert.Contains(resp.Body.String(), "Invalid cursor")
+	assert.Len(handler.cursors, 2)
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo?next=row:43", nil)
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	assert.Equal(http.StatusUnauthorized, resp.Code)
+}
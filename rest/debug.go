@@ -0,0 +1,201 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultDebugPrefix is the path prefix of the debug endpoints if one isn't configured.
+const defaultDebugPrefix = "/debug"
+
+// debugPrefix returns the path prefix of the debug endpoints.
+func debugPrefix(config *Configuration) string {
+	if config.DebugPrefix != "" {
+		return strings.TrimSuffix(config.DebugPrefix, "/")
+	}
+	return defaultDebugPrefix
+}
+
+// EnableDebug binds profiling endpoints compatible with net/http/pprof, and an
+// endpoint serving the command line and memory statistics like expvar, under the
+// Configuration's DebugPrefix:
+//
+//	GET /debug/pprof/            profile index
+//	GET /debug/pprof/cmdline     command line
+//	GET /debug/pprof/profile     CPU profile
+//	GET /debug/pprof/symbol      symbol lookup
+//	GET /debug/pprof/trace       execution trace
+//	GET /debug/pprof/{profile}   named profile, e.g. heap or goroutine
+//	GET /debug/vars              command line and memory statistics
+//
+// Requests are authenticated with the Configuration's DebugAuthenticate function. An
+// error is returned if it isn't set since the endpoints expose sensitive information.
+// The net/http/pprof and expvar packages aren't used because importing them binds
+// unauthenticated endpoints on http.DefaultServeMux, so variables published with
+// expvar aren't served.
+func (r *muxAPI) EnableDebug() error {
+	authenticate := r.config.DebugAuthenticate
+	if authenticate == nil {
+		return errors.New("DebugAuthenticate must be configured to enable debug endpoints")
+	}
+	middleware := []RequestMiddleware{newAuthMiddleware(authenticate)}
+	prefix := debugPrefix(r.config)
+
+	handlers := []struct {
+		path    string
+		handler http.HandlerFunc
+	}{
+		{"/pprof/", debugIndex},
+		{"/pprof/cmdline", debugCmdline},
+		{"/pprof/profile", debugCPUProfile},
+		{"/pprof/symbol", debugSymbol},
+		{"/pprof/trace", debugTrace},
+		{"/pprof/{profile}", debugProfile},
+		{"/vars", debugVars},
+	}
+
+	r.addRoutes(nil, func(routes Router) {
+		for _, h := range handlers {
+			r.bind(routes, "debug:"+strings.Trim(h.path, "/"), "GET", prefix+h.path,
+				applyMiddleware(h.handler, middleware))
+		}
+	})
+	return nil
+}
+
+// debugIndex lists the available profiles with their counts.
+func debugIndex(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	for _, profile := range pprof.Profiles() {
+		fmt.Fprintf(w, "%s %d\n", profile.Name(), profile.Count())
+	}
+	fmt.Fprintln(w, "profile")
+	fmt.Fprintln(w, "trace")
+}
+
+// debugCmdline writes the command line with arguments separated by NUL bytes.
+func debugCmdline(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, strings.Join(os.Args, "\x00"))
+}
+
+// debugSeconds returns the duration requested by the seconds query parameter.
+func debugSeconds(r *http.Request, fallback float64) time.Duration {
+	seconds, err := strconv.ParseFloat(r.FormValue("seconds"), 64)
+	if err != nil || seconds <= 0 {
+		seconds = fallback
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// debugSleep waits for the duration or until the request is canceled.
+func debugSleep(r *http.Request, d time.Duration) {
+	select {
+	case <-time.After(d):
+	case <-r.Context().Done():
+	}
+}
+
+// debugCPUProfile writes a CPU profile for the requested number of seconds, 30 by
+// default.
+func debugCPUProfile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", `attachment; filename="profile"`)
+	if err := pprof.StartCPUProfile(w); err != nil {
+		http.Error(w, fmt.Sprintf("Could not enable CPU profiling: %s", err),
+			http.StatusInternalServerError)
+		return
+	}
+	debugSleep(r, debugSeconds(r, 30))
+	pprof.StopCPUProfile()
+}
+
+// debugTrace writes an execution trace for the requested number of seconds, 1 by
+// default.
+func debugTrace(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", `attachment; filename="trace"`)
+	if err := trace.Start(w); err != nil {
+		http.Error(w, fmt.Sprintf("Could not enable tracing: %s", err),
+			http.StatusInternalServerError)
+		return
+	}
+	debugSleep(r, debugSeconds(r, 1))
+	trace.Stop()
+}
+
+// debugSymbol writes the names of the functions containing the program counters in
+// the query string, separated by "+", in the format of the pprof symbol endpoint.
+func debugSymbol(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, "num_symbols: 1")
+	for _, word := range strings.Split(r.URL.RawQuery, "+") {
+		pc, err := strconv.ParseUint(word, 0, 64)
+		if err != nil {
+			continue
+		}
+		if f := runtime.FuncForPC(uintptr(pc)); f != nil {
+			fmt.Fprintf(w, "%#x %s\n", pc, f.Name())
+		}
+	}
+}
+
+// debugProfile writes the named profile, as text if the debug query parameter is
+// positive. Garbage is collected before heap profiles if the gc query parameter is
+// positive.
+func debugProfile(w http.ResponseWriter, r *http.Request) {
+	name := pathVars(r)["profile"]
+	profile := pprof.Lookup(name)
+	if profile == nil {
+		http.Error(w, fmt.Sprintf("Unknown profile %s", name), http.StatusNotFound)
+		return
+	}
+	if gc, _ := strconv.Atoi(r.FormValue("gc")); gc > 0 && name == "heap" {
+		runtime.GC()
+	}
+	debug, _ := strconv.Atoi(r.FormValue("debug"))
+	if debug > 0 {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, name))
+	}
+	profile.WriteTo(w, debug)
+}
+
+// debugVars writes the command line and memory statistics as JSON, like the variables
+// expvar publishes by default.
+func debugVars(w http.ResponseWriter, r *http.Request) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"cmdline":  os.Args,
+		"memstats": stats,
+	})
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// debugAuthenticate authorizes requests with the "secret" Authorization header.
+func debugAuthenticate(r *http.Request) error {
+	if r.Header.Get("Authorization") != "secret" {
+		return errors.New("Not authorized")
+	}
+	return nil
+}
+
+// Ensures that EnableDebug binds authenticated pprof and expvar endpoints under the
+// configured prefix.
+func TestEnableDebug(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{
+		DebugPrefix:       "/internal/",
+		DebugAuthenticate: debugAuthenticate,
+		RouteDebug:        true,
+	})
+	assert.NoError(api.EnableDebug())
+
+	get := func(path string, authorized bool) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "http://foo.com"+path, nil)
+		if authorized {
+			req.Header.Set("Authorization", "secret")
+		}
+		resp := httptest.NewRecorder()
+		api.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := get("/internal/vars", true)
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), `"memstats"`)
+
+	resp = get("/internal/pprof/", true)
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), "goroutine")
+
+	resp = get("/internal/pprof/goroutine?debug=1", true)
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), "goroutine profile")
+
+	resp = get("/internal/pprof/cmdline", true)
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+
+	resp = get("/internal/pprof/bogus", true)
+	assert.Equal(http.StatusNotFound, resp.Code, "Incorrect response code")
+
+	resp = get("/internal/pprof/trace?seconds=0.01", true)
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.NotEmpty(resp.Body.Bytes())
+
+	resp = get("/internal/routes", true)
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), `"path":"/internal/vars"`)
+
+	for _, path := range []string{"/internal/vars", "/internal/pprof/heap", "/internal/routes"} {
+		resp = get(path, false)
+		assert.Equal(http.StatusUnauthorized, resp.Code, "Incorrect response code")
+	}
+
+	resp = get("/debug/vars", true)
+	assert.Equal(http.StatusNotFound, resp.Code, "Incorrect response code")
+}
+
+// Ensures that EnableDebug requires DebugAuthenticate.
+func TestEnableDebugUnauthenticated(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+
+	assert.Error(api.EnableDebug())
+
+	req, _ := http.NewRequest("GET", "http://foo.com/debug/vars", nil)
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	assert.Equal(http.StatusNotFound, resp.Code, "Incorrect response code")
+}
+
+// Ensures that importing the package doesn't bind debug endpoints on
+// http.DefaultServeMux, where they wouldn't be authenticated.
+func TestDebugDefaultServeMux(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/heap", "/debug/vars"} {
+		req, _ := http.NewRequest("GET", "http://foo.com"+path, nil)
+		_, pattern := http.DefaultServeMux.Handler(req)
+		assert.Equal("", pattern, path)
+	}
+}
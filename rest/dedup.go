@@ -0,0 +1,228 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	gcontext "github.com/gorilla/context"
+)
+
+const (
+	// duplicateMarker is the key added to the body of responses replayed for duplicate
+	// requests.
+	duplicateMarker = "duplicate"
+
+	// duplicateHeader is the header set on responses replayed for duplicate requests.
+	duplicateHeader = "X-Duplicate-Request"
+)
+
+// dedupEntry is the state of a request which subsequent duplicates are detected
+// against. The response is nil until the original request completes.
+type dedupEntry struct {
+	expires  time.Time
+	response *OutboundResponse
+}
+
+// dedupStore holds the recent non-idempotent requests keyed by their fingerprint. It's
+// safe for concurrent use.
+type dedupStore struct {
+	mu      sync.Mutex
+	entries map[string]*dedupEntry
+}
+
+// newDedupStore returns a newly allocated dedupStore.
+func newDedupStore() *dedupStore {
+	return &dedupStore{entries: map[string]*dedupEntry{}}
+}
+
+// begin returns the entry of a prior request with the fingerprint if one is within
+// the window. Otherwise, it records a new entry for the request which expires after
+// the window and returns it with false.
+func (s *dedupStore) begin(key string, window time.Duration, now time.Time) (*dedupEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, entry := range s.entries {
+		if now.After(entry.expires) {
+			delete(s.entries, k)
+		}
+	}
+
+	if entry, ok := s.entries[key]; ok {
+		return entry, true
+	}
+	entry := &dedupEntry{expires: now.Add(window)}
+	s.entries[key] = entry
+	return entry, false
+}
+
+// complete records the response to the request of the entry.
+func (s *dedupStore) complete(entry *dedupEntry, response *OutboundResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry.response = response
+}
+
+// response returns the response recorded for the entry, if any.
+func (s *dedupStore) response(entry *dedupEntry) *OutboundResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return entry.response
+}
+
+// forget removes the entry, e.g. because its request failed and can be retried.
+func (s *dedupStore) forget(key string, entry *dedupEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.entries[key] == entry {
+		delete(s.entries, key)
+	}
+}
+
+// pendingDuplicate associates a request with its dedupEntry until its response is
+// sent.
+type pendingDuplicate struct {
+	store *dedupStore
+	entry *dedupEntry
+}
+
+// nonIdempotent returns true if the request is a POST or PATCH. Method overrides have
+// already been applied to the request's method.
+func nonIdempotent(r *http.Request) bool {
+	return r.Method == "POST" || r.Method == "PATCH"
+}
+
+// dedupIdentity returns the identity of the client making the request.
+func dedupIdentity(config *Configuration, r *http.Request) string {
+	if config.DedupIdentity != nil {
+		return config.DedupIdentity(r)
+	}
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		return auth
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// newDedupMiddleware returns a RequestMiddleware which detects duplicate non-idempotent
+// requests within the Configuration's DedupWindow. The first request is passed on and
+// its response recorded when sent. Duplicates receive the recorded response with a
+// "duplicate" marker, or a 409 if the first request is still in progress. Responses
+// with 5xx statuses aren't recorded so failed requests can be retried.
+func newDedupMiddleware(config *Configuration, store *dedupStore) RequestMiddleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !nonIdempotent(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, "Failed to read request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+			hash := sha256.New()
+			for _, part := range []string{
+				resolvedTenant(r), dedupIdentity(config, r), r.Method, r.URL.String(),
+			} {
+				hash.Write([]byte(part))
+				hash.Write([]byte{0})
+			}
+			hash.Write(body)
+			key := hex.EncodeToString(hash.Sum(nil))
+
+			entry, duplicate := store.begin(key, config.DedupWindow, time.Now())
+			if duplicate {
+				replayDuplicate(w, store.response(entry))
+				return
+			}
+
+			gcontext.Set(r, duplicateEntryKey, &pendingDuplicate{store: store, entry: entry})
+			next.ServeHTTP(w, r)
+			if store.response(entry) == nil {
+				store.forget(key, entry)
+			}
+		})
+	}
+}
+
+// recordDuplicate records the response for duplicate detection if the request is
+// being tracked.
+func recordDuplicate(ctx RequestContext, out *OutboundResponse) {
+	pending, ok := ctx.Value(duplicateEntryKey).(*pendingDuplicate)
+	if !ok || out.Status >= http.StatusInternalServerError {
+		return
+	}
+	pending.store.complete(pending.entry, &OutboundResponse{
+		Status: out.Status,
+		Header: cloneHeader(out.Header),
+		Body:   append([]byte(nil), out.Body...),
+	})
+}
+
+// replayDuplicate writes the response recorded for a prior request with the duplicate
+// marker. JSON object bodies have "duplicate": true added. If the prior request is
+// still in progress, a 409 is written instead.
+func replayDuplicate(w http.ResponseWriter, out *OutboundResponse) {
+	if out == nil {
+		http.Error(w, "A duplicate request is in progress", http.StatusConflict)
+		return
+	}
+
+	body := out.Body
+	if strings.Contains(out.Header.Get("Content-Type"), "json") {
+		var payload map[string]interface{}
+		if err := json.Unmarshal(body, &payload); err == nil && payload != nil {
+			payload[duplicateMarker] = true
+			if marked, err := json.Marshal(payload); err == nil {
+				body = marked
+			}
+		}
+	}
+
+	header := w.Header()
+	for key, values := range out.Header {
+		header[key] = values
+	}
+	header.Set(duplicateHeader, "true")
+	header.Del("Content-Length")
+	w.WriteHeader(out.Status)
+	w.Write(body)
+}
+
+// cloneHeader returns a deep copy of the header.
+func cloneHeader(header http.Header) http.Header {
+	clone := make(http.Header, len(header))
+	for key, values := range header {
+		clone[key] = append([]string(nil), values...)
+	}
+	return clone
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type dedupResourceHandler struct {
+	BaseResourceHandler
+	creates int
+}
+
+func (d *dedupResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (d *dedupResourceHandler) CreateResource(ctx RequestContext, data Payload,
+	version string) (Resource, error) {
+
+	d.creates++
+	if _, ok := data["fail"]; ok {
+		return nil, InternalServerError("Failed")
+	}
+	return &TestResource{Foo: "hello"}, nil
+}
+
+func (d *dedupResourceHandler) UpdateResource(ctx RequestContext, id string, data Payload,
+	version string) (Resource, error) {
+
+	return &TestResource{Foo: "hello"}, nil
+}
+
+// dedupRequest sends a create request for the body as the identity.
+func dedupRequest(api API, identity, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo", bytes.NewBufferString(body))
+	req.Header.Set("Authorization", identity)
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	return resp
+}
+
+// Ensures that duplicate requests within the window receive the prior response with a
+// duplicate marker without reaching the handler.
+func TestDedupDuplicate(t *testing.T) {
+	assert := assert.New(t)
+	handler := &dedupResourceHandler{}
+	api := NewAPI(&Configuration{DedupWindow: time.Minute})
+	api.RegisterResourceHandler(handler)
+
+	resp := dedupRequest(api, "alice", `{"foo": "hello"}`)
+	assert.Equal(http.StatusCreated, resp.Code, "Incorrect response code")
+	assert.Equal(
+		`{"messages":[],"reason":"Created","result":{"foo":"hello"},"status":201}`,
+		resp.Body.String(),
+		"Incorrect response string",
+	)
+
+	resp = dedupRequest(api, "alice", `{"foo": "hello"}`)
+	assert.Equal(http.StatusCreated, resp.Code, "Incorrect response code")
+	assert.Equal("true", resp.Header().Get("X-Duplicate-Request"))
+	assert.Equal("application/json", resp.Header().Get("Content-Type"))
+	assert.Equal(
+		`{"duplicate":true,"messages":[],"reason":"Created","result":{"foo":"hello"},"status":201}`,
+		resp.Body.String(),
+		"Incorrect response string",
+	)
+	assert.Equal(1, handler.creates)
+}
+
+// Ensures that requests with different identities or bodies aren't duplicates.
+func TestDedupDistinct(t *testing.T) {
+	assert := assert.New(t)
+	handler := &dedupResourceHandler{}
+	api := NewAPI(&Configuration{DedupWindow: time.Minute})
+	api.RegisterResourceHandler(handler)
+
+	dedupRequest(api, "alice", `{"foo": "hello"}`)
+	resp := dedupRequest(api, "bob", `{"foo": "hello"}`)
+	assert.Empty(resp.Header().Get("X-Duplicate-Request"))
+	resp = dedupRequest(api, "alice", `{"foo": "world"}`)
+	assert.Empty(resp.Header().Get("X-Duplicate-Request"))
+
+	assert.Equal(3, handler.creates)
+}
+
+// Ensures that requests of different tenants aren't duplicates.
+func TestDedupTenants(t *testing.T) {
+	assert := assert.New(t)
+	handler := &dedupResourceHandler{}
+	api := NewAPI(&Configuration{
+		DedupWindow: time.Minute,
+		Tenancy:     &Tenancy{Source: TenantHeader},
+	})
+	api.RegisterResourceHandler(handler)
+
+	for _, tenant := range []string{"a", "b"} {
+		req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo",
+			bytes.NewBufferString(`{"foo": "hello"}`))
+		req.Header.Set("Authorization", "alice")
+		req.Header.Set("X-Tenant-ID", tenant)
+		resp := httptest.NewRecorder()
+		api.ServeHTTP(resp, req)
+		assert.Empty(resp.Header().Get("X-Duplicate-Request"))
+	}
+
+	assert.Equal(2, handler.creates)
+}
+
+// Ensures that failed requests and idempotent requests aren't deduplicated, nor are
+// requests when detection is disabled.
+func TestDedupNotRecorded(t *testing.T) {
+	assert := assert.New(t)
+	handler := &dedupResourceHandler{}
+	api := NewAPI(&Configuration{DedupWindow: time.Minute})
+	api.RegisterResourceHandler(handler)
+
+	dedupRequest(api, "alice", `{"fail": true}`)
+	resp := dedupRequest(api, "alice", `{"fail": true}`)
+	assert.Equal(http.StatusInternalServerError, resp.Code, "Incorrect response code")
+	assert.Equal(2, handler.creates)
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("PUT", "http://foo.com/api/v1/foo/1", bytes.NewBufferString("{}"))
+		resp = httptest.NewRecorder()
+		api.ServeHTTP(resp, req)
+		assert.Empty(resp.Header().Get("X-Duplicate-Request"))
+	}
+
+	handler = &dedupResourceHandler{}
+	api = NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+	dedupRequest(api, "alice", "{}")
+	dedupRequest(api, "alice", "{}")
+	assert.Equal(2, handler.creates)
+}
+
+// Ensures that duplicates of requests in progress are rejected and that entries expire
+// after the window.
+func TestDedupStore(t *testing.T) {
+	assert := assert.New(t)
+	store := newDedupStore()
+	now := time.Now()
+
+	entry, duplicate := store.begin("a", time.Second, now)
+	assert.False(duplicate)
+
+	prior, duplicate := store.begin("a", time.Second, now)
+	assert.True(duplicate)
+	assert.Equal(entry, prior)
+	resp := httptest.NewRecorder()
+	replayDuplicate(resp, store.response(prior))
+	assert.Equal(http.StatusConflict, resp.Code, "Incorrect response code")
+
+	_, duplicate = store.begin("a", time.Second, now.Add(2*time.Second))
+	assert.False(duplicate)
+}
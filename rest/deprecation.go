@@ -0,0 +1,195 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	gcontext "github.com/gorilla/context"
+)
+
+// Deprecation describes a deprecated API version or resource. Requests for deprecated
+// versions or resources are served with Deprecation and Sunset headers so clients can
+// migrate before they're removed.
+type Deprecation struct {
+	// Date is when the version or resource was deprecated. If zero, the Deprecation
+	// header is "true".
+	Date time.Time
+
+	// Sunset is when the version or resource will stop being served. If zero, no Sunset
+	// header is sent.
+	Sunset time.Time
+
+	// Link is the URL of documentation describing the deprecation, e.g. a migration
+	// guide. If set, it's sent in a Link header with the "deprecation" relation.
+	Link string
+}
+
+// DeprecatedResourceHandler can be implemented by a ResourceHandler to deprecate some
+// or all versions of its resource. It takes precedence over the Configuration's
+// DeprecatedVersions.
+type DeprecatedResourceHandler interface {
+	// Deprecation returns the Deprecation of the given version of the resource and true
+	// if it's deprecated, false if not.
+	Deprecation(version string) (Deprecation, bool)
+}
+
+// DeprecationStats contains the number of requests made for a deprecated version of a
+// resource, which helps identify clients that still need to migrate.
+type DeprecationStats struct {
+	// Resource is the name of the resource the requests were for.
+	Resource string
+
+	// Version is the deprecated version requested.
+	Version string
+
+	// Requests is the number of requests made.
+	Requests int64
+
+	// LastRequest is when the most recent request was made.
+	LastRequest time.Time
+}
+
+// deprecationKey identifies a DeprecationStats entry.
+type deprecationKey struct {
+	resource string
+	version  string
+}
+
+// deprecationMetrics tracks requests for deprecated versions. It's safe for concurrent
+// use.
+type deprecationMetrics struct {
+	mu    sync.Mutex
+	stats map[deprecationKey]*DeprecationStats
+}
+
+// newDeprecationMetrics returns a newly allocated deprecationMetrics.
+func newDeprecationMetrics() *deprecationMetrics {
+	return &deprecationMetrics{stats: map[deprecationKey]*DeprecationStats{}}
+}
+
+// record adds a request for the deprecated version of the resource to the metrics.
+func (d *deprecationMetrics) record(resource, version string, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := deprecationKey{resource, version}
+	stats, ok := d.stats[key]
+	if !ok {
+		stats = &DeprecationStats{Resource: resource, Version: version}
+		d.stats[key] = stats
+	}
+	stats.Requests++
+	stats.LastRequest = now
+}
+
+// snapshot returns a copy of the metrics sorted by resource and version.
+func (d *deprecationMetrics) snapshot() []DeprecationStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stats := make([]DeprecationStats, 0, len(d.stats))
+	for _, s := range d.stats {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Resource != stats[j].Resource {
+			return stats[i].Resource < stats[j].Resource
+		}
+		return stats[i].Version < stats[j].Version
+	})
+	return stats
+}
+
+// deprecation returns the Deprecation of the version of the ResourceHandler's resource
+// and true if it's deprecated, false if not.
+func deprecation(config *Configuration, handler ResourceHandler, version string) (Deprecation, bool) {
+	if d, ok := unwrapResourceHandler(handler).(DeprecatedResourceHandler); ok {
+		if deprecation, ok := d.Deprecation(version); ok {
+			return deprecation, true
+		}
+	}
+	deprecation, ok := config.DeprecatedVersions[version]
+	return deprecation, ok
+}
+
+// deprecationWarning returns the warning sent to clients using a deprecated version of
+// the resource.
+func deprecationWarning(resource, version string, deprecation Deprecation) string {
+	warning := fmt.Sprintf("Version %s of %s is deprecated", version, resource)
+	if !deprecation.Sunset.IsZero() {
+		warning += " and will be removed after " + deprecation.Sunset.UTC().Format(http.TimeFormat)
+	}
+	return warning
+}
+
+// addDeprecationWarnings adds the warnings to those included in the messages of the
+// response to the request.
+func addDeprecationWarnings(r *http.Request, warnings ...string) {
+	if existing, ok := gcontext.Get(r, deprecationWarningsKey).([]string); ok {
+		warnings = append(existing, warnings...)
+	}
+	gcontext.Set(r, deprecationWarningsKey, warnings)
+}
+
+// newDeprecationMiddleware returns a RequestMiddleware which adds Deprecation, Sunset,
+// and Link headers to responses for deprecated versions of the ResourceHandler's
+// resource and records the requests in the metrics. If the Configuration enables
+// DeprecationWarnings, a Warning header is also added and the warning is included in
+// the response messages.
+func newDeprecationMiddleware(config *Configuration, handler ResourceHandler,
+	metrics *deprecationMetrics) RequestMiddleware {
+
+	resource := handler.ResourceName()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			version := requestVersion(r, config)
+			deprecation, ok := deprecation(config, handler, version)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			metrics.record(resource, version, time.Now())
+
+			header := w.Header()
+			if deprecation.Date.IsZero() {
+				header.Set("Deprecation", "true")
+			} else {
+				header.Set("Deprecation", "@"+strconv.FormatInt(deprecation.Date.Unix(), 10))
+			}
+			if !deprecation.Sunset.IsZero() {
+				header.Set("Sunset", deprecation.Sunset.UTC().Format(http.TimeFormat))
+			}
+			if deprecation.Link != "" {
+				header.Add("Link", fmt.Sprintf(`<%s>; rel="deprecation"`, deprecation.Link))
+			}
+			if config.DeprecationWarnings {
+				warning := deprecationWarning(resource, version, deprecation)
+				header.Add("Warning", fmt.Sprintf(`299 - "%s"`, warning))
+				addDeprecationWarnings(r, warning)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
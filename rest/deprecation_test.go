@@ -0,0 +1,116 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type deprecatedResourceHandler struct {
+	BaseResourceHandler
+}
+
+func (d *deprecatedResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (d *deprecatedResourceHandler) ReadResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	return Payload{"id": id}, nil
+}
+
+func (d *deprecatedResourceHandler) Deprecation(version string) (Deprecation, bool) {
+	if version == "1" {
+		return Deprecation{Link: "http://foo.com/migrate"}, true
+	}
+	return Deprecation{}, false
+}
+
+// Ensures that responses for deprecated versions include Deprecation, Sunset, and Link
+// headers and that the requests are recorded.
+func TestDeprecatedVersions(t *testing.T) {
+	assert := assert.New(t)
+	date := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2015, 6, 1, 0, 0, 0, 0, time.UTC)
+	api := NewAPI(&Configuration{
+		DeprecatedVersions: map[string]Deprecation{"2": {Date: date, Sunset: sunset}},
+	})
+	api.RegisterResourceHandler(&deprecatedResourceHandler{})
+
+	read := func(version string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "http://foo.com/api/v"+version+"/foo/1", nil)
+		resp := httptest.NewRecorder()
+		api.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := read("1")
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal("true", resp.Header().Get("Deprecation"))
+	assert.Equal("", resp.Header().Get("Sunset"))
+	assert.Equal(`<http://foo.com/migrate>; rel="deprecation"`, resp.Header().Get("Link"))
+	assert.Equal("", resp.Header().Get("Warning"))
+
+	resp = read("2")
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal("@1420070400", resp.Header().Get("Deprecation"))
+	assert.Equal("Mon, 01 Jun 2015 00:00:00 GMT", resp.Header().Get("Sunset"))
+
+	read("2")
+	resp = read("3")
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal("", resp.Header().Get("Deprecation"))
+
+	stats := api.DeprecationStats()
+	if assert.Len(stats, 2) {
+		assert.Equal("foo", stats[0].Resource)
+		assert.Equal("1", stats[0].Version)
+		assert.Equal(int64(1), stats[0].Requests)
+		assert.Equal("2", stats[1].Version)
+		assert.Equal(int64(2), stats[1].Requests)
+		assert.False(stats[1].LastRequest.IsZero())
+	}
+}
+
+// Ensures that a warning is included in responses for deprecated versions when
+// DeprecationWarnings is enabled.
+func TestDeprecationWarnings(t *testing.T) {
+	assert := assert.New(t)
+	sunset := time.Date(2015, 6, 1, 0, 0, 0, 0, time.UTC)
+	api := NewAPI(&Configuration{
+		DeprecatedVersions:  map[string]Deprecation{"2": {Sunset: sunset}},
+		DeprecationWarnings: true,
+		DeprecatedParams:    map[string]string{"bar": "baz"},
+	})
+	api.RegisterResourceHandler(&deprecatedResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v2/foo/1?bar=1", nil)
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	warning := "Version 2 of foo is deprecated and will be removed after Mon, 01 Jun 2015 00:00:00 GMT"
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Header()["Warning"], `299 - "`+warning+`"`)
+	assert.Contains(resp.Body.String(), warning)
+	assert.Contains(resp.Body.String(), "Query parameter bar is deprecated, use baz instead")
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// diffKey is the name of the query string variable requesting a differential update
+// response.
+const diffKey = "diff"
+
+// DiffResourceHandler can be implemented by a ResourceHandler to support differential
+// update responses. When a client requests one with the "diff" query parameter, the
+// update endpoint responds with only the fields which changed, computed against the
+// pre-image, along with the new ETag of the resource. ResourceHandlers implementing
+// ReadBeforeWriteResourceHandler support differential responses using the captured
+// pre-image and don't need to implement this.
+type DiffResourceHandler interface {
+	// PreImage returns the resource with the given ID as it exists before it's updated.
+	PreImage(RequestContext, string, string) (Resource, error)
+}
+
+// diffRequested returns true if the client requested a differential response.
+func diffRequested(ctx RequestContext) bool {
+	diffStr, ok := ctx.Value(diffKey).(string)
+	if !ok {
+		return false
+	}
+	diff, err := strconv.ParseBool(diffStr)
+	return err == nil && diff
+}
+
+// diffPreImage returns the outbound representation of the pre-image to compute a
+// differential response against. This is the pre-image captured on the RequestContext,
+// if any, or the one provided by a DiffResourceHandler. If neither is available, false
+// is returned.
+func diffPreImage(ctx RequestContext, handler ResourceHandler) (Resource, bool, error) {
+	preImage, ok := ctx.PreImage()
+	if !ok {
+		differ, ok := unwrapResourceHandler(handler).(DiffResourceHandler)
+		if !ok {
+			return nil, false, nil
+		}
+
+		var err error
+		if preImage, err = differ.PreImage(ctx, ctx.ResourceID(), ctx.Version()); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return applyOutboundRules(preImage, visibleRules(ctx, handler.Rules()), ctx.Version()), true, nil
+}
+
+// resourcePayload converts the Resource into a Payload using its JSON representation.
+func resourcePayload(resource Resource) (Payload, error) {
+	if isNil(resource) {
+		return Payload{}, nil
+	}
+
+	serialized, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(serialized, &payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// diffResources returns a Payload containing the fields of the after Resource which
+// differ from the before Resource. Fields which were removed are included with a nil
+// value.
+func diffResources(before, after Resource) (Payload, error) {
+	beforePayload, err := resourcePayload(before)
+	if err != nil {
+		return nil, err
+	}
+	afterPayload, err := resourcePayload(after)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := Payload{}
+	for field, value := range afterPayload {
+		if previous, ok := beforePayload[field]; !ok || !reflect.DeepEqual(previous, value) {
+			diff[field] = value
+		}
+	}
+	for field := range beforePayload {
+		if _, ok := afterPayload[field]; !ok {
+			diff[field] = nil
+		}
+	}
+
+	return diff, nil
+}
+
+// resourceETag returns a strong entity tag for the Resource computed from its JSON
+// representation.
+func resourceETag(resource Resource) (string, error) {
+	serialized, err := json.Marshal(resource)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`"%x"`, sha1.Sum(serialized)), nil
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type diffResource struct {
+	Foo string `json:"foo"`
+	Bar int    `json:"bar"`
+}
+
+type diffResourceHandler struct {
+	BaseResourceHandler
+}
+
+func (d diffResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (d diffResourceHandler) PreImage(ctx RequestContext, id,
+	version string) (Resource, error) {
+
+	return &diffResource{Foo: "hello", Bar: 1}, nil
+}
+
+func (d diffResourceHandler) UpdateResource(ctx RequestContext, id string,
+	data Payload, version string) (Resource, error) {
+
+	return &diffResource{Foo: "hello", Bar: 2}, nil
+}
+
+// Ensures that diffResources includes changed, added, and removed fields.
+func TestDiffResources(t *testing.T) {
+	assert := assert.New(t)
+	before := map[string]interface{}{"a": 1, "b": "x", "c": true}
+	after := map[string]interface{}{"a": 1, "b": "y", "d": []int{1}}
+
+	diff, err := diffResources(before, after)
+
+	assert.Nil(err)
+	assert.Equal(Payload{"b": "y", "c": nil, "d": []interface{}{float64(1)}}, diff)
+}
+
+// Ensures that resourceETag is stable for equal resources and changes with content.
+func TestResourceETag(t *testing.T) {
+	assert := assert.New(t)
+
+	etag1, _ := resourceETag(&diffResource{Foo: "a"})
+	etag2, _ := resourceETag(&diffResource{Foo: "a"})
+	etag3, _ := resourceETag(&diffResource{Foo: "b"})
+
+	assert.Equal(etag1, etag2)
+	assert.NotEqual(etag1, etag3)
+	assert.Equal(byte('"'), etag1[0])
+}
+
+// Ensures that the update handler returns only the changed fields and the new ETag
+// when a differential response is requested.
+func TestHandleUpdateDiff(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(diffResourceHandler{})
+	updateHandler, _ := api.(*muxAPI).getRouteHandler("foo:update")
+
+	payload := bytes.NewBufferString(`{"bar": 2}`)
+	req, _ := http.NewRequest("PUT", "http://foo.com/api/v1/foo/1?diff=true", payload)
+	resp := httptest.NewRecorder()
+
+	updateHandler.ServeHTTP(resp, req)
+
+	etag, _ := resourceETag(&diffResource{Foo: "hello", Bar: 2})
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal(etag, resp.Header().Get("ETag"))
+	assert.Equal(
+		`{"messages":[],"reason":"OK","result":{"bar":2},"status":200}`,
+		resp.Body.String(),
+		"Incorrect response string",
+	)
+}
+
+// Ensures that the update handler returns the full resource when a differential
+// response isn't requested.
+func TestHandleUpdateNoDiff(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(diffResourceHandler{})
+	updateHandler, _ := api.(*muxAPI).getRouteHandler("foo:update")
+
+	payload := bytes.NewBufferString(`{"bar": 2}`)
+	req, _ := http.NewRequest("PUT", "http://foo.com/api/v1/foo/1", payload)
+	resp := httptest.NewRecorder()
+
+	updateHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal("", resp.Header().Get("ETag"))
+	assert.Equal(
+		`{"messages":[],"reason":"OK","result":{"foo":"hello","bar":2},"status":200}`,
+		resp.Body.String(),
+		"Incorrect response string",
+	)
+}
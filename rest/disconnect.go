@@ -0,0 +1,171 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// Response size buckets used to group disconnect metrics.
+const (
+	sizeBucketSmall  = "0-1KB"
+	sizeBucketMedium = "1KB-64KB"
+	sizeBucketLarge  = "64KB-1MB"
+	sizeBucketHuge   = "1MB+"
+)
+
+// DisconnectStats contains the number of responses for a resource which were aborted
+// mid-write because the client disconnected, grouped by response size bucket. This
+// helps distinguish server problems from flaky clients.
+type DisconnectStats struct {
+	// Resource is the name of the resource the responses were for.
+	Resource string
+
+	// SizeBucket is the range of response sizes, e.g. "1KB-64KB".
+	SizeBucket string
+
+	// Aborted is the number of responses which were aborted.
+	Aborted int64
+
+	// BytesAttempted is the total number of bytes the aborted responses tried to write.
+	BytesAttempted int64
+
+	// BytesWritten is the total number of bytes the aborted responses wrote before
+	// the client disconnected.
+	BytesWritten int64
+}
+
+// disconnectKey identifies a DisconnectStats entry.
+type disconnectKey struct {
+	resource   string
+	sizeBucket string
+}
+
+// disconnectMetrics tracks aborted responses. It's safe for concurrent use.
+type disconnectMetrics struct {
+	mu    sync.Mutex
+	stats map[disconnectKey]*DisconnectStats
+}
+
+// newDisconnectMetrics returns a newly allocated disconnectMetrics.
+func newDisconnectMetrics() *disconnectMetrics {
+	return &disconnectMetrics{stats: map[disconnectKey]*DisconnectStats{}}
+}
+
+// record adds an aborted response for the resource to the metrics.
+func (d *disconnectMetrics) record(resource string, attempted, written int64) {
+	bucket := sizeBucket(attempted)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := disconnectKey{resource, bucket}
+	stats, ok := d.stats[key]
+	if !ok {
+		stats = &DisconnectStats{Resource: resource, SizeBucket: bucket}
+		d.stats[key] = stats
+	}
+	stats.Aborted++
+	stats.BytesAttempted += attempted
+	stats.BytesWritten += written
+}
+
+// snapshot returns a copy of the metrics sorted by resource and size bucket.
+func (d *disconnectMetrics) snapshot() []DisconnectStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stats := make([]DisconnectStats, 0, len(d.stats))
+	for _, s := range d.stats {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Resource != stats[j].Resource {
+			return stats[i].Resource < stats[j].Resource
+		}
+		return stats[i].SizeBucket < stats[j].SizeBucket
+	})
+	return stats
+}
+
+// sizeBucket returns the size bucket for a response of the given size.
+func sizeBucket(size int64) string {
+	switch {
+	case size < 1<<10:
+		return sizeBucketSmall
+	case size < 64<<10:
+		return sizeBucketMedium
+	case size < 1<<20:
+		return sizeBucketLarge
+	default:
+		return sizeBucketHuge
+	}
+}
+
+// accountingResponseWriter wraps an http.ResponseWriter to account for the bytes
+// written to it and whether any writes failed.
+type accountingResponseWriter struct {
+	http.ResponseWriter
+	attempted int64
+	written   int64
+	failed    bool
+}
+
+// Write writes the data to the wrapped http.ResponseWriter, accounting for it.
+func (a *accountingResponseWriter) Write(p []byte) (int, error) {
+	n, err := a.ResponseWriter.Write(p)
+	a.attempted += int64(len(p))
+	a.written += int64(n)
+	if err != nil {
+		a.failed = true
+	}
+	return n, err
+}
+
+// Flush flushes the wrapped http.ResponseWriter if it supports flushing.
+func (a *accountingResponseWriter) Flush() {
+	if flusher, ok := a.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Push pushes the target with the wrapped http.ResponseWriter if it supports server
+// push.
+func (a *accountingResponseWriter) Push(target string, opts *http.PushOptions) error {
+	if pusher, ok := a.ResponseWriter.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// newDisconnectMiddleware returns a RequestMiddleware which records responses for the
+// resource which are aborted because the client disconnected. A response is considered
+// aborted if a write to the client fails or the request is canceled before the
+// response is complete.
+func newDisconnectMiddleware(resource string, metrics *disconnectMetrics) RequestMiddleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writer := &accountingResponseWriter{ResponseWriter: w}
+			next.ServeHTTP(writer, r)
+
+			if writer.failed || r.Context().Err() != nil {
+				metrics.record(resource, writer.attempted, writer.written)
+			}
+		})
+	}
+}
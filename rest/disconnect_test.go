@@ -0,0 +1,76 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// disconnectedResponseWriter is an http.ResponseWriter which writes a fixed number of
+// bytes before failing as if the client disconnected.
+type disconnectedResponseWriter struct {
+	*httptest.ResponseRecorder
+	remaining int
+}
+
+func (d *disconnectedResponseWriter) Write(p []byte) (int, error) {
+	if len(p) > d.remaining {
+		n, _ := d.ResponseRecorder.Write(p[:d.remaining])
+		d.remaining = 0
+		return n, errors.New("broken pipe")
+	}
+	d.remaining -= len(p)
+	return d.ResponseRecorder.Write(p)
+}
+
+// Ensures that responses aborted by client disconnects are recorded per resource and
+// size bucket.
+func TestDisconnectStats(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&streamingResourceHandler{sent: 1})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo", nil)
+	api.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Empty(api.DisconnectStats())
+
+	req, _ = http.NewRequest("GET", "http://foo.com/api/v1/foo", nil)
+	api.ServeHTTP(&disconnectedResponseWriter{httptest.NewRecorder(), 10}, req)
+
+	stats := api.DisconnectStats()
+	if assert.Len(stats, 1) {
+		assert.Equal("foo", stats[0].Resource)
+		assert.Equal(sizeBucketSmall, stats[0].SizeBucket)
+		assert.Equal(int64(1), stats[0].Aborted)
+		assert.Equal(int64(10), stats[0].BytesWritten)
+		assert.True(stats[0].BytesAttempted > 10)
+	}
+}
+
+// Ensures that response sizes are grouped into the correct buckets.
+func TestSizeBucket(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(sizeBucketSmall, sizeBucket(0))
+	assert.Equal(sizeBucketMedium, sizeBucket(1024))
+	assert.Equal(sizeBucketLarge, sizeBucket(64*1024))
+	assert.Equal(sizeBucketHuge, sizeBucket(1024*1024))
+}
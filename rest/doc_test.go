@@ -457,7 +457,7 @@ func TestGenerateNoOutput(t *testing.T) {
 	assert := assert.New(t)
 	generator := &defaultContextGenerator{}
 
-	context, err := generator.generate(&resourceHandlerProxy{&fooHandler{}}, "2")
+	context, err := generator.generate(&resourceHandlerProxy{ResourceHandler: &fooHandler{}}, "2")
 
 	assert.Nil(context, "Context should be nil")
 	assert.Nil(err, "Error should be nil")
@@ -468,7 +468,7 @@ func TestGenerateNoEndpoints(t *testing.T) {
 	assert := assert.New(t)
 	generator := &defaultContextGenerator{}
 
-	context, err := generator.generate(&resourceHandlerProxy{&bazHandler{}}, "1")
+	context, err := generator.generate(&resourceHandlerProxy{ResourceHandler: &bazHandler{}}, "1")
 
 	assert.Nil(context, "Context should be nil")
 	assert.Nil(err, "Error should be nil")
@@ -479,7 +479,7 @@ func TestGenerateHappyPath(t *testing.T) {
 	assert := assert.New(t)
 	generator := &defaultContextGenerator{}
 
-	context, err := generator.generate(&resourceHandlerProxy{&fooHandler{}}, "1")
+	context, err := generator.generate(&resourceHandlerProxy{ResourceHandler: &fooHandler{}}, "1")
 
 	if assert.NotNil(context, "Context should not be nil") {
 		assert.Equal("fooResource", context["resource"])
@@ -0,0 +1,47 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+// dryRunPreference is the Prefer header preference requesting a dry run.
+const dryRunPreference = "handling=dry-run"
+
+// DryRunResourceHandler can be implemented by a ResourceHandler to support dry runs of
+// its mutations. When a client requests a dry run, the handler should validate the
+// request and report what would happen without persisting anything; RequestContext's
+// DryRun method indicates whether one was requested. The framework skips any
+// post-mutation side effects for dry runs. Dry runs of mutations on ResourceHandlers
+// which don't support them are rejected so that nothing is accidentally persisted.
+type DryRunResourceHandler interface {
+	// SupportsDryRun returns true if the handler's mutations honor dry runs.
+	SupportsDryRun() bool
+}
+
+// checkDryRun returns an error if the client requested a dry run of a mutation the
+// ResourceHandler doesn't support dry runs for. If the dry run is supported, the
+// Preference-Applied header is set on the response.
+func checkDryRun(ctx RequestContext, handler ResourceHandler) error {
+	if !ctx.DryRun() {
+		return nil
+	}
+
+	if d, ok := unwrapResourceHandler(handler).(DryRunResourceHandler); !ok || !d.SupportsDryRun() {
+		return BadRequest("Dry run is not supported for " + handler.ResourceName())
+	}
+
+	ctx.ResponseWriter().Header().Set("Preference-Applied", dryRunPreference)
+	return nil
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type dryRunResourceHandler struct {
+	BaseResourceHandler
+	dryRun bool
+}
+
+func (d *dryRunResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (d *dryRunResourceHandler) SupportsDryRun() bool {
+	return true
+}
+
+func (d *dryRunResourceHandler) CreateResource(ctx RequestContext, data Payload,
+	version string) (Resource, error) {
+
+	d.dryRun = ctx.DryRun()
+	return &TestResource{Foo: "hello"}, nil
+}
+
+// Ensures that DryRun is true when requested with the query parameter or Prefer header.
+func TestDryRun(t *testing.T) {
+	assert := assert.New(t)
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo", nil)
+	assert.False(NewContext(nil, req, httptest.NewRecorder()).DryRun())
+
+	req, _ = http.NewRequest("POST", "http://foo.com/api/v1/foo?dry_run=true", nil)
+	assert.True(NewContext(nil, req, httptest.NewRecorder()).DryRun())
+
+	req, _ = http.NewRequest("POST", "http://foo.com/api/v1/foo?dry_run=false", nil)
+	assert.False(NewContext(nil, req, httptest.NewRecorder()).DryRun())
+
+	req, _ = http.NewRequest("POST", "http://foo.com/api/v1/foo", nil)
+	req.Header.Set("Prefer", "return=minimal, handling=dry-run")
+	assert.True(NewContext(nil, req, httptest.NewRecorder()).DryRun())
+}
+
+// Ensures that dry runs are passed to handlers which support them.
+func TestHandleCreateDryRun(t *testing.T) {
+	assert := assert.New(t)
+	handler := &dryRunResourceHandler{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+	createHandler, _ := api.(*muxAPI).getRouteHandler("foo:create")
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo?dry_run=true",
+		bytes.NewBufferString("{}"))
+	resp := httptest.NewRecorder()
+
+	createHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusCreated, resp.Code, "Incorrect response code")
+	assert.True(handler.dryRun)
+	assert.Equal(dryRunPreference, resp.Header().Get("Preference-Applied"))
+}
+
+// Ensures that dry runs are rejected for handlers which don't support them.
+func TestHandleCreateDryRunNotSupported(t *testing.T) {
+	assert := assert.New(t)
+	handler := new(MockResourceHandler)
+	handler.On("ResourceName").Return("foo")
+	handler.On("Authenticate").Return(nil)
+	handler.On("ValidVersions").Return(nil)
+	handler.On("Rules").Return(&rules{})
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+	createHandler, _ := api.(*muxAPI).getRouteHandler("foo:create")
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo", bytes.NewBufferString("{}"))
+	req.Header.Set("Prefer", "handling=dry-run")
+	resp := httptest.NewRecorder()
+
+	createHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusBadRequest, resp.Code, "Incorrect response code")
+	handler.AssertNotCalled(t, "CreateResource")
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+// EnvelopeBuilder builds the response body sent to the client from the default
+// envelope, e.g. {"status": 200, "reason": "OK", "messages": [], "result": {...}}. It
+// can rename keys, add metadata such as a request ID, or return a value other than a
+// Payload, such as the bare result. It's not called for 204 No Content responses or for
+// error responses serialized as problem details. The envelope is reused once the
+// response is written, so it must not be retained.
+type EnvelopeBuilder func(RequestContext, Payload) interface{}
+
+// ValueSerializer can be implemented by a ResponseSerializer to serialize response
+// bodies which aren't Payloads, e.g. bare results returned by an EnvelopeBuilder.
+// Serializers which don't implement it are passed the default envelope instead.
+type ValueSerializer interface {
+	// SerializeValue marshals a response body into a byte slice to be sent over the
+	// wire.
+	SerializeValue(interface{}) ([]byte, error)
+}
+
+// NoEnvelope is an EnvelopeBuilder which disables the envelope of successful
+// responses, sending the bare resource or list of resources instead. Error responses
+// keep the default envelope so clients can still read the messages.
+func NoEnvelope(ctx RequestContext, envelope Payload) interface{} {
+	if ctx.Error() != nil {
+		return envelope
+	}
+	if r, ok := envelope[results]; ok {
+		return r
+	}
+	return envelope[result]
+}
+
+// RenameEnvelopeKeys returns an EnvelopeBuilder which renames the keys of the default
+// envelope, e.g. {"result": "data", "messages": "notices"}. Keys not in the map are
+// left as is.
+func RenameEnvelopeKeys(names map[string]string) EnvelopeBuilder {
+	return func(ctx RequestContext, envelope Payload) interface{} {
+		renamed := make(Payload, len(envelope))
+		for key, value := range envelope {
+			if name, ok := names[key]; ok {
+				key = name
+			}
+			renamed[key] = value
+		}
+		return renamed
+	}
+}
+
+// buildEnvelope sets the body of the response using the EnvelopeBuilder.
+func buildEnvelope(ctx RequestContext, builder EnvelopeBuilder, resp *response) {
+	if builder == nil || resp.Payload == nil {
+		return
+	}
+	resp.body = builder(ctx, resp.Payload)
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Ensures that NoEnvelope sends bare resources and lists but keeps the envelope of
+// errors.
+func TestNoEnvelope(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{Envelope: NoEnvelope})
+	api.RegisterResourceHandler(&statusResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo", nil)
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusPartialContent, resp.Code, "Incorrect response code")
+	assert.Equal(`[{"foo":"hello"}]`, resp.Body.String(), "Incorrect response string")
+
+	req, _ = http.NewRequest("GET", "http://foo.com/api/v1/foo?next=fail", nil)
+	resp = httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusNotFound, resp.Code, "Incorrect response code")
+	assert.Equal(
+		`{"messages":["No foos"],"reason":"Not Found","status":404}`,
+		resp.Body.String(),
+		"Incorrect response string",
+	)
+}
+
+// Ensures that RenameEnvelopeKeys renames the keys of the envelope.
+func TestRenameEnvelopeKeys(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{
+		Envelope: RenameEnvelopeKeys(map[string]string{"results": "data", "messages": "notices"}),
+	})
+	api.RegisterResourceHandler(&statusResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo", nil)
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(
+		`{"data":[{"foo":"hello"}],"notices":[],"reason":"Partial Content","status":206}`,
+		resp.Body.String(),
+		"Incorrect response string",
+	)
+}
+
+// Ensures that an EnvelopeBuilder can add metadata to the envelope.
+func TestEnvelopeMetadata(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{
+		Envelope: func(ctx RequestContext, envelope Payload) interface{} {
+			envelope["request_id"] = ctx.Header().Get("X-Request-ID")
+			return envelope
+		},
+	})
+	api.RegisterResourceHandler(&statusResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo", nil)
+	req.Header.Set("X-Request-ID", "abc")
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(
+		`{"messages":[],"reason":"Partial Content","request_id":"abc","results":[{"foo":"hello"}],"status":206}`,
+		resp.Body.String(),
+		"Incorrect response string",
+	)
+}
+
+// Ensures that serializers which can't serialize bare values are passed the default
+// envelope.
+func TestNoEnvelopeUnsupportedSerializer(t *testing.T) {
+	assert := assert.New(t)
+	var serialized Payload
+	api := NewAPI(&Configuration{Envelope: NoEnvelope})
+	api.RegisterResourceHandler(&statusResourceHandler{})
+	api.RegisterResponseSerializer("foo", &payloadRecorder{payload: &serialized})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo?format=foo", nil)
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusPartialContent, resp.Code, "Incorrect response code")
+	assert.Equal(206, serialized[status])
+}
+
+// payloadRecorder is a ResponseSerializer which records the Payload it serializes.
+type payloadRecorder struct {
+	payload *Payload
+}
+
+func (p *payloadRecorder) Serialize(payload Payload) ([]byte, error) {
+	*p.payload = payload
+	return []byte{}, nil
+}
+
+func (p *payloadRecorder) ContentType() string {
+	return "application/foo"
+}
@@ -16,16 +16,36 @@ limitations under the License.
 
 package rest
 
-import "net/http"
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
 
 // statusUnprocessableEntity indicates the request was well-formed but was
 // unable to be followed due to semantic errors.
 const statusUnprocessableEntity = 422
 
+// Error codes of the validation errors returned when inbound Rules fail. Both take the
+// field name as their argument.
+const (
+	// CodeMissingField identifies errors for missing required fields.
+	CodeMissingField = "missing_field"
+
+	// CodeInvalidField identifies errors for fields whose values can't be coerced to
+	// their Rule's type.
+	CodeInvalidField = "invalid_field"
+)
+
 // Error is an implementation of the error interface representing an HTTP error.
 type Error struct {
-	reason string
-	status int
+	reason     string
+	status     int
+	code       string
+	args       []interface{}
+	retryable  bool
+	retryAfter time.Duration
 }
 
 // Error returns the Error message.
@@ -34,47 +54,146 @@ func (r Error) Error() string { return r.reason }
 // Status returns the HTTP status code.
 func (r Error) Status() int { return r.status }
 
+// Code returns the code identifying the kind of error, or an empty string if it
+// doesn't have one.
+func (r Error) Code() string { return r.code }
+
+// WithCode returns a copy of the Error identified by the code. If the API has a
+// Translator configured, the code is used to look up the message sent to clients in
+// their language, formatted with the arguments.
+func (r Error) WithCode(code string, args ...interface{}) Error {
+	r.code = code
+	r.args = args
+	return r
+}
+
+// WithRetryAfter returns a copy of the Error marked as retryable after the delay.
+// Responses for retryable errors include a Retry-After header if the delay is positive,
+// and are counted in API's RetryStats.
+func (r Error) WithRetryAfter(delay time.Duration) Error {
+	r.retryable = true
+	r.retryAfter = delay
+	return r
+}
+
+// Retryable returns true if the request may succeed if it's retried.
+func (r Error) Retryable() bool { return r.retryable }
+
+// RetryAfter returns the delay clients should wait before retrying the request, or
+// zero if there isn't one.
+func (r Error) RetryAfter() time.Duration { return r.retryAfter }
+
+// FieldErrors is an error describing the invalid fields of a request payload, keyed by
+// field name. Requests failing with FieldErrors receive 422 Unprocessable Entity with
+// the field error messages in the "errors" section of the response, so clients can
+// report them inline. It's returned when inbound Rules fail and may be returned by
+// ResourceHandlers validating payloads. Field errors which are an Error with a code
+// are translated like other errors.
+type FieldErrors map[string]error
+
+// Error returns the field errors ordered by field name.
+func (f FieldErrors) Error() string {
+	fields := make([]string, 0, len(f))
+	for field := range f {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	messages := make([]string, len(fields))
+	for i, field := range fields {
+		messages[i] = f[field].Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Messages returns the field error messages keyed by field name.
+func (f FieldErrors) Messages() map[string]string {
+	messages := make(map[string]string, len(f))
+	for field, err := range f {
+		messages[field] = err.Error()
+	}
+	return messages
+}
+
+// errorStatus returns the HTTP status code for the error, defaulting to 500 Internal
+// Server Error for errors which aren't an Error or FieldErrors.
+func errorStatus(err error) int {
+	switch e := err.(type) {
+	case Error:
+		return e.Status()
+	case FieldErrors:
+		return statusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
 // ResourceNotFound returns a Error for a 404 Not Found error.
 func ResourceNotFound(reason string) Error {
-	return Error{reason, http.StatusNotFound}
+	return Error{reason: reason, status: http.StatusNotFound}
 }
 
 // ResourceNotPermitted returns a Error for a 403 Forbidden error.
 func ResourceNotPermitted(reason string) Error {
-	return Error{reason, http.StatusForbidden}
+	return Error{reason: reason, status: http.StatusForbidden}
 }
 
 // ResourceConflict returns a Error for a 409 Conflict error.
 func ResourceConflict(reason string) Error {
-	return Error{reason, http.StatusConflict}
+	return Error{reason: reason, status: http.StatusConflict}
 }
 
 // BadRequest returns a Error for a 400 Bad Request error.
 func BadRequest(reason string) Error {
-	return Error{reason, http.StatusBadRequest}
+	return Error{reason: reason, status: http.StatusBadRequest}
+}
+
+// PreconditionFailed returns a Error for a 412 Precondition Failed error.
+func PreconditionFailed(reason string) Error {
+	return Error{reason: reason, status: http.StatusPreconditionFailed}
+}
+
+// PreconditionRequired returns a Error for a 428 Precondition Required error.
+func PreconditionRequired(reason string) Error {
+	return Error{reason: reason, status: http.StatusPreconditionRequired}
+}
+
+// NotModified returns a Error for a 304 Not Modified response to a conditional GET or
+// HEAD request. It's sent without a body.
+func NotModified() Error {
+	return Error{reason: http.StatusText(http.StatusNotModified), status: http.StatusNotModified}
 }
 
 // UnprocessableRequest returns a Error for a 422 Unprocessable Entity error.
 func UnprocessableRequest(reason string) Error {
-	return Error{reason, statusUnprocessableEntity}
+	return Error{reason: reason, status: statusUnprocessableEntity}
 }
 
 // UnauthorizedRequest returns a Error for a 401 Unauthorized error.
 func UnauthorizedRequest(reason string) Error {
-	return Error{reason, http.StatusUnauthorized}
+	return Error{reason: reason, status: http.StatusUnauthorized}
 }
 
 // MethodNotAllowed returns a Error for a 405 Method Not Allowed error.
 func MethodNotAllowed(reason string) Error {
-	return Error{reason, http.StatusMethodNotAllowed}
+	return Error{reason: reason, status: http.StatusMethodNotAllowed}
 }
 
 // InternalServerError returns a Error for a 500 Internal Server error.
 func InternalServerError(reason string) Error {
-	return Error{reason, http.StatusInternalServerError}
+	return Error{reason: reason, status: http.StatusInternalServerError}
+}
+
+// TooManyRequests returns a retryable Error for a 429 Too Many Requests error.
+func TooManyRequests(reason string) Error {
+	return Error{reason: reason, status: http.StatusTooManyRequests, retryable: true}
+}
+
+// ServiceUnavailable returns a retryable Error for a 503 Service Unavailable error.
+func ServiceUnavailable(reason string) Error {
+	return Error{reason: reason, status: http.StatusServiceUnavailable, retryable: true}
 }
 
 // CustomError returns an Error for the given HTTP status code.
 func CustomError(reason string, status int) Error {
-	return Error{reason, status}
+	return Error{reason: reason, status: status}
 }
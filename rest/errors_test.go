@@ -17,7 +17,10 @@ limitations under the License.
 package rest
 
 import (
+	"bytes"
+	"errors"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -63,3 +66,46 @@ func TestErrors(t *testing.T) {
 	assert.Equal("foo", err.Error())
 	assert.Equal(http.StatusInternalServerError, err.Status())
 }
+
+// Ensures that errorStatus maps errors to HTTP status codes.
+func TestErrorStatus(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(http.StatusNotFound, errorStatus(ResourceNotFound("foo")))
+	assert.Equal(422, errorStatus(FieldErrors{"foo": errors.New("bar")}))
+	assert.Equal(http.StatusInternalServerError, errorStatus(errors.New("foo")))
+}
+
+type validatingResourceHandler struct {
+	bindingResourceHandler
+}
+
+func (v *validatingResourceHandler) UpdateBoundResource(ctx RequestContext, id string,
+	resource interface{}, version string) (Resource, error) {
+
+	return nil, FieldErrors{"name": errors.New("Name is taken")}
+}
+
+// Ensures that requests failing inbound Rules respond with 422 and the field errors.
+func TestFieldErrorsResponse(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&validatingResourceHandler{})
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/widgets",
+		bytes.NewBufferString(`{"name": [], "count": "x"}`))
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(422, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), `"errors":{"count":"strconv.ParseInt: parsing \"x\": `+
+		`invalid syntax","name":"Unable to coerce slice to string"}`)
+
+	req, _ = http.NewRequest("PUT", "http://foo.com/api/v1/widgets/1",
+		bytes.NewBufferString(`{"name": "foo"}`))
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(422, resp.Code, "Incorrect response code")
+	assert.Equal(`{"errors":{"name":"Name is taken"},"messages":["Name is taken"],`+
+		`"reason":"Unprocessable Entity","status":422}`, resp.Body.String())
+}
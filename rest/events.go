@@ -0,0 +1,217 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultEventHeartbeat is the interval between heartbeats sent to event stream
+	// clients if one isn't configured.
+	defaultEventHeartbeat = 15 * time.Second
+
+	// eventBufferSize is the number of events buffered for each subscriber. Events
+	// published to subscribers with full buffers are dropped.
+	eventBufferSize = 64
+)
+
+// Event is a change to a resource which is published to clients subscribed to the
+// resource's events.
+type Event struct {
+	// ID identifies the event. If empty, a sequential ID is assigned when it's
+	// published.
+	ID string
+
+	// Type is the kind of change, e.g. "created" or "deleted".
+	Type string
+
+	// Resource is the changed resource. Outbound Rules are applied to it for each
+	// subscriber's version.
+	Resource Resource
+
+	// Tenant is the tenant owning the resource when Tenancy is configured. The event is
+	// only sent to clients of the tenant.
+	Tenant string
+}
+
+// EventsResourceHandler can be implemented by a ResourceHandler to opt into streaming
+// change events to clients. If implemented and Events returns true, the following
+// endpoint is registered relative to the handler's read list URI:
+//
+//	GET /api/:version/resourceName/events
+//
+// which serves Server-Sent Events published for the resource with API#Publish.
+type EventsResourceHandler interface {
+	// Events returns true if the handler's change events should be streamed.
+	Events() bool
+}
+
+// eventsEnabled returns true if the ResourceHandler opted into streaming events.
+func eventsEnabled(handler ResourceHandler) bool {
+	e, ok := unwrapResourceHandler(handler).(EventsResourceHandler)
+	return ok && e.Events()
+}
+
+// eventSubscription receives the events published for a tenant's resource.
+type eventSubscription struct {
+	tenant   string
+	resource string
+	events   chan Event
+}
+
+// eventBroker fans events published for resources out to their subscribers, keyed by
+// tenant and resource. It's safe for concurrent use.
+type eventBroker struct {
+	mu          sync.RWMutex
+	sequence    uint64
+	subscribers map[[2]string]map[*eventSubscription]bool
+}
+
+// newEventBroker returns a newly allocated eventBroker.
+func newEventBroker() *eventBroker {
+	return &eventBroker{subscribers: map[[2]string]map[*eventSubscription]bool{}}
+}
+
+// subscribe returns a new subscription to the events of the tenant's resource. The
+// tenant is empty if Tenancy isn't configured.
+func (b *eventBroker) subscribe(tenant, resource string) *eventSubscription {
+	sub := &eventSubscription{
+		tenant:   tenant,
+		resource: resource,
+		events:   make(chan Event, eventBufferSize),
+	}
+	key := [2]string{tenant, resource}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribers[key]; !ok {
+		b.subscribers[key] = map[*eventSubscription]bool{}
+	}
+	b.subscribers[key][sub] = true
+	return sub
+}
+
+// unsubscribe removes the subscription so it no longer receives events.
+func (b *eventBroker) unsubscribe(sub *eventSubscription) {
+	key := [2]string{sub.tenant, sub.resource}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subscribers[key], sub)
+	if len(b.subscribers[key]) == 0 {
+		delete(b.subscribers, key)
+	}
+}
+
+// publish sends the event to the subscribers of the event's tenant's resource.
+// Subscribers which aren't keeping up have the event dropped rather than blocking the
+// publisher.
+func (b *eventBroker) publish(resource string, event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if event.ID == "" {
+		b.sequence++
+		event.ID = strconv.FormatUint(b.sequence, 10)
+	}
+	for sub := range b.subscribers[[2]string{event.Tenant, resource}] {
+		select {
+		case sub.events <- event:
+		default:
+		}
+	}
+}
+
+// eventHeartbeat returns the interval between heartbeats sent to event stream clients.
+func eventHeartbeat(config *Configuration) time.Duration {
+	if config.EventHeartbeat > 0 {
+		return config.EventHeartbeat
+	}
+	return defaultEventHeartbeat
+}
+
+// handleEvents returns a Handler which streams the events published for the resource
+// to the client as Server-Sent Events until it disconnects or the API is shut down.
+// Heartbeat comments are sent periodically to keep the connection alive through
+// proxies.
+func (h requestHandler) handleEvents(handler ResourceHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := h.newContext(w, r)
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			h.sendResponse(ctx.setError(InternalServerError("Streaming is not supported")))
+			return
+		}
+
+		sub := h.events().subscribe(resolvedTenant(r), handler.ResourceName())
+		defer h.events().unsubscribe(sub)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, ": connected\n\n")
+		flusher.Flush()
+
+		heartbeat := time.NewTicker(eventHeartbeat(h.Configuration()))
+		defer heartbeat.Stop()
+
+		rules := visibleRules(ctx, handler.Rules())
+		version := ctx.Version()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-h.shuttingDown():
+				return
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+					return
+				}
+			case event := <-sub.events:
+				event.Resource = applyOutboundRules(event.Resource, rules, version)
+				if err := writeEvent(w, event); err != nil {
+					return
+				}
+			}
+			flusher.Flush()
+		}
+	})
+}
+
+// writeEvent writes the Event in the Server-Sent Events format.
+func writeEvent(w http.ResponseWriter, event Event) error {
+	data, err := json.Marshal(event.Resource)
+	if err != nil {
+		return err
+	}
+
+	message := "id: " + event.ID + "\n"
+	if event.Type != "" {
+		message += "event: " + event.Type + "\n"
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		message += "data: " + line + "\n"
+	}
+
+	_, err = fmt.Fprint(w, message+"\n")
+	return err
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type eventsResourceHandler struct {
+	BaseResourceHandler
+}
+
+func (e *eventsResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (e *eventsResourceHandler) Events() bool {
+	return true
+}
+
+// readEvent reads lines from the stream up to the next blank line.
+func readEvent(reader *bufio.Reader) []string {
+	lines := []string{}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil || line == "\n" {
+			return lines
+		}
+		lines = append(lines, line[:len(line)-1])
+	}
+}
+
+// Ensures that events published for a resource are streamed to its subscribers with
+// heartbeats in between.
+func TestHandleEvents(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{EventHeartbeat: 50 * time.Millisecond})
+	api.RegisterResourceHandler(&eventsResourceHandler{})
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/v1/foo/events")
+	if !assert.Nil(err) {
+		return
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal("text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	assert.Equal([]string{": connected"}, readEvent(reader))
+
+	api.Publish("bar", Event{Type: "created", Resource: &TestResource{Foo: "ignored"}})
+	api.Publish("foo", Event{Type: "created", Resource: &TestResource{Foo: "hello"}})
+	assert.Equal([]string{"id: 2", "event: created", `data: {"foo":"hello"}`}, readEvent(reader))
+
+	assert.Equal([]string{": heartbeat"}, readEvent(reader))
+}
+
+// Ensures that the events endpoint is only registered for handlers which opt in.
+func TestHandleEventsNotRegistered(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&streamingResourceHandler{})
+
+	assert.Nil(api.(*muxAPI).muxRouter().Get("foo:" + string(HandleEvents)))
+}
+
+// Ensures that events are only sent to subscribers of the event's tenant.
+func TestEventBrokerTenants(t *testing.T) {
+	assert := assert.New(t)
+	broker := newEventBroker()
+	a := broker.subscribe("a", "foo")
+	b := broker.subscribe("b", "foo")
+
+	broker.publish("foo", Event{Type: "created", Tenant: "a"})
+
+	assert.Len(a.events, 1)
+	assert.Len(b.events, 0)
+
+	broker.unsubscribe(a)
+	broker.unsubscribe(b)
+	assert.Empty(broker.subscribers)
+}
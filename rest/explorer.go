@@ -0,0 +1,214 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"sort"
+)
+
+// explorerPathVar matches path template variables and their patterns, e.g.
+// {version:[^/]+}.
+var explorerPathVar = regexp.MustCompile(`\{([^:{}]+)(:[^{}]*(\{[^{}]*\}[^{}]*)*)?\}`)
+
+// explorerSpec describes the API to the explorer.
+type explorerSpec struct {
+	Versions  []string           `json:"versions"`
+	Resources []explorerResource `json:"resources"`
+	Routes    []RouteInfo        `json:"routes"`
+}
+
+// explorerResource describes a registered resource to the explorer.
+type explorerResource struct {
+	Name       string                    `json:"name"`
+	Type       string                    `json:"type"`
+	Versions   []string                  `json:"versions"`
+	Operations []explorerOperation       `json:"operations"`
+	Schemas    map[string]explorerSchema `json:"schemas"`
+}
+
+// explorerOperation describes an endpoint of a resource to the explorer.
+type explorerOperation struct {
+	Operation   string   `json:"operation"`
+	Methods     []string `json:"methods"`
+	Path        string   `json:"path"`
+	Description string   `json:"description,omitempty"`
+}
+
+// explorerSchema describes the fields of a resource for a version to the explorer.
+type explorerSchema struct {
+	Input           []field `json:"input"`
+	Output          []field `json:"output"`
+	ExampleRequest  string  `json:"exampleRequest,omitempty"`
+	ExampleResponse string  `json:"exampleResponse,omitempty"`
+}
+
+// EnableExplorer binds an HTML API explorer under the API's base path:
+//
+//	GET /api/explorer        explorer page
+//	GET /api/explorer/spec   routes, fields, and examples described by the page
+//
+// The explorer is generated from the registered routes and the ResourceHandlers'
+// Rules, like the generated documentation, and lets developers browse resources and
+// issue test requests against the running service. Requests are authenticated with
+// the Configuration's DebugAuthenticate function. An error is returned if it isn't set
+// since the explorer exposes the API's internals.
+func (r *muxAPI) EnableExplorer() error {
+	authenticate := r.config.DebugAuthenticate
+	if authenticate == nil {
+		return errors.New("DebugAuthenticate must be configured to enable the explorer")
+	}
+	middleware := []RequestMiddleware{newAuthMiddleware(authenticate)}
+	uri := r.apiBase() + "/explorer"
+
+	r.addRoutes(nil, func(routes Router) {
+		r.bind(routes, "explorerSpec", "GET", uri+"/spec",
+			applyMiddleware(r.handler.handleExplorerSpec(), middleware))
+		r.bind(routes, "explorer", "GET", uri,
+			applyMiddleware(http.HandlerFunc(serveExplorer), middleware))
+	})
+	return nil
+}
+
+// serveExplorer responds with the explorer page, which loads the spec from the spec
+// endpoint.
+func serveExplorer(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write([]byte(explorerTemplate))
+}
+
+// handleExplorerSpec returns a Handler which responds with the explorerSpec of the
+// API.
+func (h requestHandler) handleExplorerSpec() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := h.newContext(w, r)
+		spec := newExplorerSpec(h.ResourceHandlers(), h.Routes(), h.Configuration())
+		h.sendResponse(ctx.setResult(spec).setStatus(http.StatusOK))
+	})
+}
+
+// newExplorerSpec returns the explorerSpec of the ResourceHandlers and routes.
+func newExplorerSpec(handlers []ResourceHandler, routes []RouteInfo,
+	config *Configuration) explorerSpec {
+
+	spec := explorerSpec{Resources: []explorerResource{}, Routes: []RouteInfo{}}
+	allVersions := map[string]bool{}
+	seen := map[string]bool{}
+	for _, handler := range handlers {
+		name := handler.ResourceName()
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		resource := explorerResource{
+			Name:       name,
+			Type:       handlerTypeName(handler),
+			Versions:   explorerVersions(handler, config),
+			Operations: []explorerOperation{},
+			Schemas:    map[string]explorerSchema{},
+		}
+		for _, version := range resource.Versions {
+			allVersions[version] = true
+			resource.Schemas[version] = explorerSchema{
+				Input:           getInputFields(handler.Rules().ForVersion(version)),
+				Output:          getOutputFields(handler.Rules().ForVersion(version)),
+				ExampleRequest:  buildExampleRequest(handler.Rules(), false, version),
+				ExampleResponse: buildExampleResponse(handler.Rules(), false, version),
+			}
+		}
+		for _, route := range routes {
+			if route.Resource != name {
+				continue
+			}
+			resource.Operations = append(resource.Operations, explorerOperation{
+				Operation:   route.Operation,
+				Methods:     route.Methods,
+				Path:        explorerPath(route.Path),
+				Description: operationDocumentation(handler, HandleMethod(route.Operation)),
+			})
+		}
+		spec.Resources = append(spec.Resources, resource)
+	}
+
+	for _, route := range routes {
+		if route.Resource == "" {
+			route.Path = explorerPath(route.Path)
+			spec.Routes = append(spec.Routes, route)
+		}
+	}
+	spec.Versions = make([]string, 0, len(allVersions))
+	for version := range allVersions {
+		spec.Versions = append(spec.Versions, version)
+	}
+	sort.Strings(spec.Versions)
+	return spec
+}
+
+// explorerVersions returns the concrete versions of the ResourceHandler, i.e. those
+// named by its Rules and ValidVersions other than version ranges, and the
+// DefaultVersion.
+func explorerVersions(handler ResourceHandler, config *Configuration) []string {
+	versionSet := map[string]bool{}
+	for _, version := range handlerVersions(handler) {
+		versionSet[version] = true
+	}
+	for _, version := range handler.ValidVersions() {
+		if !isVersionRange(version) {
+			versionSet[version] = true
+		}
+	}
+	if config.DefaultVersion != "" {
+		versionSet[config.DefaultVersion] = true
+	}
+
+	versions := make([]string, 0, len(versionSet))
+	for version := range versionSet {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// explorerPath returns the route path template without variable patterns, e.g.
+// /api/v{version}/foo/{resource_id}.
+func explorerPath(path string) string {
+	return explorerPathVar.ReplaceAllString(path, "{$1}")
+}
+
+// operationDocumentation returns the ResourceHandler's documentation of the operation,
+// if any.
+func operationDocumentation(handler ResourceHandler, operation HandleMethod) string {
+	switch operation {
+	case HandleCreate:
+		return handler.CreateDocumentation()
+	case HandleReadList:
+		return handler.ReadListDocumentation()
+	case HandleRead:
+		return handler.ReadDocumentation()
+	case HandleUpdateList:
+		return handler.UpdateListDocumentation()
+	case HandleUpdate:
+		return handler.UpdateDocumentation()
+	case HandleDelete:
+		return handler.DeleteDocumentation()
+	}
+	return ""
+}
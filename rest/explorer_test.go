@@ -0,0 +1,138 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type explorerResourceHandler struct {
+	BaseResourceHandler
+}
+
+func (e *explorerResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (e *explorerResourceHandler) Rules() Rules {
+	return NewRules((*TestResource)(nil),
+		&Rule{Field: "Foo", FieldAlias: "foo", Type: String, Required: true,
+			Versions: []string{"1", "2"}, DocString: "The foo"},
+	)
+}
+
+func (e *explorerResourceHandler) ReadDocumentation() string {
+	return "Reads a foo"
+}
+
+// Ensures that explorerPath strips the patterns of path template variables.
+func TestExplorerPath(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("/api/v{version}/foo/{resource_id}",
+		explorerPath("/api/v{version:[^/]+}/foo/{resource_id}"))
+	assert.Equal("/foo/{id}/bar", explorerPath("/foo/{id:[0-9]{3}}/bar"))
+	assert.Equal("/foo", explorerPath("/foo"))
+}
+
+// Ensures that EnableExplorer binds the authenticated explorer page and the spec
+// describing the registered resources' operations and fields.
+func TestEnableExplorer(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{DebugAuthenticate: debugAuthenticate})
+	api.RegisterResourceHandler(&explorerResourceHandler{})
+	assert.NoError(api.EnableExplorer())
+
+	get := func(path string, authorized bool) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "http://foo.com"+path, nil)
+		if authorized {
+			req.Header.Set("Authorization", "secret")
+		}
+		resp := httptest.NewRecorder()
+		api.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := get("/api/explorer", false)
+	assert.Equal(http.StatusUnauthorized, resp.Code)
+	resp = get("/api/explorer/spec", false)
+	assert.Equal(http.StatusUnauthorized, resp.Code)
+
+	resp = get("/api/explorer", true)
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal("text/html; charset=utf-8", resp.Header().Get("Content-Type"))
+	assert.Contains(resp.Body.String(), "REST API Explorer")
+
+	resp = get("/api/explorer/spec", true)
+	assert.Equal(http.StatusOK, resp.Code)
+	var body struct {
+		Result explorerSpec `json:"result"`
+	}
+	assert.NoError(json.Unmarshal(resp.Body.Bytes(), &body))
+	spec := body.Result
+
+	assert.Equal([]string{"1", "2"}, spec.Versions)
+	if !assert.Len(spec.Resources, 1) {
+		return
+	}
+	resource := spec.Resources[0]
+	assert.Equal("foo", resource.Name)
+	assert.Equal("TestResource", resource.Type)
+	assert.Equal([]string{"1", "2"}, resource.Versions)
+	assert.Contains(resource.Operations, explorerOperation{
+		Operation:   "read",
+		Methods:     []string{"GET"},
+		Path:        "/api/v{version}/foo/{resource_id}",
+		Description: "Reads a foo",
+	})
+	assert.Contains(resource.Operations, explorerOperation{
+		Operation: "create",
+		Methods:   []string{"POST"},
+		Path:      "/api/v{version}/foo",
+	})
+
+	schema := resource.Schemas["2"]
+	assert.Equal([]field{{"name": "foo", "required": "required", "type": "string",
+		"description": "The foo"}}, schema.Input)
+	assert.Equal([]field{{"name": "foo", "type": "string", "description": "The foo"}},
+		schema.Output)
+	assert.Contains(schema.ExampleRequest, `"foo"`)
+
+	var explorerRoutes []string
+	for _, route := range spec.Routes {
+		explorerRoutes = append(explorerRoutes, route.Path)
+	}
+	assert.Contains(explorerRoutes, "/api/explorer/spec")
+}
+
+// Ensures that EnableExplorer requires DebugAuthenticate.
+func TestEnableExplorerUnauthenticated(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+
+	assert.Error(api.EnableExplorer())
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/explorer", nil)
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	assert.Equal(http.StatusNotFound, resp.Code, "Incorrect response code")
+}
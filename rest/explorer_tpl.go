@@ -0,0 +1,211 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+// explorerTemplate is the API explorer page. It loads the explorerSpec from the spec
+// endpoint relative to the page and sends test requests with fetch.
+const explorerTemplate = `
+<!DOCTYPE HTML>
+<html lang="en">
+    <head>
+        <meta charset="utf-8">
+        <title>REST API Explorer</title>
+        <meta name="viewport" content="width=device-width, initial-scale=1">
+        <style>
+            body { font-family: sans-serif; margin: 0; color: #333; }
+            header { background: #2c3e50; color: #fff; padding: 12px 24px; }
+            header select { margin-left: 12px; }
+            main { display: flex; }
+            nav { width: 220px; border-right: 1px solid #ddd; padding: 12px; }
+            nav a { display: block; padding: 4px 0; cursor: pointer; color: #2980b9; }
+            section { flex: 1; padding: 12px 24px; }
+            table { border-collapse: collapse; margin-bottom: 12px; }
+            td, th { border: 1px solid #ddd; padding: 4px 8px; text-align: left; }
+            pre, textarea { background: #f7f7f7; padding: 8px; font-family: monospace; }
+            textarea { width: 100%; box-sizing: border-box; }
+            input[type=text] { width: 100%; box-sizing: border-box; font-family: monospace; }
+            .method { display: inline-block; width: 70px; font-weight: bold; }
+            .operation { border: 1px solid #ddd; margin-bottom: 8px; padding: 8px; cursor: pointer; }
+        </style>
+    </head>
+
+    <body>
+        <header>
+            <strong>REST API Explorer</strong>
+            <label>Version <select id="version"></select></label>
+        </header>
+        <main>
+            <nav id="resources"></nav>
+            <section id="resource"><p>Select a resource.</p></section>
+        </main>
+
+        <template id="console">
+            <h3>Request</h3>
+            <p><select name="method"></select></p>
+            <p><input type="text" name="path"></p>
+            <p>Headers (one per line, "Name: value")</p>
+            <textarea name="headers" rows="3"></textarea>
+            <p>Body</p>
+            <textarea name="body" rows="8"></textarea>
+            <p><button name="send">Send</button></p>
+            <h3>Response</h3>
+            <pre name="response"></pre>
+        </template>
+
+        <script>
+            var spec, current;
+            var versionSelect = document.getElementById("version");
+
+            function el(tag, text) {
+                var e = document.createElement(tag);
+                if (text !== undefined) {
+                    e.textContent = text;
+                }
+                return e;
+            }
+
+            function fieldsTable(title, fields) {
+                var div = el("div");
+                div.appendChild(el("h3", title));
+                if (!fields || fields.length === 0) {
+                    div.appendChild(el("p", "None"));
+                    return div;
+                }
+                var table = el("table");
+                var head = el("tr");
+                ["Name", "Type", "Required", "Description"].forEach(function(h) {
+                    head.appendChild(el("th", h));
+                });
+                table.appendChild(head);
+                fields.forEach(function(f) {
+                    var row = el("tr");
+                    [f.name, f.type, f.required || "", f.description || ""].forEach(function(v) {
+                        row.appendChild(el("td", v));
+                    });
+                    table.appendChild(row);
+                });
+                div.appendChild(table);
+                return div;
+            }
+
+            function showResource(resource) {
+                current = resource;
+                var version = versionSelect.value;
+                var section = document.getElementById("resource");
+                section.innerHTML = "";
+                section.appendChild(el("h2", resource.name + " (" + resource.type + ")"));
+
+                var schema = resource.schemas[version] || {};
+                var panel = document.getElementById("console").content.cloneNode(true);
+                var form = {};
+                ["method", "path", "headers", "body", "send", "response"].forEach(function(name) {
+                    form[name] = panel.querySelector("[name=" + name + "]");
+                });
+
+                section.appendChild(el("h3", "Operations"));
+                resource.operations.forEach(function(op) {
+                    var methods = op.methods && op.methods.length ? op.methods : ["GET"];
+                    var path = op.path.replace("{version}", version);
+                    var div = el("div");
+                    div.className = "operation";
+                    var method = el("span", methods.join(", "));
+                    method.className = "method";
+                    div.appendChild(method);
+                    div.appendChild(el("code", path));
+                    div.appendChild(el("span", " " + op.operation));
+                    if (op.description) {
+                        div.appendChild(el("p", op.description));
+                    }
+                    div.onclick = function() {
+                        form.method.value = methods[0];
+                        form.path.value = path;
+                        var hasBody = ["POST", "PUT", "PATCH"].indexOf(methods[0]) >= 0;
+                        form.body.value = hasBody ? (schema.exampleRequest || "") : "";
+                    };
+                    section.appendChild(div);
+                });
+
+                section.appendChild(fieldsTable("Input fields", schema.input));
+                section.appendChild(fieldsTable("Output fields", schema.output));
+                if (schema.exampleResponse) {
+                    section.appendChild(el("h3", "Example response"));
+                    section.appendChild(el("pre", schema.exampleResponse));
+                }
+
+                ["GET", "POST", "PUT", "PATCH", "DELETE"].forEach(function(m) {
+                    form.method.appendChild(el("option", m));
+                });
+                form.send.onclick = function() {
+                    var headers = {"Accept": "application/json"};
+                    form.headers.value.split("\n").forEach(function(line) {
+                        var i = line.indexOf(":");
+                        if (i > 0) {
+                            headers[line.slice(0, i).trim()] = line.slice(i + 1).trim();
+                        }
+                    });
+                    var init = {method: form.method.value, headers: headers, credentials: "same-origin"};
+                    if (form.body.value && form.method.value !== "GET" && form.method.value !== "DELETE") {
+                        headers["Content-Type"] = headers["Content-Type"] || "application/json";
+                        init.body = form.body.value;
+                    }
+                    form.response.textContent = "...";
+                    fetch(form.path.value, init).then(function(resp) {
+                        var lines = [resp.status + " " + resp.statusText];
+                        resp.headers.forEach(function(value, name) {
+                            lines.push(name + ": " + value);
+                        });
+                        return resp.text().then(function(text) {
+                            try {
+                                text = JSON.stringify(JSON.parse(text), null, 2);
+                            } catch (e) {}
+                            form.response.textContent = lines.join("\n") + "\n\n" + text;
+                        });
+                    }).catch(function(err) {
+                        form.response.textContent = String(err);
+                    });
+                };
+                section.appendChild(panel);
+            }
+
+            fetch(location.pathname.replace(/\/$/, "") + "/spec", {
+                headers: {"Accept": "application/json"},
+                credentials: "same-origin"
+            }).then(function(resp) {
+                return resp.json();
+            }).then(function(body) {
+                spec = body.result;
+                spec.versions.forEach(function(v) {
+                    versionSelect.appendChild(el("option", v));
+                });
+                versionSelect.onchange = function() {
+                    if (current) {
+                        showResource(current);
+                    }
+                };
+                var nav = document.getElementById("resources");
+                spec.resources.forEach(function(resource) {
+                    var a = el("a", resource.name);
+                    a.onclick = function() { showResource(resource); };
+                    nav.appendChild(a);
+                });
+            }).catch(function(err) {
+                document.getElementById("resource").textContent = "Failed to load spec: " + err;
+            });
+        </script>
+    </body>
+</html>
+`
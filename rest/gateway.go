@@ -0,0 +1,183 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// gatewayMethods maps the ResourceHandler operations which can be dispatched to their
+// HTTP methods.
+var gatewayMethods = map[HandleMethod]string{
+	HandleCreate:     "POST",
+	HandleReadList:   "GET",
+	HandleRead:       "GET",
+	HandleUpdateList: "PUT",
+	HandleUpdate:     "PUT",
+	HandlePatch:      "PATCH",
+	HandleDelete:     "DELETE",
+	HandleSearch:     "GET",
+}
+
+// GatewayRequest is an operation on a registered resource received over a transport
+// other than HTTP, e.g. the generic gRPC Create/Read/Update/Delete/List service in the
+// grpcgateway package.
+type GatewayRequest struct {
+	// Method is the operation, e.g. HandleCreate or HandleReadList.
+	Method HandleMethod
+
+	// Resource is the name of the resource.
+	Resource string
+
+	// ID is the resource ID for operations on a single resource.
+	ID string
+
+	// Version is the API version.
+	Version string
+
+	// Tenant is the tenant making the request when Tenancy is configured. It's
+	// validated like the tenants of HTTP requests.
+	Tenant string
+
+	// Header carries the request metadata, e.g. credentials checked by the
+	// ResourceHandler's Authenticate.
+	Header http.Header
+
+	// Query holds the query string variables, e.g. limit and next.
+	Query url.Values
+
+	// Body is marshaled to JSON as the request payload of mutations.
+	Body interface{}
+}
+
+// GatewayResponse is the response to a GatewayRequest.
+type GatewayResponse struct {
+	// Status is the HTTP status code of the response.
+	Status int
+
+	// Header is the response header.
+	Header http.Header
+
+	// Body is the decoded response envelope, or nil if the response isn't JSON.
+	Body Payload
+
+	// Raw is the serialized response body.
+	Raw []byte
+}
+
+// Dispatch performs the GatewayRequest against the registered ResourceHandler, going
+// through the same routing, middleware, authentication, Rules, and hooks as HTTP
+// requests, so gateways for other transports can share handler implementations. An
+// error is returned if the request can't be dispatched, e.g. because the resource
+// isn't registered; errors from the handler are described by the response.
+func (r *muxAPI) Dispatch(ctx context.Context, req GatewayRequest) (GatewayResponse, error) {
+	method, ok := gatewayMethods[req.Method]
+	if !ok {
+		return GatewayResponse{}, fmt.Errorf("Method %s can't be dispatched", req.Method)
+	}
+
+	vars := map[string]string{}
+	if req.Tenant != "" {
+		vars[tenantVar] = req.Tenant
+		ctx = withGatewayTenant(ctx, req.Tenant)
+	}
+	u, err := r.urlFor(req.Resource, req.Method, req.Version, req.ID, vars)
+	if err != nil {
+		return GatewayResponse{}, err
+	}
+	u.RawQuery = req.Query.Encode()
+
+	var body io.Reader
+	if req.Body != nil {
+		data, err := json.Marshal(req.Body)
+		if err != nil {
+			return GatewayResponse{}, err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	httpReq, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return GatewayResponse{}, err
+	}
+	httpReq = httpReq.WithContext(ctx)
+	for key, values := range req.Header {
+		httpReq.Header[key] = values
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	httpReq.Header.Set("Accept", "application/json")
+
+	resp := newGatewayResponseWriter()
+	r.ServeHTTP(resp, httpReq)
+
+	out := GatewayResponse{Status: resp.code(), Header: resp.header, Raw: resp.body.Bytes()}
+	if resp.body.Len() > 0 && strings.Contains(resp.header.Get("Content-Type"), "json") {
+		if err := json.Unmarshal(resp.body.Bytes(), &out.Body); err != nil {
+			return out, fmt.Errorf("Failed to decode response: %s", err)
+		}
+	}
+	return out, nil
+}
+
+// gatewayResponseWriter is the http.ResponseWriter buffering the response to a
+// dispatched GatewayRequest.
+type gatewayResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+// newGatewayResponseWriter returns a newly allocated gatewayResponseWriter.
+func newGatewayResponseWriter() *gatewayResponseWriter {
+	return &gatewayResponseWriter{header: http.Header{}}
+}
+
+// Header returns the response header.
+func (g *gatewayResponseWriter) Header() http.Header {
+	return g.header
+}
+
+// WriteHeader records the status unless one was already written.
+func (g *gatewayResponseWriter) WriteHeader(status int) {
+	if g.status == 0 {
+		g.status = status
+	}
+}
+
+// Write buffers the response body, recording a 200 status if one hasn't been written.
+func (g *gatewayResponseWriter) Write(p []byte) (int, error) {
+	g.WriteHeader(http.StatusOK)
+	return g.body.Write(p)
+}
+
+// code returns the status of the response, which is 200 if none was written.
+func (g *gatewayResponseWriter) code() int {
+	if g.status == 0 {
+		return http.StatusOK
+	}
+	return g.status
+}
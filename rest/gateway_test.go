@@ -0,0 +1,155 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type gatewayResourceHandler struct {
+	BaseResourceHandler
+}
+
+func (g *gatewayResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (g *gatewayResourceHandler) Authenticate(r *http.Request) error {
+	if r.Header.Get("Authorization") != "secret" {
+		return errors.New("Not authorized")
+	}
+	return nil
+}
+
+func (g *gatewayResourceHandler) CreateResource(ctx RequestContext, data Payload,
+	version string) (Resource, error) {
+
+	return Payload{"foo": data["foo"], "version": version}, nil
+}
+
+func (g *gatewayResourceHandler) ReadResourceList(ctx RequestContext, limit int,
+	cursor string, version string) ([]Resource, string, error) {
+
+	return []Resource{Payload{"limit": limit}}, "", nil
+}
+
+func (g *gatewayResourceHandler) ReadResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	return nil, ResourceNotFound("No foo " + id)
+}
+
+// Ensures that Dispatch performs operations against the registered handler like HTTP
+// requests.
+func TestDispatch(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&gatewayResourceHandler{})
+	header := http.Header{"Authorization": {"secret"}}
+
+	resp, err := api.Dispatch(context.Background(), GatewayRequest{
+		Method:   HandleCreate,
+		Resource: "foo",
+		Version:  "2",
+		Header:   header,
+		Body:     map[string]string{"foo": "bar"},
+	})
+
+	assert.NoError(err)
+	assert.Equal(http.StatusCreated, resp.Status)
+	assert.Equal(map[string]interface{}{"foo": "bar", "version": "2"}, resp.Body["result"])
+
+	resp, err = api.Dispatch(context.Background(), GatewayRequest{
+		Method:   HandleReadList,
+		Resource: "foo",
+		Version:  "1",
+		Header:   header,
+		Query:    url.Values{"limit": {"5"}},
+	})
+
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, resp.Status)
+	assert.Equal([]interface{}{map[string]interface{}{"limit": float64(5)}}, resp.Body["results"])
+
+	resp, err = api.Dispatch(context.Background(), GatewayRequest{
+		Method:   HandleRead,
+		Resource: "foo",
+		ID:       "7",
+		Version:  "1",
+		Header:   header,
+	})
+
+	assert.NoError(err)
+	assert.Equal(http.StatusNotFound, resp.Status)
+	assert.Equal([]interface{}{"No foo 7"}, resp.Body["messages"])
+}
+
+// Ensures that Dispatch authenticates requests with the handler.
+func TestDispatchUnauthorized(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&gatewayResourceHandler{})
+
+	resp, err := api.Dispatch(context.Background(), GatewayRequest{
+		Method: HandleRead, Resource: "foo", ID: "1", Version: "1",
+	})
+
+	assert.NoError(err)
+	assert.Equal(http.StatusUnauthorized, resp.Status)
+	assert.Nil(resp.Body)
+	assert.Equal("Not authorized", string(resp.Raw))
+}
+
+// Ensures that Dispatch returns an error for unknown resources and methods.
+func TestDispatchUnknown(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&gatewayResourceHandler{})
+
+	_, err := api.Dispatch(context.Background(), GatewayRequest{
+		Method: HandleRead, Resource: "bar", ID: "1", Version: "1",
+	})
+	assert.Error(err)
+
+	_, err = api.Dispatch(context.Background(), GatewayRequest{
+		Method: HandleSnapshot, Resource: "foo", ID: "1", Version: "1",
+	})
+	assert.Error(err)
+}
+
+// Ensures that gatewayResponseWriter records the first status written, defaulting to
+// 200, along with the header and body.
+func TestGatewayResponseWriter(t *testing.T) {
+	assert := assert.New(t)
+	w := newGatewayResponseWriter()
+	assert.Equal(http.StatusOK, w.code())
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Write([]byte("hello"))
+
+	assert.Equal(http.StatusCreated, w.code())
+	assert.Equal("text/plain", w.header.Get("Content-Type"))
+	assert.Equal("hello", w.body.String())
+}
@@ -0,0 +1,745 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	// graphqlMediaType is the Content-Type of POST requests whose body is the GraphQL
+	// document rather than a JSON-encoded request.
+	graphqlMediaType = "application/graphql"
+
+	// defaultGraphQLMaxFields is the maximum number of top-level fields of a GraphQL
+	// operation if a limit isn't configured.
+	defaultGraphQLMaxFields = 20
+)
+
+// graphqlExcludedHeaders are the headers of GraphQL requests which aren't passed on to
+// the resource operations they perform because they describe the GraphQL request
+// itself.
+var graphqlExcludedHeaders = map[string]bool{
+	"Content-Type":           true,
+	"Content-Length":         true,
+	"If-Match":               true,
+	"If-None-Match":          true,
+	"If-Modified-Since":      true,
+	"If-Unmodified-Since":    true,
+	"X-Http-Method-Override": true,
+}
+
+// graphqlRequest is a GraphQL request as encoded in POST bodies and GET query
+// parameters.
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// graphqlError is an error in a GraphQL response.
+type graphqlError struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// graphqlResponse is the body of GraphQL responses. Data is omitted if the request
+// failed before execution.
+type graphqlResponse struct {
+	Data   interface{}    `json:"data,omitempty"`
+	Errors []graphqlError `json:"errors,omitempty"`
+}
+
+// gqlObject is a GraphQL response object, which is serialized with its fields in the
+// order they were selected.
+type gqlObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// newGQLObject returns a newly allocated, empty gqlObject.
+func newGQLObject() *gqlObject {
+	return &gqlObject{values: map[string]interface{}{}}
+}
+
+// set sets the value of the field, keeping its original position if it was already
+// set.
+func (o *gqlObject) set(key string, value interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// MarshalJSON marshals the object with its fields in order.
+func (o *gqlObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// graphqlField is a top-level field of the GraphQL schema, which performs an operation
+// on a registered resource.
+type graphqlField struct {
+	method   HandleMethod
+	resource string
+	typeName string
+}
+
+// graphqlName returns the resource name as a GraphQL name, replacing characters which
+// aren't allowed with underscores.
+func graphqlName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if c != '_' && !isLetter(c) && !isDigit(c) {
+			b[i] = '_'
+		}
+	}
+	if len(b) == 0 || isDigit(b[0]) {
+		return "_" + string(b)
+	}
+	return string(b)
+}
+
+// graphqlTypeName returns the name of the GraphQL type of the resource.
+func graphqlTypeName(resource string) string {
+	name := graphqlName(resource)
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// graphqlFields returns the top-level query or mutation fields of the GraphQL schema
+// derived from the registered ResourceHandlers, keyed by field name. Each resource
+// has a "resource" and "resourceList" query and "createResource", "updateResource",
+// and "deleteResource" mutations.
+func graphqlFields(handlers []ResourceHandler, kind string) map[string]graphqlField {
+	fields := map[string]graphqlField{}
+	seen := map[string]bool{}
+	for _, handler := range handlers {
+		resource := handler.ResourceName()
+		name, typeName := graphqlName(resource), graphqlTypeName(resource)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		field := func(method HandleMethod) graphqlField {
+			return graphqlField{method: method, resource: resource, typeName: typeName}
+		}
+		if kind == "query" {
+			fields[name] = field(HandleRead)
+			fields[name+"List"] = field(HandleReadList)
+		} else {
+			fields["create"+typeName] = field(HandleCreate)
+			fields["update"+typeName] = field(HandleUpdate)
+			fields["delete"+typeName] = field(HandleDelete)
+		}
+	}
+	return fields
+}
+
+// graphqlMaxFields returns the maximum number of top-level fields of a GraphQL
+// operation.
+func graphqlMaxFields(config *Configuration) int {
+	if config.GraphQLMaxFields > 0 {
+		return config.GraphQLMaxFields
+	}
+	return defaultGraphQLMaxFields
+}
+
+// registerGraphQLRoute binds the GraphQL endpoint and the endpoint serving its schema
+// with the middleware the framework applies to every endpoint.
+func (r *muxAPI) registerGraphQLRoute() {
+	uri := r.layoutURI(fmt.Sprintf("/api/v{%s:[^/]+}/graphql", versionKey))
+	middleware := r.endpointMiddleware(string(HandleGraphQL), nil)
+	r.addRoutes(nil, func(routes Router) {
+		r.bind(routes, string(HandleGraphQL)+"Schema", "GET", uri+"/schema",
+			applyMiddleware(r.handler.handleGraphQLSchema(), middleware))
+		r.bind(routes, string(HandleGraphQL), "", uri,
+			applyMiddleware(r.handler.handleGraphQL(), middleware))
+	})
+}
+
+// handleGraphQL returns a Handler which executes GraphQL queries and mutations against
+// the registered resources. Each top-level field is performed as a resource operation
+// with Dispatch, so the requests go through the same authentication, Rules, hooks, and
+// middleware as REST requests, using the GraphQL request's headers. Errors from
+// operations are reported in the response's errors with their HTTP status.
+func (h requestHandler) handleGraphQL() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" && r.Method != "POST" {
+			w.Header().Set("Allow", "GET, POST")
+			writeGraphQLErrors(w, http.StatusMethodNotAllowed,
+				fmt.Errorf("GraphQL requests must use GET or POST"))
+			return
+		}
+
+		req, err := decodeGraphQLRequest(r)
+		if err != nil {
+			writeGraphQLErrors(w, http.StatusBadRequest, err)
+			return
+		}
+		operations, err := parseGraphQL(req.Query)
+		if err != nil {
+			writeGraphQLErrors(w, http.StatusBadRequest, err)
+			return
+		}
+		op, err := selectGraphQLOperation(operations, req.OperationName)
+		if err != nil {
+			writeGraphQLErrors(w, http.StatusBadRequest, err)
+			return
+		}
+		if op.kind == "mutation" && r.Method != "POST" {
+			w.Header().Set("Allow", "POST")
+			writeGraphQLErrors(w, http.StatusMethodNotAllowed,
+				fmt.Errorf("Mutations must use POST"))
+			return
+		}
+		if max := graphqlMaxFields(h.Configuration()); len(op.selections) > max {
+			writeGraphQLErrors(w, http.StatusBadRequest,
+				fmt.Errorf("Operations may select at most %d fields", max))
+			return
+		}
+
+		header := http.Header{}
+		for key, values := range r.Header {
+			if !graphqlExcludedHeaders[key] {
+				header[key] = values
+			}
+		}
+		exec := &graphqlExecutor{
+			api:       h.API,
+			ctx:       r.Context(),
+			header:    header,
+			version:   requestVersion(r, h.Configuration()),
+			variables: map[string]interface{}{},
+		}
+		exec.tenant = resolvedTenant(r)
+		for name, value := range op.defaults {
+			exec.variables[name] = value
+		}
+		for name, value := range req.Variables {
+			exec.variables[name] = value
+		}
+
+		resp, err := exec.execute(op)
+		if err != nil {
+			writeGraphQLErrors(w, http.StatusBadRequest, err)
+			return
+		}
+		writeGraphQL(w, http.StatusOK, resp)
+	})
+}
+
+// decodeGraphQLRequest returns the GraphQL request encoded in the query parameters of
+// GET requests or the body of POST requests.
+func decodeGraphQLRequest(r *http.Request) (graphqlRequest, error) {
+	var req graphqlRequest
+	if r.Method == "GET" {
+		q := r.URL.Query()
+		req.Query, req.OperationName = q.Get("query"), q.Get("operationName")
+		if variables := q.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				return req, fmt.Errorf("Invalid variables: %s", err)
+			}
+		}
+	} else {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return req, err
+		}
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType == graphqlMediaType {
+			req.Query = string(body)
+		} else if err := json.Unmarshal(body, &req); err != nil {
+			return req, fmt.Errorf("Invalid GraphQL request: %s", err)
+		}
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		return req, fmt.Errorf("Missing query")
+	}
+	return req, nil
+}
+
+// selectGraphQLOperation returns the named operation of the document. The name may be
+// omitted if the document contains a single operation.
+func selectGraphQLOperation(operations []*gqlOperation, name string) (*gqlOperation, error) {
+	if name == "" {
+		if len(operations) > 1 {
+			return nil, fmt.Errorf("operationName is required for documents with multiple operations")
+		}
+		return operations[0], nil
+	}
+	for _, op := range operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("Unknown operation %q", name)
+}
+
+// writeGraphQL writes the GraphQL response as JSON.
+func writeGraphQL(w http.ResponseWriter, status int, resp graphqlResponse) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		status = http.StatusInternalServerError
+		data, _ = json.Marshal(graphqlResponse{Errors: []graphqlError{{Message: err.Error()}}})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// writeGraphQLErrors writes a GraphQL response for a request which failed before it
+// was executed.
+func writeGraphQLErrors(w http.ResponseWriter, status int, err error) {
+	writeGraphQL(w, status, graphqlResponse{Errors: []graphqlError{{Message: err.Error()}}})
+}
+
+// graphqlExecutor executes a GraphQL operation by dispatching its top-level fields as
+// resource operations.
+type graphqlExecutor struct {
+	api       API
+	ctx       context.Context
+	header    http.Header
+	version   string
+	tenant    string
+	variables map[string]interface{}
+	errors    []graphqlError
+}
+
+// execute executes the operation. An error is returned if the operation selects
+// fields which aren't in the schema, in which case nothing is executed.
+func (e *graphqlExecutor) execute(op *gqlOperation) (graphqlResponse, error) {
+	rootType := strings.ToUpper(op.kind[:1]) + op.kind[1:]
+	fields := graphqlFields(e.api.ResourceHandlers(), op.kind)
+	for _, field := range op.selections {
+		if _, ok := fields[field.name]; !ok && field.name != "__typename" {
+			return graphqlResponse{}, fmt.Errorf("Cannot query field %q on type %q",
+				field.name, rootType)
+		}
+	}
+
+	data := newGQLObject()
+	for _, field := range op.selections {
+		if field.name == "__typename" {
+			data.set(field.key(), rootType)
+			continue
+		}
+		data.set(field.key(), e.resolve(field, fields[field.name]))
+	}
+	return graphqlResponse{Data: data, Errors: e.errors}, nil
+}
+
+// resolve performs the resource operation of the top-level field and returns the
+// selected fields of its result, or nil if it failed.
+func (e *graphqlExecutor) resolve(field *gqlField, f graphqlField) interface{} {
+	args := e.arguments(field)
+	req := GatewayRequest{
+		Method:   f.method,
+		Resource: f.resource,
+		Version:  e.version,
+		Tenant:   e.tenant,
+		Header:   e.header,
+		Query:    url.Values{},
+	}
+
+	if f.method != HandleReadList && f.method != HandleCreate {
+		id, ok := args["id"]
+		if !ok || id == nil {
+			return e.fail(field, "Argument \"id\" is required", 0)
+		}
+		req.ID = graphqlString(id)
+	}
+	if f.method == HandleCreate || f.method == HandleUpdate {
+		input, ok := args["input"].(map[string]interface{})
+		if !ok {
+			return e.fail(field, "Argument \"input\" must be an object", 0)
+		}
+		req.Body = input
+	}
+	if f.method == HandleReadList {
+		if err := e.listQuery(&req, args); err != nil {
+			return e.fail(field, err.Error(), 0)
+		}
+	}
+
+	resp, err := e.api.Dispatch(e.ctx, req)
+	if err != nil {
+		return e.fail(field, err.Error(), 0)
+	}
+	if resp.Status >= http.StatusBadRequest {
+		return e.fail(field, graphqlErrorMessage(resp), resp.Status)
+	}
+
+	if f.method != HandleReadList {
+		return applyGraphQLSelection(resp.Body[result], field.selections, f.typeName)
+	}
+
+	cursor := ""
+	if next, ok := resp.Body[next].(string); ok {
+		if u, err := url.Parse(next); err == nil {
+			cursor = u.Query().Get(cursorKey)
+		}
+	}
+	list := map[string]interface{}{"results": resp.Body[results], "next": nil}
+	if cursor != "" {
+		list["next"] = cursor
+	}
+	if field.selections == nil {
+		return list
+	}
+
+	obj := newGQLObject()
+	for _, selection := range field.selections {
+		switch selection.name {
+		case "__typename":
+			obj.set(selection.key(), f.typeName+"List")
+		case "results":
+			obj.set(selection.key(), applyGraphQLSelection(list["results"],
+				selection.selections, f.typeName))
+		default:
+			obj.set(selection.key(), list[selection.name])
+		}
+	}
+	return obj
+}
+
+// listQuery sets the query parameters of a list request from the field's arguments.
+// Requests with a search text, filters, or sort orders are performed with the
+// resource's search endpoint.
+func (e *graphqlExecutor) listQuery(req *GatewayRequest, args map[string]interface{}) error {
+	for name, value := range args {
+		if value == nil {
+			continue
+		}
+		switch name {
+		case "limit":
+			n, ok := value.(float64)
+			if !ok || n != float64(int(n)) {
+				return fmt.Errorf("Argument \"limit\" must be an integer")
+			}
+			req.Query.Set(limitKey, strconv.Itoa(int(n)))
+		case "cursor":
+			req.Query.Set(cursorKey, graphqlString(value))
+		case "q":
+			req.Query.Set(searchTextKey, graphqlString(value))
+			req.Method = HandleSearch
+		case "sort":
+			req.Query.Set(searchSortKey, strings.Join(graphqlStrings(value), ","))
+			req.Method = HandleSearch
+		case "filter":
+			filters, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("Argument \"filter\" must be an object")
+			}
+			for field, values := range filters {
+				req.Query.Set(searchFilterPrefix+field+searchFilterSuffix,
+					strings.Join(graphqlStrings(values), ","))
+			}
+			req.Method = HandleSearch
+		default:
+			return fmt.Errorf("Unknown argument %q", name)
+		}
+	}
+
+	if req.Method == HandleSearch {
+		handler, ok := resourceHandlerNamed(e.api, req.Resource)
+		if _, searchable := unwrapResourceHandler(handler).(SearchResourceHandler); !ok || !searchable {
+			return fmt.Errorf("Resource %s doesn't support search", req.Resource)
+		}
+	}
+	return nil
+}
+
+// arguments returns the field's arguments with variables replaced by their values.
+func (e *graphqlExecutor) arguments(field *gqlField) map[string]interface{} {
+	args := make(map[string]interface{}, len(field.args))
+	for name, value := range field.args {
+		args[name] = e.value(value)
+	}
+	return args
+}
+
+// value returns the argument value with variables replaced by their values.
+func (e *graphqlExecutor) value(value interface{}) interface{} {
+	switch v := value.(type) {
+	case gqlVariable:
+		return e.variables[string(v)]
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = e.value(item)
+		}
+		return list
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			object[key] = e.value(item)
+		}
+		return object
+	}
+	return value
+}
+
+// fail records an error for the field and returns nil as its value. The status is
+// included in the error's extensions if nonzero.
+func (e *graphqlExecutor) fail(field *gqlField, message string, status int) interface{} {
+	err := graphqlError{Message: message, Path: []interface{}{field.key()}}
+	if status != 0 {
+		err.Extensions = map[string]interface{}{"status": status}
+	}
+	e.errors = append(e.errors, err)
+	return nil
+}
+
+// graphqlErrorMessage returns the error message of a failed resource operation.
+func graphqlErrorMessage(resp GatewayResponse) string {
+	if messages, ok := resp.Body["messages"].([]interface{}); ok && len(messages) > 0 {
+		strs := make([]string, 0, len(messages))
+		for _, message := range messages {
+			strs = append(strs, fmt.Sprint(message))
+		}
+		return strings.Join(strs, "; ")
+	}
+	if detail, ok := resp.Body["detail"].(string); ok && detail != "" {
+		return detail
+	}
+	if raw := strings.TrimSpace(string(resp.Raw)); raw != "" && resp.Body == nil {
+		return raw
+	}
+	return http.StatusText(resp.Status)
+}
+
+// applyGraphQLSelection returns the fields of the value selected by the selection set.
+// Lists have the selection applied to each item. Values without a selection set are
+// returned as is.
+func applyGraphQLSelection(value interface{}, selections []*gqlField, typeName string) interface{} {
+	if selections == nil {
+		return value
+	}
+	switch v := value.(type) {
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = applyGraphQLSelection(item, selections, typeName)
+		}
+		return list
+	case map[string]interface{}:
+		obj := newGQLObject()
+		for _, selection := range selections {
+			if selection.name == "__typename" {
+				obj.set(selection.key(), typeName)
+				continue
+			}
+			obj.set(selection.key(), applyGraphQLSelection(v[selection.name],
+				selection.selections, typeName+graphqlTypeName(selection.name)))
+		}
+		return obj
+	}
+	return value
+}
+
+// graphqlString returns the argument value as a string.
+func graphqlString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// graphqlStrings returns the argument value, which is a list or a single value, as a
+// slice of strings.
+func graphqlStrings(value interface{}) []string {
+	list, ok := value.([]interface{})
+	if !ok {
+		return []string{graphqlString(value)}
+	}
+	strs := make([]string, 0, len(list))
+	for _, item := range list {
+		strs = append(strs, graphqlString(item))
+	}
+	return strs
+}
+
+// resourceHandlerNamed returns the first ResourceHandler registered for the named
+// resource.
+func resourceHandlerNamed(api API, resource string) (ResourceHandler, bool) {
+	for _, handler := range api.ResourceHandlers() {
+		if handler.ResourceName() == resource {
+			return handler, true
+		}
+	}
+	return nil, false
+}
+
+// handleGraphQLSchema returns a Handler which responds with the GraphQL schema of the
+// requested version in the schema definition language.
+func (h requestHandler) handleGraphQLSchema() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		schema := graphqlSchema(h.ResourceHandlers(), requestVersion(r, h.Configuration()))
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(schema))
+	})
+}
+
+// graphqlSchema returns the GraphQL schema derived from the ResourceHandlers for the
+// version. Resource types have the fields of the handler's outbound Rules for the
+// version, or the exported fields of the Rules' resource type if there are none.
+// Resources whose fields are unknown, nested resources, and mutation inputs are
+// described with the JSON scalar.
+func graphqlSchema(handlers []ResourceHandler, version string) string {
+	var queries, mutations, types bytes.Buffer
+	seen := map[string]bool{}
+	for _, handler := range handlers {
+		resource := handler.ResourceName()
+		name, typeName := graphqlName(resource), graphqlTypeName(resource)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		listArgs := "limit: Int, cursor: String"
+		if _, ok := unwrapResourceHandler(handler).(SearchResourceHandler); ok {
+			listArgs += ", q: String, filter: JSON, sort: [String!]"
+		}
+		fmt.Fprintf(&queries, "  %s(id: ID!): %s\n", name, typeName)
+		fmt.Fprintf(&queries, "  %sList(%s): %sList\n", name, listArgs, typeName)
+		fmt.Fprintf(&mutations, "  create%s(input: JSON!): %s\n", typeName, typeName)
+		fmt.Fprintf(&mutations, "  update%s(id: ID!, input: JSON!): %s\n", typeName, typeName)
+		fmt.Fprintf(&mutations, "  delete%s(id: ID!): %s\n", typeName, typeName)
+
+		fields := graphqlTypeFields(handler.Rules(), version)
+		if len(fields) == 0 {
+			fmt.Fprintf(&types, "scalar %s\n\n", typeName)
+		} else {
+			fmt.Fprintf(&types, "type %s {\n", typeName)
+			for _, field := range fields {
+				fmt.Fprintf(&types, "  %s\n", field)
+			}
+			types.WriteString("}\n\n")
+		}
+		fmt.Fprintf(&types, "type %sList {\n  results: [%s!]!\n  next: String\n}\n\n",
+			typeName, typeName)
+	}
+
+	var schema bytes.Buffer
+	schema.WriteString("scalar JSON\n\n")
+	if queries.Len() > 0 {
+		fmt.Fprintf(&schema, "type Query {\n%s}\n\n", queries.String())
+		fmt.Fprintf(&schema, "type Mutation {\n%s}\n\n", mutations.String())
+	}
+	schema.Write(types.Bytes())
+	return strings.TrimSuffix(schema.String(), "\n")
+}
+
+// graphqlTypeFields returns the field definitions of a resource type with the Rules.
+// Fields whose names aren't valid GraphQL names are omitted.
+func graphqlTypeFields(rules Rules, version string) []string {
+	fields := []string{}
+	if rules == nil {
+		return fields
+	}
+	for _, rule := range rules.Filter(Outbound).ForVersion(version).Contents() {
+		if name := rule.Name(); graphqlName(name) == name {
+			fieldType := graphqlRuleType(rule.Type)
+			if rule.Identifier {
+				fieldType = "ID"
+			}
+			fields = append(fields, name+": "+fieldType)
+		}
+	}
+	if len(fields) > 0 || rules.ResourceType() == nil ||
+		rules.ResourceType().Kind() != reflect.Struct {
+		return fields
+	}
+
+	resourceType := rules.ResourceType()
+	for i := 0; i < resourceType.NumField(); i++ {
+		field := resourceType.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Name
+		if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag == "-" {
+			continue
+		} else if tag != "" {
+			name = tag
+		}
+		if graphqlName(name) == name {
+			fields = append(fields, name+": "+graphqlKindType(field.Type.Kind()))
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// graphqlRuleType returns the GraphQL type of fields with the Rule Type.
+func graphqlRuleType(t Type) string {
+	if t == Time {
+		return "String"
+	}
+	return graphqlKindType(typeToKind[t])
+}
+
+// graphqlKindType returns the GraphQL type of fields of the reflect Kind.
+func graphqlKindType(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "Int"
+	case reflect.Float32, reflect.Float64:
+		return "Float"
+	case reflect.String:
+		return "String"
+	case reflect.Bool:
+		return "Boolean"
+	}
+	return "JSON"
+}
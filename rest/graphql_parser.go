@@ -0,0 +1,504 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// gqlTokenKind is the kind of a lexical token of a GraphQL document.
+type gqlTokenKind int
+
+// GraphQL token kinds.
+const (
+	gqlEOF gqlTokenKind = iota
+	gqlPunctuator
+	gqlName
+	gqlInt
+	gqlFloat
+	gqlString
+)
+
+// gqlToken is a lexical token of a GraphQL document.
+type gqlToken struct {
+	kind  gqlTokenKind
+	value string
+	pos   int
+}
+
+// gqlLexer splits a GraphQL document into tokens, skipping whitespace, commas, and
+// comments.
+type gqlLexer struct {
+	src string
+	pos int
+}
+
+// next returns the next token of the document.
+func (l *gqlLexer) next() (gqlToken, error) {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if c == '#' {
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+			continue
+		}
+		if c != ' ' && c != '\t' && c != '\n' && c != '\r' && c != ',' {
+			break
+		}
+		l.pos++
+	}
+	if l.pos >= len(l.src) {
+		return gqlToken{kind: gqlEOF, pos: l.pos}, nil
+	}
+
+	start := l.pos
+	c := l.src[l.pos]
+	switch {
+	case strings.HasPrefix(l.src[l.pos:], "..."):
+		l.pos += 3
+		return gqlToken{gqlPunctuator, "...", start}, nil
+	case strings.IndexByte("!$():=@[]{}|", c) >= 0:
+		l.pos++
+		return gqlToken{gqlPunctuator, string(c), start}, nil
+	case c == '_' || isLetter(c):
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		return gqlToken{gqlName, l.src[start:l.pos], start}, nil
+	case c == '-' || isDigit(c):
+		return l.number()
+	case c == '"':
+		return l.string()
+	}
+	return gqlToken{}, fmt.Errorf("Unexpected character %q at position %d", c, start)
+}
+
+// number returns an Int or Float token.
+func (l *gqlLexer) number() (gqlToken, error) {
+	start := l.pos
+	kind := gqlInt
+	if l.src[l.pos] == '-' {
+		l.pos++
+	}
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if c == '.' || c == 'e' || c == 'E' || (kind == gqlFloat && (c == '+' || c == '-')) {
+			kind = gqlFloat
+		} else if !isDigit(c) {
+			break
+		}
+		l.pos++
+	}
+	value := l.src[start:l.pos]
+	if _, err := strconv.ParseFloat(value, 64); err != nil {
+		return gqlToken{}, fmt.Errorf("Invalid number %q at position %d", value, start)
+	}
+	return gqlToken{kind, value, start}, nil
+}
+
+// string returns a String token with its escape sequences decoded.
+func (l *gqlLexer) string() (gqlToken, error) {
+	start := l.pos
+	l.pos++
+	var value strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch c {
+		case '"':
+			l.pos++
+			return gqlToken{gqlString, value.String(), start}, nil
+		case '\n', '\r':
+			return gqlToken{}, fmt.Errorf("Unterminated string at position %d", start)
+		case '\\':
+			if l.pos+1 >= len(l.src) {
+				return gqlToken{}, fmt.Errorf("Unterminated string at position %d", start)
+			}
+			escaped := l.src[l.pos+1]
+			l.pos += 2
+			switch escaped {
+			case '"', '\\', '/':
+				value.WriteByte(escaped)
+			case 'b':
+				value.WriteByte('\b')
+			case 'f':
+				value.WriteByte('\f')
+			case 'n':
+				value.WriteByte('\n')
+			case 'r':
+				value.WriteByte('\r')
+			case 't':
+				value.WriteByte('\t')
+			case 'u':
+				if l.pos+4 > len(l.src) {
+					return gqlToken{}, fmt.Errorf("Invalid escape at position %d", l.pos)
+				}
+				r, err := strconv.ParseUint(l.src[l.pos:l.pos+4], 16, 32)
+				if err != nil {
+					return gqlToken{}, fmt.Errorf("Invalid escape at position %d", l.pos)
+				}
+				value.WriteRune(rune(r))
+				l.pos += 4
+			default:
+				return gqlToken{}, fmt.Errorf("Invalid escape at position %d", l.pos-2)
+			}
+		default:
+			value.WriteByte(c)
+			l.pos++
+		}
+	}
+	return gqlToken{}, fmt.Errorf("Unterminated string at position %d", start)
+}
+
+// isLetter returns true if the byte is an ASCII letter.
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// isDigit returns true if the byte is an ASCII digit.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// gqlVariable is a reference to an operation variable in an argument value.
+type gqlVariable string
+
+// gqlField is a field selected by a GraphQL operation.
+type gqlField struct {
+	alias      string
+	name       string
+	args       map[string]interface{}
+	selections []*gqlField
+}
+
+// key returns the key of the field in the response, i.e. its alias, if any.
+func (f *gqlField) key() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+// gqlOperation is a query or mutation of a GraphQL document.
+type gqlOperation struct {
+	kind       string
+	name       string
+	defaults   map[string]interface{}
+	selections []*gqlField
+}
+
+// gqlParser parses GraphQL documents containing queries and mutations. Fragments,
+// directives, and subscriptions aren't supported.
+type gqlParser struct {
+	lexer *gqlLexer
+	tok   gqlToken
+}
+
+// parseGraphQL returns the operations of the GraphQL document.
+func parseGraphQL(document string) ([]*gqlOperation, error) {
+	p := &gqlParser{lexer: &gqlLexer{src: document}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	operations := []*gqlOperation{}
+	for p.tok.kind != gqlEOF {
+		op, err := p.operation()
+		if err != nil {
+			return nil, err
+		}
+		operations = append(operations, op)
+	}
+	if len(operations) == 0 {
+		return nil, fmt.Errorf("Document doesn't contain an operation")
+	}
+	return operations, nil
+}
+
+// advance reads the next token.
+func (p *gqlParser) advance() error {
+	tok, err := p.lexer.next()
+	p.tok = tok
+	return err
+}
+
+// peek returns true if the current token is the punctuator.
+func (p *gqlParser) peek(punctuator string) bool {
+	return p.tok.kind == gqlPunctuator && p.tok.value == punctuator
+}
+
+// expect consumes the punctuator, returning an error if it's not the current token.
+func (p *gqlParser) expect(punctuator string) error {
+	if !p.peek(punctuator) {
+		return p.unexpected("\"" + punctuator + "\"")
+	}
+	return p.advance()
+}
+
+// name consumes and returns a name.
+func (p *gqlParser) name() (string, error) {
+	if p.tok.kind != gqlName {
+		return "", p.unexpected("a name")
+	}
+	name := p.tok.value
+	return name, p.advance()
+}
+
+// unexpected returns an error for the current token, which isn't what was expected.
+func (p *gqlParser) unexpected(expected string) error {
+	if p.tok.kind == gqlEOF {
+		return fmt.Errorf("Expected %s but reached the end of the document", expected)
+	}
+	return fmt.Errorf("Expected %s but found %q at position %d", expected, p.tok.value, p.tok.pos)
+}
+
+// operation parses an operation definition, which is either a selection set alone or
+// an operation type with an optional name and variable definitions.
+func (p *gqlParser) operation() (*gqlOperation, error) {
+	op := &gqlOperation{kind: "query", defaults: map[string]interface{}{}}
+	if p.tok.kind == gqlName {
+		switch p.tok.value {
+		case "query", "mutation":
+			op.kind = p.tok.value
+		case "fragment":
+			return nil, fmt.Errorf("Fragments are not supported")
+		case "subscription":
+			return nil, fmt.Errorf("Subscriptions are not supported")
+		default:
+			return nil, p.unexpected("an operation")
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind == gqlName {
+			op.name = p.tok.value
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		}
+		if p.peek("(") {
+			if err := p.variableDefinitions(op); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	selections, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selections = selections
+	return op, nil
+}
+
+// variableDefinitions parses the variable definitions of an operation, recording
+// default values. Variable types aren't checked.
+func (p *gqlParser) variableDefinitions(op *gqlOperation) error {
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	for !p.peek(")") {
+		if err := p.expect("$"); err != nil {
+			return err
+		}
+		name, err := p.name()
+		if err != nil {
+			return err
+		}
+		if err := p.expect(":"); err != nil {
+			return err
+		}
+		if err := p.variableType(); err != nil {
+			return err
+		}
+		if p.peek("=") {
+			if err := p.advance(); err != nil {
+				return err
+			}
+			value, err := p.value(true)
+			if err != nil {
+				return err
+			}
+			op.defaults[name] = value
+		}
+	}
+	return p.advance()
+}
+
+// variableType parses a variable type, e.g. [String!]!.
+func (p *gqlParser) variableType() error {
+	if p.peek("[") {
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if err := p.variableType(); err != nil {
+			return err
+		}
+		if err := p.expect("]"); err != nil {
+			return err
+		}
+	} else if _, err := p.name(); err != nil {
+		return err
+	}
+	if p.peek("!") {
+		return p.advance()
+	}
+	return nil
+}
+
+// selectionSet parses a selection set of fields.
+func (p *gqlParser) selectionSet() ([]*gqlField, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	fields := []*gqlField{}
+	for !p.peek("}") {
+		if p.peek("...") {
+			return nil, fmt.Errorf("Fragments are not supported")
+		}
+		field, err := p.field()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("Selection sets must select at least one field")
+	}
+	return fields, p.advance()
+}
+
+// field parses a field with an optional alias, arguments, and selection set.
+func (p *gqlParser) field() (*gqlField, error) {
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	field := &gqlField{name: name, args: map[string]interface{}{}}
+	if p.peek(":") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		field.alias = name
+		if field.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.peek("(") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		for !p.peek(")") {
+			arg, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if field.args[arg], err = p.value(false); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.peek("@") {
+		return nil, fmt.Errorf("Directives are not supported")
+	}
+	if p.peek("{") {
+		if field.selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return field, nil
+}
+
+// value parses an argument value. Variables aren't allowed in constant values, e.g.
+// variable defaults.
+func (p *gqlParser) value(constant bool) (interface{}, error) {
+	tok := p.tok
+	switch {
+	case p.peek("$") && !constant:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		return gqlVariable(name), err
+
+	case p.peek("["):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		list := []interface{}{}
+		for !p.peek("]") {
+			item, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, p.advance()
+
+	case p.peek("{"):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		object := map[string]interface{}{}
+		for !p.peek("}") {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if object[name], err = p.value(constant); err != nil {
+				return nil, err
+			}
+		}
+		return object, p.advance()
+
+	case tok.kind == gqlInt, tok.kind == gqlFloat:
+		// Numbers are float64 like values decoded from JSON variables.
+		n, _ := strconv.ParseFloat(tok.value, 64)
+		return n, p.advance()
+
+	case tok.kind == gqlString:
+		return tok.value, p.advance()
+
+	case tok.kind == gqlName:
+		var value interface{}
+		switch tok.value {
+		case "true":
+			value = true
+		case "false":
+			value = false
+		case "null":
+		default:
+			// Enum values are passed as strings.
+			value = tok.value
+		}
+		return value, p.advance()
+	}
+	return nil, p.unexpected("a value")
+}
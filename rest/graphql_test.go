@@ -0,0 +1,392 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type graphqlResource struct {
+	Foo string `json:"foo"`
+	Bar int    `json:"bar"`
+}
+
+type graphqlResourceHandler struct {
+	BaseResourceHandler
+	query   SearchQuery
+	created Payload
+	deleted string
+}
+
+func (g *graphqlResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (g *graphqlResourceHandler) Rules() Rules {
+	return NewRules((*graphqlResource)(nil),
+		&Rule{Field: "Foo", FieldAlias: "foo", Type: String},
+		&Rule{Field: "Bar", FieldAlias: "bar", Type: Int},
+	)
+}
+
+func (g *graphqlResourceHandler) Authenticate(r *http.Request) error {
+	if r.Header.Get("Authorization") != "secret" {
+		return errors.New("Not authorized")
+	}
+	return nil
+}
+
+func (g *graphqlResourceHandler) ReadResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	if id != "1" {
+		return nil, ResourceNotFound("No foo " + id)
+	}
+	return &graphqlResource{Foo: "hello", Bar: 1}, nil
+}
+
+func (g *graphqlResourceHandler) ReadResourceList(ctx RequestContext, limit int,
+	cursor string, version string) ([]Resource, string, error) {
+
+	return []Resource{&graphqlResource{Foo: "a", Bar: limit}, &graphqlResource{Foo: "b"}}, "page2", nil
+}
+
+func (g *graphqlResourceHandler) SearchResources(ctx RequestContext, query SearchQuery,
+	limit int, cursor, version string) ([]Resource, string, error) {
+
+	g.query = query
+	return []Resource{&graphqlResource{Foo: query.Text}}, "", nil
+}
+
+func (g *graphqlResourceHandler) CreateResource(ctx RequestContext, data Payload,
+	version string) (Resource, error) {
+
+	g.created = data
+	return &graphqlResource{Foo: data["foo"].(string), Bar: 2}, nil
+}
+
+func (g *graphqlResourceHandler) DeleteResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	g.deleted = id
+	return &graphqlResource{Foo: "gone"}, nil
+}
+
+// postGraphQL performs the GraphQL request against the API.
+func postGraphQL(api API, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "http://example.com/api/v1/graphql",
+		bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "secret")
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	return resp
+}
+
+// newGraphQLAPI returns an API with the GraphQL endpoint enabled and the handler
+// registered.
+func newGraphQLAPI(handler ResourceHandler) API {
+	api := NewAPI(&Configuration{GraphQL: true})
+	api.RegisterResourceHandler(handler)
+	return api
+}
+
+// Ensures that parseGraphQL parses operations with variables, aliases, arguments, and
+// nested selection sets.
+func TestParseGraphQL(t *testing.T) {
+	assert := assert.New(t)
+
+	operations, err := parseGraphQL(`
+		# Comment
+		query Foos($limit: Int! = 5, $ids: [ID!]) {
+			first: foo(id: "1") { foo, bar }
+			fooList(limit: $limit, filter: {status: ["open", CLOSED]}, deep: true) {
+				results { foo }
+			}
+		}
+		mutation { createFoo(input: {foo: "a\"b!", n: -1.5e2, x: null}) { foo } }`)
+
+	assert.NoError(err)
+	if !assert.Len(operations, 2) {
+		return
+	}
+
+	query := operations[0]
+	assert.Equal("query", query.kind)
+	assert.Equal("Foos", query.name)
+	assert.Equal(map[string]interface{}{"limit": float64(5)}, query.defaults)
+	assert.Len(query.selections, 2)
+	assert.Equal("first", query.selections[0].key())
+	assert.Equal("foo", query.selections[0].name)
+	assert.Equal(map[string]interface{}{"id": "1"}, query.selections[0].args)
+	assert.Len(query.selections[0].selections, 2)
+	assert.Equal(map[string]interface{}{
+		"limit":  gqlVariable("limit"),
+		"filter": map[string]interface{}{"status": []interface{}{"open", "CLOSED"}},
+		"deep":   true,
+	}, query.selections[1].args)
+	assert.Equal("results", query.selections[1].selections[0].name)
+
+	mutation := operations[1]
+	assert.Equal("mutation", mutation.kind)
+	assert.Equal(map[string]interface{}{"input": map[string]interface{}{
+		"foo": `a"b!`, "n": -150.0, "x": nil,
+	}}, mutation.selections[0].args)
+}
+
+// Ensures that parseGraphQL returns an error for malformed and unsupported documents.
+func TestParseGraphQLInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, document := range []string{
+		``,
+		`{}`,
+		`{ foo(id: ) }`,
+		`{ foo(id: "1) }`,
+		`{ foo `,
+		`query ($x) { foo }`,
+		`{ foo { ...Fields } }`,
+		`fragment Fields on Foo { foo }`,
+		`subscription { foo }`,
+		`{ foo @include(if: true) }`,
+		`{ foo(id: 1.2.3) }`,
+	} {
+		_, err := parseGraphQL(document)
+		assert.Error(err, document)
+	}
+}
+
+// Ensures that queries read resources, applying the selection sets to the results in
+// order.
+func TestGraphQLQuery(t *testing.T) {
+	assert := assert.New(t)
+	api := newGraphQLAPI(&graphqlResourceHandler{})
+
+	resp := postGraphQL(api, `{"query": "query ($id: ID!) { __typename first: foo(id: $id) { bar foo __typename } fooList(limit: 3) { results { foo bar } next } }", "variables": {"id": "1"}}`)
+
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal("application/json", resp.Header().Get("Content-Type"))
+	assert.JSONEq(`{"data": {
+		"__typename": "Query",
+		"first": {"bar": 1, "foo": "hello", "__typename": "Foo"},
+		"fooList": {"results": [{"foo": "a", "bar": 3}, {"foo": "b", "bar": 0}], "next": "page2"}
+	}}`, resp.Body.String())
+	assert.True(strings.HasPrefix(resp.Body.String(), `{"data":{"__typename":"Query","first":{"bar":1`))
+}
+
+// Ensures that list queries with search arguments are performed with the resource's
+// search endpoint.
+func TestGraphQLSearch(t *testing.T) {
+	assert := assert.New(t)
+	handler := &graphqlResourceHandler{}
+	api := newGraphQLAPI(handler)
+
+	resp := postGraphQL(api, `{"query": "{ fooList(q: \"hi\", filter: {status: [\"open\", \"closed\"]}, sort: \"-bar\") { results { foo } next } }"}`)
+
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.JSONEq(`{"data": {"fooList": {"results": [{"foo": "hi"}], "next": null}}}`,
+		resp.Body.String())
+	assert.Equal(SearchQuery{
+		Text:    "hi",
+		Filters: map[string][]string{"status": {"open", "closed"}},
+		Sort:    []SortOrder{{Field: "bar", Descending: true}},
+	}, handler.query)
+}
+
+// Ensures that mutations create, update, and delete resources, reporting errors from
+// the handler with their status.
+func TestGraphQLMutation(t *testing.T) {
+	assert := assert.New(t)
+	handler := &graphqlResourceHandler{}
+	api := newGraphQLAPI(handler)
+
+	resp := postGraphQL(api, `{"query": "mutation ($input: JSON!) { created: createFoo(input: $input) { foo bar } deleteFoo(id: 7) { foo } updateFoo(id: 1, input: {foo: \"x\"}) { foo } }", "variables": {"input": {"foo": "new"}}}`)
+
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.JSONEq(`{
+		"data": {"created": {"foo": "new", "bar": 2}, "deleteFoo": {"foo": "gone"}, "updateFoo": null},
+		"errors": [{"message": "UpdateResource not implemented", "path": ["updateFoo"], "extensions": {"status": 405}}]
+	}`, resp.Body.String())
+	assert.Equal(Payload{"foo": "new"}, handler.created)
+	assert.Equal("7", handler.deleted)
+}
+
+// Ensures that operations are authenticated with the GraphQL request's headers and
+// that failed operations don't fail the rest of the request.
+func TestGraphQLErrors(t *testing.T) {
+	assert := assert.New(t)
+	api := newGraphQLAPI(&graphqlResourceHandler{})
+
+	resp := postGraphQL(api, `{"query": "{ foo(id: 2) { foo } a: foo(id: 1) { foo } }"}`)
+	assert.JSONEq(`{
+		"data": {"foo": null, "a": {"foo": "hello"}},
+		"errors": [{"message": "No foo 2", "path": ["foo"], "extensions": {"status": 404}}]
+	}`, resp.Body.String())
+
+	req, _ := http.NewRequest("GET", "http://example.com/api/v1/graphql?query="+
+		url.QueryEscape("{ foo(id: 1) { foo } }"), nil)
+	rec := httptest.NewRecorder()
+	api.ServeHTTP(rec, req)
+	assert.Equal(http.StatusOK, rec.Code)
+	assert.JSONEq(`{
+		"data": {"foo": null},
+		"errors": [{"message": "Not authorized", "path": ["foo"], "extensions": {"status": 401}}]
+	}`, rec.Body.String())
+}
+
+// Ensures that invalid GraphQL requests are rejected without executing any
+// operations.
+func TestGraphQLInvalidRequest(t *testing.T) {
+	assert := assert.New(t)
+	handler := &graphqlResourceHandler{}
+	api := newGraphQLAPI(handler)
+
+	resp := postGraphQL(api, `{"query": "mutation { createFoo(input: {foo: \"a\"}) { foo } bar { baz } }"}`)
+	assert.Equal(http.StatusBadRequest, resp.Code)
+	assert.JSONEq(`{"errors": [{"message": "Cannot query field \"bar\" on type \"Mutation\""}]}`,
+		resp.Body.String())
+	assert.Nil(handler.created)
+
+	resp = postGraphQL(api, `{"query": "{ foo(id: 1) "}`)
+	assert.Equal(http.StatusBadRequest, resp.Code)
+
+	resp = postGraphQL(api, `{"query": "query A { foo(id: 1) { foo } } query B { foo(id: 1) { bar } }"}`)
+	assert.Equal(http.StatusBadRequest, resp.Code)
+	resp = postGraphQL(api, `{"query": "query A { foo(id: 1) { foo } } query B { foo(id: 1) { bar } }", "operationName": "B"}`)
+	assert.JSONEq(`{"data": {"foo": {"bar": 1}}}`, resp.Body.String())
+
+	req, _ := http.NewRequest("GET", "http://example.com/api/v1/graphql?query="+
+		url.QueryEscape(`mutation { deleteFoo(id: 1) { foo } }`), nil)
+	rec := httptest.NewRecorder()
+	api.ServeHTTP(rec, req)
+	assert.Equal(http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal("", handler.deleted)
+}
+
+// Ensures that operations selecting more than the maximum number of fields are rejected
+// without executing any of them.
+func TestGraphQLMaxFields(t *testing.T) {
+	assert := assert.New(t)
+	handler := &graphqlResourceHandler{}
+	api := NewAPI(&Configuration{GraphQL: true, GraphQLMaxFields: 2})
+	api.RegisterResourceHandler(handler)
+
+	resp := postGraphQL(api, `{"query": "{ a: foo(id: 1) { foo } b: foo(id: 1) { foo } }"}`)
+	assert.Equal(http.StatusOK, resp.Code)
+
+	resp = postGraphQL(api, `{"query": "mutation { a: deleteFoo(id: 1) { foo } b: deleteFoo(id: 2) { foo } c: deleteFoo(id: 3) { foo } }"}`)
+	assert.Equal(http.StatusBadRequest, resp.Code)
+	assert.JSONEq(`{"errors": [{"message": "Operations may select at most 2 fields"}]}`,
+		resp.Body.String())
+	assert.Equal("", handler.deleted)
+}
+
+// Ensures that GraphQL requests go through the tenancy and timing middleware, and
+// perform operations as the resolved tenant.
+func TestGraphQLMiddleware(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{GraphQL: true, Tenancy: &Tenancy{Source: TenantHeader}})
+	api.RegisterResourceHandler(&graphqlResourceHandler{})
+
+	resp := postGraphQL(api, `{"query": "{ foo(id: 1) { foo } }"}`)
+	assert.Equal(http.StatusBadRequest, resp.Code)
+	assert.Contains(resp.Body.String(), "Missing tenant")
+
+	req, _ := http.NewRequest("POST", "http://example.com/api/v1/graphql",
+		bytes.NewBufferString(`{"query": "{ foo(id: 1) { foo } }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "secret")
+	req.Header.Set("X-Tenant-ID", "a")
+	rec := httptest.NewRecorder()
+	api.ServeHTTP(rec, req)
+	assert.Equal(http.StatusOK, rec.Code)
+	assert.JSONEq(`{"data": {"foo": {"foo": "hello"}}}`, rec.Body.String())
+
+	assert.Contains(api.TenantStats(), TenantStats{Tenant: "a", Resource: "graphql", Requests: 1})
+	assert.Contains(api.TenantStats(), TenantStats{Tenant: "a", Resource: "foo", Requests: 1})
+}
+
+// Ensures that the GraphQL endpoint accepts documents sent as application/graphql.
+func TestGraphQLDocumentBody(t *testing.T) {
+	assert := assert.New(t)
+	api := newGraphQLAPI(&graphqlResourceHandler{})
+
+	req, _ := http.NewRequest("POST", "http://example.com/api/v1/graphql",
+		bytes.NewBufferString(`{ foo(id: "1") { foo } }`))
+	req.Header.Set("Content-Type", graphqlMediaType)
+	req.Header.Set("Authorization", "secret")
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.JSONEq(`{"data": {"foo": {"foo": "hello"}}}`, resp.Body.String())
+}
+
+// Ensures that the GraphQL endpoint isn't registered unless it's enabled.
+func TestGraphQLDisabled(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&graphqlResourceHandler{})
+
+	resp := postGraphQL(api, `{"query": "{ foo(id: 1) { foo } }"}`)
+
+	assert.Equal(http.StatusNotFound, resp.Code)
+}
+
+// Ensures that the schema endpoint describes the queries, mutations, and types derived
+// from the registered resources.
+func TestGraphQLSchema(t *testing.T) {
+	assert := assert.New(t)
+	api := newGraphQLAPI(&graphqlResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://example.com/api/v1/graphql/schema", nil)
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal(`scalar JSON
+
+type Query {
+  foo(id: ID!): Foo
+  fooList(limit: Int, cursor: String, q: String, filter: JSON, sort: [String!]): FooList
+}
+
+type Mutation {
+  createFoo(input: JSON!): Foo
+  updateFoo(id: ID!, input: JSON!): Foo
+  deleteFoo(id: ID!): Foo
+}
+
+type Foo {
+  foo: String
+  bar: Int
+}
+
+type FooList {
+  results: [Foo!]!
+  next: String
+}
+`, resp.Body.String())
+}
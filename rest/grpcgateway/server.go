@@ -0,0 +1,300 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package grpcgateway serves the resources registered with a rest.API over gRPC with
+// the generic ResourceService defined in gateway.proto. Payloads are sent as
+// google.protobuf.Any, holding a google.protobuf.Struct or any registered message, and
+// resources are returned as google.protobuf.Struct. Requests are performed with
+// API#Dispatch, so they go through the same routing, middleware, authentication,
+// Rules, and hooks as HTTP requests, and HTTP error statuses are translated to gRPC
+// status codes. The API must use the default response envelope.
+package grpcgateway
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative gateway.proto
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Workiva/go-rest/rest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// httpCodes maps HTTP error statuses to gRPC status codes. Other 4xx statuses map to
+// FailedPrecondition and other 5xx statuses to Internal.
+var httpCodes = map[int]codes.Code{
+	http.StatusBadRequest:            codes.InvalidArgument,
+	http.StatusUnauthorized:          codes.Unauthenticated,
+	http.StatusForbidden:             codes.PermissionDenied,
+	http.StatusNotFound:              codes.NotFound,
+	http.StatusMethodNotAllowed:      codes.Unimplemented,
+	http.StatusConflict:              codes.Aborted,
+	http.StatusPreconditionFailed:    codes.FailedPrecondition,
+	http.StatusRequestEntityTooLarge: codes.InvalidArgument,
+	http.StatusUnprocessableEntity:   codes.InvalidArgument,
+	http.StatusPreconditionRequired:  codes.FailedPrecondition,
+	http.StatusTooManyRequests:       codes.ResourceExhausted,
+	http.StatusNotImplemented:        codes.Unimplemented,
+	http.StatusServiceUnavailable:    codes.Unavailable,
+	http.StatusGatewayTimeout:        codes.DeadlineExceeded,
+}
+
+// Server is a ResourceServiceServer performing operations on the resources registered
+// with an API.
+type Server struct {
+	UnimplementedResourceServiceServer
+	api rest.API
+}
+
+// NewServer returns a Server performing operations with the API.
+func NewServer(api rest.API) *Server {
+	return &Server{api: api}
+}
+
+// Register registers a Server for the API with the gRPC server.
+func Register(registrar grpc.ServiceRegistrar, api rest.API) {
+	RegisterResourceServiceServer(registrar, NewServer(api))
+}
+
+// Create creates a resource from the payload.
+func (s *Server) Create(ctx context.Context, req *CreateRequest) (*ResourceResponse, error) {
+	body, err := payloadBody(req.GetPayload())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.dispatch(ctx, rest.HandleCreate, req.GetRef(), "", nil, body)
+	if err != nil {
+		return nil, err
+	}
+	return resourceResponse(resp)
+}
+
+// Read reads the resource with the ID.
+func (s *Server) Read(ctx context.Context, req *ReadRequest) (*ResourceResponse, error) {
+	resp, err := s.dispatch(ctx, rest.HandleRead, req.GetRef(), req.GetId(), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resourceResponse(resp)
+}
+
+// Update updates the resource with the ID from the payload.
+func (s *Server) Update(ctx context.Context, req *UpdateRequest) (*ResourceResponse, error) {
+	body, err := payloadBody(req.GetPayload())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.dispatch(ctx, rest.HandleUpdate, req.GetRef(), req.GetId(), nil, body)
+	if err != nil {
+		return nil, err
+	}
+	return resourceResponse(resp)
+}
+
+// Delete deletes the resource with the ID.
+func (s *Server) Delete(ctx context.Context, req *DeleteRequest) (*ResourceResponse, error) {
+	resp, err := s.dispatch(ctx, rest.HandleDelete, req.GetRef(), req.GetId(), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resourceResponse(resp)
+}
+
+// List reads a page of resources.
+func (s *Server) List(ctx context.Context, req *ListRequest) (*ListResponse, error) {
+	query := url.Values{}
+	for key, value := range req.GetQuery() {
+		query.Set(key, value)
+	}
+	if req.GetLimit() > 0 {
+		query.Set("limit", strconv.Itoa(int(req.GetLimit())))
+	}
+	if req.GetCursor() != "" {
+		query.Set("next", req.GetCursor())
+	}
+
+	resp, err := s.dispatch(ctx, rest.HandleReadList, req.GetRef(), "", query, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	results, _ := resp.Body["results"].([]interface{})
+	out := &ListResponse{
+		Resources:  make([]*structpb.Struct, 0, len(results)),
+		NextCursor: nextCursor(resp.Header),
+		Messages:   responseMessages(resp),
+	}
+	for _, result := range results {
+		resource, err := resourceStruct(result)
+		if err != nil {
+			return nil, err
+		}
+		out.Resources = append(out.Resources, resource)
+	}
+	return out, nil
+}
+
+// dispatch performs the operation with the API, returning an error with the gRPC
+// status translated from the response if it failed.
+func (s *Server) dispatch(ctx context.Context, method rest.HandleMethod, ref *ResourceRef,
+	id string, query url.Values, body interface{}) (rest.GatewayResponse, error) {
+
+	resp, err := s.api.Dispatch(ctx, rest.GatewayRequest{
+		Method:   method,
+		Resource: ref.GetResource(),
+		ID:       id,
+		Version:  ref.GetVersion(),
+		Tenant:   ref.GetTenant(),
+		Header:   incomingHeader(ctx),
+		Query:    query,
+		Body:     body,
+	})
+	if err != nil {
+		if resp.Status == 0 {
+			// The request couldn't be routed, e.g. because the resource isn't registered.
+			return resp, status.Error(codes.NotFound, err.Error())
+		}
+		return resp, status.Error(codes.Internal, err.Error())
+	}
+	if resp.Status >= http.StatusBadRequest {
+		return resp, status.Error(statusCode(resp.Status), errorMessage(resp))
+	}
+	return resp, nil
+}
+
+// incomingHeader returns the metadata of the incoming gRPC request as HTTP headers.
+// Reserved gRPC metadata isn't included.
+func incomingHeader(ctx context.Context) http.Header {
+	header := http.Header{}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for key, values := range md {
+		if strings.HasPrefix(key, ":") || strings.HasPrefix(key, "grpc-") {
+			continue
+		}
+		header[http.CanonicalHeaderKey(key)] = values
+	}
+	return header
+}
+
+// payloadBody returns the JSON mapping of the payload's message, using the field
+// names in its definition, to be dispatched as the request body. A missing payload is
+// an empty object.
+func payloadBody(payload *anypb.Any) (interface{}, error) {
+	if payload == nil {
+		return map[string]interface{}{}, nil
+	}
+	message, err := payload.UnmarshalNew()
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument,
+			fmt.Sprintf("Invalid payload of type %s: %s", payload.GetTypeUrl(), err))
+	}
+	data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(message)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("Invalid payload: %s", err))
+	}
+	return json.RawMessage(data), nil
+}
+
+// resourceResponse returns the ResourceResponse for the response's result.
+func resourceResponse(resp rest.GatewayResponse) (*ResourceResponse, error) {
+	out := &ResourceResponse{Messages: responseMessages(resp)}
+	if result, ok := resp.Body["result"]; ok && result != nil {
+		resource, err := resourceStruct(result)
+		if err != nil {
+			return nil, err
+		}
+		out.Resource = resource
+	}
+	return out, nil
+}
+
+// resourceStruct returns the decoded JSON resource as a Struct.
+func resourceStruct(resource interface{}) (*structpb.Struct, error) {
+	fields, ok := resource.(map[string]interface{})
+	if !ok {
+		return nil, status.Error(codes.Internal,
+			fmt.Sprintf("Resource isn't an object: %v", resource))
+	}
+	s, err := structpb.NewStruct(fields)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("Invalid resource: %s", err))
+	}
+	return s, nil
+}
+
+// responseMessages returns the messages of the response envelope.
+func responseMessages(resp rest.GatewayResponse) []string {
+	values, _ := resp.Body["messages"].([]interface{})
+	messages := make([]string, 0, len(values))
+	for _, value := range values {
+		messages = append(messages, fmt.Sprint(value))
+	}
+	return messages
+}
+
+// errorMessage returns the message describing a failed response: its messages,
+// falling back to its reason, its raw body, e.g. from authentication middleware, and
+// the text of its status.
+func errorMessage(resp rest.GatewayResponse) string {
+	if messages := responseMessages(resp); len(messages) > 0 {
+		return strings.Join(messages, "; ")
+	}
+	if reason, ok := resp.Body["reason"].(string); ok && reason != "" {
+		return reason
+	}
+	if resp.Body == nil && len(resp.Raw) > 0 {
+		return string(resp.Raw)
+	}
+	return http.StatusText(resp.Status)
+}
+
+// statusCode returns the gRPC status code for the HTTP error status.
+func statusCode(httpStatus int) codes.Code {
+	if code, ok := httpCodes[httpStatus]; ok {
+		return code
+	}
+	if httpStatus < http.StatusInternalServerError {
+		return codes.FailedPrecondition
+	}
+	return codes.Internal
+}
+
+// nextCursor returns the cursor of the next page from the Link header, or an empty
+// string if there isn't a next page.
+func nextCursor(header http.Header) string {
+	for _, link := range strings.Split(header.Get("Link"), ",") {
+		parts := strings.Split(link, ";")
+		if len(parts) < 2 || strings.TrimSpace(parts[1]) != `rel="next"` {
+			continue
+		}
+		target := strings.Trim(strings.TrimSpace(parts[0]), "<>")
+		if u, err := url.Parse(target); err == nil {
+			return u.Query().Get("next")
+		}
+	}
+	return ""
+}
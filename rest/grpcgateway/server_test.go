@@ -0,0 +1,278 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpcgateway
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/Workiva/go-rest/rest"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/sourcecontextpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type fooResourceHandler struct {
+	rest.BaseResourceHandler
+}
+
+func (f *fooResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (f *fooResourceHandler) Authenticate(r *http.Request) error {
+	if r.Header.Get("Authorization") != "secret" {
+		return errors.New("Not authorized")
+	}
+	return nil
+}
+
+func (f *fooResourceHandler) CreateResource(ctx rest.RequestContext, data rest.Payload,
+	version string) (rest.Resource, error) {
+
+	if data["invalid"] == true {
+		return nil, rest.UnprocessableRequest("Invalid foo")
+	}
+	data["version"] = version
+	return data, nil
+}
+
+func (f *fooResourceHandler) ReadResource(ctx rest.RequestContext, id string,
+	version string) (rest.Resource, error) {
+
+	if id != "1" {
+		return nil, rest.ResourceNotFound("No foo " + id)
+	}
+	return rest.Payload{"id": id}, nil
+}
+
+func (f *fooResourceHandler) ReadResourceList(ctx rest.RequestContext, limit int,
+	cursor string, version string) ([]rest.Resource, string, error) {
+
+	if cursor == "" {
+		return []rest.Resource{rest.Payload{"id": "1", "limit": limit}}, "abc", nil
+	}
+	return []rest.Resource{rest.Payload{"id": "2", "cursor": cursor}}, "", nil
+}
+
+// newTestClient returns a client of a ResourceService serving the API over an
+// in-memory connection, and a function stopping the server.
+func newTestClient(t *testing.T, api rest.API) (ResourceServiceClient, func()) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	Register(server, api)
+	go server.Serve(listener)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewResourceServiceClient(conn), func() {
+		conn.Close()
+		server.Stop()
+	}
+}
+
+// newTestAPI returns an API with the foo resource registered.
+func newTestAPI() rest.API {
+	api := rest.NewAPI(&rest.Configuration{})
+	api.RegisterResourceHandler(&fooResourceHandler{})
+	return api
+}
+
+// authorized returns a context sending the credentials accepted by the foo resource.
+func authorized() context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "secret")
+}
+
+// Ensures that Create dispatches a Struct payload and returns the created resource.
+func TestCreateStruct(t *testing.T) {
+	assert := assert.New(t)
+	client, stop := newTestClient(t, newTestAPI())
+	defer stop()
+	fields, _ := structpb.NewStruct(map[string]interface{}{"foo": "bar"})
+	payload, _ := anypb.New(fields)
+
+	resp, err := client.Create(authorized(), &CreateRequest{
+		Ref:     &ResourceRef{Resource: "foo", Version: "2"},
+		Payload: payload,
+	})
+
+	if assert.NoError(err) {
+		assert.Equal(map[string]interface{}{"foo": "bar", "version": "2"},
+			resp.GetResource().AsMap())
+		assert.Empty(resp.GetMessages())
+	}
+}
+
+// Ensures that Create dispatches other messages by their JSON mapping, using the field
+// names in their definition.
+func TestCreateMessage(t *testing.T) {
+	assert := assert.New(t)
+	client, stop := newTestClient(t, newTestAPI())
+	defer stop()
+	payload, _ := anypb.New(&sourcecontextpb.SourceContext{FileName: "foo.proto"})
+
+	resp, err := client.Create(authorized(), &CreateRequest{
+		Ref:     &ResourceRef{Resource: "foo", Version: "1"},
+		Payload: payload,
+	})
+
+	if assert.NoError(err) {
+		assert.Equal(map[string]interface{}{"file_name": "foo.proto", "version": "1"},
+			resp.GetResource().AsMap())
+	}
+}
+
+// Ensures that Create returns InvalidArgument for payloads of unknown types.
+func TestCreateUnknownPayload(t *testing.T) {
+	assert := assert.New(t)
+	client, stop := newTestClient(t, newTestAPI())
+	defer stop()
+
+	_, err := client.Create(authorized(), &CreateRequest{
+		Ref:     &ResourceRef{Resource: "foo", Version: "1"},
+		Payload: &anypb.Any{TypeUrl: "type.googleapis.com/unknown.Message"},
+	})
+
+	assert.Equal(codes.InvalidArgument, status.Code(err))
+}
+
+// Ensures that errors returned by the handler are translated to gRPC status codes with
+// the response messages.
+func TestCreateHandlerError(t *testing.T) {
+	assert := assert.New(t)
+	client, stop := newTestClient(t, newTestAPI())
+	defer stop()
+	fields, _ := structpb.NewStruct(map[string]interface{}{"invalid": true})
+	payload, _ := anypb.New(fields)
+
+	_, err := client.Create(authorized(), &CreateRequest{
+		Ref:     &ResourceRef{Resource: "foo", Version: "1"},
+		Payload: payload,
+	})
+
+	assert.Equal(codes.InvalidArgument, status.Code(err))
+	assert.Equal("Invalid foo", status.Convert(err).Message())
+}
+
+// Ensures that Read returns the resource and NotFound for missing resources.
+func TestRead(t *testing.T) {
+	assert := assert.New(t)
+	client, stop := newTestClient(t, newTestAPI())
+	defer stop()
+	ref := &ResourceRef{Resource: "foo", Version: "1"}
+
+	resp, err := client.Read(authorized(), &ReadRequest{Ref: ref, Id: "1"})
+
+	if assert.NoError(err) {
+		assert.Equal(map[string]interface{}{"id": "1"}, resp.GetResource().AsMap())
+	}
+
+	_, err = client.Read(authorized(), &ReadRequest{Ref: ref, Id: "2"})
+
+	assert.Equal(codes.NotFound, status.Code(err))
+	assert.Equal("No foo 2", status.Convert(err).Message())
+}
+
+// Ensures that the request metadata is passed to the handler's Authenticate and that
+// failed authentication is Unauthenticated.
+func TestReadUnauthenticated(t *testing.T) {
+	assert := assert.New(t)
+	client, stop := newTestClient(t, newTestAPI())
+	defer stop()
+
+	_, err := client.Read(context.Background(), &ReadRequest{
+		Ref: &ResourceRef{Resource: "foo", Version: "1"},
+		Id:  "1",
+	})
+
+	assert.Equal(codes.Unauthenticated, status.Code(err))
+	assert.Equal("Not authorized", status.Convert(err).Message())
+}
+
+// Ensures that operations on unregistered resources are NotFound and unimplemented
+// operations are Unimplemented.
+func TestUnknownOperation(t *testing.T) {
+	assert := assert.New(t)
+	client, stop := newTestClient(t, newTestAPI())
+	defer stop()
+
+	_, err := client.Read(authorized(), &ReadRequest{
+		Ref: &ResourceRef{Resource: "bar", Version: "1"},
+		Id:  "1",
+	})
+
+	assert.Equal(codes.NotFound, status.Code(err))
+
+	_, err = client.Delete(authorized(), &DeleteRequest{
+		Ref: &ResourceRef{Resource: "foo", Version: "1"},
+		Id:  "1",
+	})
+
+	assert.Equal(codes.Unimplemented, status.Code(err))
+}
+
+// Ensures that List returns pages of resources with the cursor of the next page.
+func TestList(t *testing.T) {
+	assert := assert.New(t)
+	client, stop := newTestClient(t, newTestAPI())
+	defer stop()
+	ref := &ResourceRef{Resource: "foo", Version: "1"}
+
+	resp, err := client.List(authorized(), &ListRequest{Ref: ref, Limit: 5})
+
+	if assert.NoError(err) && assert.Len(resp.GetResources(), 1) {
+		assert.Equal(map[string]interface{}{"id": "1", "limit": float64(5)},
+			resp.GetResources()[0].AsMap())
+		assert.Equal("abc", resp.GetNextCursor())
+	}
+
+	resp, err = client.List(authorized(), &ListRequest{Ref: ref, Cursor: "abc"})
+
+	if assert.NoError(err) && assert.Len(resp.GetResources(), 1) {
+		assert.Equal(map[string]interface{}{"id": "2", "cursor": "abc"},
+			resp.GetResources()[0].AsMap())
+		assert.Equal("", resp.GetNextCursor())
+	}
+}
+
+// Ensures that HTTP error statuses are translated to gRPC status codes.
+func TestStatusCode(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(codes.InvalidArgument, statusCode(http.StatusBadRequest))
+	assert.Equal(codes.PermissionDenied, statusCode(http.StatusForbidden))
+	assert.Equal(codes.Aborted, statusCode(http.StatusConflict))
+	assert.Equal(codes.ResourceExhausted, statusCode(http.StatusTooManyRequests))
+	assert.Equal(codes.FailedPrecondition, statusCode(http.StatusGone))
+	assert.Equal(codes.Unavailable, statusCode(http.StatusServiceUnavailable))
+	assert.Equal(codes.Internal, statusCode(http.StatusBadGateway))
+}
@@ -21,8 +21,6 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-
-	"github.com/gorilla/mux"
 )
 
 // Resource represents a domain model.
@@ -87,8 +85,8 @@ type ResourceHandler interface {
 
 	// ReadResource is the logic that corresponds to reading a single resource by its ID
 	// at GET /api/:version/resourceName/{id}. Typically, this would make some sort of
-	// database query to load the resource. If the resource doesn't exist, nil should be
-	// returned along with an appropriate error.
+	// database query to load the resource. If the resource doesn't exist, it should
+	// return ResourceNotFound, or nil without an error, so the client receives a 404.
 	ReadResource(RequestContext, string, string) (Resource, error)
 
 	// UpdateResourceList is the logic that corresponds to updating a collection of
@@ -100,13 +98,16 @@ type ResourceHandler interface {
 	// UpdateResource is the logic that corresponds to updating an existing resource at
 	// PUT /api/:version/resourceName/{id}. Typically, this would make some sort of
 	// database update call. It returns the updated resource or an error if the update
-	// failed.
+	// failed. It's also called with the patched resource for JSON Merge Patch and JSON
+	// Patch requests at PATCH /api/:version/resourceName/{id}.
 	UpdateResource(RequestContext, string, Payload, string) (Resource, error)
 
 	// DeleteResource is the logic that corresponds to deleting an existing resource at
 	// DELETE /api/:version/resourceName/{id}. Typically, this would make some sort of
 	// database delete call. It returns the deleted resource or an error if the delete
-	// failed.
+	// failed. If the resource doesn't exist, it should return ResourceNotFound so the
+	// client receives a 404. Handlers which don't return the deleted resource can
+	// respond with 204 No Content by implementing NoContentDeleteResourceHandler.
 	DeleteResource(RequestContext, string, string) (Resource, error)
 
 	// Authenticate is logic that is used to authenticate requests. The default behavior
@@ -116,6 +117,7 @@ type ResourceHandler interface {
 	Authenticate(*http.Request) error
 
 	// ValidVersions returns the list of all versions accepted at this endpoint.
+	// Versions may be ranges, e.g. ">=1.2 <2", accepting any version in the range.
 	// Invalid versions will result in a BadRequest error.
 	// If the value is nil, any version will be accepted.
 	ValidVersions() []string
@@ -129,7 +131,18 @@ type ResourceHandler interface {
 // requestHandler constructs http.HandlerFuncs responsible for handling HTTP requests.
 type requestHandler struct {
 	API
-	router *mux.Router
+}
+
+// newContext returns a RequestContext for the request which has access to the API
+// Configuration. Any deprecation warnings for the request are added to its messages.
+func (h requestHandler) newContext(w http.ResponseWriter, r *http.Request) RequestContext {
+	ctx := newContextWithRouter(nil, r, w, h.activeRouter())
+	if warnings, ok := ctx.Value(deprecationWarningsKey).([]string); ok {
+		for _, warning := range warnings {
+			ctx.AddMessage(warning)
+		}
+	}
+	return ctx.setConfiguration(h.Configuration())
 }
 
 // handleCreate returns a HandlerFunc which will deserialize the request payload, pass
@@ -137,23 +150,54 @@ type requestHandler struct {
 // The serialization mechanism used is specified by the "format" query parameter.
 func (h requestHandler) handleCreate(handler ResourceHandler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx := NewContextWithRouter(nil, r, w, h.router)
+		ctx := h.newContext(w, r)
 		version := ctx.Version()
-		rules := handler.Rules()
+		rules := visibleRules(ctx, handler.Rules())
+
+		if err := checkDryRun(ctx, handler); err != nil {
+			h.sendResponse(ctx.setError(err))
+			return
+		}
 
-		data, err := decodePayload(ctx.Body().Bytes())
+		middleware := payloadMiddleware(h.Configuration(), handler)
+		inbound, err := inboundRules(ctx, handler)
+		if err != nil {
+			h.sendResponse(ctx.setError(err))
+			return
+		}
+
+		body, err := h.requestBody(ctx)
+		if err != nil {
+			h.sendResponse(ctx.setError(err))
+			return
+		}
+
+		data, err := decodeRequestPayload(ctx, handler, body)
 		if err != nil {
 			// Payload decoding failed.
-			ctx = ctx.setError(BadRequest(err.Error()))
+			ctx = ctx.setError(err)
+		} else if data, err = applyPayloadMiddleware(ctx, middleware, data); err != nil {
+			ctx = ctx.setError(err)
+		} else if err = validatePayload(payloadJSONSchema(ctx, handler), data); err != nil {
+			ctx = ctx.setError(err)
 		} else {
-			data, err := applyInboundRules(data, rules, version)
+			data, err := applyTimedInboundRules(ctx, data, inbound, version)
 			if err != nil {
 				// Type coercion failed.
-				ctx = ctx.setError(UnprocessableRequest(err.Error()))
+				ctx = ctx.setError(err)
 			} else {
-				resource, err := handler.CreateResource(ctx, data, ctx.Version())
+				resource, err := createResource(ctx, handler, data, ctx.Version())
+				if async, ok := resource.(*AsyncResult); ok && err == nil {
+					h.sendResponse(h.startOperation(ctx, handler, async))
+					return
+				}
 				if err == nil {
-					resource = applyOutboundRules(resource, rules, version)
+					stop := startTiming(ctx, TimingRules)
+					outbound := applyOutboundRules(resource, rules, version)
+					stop()
+					setCreatedLocation(ctx, handler, resource, outbound)
+					h.notifyWebhooks(ctx, handler, HandleCreate, "", resource)
+					resource = outbound
 				}
 
 				if resource != nil {
@@ -178,18 +222,46 @@ func (h requestHandler) handleCreate(handler ResourceHandler) http.Handler {
 // serialization mechanism used is specified by the "format" query parameter.
 func (h requestHandler) handleReadList(handler ResourceHandler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx := NewContextWithRouter(nil, r, w, h.router)
+		ctx := h.newContext(w, r)
+		ctx = ctx.setPagination(paginationStrategy(handler))
+		limits := resolveListLimits(h.Configuration(), handler)
+		ctx = ctx.setLimits(limits)
 		version := ctx.Version()
-		rules := handler.Rules()
+		rules := visibleRules(ctx, handler.Rules())
+
+		if h.Configuration().RejectOversizedLimits && ctx.limitExceeded() {
+			ctx = ctx.setError(BadRequest(fmt.Sprintf(
+				"Limit exceeds maximum of %d", limits.maxLimit)))
+			h.sendResponse(ctx)
+			return
+		}
 
-		resources, cursor, err := handler.ReadResourceList(
-			ctx, ctx.Limit(), ctx.Cursor(), version)
+		limit, cursor := ctx.Limit(), ctx.Cursor()
+		if ctx.Pagination() == OffsetPagination {
+			limit, cursor = ctx.PerPage(), ""
+		}
+
+		if reader, ok := unwrapResourceHandler(handler).(MultiReadResourceHandler); ok &&
+			multiReadRequested(ctx) {
+			h.multiRead(ctx, handler, reader, limits)
+			return
+		}
+
+		if streamer, ok := unwrapResourceHandler(handler).(StreamingResourceHandler); ok &&
+			streamRequested(ctx) {
+			h.stream(ctx, handler, streamer, limit, cursor)
+			return
+		}
+
+		resources, cursor, err := readResourceList(ctx, handler, limit, cursor, version)
 
 		if err == nil {
 			// Apply rules to results.
+			stop := startTiming(ctx, TimingRules)
 			for idx, resource := range resources {
 				resources[idx] = applyOutboundRules(resource, rules, version)
 			}
+			stop()
 		}
 
 		ctx = ctx.setResult(resources)
@@ -197,6 +269,10 @@ func (h requestHandler) handleReadList(handler ResourceHandler) http.Handler {
 		ctx = ctx.setError(err)
 		ctx = ctx.setStatus(http.StatusOK)
 
+		if err == nil {
+			setLinkHeader(ctx)
+		}
+
 		h.sendResponse(ctx)
 	})
 }
@@ -206,13 +282,16 @@ func (h requestHandler) handleReadList(handler ResourceHandler) http.Handler {
 // mechanism used is specified by the "format" query parameter.
 func (h requestHandler) handleRead(handler ResourceHandler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx := NewContextWithRouter(nil, r, w, h.router)
+		ctx := h.newContext(w, r)
 		version := ctx.Version()
-		rules := handler.Rules()
+		rules := visibleRules(ctx, handler.Rules())
 
-		resource, err := handler.ReadResource(ctx, ctx.ResourceID(), version)
+		resource, err := readResource(ctx, handler, ctx.ResourceID(), version)
 		if err == nil {
+			stop := startTiming(ctx, TimingRules)
 			resource = applyOutboundRules(resource, rules, version)
+			stop()
+			h.pushRelations(ctx, handler, resource)
 		}
 
 		ctx = ctx.setResult(resource)
@@ -229,37 +308,61 @@ func (h requestHandler) handleRead(handler ResourceHandler) http.Handler {
 // parameter.
 func (h requestHandler) handleUpdateList(handler ResourceHandler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx := NewContextWithRouter(nil, r, w, h.router)
+		ctx := h.newContext(w, r)
 		version := ctx.Version()
-		rules := handler.Rules()
+		rules := visibleRules(ctx, handler.Rules())
+
+		if err := checkDryRun(ctx, handler); err != nil {
+			h.sendResponse(ctx.setError(err))
+			return
+		}
+
+		middleware := payloadMiddleware(h.Configuration(), handler)
+		inbound, err := inboundRules(ctx, handler)
+		if err != nil {
+			h.sendResponse(ctx.setError(err))
+			return
+		}
 
-		payloadStr := ctx.Body().Bytes()
-		var data []Payload
-		var err error
-		data, err = decodePayloadSlice(payloadStr)
+		payloadStr, err := h.requestBody(ctx)
 		if err != nil {
-			var p Payload
-			p, err = decodePayload(payloadStr)
-			data = []Payload{p}
+			h.sendResponse(ctx.setError(err))
+			return
 		}
 
+		data, err := decodeRequestPayloads(ctx, handler, payloadStr)
 		if err != nil {
 			// Payload decoding failed.
-			ctx = ctx.setError(BadRequest(err.Error()))
+			ctx = ctx.setError(err)
+		} else if err = applyPayloadMiddlewareList(ctx, middleware, data); err != nil {
+			ctx = ctx.setError(err)
+		} else if err = validatePayloads(payloadJSONSchema(ctx, handler), data); err != nil {
+			ctx = ctx.setError(err)
 		} else {
+			stop := startTiming(ctx, TimingRules)
 			for i := range data {
-				data[i], err = applyInboundRules(data[i], rules, version)
+				if data[i], err = applyInboundRules(data[i], inbound, version); err != nil {
+					break
+				}
 			}
+			stop()
 			if err != nil {
 				// Type coercion failed.
-				ctx = ctx.setError(UnprocessableRequest(err.Error()))
+				ctx = ctx.setError(err)
 			} else {
-				resources, err := handler.UpdateResourceList(ctx, data, version)
+				resources, err := updateResourceList(ctx, handler, data, version)
 				if err == nil {
 					// Apply rules to results.
+					updated := append([]Resource{}, resources...)
+					stop := startTiming(ctx, TimingRules)
 					for idx, resource := range resources {
 						resources[idx] = applyOutboundRules(resource, rules, version)
 					}
+					stop()
+					for idx, resource := range updated {
+						id, _ := resourceID(resource, resources[idx], handler.Rules(), version)
+						h.notifyWebhooks(ctx, handler, HandleUpdate, id, resource)
+					}
 				}
 
 				ctx = ctx.setResult(resources)
@@ -278,29 +381,47 @@ func (h requestHandler) handleUpdateList(handler ResourceHandler) http.Handler {
 // parameter.
 func (h requestHandler) handleUpdate(handler ResourceHandler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx := NewContextWithRouter(nil, r, w, h.router)
+		ctx := h.newContext(w, r)
 		version := ctx.Version()
-		rules := handler.Rules()
 
-		data, err := decodePayload(ctx.Body().Bytes())
+		if err := checkDryRun(ctx, handler); err != nil {
+			h.sendResponse(ctx.setError(err))
+			return
+		}
+
+		if err := checkPreconditionRequired(ctx, handler); err != nil {
+			h.sendResponse(ctx.setError(err))
+			return
+		}
+
+		middleware := payloadMiddleware(h.Configuration(), handler)
+		inbound, err := inboundRules(ctx, handler)
+		if err != nil {
+			h.sendResponse(ctx.setError(err))
+			return
+		}
+
+		body, err := h.requestBody(ctx)
+		if err != nil {
+			h.sendResponse(ctx.setError(err))
+			return
+		}
+
+		data, err := decodeRequestPayload(ctx, handler, body)
 		if err != nil {
 			// Payload decoding failed.
-			ctx = ctx.setError(BadRequest(err.Error()))
+			ctx = ctx.setError(err)
+		} else if data, err = applyPayloadMiddleware(ctx, middleware, data); err != nil {
+			ctx = ctx.setError(err)
+		} else if err = validatePayload(payloadJSONSchema(ctx, handler), data); err != nil {
+			ctx = ctx.setError(err)
 		} else {
-			data, err := applyInboundRules(data, rules, version)
+			data, err := applyTimedInboundRules(ctx, data, inbound, version)
 			if err != nil {
 				// Type coercion failed.
-				ctx = ctx.setError(UnprocessableRequest(err.Error()))
-			} else {
-				resource, err := handler.UpdateResource(
-					ctx, ctx.ResourceID(), data, version)
-				if err == nil {
-					resource = applyOutboundRules(resource, rules, version)
-				}
-
-				ctx = ctx.setResult(resource)
 				ctx = ctx.setError(err)
-				ctx = ctx.setStatus(http.StatusOK)
+			} else {
+				ctx = h.update(ctx, handler, data)
 			}
 		}
 
@@ -308,18 +429,108 @@ func (h requestHandler) handleUpdate(handler ResourceHandler) http.Handler {
 	})
 }
 
+// update passes the payload to the provided update function and sets the result on
+// the RequestContext. If the client requested a differential response and the handler
+// supports it, the result only contains the fields which changed.
+func (h requestHandler) update(ctx RequestContext, handler ResourceHandler,
+	data Payload) RequestContext {
+
+	return h.updateWith(ctx, handler, func(ctx RequestContext) (Resource, error) {
+		return updateResource(ctx, handler, ctx.ResourceID(), data, ctx.Version())
+	})
+}
+
+// updateWith updates the resource with the update function and sets the result on the
+// RequestContext like update.
+func (h requestHandler) updateWith(ctx RequestContext, handler ResourceHandler,
+	update func(RequestContext) (Resource, error)) RequestContext {
+
+	version := ctx.Version()
+	rules := visibleRules(ctx, handler.Rules())
+
+	ctx, err := capturePreImage(ctx, handler)
+	if err != nil {
+		return ctx.setError(err)
+	}
+
+	var preImage Resource
+	diff := diffRequested(ctx)
+	if diff {
+		if preImage, diff, err = diffPreImage(ctx, handler); err != nil {
+			return ctx.setError(err)
+		}
+	}
+
+	resource, err := update(ctx)
+	if async, ok := resource.(*AsyncResult); ok && err == nil {
+		return h.startOperation(ctx, handler, async)
+	}
+	if err == nil {
+		h.notifyWebhooks(ctx, handler, HandleUpdate, ctx.ResourceID(), resource)
+		stop := startTiming(ctx, TimingRules)
+		resource = applyOutboundRules(resource, rules, version)
+		stop()
+	}
+
+	if err == nil && diff {
+		if etag, etagErr := resourceETag(resource); etagErr == nil {
+			ctx.ResponseWriter().Header().Set("ETag", etag)
+		}
+		var changed Payload
+		if changed, err = diffResources(preImage, resource); err == nil {
+			resource = changed
+		}
+	}
+
+	ctx = ctx.setResult(resource)
+	ctx = ctx.setError(err)
+	return ctx.setStatus(http.StatusOK)
+}
+
 // handleDelete returns a Handler which will pass the resource id to the provided
 // delete function and then serialize and dispatch the response. The serialization
 // mechanism used is specified by the "format" query parameter.
 func (h requestHandler) handleDelete(handler ResourceHandler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx := NewContextWithRouter(nil, r, w, h.router)
+		ctx := h.newContext(w, r)
 		version := ctx.Version()
-		rules := handler.Rules()
+		rules := visibleRules(ctx, handler.Rules())
+
+		if err := checkDryRun(ctx, handler); err != nil {
+			h.sendResponse(ctx.setError(err))
+			return
+		}
+
+		if err := checkPreconditionRequired(ctx, handler); err != nil {
+			h.sendResponse(ctx.setError(err))
+			return
+		}
+
+		if confirmer, ok := unwrapResourceHandler(handler).(ConfirmedDeleteResourceHandler); ok {
+			var confirmed bool
+			if ctx, confirmed = h.confirmDelete(ctx, handler, confirmer); !confirmed {
+				h.sendResponse(ctx)
+				return
+			}
+		}
+
+		ctx, err := capturePreImage(ctx, handler)
+		if err != nil {
+			h.sendResponse(ctx.setError(err))
+			return
+		}
 
-		resource, err := handler.DeleteResource(ctx, ctx.ResourceID(), version)
+		resource, err := deleteResource(ctx, handler, ctx.ResourceID(), version)
 		if err == nil {
+			h.notifyWebhooks(ctx, handler, HandleDelete, ctx.ResourceID(), resource)
+			stop := startTiming(ctx, TimingRules)
 			resource = applyOutboundRules(resource, rules, version)
+			stop()
+		}
+
+		if err == nil && deleteNoContent(handler) {
+			h.sendResponse(ctx.setStatus(http.StatusNoContent))
+			return
 		}
 
 		ctx = ctx.setResult(resource)
@@ -333,42 +544,135 @@ func (h requestHandler) handleDelete(handler ResourceHandler) http.Handler {
 // sendResponse writes a success or error response to the provided http.ResponseWriter
 // based on the contents of the RequestContext.
 func (h requestHandler) sendResponse(ctx RequestContext) {
+	defer removeUploadedFiles(ctx)
+	if content, ok := ctx.Result().(*Content); ok && ctx.Error() == nil {
+		sendContent(ctx, content)
+		return
+	}
+	original := ctx.Error()
+	ctx = h.redactResponseError(ctx)
+	h.reportError(ctx, original, ctx.Error())
+	ctx = h.translateError(ctx)
+	h.applyRetryAfter(ctx)
+	ctx, serializer := h.requestedSerializer(ctx)
+	ctx, serializer = checkSerializerCapabilities(ctx, serializer)
+	if ctx.Error() != nil {
+		serializer = h.errorSerializer(serializer)
+	}
+
+	stop := startTiming(ctx, TimingSerialize)
+	var resp response
+	switch serializer.(type) {
+	case jsonAPISerializer:
+		resp = h.jsonAPIResponse(ctx)
+	case problemSerializer, ndjsonSerializer, protoSerializer:
+		resp = NewResponse(ctx)
+	default:
+		resp = NewResponse(ctx)
+		if links := h.responseLinks(ctx); len(links) > 0 && resp.Payload != nil {
+			resp.Payload[resourceLinks] = links
+		}
+		buildEnvelope(ctx, h.Configuration().Envelope, &resp)
+	}
+
+	w := ctx.ResponseWriter()
+	out := serializeResponse(w, resp, serializer)
+	stop()
+	applyResponseHeaders(ctx)
+	if err := applyResponseMiddleware(ctx, h.Configuration().ResponseMiddleware, out); err != nil {
+		h.logf("Response middleware failed: %s", err)
+		out.Status = http.StatusInternalServerError
+		out.Header.Set("Content-Type", "text/plain")
+		out.Body = []byte(err.Error())
+	}
+
+	recordDuplicate(ctx, out)
+	h.recordCache(ctx, out)
+	// Applied after the response is recorded so replayed responses don't report stale
+	// timings.
+	applyServerTiming(ctx, out.Header)
+	if req, ok := ctx.Request(); ok && rangeable(h.Configuration(), req, out) {
+		writeRangeResponse(w, req, out)
+	} else {
+		writeResponse(w, out)
+	}
+	releaseResponse(resp, out, serializer)
+}
+
+// requestedSerializer returns the ResponseSerializer for the format requested by the
+// client. If the format isn't implemented, the JSON serializer is returned along with a
+// RequestContext with a 400 error.
+func (h requestHandler) requestedSerializer(ctx RequestContext) (RequestContext, ResponseSerializer) {
+	switch {
+	case jsonAPIRequested(ctx):
+		return ctx, jsonAPISerializer{}
+	case streamRequested(ctx):
+		return ctx, ndjsonSerializer{}
+	case protoRequested(ctx):
+		return h.protoSerializer(ctx)
+	}
+
 	format := ctx.ResponseFormat()
 	serializer, err := h.responseSerializer(format)
 	if err != nil {
 		// Fall back to json serialization.
-		serializer = jsonSerializer{}
-		ctx = ctx.setError(BadRequest(fmt.Sprintf("Format not implemented: %s", format)))
+		return ctx.setError(BadRequest(fmt.Sprintf("Format not implemented: %s", format))),
+			jsonSerializer{}
 	}
+	return ctx, serializer
+}
 
-	sendResponse(ctx.ResponseWriter(), NewResponse(ctx), serializer)
+// protoSerializer returns the protocol buffer ResponseSerializer for the requested
+// resource. If the resource isn't mapped to protocol buffers, the JSON serializer is
+// returned along with a RequestContext with a 406 error.
+func (h requestHandler) protoSerializer(ctx RequestContext) (RequestContext, ResponseSerializer) {
+	if handler, ok := h.routeResourceHandler(ctx); ok {
+		if mapper, ok := unwrapResourceHandler(handler).(ProtoResourceHandler); ok {
+			return ctx, protoSerializer{mapper, ctx.Version()}
+		}
+	}
+	if ctx.Error() == nil {
+		ctx = ctx.setError(CustomError("Protocol buffers are not supported for this resource",
+			http.StatusNotAcceptable))
+	}
+	return ctx, jsonSerializer{}
+}
+
+// errorSerializer returns the ResponseSerializer for error responses, which is the
+// one for the configured ErrorFormat, if any, or the given one otherwise.
+func (h requestHandler) errorSerializer(serializer ResponseSerializer) ResponseSerializer {
+	switch format := h.Configuration().ErrorFormat; format {
+	case "":
+		return serializer
+	case ErrorFormatProblem:
+		return problemSerializer{}
+	default:
+		if errorSerializer, err := h.responseSerializer(format); err == nil {
+			return errorSerializer
+		}
+		return jsonSerializer{}
+	}
 }
 
 // sendResponse writes a response to the http.ResponseWriter.
 func sendResponse(w http.ResponseWriter, r response, serializer ResponseSerializer) {
-	status := r.Status
-	contentType := serializer.ContentType()
+	out := serializeResponse(w, r, serializer)
+	writeResponse(w, out)
+	releaseResponse(r, out, serializer)
+}
 
-	var response []byte
-	if r.Payload != nil {
-		var err error
-		response, err = serializer.Serialize(r.Payload)
-		if err != nil {
-			log.Printf("Response serialization failed: %s", err)
-			status = http.StatusInternalServerError
-			contentType = "text/plain"
-			response = []byte(err.Error())
-		}
+// logf writes to the Configuration Logger, falling back to the standard logger.
+func (h requestHandler) logf(format string, v ...interface{}) {
+	if logger := h.Configuration().Logger; logger != nil {
+		logger.Printf(format, v...)
+		return
 	}
-
-	w.Header().Set("Content-Type", contentType)
-	w.WriteHeader(status)
-	w.Write(response)
+	log.Printf(format, v...)
 }
 
 // decodePayload unmarshals the JSON payload and returns the resulting map. If the
 // content is empty, an empty map is returned. If decoding fails, nil is returned
-// with an error.
+// with an error describing where.
 func decodePayload(payload []byte) (Payload, error) {
 	if len(payload) == 0 {
 		return map[string]interface{}{}, nil
@@ -376,7 +680,7 @@ func decodePayload(payload []byte) (Payload, error) {
 
 	var data Payload
 	if err := json.Unmarshal(payload, &data); err != nil {
-		return nil, err
+		return nil, payloadError(payload, err)
 	}
 
 	return data, nil
@@ -384,7 +688,7 @@ func decodePayload(payload []byte) (Payload, error) {
 
 // decodePayloadSlice unmarshals the JSON payload and returns the resulting slice.
 // If the content is empty, an empty list is returned. If decoding fails, nil is
-// returned with an error.
+// returned with an error describing where.
 func decodePayloadSlice(payload []byte) ([]Payload, error) {
 	if len(payload) == 0 {
 		return []Payload{}, nil
@@ -392,7 +696,7 @@ func decodePayloadSlice(payload []byte) ([]Payload, error) {
 
 	var data []Payload
 	if err := json.Unmarshal(payload, &data); err != nil {
-		return nil, err
+		return nil, payloadError(payload, err)
 	}
 
 	return data, nil
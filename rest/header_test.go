@@ -0,0 +1,79 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type headerResourceHandler struct {
+	BaseResourceHandler
+}
+
+func (h *headerResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (h *headerResourceHandler) ReadResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	if id != "1" {
+		ctx.SetHeader("Retry-After", "120")
+		return nil, CustomError("Try again later", http.StatusServiceUnavailable)
+	}
+	ctx.SetHeader("Content-Disposition", `attachment; filename="foo.json"`)
+	ctx.AddHeader("Link", `</api/v1/foo/2>; rel="next"`)
+	ctx.AddHeader("Link", `</api/v1/foo/0>; rel="prev"`)
+	return &TestResource{Foo: "hello"}, nil
+}
+
+// Ensures that headers set by the handler are written with the response.
+func TestHandleReadResponseHeaders(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&headerResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/1", nil)
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal(`attachment; filename="foo.json"`, resp.Header().Get("Content-Disposition"))
+	assert.Equal([]string{`</api/v1/foo/2>; rel="next"`, `</api/v1/foo/0>; rel="prev"`},
+		resp.Header()["Link"])
+	assert.Equal("application/json", resp.Header().Get("Content-Type"))
+}
+
+// Ensures that headers set by the handler are written with error responses.
+func TestHandleReadResponseHeadersOnError(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&headerResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/2", nil)
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusServiceUnavailable, resp.Code, "Incorrect response code")
+	assert.Equal("120", resp.Header().Get("Retry-After"))
+}
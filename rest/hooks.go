@@ -0,0 +1,224 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import "fmt"
+
+// Lifecycle hooks can be implemented by a ResourceHandler to run logic around its CRUD
+// operations, e.g. audit logging, cache invalidation, or event publication, without
+// wrapping each handler method. Before hooks are called with the request payload, after
+// PayloadMiddleware and inbound Rules are applied, and if one returns an error the
+// operation isn't performed and the request fails with it. After hooks are called with
+// the result of a successful operation before outbound Rules are applied. They aren't
+// called for operations which return an AsyncResult, or after mutations for dry runs.
+// Before hooks are called for dry runs, so those with side effects should check
+// RequestContext's DryRun method.
+
+// BeforeCreateHook is called before a resource is created.
+type BeforeCreateHook interface {
+	BeforeCreate(RequestContext, Payload) error
+}
+
+// AfterCreateHook is called after a resource is created.
+type AfterCreateHook interface {
+	AfterCreate(RequestContext, Resource)
+}
+
+// BeforeReadListHook is called before a list of resources is read.
+type BeforeReadListHook interface {
+	BeforeReadList(RequestContext) error
+}
+
+// AfterReadListHook is called after a list of resources is read.
+type AfterReadListHook interface {
+	AfterReadList(RequestContext, []Resource)
+}
+
+// BeforeReadHook is called with the resource ID before a resource is read.
+type BeforeReadHook interface {
+	BeforeRead(RequestContext, string) error
+}
+
+// AfterReadHook is called with the resource ID after a resource is read.
+type AfterReadHook interface {
+	AfterRead(RequestContext, string, Resource)
+}
+
+// BeforeUpdateListHook is called before a list of resources is updated.
+type BeforeUpdateListHook interface {
+	BeforeUpdateList(RequestContext, []Payload) error
+}
+
+// AfterUpdateListHook is called after a list of resources is updated.
+type AfterUpdateListHook interface {
+	AfterUpdateList(RequestContext, []Resource)
+}
+
+// BeforeUpdateHook is called with the resource ID before a resource is updated.
+type BeforeUpdateHook interface {
+	BeforeUpdate(RequestContext, string, Payload) error
+}
+
+// AfterUpdateHook is called with the resource ID after a resource is updated.
+type AfterUpdateHook interface {
+	AfterUpdate(RequestContext, string, Resource)
+}
+
+// BeforeDeleteHook is called with the resource ID before a resource is deleted.
+type BeforeDeleteHook interface {
+	BeforeDelete(RequestContext, string) error
+}
+
+// AfterDeleteHook is called with the resource ID after a resource is deleted.
+type AfterDeleteHook interface {
+	AfterDelete(RequestContext, string, Resource)
+}
+
+// isAsync returns true if the Resource is an AsyncResult.
+func isAsync(resource Resource) bool {
+	_, ok := resource.(*AsyncResult)
+	return ok
+}
+
+// createResource creates the resource with the ResourceHandler, calling its lifecycle
+// hooks.
+func createResource(ctx RequestContext, handler ResourceHandler, data Payload,
+	version string) (Resource, error) {
+
+	defer startTiming(ctx, TimingHandler)()
+	h := unwrapResourceHandler(handler)
+	if hook, ok := h.(BeforeCreateHook); ok {
+		if err := hook.BeforeCreate(ctx, data); err != nil {
+			return nil, err
+		}
+	}
+
+	resource, err := invokeCreate(ctx, handler, data, version)
+	if hook, ok := h.(AfterCreateHook); ok && err == nil && !isAsync(resource) &&
+		!ctx.DryRun() {
+		hook.AfterCreate(ctx, resource)
+	}
+	return resource, err
+}
+
+// readResourceList reads a list of resources with the ResourceHandler, calling its
+// lifecycle hooks.
+func readResourceList(ctx RequestContext, handler ResourceHandler, limit int,
+	cursor, version string) ([]Resource, string, error) {
+
+	defer startTiming(ctx, TimingHandler)()
+	h := unwrapResourceHandler(handler)
+	if hook, ok := h.(BeforeReadListHook); ok {
+		if err := hook.BeforeReadList(ctx); err != nil {
+			return nil, "", err
+		}
+	}
+
+	resources, cursor, err := handler.ReadResourceList(ctx, limit, cursor, version)
+	if hook, ok := h.(AfterReadListHook); ok && err == nil {
+		hook.AfterReadList(ctx, resources)
+	}
+	return resources, cursor, err
+}
+
+// readResource reads the resource with the ResourceHandler, calling its lifecycle
+// hooks. A nil resource returned without an error is treated as not found.
+func readResource(ctx RequestContext, handler ResourceHandler, id,
+	version string) (Resource, error) {
+
+	defer startTiming(ctx, TimingHandler)()
+	h := unwrapResourceHandler(handler)
+	if hook, ok := h.(BeforeReadHook); ok {
+		if err := hook.BeforeRead(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	resource, err := handler.ReadResource(ctx, id, version)
+	if err == nil && isNil(resource) {
+		reason := fmt.Sprintf("No %s %s", handler.ResourceName(), id)
+		if id == "" {
+			reason = fmt.Sprintf("No %s", handler.ResourceName())
+		}
+		return nil, ResourceNotFound(reason)
+	}
+	if hook, ok := h.(AfterReadHook); ok && err == nil {
+		hook.AfterRead(ctx, id, resource)
+	}
+	return resource, err
+}
+
+// updateResourceList updates a list of resources with the ResourceHandler, calling its
+// lifecycle hooks.
+func updateResourceList(ctx RequestContext, handler ResourceHandler, data []Payload,
+	version string) ([]Resource, error) {
+
+	defer startTiming(ctx, TimingHandler)()
+	h := unwrapResourceHandler(handler)
+	if hook, ok := h.(BeforeUpdateListHook); ok {
+		if err := hook.BeforeUpdateList(ctx, data); err != nil {
+			return nil, err
+		}
+	}
+
+	resources, err := handler.UpdateResourceList(ctx, data, version)
+	if hook, ok := h.(AfterUpdateListHook); ok && err == nil && !ctx.DryRun() {
+		hook.AfterUpdateList(ctx, resources)
+	}
+	return resources, err
+}
+
+// updateResource updates the resource with the ResourceHandler, calling its lifecycle
+// hooks.
+func updateResource(ctx RequestContext, handler ResourceHandler, id string, data Payload,
+	version string) (Resource, error) {
+
+	defer startTiming(ctx, TimingHandler)()
+	h := unwrapResourceHandler(handler)
+	if hook, ok := h.(BeforeUpdateHook); ok {
+		if err := hook.BeforeUpdate(ctx, id, data); err != nil {
+			return nil, err
+		}
+	}
+
+	resource, err := invokeUpdate(ctx, handler, id, data, version)
+	if hook, ok := h.(AfterUpdateHook); ok && err == nil && !isAsync(resource) &&
+		!ctx.DryRun() {
+		hook.AfterUpdate(ctx, id, resource)
+	}
+	return resource, err
+}
+
+// deleteResource deletes the resource with the ResourceHandler, calling its lifecycle
+// hooks.
+func deleteResource(ctx RequestContext, handler ResourceHandler, id,
+	version string) (Resource, error) {
+
+	defer startTiming(ctx, TimingHandler)()
+	h := unwrapResourceHandler(handler)
+	if hook, ok := h.(BeforeDeleteHook); ok {
+		if err := hook.BeforeDelete(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	resource, err := handler.DeleteResource(ctx, id, version)
+	if hook, ok := h.(AfterDeleteHook); ok && err == nil && !ctx.DryRun() {
+		hook.AfterDelete(ctx, id, resource)
+	}
+	return resource, err
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type hooksResourceHandler struct {
+	BaseResourceHandler
+	calls []string
+}
+
+func (h *hooksResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (h *hooksResourceHandler) BeforeCreate(ctx RequestContext, data Payload) error {
+	h.calls = append(h.calls, "BeforeCreate")
+	if _, ok := data["invalid"]; ok {
+		return UnprocessableRequest("invalid")
+	}
+	return nil
+}
+
+func (h *hooksResourceHandler) CreateResource(ctx RequestContext, data Payload,
+	version string) (Resource, error) {
+
+	h.calls = append(h.calls, "CreateResource")
+	return &TestResource{Foo: "hello"}, nil
+}
+
+func (h *hooksResourceHandler) AfterCreate(ctx RequestContext, resource Resource) {
+	h.calls = append(h.calls, "AfterCreate:"+resource.(*TestResource).Foo)
+}
+
+func (h *hooksResourceHandler) BeforeDelete(ctx RequestContext, id string) error {
+	h.calls = append(h.calls, "BeforeDelete:"+id)
+	return nil
+}
+
+func (h *hooksResourceHandler) DeleteResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	h.calls = append(h.calls, "DeleteResource:"+id)
+	return nil, ResourceNotFound("not found")
+}
+
+func (h *hooksResourceHandler) AfterDelete(ctx RequestContext, id string, resource Resource) {
+	h.calls = append(h.calls, "AfterDelete:"+id)
+}
+
+// Ensures that lifecycle hooks are called around a create.
+func TestHandleCreateHooks(t *testing.T) {
+	assert := assert.New(t)
+	handler := &hooksResourceHandler{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+	createHandler, _ := api.(*muxAPI).getRouteHandler("foo:create")
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo", bytes.NewBufferString("{}"))
+	resp := httptest.NewRecorder()
+
+	createHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusCreated, resp.Code, "Incorrect response code")
+	assert.Equal([]string{"BeforeCreate", "CreateResource", "AfterCreate:hello"}, handler.calls)
+}
+
+// Ensures that an error returned by a before hook fails the request without performing
+// the operation.
+func TestHandleCreateBeforeHookError(t *testing.T) {
+	assert := assert.New(t)
+	handler := &hooksResourceHandler{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+	createHandler, _ := api.(*muxAPI).getRouteHandler("foo:create")
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo",
+		bytes.NewBufferString(`{"invalid": true}`))
+	resp := httptest.NewRecorder()
+
+	createHandler.ServeHTTP(resp, req)
+
+	assert.Equal(422, resp.Code, "Incorrect response code")
+	assert.Equal([]string{"BeforeCreate"}, handler.calls)
+}
+
+// Ensures that after hooks aren't called if the operation fails.
+func TestHandleDeleteHooksOnError(t *testing.T) {
+	assert := assert.New(t)
+	handler := &hooksResourceHandler{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+
+	req, _ := http.NewRequest("DELETE", "http://foo.com/api/v1/foo/1", nil)
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusNotFound, resp.Code, "Incorrect response code")
+	assert.Equal([]string{"BeforeDelete:1", "DeleteResource:1"}, handler.calls)
+}
+
+type dryRunHooksResourceHandler struct {
+	hooksResourceHandler
+}
+
+func (d *dryRunHooksResourceHandler) SupportsDryRun() bool {
+	return true
+}
+
+func (d *dryRunHooksResourceHandler) UpdateResource(ctx RequestContext, id string,
+	data Payload, version string) (Resource, error) {
+
+	d.calls = append(d.calls, "UpdateResource:"+id)
+	return &TestResource{Foo: "hello"}, nil
+}
+
+func (d *dryRunHooksResourceHandler) AfterUpdate(ctx RequestContext, id string,
+	resource Resource) {
+
+	d.calls = append(d.calls, "AfterUpdate:"+id)
+}
+
+func (d *dryRunHooksResourceHandler) DeleteResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	d.calls = append(d.calls, "DeleteResource:"+id)
+	return &TestResource{Foo: "hello"}, nil
+}
+
+// Ensures that after hooks aren't called for dry runs of mutations.
+func TestHandleDryRunAfterHooks(t *testing.T) {
+	assert := assert.New(t)
+	handler := &dryRunHooksResourceHandler{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+
+	requests := []struct {
+		method string
+		url    string
+	}{
+		{"POST", "http://foo.com/api/v1/foo?dry_run=true"},
+		{"PUT", "http://foo.com/api/v1/foo/1?dry_run=true"},
+		{"DELETE", "http://foo.com/api/v1/foo/1?dry_run=true"},
+	}
+	for _, request := range requests {
+		req, _ := http.NewRequest(request.method, request.url, bytes.NewBufferString("{}"))
+		resp := httptest.NewRecorder()
+
+		api.ServeHTTP(resp, req)
+
+		assert.True(resp.Code < http.StatusBadRequest, "Incorrect response code")
+	}
+
+	assert.Equal([]string{"BeforeCreate", "CreateResource", "UpdateResource:1",
+		"BeforeDelete:1", "DeleteResource:1"}, handler.calls)
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Translator translates the messages of errors sent to clients into their requested
+// language. Errors are translated if they're an Error with a code, as are the field
+// errors of FieldErrors.
+type Translator interface {
+	// Translate returns the message for the error code in the language, formatted with
+	// the arguments. It returns false if there's no translation for the language.
+	Translate(language, code string, args ...interface{}) (string, bool)
+}
+
+// MessageCatalog is a Translator of message templates keyed by language, e.g. "fr" or
+// "pt-BR", and then error code. Templates are formatted with the error arguments using
+// fmt.Sprintf.
+type MessageCatalog map[string]map[string]string
+
+// Translate returns the message template for the error code in the language formatted
+// with the arguments.
+func (c MessageCatalog) Translate(language, code string, args ...interface{}) (string, bool) {
+	template, ok := c[language][code]
+	if !ok {
+		return "", false
+	}
+	if len(args) == 0 {
+		return template, true
+	}
+	return fmt.Sprintf(template, args...), true
+}
+
+// acceptedLanguage is a language range from an Accept-Language header.
+type acceptedLanguage struct {
+	tag     string
+	quality float64
+}
+
+// requestLanguages returns the languages accepted by the client from the
+// Accept-Language header in order of preference followed by the default language.
+// Regional tags are followed by their primary language, e.g. "pt-BR" by "pt".
+func requestLanguages(header, defaultLanguage string) []string {
+	accepted := []acceptedLanguage{}
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		tag := strings.TrimSpace(params[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+		language := acceptedLanguage{tag: tag, quality: 1}
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+				language.quality = q
+			}
+		}
+		if language.quality > 0 {
+			accepted = append(accepted, language)
+		}
+	}
+	sort.SliceStable(accepted, func(i, j int) bool {
+		return accepted[i].quality > accepted[j].quality
+	})
+
+	languages := []string{}
+	for _, language := range accepted {
+		languages = append(languages, language.tag)
+		if i := strings.Index(language.tag, "-"); i > 0 {
+			languages = append(languages, language.tag[:i])
+		}
+	}
+	if defaultLanguage != "" {
+		languages = append(languages, defaultLanguage)
+	}
+	return languages
+}
+
+// translateError returns the error with its message translated into the first of the
+// languages with a translation. Errors which can't be translated are returned as is.
+func translateError(translator Translator, languages []string, err error) error {
+	switch e := err.(type) {
+	case Error:
+		if e.code == "" {
+			return e
+		}
+		for _, language := range languages {
+			if message, ok := translator.Translate(language, e.code, e.args...); ok {
+				e.reason = message
+				break
+			}
+		}
+		return e
+	case FieldErrors:
+		translated := make(FieldErrors, len(e))
+		for field, fieldErr := range e {
+			translated[field] = translateError(translator, languages, fieldErr)
+		}
+		return translated
+	}
+	return err
+}
+
+// translateError translates the error of the request, if there is one, using the
+// configured Translator and the languages accepted by the client.
+func (h requestHandler) translateError(ctx RequestContext) RequestContext {
+	config := h.Configuration()
+	err := ctx.Error()
+	if err == nil || config.Translator == nil {
+		return ctx
+	}
+
+	languages := requestLanguages(ctx.Header().Get("Accept-Language"), config.DefaultLanguage)
+	return ctx.setError(translateError(config.Translator, languages, err))
+}
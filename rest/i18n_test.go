@@ -0,0 +1,138 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testCatalog = MessageCatalog{
+	"fr": {
+		CodeMissingField: "Le champ '%s' est obligatoire",
+		"foo_not_found":  "Aucun foo %s",
+	},
+	"de": {
+		CodeMissingField: "Das Feld '%s' ist erforderlich",
+	},
+	"en": {
+		"foo_not_found": "No foo %s",
+	},
+}
+
+type translatedResourceHandler struct {
+	BaseResourceHandler
+}
+
+func (t translatedResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (t translatedResourceHandler) Rules() Rules {
+	return NewRules((*TestResource)(nil),
+		&Rule{Field: "Foo", FieldAlias: "foo", Required: true},
+	)
+}
+
+func (t translatedResourceHandler) CreateResource(ctx RequestContext, data Payload,
+	version string) (Resource, error) {
+
+	return data, nil
+}
+
+func (t translatedResourceHandler) ReadResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	return nil, ResourceNotFound("No foo "+id).WithCode("foo_not_found", id)
+}
+
+// Ensures that requestLanguages orders the accepted languages by quality, following
+// regional tags with their primary language and ending with the default.
+func TestRequestLanguages(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]string{"fr-CA", "fr", "de", "en"},
+		requestLanguages("de;q=0.5, fr-CA, *;q=0.1, es;q=0", "en"))
+	assert.Equal([]string{}, requestLanguages("", ""))
+}
+
+// Ensures that MessageCatalog formats the template for the language and code.
+func TestMessageCatalogTranslate(t *testing.T) {
+	assert := assert.New(t)
+
+	message, ok := testCatalog.Translate("fr", "foo_not_found", "1")
+	assert.True(ok)
+	assert.Equal("Aucun foo 1", message)
+
+	_, ok = testCatalog.Translate("de", "foo_not_found", "1")
+	assert.False(ok)
+
+	_, ok = testCatalog.Translate("es", "foo_not_found", "1")
+	assert.False(ok)
+}
+
+// Ensures that error messages are translated into the language accepted by the client,
+// falling back to the default language.
+func TestTranslatedErrors(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{Translator: testCatalog, DefaultLanguage: "en"})
+	api.RegisterResourceHandler(translatedResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/1", nil)
+	req.Header.Set("Accept-Language", "fr-CA, en;q=0.8")
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusNotFound, resp.Code)
+	assert.Contains(resp.Body.String(), `"messages":["Aucun foo 1"]`)
+
+	req, _ = http.NewRequest("GET", "http://foo.com/api/v1/foo/1", nil)
+	req.Header.Set("Accept-Language", "de")
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusNotFound, resp.Code)
+	assert.Contains(resp.Body.String(), `"messages":["No foo 1"]`)
+}
+
+// Ensures that validation errors from inbound Rules are translated.
+func TestTranslatedFieldErrors(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{Translator: testCatalog})
+	api.RegisterResourceHandler(translatedResourceHandler{})
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo", bytes.NewBufferString(`{}`))
+	req.Header.Set("Accept-Language", "de")
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(422, resp.Code)
+	assert.Equal(`{"errors":{"foo":"Das Feld 'foo' ist erforderlich"},`+
+		`"messages":["Das Feld 'foo' ist erforderlich"],"reason":"Unprocessable Entity",`+
+		`"status":422}`, resp.Body.String())
+
+	req, _ = http.NewRequest("POST", "http://foo.com/api/v1/foo", bytes.NewBufferString(`{}`))
+	req.Header.Set("Accept-Language", "es")
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Contains(resp.Body.String(), `"errors":{"foo":"Missing required field 'foo'"}`)
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+
+	gcontext "github.com/gorilla/context"
+)
+
+// Identity is the authenticated client making a request. ResourceHandlers attach it to
+// requests with SetIdentity when authenticating them, and it's available to handlers
+// with RequestContext's Identity. Its roles determine which fields of responses are
+// visible to it, as specified by the Roles and ExcludeRoles of outbound Rules.
+type Identity struct {
+	// ID identifies the client, e.g. a user ID.
+	ID string
+
+	// Roles are the roles granted to the client, e.g. "admin".
+	Roles []string
+}
+
+// HasRole returns whether the Identity was granted the role. A nil Identity, i.e. an
+// unauthenticated client, has no roles.
+func (i *Identity) HasRole(role string) bool {
+	if i == nil {
+		return false
+	}
+	for _, r := range i.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAnyRole returns whether the Identity was granted any of the roles.
+func (i *Identity) hasAnyRole(roles []string) bool {
+	for _, role := range roles {
+		if i.HasRole(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetIdentity attaches the authenticated Identity to the request. It's typically
+// called from a ResourceHandler's Authenticate method or an authentication middleware.
+func SetIdentity(r *http.Request, identity *Identity) {
+	gcontext.Set(r, identityKey, identity)
+}
+
+// requestIdentity returns the Identity attached to the request, or nil if there isn't
+// one.
+func requestIdentity(r *http.Request) *Identity {
+	identity, _ := gcontext.Get(r, identityKey).(*Identity)
+	return identity
+}
+
+// identityRules are Rules whose outbound Rules are applied for an Identity, so fields it
+// isn't allowed to see are omitted. Outbound Rules which aren't applied for an Identity
+// omit every field restricted to roles.
+type identityRules struct {
+	Rules
+	identity *Identity
+}
+
+// rulesForIdentity returns the Rules to apply to responses for the Identity. Nil Rules
+// are returned as is.
+func rulesForIdentity(rules Rules, identity *Identity) Rules {
+	if rules == nil || identity == nil {
+		return rules
+	}
+	if visible, ok := rules.(*identityRules); ok {
+		rules = visible.Rules
+	}
+	return &identityRules{Rules: rules, identity: identity}
+}
+
+// visibleRules returns the Rules to apply to responses for the request's Identity.
+func visibleRules(ctx RequestContext, rules Rules) Rules {
+	return rulesForIdentity(rules, ctx.Identity())
+}
+
+// rulesRestricted returns whether any of the Rules, or their nested Rules, restrict the
+// identities their field is visible to.
+func rulesRestricted(rules Rules) bool {
+	if rules == nil {
+		return false
+	}
+	for _, rule := range rules.Contents() {
+		if rule.restricted() || rulesRestricted(rule.Rules) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type identityResource struct {
+	Name          string
+	InternalNotes string
+	Owner         *identityOwner
+}
+
+type identityOwner struct {
+	Email string
+	Phone string
+}
+
+// newIdentityRules returns Rules showing InternalNotes and owner phone numbers to admins
+// only and hiding owner emails from guests.
+func newIdentityRules() Rules {
+	return NewRules((*identityResource)(nil),
+		&Rule{Field: "Name", FieldAlias: "name"},
+		&Rule{Field: "InternalNotes", FieldAlias: "internalNotes", Roles: []string{"admin"}},
+		&Rule{Field: "Owner", FieldAlias: "owner", Type: Map,
+			Rules: NewRules((*identityOwner)(nil),
+				&Rule{Field: "Email", FieldAlias: "email", ExcludeRoles: []string{"guest"}},
+				&Rule{Field: "Phone", FieldAlias: "phone", Roles: []string{"admin"}},
+			)},
+	)
+}
+
+type identityResourceHandler struct {
+	BaseResourceHandler
+}
+
+func (i *identityResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (i *identityResourceHandler) Rules() Rules {
+	return newIdentityRules()
+}
+
+func (i *identityResourceHandler) Authenticate(r *http.Request) error {
+	if role := r.Header.Get("X-Role"); role != "" {
+		SetIdentity(r, &Identity{ID: "bob", Roles: []string{role}})
+	}
+	return nil
+}
+
+func (i *identityResourceHandler) ReadResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	return &identityResource{
+		Name:          "foo",
+		InternalNotes: "notes",
+		Owner:         &identityOwner{Email: "bob@example.com", Phone: "555"},
+	}, nil
+}
+
+// Ensures that Rules are visible to identities with one of their Roles and without any
+// of their ExcludeRoles.
+func TestRuleVisibleTo(t *testing.T) {
+	assert := assert.New(t)
+	admin := &Identity{Roles: []string{"user", "admin"}}
+	guest := &Identity{Roles: []string{"guest"}}
+
+	assert.True(Rule{}.VisibleTo(nil))
+	assert.True(Rule{Roles: []string{"admin"}}.VisibleTo(admin))
+	assert.False(Rule{Roles: []string{"admin"}}.VisibleTo(guest))
+	assert.False(Rule{Roles: []string{"admin"}}.VisibleTo(nil))
+	assert.True(Rule{ExcludeRoles: []string{"guest"}}.VisibleTo(nil))
+	assert.False(Rule{ExcludeRoles: []string{"guest"}}.VisibleTo(guest))
+	assert.False(Rule{Roles: []string{"admin"}, ExcludeRoles: []string{"user"}}.VisibleTo(admin))
+}
+
+// Ensures that outbound Rules omit fields which aren't visible to the Identity they're
+// applied for, including nested fields, and omit every restricted field otherwise.
+func TestApplyOutboundRulesForIdentity(t *testing.T) {
+	assert := assert.New(t)
+	resource := &identityResource{
+		Name:          "foo",
+		InternalNotes: "notes",
+		Owner:         &identityOwner{Email: "bob@example.com", Phone: "555"},
+	}
+
+	for _, rules := range []Rules{newIdentityRules(), compileRules(newIdentityRules())} {
+		admin := rulesForIdentity(rules, &Identity{Roles: []string{"admin"}})
+		assert.Equal(Payload{
+			"name":          "foo",
+			"internalNotes": "notes",
+			"owner":         Payload{"email": "bob@example.com", "phone": "555"},
+		}, applyOutboundRules(resource, admin, "1"))
+
+		guest := rulesForIdentity(rules, &Identity{Roles: []string{"guest"}})
+		assert.Equal(Payload{"name": "foo", "owner": Payload{}},
+			applyOutboundRules(resource, guest, "1"))
+
+		assert.Equal(Payload{"name": "foo", "owner": Payload{"email": "bob@example.com"}},
+			applyOutboundRules(resource, rules, "1"))
+	}
+}
+
+// Ensures that resources aren't sent as is when none of their fields are visible.
+func TestApplyOutboundRulesNoVisibleFields(t *testing.T) {
+	assert := assert.New(t)
+	rules := NewRules((*identityResource)(nil),
+		&Rule{Field: "InternalNotes", Roles: []string{"admin"}})
+
+	assert.Equal(Payload{},
+		applyOutboundRules(&identityResource{InternalNotes: "notes"}, rules, "1"))
+}
+
+// Ensures that responses are shaped for the Identity attached to the request when
+// it's authenticated.
+func TestIdentityResponseShaping(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&identityResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/1", nil)
+	req.Header.Set("X-Role", "admin")
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Contains(resp.Body.String(), `"internalNotes":"notes"`)
+	assert.Contains(resp.Body.String(), `"phone":"555"`)
+
+	req, _ = http.NewRequest("GET", "http://foo.com/api/v1/foo/1", nil)
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.NotContains(resp.Body.String(), "internalNotes")
+	assert.NotContains(resp.Body.String(), "phone")
+	assert.Contains(resp.Body.String(), `"email":"bob@example.com"`)
+}
@@ -0,0 +1,289 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// jsonAPIFormat is the response format requesting JSON:API documents.
+	jsonAPIFormat = "jsonapi"
+
+	// jsonAPIContentType is the JSON:API media type.
+	jsonAPIContentType = "application/vnd.api+json"
+
+	// jsonAPIInclude is the query string variable listing the relations whose resources
+	// are included in JSON:API documents.
+	jsonAPIInclude = "include"
+)
+
+// jsonAPIRequested returns true if the client requested a JSON:API document, either
+// with the "jsonapi" format or by accepting the JSON:API media type.
+func jsonAPIRequested(ctx RequestContext) bool {
+	if ctx.ResponseFormat() == jsonAPIFormat {
+		return true
+	}
+	return strings.Contains(ctx.Header().Get("Accept"), jsonAPIContentType)
+}
+
+// jsonAPISerializer is an implementation of ResponseSerializer which serializes
+// JSON:API documents built by requestHandler#jsonAPIResponse.
+type jsonAPISerializer struct{}
+
+// Serialize marshals a JSON:API document into a JSON byte slice to be sent over the
+// wire.
+func (j jsonAPISerializer) Serialize(p Payload) ([]byte, error) {
+	return json.Marshal(p)
+}
+
+// ContentType returns the JSON:API MIME type of the response.
+func (j jsonAPISerializer) ContentType() string {
+	return jsonAPIContentType
+}
+
+// jsonAPIResponse constructs a response containing the JSON:API document for the
+// RequestContext (https://jsonapi.org). Resources are represented by their type, which
+// is the resource name, their ID, which is determined like Location headers or taken
+// from an "id" field, their attributes, and relationships for relations declared by a
+// LinkedResourceHandler. The resources of relations listed in the "include" query
+// string variable are read and added to the included documents.
+func (h requestHandler) jsonAPIResponse(ctx RequestContext) response {
+	if ctx.Error() != nil {
+		return jsonAPIErrorResponse(ctx)
+	}
+
+	s := ctx.Status()
+	resp := response{Status: s}
+	if s == http.StatusNoContent {
+		return resp
+	}
+
+	handler, ok := h.routeResourceHandler(ctx)
+	if !ok {
+		return jsonAPIErrorResponse(ctx.setError(InternalServerError("Unknown resource type")))
+	}
+
+	doc := jsonAPIDocument{h: h, ctx: ctx, included: map[string]bool{}}
+	links := map[string]string{}
+	document := Payload{resourceLinks: links}
+
+	result := ctx.Result()
+	if isList(result) {
+		data := []Payload{}
+		v := reflect.ValueOf(result)
+		for i := 0; i < v.Len(); i++ {
+			data = append(data, doc.resourceObject(handler, v.Index(i).Interface(), true))
+		}
+		document["data"] = data
+
+		if self, err := ctx.SelfURL(); err == nil {
+			links["self"] = self
+		}
+		if next, err := ctx.NextURL(); err == nil && next != "" {
+			links["next"] = next
+		}
+		if prev, err := ctx.PrevURL(); err == nil && prev != "" {
+			links["prev"] = prev
+		}
+		if ctx.Pagination() == OffsetPagination {
+			meta := Payload{page: ctx.Page(), perPage: ctx.PerPage()}
+			if count, ok := ctx.TotalCount(); ok {
+				meta[total] = count
+			}
+			document["meta"] = meta
+		}
+	} else if result == nil {
+		document["data"] = nil
+	} else {
+		object := doc.resourceObject(handler, result, true)
+		document["data"] = object
+		if objectLinks, ok := object[resourceLinks].(map[string]string); ok {
+			links["self"] = objectLinks["self"]
+		}
+	}
+
+	if len(doc.includes) > 0 {
+		document["included"] = doc.includes
+	}
+	if messages := ctx.Messages(); len(messages) > 0 {
+		meta, _ := document["meta"].(Payload)
+		if meta == nil {
+			meta = Payload{}
+		}
+		meta["messages"] = messages
+		document["meta"] = meta
+	}
+
+	resp.Payload = document
+	return resp
+}
+
+// jsonAPIErrorResponse constructs a response containing a JSON:API error document with
+// an error object for each message. 304 Not Modified responses don't have a body.
+func jsonAPIErrorResponse(ctx RequestContext) response {
+	s := errorStatus(ctx.Error())
+	if s == http.StatusNotModified {
+		return response{Status: s}
+	}
+
+	messages := ctx.Messages()
+	if len(messages) == 0 {
+		messages = []string{""}
+	}
+	errs := make([]Payload, len(messages))
+	for i, message := range messages {
+		errs[i] = Payload{"status": strconv.Itoa(s), "title": http.StatusText(s)}
+		if message != "" {
+			errs[i]["detail"] = message
+		}
+	}
+
+	return response{Payload: Payload{"errors": errs}, Status: s}
+}
+
+// jsonAPIDocument builds the resource objects of a JSON:API document, collecting the
+// included resources.
+type jsonAPIDocument struct {
+	h        requestHandler
+	ctx      RequestContext
+	includes []Payload
+	included map[string]bool
+}
+
+// resourceObject returns the JSON:API resource object for the resource. If primary is
+// true, the resources of requested relations are added to the included documents.
+func (d *jsonAPIDocument) resourceObject(handler ResourceHandler, resource Resource,
+	primary bool) Payload {
+
+	version := d.ctx.Version()
+	attributes, err := resourcePayload(resource)
+	if err != nil {
+		attributes = Payload{}
+	}
+
+	id, ok := resourceID(resource, resource, handler.Rules(), version)
+	if !ok {
+		if value, ok := attributes["id"]; ok && value != nil {
+			id = fmt.Sprint(value)
+		} else if primary && !isList(d.ctx.Result()) {
+			id = d.ctx.ResourceID()
+		}
+	}
+	for _, rule := range handler.Rules().Filter(false).ForVersion(version).Contents() {
+		if rule.Identifier {
+			delete(attributes, rule.Name())
+		}
+	}
+	delete(attributes, "id")
+
+	object := Payload{"type": handler.ResourceName(), "id": id}
+	if id != "" {
+		if self, err := d.ctx.BuildURL(handler.ResourceName(), HandleRead,
+			RouteVars{resourceIDKey: id}); err == nil {
+			object[resourceLinks] = map[string]string{"self": self.String()}
+		}
+	}
+
+	if linked, ok := unwrapResourceHandler(handler).(LinkedResourceHandler); ok {
+		if relationships := d.relationships(linked.Relations(), attributes, primary); len(relationships) > 0 {
+			object["relationships"] = relationships
+		}
+	}
+
+	object["attributes"] = attributes
+	return object
+}
+
+// relationships returns the JSON:API relationships for the relations which identify a
+// related resource, removing the identifying fields from the attributes.
+func (d *jsonAPIDocument) relationships(relations map[string]Relation, attributes Payload,
+	primary bool) Payload {
+
+	include := map[string]bool{}
+	if r, ok := d.ctx.Request(); ok && primary {
+		for _, name := range strings.Split(r.URL.Query().Get(jsonAPIInclude), ",") {
+			include[strings.TrimSpace(name)] = true
+		}
+	}
+
+	names := make([]string, 0, len(relations))
+	for name := range relations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	relationships := Payload{}
+	for _, name := range names {
+		relation := relations[name]
+		if relation.IDField == "" || (relation.Method != "" && relation.Method != HandleRead) {
+			continue
+		}
+		value, ok := attributes[relation.IDField]
+		if !ok {
+			continue
+		}
+		delete(attributes, relation.IDField)
+		if value == nil {
+			relationships[name] = Payload{"data": nil}
+			continue
+		}
+
+		id := fmt.Sprint(value)
+		relationship := Payload{"data": Payload{"type": relation.Resource, "id": id}}
+		if related, err := d.ctx.BuildURL(relation.Resource, HandleRead,
+			RouteVars{resourceIDKey: id}); err == nil {
+			relationship[resourceLinks] = map[string]string{"related": related.String()}
+		}
+		relationships[name] = relationship
+
+		if include[name] {
+			d.include(relation.Resource, id)
+		}
+	}
+	return relationships
+}
+
+// include reads the related resource and adds it to the included documents unless it
+// was already included. Resources which can't be read are omitted.
+func (d *jsonAPIDocument) include(resourceName, id string) {
+	key := resourceName + "/" + id
+	if d.included[key] {
+		return
+	}
+	d.included[key] = true
+
+	handler, ok := d.h.resourceHandler(resourceName)
+	if !ok {
+		return
+	}
+	version := d.ctx.Version()
+	resource, err := readResource(d.ctx, handler, id, version)
+	if err != nil || isNil(resource) {
+		return
+	}
+	resource = applyOutboundRules(resource, visibleRules(d.ctx, handler.Rules()), version)
+	object := d.resourceObject(handler, resource, false)
+	object["id"] = id
+	d.includes = append(d.includes, object)
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Ensures that single resources are sent as JSON:API documents with relationships and
+// included resources.
+func TestJSONAPIResource(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&linkedResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/1?format=jsonapi&include=owner", nil)
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal("application/vnd.api+json", resp.Header().Get("Content-Type"))
+	assert.JSONEq(`{
+		"data": {
+			"type": "foo",
+			"id": "1",
+			"attributes": {},
+			"relationships": {
+				"owner": {
+					"data": {"type": "foo", "id": "7"},
+					"links": {"related": "http://foo.com/api/v1/foo/7"}
+				}
+			},
+			"links": {"self": "http://foo.com/api/v1/foo/1"}
+		},
+		"included": [{
+			"type": "foo",
+			"id": "7",
+			"attributes": {},
+			"relationships": {
+				"owner": {
+					"data": {"type": "foo", "id": "7"},
+					"links": {"related": "http://foo.com/api/v1/foo/7"}
+				}
+			},
+			"links": {"self": "http://foo.com/api/v1/foo/7"}
+		}],
+		"links": {"self": "http://foo.com/api/v1/foo/1"}
+	}`, resp.Body.String())
+}
+
+// Ensures that lists are sent as JSON:API documents with pagination links when the
+// JSON:API media type is accepted.
+func TestJSONAPIList(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&linkedResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo?limit=1", nil)
+	req.Header.Set("Accept", "application/vnd.api+json")
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal("application/vnd.api+json", resp.Header().Get("Content-Type"))
+	assert.JSONEq(`{
+		"data": [{
+			"type": "foo",
+			"id": "1",
+			"attributes": {},
+			"links": {"self": "http://foo.com/api/v1/foo/1"}
+		}],
+		"links": {
+			"self": "http://foo.com/api/v1/foo?limit=1",
+			"next": "http://foo.com/api/v1/foo?limit=1&next=abc"
+		}
+	}`, resp.Body.String())
+}
+
+// Ensures that errors are sent as JSON:API error documents.
+func TestJSONAPIError(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&headerResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/2?format=jsonapi", nil)
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusServiceUnavailable, resp.Code, "Incorrect response code")
+	assert.Equal("application/vnd.api+json", resp.Header().Get("Content-Type"))
+	assert.Equal(
+		`{"errors":[{"detail":"Try again later","status":"503","title":"Service Unavailable"}]}`,
+		resp.Body.String(),
+		"Incorrect response string",
+	)
+}
@@ -0,0 +1,790 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// JSONSchemaResourceHandler can be implemented by a ResourceHandler to validate the
+// payloads of create and update requests, including PATCH results and batch
+// operations, against a JSON Schema before they're passed to the handler. This allows
+// deeply nested payloads to be validated strictly. Payloads are validated after
+// PayloadMiddleware and before inbound Rules are applied, and violations are rejected
+// with 422 Unprocessable Entity FieldErrors keyed by the dotted path of the invalid
+// value, e.g. "address.lines.0". Requests selecting a schema with SchemaResourceHandler
+// are only validated by that schema's Rules.
+type JSONSchemaResourceHandler interface {
+	// JSONSchema returns the schema payloads for the version must conform to, or nil if
+	// they aren't validated. RulesJSONSchema derives one from the handler's Rules.
+	JSONSchema(version string) *JSONSchema
+}
+
+// uuidPattern matches UUIDs in their canonical textual representation.
+var uuidPattern = regexp.MustCompile(
+	`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// JSONSchema is a compiled JSON Schema used to validate request payloads. The
+// validation keywords of drafts 7 and 2020-12 are supported: type, enum, const,
+// properties, required, additionalProperties, minProperties, maxProperties, items,
+// minItems, maxItems, uniqueItems, minLength, maxLength, pattern, format ("date-time",
+// "date", "email", "uri", and "uuid"), minimum, maximum, exclusiveMinimum,
+// exclusiveMaximum, multipleOf, allOf, anyOf, oneOf, not, and $ref to locations within
+// the schema, e.g. "#/$defs/address". Other keywords are ignored.
+type JSONSchema struct {
+	source interface{}
+	root   *jsonSchemaNode
+	refs   map[string]*jsonSchemaNode
+}
+
+// jsonSchemaNode is a compiled schema or subschema.
+type jsonSchemaNode struct {
+	// always is the result of a boolean schema, which doesn't have other keywords.
+	always *bool
+
+	ref           string
+	types         []string
+	enum          []interface{}
+	constant      interface{}
+	hasConstant   bool
+	properties    map[string]*jsonSchemaNode
+	required      []string
+	additional    *jsonSchemaNode
+	minProperties *int
+	maxProperties *int
+	items         *jsonSchemaNode
+	minItems      *int
+	maxItems      *int
+	uniqueItems   bool
+	minLength     *int
+	maxLength     *int
+	pattern       *regexp.Regexp
+	format        string
+	minimum       *float64
+	maximum       *float64
+	exclusiveMin  *float64
+	exclusiveMax  *float64
+	multipleOf    *float64
+	allOf         []*jsonSchemaNode
+	anyOf         []*jsonSchemaNode
+	oneOf         []*jsonSchemaNode
+	not           *jsonSchemaNode
+}
+
+// ParseJSONSchema parses and compiles the JSON Schema document. An error is returned if
+// the document isn't a valid schema.
+func ParseJSONSchema(data []byte) (*JSONSchema, error) {
+	var source interface{}
+	if err := json.Unmarshal(data, &source); err != nil {
+		return nil, fmt.Errorf("Invalid JSON Schema: %s", err)
+	}
+	return newJSONSchema(source)
+}
+
+// MustParseJSONSchema is like ParseJSONSchema but panics if the document isn't a valid
+// schema. It simplifies declaring schemas as package-level variables.
+func MustParseJSONSchema(data string) *JSONSchema {
+	schema, err := ParseJSONSchema([]byte(data))
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+// newJSONSchema compiles the decoded JSON Schema document.
+func newJSONSchema(source interface{}) (*JSONSchema, error) {
+	c := &jsonSchemaCompiler{source: source, refs: map[string]*jsonSchemaNode{}}
+	root, err := c.compile(source, "#")
+	if err != nil {
+		return nil, fmt.Errorf("Invalid JSON Schema: %s", err)
+	}
+	c.refs["#"] = root
+	if err := c.resolveRefs(); err != nil {
+		return nil, fmt.Errorf("Invalid JSON Schema: %s", err)
+	}
+	return &JSONSchema{source: source, root: root, refs: c.refs}, nil
+}
+
+// MarshalJSON returns the JSON Schema document.
+func (s *JSONSchema) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.source)
+}
+
+// Validate returns FieldErrors describing the values of the decoded JSON value which
+// don't conform to the schema, or nil if it conforms.
+func (s *JSONSchema) Validate(value interface{}) error {
+	v := &jsonSchemaValidator{schema: s, errs: FieldErrors{}}
+	v.validate(s.root, normalizeJSONValue(value), nil)
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+// jsonSchemaCompiler compiles a JSON Schema document, resolving $refs to locations
+// within it.
+type jsonSchemaCompiler struct {
+	source  interface{}
+	refs    map[string]*jsonSchemaNode
+	pending []*jsonSchemaNode
+}
+
+// compile returns the compiled schema at the location.
+func (c *jsonSchemaCompiler) compile(schema interface{},
+	location string) (*jsonSchemaNode, error) {
+
+	node := &jsonSchemaNode{}
+	if b, ok := schema.(bool); ok {
+		node.always = &b
+		return node, nil
+	}
+	obj, ok := schema.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: schema must be an object or boolean", location)
+	}
+
+	var err error
+	if ref, ok := obj["$ref"]; ok {
+		if node.ref, ok = ref.(string); !ok || !strings.HasPrefix(node.ref, "#") {
+			return nil, fmt.Errorf("%s: $ref must reference a location within the schema",
+				location)
+		}
+		c.pending = append(c.pending, node)
+	}
+
+	switch t := obj["type"].(type) {
+	case nil:
+	case string:
+		node.types = []string{t}
+	case []interface{}:
+		for _, item := range t {
+			name, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: type must be a string or list of strings",
+					location)
+			}
+			node.types = append(node.types, name)
+		}
+	default:
+		return nil, fmt.Errorf("%s: type must be a string or list of strings", location)
+	}
+
+	if enum, ok := obj["enum"]; ok {
+		if node.enum, ok = enum.([]interface{}); !ok {
+			return nil, fmt.Errorf("%s: enum must be a list", location)
+		}
+	}
+	node.constant, node.hasConstant = obj["const"]
+
+	if properties, ok := obj["properties"]; ok {
+		props, ok := properties.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: properties must be an object", location)
+		}
+		node.properties = make(map[string]*jsonSchemaNode, len(props))
+		for name, prop := range props {
+			if node.properties[name], err = c.compile(prop,
+				location+"/properties/"+name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if required, ok := obj["required"]; ok {
+		fields, ok := required.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: required must be a list of strings", location)
+		}
+		for _, field := range fields {
+			name, ok := field.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: required must be a list of strings", location)
+			}
+			node.required = append(node.required, name)
+		}
+	}
+
+	for _, sub := range []struct {
+		keyword string
+		node    **jsonSchemaNode
+	}{
+		{"additionalProperties", &node.additional},
+		{"items", &node.items},
+		{"not", &node.not},
+	} {
+		if schema, ok := obj[sub.keyword]; ok {
+			if *sub.node, err = c.compile(schema, location+"/"+sub.keyword); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, list := range []struct {
+		keyword string
+		nodes   *[]*jsonSchemaNode
+	}{
+		{"allOf", &node.allOf},
+		{"anyOf", &node.anyOf},
+		{"oneOf", &node.oneOf},
+	} {
+		schemas, ok := obj[list.keyword]
+		if !ok {
+			continue
+		}
+		items, ok := schemas.([]interface{})
+		if !ok || len(items) == 0 {
+			return nil, fmt.Errorf("%s: %s must be a non-empty list", location, list.keyword)
+		}
+		for i, item := range items {
+			compiled, err := c.compile(item, fmt.Sprintf("%s/%s/%d", location, list.keyword, i))
+			if err != nil {
+				return nil, err
+			}
+			*list.nodes = append(*list.nodes, compiled)
+		}
+	}
+
+	for _, limit := range []struct {
+		keyword string
+		value   **int
+	}{
+		{"minProperties", &node.minProperties},
+		{"maxProperties", &node.maxProperties},
+		{"minItems", &node.minItems},
+		{"maxItems", &node.maxItems},
+		{"minLength", &node.minLength},
+		{"maxLength", &node.maxLength},
+	} {
+		if value, ok := obj[limit.keyword]; ok {
+			n, ok := value.(float64)
+			if !ok || n < 0 || n != math.Trunc(n) {
+				return nil, fmt.Errorf("%s: %s must be a non-negative integer",
+					location, limit.keyword)
+			}
+			i := int(n)
+			*limit.value = &i
+		}
+	}
+	for _, limit := range []struct {
+		keyword string
+		value   **float64
+	}{
+		{"minimum", &node.minimum},
+		{"maximum", &node.maximum},
+		{"exclusiveMinimum", &node.exclusiveMin},
+		{"exclusiveMaximum", &node.exclusiveMax},
+		{"multipleOf", &node.multipleOf},
+	} {
+		if value, ok := obj[limit.keyword]; ok {
+			n, ok := value.(float64)
+			if !ok {
+				return nil, fmt.Errorf("%s: %s must be a number", location, limit.keyword)
+			}
+			*limit.value = &n
+		}
+	}
+	if node.multipleOf != nil && *node.multipleOf <= 0 {
+		return nil, fmt.Errorf("%s: multipleOf must be greater than 0", location)
+	}
+
+	if unique, ok := obj["uniqueItems"]; ok {
+		if node.uniqueItems, ok = unique.(bool); !ok {
+			return nil, fmt.Errorf("%s: uniqueItems must be a boolean", location)
+		}
+	}
+	if pattern, ok := obj["pattern"]; ok {
+		expr, ok := pattern.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: pattern must be a string", location)
+		}
+		if node.pattern, err = regexp.Compile(expr); err != nil {
+			return nil, fmt.Errorf("%s: invalid pattern: %s", location, err)
+		}
+	}
+	if format, ok := obj["format"]; ok {
+		if node.format, ok = format.(string); !ok {
+			return nil, fmt.Errorf("%s: format must be a string", location)
+		}
+	}
+
+	return node, nil
+}
+
+// resolveRefs compiles the targets of the pending $refs, including those of the
+// compiled targets, so references are resolved when validating.
+func (c *jsonSchemaCompiler) resolveRefs() error {
+	for len(c.pending) > 0 {
+		node := c.pending[0]
+		c.pending = c.pending[1:]
+		if _, ok := c.refs[node.ref]; ok {
+			continue
+		}
+
+		pointer, err := url.PathUnescape(strings.TrimPrefix(node.ref, "#"))
+		if err != nil {
+			return fmt.Errorf("invalid $ref %q", node.ref)
+		}
+		path, err := parseJSONPointer(pointer)
+		if err != nil {
+			return fmt.Errorf("invalid $ref %q: %s", node.ref, err)
+		}
+		target, err := jsonPointerGet(c.source, path)
+		if err != nil {
+			return fmt.Errorf("unresolved $ref %q", node.ref)
+		}
+		if c.refs[node.ref], err = c.compile(target, node.ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonSchemaValidator collects the errors of validating a value against a JSONSchema.
+type jsonSchemaValidator struct {
+	schema *JSONSchema
+	errs   FieldErrors
+}
+
+// fail records an error for the value at the path, unless one was already recorded.
+func (v *jsonSchemaValidator) fail(path []string, format string, args ...interface{}) {
+	field := strings.Join(path, ".")
+	if _, ok := v.errs[field]; ok {
+		return
+	}
+	v.errs[field] = UnprocessableRequest(fmt.Sprintf(format, args...)).
+		WithCode(CodeInvalidField, field)
+}
+
+// matches returns whether the value conforms to the schema without recording errors.
+func (v *jsonSchemaValidator) matches(node *jsonSchemaNode, value interface{}) bool {
+	sub := &jsonSchemaValidator{schema: v.schema, errs: FieldErrors{}}
+	sub.validate(node, value, nil)
+	return len(sub.errs) == 0
+}
+
+// validate records errors for the parts of the value at the path which don't conform
+// to the schema.
+func (v *jsonSchemaValidator) validate(node *jsonSchemaNode, value interface{},
+	path []string) {
+
+	if node.always != nil {
+		if !*node.always {
+			v.fail(path, "Value isn't allowed")
+		}
+		return
+	}
+	if node.ref != "" {
+		v.validate(v.schema.refs[node.ref], value, path)
+	}
+
+	valueType := jsonValueType(value)
+	if len(node.types) > 0 && !jsonTypeAllowed(node.types, valueType) {
+		v.fail(path, "Expected %s, got %s", strings.Join(node.types, " or "), valueType)
+		return
+	}
+	if node.enum != nil && !jsonContains(node.enum, value) {
+		v.fail(path, "Value must be one of %s", jsonList(node.enum))
+	}
+	if node.hasConstant && !jsonEqual(node.constant, value) {
+		v.fail(path, "Value must be %s", jsonList([]interface{}{node.constant}))
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		v.validateObject(node, value, path)
+	case []interface{}:
+		v.validateArray(node, value, path)
+	case string:
+		v.validateString(node, value, path)
+	case float64:
+		v.validateNumber(node, value, path)
+	}
+
+	for _, sub := range node.allOf {
+		v.validate(sub, value, path)
+	}
+	if node.anyOf != nil {
+		matched := false
+		for _, sub := range node.anyOf {
+			if matched = v.matches(sub, value); matched {
+				break
+			}
+		}
+		if !matched {
+			v.fail(path, "Value doesn't match any of the allowed schemas")
+		}
+	}
+	if node.oneOf != nil {
+		matched := 0
+		for _, sub := range node.oneOf {
+			if v.matches(sub, value) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			v.fail(path, "Value must match exactly one of the allowed schemas, matched %d",
+				matched)
+		}
+	}
+	if node.not != nil && v.matches(node.not, value) {
+		v.fail(path, "Value matches a disallowed schema")
+	}
+}
+
+// validateObject validates the keywords applying to objects.
+func (v *jsonSchemaValidator) validateObject(node *jsonSchemaNode,
+	value map[string]interface{}, path []string) {
+
+	for _, field := range node.required {
+		if _, ok := value[field]; !ok {
+			fieldPath := strings.Join(append(path[:len(path):len(path)], field), ".")
+			if _, ok := v.errs[fieldPath]; !ok {
+				v.errs[fieldPath] = UnprocessableRequest(fmt.Sprintf(
+					"Missing required field '%s'", fieldPath)).WithCode(CodeMissingField, fieldPath)
+			}
+		}
+	}
+	if node.minProperties != nil && len(value) < *node.minProperties {
+		v.fail(path, "Object must have at least %d fields", *node.minProperties)
+	}
+	if node.maxProperties != nil && len(value) > *node.maxProperties {
+		v.fail(path, "Object must have at most %d fields", *node.maxProperties)
+	}
+
+	fields := make([]string, 0, len(value))
+	for field := range value {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		fieldPath := append(path[:len(path):len(path)], field)
+		if prop, ok := node.properties[field]; ok {
+			v.validate(prop, value[field], fieldPath)
+		} else if node.additional != nil {
+			if node.additional.always != nil && !*node.additional.always {
+				v.fail(fieldPath, "Unknown field '%s'", strings.Join(fieldPath, "."))
+				continue
+			}
+			v.validate(node.additional, value[field], fieldPath)
+		}
+	}
+}
+
+// validateArray validates the keywords applying to arrays.
+func (v *jsonSchemaValidator) validateArray(node *jsonSchemaNode, value []interface{},
+	path []string) {
+
+	if node.minItems != nil && len(value) < *node.minItems {
+		v.fail(path, "Array must have at least %d items", *node.minItems)
+	}
+	if node.maxItems != nil && len(value) > *node.maxItems {
+		v.fail(path, "Array must have at most %d items", *node.maxItems)
+	}
+	if node.uniqueItems {
+		for i := range value {
+			if jsonContains(value[:i], value[i]) {
+				v.fail(path, "Array items must be unique")
+				break
+			}
+		}
+	}
+	if node.items != nil {
+		for i, item := range value {
+			v.validate(node.items, item, append(path[:len(path):len(path)], strconv.Itoa(i)))
+		}
+	}
+}
+
+// validateString validates the keywords applying to strings.
+func (v *jsonSchemaValidator) validateString(node *jsonSchemaNode, value string,
+	path []string) {
+
+	length := utf8.RuneCountInString(value)
+	if node.minLength != nil && length < *node.minLength {
+		v.fail(path, "Value must be at least %d characters", *node.minLength)
+	}
+	if node.maxLength != nil && length > *node.maxLength {
+		v.fail(path, "Value must be at most %d characters", *node.maxLength)
+	}
+	if node.pattern != nil && !node.pattern.MatchString(value) {
+		v.fail(path, "Value must match pattern %q", node.pattern.String())
+	}
+	if node.format != "" && !validJSONFormat(node.format, value) {
+		v.fail(path, "Value must be a valid %s", node.format)
+	}
+}
+
+// validateNumber validates the keywords applying to numbers.
+func (v *jsonSchemaValidator) validateNumber(node *jsonSchemaNode, value float64,
+	path []string) {
+
+	if node.minimum != nil && value < *node.minimum {
+		v.fail(path, "Value must be at least %v", *node.minimum)
+	}
+	if node.maximum != nil && value > *node.maximum {
+		v.fail(path, "Value must be at most %v", *node.maximum)
+	}
+	if node.exclusiveMin != nil && value <= *node.exclusiveMin {
+		v.fail(path, "Value must be greater than %v", *node.exclusiveMin)
+	}
+	if node.exclusiveMax != nil && value >= *node.exclusiveMax {
+		v.fail(path, "Value must be less than %v", *node.exclusiveMax)
+	}
+	if node.multipleOf != nil {
+		quotient := value / *node.multipleOf
+		if math.Abs(quotient-math.Round(quotient)) > 1e-9 {
+			v.fail(path, "Value must be a multiple of %v", *node.multipleOf)
+		}
+	}
+}
+
+// jsonValueType returns the JSON Schema type of the decoded JSON value.
+func jsonValueType(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if value == math.Trunc(value) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// jsonTypeAllowed returns whether the value's JSON Schema type is one of the types.
+// Integers are numbers.
+func jsonTypeAllowed(types []string, valueType string) bool {
+	for _, t := range types {
+		if t == valueType || t == "number" && valueType == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonEqual returns whether the decoded JSON values are equal.
+func jsonEqual(a, b interface{}) bool {
+	return reflect.DeepEqual(normalizeJSONValue(a), normalizeJSONValue(b))
+}
+
+// jsonContains returns whether any of the values equals the value.
+func jsonContains(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if jsonEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonList returns the JSON encodings of the values separated by commas.
+func jsonList(values []interface{}) string {
+	encoded := make([]string, len(values))
+	for i, value := range values {
+		data, _ := json.Marshal(value)
+		encoded[i] = string(data)
+	}
+	return strings.Join(encoded, ", ")
+}
+
+// normalizeJSONValue returns the value with Payloads converted to maps and numbers to
+// float64, as json.Unmarshal decodes them, so payloads decoded from other formats are
+// validated alike.
+func normalizeJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, bool, string, float64:
+		return v
+	case Payload:
+		return normalizeJSONValue(map[string]interface{}(v))
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			normalized[key] = normalizeJSONValue(item)
+		}
+		return normalized
+	case []interface{}:
+		normalized := make([]interface{}, len(v))
+		for i, item := range v {
+			normalized[i] = normalizeJSONValue(item)
+		}
+		return normalized
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32:
+		return rv.Float()
+	}
+	return value
+}
+
+// validJSONFormat returns whether the string is valid for the format. Unknown formats
+// are always valid.
+func validJSONFormat(format, value string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", value)
+		return err == nil
+	case "email":
+		address, err := mail.ParseAddress(value)
+		return err == nil && address.Address == value
+	case "uri":
+		u, err := url.Parse(value)
+		return err == nil && u.IsAbs()
+	case "uuid":
+		return uuidPattern.MatchString(value)
+	}
+	return true
+}
+
+// RulesJSONSchema returns a JSONSchema derived from the inbound Rules which apply to
+// the version. Fields must have the JSON type of their Rule's Type, which is stricter
+// than type coercion, e.g. strings aren't accepted for Int fields. Required Rules are
+// required and nested Rules are applied to objects and array items. Fields without
+// Rules are allowed, since they're discarded.
+func RulesJSONSchema(rules Rules, version string) *JSONSchema {
+	schema, err := newJSONSchema(rulesSchemaSource(rules, version))
+	if err != nil {
+		// Derived schemas are always valid.
+		panic(err)
+	}
+	return schema
+}
+
+// rulesSchemaSource returns the JSON Schema document of an object conforming to the
+// inbound Rules which apply to the version.
+func rulesSchemaSource(rules Rules, version string) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []interface{}{}
+	for _, field := range rulePlanFor(rules, Inbound, version).fields {
+		if field.name == "" {
+			continue
+		}
+		properties[field.name] = ruleSchemaSource(field.rule.Type, field.nested, version)
+		if field.rule.Required {
+			required = append(required, field.name)
+		}
+	}
+
+	source := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		source["required"] = required
+	}
+	return source
+}
+
+// ruleSchemaSource returns the JSON Schema document of a value of the Type with the
+// nested Rules applied.
+func ruleSchemaSource(t Type, nested Rules, version string) map[string]interface{} {
+	hasNested := nested != nil && nested.Size() > 0
+	switch typeToKind[t] {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if t == Duration {
+			return map[string]interface{}{"type": []interface{}{"string", "integer"}}
+		}
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Struct:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case reflect.Slice:
+		source := map[string]interface{}{"type": "array"}
+		if hasNested {
+			if element := nested.Contents()[0]; element.isResourceRule() {
+				source["items"] = rulesSchemaSource(nested, version)
+			} else {
+				source["items"] = ruleSchemaSource(element.Type, element.Rules, version)
+			}
+		}
+		return source
+	case reflect.Map:
+		if hasNested {
+			return rulesSchemaSource(nested, version)
+		}
+		return map[string]interface{}{"type": "object"}
+	}
+	if hasNested && nested.Contents()[0].isResourceRule() {
+		return rulesSchemaSource(nested, version)
+	}
+	return map[string]interface{}{}
+}
+
+// payloadJSONSchema returns the JSONSchema the request payload must conform to, or nil
+// if it isn't validated.
+func payloadJSONSchema(ctx RequestContext, handler ResourceHandler) *JSONSchema {
+	if ctx.Schema() != "" {
+		return nil
+	}
+	if h, ok := unwrapResourceHandler(handler).(JSONSchemaResourceHandler); ok {
+		return h.JSONSchema(ctx.Version())
+	}
+	return nil
+}
+
+// validatePayload returns FieldErrors if the payload doesn't conform to the schema. A
+// nil schema accepts any payload.
+func validatePayload(schema *JSONSchema, payload Payload) error {
+	if schema == nil {
+		return nil
+	}
+	return schema.Validate(payload)
+}
+
+// validatePayloads validates each of the payloads against the schema, returning the
+// FieldErrors of all of them keyed by the payload index and field, e.g. "1.name".
+func validatePayloads(schema *JSONSchema, payloads []Payload) error {
+	errs := FieldErrors{}
+	for i, payload := range payloads {
+		if err := validatePayload(schema, payload); err != nil {
+			addFieldError(errs, strconv.Itoa(i), err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
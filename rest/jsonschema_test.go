@@ -0,0 +1,250 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testJSONSchema = MustParseJSONSchema(`{
+	"type": "object",
+	"required": ["name", "address"],
+	"additionalProperties": false,
+	"properties": {
+		"name": {"type": "string", "minLength": 2, "maxLength": 5},
+		"age": {"type": "integer", "minimum": 0, "exclusiveMaximum": 150},
+		"email": {"type": "string", "format": "email"},
+		"kind": {"enum": ["person", "company"]},
+		"address": {"$ref": "#/$defs/address"},
+		"tags": {
+			"type": "array",
+			"maxItems": 3,
+			"uniqueItems": true,
+			"items": {"type": "string", "pattern": "^[a-z]+$"}
+		},
+		"id": {"oneOf": [{"type": "string", "format": "uuid"}, {"type": "integer"}]},
+		"note": {"anyOf": [{"type": "null"}, {"type": "string"}], "not": {"const": "x"}}
+	},
+	"$defs": {
+		"address": {
+			"type": "object",
+			"required": ["city"],
+			"properties": {"city": {"type": "string"}, "lines": {"type": "array"}}
+		}
+	}
+}`)
+
+// validateJSON decodes the JSON document and validates it against the schema,
+// returning the error messages keyed by field.
+func validateJSON(schema *JSONSchema, doc string) map[string]string {
+	var value interface{}
+	if err := json.Unmarshal([]byte(doc), &value); err != nil {
+		panic(err)
+	}
+	if errs, ok := schema.Validate(value).(FieldErrors); ok {
+		return errs.Messages()
+	}
+	return nil
+}
+
+// Ensures that conforming values are valid.
+func TestJSONSchemaValid(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(validateJSON(testJSONSchema, `{
+		"name": "bob",
+		"age": 30,
+		"email": "bob@example.com",
+		"kind": "person",
+		"address": {"city": "Ames", "lines": []},
+		"tags": ["a", "b"],
+		"id": "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+		"note": null
+	}`))
+	assert.Nil(testJSONSchema.Validate(Payload{"name": "al", "age": int64(4),
+		"address": Payload{"city": "Ames"}}))
+}
+
+// Ensures that every violation is reported, keyed by the dotted path of the invalid
+// value.
+func TestJSONSchemaInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(map[string]string{
+		"name":         "Value must be at most 5 characters",
+		"age":          "Expected integer, got number",
+		"email":        "Value must be a valid email",
+		"kind":         `Value must be one of "person", "company"`,
+		"address.city": "Missing required field 'address.city'",
+		"tags":         "Array items must be unique",
+		"tags.2":       `Value must match pattern "^[a-z]+$"`,
+		"id":           "Value must match exactly one of the allowed schemas, matched 0",
+		"note":         "Value matches a disallowed schema",
+		"extra":        "Unknown field 'extra'",
+	}, validateJSON(testJSONSchema, `{
+		"name": "robert",
+		"age": 1.5,
+		"email": "bob",
+		"kind": "animal",
+		"address": {},
+		"tags": ["a", "a", "B"],
+		"id": true,
+		"note": "x",
+		"extra": 1
+	}`))
+
+	assert.Equal(map[string]string{
+		"name":    "Missing required field 'name'",
+		"address": "Expected object, got string",
+		"age":     "Value must be less than 150",
+		"note":    "Value doesn't match any of the allowed schemas",
+	}, validateJSON(testJSONSchema, `{"address": "Ames", "age": 150, "note": 1}`))
+}
+
+// Ensures that invalid schemas are rejected.
+func TestParseJSONSchemaInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, doc := range []string{
+		`[]`,
+		`{"type": 1}`,
+		`{"properties": {"a": 1}}`,
+		`{"minLength": -1}`,
+		`{"pattern": "("}`,
+		`{"$ref": "other.json"}`,
+		`{"$ref": "#/$defs/missing"}`,
+		`{"anyOf": []}`,
+		`{`,
+	} {
+		_, err := ParseJSONSchema([]byte(doc))
+		assert.Error(err, doc)
+	}
+
+	schema, err := ParseJSONSchema([]byte(`{"$defs": {"node": {"type": "object",
+		"properties": {"child": {"$ref": "#/$defs/node"}}}}, "$ref": "#/$defs/node"}`))
+	if assert.NoError(err) {
+		assert.Nil(schema.Validate(map[string]interface{}{
+			"child": map[string]interface{}{"child": map[string]interface{}{}}}))
+		assert.NotNil(schema.Validate(map[string]interface{}{
+			"child": map[string]interface{}{"child": 1}}))
+	}
+}
+
+// Ensures that schemas derived from Rules require the JSON types of the Rules' Types
+// and apply nested Rules.
+func TestRulesJSONSchema(t *testing.T) {
+	assert := assert.New(t)
+	rules := NewRules((*map[string]interface{})(nil),
+		&Rule{Field: "name", Type: String, Required: true},
+		&Rule{Field: "count", Type: Int, Versions: []string{"2"}},
+		&Rule{Field: "created", Type: Time, OutputOnly: true},
+		&Rule{Field: "owner", Type: Map, Rules: NewRules((*map[string]interface{})(nil),
+			&Rule{Field: "email", Type: String, Required: true})},
+		&Rule{Field: "scores", Type: Slice, Rules: NewRules((*map[string]interface{})(nil),
+			&Rule{Type: Float64})},
+	)
+
+	data, err := json.Marshal(RulesJSONSchema(rules, "1"))
+	assert.NoError(err)
+	assert.JSONEq(`{
+		"type": "object",
+		"required": ["name"],
+		"properties": {
+			"name": {"type": "string"},
+			"owner": {"type": "object", "required": ["email"],
+				"properties": {"email": {"type": "string"}}},
+			"scores": {"type": "array", "items": {"type": "number"}}
+		}
+	}`, string(data))
+
+	assert.Equal(map[string]string{
+		"count":       "Expected integer, got string",
+		"owner.email": "Missing required field 'owner.email'",
+		"scores.1":    "Expected number, got string",
+	}, validateJSON(RulesJSONSchema(rules, "2"),
+		`{"name": "a", "count": "1", "owner": {}, "scores": [1, "2"], "other": true}`))
+}
+
+type jsonSchemaResourceHandler struct {
+	BaseResourceHandler
+	created []Payload
+}
+
+func (j *jsonSchemaResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (j *jsonSchemaResourceHandler) JSONSchema(version string) *JSONSchema {
+	return testJSONSchema
+}
+
+func (j *jsonSchemaResourceHandler) CreateResource(ctx RequestContext, data Payload,
+	version string) (Resource, error) {
+
+	j.created = append(j.created, data)
+	return data, nil
+}
+
+func (j *jsonSchemaResourceHandler) UpdateResourceList(ctx RequestContext, data []Payload,
+	version string) ([]Resource, error) {
+
+	j.created = append(j.created, data...)
+	return []Resource{}, nil
+}
+
+// Ensures that create and update payloads are validated against the handler's
+// JSONSchema before the handler is called, with violations aggregated in a 422.
+func TestJSONSchemaResourceHandler(t *testing.T) {
+	assert := assert.New(t)
+	handler := &jsonSchemaResourceHandler{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo",
+		bytes.NewBufferString(`{"name": "robert", "tags": [1]}`))
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusUnprocessableEntity, resp.Code)
+	assert.Contains(resp.Body.String(), "Value must be at most 5 characters")
+	assert.Contains(resp.Body.String(), "Missing required field 'address'")
+	assert.Contains(resp.Body.String(), "Expected string, got integer")
+	assert.Empty(handler.created)
+
+	req, _ = http.NewRequest("PUT", "http://foo.com/api/v1/foo",
+		bytes.NewBufferString(`[{"name": "al", "address": {"city": "Ames"}}, {"name": "al"}]`))
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusUnprocessableEntity, resp.Code)
+	assert.Contains(resp.Body.String(), "Missing required field 'address'")
+	assert.Empty(handler.created)
+
+	req, _ = http.NewRequest("POST", "http://foo.com/api/v1/foo",
+		bytes.NewBufferString(`{"name": "al", "address": {"city": "Ames"}}`))
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusCreated, resp.Code)
+	assert.Len(handler.created, 1)
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// defaultLifecycleTimeout bounds Init, and Close of unregistered ResourceHandlers, when
+// the Configuration doesn't have a LifecycleTimeout.
+const defaultLifecycleTimeout = 30 * time.Second
+
+// InitResourceHandler can be implemented by a ResourceHandler to acquire resources
+// tied to the lifetime of the server, e.g. database connection pools or background
+// workers, rather than relying on package initialization.
+type InitResourceHandler interface {
+	// Init is called when the API starts serving, after plugins are started and before
+	// requests are accepted, or when the API first handles a request if it's served
+	// with Handler or ServeHTTP. If it returns an error, the API doesn't start and the
+	// error is returned by Start, StartTLS, or Serve. ResourceHandlers registered once
+	// the API is serving are initialized before their endpoints are bound, and aren't
+	// registered if Init fails. The Context is done after the LifecycleTimeout.
+	Init(context.Context) error
+}
+
+// CloseResourceHandler can be implemented by a ResourceHandler to release the
+// resources acquired by Init rather than relying on process exit.
+type CloseResourceHandler interface {
+	// Close is called by API#Shutdown once in-flight requests have completed, or the
+	// Context passed to Shutdown is done, with that Context. Its error is returned by
+	// Shutdown. Initialized ResourceHandlers are also closed when they're unregistered,
+	// with a Context which is done after the LifecycleTimeout.
+	Close(context.Context) error
+}
+
+// lifecycleContext returns a Context for initializing or closing ResourceHandlers
+// outside of Shutdown, which is done after the Configuration's LifecycleTimeout.
+func (r *muxAPI) lifecycleContext() (context.Context, context.CancelFunc) {
+	timeout := r.config.LifecycleTimeout
+	if timeout <= 0 {
+		timeout = defaultLifecycleTimeout
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// initHandlers initializes the registered ResourceHandlers which implement
+// InitResourceHandler in registration order. It's a no-op if they were already
+// initialized by another server or request. If a handler fails to initialize, those
+// already initialized are closed and the error is returned.
+func (r *muxAPI) initHandlers() error {
+	if atomic.LoadInt32(&r.handlersStarted) == 1 {
+		return nil
+	}
+	r.lifecycleMu.Lock()
+	defer r.lifecycleMu.Unlock()
+	if atomic.LoadInt32(&r.handlersStarted) == 1 {
+		return nil
+	}
+
+	ctx, cancel := r.lifecycleContext()
+	defer cancel()
+	for _, handler := range r.loadState().handlers {
+		if err := initHandler(ctx, handler); err != nil {
+			// Closed with a new Context in case initialization exhausted the timeout.
+			closeCtx, closeCancel := r.lifecycleContext()
+			defer closeCancel()
+			r.closeInitialized(closeCtx, r.initialized)
+			r.initialized = nil
+			return err
+		}
+		r.initialized = append(r.initialized, handler)
+	}
+	atomic.StoreInt32(&r.handlersStarted, 1)
+	return nil
+}
+
+// initHandler initializes the ResourceHandler if it implements InitResourceHandler.
+func initHandler(ctx context.Context, handler ResourceHandler) error {
+	if h, ok := unwrapResourceHandler(handler).(InitResourceHandler); ok {
+		if err := h.Init(ctx); err != nil {
+			return fmt.Errorf("Resource handler %s failed to initialize: %s",
+				handler.ResourceName(), err)
+		}
+	}
+	return nil
+}
+
+// addResourceHandler adds the ResourceHandler to the registered ResourceHandlers. If
+// the registered ResourceHandlers were already initialized, it's initialized first and
+// isn't added if that fails.
+func (r *muxAPI) addResourceHandler(h ResourceHandler) error {
+	r.lifecycleMu.Lock()
+	defer r.lifecycleMu.Unlock()
+	if atomic.LoadInt32(&r.handlersStarted) == 1 {
+		ctx, cancel := r.lifecycleContext()
+		defer cancel()
+		if err := initHandler(ctx, h); err != nil {
+			return err
+		}
+		r.initialized = append(r.initialized, h)
+	}
+	r.updateState(func(state *apiState) { state.handlers = append(state.handlers, h) })
+	return nil
+}
+
+// closeResource closes the initialized ResourceHandlers of the unregistered resource
+// like closeHandlers.
+func (r *muxAPI) closeResource(resource string) error {
+	r.lifecycleMu.Lock()
+	defer r.lifecycleMu.Unlock()
+
+	var closing []ResourceHandler
+	initialized := make([]ResourceHandler, 0, len(r.initialized))
+	for _, handler := range r.initialized {
+		if handler.ResourceName() == resource {
+			closing = append(closing, handler)
+		} else {
+			initialized = append(initialized, handler)
+		}
+	}
+	r.initialized = initialized
+
+	ctx, cancel := r.lifecycleContext()
+	defer cancel()
+	return r.closeInitialized(ctx, closing)
+}
+
+// closeHandlers closes the ResourceHandlers initialized by initHandlers which implement
+// CloseResourceHandler in reverse registration order, returning the first error
+// encountered. Every handler is closed regardless of errors.
+func (r *muxAPI) closeHandlers(ctx context.Context) error {
+	r.lifecycleMu.Lock()
+	defer r.lifecycleMu.Unlock()
+	handlers := r.initialized
+	r.initialized = nil
+	return r.closeInitialized(ctx, handlers)
+}
+
+// closeInitialized closes the initialized ResourceHandlers like closeHandlers.
+func (r *muxAPI) closeInitialized(ctx context.Context, handlers []ResourceHandler) error {
+	var err error
+	for i := len(handlers) - 1; i >= 0; i-- {
+		handler := handlers[i]
+		if h, ok := unwrapResourceHandler(handler).(CloseResourceHandler); ok {
+			if closeErr := h.Close(ctx); closeErr != nil && err == nil {
+				err = fmt.Errorf("Resource handler %s failed to close: %s",
+					handler.ResourceName(), closeErr)
+			}
+		}
+	}
+	return err
+}
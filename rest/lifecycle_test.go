@@ -0,0 +1,219 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+// lifecycleEvents records the lifecycle calls of ResourceHandlers in order.
+type lifecycleEvents struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (l *lifecycleEvents) add(event string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+}
+
+func (l *lifecycleEvents) get() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.events...)
+}
+
+// wait blocks until at least n calls were recorded.
+func (l *lifecycleEvents) wait(n int) {
+	for len(l.get()) < n {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+type lifecycleResourceHandler struct {
+	BaseResourceHandler
+	name     string
+	events   *lifecycleEvents
+	initErr  error
+	closeErr error
+	deadline bool
+}
+
+func (l *lifecycleResourceHandler) ResourceName() string {
+	return l.name
+}
+
+func (l *lifecycleResourceHandler) Init(ctx context.Context) error {
+	l.events.add("init " + l.name)
+	_, l.deadline = ctx.Deadline()
+	return l.initErr
+}
+
+func (l *lifecycleResourceHandler) Close(ctx context.Context) error {
+	l.events.add("close " + l.name)
+	return l.closeErr
+}
+
+// serveLifecycle serves the API on a new local listener, returning a channel which
+// receives the result of Serve.
+func serveLifecycle(t *testing.T, api API) chan error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- api.Serve(listener) }()
+	return served
+}
+
+// Ensures that ResourceHandlers are initialized in registration order when the API
+// starts serving and closed in reverse order when it's shut down.
+func TestResourceHandlerLifecycle(t *testing.T) {
+	assert := assert.New(t)
+	events := &lifecycleEvents{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&lifecycleResourceHandler{name: "foo", events: events})
+	api.RegisterResourceHandler(&lifecycleResourceHandler{name: "bar", events: events})
+
+	served := serveLifecycle(t, api)
+	events.wait(2)
+	assert.NoError(api.Shutdown(context.Background()))
+	assert.Equal(http.ErrServerClosed, <-served)
+	assert.Equal([]string{"init foo", "init bar", "close bar", "close foo"}, events.get())
+
+	assert.NoError(api.Shutdown(context.Background()))
+	assert.Len(events.get(), 4)
+}
+
+// Ensures that the API doesn't start if a ResourceHandler fails to initialize, and that
+// the handlers already initialized are closed.
+func TestResourceHandlerInitError(t *testing.T) {
+	assert := assert.New(t)
+	events := &lifecycleEvents{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&lifecycleResourceHandler{name: "foo", events: events})
+	api.RegisterResourceHandler(&lifecycleResourceHandler{name: "bar", events: events,
+		initErr: errors.New("no database")})
+
+	err := <-serveLifecycle(t, api)
+	if assert.Error(err) {
+		assert.Equal("Resource handler bar failed to initialize: no database", err.Error())
+	}
+	assert.Equal([]string{"init foo", "init bar", "close foo"}, events.get())
+}
+
+// Ensures that Shutdown returns the error of a ResourceHandler which fails to close
+// after closing the others.
+func TestResourceHandlerCloseError(t *testing.T) {
+	assert := assert.New(t)
+	events := &lifecycleEvents{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&lifecycleResourceHandler{name: "foo", events: events})
+	api.RegisterResourceHandler(&lifecycleResourceHandler{name: "bar", events: events,
+		closeErr: errors.New("busy")})
+
+	served := serveLifecycle(t, api)
+	events.wait(2)
+	err := api.Shutdown(context.Background())
+	if assert.Error(err) {
+		assert.Equal("Resource handler bar failed to close: busy", err.Error())
+	}
+	assert.Equal(http.ErrServerClosed, <-served)
+	assert.Equal([]string{"init foo", "init bar", "close bar", "close foo"}, events.get())
+}
+
+// serveLifecycleRequest serves a request for the resource with ServeHTTP, returning the
+// response status.
+func serveLifecycleRequest(api API, resource string) int {
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/"+resource, nil)
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	return resp.Code
+}
+
+// Ensures that ResourceHandlers are initialized once, with a Context which has a
+// deadline, when the API is served with ServeHTTP.
+func TestResourceHandlerLifecycleServeHTTP(t *testing.T) {
+	assert := assert.New(t)
+	events := &lifecycleEvents{}
+	handler := &lifecycleResourceHandler{name: "foo", events: events}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+
+	serveLifecycleRequest(api, "foo")
+	serveLifecycleRequest(api, "foo")
+
+	assert.Equal([]string{"init foo"}, events.get())
+	assert.True(handler.deadline)
+}
+
+// Ensures that requests fail with 503 while ResourceHandlers fail to initialize.
+func TestResourceHandlerLifecycleServeHTTPInitError(t *testing.T) {
+	assert := assert.New(t)
+	events := &lifecycleEvents{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&lifecycleResourceHandler{name: "foo", events: events,
+		initErr: errors.New("no database")})
+
+	assert.Equal(http.StatusServiceUnavailable, serveLifecycleRequest(api, "foo"))
+	assert.Equal([]string{"init foo"}, events.get())
+}
+
+// Ensures that ResourceHandlers registered once the API is serving are initialized
+// when registered and closed when unregistered.
+func TestResourceHandlerLifecycleHotRegistration(t *testing.T) {
+	assert := assert.New(t)
+	events := &lifecycleEvents{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&lifecycleResourceHandler{name: "foo", events: events})
+	serveLifecycleRequest(api, "foo")
+
+	api.RegisterResourceHandler(&lifecycleResourceHandler{name: "bar", events: events})
+	assert.Equal([]string{"init foo", "init bar"}, events.get())
+
+	assert.NoError(api.UnregisterResourceHandler("bar"))
+	assert.Equal([]string{"init foo", "init bar", "close bar"}, events.get())
+
+	assert.NoError(api.Shutdown(context.Background()))
+	assert.Equal([]string{"init foo", "init bar", "close bar", "close foo"}, events.get())
+}
+
+// Ensures that ResourceHandlers registered once the API is serving aren't registered
+// if they fail to initialize.
+func TestResourceHandlerLifecycleHotRegistrationInitError(t *testing.T) {
+	assert := assert.New(t)
+	events := &lifecycleEvents{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&lifecycleResourceHandler{name: "foo", events: events})
+	serveLifecycleRequest(api, "foo")
+
+	api.RegisterResourceHandler(&lifecycleResourceHandler{name: "bar", events: events,
+		initErr: errors.New("no database")})
+
+	assert.Len(api.ResourceHandlers(), 1)
+	assert.Equal(http.StatusNotFound, serveLifecycleRequest(api, "bar"))
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// resourceLinks is the key of the links section of responses.
+const resourceLinks = "links"
+
+// Relation describes a link from a resource to a related resource's endpoint.
+type Relation struct {
+	// Resource is the name of the related resource.
+	Resource string
+
+	// Method is the endpoint of the related resource to link to. Defaults to
+	// HandleRead.
+	Method HandleMethod
+
+	// IDField is the field of the outbound representation of the resource containing
+	// the ID of the related resource. It's required for endpoints which take an ID.
+	IDField string
+}
+
+// LinkedResourceHandler can be implemented by a ResourceHandler to declare relations
+// to other resources. When links are enabled, responses containing a single resource
+// include a link for each relation.
+type LinkedResourceHandler interface {
+	// Relations returns the resource's relations keyed by link relation name.
+	Relations() map[string]Relation
+}
+
+// URLFor returns the URL path of the named resource's endpoint for the operation,
+// version, and resource ID, built from the registered routes.
+func (r *muxAPI) URLFor(resource string, operation HandleMethod, version,
+	id string) (*url.URL, error) {
+
+	return r.urlFor(resource, operation, version, id, nil)
+}
+
+// urlFor returns the URL path of the named resource's endpoint for the operation,
+// version, and resource ID with the additional path variables, e.g. the tenant.
+func (r *muxAPI) urlFor(resource string, operation HandleMethod, version, id string,
+	vars map[string]string) (*url.URL, error) {
+
+	r.compileRoutes()
+	routeVars := map[string]string{versionKey: version}
+	for key, value := range vars {
+		routeVars[key] = value
+	}
+	if id != "" {
+		routeVars[resourceIDKey] = id
+	}
+	u, err := r.activeRouter().URL(resource+":"+string(operation), routeVars)
+	if err != nil {
+		return nil, fmt.Errorf("No %s route for resource %s: %s", operation, resource, err)
+	}
+	return u, nil
+}
+
+// responseLinks returns the links section for the response described by the
+// RequestContext, built from the registered routes. Links are only included in
+// successful responses from ResourceHandler endpoints when enabled in the
+// Configuration. Responses contain "self" and "collection" links, "next" and "prev"
+// links for lists, and links for relations declared by the handler for single
+// resources.
+func (h requestHandler) responseLinks(ctx RequestContext) map[string]string {
+	if !h.Configuration().Links || ctx.Error() != nil {
+		return nil
+	}
+
+	handler, ok := h.routeResourceHandler(ctx)
+	if !ok {
+		return nil
+	}
+	resourceName := handler.ResourceName()
+
+	links := map[string]string{}
+	if collection, err := ctx.BuildURL(resourceName, HandleReadList, nil); err == nil {
+		links["collection"] = collection.String()
+	}
+
+	result := ctx.Result()
+	if isList(result) {
+		if self, err := ctx.SelfURL(); err == nil {
+			links["self"] = self
+		}
+		if next, err := ctx.NextURL(); err == nil && next != "" {
+			links["next"] = next
+		}
+		if prev, err := ctx.PrevURL(); err == nil && prev != "" {
+			links["prev"] = prev
+		}
+		return links
+	}
+
+	id, ok := resourceID(result, result, handler.Rules(), ctx.Version())
+	if !ok {
+		id = ctx.ResourceID()
+	}
+	if id != "" {
+		if self, err := ctx.BuildURL(resourceName, HandleRead, RouteVars{resourceIDKey: id}); err == nil {
+			links["self"] = self.String()
+		}
+	}
+
+	if linked, ok := unwrapResourceHandler(handler).(LinkedResourceHandler); ok {
+		addRelationLinks(ctx, links, linked.Relations(), result)
+	}
+	return links
+}
+
+// addRelationLinks adds links for the relations of the resource. Relations which
+// can't be built, e.g. because the related ID is missing, are omitted.
+func addRelationLinks(ctx RequestContext, links map[string]string,
+	relations map[string]Relation, resource Resource) {
+
+	payload, err := resourcePayload(resource)
+	if err != nil {
+		return
+	}
+
+	for name, relation := range relations {
+		method := relation.Method
+		if method == "" {
+			method = HandleRead
+		}
+
+		vars := RouteVars{}
+		if relation.IDField != "" {
+			id, ok := payload[relation.IDField]
+			if !ok || id == nil {
+				continue
+			}
+			vars[resourceIDKey] = fmt.Sprint(id)
+		}
+
+		if u, err := ctx.BuildURL(relation.Resource, method, vars); err == nil {
+			links[name] = u.String()
+		}
+	}
+}
+
+// routeResourceHandler returns the ResourceHandler whose endpoint matched the request.
+func (h requestHandler) routeResourceHandler(ctx RequestContext) (ResourceHandler, bool) {
+	r, ok := ctx.Request()
+	if !ok {
+		return nil, false
+	}
+	name := routeName(r)
+	if name == "" {
+		return nil, false
+	}
+
+	return h.resourceHandler(strings.SplitN(name, ":", 2)[0])
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type linkedResourceHandler struct {
+	BaseResourceHandler
+}
+
+func (l *linkedResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (l *linkedResourceHandler) Relations() map[string]Relation {
+	return map[string]Relation{
+		"owner":   {Resource: "foo", IDField: "owner_id"},
+		"missing": {Resource: "foo", IDField: "missing_id"},
+	}
+}
+
+func (l *linkedResourceHandler) ReadResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	return map[string]interface{}{"id": id, "owner_id": 7}, nil
+}
+
+func (l *linkedResourceHandler) ReadResourceList(ctx RequestContext, limit int,
+	cursor string, version string) ([]Resource, string, error) {
+
+	return []Resource{map[string]interface{}{"id": "1"}}, "abc", nil
+}
+
+// Ensures that responses include links to the resource, its collection, and its
+// relations when enabled.
+func TestResponseLinks(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{Links: true})
+	api.RegisterResourceHandler(&linkedResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/1", nil)
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	var body map[string]interface{}
+	json.Unmarshal(resp.Body.Bytes(), &body)
+	assert.Equal(map[string]interface{}{
+		"self":       "http://foo.com/api/v1/foo/1",
+		"collection": "http://foo.com/api/v1/foo",
+		"owner":      "http://foo.com/api/v1/foo/7",
+	}, body["links"])
+}
+
+// Ensures that list responses include links to adjacent pages when enabled.
+func TestResponseLinksList(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{Links: true})
+	api.RegisterResourceHandler(&linkedResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo?limit=1", nil)
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	var body map[string]interface{}
+	json.Unmarshal(resp.Body.Bytes(), &body)
+	assert.Equal(map[string]interface{}{
+		"self":       "http://foo.com/api/v1/foo?limit=1",
+		"collection": "http://foo.com/api/v1/foo",
+		"next":       "http://foo.com/api/v1/foo?limit=1&next=abc",
+	}, body["links"])
+}
+
+// Ensures that responses don't include links unless enabled.
+func TestResponseLinksDisabled(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&linkedResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/1", nil)
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(
+		`{"messages":[],"reason":"OK","result":{"id":"1","owner_id":7},"status":200}`,
+		resp.Body.String(),
+		"Incorrect response string",
+	)
+}
+
+// Ensures that URLFor builds URLs for the registered routes under the mount prefix and
+// base path.
+func TestURLFor(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{MountPrefix: "/v0"})
+	api.RegisterResourceHandler(&linkedResourceHandler{})
+
+	u, err := api.URLFor("foo", HandleRead, "2", "42")
+	if assert.NoError(err) {
+		assert.Equal("/v0/api/v2/foo/42", u.String())
+	}
+
+	u, err = api.URLFor("foo", HandleCreate, "1", "")
+	if assert.NoError(err) {
+		assert.Equal("/v0/api/v1/foo", u.String())
+	}
+
+	_, err = api.URLFor("foo", HandleRead, "1", "")
+	assert.Error(err)
+
+	_, err = api.URLFor("bar", HandleRead, "1", "42")
+	assert.Error(err)
+}
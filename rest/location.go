@@ -0,0 +1,77 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import "fmt"
+
+// Identifiable can be implemented by a Resource to expose its ID. When a created
+// Resource is Identifiable, the create response includes a Location header pointing
+// to the resource's read endpoint. Alternatively, the ID field can be designated with
+// a Rule's Identifier flag.
+type Identifiable interface {
+	// ID returns the ID of the resource.
+	ID() string
+}
+
+// resourceID returns the ID of the Resource, which is either provided by the
+// Resource itself or the value of the field designated as the identifier by the Rules
+// in the outbound representation. False is returned if the ID isn't known.
+func resourceID(resource, outbound Resource, rules Rules, version string) (string, bool) {
+	if identifiable, ok := resource.(Identifiable); ok {
+		id := identifiable.ID()
+		return id, id != ""
+	}
+
+	for _, rule := range rules.Filter(false).ForVersion(version).Contents() {
+		if !rule.Identifier {
+			continue
+		}
+		payload, err := resourcePayload(outbound)
+		if err != nil {
+			return "", false
+		}
+		id, ok := payload[rule.Name()]
+		if !ok || id == nil || id == "" {
+			return "", false
+		}
+		return fmt.Sprint(id), true
+	}
+
+	return "", false
+}
+
+// setCreatedLocation sets the Location header of a create response to the read
+// endpoint of the created resource, if its ID is known. Dry runs don't have a Location
+// since nothing was created.
+func setCreatedLocation(ctx RequestContext, handler ResourceHandler, resource,
+	outbound Resource) {
+
+	if ctx.DryRun() {
+		return
+	}
+
+	id, ok := resourceID(resource, outbound, handler.Rules(), ctx.Version())
+	if !ok {
+		return
+	}
+
+	location, err := ctx.BuildURL(handler.ResourceName(), HandleRead, RouteVars{resourceIDKey: id})
+	if err != nil {
+		return
+	}
+	ctx.ResponseWriter().Header().Set("Location", location.String())
+}
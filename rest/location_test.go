@@ -0,0 +1,101 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type identifiableResource struct {
+	Key string `json:"key"`
+}
+
+func (i *identifiableResource) ID() string {
+	return i.Key
+}
+
+type locationResourceHandler struct {
+	BaseResourceHandler
+}
+
+func (l *locationResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (l *locationResourceHandler) CreateResource(ctx RequestContext, data Payload,
+	version string) (Resource, error) {
+
+	return &identifiableResource{Key: "42"}, nil
+}
+
+// Ensures that create responses include the Location of Identifiable resources.
+func TestHandleCreateLocationIdentifiable(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&locationResourceHandler{})
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo", bytes.NewBufferString("{}"))
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusCreated, resp.Code, "Incorrect response code")
+	assert.Equal("http://foo.com/api/v1/foo/42", resp.Header().Get("Location"))
+}
+
+// Ensures that create responses include the Location built from the identifier field
+// designated by the Rules.
+func TestHandleCreateLocationIdentifierRule(t *testing.T) {
+	assert := assert.New(t)
+	handler := new(MockResourceHandler)
+	handler.On("ResourceName").Return("foo")
+	handler.On("Authenticate").Return(nil)
+	handler.On("ValidVersions").Return(nil)
+	handler.On("Rules").Return(NewRules((*TestResource)(nil),
+		&Rule{Field: "Foo", FieldAlias: "id", Identifier: true}))
+	handler.On("CreateResource").Return(&TestResource{Foo: "abc"}, nil)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo", bytes.NewBufferString("{}"))
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusCreated, resp.Code, "Incorrect response code")
+	assert.Equal("http://foo.com/api/v1/foo/abc", resp.Header().Get("Location"))
+}
+
+// Ensures that create responses don't include a Location if the ID isn't known.
+func TestHandleCreateNoLocation(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&dryRunResourceHandler{})
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo", bytes.NewBufferString("{}"))
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusCreated, resp.Code, "Incorrect response code")
+	assert.Equal("", resp.Header().Get("Location"))
+}
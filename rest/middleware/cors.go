@@ -42,6 +42,12 @@ func NewCORSMiddleware(originWhitelist []string) rest.Middleware {
 	}
 }
 
+// WithCORS returns an Option enabling cross-origin requests for the API, as with
+// NewCORSMiddleware, when passed to rest.NewAPI.
+func WithCORS(originWhitelist []string) rest.Option {
+	return rest.WithMiddleware(NewCORSMiddleware(originWhitelist))
+}
+
 // checkOrigin checks if the given origin is contained in the origin whitelist.
 // Returns true if the origin is in the whitelist, false if not.
 func checkOrigin(origin string, whitelist []string) bool {
@@ -5,6 +5,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/Workiva/go-rest/rest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -73,3 +74,24 @@ func TestCORSMiddlewareNoOrigin(t *testing.T) {
 	err := NewCORSMiddleware([]string{"blah.wdesk.org", "*.wdesk.com"})(w, req)
 	assert.Nil(t, err)
 }
+
+// Ensures that WithCORS enables CORS for every request handled by the API.
+func TestWithCORS(t *testing.T) {
+	assert := assert.New(t)
+	api := rest.NewAPI(nil, WithCORS([]string{"foo.com"}))
+
+	req, _ := http.NewRequest("OPTIONS", "http://example.com/api/v1/foo", nil)
+	req.Header.Set("Origin", "http://foo.com")
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, req)
+
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal("http://foo.com", w.Header().Get("Access-Control-Allow-Origin"))
+
+	req, _ = http.NewRequest("GET", "http://example.com/api/v1/foo", nil)
+	req.Header.Set("Origin", "http://bar.com")
+	w = httptest.NewRecorder()
+	api.ServeHTTP(w, req)
+
+	assert.Equal(http.StatusBadRequest, w.Code)
+}
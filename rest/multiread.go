@@ -0,0 +1,104 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// idsKey is the name of the query string variable listing the IDs of the resources to
+// read in a multi-get request.
+const idsKey = "ids"
+
+// MultiReadResourceHandler can be implemented by a ResourceHandler to serve batched
+// reads of resources by ID in a single request. If implemented, requests to the read
+// list endpoint with an "ids" query parameter, i.e.
+//
+//	GET /api/:version/resourceName?ids=1,2,3
+//
+// are passed to ReadResources rather than ReadResourceList. The found resources are
+// returned in "results" and the IDs which couldn't be read are returned in "errors"
+// with their status and reason.
+type MultiReadResourceHandler interface {
+	// ReadResources is the logic that corresponds to reading multiple resources by their
+	// IDs. It returns the resources which were found, the errors for individual IDs
+	// which couldn't be read, and an error (or nil) if the request failed entirely.
+	ReadResources(RequestContext, []string, string) ([]Resource, map[string]error, error)
+}
+
+// multiReadRequested returns true if the client requested resources by ID.
+func multiReadRequested(ctx RequestContext) bool {
+	_, ok := ctx.Value(idsKey).(string)
+	return ok
+}
+
+// requestedIDs returns the unique, non-empty resource IDs in the "ids" query parameter
+// in the order they were given.
+func requestedIDs(ctx RequestContext) []string {
+	idsStr, _ := ctx.Value(idsKey).(string)
+	ids := []string{}
+	seen := map[string]bool{}
+	for _, id := range strings.Split(idsStr, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// multiRead passes the requested IDs to the MultiReadResourceHandler and then
+// serializes and dispatches the response. The number of IDs is subject to the read list
+// maximum limit.
+func (h requestHandler) multiRead(ctx RequestContext, handler ResourceHandler,
+	reader MultiReadResourceHandler, limits listLimits) {
+
+	version := ctx.Version()
+	ids := requestedIDs(ctx)
+
+	if len(ids) == 0 {
+		h.sendResponse(ctx.setError(BadRequest("No resource IDs provided")))
+		return
+	}
+	if limits.maxLimit > 0 && len(ids) > limits.maxLimit {
+		h.sendResponse(ctx.setError(BadRequest(fmt.Sprintf(
+			"Number of IDs exceeds maximum of %d", limits.maxLimit))))
+		return
+	}
+
+	resources, errs, err := reader.ReadResources(ctx, ids, version)
+	if err == nil {
+		rules := visibleRules(ctx, handler.Rules())
+		for idx, resource := range resources {
+			resources[idx] = applyOutboundRules(resource, rules, version)
+		}
+		if resources == nil {
+			resources = []Resource{}
+		}
+	}
+
+	ctx = ctx.setResult(resources)
+	ctx = ctx.setResourceErrors(errs)
+	ctx = ctx.setError(err)
+	ctx = ctx.setStatus(http.StatusOK)
+
+	h.sendResponse(ctx)
+}
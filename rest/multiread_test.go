@@ -0,0 +1,98 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type multiReadResourceHandler struct {
+	BaseResourceHandler
+	ids []string
+}
+
+func (m *multiReadResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (m *multiReadResourceHandler) MaxLimit() int {
+	return 3
+}
+
+func (m *multiReadResourceHandler) DefaultLimit() int {
+	return 0
+}
+
+func (m *multiReadResourceHandler) ReadResources(ctx RequestContext, ids []string,
+	version string) ([]Resource, map[string]error, error) {
+
+	m.ids = ids
+	resources := []Resource{}
+	errs := map[string]error{}
+	for _, id := range ids {
+		if id == "missing" {
+			errs[id] = ResourceNotFound("No foo " + id)
+			continue
+		}
+		resources = append(resources, &TestResource{Foo: id})
+	}
+	return resources, errs, nil
+}
+
+// Ensures that the read list handler passes requested IDs to a MultiReadResourceHandler
+// and returns found resources along with per-ID errors.
+func TestHandleReadListMultiRead(t *testing.T) {
+	assert := assert.New(t)
+	handler := &multiReadResourceHandler{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+	readHandler, _ := api.(*muxAPI).getRouteHandler("foo:readList")
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo?ids=1,missing,%202,1,", nil)
+	resp := httptest.NewRecorder()
+
+	readHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal([]string{"1", "missing", "2"}, handler.ids)
+	assert.Equal(
+		`{"errors":{"missing":{"reason":"No foo missing","status":404}},"messages":[],"reason":"OK",`+
+			`"results":[{"foo":"1"},{"foo":"2"}],"status":200}`,
+		resp.Body.String(),
+	)
+}
+
+// Ensures that multi-get requests with more IDs than the maximum limit are rejected.
+func TestHandleReadListMultiReadTooManyIDs(t *testing.T) {
+	assert := assert.New(t)
+	handler := &multiReadResourceHandler{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+	readHandler, _ := api.(*muxAPI).getRouteHandler("foo:readList")
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo?ids=1,2,3,4", nil)
+	resp := httptest.NewRecorder()
+
+	readHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusBadRequest, resp.Code, "Incorrect response code")
+	assert.Nil(handler.ids)
+}
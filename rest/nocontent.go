@@ -0,0 +1,34 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+// NoContentDeleteResourceHandler can be implemented by a ResourceHandler to respond to
+// successful deletes with 204 No Content instead of 200 with the deleted resource.
+// Deletes of missing resources should still return ResourceNotFound so clients receive
+// a 404.
+type NoContentDeleteResourceHandler interface {
+	// DeleteNoContent returns true if successful deletes should respond with 204 No
+	// Content.
+	DeleteNoContent() bool
+}
+
+// deleteNoContent returns true if the ResourceHandler responds to successful deletes
+// with 204 No Content.
+func deleteNoContent(handler ResourceHandler) bool {
+	n, ok := unwrapResourceHandler(handler).(NoContentDeleteResourceHandler)
+	return ok && n.DeleteNoContent()
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type noContentResourceHandler struct {
+	BaseResourceHandler
+}
+
+func (n *noContentResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (n *noContentResourceHandler) DeleteNoContent() bool {
+	return true
+}
+
+func (n *noContentResourceHandler) DeleteResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	if id != "1" {
+		return nil, ResourceNotFound("No foo " + id)
+	}
+	return &TestResource{Foo: "hello"}, nil
+}
+
+// Ensures that successful deletes respond with 204 No Content when the handler opts in.
+func TestHandleDeleteNoContent(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&noContentResourceHandler{})
+
+	req, _ := http.NewRequest("DELETE", "http://foo.com/api/v1/foo/1", nil)
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusNoContent, resp.Code, "Incorrect response code")
+	assert.Equal("", resp.Body.String())
+}
+
+// Ensures that deletes of missing resources respond with 404 when the handler opts into
+// 204 No Content.
+func TestHandleDeleteNoContentNotFound(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&noContentResourceHandler{})
+
+	req, _ := http.NewRequest("DELETE", "http://foo.com/api/v1/foo/2", nil)
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusNotFound, resp.Code, "Incorrect response code")
+	assert.Equal(`{"messages":["No foo 2"],"reason":"Not Found","status":404}`, resp.Body.String())
+}
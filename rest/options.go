@@ -0,0 +1,139 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// Option configures an API constructed with NewAPI, allowing it to be fully set up in
+// one call rather than with a series of mutating calls.
+type Option func(*options)
+
+// options holds the settings applied by Options.
+type options struct {
+	config      *Configuration
+	serializers map[string]ResponseSerializer
+	middleware  []Middleware
+}
+
+// WithLogger sets the Logger used by the API.
+func WithLogger(logger StdLogger) Option {
+	return func(o *options) {
+		o.config.Logger = logger
+	}
+}
+
+// WithBasePath sets the path prefix of resource endpoints, replacing "/api".
+func WithBasePath(path string) Option {
+	return func(o *options) {
+		o.config.BasePath = path
+	}
+}
+
+// WithDefaultFormat sets the response format used when requests don't specify one.
+func WithDefaultFormat(format string) Option {
+	return func(o *options) {
+		o.config.DefaultFormat = format
+	}
+}
+
+// WithTimeouts sets the read, write, and idle timeouts of the servers started by the
+// API. Zero means no timeout.
+func WithTimeouts(read, write, idle time.Duration) Option {
+	return func(o *options) {
+		o.config.ReadTimeout = read
+		o.config.WriteTimeout = write
+		o.config.IdleTimeout = idle
+	}
+}
+
+// WithMaxBodySize sets the maximum size, in bytes, of request bodies.
+func WithMaxBodySize(size int64) Option {
+	return func(o *options) {
+		o.config.MaxBodySize = size
+	}
+}
+
+// WithSerializer registers the ResponseSerializer for the format.
+func WithSerializer(format string, serializer ResponseSerializer) Option {
+	return func(o *options) {
+		o.serializers[format] = serializer
+	}
+}
+
+// WithMiddleware adds Middleware invoked for every request handled by the API, before
+// any Middleware provided to Start, StartTLS, Serve, or Handler.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(o *options) {
+		o.middleware = append(o.middleware, middleware...)
+	}
+}
+
+// WithConfiguration applies the function to the API's Configuration, allowing any
+// setting without a dedicated Option to be configured.
+func WithConfiguration(configure func(*Configuration)) Option {
+	return func(o *options) {
+		configure(o.config)
+	}
+}
+
+// applyOptions returns the settings from applying the Options to the Configuration. If
+// the Configuration is nil, an empty one is used.
+func applyOptions(config *Configuration, opts []Option) *options {
+	if config == nil {
+		config = &Configuration{}
+	}
+	o := &options{config: config, serializers: map[string]ResponseSerializer{}}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// newBodyLimitMiddleware returns a Middleware which rejects requests with bodies larger
+// than the maximum size with 413 Request Entity Too Large.
+func newBodyLimitMiddleware(maxSize int64) Middleware {
+	return func(w http.ResponseWriter, r *http.Request) *MiddlewareError {
+		tooLarge := &MiddlewareError{
+			Code:     http.StatusRequestEntityTooLarge,
+			Response: []byte(fmt.Sprintf("Request body exceeds %d bytes", maxSize)),
+		}
+		if r.Body == nil {
+			return nil
+		}
+		if r.ContentLength > maxSize {
+			return tooLarge
+		}
+		if isMultipart(r.Header) {
+			// Multipart bodies are streamed, failing when the limit is exceeded.
+			r.Body = http.MaxBytesReader(w, r.Body, maxSize)
+			return nil
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxSize))
+		if err != nil {
+			return tooLarge
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		return nil
+	}
+}
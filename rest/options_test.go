@@ -0,0 +1,128 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Ensures that NewAPI applies Options to a nil Configuration.
+func TestNewAPIOptions(t *testing.T) {
+	assert := assert.New(t)
+	logger := log.New(ioutil.Discard, "", 0)
+	api := NewAPI(nil,
+		WithLogger(logger),
+		WithBasePath("/v"),
+		WithDefaultFormat("foo"),
+		WithTimeouts(time.Second, 2*time.Second, 3*time.Second),
+		WithMaxBodySize(1024),
+		WithSerializer("foo", &TestResponseSerializer{}),
+		WithConfiguration(func(config *Configuration) { config.DefaultLimit = 5 }),
+	)
+
+	config := api.Configuration()
+	assert.Equal(logger, config.Logger)
+	assert.Equal("/v", config.BasePath)
+	assert.Equal("foo", config.DefaultFormat)
+	assert.Equal(time.Second, config.ReadTimeout)
+	assert.Equal(2*time.Second, config.WriteTimeout)
+	assert.Equal(3*time.Second, config.IdleTimeout)
+	assert.Equal(int64(1024), config.MaxBodySize)
+	assert.Equal(5, config.DefaultLimit)
+	assert.Equal([]string{"foo", "json"}, api.AvailableFormats())
+
+	server := api.(*muxAPI).newServer(":0", nil)
+	assert.Equal(time.Second, server.ReadTimeout)
+	assert.Equal(2*time.Second, server.WriteTimeout)
+	assert.Equal(3*time.Second, server.IdleTimeout)
+}
+
+// Ensures that the DefaultFormat is used when requests don't specify a format.
+func TestDefaultFormat(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(nil, WithDefaultFormat("foo"),
+		WithSerializer("foo", &TestResponseSerializer{}))
+	api.RegisterResourceHandler(&headerResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/1", nil)
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal("application/foo", resp.Header().Get("Content-Type"))
+
+	req, _ = http.NewRequest("GET", "http://foo.com/api/v1/foo/1?format=json", nil)
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal("application/json", resp.Header().Get("Content-Type"))
+}
+
+// Ensures that request bodies larger than the MaxBodySize are rejected.
+func TestMaxBodySize(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(nil, WithMaxBodySize(16))
+	api.RegisterResourceHandler(&gatewayResourceHandler{})
+
+	post := func(body string, contentLength int64) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo", bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "secret")
+		req.ContentLength = contentLength
+		resp := httptest.NewRecorder()
+		api.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := post(`{"foo": 1}`, 10)
+	assert.Equal(http.StatusCreated, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), `"foo":1`)
+
+	large := `{"foo": "` + strings.Repeat("a", 16) + `"}`
+	resp = post(large, int64(len(large)))
+	assert.Equal(http.StatusRequestEntityTooLarge, resp.Code, "Incorrect response code")
+	assert.Equal("Request body exceeds 16 bytes", resp.Body.String())
+
+	resp = post(large, -1)
+	assert.Equal(http.StatusRequestEntityTooLarge, resp.Code, "Incorrect response code")
+}
+
+// Ensures that Middleware added with WithMiddleware is invoked for every request.
+func TestWithMiddleware(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(nil, WithMiddleware(func(w http.ResponseWriter, r *http.Request) *MiddlewareError {
+		w.Header().Set("X-Middleware", "true")
+		return nil
+	}))
+	api.RegisterResourceHandler(&headerResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/1", nil)
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal("true", resp.Header().Get("X-Middleware"))
+}
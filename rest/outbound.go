@@ -0,0 +1,138 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+)
+
+// OutboundResponse is a serialized response which is about to be written to the
+// client.
+type OutboundResponse struct {
+	// Status is the HTTP status code of the response.
+	Status int
+
+	// Header is the response header. It's the header of the http.ResponseWriter, so
+	// changes are sent to the client.
+	Header http.Header
+
+	// Body is the serialized response payload. It may be backed by a pooled buffer, so
+	// it must not be retained after the response is written.
+	Body []byte
+
+	// buf is the pooled buffer the Body was serialized into, if any.
+	buf *bytes.Buffer
+}
+
+// ResponseMiddleware is a function that operates on a serialized response before it's
+// written to the client. It can inspect or replace the status, headers, and body, e.g.
+// to sign or compress the response. If an error is returned, a 500 is sent to the
+// client instead.
+type ResponseMiddleware func(RequestContext, *OutboundResponse) error
+
+// serializeResponse returns the OutboundResponse for the response serialized with the
+// ResponseSerializer. If serialization fails, the OutboundResponse is a 500 containing
+// the error.
+func serializeResponse(w http.ResponseWriter, r response,
+	serializer ResponseSerializer) *OutboundResponse {
+
+	out := &OutboundResponse{Status: r.Status, Header: w.Header()}
+	contentType := serializer.ContentType()
+
+	if r.Payload != nil {
+		body, buf, err := serializeBody(r, serializer)
+		if err != nil {
+			log.Printf("Response serialization failed: %s", err)
+			out.Status = http.StatusInternalServerError
+			contentType = "text/plain"
+			body = []byte(err.Error())
+		}
+		out.Body = body
+		out.buf = buf
+	}
+
+	out.Header.Set("Content-Type", contentType)
+	return out
+}
+
+// serializeBody marshals the response body using the ResponseSerializer. Built-in
+// serializers encode into a pooled buffer, which is returned along with the body.
+func serializeBody(r response, serializer ResponseSerializer) ([]byte, *bytes.Buffer, error) {
+	value := responseBody(r, serializer)
+	if e, ok := serializer.(encodingSerializer); ok {
+		buf := getBuffer()
+		if err := e.encode(buf, value); err != nil {
+			putBuffer(buf)
+			return nil, nil, err
+		}
+		return buf.Bytes(), buf, nil
+	}
+
+	if payload, ok := value.(Payload); ok {
+		body, err := serializer.Serialize(payload)
+		return body, nil, err
+	}
+	body, err := serializer.(ValueSerializer).SerializeValue(value)
+	return body, nil, err
+}
+
+// responseBody returns the value to serialize as the response body. Bodies built by an
+// EnvelopeBuilder are used if they're Payloads or the serializer is a ValueSerializer,
+// otherwise the default envelope is used.
+func responseBody(r response, serializer ResponseSerializer) interface{} {
+	switch body := r.body.(type) {
+	case nil:
+	case Payload:
+		return body
+	default:
+		if _, ok := serializer.(ValueSerializer); ok {
+			return body
+		}
+	}
+	return r.Payload
+}
+
+// applyResponseHeaders copies the response headers set by the handler to the
+// http.ResponseWriter, replacing any set by the framework.
+func applyResponseHeaders(ctx RequestContext) {
+	header := ctx.ResponseWriter().Header()
+	for key, values := range ctx.responseHeader() {
+		header[key] = values
+	}
+}
+
+// applyResponseMiddleware passes the OutboundResponse through each ResponseMiddleware in
+// order, returning the first error encountered.
+func applyResponseMiddleware(ctx RequestContext, middleware []ResponseMiddleware,
+	out *OutboundResponse) error {
+
+	for _, m := range middleware {
+		if err := m(ctx, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeResponse writes the OutboundResponse to the http.ResponseWriter. The header was
+// already set on the http.ResponseWriter.
+func writeResponse(w http.ResponseWriter, out *OutboundResponse) {
+	w.WriteHeader(out.Status)
+	w.Write(out.Body)
+}
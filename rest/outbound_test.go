@@ -0,0 +1,95 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Ensures that ResponseMiddleware can inspect and replace the serialized response.
+func TestResponseMiddleware(t *testing.T) {
+	assert := assert.New(t)
+	handler := new(MockResourceHandler)
+	handler.On("ResourceName").Return("foo")
+	handler.On("Authenticate").Return(nil)
+	handler.On("ValidVersions").Return(nil)
+	handler.On("Rules").Return(&rules{})
+	handler.On("ReadResource").Return(&TestResource{Foo: "hello"}, nil)
+
+	var seen []byte
+	api := NewAPI(&Configuration{ResponseMiddleware: []ResponseMiddleware{
+		func(ctx RequestContext, out *OutboundResponse) error {
+			seen = out.Body
+			out.Header.Set("X-Signature", "signed")
+			out.Status = http.StatusAccepted
+			out.Body = bytes.ToUpper(out.Body)
+			return nil
+		},
+	}})
+	api.RegisterResourceHandler(handler)
+	readHandler, _ := api.(*muxAPI).getRouteHandler("foo:read")
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/1", nil)
+	resp := httptest.NewRecorder()
+
+	readHandler.ServeHTTP(resp, req)
+
+	assert.Equal(
+		`{"messages":[],"reason":"OK","result":{"foo":"hello"},"status":200}`,
+		string(seen),
+	)
+	assert.Equal(http.StatusAccepted, resp.Code, "Incorrect response code")
+	assert.Equal("signed", resp.Header().Get("X-Signature"))
+	assert.Equal("application/json", resp.Header().Get("Content-Type"))
+	assert.Equal(
+		`{"MESSAGES":[],"REASON":"OK","RESULT":{"FOO":"HELLO"},"STATUS":200}`,
+		resp.Body.String(),
+	)
+}
+
+// Ensures that a 500 is sent if a ResponseMiddleware returns an error.
+func TestResponseMiddlewareError(t *testing.T) {
+	assert := assert.New(t)
+	handler := new(MockResourceHandler)
+	handler.On("ResourceName").Return("foo")
+	handler.On("Authenticate").Return(nil)
+	handler.On("ValidVersions").Return(nil)
+	handler.On("Rules").Return(&rules{})
+	handler.On("ReadResource").Return(&TestResource{Foo: "hello"}, nil)
+
+	api := NewAPI(&Configuration{ResponseMiddleware: []ResponseMiddleware{
+		func(ctx RequestContext, out *OutboundResponse) error {
+			return errors.New("signing failed")
+		},
+	}})
+	api.RegisterResourceHandler(handler)
+	readHandler, _ := api.(*muxAPI).getRouteHandler("foo:read")
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/1", nil)
+	resp := httptest.NewRecorder()
+
+	readHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusInternalServerError, resp.Code, "Incorrect response code")
+	assert.Equal("signing failed", resp.Body.String())
+}
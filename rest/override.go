@@ -0,0 +1,103 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// methodOverrideHeader is the request header containing a method override.
+	methodOverrideHeader = "X-HTTP-Method-Override"
+
+	// methodOverrideKey is the name of the query string variable or form field
+	// containing a method override.
+	methodOverrideKey = "_method"
+)
+
+// overridableMethods are the methods POST requests can be overridden with.
+var overridableMethods = map[string]bool{
+	"GET":    true,
+	"PUT":    true,
+	"PATCH":  true,
+	"DELETE": true,
+}
+
+// newMethodOverrideMiddleware returns a Middleware which sets the method of POST
+// requests to the method override in the X-HTTP-Method-Override header, the "_method"
+// query string variable, or the "_method" field of form bodies, in that order of
+// precedence. Requests overriding with a method other than GET, PUT, PATCH, or DELETE
+// are rejected with 400 Bad Request.
+func newMethodOverrideMiddleware() Middleware {
+	return func(w http.ResponseWriter, r *http.Request) *MiddlewareError {
+		if r.Method != "POST" {
+			return nil
+		}
+
+		override, err := methodOverride(r)
+		if err != nil {
+			return &MiddlewareError{Code: http.StatusBadRequest, Response: []byte(err.Error())}
+		}
+		if override == "" {
+			return nil
+		}
+
+		override = strings.ToUpper(override)
+		if !overridableMethods[override] {
+			return &MiddlewareError{
+				Code:     http.StatusBadRequest,
+				Response: []byte(fmt.Sprintf("Method can't be overridden with %s", override)),
+			}
+		}
+		r.Method = override
+		return nil
+	}
+}
+
+// methodOverride returns the method override of the request, or an empty string if it
+// doesn't have one. Form bodies are read to find the override and then restored so
+// they can be read again.
+func methodOverride(r *http.Request) (string, error) {
+	if override := r.Header.Get(methodOverrideHeader); override != "" {
+		return override, nil
+	}
+	if override := r.URL.Query().Get(methodOverrideKey); override != "" {
+		return override, nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/x-www-form-urlencoded" || r.Body == nil {
+		return "", nil
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return "", errors.New("Failed to read request body")
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return "", nil
+	}
+	return form.Get(methodOverrideKey), nil
+}
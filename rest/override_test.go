@@ -0,0 +1,108 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type overrideResourceHandler struct {
+	BaseResourceHandler
+}
+
+func (o overrideResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (o overrideResourceHandler) UpdateResource(ctx RequestContext, id string, data Payload,
+	version string) (Resource, error) {
+
+	return Payload{"method": "update", "data": data}, nil
+}
+
+func (o overrideResourceHandler) DeleteResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	return Payload{"method": "delete"}, nil
+}
+
+// Ensures that POST requests are handled with the method override from the header,
+// query string, or form body when method overriding is enabled.
+func TestMethodOverride(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{MethodOverride: true, AllowEmptyBody: true})
+	api.RegisterResourceHandler(overrideResourceHandler{})
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo/1",
+		bytes.NewBufferString(`{"foo": "bar"}`))
+	req.Header.Set("X-HTTP-Method-Override", "PUT")
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Contains(resp.Body.String(), `"result":{"data":{"foo":"bar"},"method":"update"}`)
+
+	req, _ = http.NewRequest("POST", "http://foo.com/api/v1/foo/1?_method=delete", nil)
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Contains(resp.Body.String(), `"method":"delete"`)
+
+	req, _ = http.NewRequest("POST", "http://foo.com/api/v1/foo/1",
+		bytes.NewBufferString("_method=DELETE"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Contains(resp.Body.String(), `"method":"delete"`)
+}
+
+// Ensures that overriding with an unsupported method is rejected.
+func TestMethodOverrideInvalid(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{MethodOverride: true})
+	api.RegisterResourceHandler(overrideResourceHandler{})
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo/1?_method=TRACE", nil)
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusBadRequest, resp.Code)
+	assert.Equal("Method can't be overridden with TRACE", resp.Body.String())
+}
+
+// Ensures that method overrides are ignored unless method overriding is enabled.
+func TestMethodOverrideDisabled(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(overrideResourceHandler{})
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo/1", nil)
+	req.Header.Set("X-HTTP-Method-Override", "DELETE")
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.NotEqual(http.StatusOK, resp.Code)
+	assert.NotContains(resp.Body.String(), `"method":"delete"`)
+}
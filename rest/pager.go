@@ -0,0 +1,241 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultPagerRetries is the number of times a Pager retries a throttled request if
+	// a limit isn't set.
+	defaultPagerRetries = 5
+
+	// defaultPagerBackoff is the delay before a Pager retries a throttled request which
+	// didn't specify a Retry-After. The delay doubles for each subsequent retry.
+	defaultPagerBackoff = time.Second
+
+	// maxPagerDelay caps the delays requested by servers.
+	maxPagerDelay = 5 * time.Minute
+
+	// epochPagerReset is the rate limit reset value above which it's treated as a Unix
+	// time rather than a number of seconds.
+	epochPagerReset = 1000000000
+)
+
+// Pager crawls the pages of a resource list, following the next URL of each page until
+// there are no more. It paces itself using the RateLimit-Remaining and RateLimit-Reset
+// headers (or their X-RateLimit- equivalents) by spreading the remaining requests
+// evenly over the reset window, and retries 429 Too Many Requests and 503 Service
+// Unavailable responses after their Retry-After delay, backing off exponentially if
+// there isn't one.
+//
+//	pager := NewPager(client, "http://example.com/api/v1/foo?limit=100", nil)
+//	for pager.Next() {
+//		for _, resource := range pager.Results() {
+//			...
+//		}
+//	}
+//	if err := pager.Err(); err != nil {
+//		...
+//	}
+type Pager struct {
+	// MaxRetries is the number of times a throttled request is retried before giving
+	// up. Defaults to 5.
+	MaxRetries int
+
+	// Backoff is the delay before retrying a throttled request which didn't specify a
+	// Retry-After. It doubles for each subsequent retry. Defaults to one second.
+	Backoff time.Duration
+
+	client RestClient
+	url    string
+	header http.Header
+	page   *Response
+	err    error
+	delay  time.Duration
+	sleep  func(time.Duration)
+	now    func() time.Time
+}
+
+// NewPager returns a Pager which crawls the resource list at the URL using the
+// RestClient, sending the header with each request.
+func NewPager(client RestClient, url string, header http.Header) *Pager {
+	return &Pager{
+		client: client,
+		url:    url,
+		header: header,
+		sleep:  time.Sleep,
+		now:    time.Now,
+	}
+}
+
+// Next fetches the next page, returning false when there are no more pages or an error
+// occurred.
+func (p *Pager) Next() bool {
+	if p.err != nil || p.url == "" {
+		return false
+	}
+
+	if p.delay > 0 {
+		p.sleep(p.delay)
+		p.delay = 0
+	}
+
+	page, err := p.fetch()
+	if err != nil {
+		p.err = err
+		p.page = nil
+		return false
+	}
+
+	p.page = page
+	p.url = page.Next
+	if page.Raw != nil {
+		p.delay = p.pace(page.Raw.Header)
+	}
+	return true
+}
+
+// Page returns the current page.
+func (p *Pager) Page() *Response {
+	return p.page
+}
+
+// Results returns the resources of the current page.
+func (p *Pager) Results() []interface{} {
+	if p.page == nil {
+		return nil
+	}
+	results, _ := p.page.Result.([]interface{})
+	return results
+}
+
+// Err returns the error which stopped the crawl, if any.
+func (p *Pager) Err() error {
+	return p.err
+}
+
+// fetch requests the current page, retrying throttled requests. Non-2xx responses
+// are returned as errors.
+func (p *Pager) fetch() (*Response, error) {
+	maxRetries := p.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = defaultPagerRetries
+	}
+	backoff := p.Backoff
+	if backoff <= 0 {
+		backoff = defaultPagerBackoff
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := p.client.Get(p.url, p.header)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.Status == http.StatusTooManyRequests || resp.Status == http.StatusServiceUnavailable {
+			if attempt >= maxRetries {
+				return nil, fmt.Errorf("%s throttled after %d retries", p.url, attempt)
+			}
+			delay, ok := time.Duration(0), false
+			if resp.Raw != nil {
+				delay, ok = p.retryAfter(resp.Raw.Header)
+			}
+			if !ok {
+				delay = backoff
+				backoff *= 2
+			}
+			p.sleep(delay)
+			continue
+		}
+
+		if resp.Status < 200 || resp.Status >= 300 {
+			return nil, fmt.Errorf("%s responded with %d %s: %s", p.url, resp.Status,
+				resp.Reason, strings.Join(resp.Messages, "; "))
+		}
+		return resp, nil
+	}
+}
+
+// retryAfter returns the delay requested by the Retry-After header, which is either a
+// number of seconds or an HTTP date.
+func (p *Pager) retryAfter(header http.Header) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return capPagerDelay(time.Duration(seconds) * time.Second), true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		delay := date.Sub(p.now())
+		if delay < 0 {
+			delay = 0
+		}
+		return capPagerDelay(delay), true
+	}
+	return 0, false
+}
+
+// pace returns the delay before the next request according to the rate limit headers
+// of the last response. The remaining requests are spread evenly over the time until
+// the limit resets, and requests wait for the reset once none remain.
+func (p *Pager) pace(header http.Header) time.Duration {
+	remaining, ok := rateLimitHeader(header, "Remaining")
+	if !ok {
+		return 0
+	}
+	reset, ok := rateLimitHeader(header, "Reset")
+	if ok && reset > epochPagerReset {
+		// Some servers send the time of the reset rather than the seconds until it.
+		reset -= int(p.now().Unix())
+	}
+	if !ok || reset <= 0 {
+		return 0
+	}
+
+	window := time.Duration(reset) * time.Second
+	if remaining <= 0 {
+		return capPagerDelay(window)
+	}
+	return capPagerDelay(window / time.Duration(remaining))
+}
+
+// rateLimitHeader returns the integer value of the RateLimit header with the given
+// suffix, falling back to the X-RateLimit header.
+func rateLimitHeader(header http.Header, suffix string) (int, bool) {
+	for _, name := range []string{"RateLimit-" + suffix, "X-RateLimit-" + suffix} {
+		if value := header.Get(name); value != "" {
+			n, err := strconv.Atoi(value)
+			return n, err == nil
+		}
+	}
+	return 0, false
+}
+
+// capPagerDelay limits the delay to maxPagerDelay.
+func capPagerDelay(delay time.Duration) time.Duration {
+	if delay > maxPagerDelay {
+		return maxPagerDelay
+	}
+	return delay
+}
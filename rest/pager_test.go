@@ -0,0 +1,157 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newPagerServer returns a server with three pages of results which throttles the
+// first request for the second page.
+func newPagerServer(header http.Header) *httptest.Server {
+	throttled := false
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for key, values := range header {
+			w.Header()[key] = values
+		}
+		page := r.URL.Query().Get("page")
+		if page == "2" && !throttled {
+			throttled = true
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		next := ""
+		switch page {
+		case "":
+			next = ts.URL + "?page=2"
+		case "2":
+			next = ts.URL + "?page=3"
+		}
+		fmt.Fprintf(w, `{"status":200,"reason":"OK","messages":[],"results":["%s"],"next":"%s"}`,
+			page, next)
+	}))
+	return ts
+}
+
+// Ensures that Pager follows next URLs and retries throttled requests after their
+// Retry-After delay.
+func TestPagerRetryAfter(t *testing.T) {
+	assert := assert.New(t)
+	ts := newPagerServer(nil)
+	defer ts.Close()
+
+	pager := NewPager(NewRestClient(http.DefaultClient), ts.URL, nil)
+	sleeps := []time.Duration{}
+	pager.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+
+	results := []interface{}{}
+	for pager.Next() {
+		results = append(results, pager.Results()...)
+	}
+
+	assert.NoError(pager.Err())
+	assert.Equal([]interface{}{"", "2", "3"}, results)
+	assert.Equal([]time.Duration{7 * time.Second}, sleeps)
+}
+
+// Ensures that Pager spreads requests over the rate limit window.
+func TestPagerPacing(t *testing.T) {
+	assert := assert.New(t)
+	ts := newPagerServer(http.Header{
+		"Ratelimit-Remaining": {"5"},
+		"Ratelimit-Reset":     {"10"},
+	})
+	defer ts.Close()
+
+	pager := NewPager(NewRestClient(http.DefaultClient), ts.URL, nil)
+	sleeps := []time.Duration{}
+	pager.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+
+	pages := 0
+	for pager.Next() {
+		pages++
+	}
+
+	assert.NoError(pager.Err())
+	assert.Equal(3, pages)
+	assert.Equal([]time.Duration{2 * time.Second, 7 * time.Second, 2 * time.Second}, sleeps)
+}
+
+// Ensures that Pager waits for the rate limit to reset once no requests remain,
+// including resets given as Unix times.
+func TestPagerPaceExhausted(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Unix(1500000000, 0)
+	pager := NewPager(nil, "", nil)
+	pager.now = func() time.Time { return now }
+
+	assert.Equal(30*time.Second, pager.pace(http.Header{
+		"X-Ratelimit-Remaining": {"0"},
+		"X-Ratelimit-Reset":     {"1500000030"},
+	}))
+	assert.Equal(maxPagerDelay, pager.pace(http.Header{
+		"Ratelimit-Remaining": {"0"},
+		"Ratelimit-Reset":     {"3600"},
+	}))
+	assert.Equal(time.Duration(0), pager.pace(http.Header{}))
+}
+
+// Ensures that Pager gives up after the maximum retries, backing off exponentially when
+// there's no Retry-After.
+func TestPagerMaxRetries(t *testing.T) {
+	assert := assert.New(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	pager := NewPager(NewRestClient(http.DefaultClient), ts.URL, nil)
+	pager.MaxRetries = 3
+	sleeps := []time.Duration{}
+	pager.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+
+	assert.False(pager.Next())
+	assert.Error(pager.Err())
+	assert.Equal([]time.Duration{time.Second, 2 * time.Second, 4 * time.Second}, sleeps)
+}
+
+// Ensures that Pager stops with an error on unsuccessful responses.
+func TestPagerError(t *testing.T) {
+	assert := assert.New(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"status":400,"reason":"Bad Request","messages":["Invalid limit"]}`))
+	}))
+	defer ts.Close()
+
+	pager := NewPager(NewRestClient(http.DefaultClient), ts.URL, nil)
+
+	assert.False(pager.Next())
+	assert.Nil(pager.Page())
+	if assert.Error(pager.Err()) {
+		assert.Contains(pager.Err().Error(), "400 Bad Request: Invalid limit")
+	}
+}
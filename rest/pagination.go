@@ -0,0 +1,117 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PaginationStrategy determines how the results of a read list endpoint are paged.
+type PaginationStrategy uint
+
+// PaginationStrategy constants define the supported pagination modes.
+const (
+	// CursorPagination pages results using an opaque cursor returned by the
+	// ResourceHandler and passed back by clients with the "next" query parameter.
+	CursorPagination PaginationStrategy = iota
+
+	// OffsetPagination pages results using the "page" and "per_page" query
+	// parameters. This is useful for backends which cannot produce cursors.
+	OffsetPagination
+)
+
+// PaginatedResourceHandler can be implemented by a ResourceHandler to select the
+// PaginationStrategy used by its read list endpoint. ResourceHandlers which don't
+// implement it use CursorPagination.
+type PaginatedResourceHandler interface {
+	// Pagination returns the PaginationStrategy for the handler's read list endpoint.
+	Pagination() PaginationStrategy
+}
+
+// paginationStrategy returns the PaginationStrategy for the given ResourceHandler.
+func paginationStrategy(handler ResourceHandler) PaginationStrategy {
+	if p, ok := unwrapResourceHandler(handler).(PaginatedResourceHandler); ok {
+		return p.Pagination()
+	}
+	return CursorPagination
+}
+
+// LimitedResourceHandler can be implemented by a ResourceHandler to override the
+// default and maximum read list limits set in the API Configuration. Returning zero
+// from either method falls back to the Configuration.
+type LimitedResourceHandler interface {
+	// DefaultLimit returns the limit used when the client doesn't specify one.
+	DefaultLimit() int
+
+	// MaxLimit returns the largest limit passed to the handler's read list endpoint.
+	MaxLimit() int
+}
+
+// listLimits contains the default and maximum read list limits for a request.
+type listLimits struct {
+	defaultLimit int
+	maxLimit     int
+}
+
+// resolveListLimits returns the read list limits for the given ResourceHandler,
+// falling back to the Configuration for any limits the handler doesn't specify.
+func resolveListLimits(config *Configuration, handler ResourceHandler) listLimits {
+	limits := listLimits{defaultLimit: config.DefaultLimit, maxLimit: config.MaxLimit}
+	if l, ok := unwrapResourceHandler(handler).(LimitedResourceHandler); ok {
+		if d := l.DefaultLimit(); d > 0 {
+			limits.defaultLimit = d
+		}
+		if m := l.MaxLimit(); m > 0 {
+			limits.maxLimit = m
+		}
+	}
+
+	if limits.defaultLimit <= 0 {
+		limits.defaultLimit = defaultLimit
+	}
+	if limits.maxLimit > 0 && limits.defaultLimit > limits.maxLimit {
+		limits.defaultLimit = limits.maxLimit
+	}
+	return limits
+}
+
+// clamp returns the given limit reduced to the maximum limit, if there is one.
+func (l listLimits) clamp(limit int) int {
+	if l.maxLimit > 0 && limit > l.maxLimit {
+		return l.maxLimit
+	}
+	return limit
+}
+
+// setLinkHeader sets an RFC 5988 Link header on the response containing the self, next,
+// and previous page URLs for the request so that clients can paginate without parsing
+// the response body.
+func setLinkHeader(ctx RequestContext) {
+	links := []string{}
+	if nextURL, err := ctx.NextURL(); err == nil && nextURL != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, nextURL))
+	}
+	if prevURL, err := ctx.PrevURL(); err == nil && prevURL != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, prevURL))
+	}
+	if selfURL, err := ctx.SelfURL(); err == nil {
+		links = append(links, fmt.Sprintf(`<%s>; rel="self"`, selfURL))
+	}
+
+	ctx.ResponseWriter().Header().Add("Link", strings.Join(links, ", "))
+}
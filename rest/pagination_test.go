@@ -0,0 +1,221 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type offsetResourceHandler struct {
+	BaseResourceHandler
+	limit int
+	total int
+}
+
+func (o *offsetResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (o *offsetResourceHandler) Pagination() PaginationStrategy {
+	return OffsetPagination
+}
+
+func (o *offsetResourceHandler) ReadResourceList(ctx RequestContext, limit int,
+	cursor string, version string) ([]Resource, string, error) {
+
+	o.limit = limit
+	ctx.SetTotalCount(o.total)
+	return []Resource{&TestResource{Foo: "hello"}}, "", nil
+}
+
+// Ensures that paginationStrategy defaults to CursorPagination and detects handlers
+// implementing PaginatedResourceHandler through the proxy.
+func TestPaginationStrategy(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(CursorPagination, paginationStrategy(TestResourceHandler{}))
+	assert.Equal(OffsetPagination,
+		paginationStrategy(resourceHandlerProxy{ResourceHandler: &offsetResourceHandler{}}))
+}
+
+// Ensures that the read list handler passes per_page as the limit and includes the
+// offset pagination metadata in the response when using OffsetPagination.
+func TestHandleReadListOffsetPagination(t *testing.T) {
+	assert := assert.New(t)
+	handler := &offsetResourceHandler{total: 5}
+	api := NewAPI(&Configuration{})
+
+	api.RegisterResourceHandler(handler)
+	readHandler, _ := api.(*muxAPI).getRouteHandler("foo:readList")
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v0.1/foo?page=2&per_page=2", nil)
+	req.RequestURI = "/api/v0.1/foo?page=2&per_page=2"
+	resp := httptest.NewRecorder()
+
+	readHandler.ServeHTTP(resp, req)
+
+	assert.Equal(2, handler.limit)
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal(
+		`{"messages":[],"next":"http://foo.com/api/v0.1/foo?page=3\u0026per_page=2",`+
+			`"page":2,"per_page":2,"prev":"http://foo.com/api/v0.1/foo?page=1\u0026per_page=2",`+
+			`"reason":"OK","results":[{"foo":"hello"}],"status":200,"total":5}`,
+		resp.Body.String(),
+		"Incorrect response string",
+	)
+}
+
+type limitedResourceHandler struct {
+	BaseResourceHandler
+	limit int
+}
+
+func (l *limitedResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (l *limitedResourceHandler) DefaultLimit() int {
+	return 10
+}
+
+func (l *limitedResourceHandler) MaxLimit() int {
+	return 20
+}
+
+func (l *limitedResourceHandler) ReadResourceList(ctx RequestContext, limit int,
+	cursor string, version string) ([]Resource, string, error) {
+
+	l.limit = limit
+	return []Resource{}, "", nil
+}
+
+// Ensures that resolveListLimits falls back to the Configuration and the package
+// default for limits the handler doesn't specify.
+func TestResolveListLimits(t *testing.T) {
+	assert := assert.New(t)
+
+	limits := resolveListLimits(&Configuration{}, TestResourceHandler{})
+	assert.Equal(listLimits{defaultLimit: 100}, limits)
+
+	limits = resolveListLimits(&Configuration{DefaultLimit: 500, MaxLimit: 200},
+		TestResourceHandler{})
+	assert.Equal(listLimits{defaultLimit: 200, maxLimit: 200}, limits)
+
+	limits = resolveListLimits(&Configuration{DefaultLimit: 5, MaxLimit: 200},
+		resourceHandlerProxy{ResourceHandler: &limitedResourceHandler{}})
+	assert.Equal(listLimits{defaultLimit: 10, maxLimit: 20}, limits)
+}
+
+// Ensures that the read list handler passes the handler's default limit and clamps
+// oversized limits to the maximum.
+func TestHandleReadListLimits(t *testing.T) {
+	assert := assert.New(t)
+	handler := &limitedResourceHandler{}
+	api := NewAPI(&Configuration{})
+
+	api.RegisterResourceHandler(handler)
+	readHandler, _ := api.(*muxAPI).getRouteHandler("foo:readList")
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v0.1/foo", nil)
+	readHandler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(10, handler.limit)
+
+	req, _ = http.NewRequest("GET", "http://foo.com/api/v0.1/foo?limit=1000000", nil)
+	readHandler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(20, handler.limit)
+}
+
+// Ensures that the read list handler rejects oversized limits with a 400 when
+// RejectOversizedLimits is enabled.
+func TestHandleReadListRejectOversizedLimit(t *testing.T) {
+	assert := assert.New(t)
+	handler := &limitedResourceHandler{}
+	api := NewAPI(&Configuration{RejectOversizedLimits: true})
+
+	api.RegisterResourceHandler(handler)
+	readHandler, _ := api.(*muxAPI).getRouteHandler("foo:readList")
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v0.1/foo?limit=21", nil)
+	resp := httptest.NewRecorder()
+
+	readHandler.ServeHTTP(resp, req)
+
+	assert.Equal(0, handler.limit, "Handler should not be invoked")
+	assert.Equal(http.StatusBadRequest, resp.Code, "Incorrect response code")
+	assert.Equal(
+		`{"messages":["Limit exceeds maximum of 20"],"reason":"Bad Request","status":400}`,
+		resp.Body.String(),
+		"Incorrect response string",
+	)
+}
+
+// Ensures that the read list handler sets a Link header containing the next, prev,
+// and self URLs.
+func TestHandleReadListLinkHeader(t *testing.T) {
+	assert := assert.New(t)
+	handler := &offsetResourceHandler{total: 5}
+	api := NewAPI(&Configuration{})
+
+	api.RegisterResourceHandler(handler)
+	readHandler, _ := api.(*muxAPI).getRouteHandler("foo:readList")
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v0.1/foo?page=2&per_page=2", nil)
+	req.RequestURI = "/api/v0.1/foo?page=2&per_page=2"
+	resp := httptest.NewRecorder()
+
+	readHandler.ServeHTTP(resp, req)
+
+	assert.Equal(
+		`<http://foo.com/api/v0.1/foo?page=3&per_page=2>; rel="next", `+
+			`<http://foo.com/api/v0.1/foo?page=1&per_page=2>; rel="prev", `+
+			`<http://foo.com/api/v0.1/foo?page=2&per_page=2>; rel="self"`,
+		resp.Header().Get("Link"),
+	)
+}
+
+// Ensures that the Link header contains the cursor-based next URL when using
+// CursorPagination.
+func TestHandleReadListLinkHeaderCursor(t *testing.T) {
+	assert := assert.New(t)
+	handler := new(MockResourceHandler)
+	api := NewAPI(&Configuration{})
+
+	handler.On("ResourceName").Return("foo")
+	handler.On("Authenticate").Return(nil)
+	handler.On("ValidVersions").Return(nil)
+	handler.On("Rules").Return(&rules{})
+	handler.On("ReadResourceList").Return([]Resource{}, "cursor123", nil)
+
+	api.RegisterResourceHandler(handler)
+	readHandler, _ := api.(*muxAPI).getRouteHandler("foo:readList")
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v0.1/foo", nil)
+	req.RequestURI = "/api/v0.1/foo"
+	resp := httptest.NewRecorder()
+
+	readHandler.ServeHTTP(resp, req)
+
+	assert.Equal(
+		`<http://foo.com/api/v0.1/foo?next=cursor123>; rel="next", `+
+			`<http://foo.com/api/v0.1/foo>; rel="self"`,
+		resp.Header().Get("Link"),
+	)
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// DeprecatedParamsResourceHandler can be implemented by a ResourceHandler to declare
+// deprecated query parameters in addition to those in the API Configuration.
+type DeprecatedParamsResourceHandler interface {
+	// DeprecatedParams returns a map of deprecated query parameter names to the names
+	// of their replacements.
+	DeprecatedParams() map[string]string
+}
+
+// deprecatedParams returns the deprecated query parameters for the ResourceHandler,
+// merging those it declares over those in the Configuration.
+func deprecatedParams(config *Configuration, handler ResourceHandler) map[string]string {
+	params := map[string]string{}
+	for name, replacement := range config.DeprecatedParams {
+		params[name] = replacement
+	}
+	if d, ok := unwrapResourceHandler(handler).(DeprecatedParamsResourceHandler); ok {
+		for name, replacement := range d.DeprecatedParams() {
+			params[name] = replacement
+		}
+	}
+	return params
+}
+
+// newDeprecatedParamsMiddleware returns a RequestMiddleware which aliases deprecated
+// query parameters to their replacements so handlers only need to know the new names.
+// If the replacement is also provided, it takes precedence. A Warning header is added
+// to the response for each deprecated parameter used, and the warnings are included in
+// the response messages.
+func newDeprecatedParamsMiddleware(params map[string]string) RequestMiddleware {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			warnings := []string{}
+			for _, name := range names {
+				values, ok := query[name]
+				if !ok {
+					continue
+				}
+
+				replacement := params[name]
+				if _, ok := query[replacement]; !ok {
+					query[replacement] = values
+				}
+
+				warning := fmt.Sprintf("Query parameter %s is deprecated, use %s instead",
+					name, replacement)
+				w.Header().Add("Warning", fmt.Sprintf(`299 - "%s"`, warning))
+				warnings = append(warnings, warning)
+			}
+
+			if len(warnings) > 0 {
+				r.URL.RawQuery = query.Encode()
+				addDeprecationWarnings(r, warnings...)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type deprecatedParamsResourceHandler struct {
+	BaseResourceHandler
+	limit  int
+	filter string
+}
+
+func (d *deprecatedParamsResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (d *deprecatedParamsResourceHandler) DeprecatedParams() map[string]string {
+	return map[string]string{"q": "filter"}
+}
+
+func (d *deprecatedParamsResourceHandler) ReadResourceList(ctx RequestContext, limit int,
+	cursor, version string) ([]Resource, string, error) {
+
+	d.limit = limit
+	d.filter, _ = ctx.Value("filter").(string)
+	return []Resource{}, "", nil
+}
+
+// Ensures that deprecated query parameters are aliased to their replacements and a
+// warning is returned.
+func TestDeprecatedParams(t *testing.T) {
+	assert := assert.New(t)
+	handler := &deprecatedParamsResourceHandler{}
+	api := NewAPI(&Configuration{DeprecatedParams: map[string]string{"max": "limit"}})
+	api.RegisterResourceHandler(handler)
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo?max=5&q=bar", nil)
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal(5, handler.limit)
+	assert.Equal("bar", handler.filter)
+	assert.Equal([]string{
+		`299 - "Query parameter max is deprecated, use limit instead"`,
+		`299 - "Query parameter q is deprecated, use filter instead"`,
+	}, resp.Header()["Warning"])
+	assert.Contains(resp.Body.String(), "Query parameter max is deprecated, use limit instead")
+}
+
+// Ensures that replacement query parameters take precedence over deprecated ones.
+func TestDeprecatedParamsReplacementPrecedence(t *testing.T) {
+	assert := assert.New(t)
+	handler := &deprecatedParamsResourceHandler{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo?q=old&filter=new", nil)
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal("new", handler.filter)
+	assert.Len(resp.Header()["Warning"], 1)
+}
@@ -0,0 +1,623 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	// mergePatchContentType is the media type of RFC 7386 JSON Merge Patch documents.
+	mergePatchContentType = "application/merge-patch+json"
+
+	// jsonPatchContentType is the media type of RFC 6902 JSON Patch documents.
+	jsonPatchContentType = "application/json-patch+json"
+)
+
+// PatchOperation is an operation of an RFC 6902 JSON Patch document.
+type PatchOperation struct {
+	// Op is the operation: "add", "remove", "replace", "move", "copy", or "test".
+	Op string `json:"op"`
+
+	// Path is the JSON Pointer to the location the operation targets, e.g. "/name".
+	Path string `json:"path"`
+
+	// From is the JSON Pointer to the location moved or copied by "move" and "copy"
+	// operations.
+	From string `json:"from,omitempty"`
+
+	// Value is the decoded value of "add", "replace", and "test" operations.
+	Value interface{} `json:"value,omitempty"`
+}
+
+// PatchResourceHandler is implemented by ResourceHandlers which apply JSON Patch
+// documents themselves, e.g. as atomic updates in their datastore. PATCH requests with
+// "application/json-patch+json" bodies are passed to ApplyPatch instead of reading the
+// resource, patching it, and passing the result to UpdateResource. Other patch media
+// types are handled as usual.
+type PatchResourceHandler interface {
+	// ApplyPatch applies the operations, in order, to the resource with the ID and
+	// returns the updated resource. The operations' paths have been validated against
+	// the resource's Rules. A failed "test" operation should return a 409 Conflict
+	// error and an operation which can't be applied a 422 Unprocessable Entity error.
+	ApplyPatch(ctx RequestContext, id string, operations []PatchOperation,
+		version string) (Resource, error)
+}
+
+// requestPatchType returns the patch media type of the request Content-Type. JSON Merge
+// Patch is used for "application/merge-patch+json", "application/json", or no
+// Content-Type and JSON Patch for "application/json-patch+json". An error is returned
+// for other media types.
+func requestPatchType(ctx RequestContext) (string, error) {
+	contentType := ctx.Header().Get("Content-Type")
+	if contentType == "" {
+		return mergePatchContentType, nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", BadRequest(fmt.Sprintf("Invalid Content-Type: %s", err))
+	}
+
+	switch mediaType {
+	case mergePatchContentType, "application/json":
+		return mergePatchContentType, nil
+	case jsonPatchContentType:
+		return jsonPatchContentType, nil
+	}
+
+	ctx.SetHeader("Accept-Patch", mergePatchContentType+", "+jsonPatchContentType)
+	return "", CustomError(fmt.Sprintf("Unsupported patch media type %q", mediaType),
+		http.StatusUnsupportedMediaType)
+}
+
+// handlePatch returns a Handler which will apply the patch document in the request
+// payload to the current resource, pass the patched resource to the provided update
+// function, and then serialize and dispatch the response. The patch is applied to the
+// outbound representation of the resource read using ReadResource, or the pre-image if
+// the handler captures one, and the result is treated like an update payload. JSON
+// Patch operations are validated against the Rules first and passed to ApplyPatch if
+// the handler implements PatchResourceHandler. The serialization mechanism used is
+// specified by the "format" query parameter.
+func (h requestHandler) handlePatch(handler ResourceHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := h.newContext(w, r)
+		version := ctx.Version()
+
+		if err := checkDryRun(ctx, handler); err != nil {
+			h.sendResponse(ctx.setError(err))
+			return
+		}
+
+		if err := checkPreconditionRequired(ctx, handler); err != nil {
+			h.sendResponse(ctx.setError(err))
+			return
+		}
+
+		patchType, err := requestPatchType(ctx)
+		if err != nil {
+			h.sendResponse(ctx.setError(err))
+			return
+		}
+
+		middleware := payloadMiddleware(h.Configuration(), handler)
+		inbound, err := inboundRules(ctx, handler)
+		if err != nil {
+			h.sendResponse(ctx.setError(err))
+			return
+		}
+
+		outbound := visibleRules(ctx, handler.Rules())
+
+		body, err := h.requestBody(ctx)
+		if err != nil {
+			h.sendResponse(ctx.setError(err))
+			return
+		}
+
+		var operations []PatchOperation
+		if patchType == jsonPatchContentType {
+			operations, err = parsePatchOperations(body)
+			if err == nil {
+				err = validatePatchOperations(operations, inbound, outbound, version)
+			}
+			if err != nil {
+				h.sendResponse(ctx.setError(err))
+				return
+			}
+
+			if patcher, ok := unwrapResourceHandler(handler).(PatchResourceHandler); ok {
+				h.sendResponse(h.updateWith(ctx, handler,
+					func(ctx RequestContext) (Resource, error) {
+						defer startTiming(ctx, TimingHandler)()
+						return patcher.ApplyPatch(ctx, ctx.ResourceID(), operations, version)
+					}))
+				return
+			}
+		}
+
+		ctx, err = capturePreImage(ctx, handler)
+		if err != nil {
+			h.sendResponse(ctx.setError(err))
+			return
+		}
+
+		current, ok := ctx.PreImage()
+		if !ok {
+			if current, err = handler.ReadResource(ctx, ctx.ResourceID(), version); err != nil {
+				h.sendResponse(ctx.setError(err))
+				return
+			}
+			ctx = ctx.setPreImage(current)
+		}
+
+		stop := startTiming(ctx, TimingRules)
+		target, err := resourcePayload(applyOutboundRules(current, outbound, version))
+		stop()
+		if err != nil {
+			h.sendResponse(ctx.setError(InternalServerError(err.Error())))
+			return
+		}
+
+		var patched interface{}
+		if patchType == jsonPatchContentType {
+			patched, err = applyPatchOperations(map[string]interface{}(target), operations)
+		} else {
+			patched, err = mergePatch(map[string]interface{}(target), body)
+		}
+		if err != nil {
+			h.sendResponse(ctx.setError(err))
+			return
+		}
+
+		data, ok := patched.(map[string]interface{})
+		if !ok {
+			h.sendResponse(ctx.setError(UnprocessableRequest("Patched resource is not an object")))
+			return
+		}
+
+		if data, err := applyPayloadMiddleware(ctx, middleware, data); err != nil {
+			ctx = ctx.setError(err)
+		} else if err := validatePayload(payloadJSONSchema(ctx, handler), data); err != nil {
+			ctx = ctx.setError(err)
+		} else if data, err := applyTimedInboundRules(ctx, data, inbound, version); err != nil {
+			// Type coercion failed.
+			ctx = ctx.setError(err)
+		} else {
+			ctx = h.update(ctx, handler, data)
+		}
+
+		h.sendResponse(ctx)
+	})
+}
+
+// mergePatch applies the RFC 7386 JSON Merge Patch document to the target.
+func mergePatch(target interface{}, patch []byte) (interface{}, error) {
+	var doc interface{}
+	if err := json.Unmarshal(patch, &doc); err != nil {
+		return nil, BadRequest(payloadError(patch, err).Error())
+	}
+	return mergePatchValue(target, doc), nil
+}
+
+// mergePatchValue recursively merges the patch value into the target value. Null
+// patch members remove the corresponding target members.
+func mergePatchValue(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+		} else {
+			targetObj[key] = mergePatchValue(targetObj[key], value)
+		}
+	}
+	return targetObj
+}
+
+// jsonPatchOperation is an operation of an RFC 6902 JSON Patch document as sent by the
+// client, so missing members can be detected.
+type jsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// jsonPatch applies the RFC 6902 JSON Patch document to the target. Operations are
+// applied in order and if any fails, an error is returned.
+func jsonPatch(target interface{}, patch []byte) (interface{}, error) {
+	operations, err := parsePatchOperations(patch)
+	if err != nil {
+		return nil, err
+	}
+	return applyPatchOperations(target, operations)
+}
+
+// parsePatchOperations decodes the operations of the RFC 6902 JSON Patch document. A
+// BadRequest Error is returned if the document is malformed or an operation is
+// invalid or missing a member it requires.
+func parsePatchOperations(patch []byte) ([]PatchOperation, error) {
+	var raw []jsonPatchOperation
+	if err := json.Unmarshal(patch, &raw); err != nil {
+		return nil, BadRequest(payloadError(patch, err).Error())
+	}
+
+	operations := make([]PatchOperation, len(raw))
+	for i, op := range raw {
+		parsed, err := parsePatchOperation(op)
+		if err != nil {
+			return nil, BadRequest(fmt.Sprintf("Operation %d: %s", i, err))
+		}
+		operations[i] = parsed
+	}
+	return operations, nil
+}
+
+// parsePatchOperation returns the PatchOperation of the operation, or an error if it's
+// invalid or missing a member it requires.
+func parsePatchOperation(op jsonPatchOperation) (PatchOperation, error) {
+	parsed := PatchOperation{Op: op.Op}
+	if op.Path == nil {
+		return parsed, errors.New("missing path")
+	}
+	if _, err := parseJSONPointer(*op.Path); err != nil {
+		return parsed, err
+	}
+	parsed.Path = *op.Path
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return parsed, errors.New("missing value")
+		}
+		if err := json.Unmarshal(op.Value, &parsed.Value); err != nil {
+			return parsed, err
+		}
+	case "move", "copy":
+		if op.From == nil {
+			return parsed, errors.New("missing from")
+		}
+		if _, err := parseJSONPointer(*op.From); err != nil {
+			return parsed, err
+		}
+		parsed.From = *op.From
+	case "remove":
+	default:
+		return parsed, fmt.Errorf("invalid op %q", op.Op)
+	}
+	return parsed, nil
+}
+
+// validatePatchOperations returns a 422 Unprocessable Entity error if any of the
+// operations modifies a location which isn't a field of the inbound Rules or reads a
+// location which isn't a field of the outbound Rules. Any location is allowed if there
+// are no Rules in that direction. Paths within fields without nested Rules are
+// allowed.
+func validatePatchOperations(operations []PatchOperation, inbound, outbound Rules,
+	version string) error {
+
+	for i, op := range operations {
+		writes := []string{op.Path}
+		var reads []string
+		switch op.Op {
+		case "move":
+			writes = append(writes, op.From)
+		case "copy":
+			reads = append(reads, op.From)
+		case "test":
+			writes, reads = nil, []string{op.Path}
+		}
+
+		for _, pointer := range writes {
+			path, _ := parseJSONPointer(pointer)
+			if !patchPathAllowed(inbound, Inbound, version, path) {
+				return UnprocessableRequest(fmt.Sprintf(
+					"Operation %d: %q isn't a writable field", i, pointer))
+			}
+		}
+		for _, pointer := range reads {
+			path, _ := parseJSONPointer(pointer)
+			if !patchPathAllowed(outbound, Outbound, version, path) {
+				return UnprocessableRequest(fmt.Sprintf(
+					"Operation %d: %q isn't a readable field", i, pointer))
+			}
+		}
+	}
+	return nil
+}
+
+// patchPathAllowed returns whether the path references a field of the Rules which pass
+// the Filter, or a location within one. Nested Rules are checked for the remainder of
+// the path, skipping the array index of slice fields.
+func patchPathAllowed(rules Rules, filter Filter, version string, path []string) bool {
+	plan := rulePlanFor(rules, filter, version)
+	if len(plan.fields) == 0 && !plan.restricted {
+		return true
+	}
+	if len(path) == 0 {
+		// Only fields can be referenced when there are Rules.
+		return false
+	}
+
+	field, ok := plan.byName[path[0]]
+	if !ok {
+		return false
+	}
+	rest := path[1:]
+	if len(rest) > 0 && typeToKind[field.rule.Type] == reflect.Slice {
+		rest = rest[1:]
+	}
+	if field.nested == nil || len(rest) == 0 {
+		return true
+	}
+	return patchPathAllowed(field.nested, filter, version, rest)
+}
+
+// applyPatchOperations applies the JSON Patch operations to the target in order. If
+// any fails, an error is returned.
+func applyPatchOperations(target interface{},
+	operations []PatchOperation) (interface{}, error) {
+
+	doc := target
+	for i, op := range operations {
+		var err error
+		if doc, err = applyPatchOperation(doc, op); err != nil {
+			if restErr, ok := err.(Error); ok {
+				return nil, CustomError(fmt.Sprintf("Operation %d: %s", i, restErr.reason),
+					restErr.Status())
+			}
+			return nil, UnprocessableRequest(fmt.Sprintf("Operation %d: %s", i, err))
+		}
+	}
+	return doc, nil
+}
+
+// applyPatchOperation applies a single parsed JSON Patch operation to the document and
+// returns the result.
+func applyPatchOperation(doc interface{}, op PatchOperation) (interface{}, error) {
+	path, err := parseJSONPointer(op.Path)
+	if err != nil {
+		return nil, BadRequest(err.Error())
+	}
+
+	switch op.Op {
+	case "add":
+		return jsonPointerAdd(doc, path, op.Value)
+	case "remove":
+		doc, _, err := jsonPointerRemove(doc, path)
+		return doc, err
+	case "replace":
+		if doc, _, err = jsonPointerRemove(doc, path); err != nil {
+			return nil, err
+		}
+		return jsonPointerAdd(doc, path, op.Value)
+	case "move":
+		fromPath, err := parseJSONPointer(op.From)
+		if err != nil {
+			return nil, BadRequest(err.Error())
+		}
+		doc, v, err := jsonPointerRemove(doc, fromPath)
+		if err != nil {
+			return nil, err
+		}
+		return jsonPointerAdd(doc, path, v)
+	case "copy":
+		fromPath, err := parseJSONPointer(op.From)
+		if err != nil {
+			return nil, BadRequest(err.Error())
+		}
+		v, err := jsonPointerGet(doc, fromPath)
+		if err != nil {
+			return nil, err
+		}
+		return jsonPointerAdd(doc, path, deepCopyJSON(v))
+	case "test":
+		actual, err := jsonPointerGet(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(actual, op.Value) {
+			return nil, CustomError(fmt.Sprintf("test failed at %q", op.Path),
+				http.StatusConflict)
+		}
+		return doc, nil
+	}
+
+	return nil, BadRequest(fmt.Sprintf("invalid op %q", op.Op))
+}
+
+// parseJSONPointer splits the RFC 6901 JSON Pointer into its unescaped reference
+// tokens.
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+// jsonArrayIndex returns the array index referenced by the token. If allowEnd is true,
+// the index one past the end of the array, referenced by "-" or the array length, is
+// allowed.
+func jsonArrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return length, nil
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if index > length || (index == length && !allowEnd) {
+		return 0, fmt.Errorf("array index %d out of bounds", index)
+	}
+	return index, nil
+}
+
+// jsonPointerGet returns the value referenced by the path in the document.
+func jsonPointerGet(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("path %q does not exist", token)
+			}
+			doc = value
+		case []interface{}:
+			index, err := jsonArrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[index]
+		default:
+			return nil, fmt.Errorf("path %q does not exist", token)
+		}
+	}
+	return doc, nil
+}
+
+// jsonPointerAdd adds the value at the path in the document and returns the result.
+// Object members are replaced and array elements are inserted.
+func jsonPointerAdd(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := jsonPointerGet(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	token := path[len(path)-1]
+
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[token] = value
+		return doc, nil
+	case []interface{}:
+		index, err := jsonArrayIndex(token, len(node), true)
+		if err != nil {
+			return nil, err
+		}
+		node = append(node, nil)
+		copy(node[index+1:], node[index:])
+		node[index] = value
+		return jsonPointerSet(doc, path[:len(path)-1], node)
+	}
+	return nil, fmt.Errorf("path %q does not exist", token)
+}
+
+// jsonPointerSet replaces the existing value at the path in the document and returns
+// the result.
+func jsonPointerSet(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := jsonPointerGet(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	token := path[len(path)-1]
+
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[token] = value
+		return doc, nil
+	case []interface{}:
+		index, err := jsonArrayIndex(token, len(node), false)
+		if err != nil {
+			return nil, err
+		}
+		node[index] = value
+		return doc, nil
+	}
+	return nil, fmt.Errorf("path %q does not exist", token)
+}
+
+// jsonPointerRemove removes the value at the path in the document and returns the
+// result along with the removed value.
+func jsonPointerRemove(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, doc, nil
+	}
+
+	parent, err := jsonPointerGet(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	token := path[len(path)-1]
+
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		value, ok := node[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("path %q does not exist", token)
+		}
+		delete(node, token)
+		return doc, value, nil
+	case []interface{}:
+		index, err := jsonArrayIndex(token, len(node), false)
+		if err != nil {
+			return nil, nil, err
+		}
+		value := node[index]
+		node = append(node[:index:index], node[index+1:]...)
+		doc, err := jsonPointerSet(doc, path[:len(path)-1], node)
+		return doc, value, err
+	}
+	return nil, nil, fmt.Errorf("path %q does not exist", token)
+}
+
+// deepCopyJSON returns a deep copy of the decoded JSON value.
+func deepCopyJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = deepCopyJSON(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = deepCopyJSON(item)
+		}
+		return copied
+	}
+	return value
+}
@@ -0,0 +1,317 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type patchResourceHandler struct {
+	BaseResourceHandler
+	reads   int
+	updated Payload
+}
+
+func (p *patchResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (p *patchResourceHandler) ReadResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	p.reads++
+	if id != "1" {
+		return nil, ResourceNotFound("No foo " + id)
+	}
+	return map[string]interface{}{
+		"name": "foo",
+		"tags": []interface{}{"a", "b"},
+		"meta": map[string]interface{}{"owner": "bob", "color": "red"},
+	}, nil
+}
+
+func (p *patchResourceHandler) UpdateResource(ctx RequestContext, id string, data Payload,
+	version string) (Resource, error) {
+
+	p.updated = data
+	return data, nil
+}
+
+// Ensures that JSON Merge Patch documents are applied to the current resource.
+func TestHandlePatchMergePatch(t *testing.T) {
+	assert := assert.New(t)
+	handler := &patchResourceHandler{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+
+	req, _ := http.NewRequest("PATCH", "http://foo.com/api/v1/foo/1",
+		bytes.NewBufferString(`{"name": "bar", "meta": {"color": null, "size": 2}}`))
+	req.Header.Set("Content-Type", mergePatchContentType)
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal(1, handler.reads)
+	assert.Equal(Payload{
+		"name": "bar",
+		"tags": []interface{}{"a", "b"},
+		"meta": map[string]interface{}{"owner": "bob", "size": float64(2)},
+	}, handler.updated)
+}
+
+// Ensures that JSON Patch documents are applied to the current resource.
+func TestHandlePatchJSONPatch(t *testing.T) {
+	assert := assert.New(t)
+	handler := &patchResourceHandler{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+
+	req, _ := http.NewRequest("PATCH", "http://foo.com/api/v1/foo/1", bytes.NewBufferString(`[
+		{"op": "test", "path": "/name", "value": "foo"},
+		{"op": "replace", "path": "/name", "value": "bar"},
+		{"op": "add", "path": "/tags/1", "value": "c"},
+		{"op": "remove", "path": "/tags/0"},
+		{"op": "copy", "from": "/meta/owner", "path": "/owner"},
+		{"op": "move", "from": "/meta/color", "path": "/tags/-"}
+	]`))
+	req.Header.Set("Content-Type", jsonPatchContentType)
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal(Payload{
+		"name":  "bar",
+		"owner": "bob",
+		"tags":  []interface{}{"c", "b", "red"},
+		"meta":  map[string]interface{}{"owner": "bob"},
+	}, handler.updated)
+}
+
+// Ensures that failed JSON Patch tests respond with 409 without updating the resource.
+func TestHandlePatchJSONPatchTestFailed(t *testing.T) {
+	assert := assert.New(t)
+	handler := &patchResourceHandler{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+
+	req, _ := http.NewRequest("PATCH", "http://foo.com/api/v1/foo/1", bytes.NewBufferString(
+		`[{"op": "test", "path": "/name", "value": "baz"}, {"op": "remove", "path": "/name"}]`))
+	req.Header.Set("Content-Type", jsonPatchContentType)
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusConflict, resp.Code, "Incorrect response code")
+	assert.Nil(handler.updated)
+}
+
+// Ensures that patches with unsupported media types are rejected.
+func TestHandlePatchUnsupportedMediaType(t *testing.T) {
+	assert := assert.New(t)
+	handler := &patchResourceHandler{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+
+	req, _ := http.NewRequest("PATCH", "http://foo.com/api/v1/foo/1",
+		bytes.NewBufferString(`<name>bar</name>`))
+	req.Header.Set("Content-Type", "application/xml")
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusUnsupportedMediaType, resp.Code, "Incorrect response code")
+	assert.Equal(mergePatchContentType+", "+jsonPatchContentType,
+		resp.Header().Get("Accept-Patch"))
+	assert.Equal(0, handler.reads)
+}
+
+// Ensures that patching a missing resource responds with 404.
+func TestHandlePatchNotFound(t *testing.T) {
+	assert := assert.New(t)
+	handler := &patchResourceHandler{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+
+	req, _ := http.NewRequest("PATCH", "http://foo.com/api/v1/foo/2",
+		bytes.NewBufferString(`{"name": "bar"}`))
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusNotFound, resp.Code, "Incorrect response code")
+	assert.Nil(handler.updated)
+}
+
+// Ensures that JSON Patch array operations follow RFC 6902.
+func TestJSONPatchArrays(t *testing.T) {
+	assert := assert.New(t)
+	var target interface{}
+	json.Unmarshal([]byte(`{"a": [[1, 2], 3]}`), &target)
+
+	result, err := jsonPatch(target, []byte(`[
+		{"op": "add", "path": "/a/0/-", "value": 9},
+		{"op": "replace", "path": "/a/1", "value": 4},
+		{"op": "add", "path": "/a~1b", "value": true}
+	]`))
+
+	assert.Nil(err)
+	expected := map[string]interface{}{
+		"a":   []interface{}{[]interface{}{float64(1), float64(2), float64(9)}, float64(4)},
+		"a/b": true,
+	}
+	assert.Equal(expected, result)
+
+	_, err = jsonPatch(result, []byte(`[{"op": "remove", "path": "/a/5"}]`))
+	assert.Equal(UnprocessableRequest("Operation 0: array index 5 out of bounds"), err)
+}
+
+type applyPatchResourceHandler struct {
+	BaseResourceHandler
+	operations []PatchOperation
+}
+
+func (a *applyPatchResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (a *applyPatchResourceHandler) Rules() Rules {
+	return NewRules((*map[string]interface{})(nil),
+		&Rule{Field: "name", Type: String},
+		&Rule{Field: "created", OutputOnly: true},
+		&Rule{Field: "notes", Roles: []string{"admin"}},
+		&Rule{Field: "tags", Type: Slice},
+	)
+}
+
+func (a *applyPatchResourceHandler) ApplyPatch(ctx RequestContext, id string,
+	operations []PatchOperation, version string) (Resource, error) {
+
+	a.operations = operations
+	if operations[0].Op == "test" {
+		return nil, CustomError("test failed", http.StatusConflict)
+	}
+	return map[string]interface{}{"name": "bar", "created": "today", "notes": "secret"}, nil
+}
+
+// servePatch performs a JSON Patch request against the handler and returns the
+// response.
+func servePatch(handler ResourceHandler, patch string) *httptest.ResponseRecorder {
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+
+	req, _ := http.NewRequest("PATCH", "http://foo.com/api/v1/foo/1",
+		bytes.NewBufferString(patch))
+	req.Header.Set("Content-Type", jsonPatchContentType)
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	return resp
+}
+
+// Ensures that JSON Patch operations are parsed and passed to ApplyPatch, and that its
+// result is sent with outbound Rules applied.
+func TestHandlePatchApplyPatch(t *testing.T) {
+	assert := assert.New(t)
+	handler := &applyPatchResourceHandler{}
+
+	resp := servePatch(handler, `[
+		{"op": "replace", "path": "/name", "value": "bar"},
+		{"op": "add", "path": "/tags/-", "value": null},
+		{"op": "copy", "from": "/created", "path": "/name"},
+		{"op": "move", "from": "/tags/0", "path": "/tags/1"}
+	]`)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal([]PatchOperation{
+		{Op: "replace", Path: "/name", Value: "bar"},
+		{Op: "add", Path: "/tags/-"},
+		{Op: "copy", Path: "/name", From: "/created"},
+		{Op: "move", Path: "/tags/1", From: "/tags/0"},
+	}, handler.operations)
+	assert.Contains(resp.Body.String(), `"name":"bar"`)
+	assert.NotContains(resp.Body.String(), "secret")
+}
+
+// Ensures that errors returned by ApplyPatch are sent to the client.
+func TestHandlePatchApplyPatchConflict(t *testing.T) {
+	assert := assert.New(t)
+
+	resp := servePatch(&applyPatchResourceHandler{},
+		`[{"op": "test", "path": "/name", "value": "baz"}]`)
+
+	assert.Equal(http.StatusConflict, resp.Code, "Incorrect response code")
+}
+
+// Ensures that JSON Patch operations referencing fields which aren't writable or
+// readable under the Rules are rejected with 422 and malformed operations with 400.
+func TestHandlePatchInvalidOperations(t *testing.T) {
+	assert := assert.New(t)
+
+	for patch, status := range map[string]int{
+		`[{"op": "replace", "path": "/created", "value": "now"}]`:                           http.StatusUnprocessableEntity,
+		`[{"op": "remove", "path": "/missing"}]`:                                            http.StatusUnprocessableEntity,
+		`[{"op": "move", "from": "/created", "path": "/name"}]`:                             http.StatusUnprocessableEntity,
+		`[{"op": "test", "path": "/notes", "value": "secret"}]`:                             http.StatusUnprocessableEntity,
+		`[{"op": "copy", "from": "/notes", "path": "/name"}]`:                               http.StatusUnprocessableEntity,
+		`[{"op": "replace", "path": "", "value": {}}]`:                                      http.StatusUnprocessableEntity,
+		`[{"op": "replace", "path": "/name"}]`:                                              http.StatusBadRequest,
+		`[{"op": "increment", "path": "/name"}]`:                                            http.StatusBadRequest,
+		`[{"op": "remove", "path": "name"}]`:                                                http.StatusBadRequest,
+		`{"op": "remove", "path": "/name"}`:                                                 http.StatusBadRequest,
+		`[{"op": "replace", "path": "/tags/0", "value": "a"}]`:                              http.StatusOK,
+		`[{"op": "add", "path": "/name", "value": "a"}, {"op": "remove", "path": "/tags"}]`: http.StatusOK,
+	} {
+		handler := &applyPatchResourceHandler{}
+		resp := servePatch(handler, patch)
+		assert.Equal(status, resp.Code, patch)
+		if status != http.StatusOK {
+			assert.Nil(handler.operations, patch)
+		}
+	}
+}
+
+// Ensures that JSON Patch operations are validated against the Rules of handlers
+// without ApplyPatch before the resource is read.
+func TestHandlePatchJSONPatchRules(t *testing.T) {
+	assert := assert.New(t)
+	handler := &rulesPatchResourceHandler{}
+
+	resp := servePatch(handler, `[{"op": "replace", "path": "/meta/owner", "value": "al"}]`)
+
+	assert.Equal(http.StatusUnprocessableEntity, resp.Code, "Incorrect response code")
+	assert.Equal(0, handler.reads)
+
+	resp = servePatch(handler, `[{"op": "replace", "path": "/name", "value": "bar"}]`)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal(Payload{"name": "bar"}, handler.updated)
+}
+
+type rulesPatchResourceHandler struct {
+	patchResourceHandler
+}
+
+func (r *rulesPatchResourceHandler) Rules() Rules {
+	return NewRules((*map[string]interface{})(nil), &Rule{Field: "name"})
+}
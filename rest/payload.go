@@ -17,7 +17,12 @@ limitations under the License.
 package rest
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"reflect"
+	"strings"
 	"time"
 )
 
@@ -281,3 +286,126 @@ func (p Payload) GetTime(key string) (time.Time, error) {
 	}
 	return time.Time{}, fmt.Errorf("Value with key '%s' not a time.Time", key)
 }
+
+// Has returns true if the payload contains the key, even if its value is null. This
+// distinguishes fields omitted from a partial update from fields being cleared.
+func (p Payload) Has(key string) bool {
+	_, ok := p[key]
+	return ok
+}
+
+// IsNull returns true if the payload contains the key with a null value.
+func (p Payload) IsNull(key string) bool {
+	value, ok := p[key]
+	return ok && value == nil
+}
+
+// String returns the value with the given key as a string. It returns false if the key
+// doesn't exist or isn't a string.
+func (p Payload) String(key string) (string, bool) {
+	value, ok := p[key].(string)
+	return value, ok
+}
+
+// Int returns the value with the given key as an int. Unlike GetInt, any integer type,
+// json.Number, or float without a fractional part, as produced by decoding JSON, is
+// converted. It returns false if the key doesn't exist or can't be converted.
+func (p Payload) Int(key string) (int, bool) {
+	value, ok := p.Float(key)
+	if !ok || value != math.Trunc(value) {
+		return 0, false
+	}
+	return int(value), true
+}
+
+// Float returns the value with the given key as a float64. Any numeric type or
+// json.Number is converted. It returns false if the key doesn't exist or can't be
+// converted.
+func (p Payload) Float(key string) (float64, bool) {
+	switch value := p[key].(type) {
+	case json.Number:
+		f, err := value.Float64()
+		return f, err == nil
+	case nil:
+		return 0, false
+	default:
+		v := reflect.ValueOf(value)
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return float64(v.Int()), true
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return float64(v.Uint()), true
+		case reflect.Float32, reflect.Float64:
+			return v.Float(), true
+		}
+	}
+	return 0, false
+}
+
+// Time returns the value with the given key as a time.Time. Unlike GetTime, RFC 3339
+// timestamp strings are parsed. It returns false if the key doesn't exist or can't be
+// converted.
+func (p Payload) Time(key string) (time.Time, bool) {
+	switch value := p[key].(type) {
+	case time.Time:
+		return value, true
+	case string:
+		t, err := time.Parse(time.RFC3339, value)
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
+
+// Decode stores the payload in the struct pointed to by v. Each exported struct field
+// is populated from the payload field named by the FieldAlias of a Rule for it, if
+// any, and otherwise by its json tag or name. Values are converted as they would be
+// by encoding/json, so numbers decode into int fields and timestamps into time.Time
+// fields. Fields missing from the payload are left unchanged. Rules may be nil.
+func (p Payload) Decode(v interface{}, rules Rules) error {
+	ptr := reflect.ValueOf(v)
+	if ptr.Kind() != reflect.Ptr || ptr.Elem().Kind() != reflect.Struct {
+		return errors.New("Payload can only be decoded into a pointer to a struct")
+	}
+	value := ptr.Elem()
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		key, ok := p.decodeKey(field, rules)
+		if !ok {
+			continue
+		}
+
+		data, err := json.Marshal(p[key])
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, value.Field(i).Addr().Interface()); err != nil {
+			return fmt.Errorf("Value with key '%s' can't be decoded into %s: %s",
+				key, field.Name, err)
+		}
+	}
+	return nil
+}
+
+// decodeKey returns the payload key from which the struct field is decoded. It returns
+// false if the payload doesn't contain the field.
+func (p Payload) decodeKey(field reflect.StructField, rules Rules) (string, bool) {
+	if rules != nil {
+		for _, rule := range rules.Contents() {
+			if rule.Field == field.Name && p.Has(rule.Name()) {
+				return rule.Name(), true
+			}
+		}
+	}
+
+	key := field.Name
+	if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag == "-" {
+		return "", false
+	} else if tag != "" {
+		key = tag
+	}
+	return key, p.Has(key)
+}
@@ -467,3 +467,87 @@ func TestGetTime(t *testing.T) {
 	assert.Equal(now, actual, "Incorrect return value")
 	assert.Nil(err, "Error should be nil")
 }
+
+// Ensures that Has and IsNull distinguish absent fields from null fields.
+func TestPayloadHasIsNull(t *testing.T) {
+	assert := assert.New(t)
+	payload := Payload{"foo": nil, "bar": 1}
+
+	assert.True(payload.Has("foo"))
+	assert.True(payload.IsNull("foo"))
+	assert.True(payload.Has("bar"))
+	assert.False(payload.IsNull("bar"))
+	assert.False(payload.Has("baz"))
+	assert.False(payload.IsNull("baz"))
+}
+
+// Ensures that the typed getters convert decoded JSON values.
+func TestPayloadTypedGetters(t *testing.T) {
+	assert := assert.New(t)
+	payload := Payload{
+		"name":  "foo",
+		"count": float64(3),
+		"ratio": 1.5,
+		"small": int8(2),
+		"at":    "2015-01-02T03:04:05Z",
+	}
+
+	name, ok := payload.String("name")
+	assert.True(ok)
+	assert.Equal("foo", name)
+	_, ok = payload.String("count")
+	assert.False(ok)
+
+	count, ok := payload.Int("count")
+	assert.True(ok)
+	assert.Equal(3, count)
+	small, ok := payload.Int("small")
+	assert.True(ok)
+	assert.Equal(2, small)
+	_, ok = payload.Int("ratio")
+	assert.False(ok)
+	_, ok = payload.Int("name")
+	assert.False(ok)
+
+	ratio, ok := payload.Float("ratio")
+	assert.True(ok)
+	assert.Equal(1.5, ratio)
+
+	at, ok := payload.Time("at")
+	assert.True(ok)
+	assert.Equal(time.Date(2015, 1, 2, 3, 4, 5, 0, time.UTC), at)
+	_, ok = payload.Time("name")
+	assert.False(ok)
+}
+
+type decodedPayload struct {
+	Name    string
+	Count   int       `json:"count"`
+	At      time.Time `json:"at"`
+	Ignored string    `json:"-"`
+	Missing string
+}
+
+// Ensures that Decode populates struct fields using Rule aliases and json tags.
+func TestPayloadDecode(t *testing.T) {
+	assert := assert.New(t)
+	rules := NewRules((*decodedPayload)(nil), &Rule{Field: "Name", FieldAlias: "title"})
+	payload := Payload{
+		"title":   "foo",
+		"count":   float64(3),
+		"at":      "2015-01-02T03:04:05Z",
+		"Ignored": "bar",
+	}
+
+	decoded := decodedPayload{Missing: "baz"}
+	assert.NoError(payload.Decode(&decoded, rules))
+	assert.Equal(decodedPayload{
+		Name:    "foo",
+		Count:   3,
+		At:      time.Date(2015, 1, 2, 3, 4, 5, 0, time.UTC),
+		Missing: "baz",
+	}, decoded)
+
+	assert.Error(Payload{"count": "x"}.Decode(&decoded, nil))
+	assert.Error(payload.Decode(decoded, nil))
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import "fmt"
+
+// Plugin is a cohesive extension of an API, e.g. an authentication provider, metrics
+// stack, or set of serializers, which is installed with API#UsePlugin. Install
+// registers whatever the plugin provides, such as ResponseSerializers or handlers.
+// Plugins hook into the rest of the API's lifecycle by also implementing
+// ResourcePlugin, StartupPlugin, ShutdownPlugin, or DocsPlugin.
+type Plugin interface {
+	// Name uniquely identifies the plugin.
+	Name() string
+
+	// Install is called when the plugin is used with the API. It returns an error if
+	// the plugin can't be installed.
+	Install(API) error
+}
+
+// ResourcePlugin can be implemented by a Plugin to hook into ResourceHandler
+// registration and the lifecycle of requests to the handlers. It only applies to
+// ResourceHandlers registered after the plugin is used.
+type ResourcePlugin interface {
+	// ResourceMiddleware is called when a ResourceHandler is registered and returns the
+	// RequestMiddleware to apply to its endpoints, if any.
+	ResourceMiddleware(ResourceHandler) []RequestMiddleware
+}
+
+// StartupPlugin can be implemented by a Plugin to run when the API is started.
+type StartupPlugin interface {
+	// Startup is called before the API starts serving requests. If it returns an error,
+	// the API isn't started and the error is returned.
+	Startup(API) error
+}
+
+// ShutdownPlugin can be implemented by a Plugin to run when the API stops serving.
+type ShutdownPlugin interface {
+	// Shutdown is called after the API stops serving requests.
+	Shutdown(API) error
+}
+
+// DocsPlugin can be implemented by a Plugin to contribute to the generated
+// documentation.
+type DocsPlugin interface {
+	// GenerateDocs is called after the API's documentation is generated with the
+	// directory it was written to.
+	GenerateDocs(API, string) error
+}
+
+// UsePlugin installs the Plugin. It returns an error if a plugin with the same name is
+// already used or installation fails.
+func (r *muxAPI) UsePlugin(plugin Plugin) error {
+	if r.pluginUsed(plugin.Name()) {
+		return fmt.Errorf("Plugin %s is already used", plugin.Name())
+	}
+	if err := plugin.Install(r); err != nil {
+		return fmt.Errorf("Failed to install plugin %s: %s", plugin.Name(), err)
+	}
+	var used bool
+	r.updateState(func(state *apiState) {
+		// The plugin may have been used concurrently while it was installed.
+		if used = containsPlugin(state.plugins, plugin.Name()); !used {
+			state.plugins = append(state.plugins, plugin)
+		}
+	})
+	if used {
+		return fmt.Errorf("Plugin %s is already used", plugin.Name())
+	}
+	r.config.Debugf("Installed plugin %s", plugin.Name())
+	return nil
+}
+
+// pluginMiddleware returns the RequestMiddleware the plugins apply to the
+// ResourceHandler.
+func (r *muxAPI) pluginMiddleware(h ResourceHandler) []RequestMiddleware {
+	middleware := []RequestMiddleware{}
+	for _, plugin := range r.loadState().plugins {
+		if p, ok := plugin.(ResourcePlugin); ok {
+			middleware = append(middleware, p.ResourceMiddleware(h)...)
+		}
+	}
+	return middleware
+}
+
+// startupPlugins runs the plugins' startup hooks, returning the first error
+// encountered.
+func (r *muxAPI) startupPlugins() error {
+	for _, plugin := range r.loadState().plugins {
+		if p, ok := plugin.(StartupPlugin); ok {
+			if err := p.Startup(r); err != nil {
+				return fmt.Errorf("Plugin %s failed to start: %s", plugin.Name(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// shutdownPlugins runs the plugins' shutdown hooks in reverse order, logging errors.
+func (r *muxAPI) shutdownPlugins() {
+	plugins := r.loadState().plugins
+	for i := len(plugins) - 1; i >= 0; i-- {
+		if p, ok := plugins[i].(ShutdownPlugin); ok {
+			if err := p.Shutdown(r); err != nil {
+				r.handler.logf("Plugin %s failed to shut down: %s", plugins[i].Name(), err)
+			}
+		}
+	}
+}
+
+// generatePluginDocs runs the plugins' documentation hooks, returning the first error
+// encountered.
+func (r *muxAPI) generatePluginDocs(dir string) error {
+	for _, plugin := range r.loadState().plugins {
+		if p, ok := plugin.(DocsPlugin); ok {
+			if err := p.GenerateDocs(r, dir); err != nil {
+				return fmt.Errorf("Plugin %s failed to generate docs: %s", plugin.Name(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// pluginUsed returns true if a plugin with the name is used.
+func (r *muxAPI) pluginUsed(name string) bool {
+	return containsPlugin(r.loadState().plugins, name)
+}
+
+// containsPlugin returns true if one of the plugins has the name.
+func containsPlugin(plugins []Plugin, name string) bool {
+	for _, plugin := range plugins {
+		if plugin.Name() == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	goplugin "plugin"
+	"sync"
+)
+
+// pluginSymbol is the name of the symbol looked up in Go plugins loaded with
+// LoadPlugin.
+const pluginSymbol = "Plugin"
+
+// pluginRegistry holds the Plugins registered with RegisterPlugin in registration
+// order. It's safe for concurrent use.
+type pluginRegistry struct {
+	mu      sync.RWMutex
+	plugins []Plugin
+}
+
+// registeredPlugins is the registry of Plugins available to APIs.
+var registeredPlugins = &pluginRegistry{}
+
+// register adds the Plugin to the registry. It returns an error if a plugin with the
+// same name is already registered.
+func (p *pluginRegistry) register(plugin Plugin) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, registered := range p.plugins {
+		if registered.Name() == plugin.Name() {
+			return fmt.Errorf("Plugin %s is already registered", plugin.Name())
+		}
+	}
+	p.plugins = append(p.plugins, plugin)
+	return nil
+}
+
+// get returns the registered plugins with the given names, or all registered plugins if
+// no names are given. It returns an error if a plugin isn't registered.
+func (p *pluginRegistry) get(names ...string) ([]Plugin, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if len(names) == 0 {
+		plugins := make([]Plugin, len(p.plugins))
+		copy(plugins, p.plugins)
+		return plugins, nil
+	}
+
+	plugins := make([]Plugin, 0, len(names))
+nameLoop:
+	for _, name := range names {
+		for _, plugin := range p.plugins {
+			if plugin.Name() == name {
+				plugins = append(plugins, plugin)
+				continue nameLoop
+			}
+		}
+		return nil, fmt.Errorf("Plugin %s is not registered", name)
+	}
+	return plugins, nil
+}
+
+// names returns the names of the registered plugins in registration order.
+func (p *pluginRegistry) names() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	names := make([]string, len(p.plugins))
+	for i, plugin := range p.plugins {
+		names[i] = plugin.Name()
+	}
+	return names
+}
+
+// RegisterPlugin makes the Plugin available to APIs assembled with
+// UseRegisteredPlugins. It's intended to be called from the init function of
+// independently developed packages contributing ResourceHandlers, ResponseSerializers,
+// or middleware, so importing the package is enough to make its Plugin available. It
+// panics if the Plugin is nil or a plugin with the same name is already registered.
+func RegisterPlugin(plugin Plugin) {
+	if plugin == nil {
+		panic("rest: RegisterPlugin plugin is nil")
+	}
+	if err := registeredPlugins.register(plugin); err != nil {
+		panic("rest: " + err.Error())
+	}
+}
+
+// RegisteredPlugins returns the names of the plugins registered with RegisterPlugin in
+// registration order.
+func RegisteredPlugins() []string {
+	return registeredPlugins.names()
+}
+
+// UseRegisteredPlugins installs the named plugins registered with RegisterPlugin, in
+// the given order, with the API. If no names are given, all registered plugins are
+// installed in registration order. It returns an error if a plugin isn't registered or
+// fails to install.
+func UseRegisteredPlugins(api API, names ...string) error {
+	plugins, err := registeredPlugins.get(names...)
+	if err != nil {
+		return err
+	}
+	for _, plugin := range plugins {
+		if err := api.UsePlugin(plugin); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadPlugin opens the Go plugin, built with -buildmode=plugin, at the path and
+// registers the Plugin it exports as if with RegisterPlugin. The Go plugin must export
+// a Plugin variable of type rest.Plugin or a Plugin function returning a rest.Plugin.
+// Loading Go plugins is only supported on platforms supported by the plugin package.
+func LoadPlugin(path string) (Plugin, error) {
+	p, err := goplugin.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load plugin %s: %s", path, err)
+	}
+	symbol, err := p.Lookup(pluginSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load plugin %s: %s", path, err)
+	}
+
+	var plugin Plugin
+	switch s := symbol.(type) {
+	case *Plugin:
+		plugin = *s
+	case func() Plugin:
+		plugin = s()
+	default:
+		return nil, fmt.Errorf("Failed to load plugin %s: %s has type %T, expected "+
+			"rest.Plugin or func() rest.Plugin", path, pluginSymbol, symbol)
+	}
+	if plugin == nil {
+		return nil, fmt.Errorf("Failed to load plugin %s: %s is nil", path, pluginSymbol)
+	}
+
+	if err := registeredPlugins.register(plugin); err != nil {
+		return nil, err
+	}
+	return plugin, nil
+}
+
+// funcPlugin is a Plugin installed by calling a function.
+type funcPlugin struct {
+	name    string
+	install func(API) error
+}
+
+// NewPlugin returns a Plugin with the name which is installed by calling the function,
+// e.g. to register a package's ResourceHandlers:
+//
+//	func init() {
+//		rest.RegisterPlugin(rest.NewPlugin("widgets", func(api rest.API) error {
+//			api.RegisterResourceHandler(&WidgetHandler{})
+//			return nil
+//		}))
+//	}
+func NewPlugin(name string, install func(API) error) Plugin {
+	return &funcPlugin{name: name, install: install}
+}
+
+// Name returns the name of the plugin.
+func (f *funcPlugin) Name() string {
+	return f.name
+}
+
+// Install calls the plugin's install function.
+func (f *funcPlugin) Install(api API) error {
+	return f.install(api)
+}
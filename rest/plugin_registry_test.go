@@ -0,0 +1,81 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Ensures that plugins registered with RegisterPlugin are installed by
+// UseRegisteredPlugins.
+func TestUseRegisteredPlugins(t *testing.T) {
+	assert := assert.New(t)
+	RegisterPlugin(NewPlugin("registry-widgets", func(api API) error {
+		api.RegisterResourceHandler(hotResourceHandler{name: "widgets"})
+		return nil
+	}))
+	RegisterPlugin(NewPlugin("registry-broken", func(api API) error {
+		return errors.New("broken")
+	}))
+
+	assert.Contains(RegisteredPlugins(), "registry-widgets")
+	assert.Panics(func() {
+		RegisterPlugin(NewPlugin("registry-widgets", func(api API) error { return nil }))
+	})
+	assert.Panics(func() { RegisterPlugin(nil) })
+
+	api := NewAPI(&Configuration{})
+	assert.NoError(UseRegisteredPlugins(api, "registry-widgets"))
+	assert.Equal(http.StatusOK, serveRead(api, "widgets").Code)
+
+	assert.EqualError(UseRegisteredPlugins(api, "registry-missing"),
+		"Plugin registry-missing is not registered")
+	assert.EqualError(UseRegisteredPlugins(NewAPI(&Configuration{}), "registry-broken"),
+		"Failed to install plugin registry-broken: broken")
+}
+
+// Ensures that pluginRegistry returns all plugins in registration order if no names are
+// given.
+func TestPluginRegistryGet(t *testing.T) {
+	assert := assert.New(t)
+	registry := &pluginRegistry{}
+	foo := NewPlugin("foo", nil)
+	bar := NewPlugin("bar", nil)
+	assert.NoError(registry.register(foo))
+	assert.NoError(registry.register(bar))
+	assert.Error(registry.register(NewPlugin("foo", nil)))
+
+	plugins, err := registry.get()
+	assert.NoError(err)
+	assert.Equal([]Plugin{foo, bar}, plugins)
+
+	plugins, err = registry.get("bar")
+	assert.NoError(err)
+	assert.Equal([]Plugin{bar}, plugins)
+	assert.Equal([]string{"foo", "bar"}, registry.names())
+}
+
+// Ensures that LoadPlugin returns an error if the Go plugin can't be opened.
+func TestLoadPluginMissing(t *testing.T) {
+	assert := assert.New(t)
+	_, err := LoadPlugin("/nonexistent/plugin.so")
+	assert.Error(err)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testPlugin struct {
+	name       string
+	startupErr error
+	events     []string
+}
+
+func (p *testPlugin) Name() string {
+	return p.name
+}
+
+func (p *testPlugin) Install(api API) error {
+	p.events = append(p.events, "install")
+	api.RegisterResponseSerializer("foo", &TestResponseSerializer{})
+	return nil
+}
+
+func (p *testPlugin) ResourceMiddleware(handler ResourceHandler) []RequestMiddleware {
+	p.events = append(p.events, "register "+handler.ResourceName())
+	return []RequestMiddleware{func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Plugin", p.name)
+			next.ServeHTTP(w, r)
+		})
+	}}
+}
+
+func (p *testPlugin) Startup(api API) error {
+	p.events = append(p.events, "startup")
+	return p.startupErr
+}
+
+func (p *testPlugin) Shutdown(api API) error {
+	p.events = append(p.events, "shutdown")
+	return nil
+}
+
+// Ensures that plugins are installed and hook into handler registration and requests.
+func TestUsePlugin(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	plugin := &testPlugin{name: "test"}
+
+	assert.NoError(api.UsePlugin(plugin))
+	api.RegisterResourceHandler(&headerResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/1", nil)
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal("test", resp.Header().Get("X-Plugin"))
+	assert.Equal([]string{"foo", "json"}, api.AvailableFormats())
+	assert.Equal([]string{"install", "register foo"}, plugin.events)
+}
+
+// Ensures that plugins with the same name can't be used twice.
+func TestUsePluginDuplicate(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+
+	assert.NoError(api.UsePlugin(&testPlugin{name: "test"}))
+	assert.Error(api.UsePlugin(&testPlugin{name: "test"}))
+}
+
+// Ensures that a plugin failing to start prevents the API from starting and that
+// plugins are shut down when the API stops serving.
+func TestPluginStartupShutdown(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	plugin := &testPlugin{name: "test", startupErr: errors.New("no metrics backend")}
+	api.UsePlugin(plugin)
+
+	err := api.Start(":-1")
+
+	if assert.Error(err) {
+		assert.Equal("Plugin test failed to start: no metrics backend", err.Error())
+	}
+	assert.Equal([]string{"install", "startup"}, plugin.events)
+
+	plugin.startupErr = nil
+	plugin.events = nil
+
+	assert.Error(api.Start(":-1"))
+	assert.Equal([]string{"startup", "shutdown"}, plugin.events)
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxPooledBufferSize is the capacity above which buffers aren't returned to the pool,
+// so a few large responses don't pin their memory for the life of the process.
+const maxPooledBufferSize = 64 << 10
+
+var (
+	// bufferPool holds the buffers responses are serialized into.
+	bufferPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+	// envelopePool holds the Payloads used as default response envelopes.
+	envelopePool = sync.Pool{New: func() interface{} { return Payload{} }}
+)
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// putBuffer resets the buffer and returns it to the pool unless it has grown too large.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// getEnvelope returns an empty Payload from the pool.
+func getEnvelope() Payload {
+	return envelopePool.Get().(Payload)
+}
+
+// putEnvelope clears the Payload and returns it to the pool.
+func putEnvelope(envelope Payload) {
+	for key := range envelope {
+		delete(envelope, key)
+	}
+	envelopePool.Put(envelope)
+}
+
+// encodingSerializer is implemented by the built-in ResponseSerializers which encode
+// directly into a pooled buffer and don't retain the values they serialize, so the
+// buffer and envelope can be reused once the response is written.
+type encodingSerializer interface {
+	// encode serializes the value into the buffer.
+	encode(*bytes.Buffer, interface{}) error
+}
+
+// releaseResponse returns the buffer the response was serialized into and, if the
+// serializer doesn't retain it, the response envelope to their pools. Neither may be
+// used afterwards.
+func releaseResponse(r response, out *OutboundResponse, serializer ResponseSerializer) {
+	if out.buf != nil {
+		putBuffer(out.buf)
+		out.buf = nil
+		out.Body = nil
+	}
+	if _, ok := serializer.(encodingSerializer); ok && r.pooled {
+		putEnvelope(r.Payload)
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Ensures that encodeJSON produces the same output as json.Marshal.
+func TestEncodeJSON(t *testing.T) {
+	assert := assert.New(t)
+	value := Payload{"b": "<tag> & more", "a": []int{1, 2}, "c": nil}
+	expected, err := json.Marshal(value)
+	assert.NoError(err)
+
+	buf := new(bytes.Buffer)
+	assert.NoError(encodeJSON(buf, value))
+	assert.Equal(string(expected), buf.String())
+}
+
+// Ensures that large buffers aren't returned to the pool and envelopes are cleared when
+// they are.
+func TestPoolRelease(t *testing.T) {
+	assert := assert.New(t)
+
+	envelope := getEnvelope()
+	envelope["foo"] = "bar"
+	putEnvelope(envelope)
+	assert.Empty(envelope)
+
+	buf := getBuffer()
+	buf.Write(make([]byte, maxPooledBufferSize+1))
+	putBuffer(buf)
+	assert.NotEqual(0, buf.Len())
+}
+
+// Ensures that serialized responses are released once written without affecting the
+// written body.
+func TestReleaseResponse(t *testing.T) {
+	assert := assert.New(t)
+	w := httptest.NewRecorder()
+	resp := response{Status: http.StatusOK, Payload: getEnvelope(), pooled: true}
+	resp.Payload[result] = "foo"
+
+	out := serializeResponse(w, resp, jsonSerializer{})
+	assert.NotNil(out.buf)
+	writeResponse(w, out)
+	releaseResponse(resp, out, jsonSerializer{})
+
+	assert.Nil(out.buf)
+	assert.Nil(out.Body)
+	assert.Empty(resp.Payload)
+	assert.Equal(`{"result":"foo"}`, w.Body.String())
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Error codes of the errors returned when request preconditions fail.
+const (
+	// CodePreconditionFailed identifies errors for requests whose conditional headers
+	// didn't match the current resource. It takes the name of the failed header as its
+	// argument.
+	CodePreconditionFailed = "precondition_failed"
+
+	// CodePreconditionRequired identifies errors for unconditional requests to modify
+	// resources which require them to be conditional.
+	CodePreconditionRequired = "precondition_required"
+)
+
+// anyETag is the entity tag list matching any current representation of a resource.
+const anyETag = "*"
+
+// Preconditions are the parsed conditional headers of a request. ResourceHandlers
+// implementing optimistic concurrency control or conditional reads can evaluate them
+// against the current resource using Check, or RequestContext's CheckPreconditions,
+// rather than parsing the headers themselves.
+type Preconditions struct {
+	// IfMatch contains the entity tags of the If-Match header, including any "W/" weak
+	// prefix, or "*" to match any current representation.
+	IfMatch []string
+
+	// IfNoneMatch contains the entity tags of the If-None-Match header, including any
+	// "W/" weak prefix, or "*" to match any current representation.
+	IfNoneMatch []string
+
+	// IfModifiedSince is the time of the If-Modified-Since header, or the zero time if
+	// it's missing or invalid.
+	IfModifiedSince time.Time
+
+	// IfUnmodifiedSince is the time of the If-Unmodified-Since header, or the zero time
+	// if it's missing or invalid.
+	IfUnmodifiedSince time.Time
+
+	// method is the HTTP method of the request, which determines whether failed
+	// If-None-Match and If-Modified-Since preconditions are 304 Not Modified.
+	method string
+}
+
+// parsePreconditions returns the Preconditions of the request with the given method
+// and headers.
+func parsePreconditions(method string, header http.Header) Preconditions {
+	return Preconditions{
+		IfMatch:           parseETags(header.Get("If-Match")),
+		IfNoneMatch:       parseETags(header.Get("If-None-Match")),
+		IfModifiedSince:   parseHTTPTime(header.Get("If-Modified-Since")),
+		IfUnmodifiedSince: parseHTTPTime(header.Get("If-Unmodified-Since")),
+		method:            method,
+	}
+}
+
+// Conditional returns true if the request has any conditional headers.
+func (p Preconditions) Conditional() bool {
+	return p.IfNoneMatch != nil || !p.IfModifiedSince.IsZero() || p.guardsWrites()
+}
+
+// guardsWrites returns true if the request has a precondition which prevents lost
+// updates, i.e. If-Match or If-Unmodified-Since.
+func (p Preconditions) guardsWrites() bool {
+	return p.IfMatch != nil || !p.IfUnmodifiedSince.IsZero()
+}
+
+// Check evaluates the Preconditions against the current entity tag and modification
+// time of the resource in the order defined by RFC 7232, section 6. An empty etag means
+// the resource doesn't exist and a zero modification time means it's unknown, in which
+// case the date preconditions are ignored. Failed If-None-Match or If-Modified-Since
+// preconditions of GET and HEAD requests return a 304 Not Modified Error, and other
+// failures a 412 Precondition Failed Error.
+func (p Preconditions) Check(etag string, modified time.Time) error {
+	if p.IfMatch != nil {
+		if !etagListMatches(p.IfMatch, etag, false) {
+			return preconditionFailed("If-Match")
+		}
+	} else if !p.IfUnmodifiedSince.IsZero() && !modified.IsZero() &&
+		modified.Truncate(time.Second).After(p.IfUnmodifiedSince) {
+		return preconditionFailed("If-Unmodified-Since")
+	}
+
+	safe := p.method == http.MethodGet || p.method == http.MethodHead
+	if p.IfNoneMatch != nil {
+		if etagListMatches(p.IfNoneMatch, etag, true) {
+			if safe {
+				return NotModified()
+			}
+			return preconditionFailed("If-None-Match")
+		}
+	} else if safe && !p.IfModifiedSince.IsZero() && !modified.IsZero() &&
+		!modified.Truncate(time.Second).After(p.IfModifiedSince) {
+		return NotModified()
+	}
+
+	return nil
+}
+
+// preconditionFailed returns a 412 Precondition Failed Error for the named header.
+func preconditionFailed(header string) Error {
+	return PreconditionFailed("Precondition "+header+" failed").
+		WithCode(CodePreconditionFailed, header)
+}
+
+// ConditionalResourceHandler can be implemented by a ResourceHandler to require that
+// updates and deletes are conditional. If implemented and RequirePreconditions returns
+// true, update, patch, and delete requests without an If-Match or If-Unmodified-Since
+// header fail with 428 Precondition Required, preventing clients from overwriting
+// changes they haven't seen.
+type ConditionalResourceHandler interface {
+	// RequirePreconditions returns true if updates and deletes must be conditional.
+	RequirePreconditions() bool
+}
+
+// checkPreconditionRequired returns a 428 Precondition Required Error if the
+// ResourceHandler requires conditional writes and the request isn't conditional.
+func checkPreconditionRequired(ctx RequestContext, handler ResourceHandler) error {
+	c, ok := unwrapResourceHandler(handler).(ConditionalResourceHandler)
+	if !ok || !c.RequirePreconditions() || ctx.Preconditions().guardsWrites() {
+		return nil
+	}
+	return PreconditionRequired("Request must be conditional using If-Match or If-Unmodified-Since").
+		WithCode(CodePreconditionRequired)
+}
+
+// parseETags returns the entity tags of an If-Match or If-None-Match header value, or
+// nil if it's empty. Unquoted tags are accepted for leniency.
+func parseETags(value string) []string {
+	var etags []string
+	for value = strings.TrimSpace(value); value != ""; value = strings.TrimSpace(value) {
+		if value[0] == ',' {
+			value = value[1:]
+			continue
+		}
+
+		prefix := ""
+		if strings.HasPrefix(value, "W/") {
+			prefix, value = "W/", value[2:]
+		}
+
+		end := strings.IndexByte(value, ',')
+		if strings.HasPrefix(value, `"`) {
+			if closing := strings.IndexByte(value[1:], '"'); closing >= 0 {
+				end = closing + 2
+			}
+		}
+		if end < 0 {
+			end = len(value)
+		}
+
+		etags = append(etags, prefix+strings.TrimSpace(value[:end]))
+		value = value[end:]
+	}
+	return etags
+}
+
+// etagListMatches returns true if any of the entity tags match the current entity tag
+// using the weak or strong comparison of RFC 7232, section 2.3.2. Nothing matches an
+// empty current entity tag, i.e. a resource which doesn't exist.
+func etagListMatches(etags []string, etag string, weak bool) bool {
+	if etag == "" {
+		return false
+	}
+	for _, candidate := range etags {
+		if candidate == anyETag || etagEqual(candidate, etag, weak) {
+			return true
+		}
+	}
+	return false
+}
+
+// etagEqual compares the entity tags. Weak tags are only equal using weak comparison.
+func etagEqual(a, b string, weak bool) bool {
+	aWeak, bWeak := strings.HasPrefix(a, "W/"), strings.HasPrefix(b, "W/")
+	if !weak && (aWeak || bWeak) {
+		return false
+	}
+	return strings.TrimPrefix(a, "W/") == strings.TrimPrefix(b, "W/")
+}
+
+// parseHTTPTime returns the time of an HTTP date header value, or the zero time if it's
+// empty or invalid.
+func parseHTTPTime(value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	t, err := http.ParseTime(value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
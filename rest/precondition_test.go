@@ -0,0 +1,213 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gContext "github.com/gorilla/context"
+	"github.com/stretchr/testify/assert"
+)
+
+type conditionalResourceHandler struct {
+	BaseResourceHandler
+	modified time.Time
+}
+
+func (c *conditionalResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (c *conditionalResourceHandler) RequirePreconditions() bool {
+	return true
+}
+
+func (c *conditionalResourceHandler) ReadResource(ctx RequestContext, id,
+	version string) (Resource, error) {
+
+	if err := ctx.CheckPreconditions(`"v1"`, c.modified); err != nil {
+		return nil, err
+	}
+	return Payload{"id": id}, nil
+}
+
+func (c *conditionalResourceHandler) UpdateResource(ctx RequestContext, id string,
+	data Payload, version string) (Resource, error) {
+
+	if err := ctx.CheckPreconditions(`"v1"`, c.modified); err != nil {
+		return nil, err
+	}
+	return Payload{"id": id}, nil
+}
+
+func (c *conditionalResourceHandler) DeleteResource(ctx RequestContext, id,
+	version string) (Resource, error) {
+
+	return Payload{"id": id}, nil
+}
+
+// Ensures that parseETags parses strong, weak, quoted, unquoted, and wildcard entity
+// tags.
+func TestParseETags(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(parseETags(""))
+	assert.Equal([]string{"*"}, parseETags("*"))
+	assert.Equal([]string{`"a"`, `W/"b"`, `"c,d"`}, parseETags(`"a", W/"b" ,"c,d"`))
+	assert.Equal([]string{"a", "b"}, parseETags("a, ,b,"))
+}
+
+// Ensures that RequestContext exposes the parsed conditional headers of the request.
+func TestRequestContextPreconditions(t *testing.T) {
+	assert := assert.New(t)
+	req, _ := http.NewRequest("GET", "http://example.com/foo", nil)
+	req.Header.Set("If-Match", `"a", W/"b"`)
+	req.Header.Set("If-None-Match", "*")
+	req.Header.Set("If-Modified-Since", "Sun, 06 Nov 1994 08:49:37 GMT")
+	req.Header.Set("If-Unmodified-Since", "not a date")
+	ctx := NewContext(nil, req, httptest.NewRecorder())
+
+	preconditions := ctx.Preconditions()
+
+	assert.True(preconditions.Conditional())
+	assert.Equal([]string{`"a"`, `W/"b"`}, preconditions.IfMatch)
+	assert.Equal([]string{"*"}, preconditions.IfNoneMatch)
+	assert.Equal(time.Date(1994, 11, 6, 8, 49, 37, 0, time.UTC), preconditions.IfModifiedSince)
+	assert.True(preconditions.IfUnmodifiedSince.IsZero())
+
+	req, _ = http.NewRequest("GET", "http://example.com/foo", nil)
+	assert.False(NewContext(nil, req, httptest.NewRecorder()).Preconditions().Conditional())
+}
+
+// Ensures that Check evaluates Preconditions in the order defined by RFC 7232.
+func TestPreconditionsCheck(t *testing.T) {
+	assert := assert.New(t)
+	modified := time.Date(2015, 1, 1, 12, 0, 0, 500, time.UTC)
+	before := modified.Add(-time.Hour)
+	after := modified.Add(time.Hour)
+
+	cases := []struct {
+		preconditions Preconditions
+		etag          string
+		status        int
+	}{
+		{Preconditions{method: "PUT"}, `"a"`, 0},
+		{Preconditions{method: "PUT", IfMatch: []string{`"a"`}}, `"a"`, 0},
+		{Preconditions{method: "PUT", IfMatch: []string{`"b"`}}, `"a"`, http.StatusPreconditionFailed},
+		{Preconditions{method: "PUT", IfMatch: []string{`W/"a"`}}, `"a"`, http.StatusPreconditionFailed},
+		{Preconditions{method: "PUT", IfMatch: []string{"*"}}, "", http.StatusPreconditionFailed},
+		{Preconditions{method: "PUT", IfMatch: []string{"*"}, IfUnmodifiedSince: before}, `"a"`, 0},
+		{Preconditions{method: "PUT", IfUnmodifiedSince: before}, `"a"`, http.StatusPreconditionFailed},
+		{Preconditions{method: "PUT", IfUnmodifiedSince: modified.Truncate(time.Second)}, `"a"`, 0},
+		{Preconditions{method: "PUT", IfNoneMatch: []string{"*"}}, "", 0},
+		{Preconditions{method: "PUT", IfNoneMatch: []string{"*"}}, `"a"`, http.StatusPreconditionFailed},
+		{Preconditions{method: "GET", IfNoneMatch: []string{`W/"a"`}}, `"a"`, http.StatusNotModified},
+		{Preconditions{method: "GET", IfNoneMatch: []string{`"b"`}, IfModifiedSince: after}, `"a"`, 0},
+		{Preconditions{method: "GET", IfModifiedSince: after}, `"a"`, http.StatusNotModified},
+		{Preconditions{method: "GET", IfModifiedSince: before}, `"a"`, 0},
+		{Preconditions{method: "PUT", IfModifiedSince: after}, `"a"`, 0},
+	}
+
+	for i, c := range cases {
+		err := c.preconditions.Check(c.etag, modified)
+		if c.status == 0 {
+			assert.NoError(err, "case %d", i)
+			continue
+		}
+		if assert.Error(err, "case %d", i) {
+			assert.Equal(c.status, errorStatus(err), "case %d", i)
+		}
+	}
+
+	err := Preconditions{method: "PUT", IfMatch: []string{`"b"`}}.Check(`"a"`, time.Time{})
+	assert.Equal(CodePreconditionFailed, err.(Error).Code())
+	assert.Equal([]interface{}{"If-Match"}, err.(Error).args)
+}
+
+// Ensures that handlers using CheckPreconditions respond with 304 Not Modified without
+// a body and with the validators of the current resource.
+func TestCheckPreconditionsNotModified(t *testing.T) {
+	assert := assert.New(t)
+	modified := time.Date(2015, 1, 1, 12, 0, 0, 0, time.UTC)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&conditionalResourceHandler{modified: modified})
+	readHandler, _ := api.(*muxAPI).getRouteHandler("foo:read")
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/1", nil)
+	req.Header.Set("If-None-Match", `"v1"`)
+	gContext.Set(req, resourceIDKey, "1")
+	resp := httptest.NewRecorder()
+
+	readHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusNotModified, resp.Code)
+	assert.Empty(resp.Body.String())
+	assert.Equal(`"v1"`, resp.Header().Get("ETag"))
+	assert.Equal("Thu, 01 Jan 2015 12:00:00 GMT", resp.Header().Get("Last-Modified"))
+
+	req, _ = http.NewRequest("GET", "http://foo.com/api/v1/foo/1", nil)
+	req.Header.Set("If-None-Match", `"v0"`)
+	gContext.Set(req, resourceIDKey, "1")
+	resp = httptest.NewRecorder()
+
+	readHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal(`"v1"`, resp.Header().Get("ETag"))
+}
+
+// Ensures that ConditionalResourceHandlers reject unconditional updates and deletes
+// with 428 Precondition Required and failed preconditions with 412.
+func TestConditionalResourceHandler(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&conditionalResourceHandler{})
+	updateHandler, _ := api.(*muxAPI).getRouteHandler("foo:update")
+	deleteHandler, _ := api.(*muxAPI).getRouteHandler("foo:delete")
+
+	req, _ := http.NewRequest("DELETE", "http://foo.com/api/v1/foo/1", nil)
+	resp := httptest.NewRecorder()
+	deleteHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusPreconditionRequired, resp.Code)
+	assert.Contains(resp.Body.String(), "Request must be conditional")
+
+	update := func(ifMatch string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("PUT", "http://foo.com/api/v1/foo/1",
+			bytes.NewBufferString(`{"foo": "bar"}`))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		gContext.Set(req, resourceIDKey, "1")
+		resp := httptest.NewRecorder()
+		updateHandler.ServeHTTP(resp, req)
+		return resp
+	}
+
+	assert.Equal(http.StatusPreconditionRequired, update("").Code)
+
+	resp = update(`"v0"`)
+	assert.Equal(http.StatusPreconditionFailed, resp.Code)
+	assert.Contains(resp.Body.String(), "Precondition If-Match failed")
+	assert.Equal(`"v1"`, resp.Header().Get("ETag"))
+
+	assert.Equal(http.StatusOK, update(`"v1"`).Code)
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"time"
+)
+
+// ReadBeforeWriteResourceHandler can be implemented by a ResourceHandler to have the
+// framework read the current resource using ReadResource before it's updated or
+// deleted. The pre-image is available through RequestContext's PreImage, is used for
+// differential update responses, and is checked against the request's Preconditions,
+// if any.
+type ReadBeforeWriteResourceHandler interface {
+	// ReadBeforeWrite returns true if the current resource should be read before it's
+	// updated or deleted.
+	ReadBeforeWrite() bool
+}
+
+// readsBeforeWrite returns true if the ResourceHandler requested pre-image capture.
+func readsBeforeWrite(handler ResourceHandler) bool {
+	rbw, ok := unwrapResourceHandler(handler).(ReadBeforeWriteResourceHandler)
+	return ok && rbw.ReadBeforeWrite()
+}
+
+// capturePreImage reads the current resource for the request if the ResourceHandler
+// requested pre-image capture and returns a RequestContext containing it. If the read
+// fails or the pre-image doesn't satisfy the request's Preconditions, an error is
+// returned. If a pre-image was already captured, the RequestContext is returned as is.
+func capturePreImage(ctx RequestContext, handler ResourceHandler) (RequestContext, error) {
+	if !readsBeforeWrite(handler) {
+		return ctx, nil
+	}
+	if _, ok := ctx.PreImage(); ok {
+		return ctx, nil
+	}
+
+	version := ctx.Version()
+	resource, err := handler.ReadResource(ctx, ctx.ResourceID(), version)
+	if err != nil {
+		return ctx, err
+	}
+
+	if preconditions := ctx.Preconditions(); preconditions.Conditional() {
+		etag, err := resourceETag(applyOutboundRules(resource, visibleRules(ctx, handler.Rules()),
+			version))
+		if err != nil {
+			return ctx, err
+		}
+		if err := preconditions.Check(etag, time.Time{}); err != nil {
+			return ctx, err
+		}
+	}
+
+	return ctx.setPreImage(resource), nil
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gContext "github.com/gorilla/context"
+	"github.com/stretchr/testify/assert"
+)
+
+type preImageResourceHandler struct {
+	BaseResourceHandler
+	reads    int
+	preImage Resource
+}
+
+func (p *preImageResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (p *preImageResourceHandler) ReadBeforeWrite() bool {
+	return true
+}
+
+func (p *preImageResourceHandler) ReadResource(ctx RequestContext, id,
+	version string) (Resource, error) {
+
+	p.reads++
+	if id == "missing" {
+		return nil, ResourceNotFound("No resource with id missing")
+	}
+	return &diffResource{Foo: "hello", Bar: 1}, nil
+}
+
+func (p *preImageResourceHandler) UpdateResource(ctx RequestContext, id string,
+	data Payload, version string) (Resource, error) {
+
+	p.preImage, _ = ctx.PreImage()
+	return &diffResource{Foo: "hello", Bar: 2}, nil
+}
+
+func (p *preImageResourceHandler) DeleteResource(ctx RequestContext, id,
+	version string) (Resource, error) {
+
+	p.preImage, _ = ctx.PreImage()
+	return p.preImage, nil
+}
+
+// Ensures that the pre-image is read once and exposed to the update handler, which
+// also enables differential responses.
+func TestHandleUpdateReadBeforeWrite(t *testing.T) {
+	assert := assert.New(t)
+	handler := &preImageResourceHandler{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+	updateHandler, _ := api.(*muxAPI).getRouteHandler("foo:update")
+
+	payload := bytes.NewBufferString(`{"bar": 2}`)
+	req, _ := http.NewRequest("PUT", "http://foo.com/api/v1/foo/1?diff=true", payload)
+	resp := httptest.NewRecorder()
+
+	updateHandler.ServeHTTP(resp, req)
+
+	assert.Equal(1, handler.reads)
+	assert.Equal(&diffResource{Foo: "hello", Bar: 1}, handler.preImage)
+	assert.Equal(
+		`{"messages":[],"reason":"OK","result":{"bar":2},"status":200}`,
+		resp.Body.String(),
+		"Incorrect response string",
+	)
+}
+
+// Ensures that the delete handler returns the pre-image read error.
+func TestHandleDeleteReadBeforeWriteNotFound(t *testing.T) {
+	assert := assert.New(t)
+	handler := &preImageResourceHandler{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+	deleteHandler, _ := api.(*muxAPI).getRouteHandler("foo:delete")
+
+	req, _ := http.NewRequest("DELETE", "http://foo.com/api/v1/foo/missing", nil)
+	gContext.Set(req, resourceIDKey, "missing")
+	resp := httptest.NewRecorder()
+
+	deleteHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusNotFound, resp.Code, "Incorrect response code")
+	assert.Nil(handler.preImage)
+}
+
+// Ensures that requests with a stale If-Match header are rejected with a 412.
+func TestHandleDeleteReadBeforeWriteIfMatch(t *testing.T) {
+	assert := assert.New(t)
+	handler := &preImageResourceHandler{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+	deleteHandler, _ := api.(*muxAPI).getRouteHandler("foo:delete")
+
+	req, _ := http.NewRequest("DELETE", "http://foo.com/api/v1/foo/1", nil)
+	req.Header.Set("If-Match", `"stale"`)
+	resp := httptest.NewRecorder()
+
+	deleteHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusPreconditionFailed, resp.Code, "Incorrect response code")
+
+	etag, _ := resourceETag(&diffResource{Foo: "hello", Bar: 1})
+	req, _ = http.NewRequest("DELETE", "http://foo.com/api/v1/foo/1", nil)
+	req.Header.Set("If-Match", `"stale", `+etag)
+	resp = httptest.NewRecorder()
+
+	deleteHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal(&diffResource{Foo: "hello", Bar: 1}, handler.preImage)
+}
@@ -0,0 +1,224 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+const (
+	// protoFormat is the response format requesting protocol buffers.
+	protoFormat = "protobuf"
+
+	// protoContentType is the MIME type of protocol buffer request and response bodies.
+	protoContentType = "application/x-protobuf"
+)
+
+// ProtoMessage is a protocol buffer message, e.g. a generated message wrapped to use
+// proto.Marshal and proto.Unmarshal.
+type ProtoMessage interface {
+	// Marshal encodes the message in the protocol buffer wire format.
+	Marshal() ([]byte, error)
+
+	// Unmarshal decodes the message from the protocol buffer wire format.
+	Unmarshal([]byte) error
+}
+
+// ProtoResourceHandler can be implemented by a ResourceHandler to map its resources to
+// protocol buffer messages. Clients then send and receive protocol buffers by using
+// the application/x-protobuf Content-Type and Accept headers or the "protobuf" format.
+// Single resources are sent as a message and lists as a stream of messages, each
+// prefixed with its varint-encoded length, with pagination in the Link header. Errors
+// are sent as JSON.
+type ProtoResourceHandler interface {
+	// ToProto maps the outbound representation of a Resource, i.e. with outbound Rules
+	// applied, to its message for the given version.
+	ToProto(Resource, string) (ProtoMessage, error)
+
+	// NewProtoMessage returns an empty message for decoding request bodies of the
+	// given version.
+	NewProtoMessage(string) ProtoMessage
+
+	// FromProto maps a decoded request message of the given version to the Payload
+	// passed to the handler. Inbound Rules are applied to it like JSON payloads.
+	FromProto(ProtoMessage, string) (Payload, error)
+}
+
+// protoRequested returns true if the client requested a protocol buffer response.
+func protoRequested(ctx RequestContext) bool {
+	if ctx.ResponseFormat() == protoFormat {
+		return true
+	}
+	return strings.Contains(ctx.Header().Get("Accept"), protoContentType)
+}
+
+// protoRequest returns true if the request body is a protocol buffer.
+func protoRequest(ctx RequestContext) bool {
+	mediaType, _, err := mime.ParseMediaType(ctx.Header().Get("Content-Type"))
+	return err == nil && mediaType == protoContentType
+}
+
+// protoHandler returns the ResourceHandler as a ProtoResourceHandler, or a 415 error if
+// it doesn't map its resources to protocol buffers.
+func protoHandler(handler ResourceHandler) (ProtoResourceHandler, error) {
+	mapper, ok := unwrapResourceHandler(handler).(ProtoResourceHandler)
+	if !ok {
+		return nil, CustomError(fmt.Sprintf("Protocol buffers are not supported for %s",
+			handler.ResourceName()), http.StatusUnsupportedMediaType)
+	}
+	return mapper, nil
+}
+
+// decodeRequestPayload decodes the request body into a Payload, either from a protocol
+// buffer or multipart form if the request is one or from JSON otherwise. A 400 is
+// returned if decoding fails.
+func decodeRequestPayload(ctx RequestContext, handler ResourceHandler, body []byte) (Payload, error) {
+	if multipartRequest(ctx) {
+		return decodeMultipartPayload(ctx, handler)
+	}
+	if !protoRequest(ctx) {
+		data, err := decodePayload(body)
+		if err != nil {
+			return nil, BadRequest(err.Error())
+		}
+		return data, nil
+	}
+
+	mapper, err := protoHandler(handler)
+	if err != nil {
+		return nil, err
+	}
+	return decodeProto(mapper, body, ctx.Version())
+}
+
+// decodeRequestPayloads decodes the request body into a list of Payloads, either from
+// a stream of length-prefixed protocol buffers if the request is one or from a JSON
+// list or object otherwise. A 400 is returned if decoding fails.
+func decodeRequestPayloads(ctx RequestContext, handler ResourceHandler,
+	body []byte) ([]Payload, error) {
+
+	if !protoRequest(ctx) {
+		data, err := decodePayloadSlice(body)
+		if err != nil {
+			var p Payload
+			p, err = decodePayload(body)
+			data = []Payload{p}
+		}
+		if err != nil {
+			return nil, BadRequest(err.Error())
+		}
+		return data, nil
+	}
+
+	mapper, err := protoHandler(handler)
+	if err != nil {
+		return nil, err
+	}
+	data := []Payload{}
+	for len(body) > 0 {
+		size, n := binary.Uvarint(body)
+		if n <= 0 || uint64(len(body)-n) < size {
+			return nil, BadRequest(fmt.Sprintf("Malformed protocol buffer stream at message %d",
+				len(data)))
+		}
+		p, err := decodeProto(mapper, body[n:n+int(size)], ctx.Version())
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, p)
+		body = body[n+int(size):]
+	}
+	return data, nil
+}
+
+// decodeProto unmarshals the protocol buffer message and maps it to a Payload.
+func decodeProto(mapper ProtoResourceHandler, body []byte, version string) (Payload, error) {
+	message := mapper.NewProtoMessage(version)
+	if err := message.Unmarshal(body); err != nil {
+		return nil, BadRequest(fmt.Sprintf("Malformed protocol buffer: %s", err))
+	}
+	data, err := mapper.FromProto(message, version)
+	if err != nil {
+		return nil, BadRequest(err.Error())
+	}
+	return data, nil
+}
+
+// protoSerializer is an implementation of ResponseSerializer which serializes the
+// resources of responses as protocol buffers using a ProtoResourceHandler's mapping.
+type protoSerializer struct {
+	mapper  ProtoResourceHandler
+	version string
+}
+
+// Serialize marshals the resources of a response payload into protocol buffers. Lists
+// are marshaled as a stream of length-prefixed messages.
+func (p protoSerializer) Serialize(payload Payload) ([]byte, error) {
+	list, ok := payload[results]
+	if !ok {
+		return p.marshal(payload[result])
+	}
+
+	stream := []byte{}
+	if list == nil {
+		return stream, nil
+	}
+	v := reflect.ValueOf(list)
+	for i := 0; i < v.Len(); i++ {
+		message, err := p.marshal(v.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		var size [binary.MaxVarintLen64]byte
+		stream = append(stream, size[:binary.PutUvarint(size[:], uint64(len(message)))]...)
+		stream = append(stream, message...)
+	}
+	return stream, nil
+}
+
+// marshal maps the resource to its message and marshals it.
+func (p protoSerializer) marshal(resource Resource) ([]byte, error) {
+	if resource == nil {
+		return nil, errors.New("No resource to marshal")
+	}
+	message, err := p.mapper.ToProto(resource, p.version)
+	if err != nil {
+		return nil, err
+	}
+	return message.Marshal()
+}
+
+// ContentType returns the protocol buffer MIME type of the response.
+func (p protoSerializer) ContentType() string {
+	return protoContentType
+}
+
+// SupportsList returns true since lists are serialized as streams of messages.
+func (p protoSerializer) SupportsList() bool {
+	return true
+}
+
+// SupportsErrors returns false so errors are serialized as JSON.
+func (p protoSerializer) SupportsErrors() bool {
+	return false
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fooMessage is a ProtoMessage with a single string field encoded in the protocol
+// buffer wire format as field 1.
+type fooMessage struct {
+	Foo string
+}
+
+func (f *fooMessage) Marshal() ([]byte, error) {
+	return append([]byte{0x0a, byte(len(f.Foo))}, f.Foo...), nil
+}
+
+func (f *fooMessage) Unmarshal(data []byte) error {
+	if len(data) < 2 || data[0] != 0x0a || int(data[1]) != len(data)-2 {
+		return errors.New("invalid message")
+	}
+	f.Foo = string(data[2:])
+	return nil
+}
+
+type protoResourceHandler struct {
+	BaseResourceHandler
+}
+
+func (p *protoResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (p *protoResourceHandler) ToProto(resource Resource, version string) (ProtoMessage, error) {
+	return &fooMessage{Foo: resource.(*TestResource).Foo}, nil
+}
+
+func (p *protoResourceHandler) NewProtoMessage(version string) ProtoMessage {
+	return &fooMessage{}
+}
+
+func (p *protoResourceHandler) FromProto(message ProtoMessage, version string) (Payload, error) {
+	return Payload{"foo": message.(*fooMessage).Foo}, nil
+}
+
+func (p *protoResourceHandler) CreateResource(ctx RequestContext, data Payload,
+	version string) (Resource, error) {
+
+	return &TestResource{Foo: strings.ToUpper(data["foo"].(string))}, nil
+}
+
+func (p *protoResourceHandler) ReadResourceList(ctx RequestContext, limit int,
+	cursor string, version string) ([]Resource, string, error) {
+
+	return []Resource{&TestResource{Foo: "a"}, &TestResource{Foo: "bc"}}, "next", nil
+}
+
+func (p *protoResourceHandler) UpdateResourceList(ctx RequestContext, data []Payload,
+	version string) ([]Resource, error) {
+
+	resources := []Resource{}
+	for _, payload := range data {
+		resources = append(resources, &TestResource{Foo: payload["foo"].(string)})
+	}
+	return resources, nil
+}
+
+// Ensures that resources are decoded from and encoded to protocol buffers using the
+// handler's mapping.
+func TestProtoCreate(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&protoResourceHandler{})
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo",
+		bytes.NewReader([]byte{0x0a, 0x02, 'h', 'i'}))
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Accept", "application/x-protobuf")
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusCreated, resp.Code, "Incorrect response code")
+	assert.Equal("application/x-protobuf", resp.Header().Get("Content-Type"))
+	assert.Equal([]byte{0x0a, 0x02, 'H', 'I'}, resp.Body.Bytes())
+}
+
+// Ensures that lists are encoded as streams of length-prefixed messages with the next
+// page in the Link header.
+func TestProtoReadList(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&protoResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo?format=protobuf", nil)
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal([]byte{3, 0x0a, 1, 'a', 4, 0x0a, 2, 'b', 'c'}, resp.Body.Bytes())
+	assert.Contains(resp.Header().Get("Link"),
+		`<http://foo.com/api/v1/foo?format=protobuf&next=next>; rel="next"`)
+}
+
+// Ensures that streams of length-prefixed messages are decoded for list updates and
+// malformed streams are rejected.
+func TestProtoUpdateList(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&protoResourceHandler{})
+
+	req, _ := http.NewRequest("PUT", "http://foo.com/api/v1/foo",
+		bytes.NewReader([]byte{3, 0x0a, 1, 'a', 4, 0x0a, 2, 'b', 'c'}))
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal(
+		`{"messages":[],"reason":"OK","results":[{"foo":"a"},{"foo":"bc"}],"status":200}`,
+		resp.Body.String(),
+		"Incorrect response string",
+	)
+
+	req, _ = http.NewRequest("PUT", "http://foo.com/api/v1/foo",
+		bytes.NewReader([]byte{3, 0x0a, 1, 'a', 9, 0x0a}))
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	resp = httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusBadRequest, resp.Code, "Incorrect response code")
+	assert.Equal(
+		`{"messages":["Malformed protocol buffer stream at message 1"],"reason":"Bad Request","status":400}`,
+		resp.Body.String(),
+		"Incorrect response string",
+	)
+}
+
+// Ensures that protocol buffers are rejected for resources without a mapping.
+func TestProtoUnsupported(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&statusResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo", nil)
+	req.Header.Set("Accept", "application/x-protobuf")
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusNotAcceptable, resp.Code, "Incorrect response code")
+	assert.Equal("application/json", resp.Header().Get("Content-Type"))
+
+	req, _ = http.NewRequest("POST", "http://foo.com/api/v1/foo",
+		bytes.NewReader([]byte{0x0a, 0x00}))
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	resp = httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusUnsupportedMediaType, resp.Code, "Incorrect response code")
+}
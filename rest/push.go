@@ -0,0 +1,120 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// pushedHeaders are the request headers forwarded to the requests promised by HTTP/2
+// server pushes, so they're authenticated and negotiated like the parent request.
+var pushedHeaders = []string{"Authorization", "Cookie", "Accept", "Accept-Language"}
+
+// PushResourceHandler can be implemented by a LinkedResourceHandler to push the
+// responses of related resources to HTTP/2 clients along with responses reading a
+// single resource, saving clients the round trips of requesting them once they've
+// received the links. Pushed requests are handled like any other, including
+// authentication with the parent request's Authorization and Cookie headers. Clients
+// which don't support server push, or have it disabled, are sent the links alone.
+type PushResourceHandler interface {
+	// PushRelations returns the names of the relations, as returned by Relations,
+	// whose related resources are pushed.
+	PushRelations() []string
+}
+
+// pushRelations pushes the endpoints of the relations of the resource read by the
+// handler which it pushes, if the client supports HTTP/2 server push. It must be
+// called before the response is written.
+func (h requestHandler) pushRelations(ctx RequestContext, handler ResourceHandler,
+	resource Resource) {
+
+	pusher, ok := ctx.ResponseWriter().(http.Pusher)
+	if !ok {
+		return
+	}
+	pushing, ok := unwrapResourceHandler(handler).(PushResourceHandler)
+	if !ok {
+		return
+	}
+	linked, ok := unwrapResourceHandler(handler).(LinkedResourceHandler)
+	if !ok {
+		return
+	}
+
+	relations := linked.Relations()
+	pushed := map[string]Relation{}
+	for _, name := range pushing.PushRelations() {
+		if relation, ok := relations[name]; ok {
+			pushed[name] = relation
+		}
+	}
+	links := map[string]string{}
+	addRelationLinks(ctx, links, pushed, resource)
+	if len(links) == 0 {
+		return
+	}
+
+	options := &http.PushOptions{Header: pushHeader(ctx)}
+	for name, link := range links {
+		target, err := pushTarget(link)
+		if err != nil {
+			continue
+		}
+		if err := pusher.Push(target, options); err != nil {
+			if err == http.ErrNotSupported {
+				// The client disabled server push or this is a pushed request.
+				return
+			}
+			h.logf("Failed to push %s relation %s: %s", handler.ResourceName(), name, err)
+		}
+	}
+}
+
+// pushHeader returns the headers of the request forwarded to the requests it promises
+// with server pushes.
+func pushHeader(ctx RequestContext) http.Header {
+	header := http.Header{}
+	r, ok := ctx.Request()
+	if !ok {
+		return header
+	}
+	names := pushedHeaders
+	config := ctx.configuration()
+	if config.VersionHeader != "" {
+		names = append(names[:len(names):len(names)], config.VersionHeader)
+	}
+	if config.Tenancy != nil && config.Tenancy.Source == TenantHeader {
+		names = append(names[:len(names):len(names)], config.Tenancy.header())
+	}
+	for _, name := range names {
+		if values, ok := r.Header[http.CanonicalHeaderKey(name)]; ok {
+			header[http.CanonicalHeaderKey(name)] = values
+		}
+	}
+	return header
+}
+
+// pushTarget returns the path and query of the link, which is pushed to the same
+// server as the parent request even if links are built with an ExternalBaseURL.
+func pushTarget(link string) (string, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", err
+	}
+	return u.RequestURI(), nil
+}
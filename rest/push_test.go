@@ -0,0 +1,142 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type pushResourceHandler struct {
+	linkedResourceHandler
+}
+
+func (p *pushResourceHandler) PushRelations() []string {
+	return []string{"owner", "missing", "unknown"}
+}
+
+type pushRecorder struct {
+	*httptest.ResponseRecorder
+	targets []string
+	headers []http.Header
+	err     error
+}
+
+func (p *pushRecorder) Push(target string, opts *http.PushOptions) error {
+	p.targets = append(p.targets, target)
+	p.headers = append(p.headers, opts.Header)
+	return p.err
+}
+
+// Ensures that read responses push the related resources of the relations the
+// ResourceHandler pushes, forwarding the request's credentials.
+func TestPushRelations(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&pushResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/1", nil)
+	req.Header.Set("Authorization", "Bearer abc")
+	req.Header.Set("X-Other", "bar")
+	resp := &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal([]string{"/api/v1/foo/7"}, resp.targets)
+	assert.Equal(http.Header{"Authorization": {"Bearer abc"}}, resp.headers[0])
+}
+
+// Ensures that the version and tenant headers are forwarded to pushed requests when
+// they identify the version and tenant.
+func TestPushRelationsHeaders(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{
+		VersionHeader: "X-Version",
+		Tenancy:       &Tenancy{Source: TenantHeader},
+	})
+	api.RegisterResourceHandler(&pushResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/1", nil)
+	req.Header.Set("X-Version", "1")
+	req.Header.Set("X-Tenant-ID", "acme")
+	resp := &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+
+	api.ServeHTTP(resp, req)
+
+	if assert.Len(resp.headers, 1) {
+		assert.Equal("1", resp.headers[0].Get("X-Version"))
+		assert.Equal("acme", resp.headers[0].Get("X-Tenant-ID"))
+	}
+}
+
+// Ensures that the response is sent if the client doesn't accept pushes.
+func TestPushRelationsNotSupported(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&pushResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/1", nil)
+	resp := &pushRecorder{
+		ResponseRecorder: httptest.NewRecorder(),
+		err:              http.ErrNotSupported,
+	}
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal(
+		`{"messages":[],"reason":"OK","result":{"id":"1","owner_id":7},"status":200}`,
+		resp.Body.String(),
+	)
+}
+
+// Ensures that nothing is pushed for ResourceHandlers which don't push relations.
+func TestPushRelationsNotPushed(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&linkedResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/1", nil)
+	resp := &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Empty(resp.targets)
+}
+
+// Ensures that ResponseWriter wrappers delegate pushes to the wrapped ResponseWriter
+// and report when it doesn't support them.
+func TestResponseWriterPush(t *testing.T) {
+	assert := assert.New(t)
+	recorder := &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	opts := &http.PushOptions{}
+
+	assert.Nil((&statusResponseWriter{ResponseWriter: recorder}).Push("/a", opts))
+	assert.Nil((&accountingResponseWriter{ResponseWriter: recorder}).Push("/b", opts))
+	assert.Equal([]string{"/a", "/b"}, recorder.targets)
+
+	plain := httptest.NewRecorder()
+	assert.Equal(http.ErrNotSupported,
+		(&statusResponseWriter{ResponseWriter: plain}).Push("/a", opts))
+	assert.Equal(http.ErrNotSupported,
+		(&accountingResponseWriter{ResponseWriter: plain}).Push("/b", opts))
+}
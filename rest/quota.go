@@ -0,0 +1,488 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultQuotaWindow is the length of quota windows when a Quota doesn't have one.
+	defaultQuotaWindow = time.Hour
+
+	// defaultQuotaScope is the scope of the default Quota, which applies across all
+	// resources.
+	defaultQuotaScope = "*"
+
+	// usageResource is the name of the resource reporting quota usage.
+	usageResource = "usage"
+
+	// memoryQuotaStoreSweepInterval is the minimum interval between removals of expired
+	// usage from a MemoryQuotaStore.
+	memoryQuotaStoreSweepInterval = time.Minute
+)
+
+// Headers reporting the quota usage of the client to responses.
+const (
+	quotaLimitHeader          = "X-RateLimit-Limit"
+	quotaRemainingHeader      = "X-RateLimit-Remaining"
+	quotaResetHeader          = "X-RateLimit-Reset"
+	quotaBytesLimitHeader     = "X-RateLimit-Bytes-Limit"
+	quotaBytesRemainingHeader = "X-RateLimit-Bytes-Remaining"
+)
+
+// quotaHeaders are the headers reporting quota usage, which describe a single response
+// and aren't cached.
+var quotaHeaders = []string{
+	quotaLimitHeader,
+	quotaRemainingHeader,
+	quotaResetHeader,
+	quotaBytesLimitHeader,
+	quotaBytesRemainingHeader,
+}
+
+// Quota limits the number of requests a client makes and the bandwidth it uses, i.e.
+// the size of its request and response bodies, over fixed windows of time.
+type Quota struct {
+	// Requests is the maximum number of requests per window. Zero is unlimited.
+	Requests int64
+
+	// Bytes is the maximum number of request and response body bytes per window. A
+	// request is rejected once the limit is reached, so the request which crosses it is
+	// served. Zero is unlimited.
+	Bytes int64
+
+	// Window is the length of the windows, which start at multiples of it since the
+	// Unix epoch. If zero, windows are an hour long.
+	Window time.Duration
+}
+
+// limited returns true if the Quota limits requests or bandwidth.
+func (q Quota) limited() bool {
+	return q.Requests > 0 || q.Bytes > 0
+}
+
+// window returns the length of the Quota's windows.
+func (q Quota) window() time.Duration {
+	if q.Window > 0 {
+		return q.Window
+	}
+	return defaultQuotaWindow
+}
+
+// Quotas configures the quotas enforced on clients, identified by their tenant and
+// identity or API key, or by their address if they don't have one. Requests exceeding a quota are rejected with 429 Too Many Requests and a
+// Retry-After header for when the window resets. Responses report the client's usage
+// with X-RateLimit-Limit, X-RateLimit-Remaining, and X-RateLimit-Reset headers, along
+// with X-RateLimit-Bytes-Limit and X-RateLimit-Bytes-Remaining for bandwidth, which
+// describe the quota closest to being exhausted.
+type Quotas struct {
+	// Default is the quota of each client across all resources.
+	Default Quota
+
+	// Routes adds quotas for resources, keyed by resource name, e.g. "widgets", and
+	// operations, keyed by route name, e.g. "widgets:create", which are counted
+	// separately from the Default quota. Requests must be within all of them.
+	Routes map[string]Quota
+
+	// Identity returns the identity of the client making the request, e.g. an API key.
+	// If nil, the ID of the request's Identity is used, falling back to a SHA-256 hash
+	// of the Authorization header so credentials aren't stored. Requests with an empty
+	// identity are identified by the host of their remote address, and requests
+	// without one, e.g. those performed with API#Dispatch, share a quota.
+	Identity func(*http.Request) string
+
+	// Store stores usage, and can be shared by API instances so quotas apply across
+	// them. If nil, usage is stored in memory.
+	Store QuotaStore
+}
+
+// identity returns the identity of the client making the request, falling back to its
+// address so anonymous clients can't bypass quotas.
+func (q *Quotas) identity(r *http.Request) string {
+	if q.Identity != nil {
+		if identity := q.Identity(r); identity != "" {
+			return identity
+		}
+	} else if identity := requestIdentity(r); identity != nil && identity.ID != "" {
+		return identity.ID
+	} else if authorization := r.Header.Get("Authorization"); authorization != "" {
+		sum := sha256.Sum256([]byte(authorization))
+		return "authorization:" + hex.EncodeToString(sum[:])
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return "address:" + host
+	}
+	return "address:" + r.RemoteAddr
+}
+
+// scopes returns the scopes of the quotas which apply to requests of the route of the
+// resource, ordered from broadest to narrowest.
+func (q *Quotas) scopes(resource, route string) []string {
+	var scopes []string
+	if q.Default.limited() {
+		scopes = append(scopes, defaultQuotaScope)
+	}
+	for _, scope := range []string{resource, route} {
+		if quota, ok := q.Routes[scope]; ok && quota.limited() {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// allScopes returns the scopes of all configured quotas in order.
+func (q *Quotas) allScopes() []string {
+	var scopes []string
+	for scope, quota := range q.Routes {
+		if quota.limited() {
+			scopes = append(scopes, scope)
+		}
+	}
+	sort.Strings(scopes)
+	if q.Default.limited() {
+		scopes = append([]string{defaultQuotaScope}, scopes...)
+	}
+	return scopes
+}
+
+// quota returns the Quota of the scope.
+func (q *Quotas) quota(scope string) Quota {
+	if scope == defaultQuotaScope {
+		return q.Default
+	}
+	return q.Routes[scope]
+}
+
+// QuotaStore stores the usage counted against quotas. Usage is stored under keys
+// identifying the client, quota, and window. Implementations must be safe for
+// concurrent use. Errors returned by a QuotaStore are logged and the request is served
+// without enforcing the quota.
+type QuotaStore interface {
+	// Add adds the requests and bytes to the usage stored under the key and returns the
+	// updated usage. The usage can be discarded once the expiration time passes.
+	Add(key string, requests, bytes int64, expires time.Time) (int64, int64, error)
+
+	// Get returns the requests and bytes stored under the key, or zeros if there's no
+	// usage.
+	Get(key string) (int64, int64, error)
+}
+
+// memoryQuotaUsage is the usage stored under a key in a MemoryQuotaStore.
+type memoryQuotaUsage struct {
+	requests int64
+	bytes    int64
+	expires  time.Time
+}
+
+// MemoryQuotaStore is a QuotaStore which stores usage in memory. Expired usage is
+// removed periodically. It's safe for concurrent use.
+type MemoryQuotaStore struct {
+	mu    sync.Mutex
+	usage map[string]memoryQuotaUsage
+	swept time.Time
+}
+
+// NewMemoryQuotaStore returns a newly allocated MemoryQuotaStore.
+func NewMemoryQuotaStore() *MemoryQuotaStore {
+	return &MemoryQuotaStore{usage: map[string]memoryQuotaUsage{}}
+}
+
+// Add adds the requests and bytes to the usage stored under the key and returns the
+// updated usage.
+func (m *MemoryQuotaStore) Add(key string, requests, bytes int64,
+	expires time.Time) (int64, int64, error) {
+
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if now.Sub(m.swept) > memoryQuotaStoreSweepInterval {
+		m.sweep(now)
+	}
+	usage, ok := m.usage[key]
+	if !ok || now.After(usage.expires) {
+		usage = memoryQuotaUsage{}
+	}
+	usage.requests += requests
+	usage.bytes += bytes
+	usage.expires = expires
+	m.usage[key] = usage
+	return usage.requests, usage.bytes, nil
+}
+
+// Get returns the requests and bytes stored under the key if they haven't expired.
+func (m *MemoryQuotaStore) Get(key string) (int64, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	usage, ok := m.usage[key]
+	if !ok || time.Now().After(usage.expires) {
+		return 0, 0, nil
+	}
+	return usage.requests, usage.bytes, nil
+}
+
+// sweep removes expired usage. The mutex must be held.
+func (m *MemoryQuotaStore) sweep(now time.Time) {
+	for key, usage := range m.usage {
+		if now.After(usage.expires) {
+			delete(m.usage, key)
+		}
+	}
+	m.swept = now
+}
+
+// quotaWindow identifies the current window of a client's quota and its usage.
+type quotaWindow struct {
+	scope    string
+	quota    Quota
+	key      string
+	reset    time.Time
+	requests int64
+	bytes    int64
+}
+
+// newQuotaWindow returns the window of the quota of the tenant's client for the scope
+// containing the time. The tenant is empty if Tenancy isn't configured.
+func newQuotaWindow(quotas *Quotas, tenant, identity, scope string,
+	now time.Time) quotaWindow {
+
+	quota := quotas.quota(scope)
+	start := now.Truncate(quota.window())
+	return quotaWindow{
+		scope: scope,
+		quota: quota,
+		key:   fmt.Sprintf("%s\x00%s\x00%s\x00%d", tenant, identity, scope, start.Unix()),
+		reset: start.Add(quota.window()),
+	}
+}
+
+// add adds the requests and bytes to the usage of the window in the QuotaStore and
+// updates the window's usage.
+func (w *quotaWindow) add(store QuotaStore, requests, bytes int64) error {
+	used, usedBytes, err := store.Add(w.key, requests, bytes, w.reset)
+	if err != nil {
+		return err
+	}
+	w.requests, w.bytes = used, usedBytes
+	return nil
+}
+
+// exceeded returns true if the usage has exceeded the quota.
+func (w quotaWindow) exceeded() bool {
+	return (w.quota.Requests > 0 && w.requests > w.quota.Requests) ||
+		(w.quota.Bytes > 0 && w.bytes >= w.quota.Bytes)
+}
+
+// remaining returns the fraction of the quota remaining, which is used to find the
+// quota closest to being exhausted.
+func (w quotaWindow) remaining() float64 {
+	remaining := 1.0
+	if w.quota.Requests > 0 {
+		remaining = float64(w.quota.Requests-w.requests) / float64(w.quota.Requests)
+	}
+	if w.quota.Bytes > 0 {
+		if r := float64(w.quota.Bytes-w.bytes) / float64(w.quota.Bytes); r < remaining {
+			remaining = r
+		}
+	}
+	return remaining
+}
+
+// setHeaders sets the headers reporting the usage of the quota to the response.
+func (w quotaWindow) setHeaders(header http.Header, now time.Time) {
+	if w.quota.Requests > 0 {
+		header.Set(quotaLimitHeader, strconv.FormatInt(w.quota.Requests, 10))
+		header.Set(quotaRemainingHeader, quotaRemaining(w.quota.Requests, w.requests))
+	}
+	if w.quota.Bytes > 0 {
+		header.Set(quotaBytesLimitHeader, strconv.FormatInt(w.quota.Bytes, 10))
+		header.Set(quotaBytesRemainingHeader, quotaRemaining(w.quota.Bytes, w.bytes))
+	}
+	header.Set(quotaResetHeader, retryAfterSeconds(w.reset.Sub(now)))
+}
+
+// quotaRemaining returns the header value of the amount remaining of the limit after
+// the usage, which is never negative.
+func quotaRemaining(limit, used int64) string {
+	if used > limit {
+		return "0"
+	}
+	return strconv.FormatInt(limit-used, 10)
+}
+
+// countingReadCloser wraps an io.ReadCloser to count the bytes read from it.
+type countingReadCloser struct {
+	io.ReadCloser
+	read int64
+}
+
+// Read reads from the wrapped io.ReadCloser, counting the bytes read.
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.read += int64(n)
+	return n, err
+}
+
+// newQuotaStore returns the QuotaStore of the Quotas, or a newly allocated
+// MemoryQuotaStore if one isn't configured.
+func newQuotaStore(quotas *Quotas) QuotaStore {
+	if quotas.Store != nil {
+		return quotas.Store
+	}
+	return NewMemoryQuotaStore()
+}
+
+// newQuotaMiddleware returns a RequestMiddleware which enforces the Quotas on requests
+// to the resource. The request is counted against each quota which applies to it
+// before it's handled, and rejected with 429 Too Many Requests if any is exceeded. The
+// bytes of the request and response bodies are counted once the response is sent.
+func newQuotaMiddleware(quotas *Quotas, store QuotaStore, h *requestHandler,
+	resource string) RequestMiddleware {
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity := quotas.identity(r)
+			scopes := quotas.scopes(resource, routeName(r))
+			if len(scopes) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			now := time.Now()
+			var windows []quotaWindow
+			reported, exceeded := -1, -1
+			for _, scope := range scopes {
+				window := newQuotaWindow(quotas, resolvedTenant(r), identity, scope, now)
+				if err := window.add(store, 1, 0); err != nil {
+					h.logf("Failed to count request against %s quota: %s", scope, err)
+					continue
+				}
+				windows = append(windows, window)
+				if exceeded < 0 && window.exceeded() {
+					exceeded = len(windows) - 1
+				}
+				if reported < 0 || window.remaining() < windows[reported].remaining() {
+					reported = len(windows) - 1
+				}
+			}
+			if reported >= 0 {
+				windows[reported].setHeaders(w.Header(), now)
+			}
+			if exceeded >= 0 {
+				window := windows[exceeded]
+				h.sendResponse(h.newContext(w, r).setError(TooManyRequests(
+					fmt.Sprintf("Quota exceeded for %s", window.scope)).
+					WithRetryAfter(window.reset.Sub(now))))
+				return
+			}
+
+			body := &countingReadCloser{ReadCloser: r.Body}
+			if r.Body != nil {
+				r.Body = body
+			}
+			writer := &accountingResponseWriter{ResponseWriter: w}
+			next.ServeHTTP(writer, r)
+
+			if used := body.read + writer.written; used > 0 {
+				for _, window := range windows {
+					if err := window.add(store, 0, used); err != nil {
+						h.logf("Failed to count bytes against %s quota: %s", window.scope,
+							err)
+					}
+				}
+			}
+		})
+	}
+}
+
+// QuotaUsage describes a client's usage of a quota in the current window.
+type QuotaUsage struct {
+	// Scope is the resource or route name of the quota, or "*" for the default quota.
+	Scope string `json:"scope"`
+
+	// Requests and Bytes are the usage in the current window.
+	Requests int64 `json:"requests"`
+	Bytes    int64 `json:"bytes"`
+
+	// RequestLimit and ByteLimit are the limits of the quota, or zero if unlimited.
+	RequestLimit int64 `json:"request_limit"`
+	ByteLimit    int64 `json:"byte_limit"`
+
+	// Reset is when the current window ends.
+	Reset time.Time `json:"reset"`
+}
+
+// usageResourceHandler is the ResourceHandler used to report the quota usage of the
+// client making the request.
+type usageResourceHandler struct {
+	BaseResourceHandler
+	quotas *Quotas
+	store  QuotaStore
+	logf   func(string, ...interface{})
+}
+
+// ResourceName returns "usage".
+func (u *usageResourceHandler) ResourceName() string {
+	return usageResource
+}
+
+// ReadResourceList returns the client's usage of each configured quota.
+func (u *usageResourceHandler) ReadResourceList(ctx RequestContext, limit int,
+	cursor string, version string) ([]Resource, string, error) {
+
+	req, ok := ctx.Request()
+	if !ok {
+		return nil, "", InternalServerError("Request not available")
+	}
+	identity := u.quotas.identity(req)
+
+	now := time.Now()
+	var resources []Resource
+	for _, scope := range u.quotas.allScopes() {
+		window := newQuotaWindow(u.quotas, resolvedTenant(req), identity, scope, now)
+		requests, bytes, err := u.store.Get(window.key)
+		if err != nil {
+			u.logf("Failed to get usage of %s quota: %s", scope, err)
+			return nil, "", InternalServerError("Usage is unavailable")
+		}
+		resources = append(resources, &QuotaUsage{
+			Scope:        scope,
+			Requests:     requests,
+			Bytes:        bytes,
+			RequestLimit: window.quota.Requests,
+			ByteLimit:    window.quota.Bytes,
+			Reset:        window.reset,
+		})
+	}
+	return resources, "", nil
+}
+
+// isUsageResourceHandler returns true if the ResourceHandler reports quota usage, which
+// isn't counted against quotas.
+func isUsageResourceHandler(h ResourceHandler) bool {
+	_, ok := unwrapResourceHandler(h).(*usageResourceHandler)
+	return ok
+}
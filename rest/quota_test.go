@@ -0,0 +1,283 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type quotaResourceHandler struct {
+	BaseResourceHandler
+}
+
+func (q *quotaResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (q *quotaResourceHandler) ReadResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	return map[string]string{"id": id, "name": "a fairly long name"}, nil
+}
+
+func (q *quotaResourceHandler) CreateResource(ctx RequestContext, data Payload,
+	version string) (Resource, error) {
+
+	return data, nil
+}
+
+// serveQuota performs a request against the API as the client with the Authorization
+// header and returns the response.
+func serveQuota(api API, method, url, client string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, url, strings.NewReader(`{"id":"1"}`))
+	if client != "" {
+		req.Header.Set("Authorization", client)
+	}
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	return resp
+}
+
+// Ensures that clients exceeding the default request quota are rejected with 429 and
+// that their usage is reported in response headers.
+func TestQuotaRequests(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{Quotas: &Quotas{Default: Quota{Requests: 2}}})
+	api.RegisterResourceHandler(&quotaResourceHandler{})
+	url := "http://foo.com/api/v1/foo/1"
+
+	resp := serveQuota(api, "GET", url, "alice")
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal("2", resp.Header().Get("X-RateLimit-Limit"))
+	assert.Equal("1", resp.Header().Get("X-RateLimit-Remaining"))
+	assert.NotEmpty(resp.Header().Get("X-RateLimit-Reset"))
+
+	resp = serveQuota(api, "GET", url, "alice")
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal("0", resp.Header().Get("X-RateLimit-Remaining"))
+
+	resp = serveQuota(api, "GET", url, "alice")
+	assert.Equal(http.StatusTooManyRequests, resp.Code)
+	assert.Equal("0", resp.Header().Get("X-RateLimit-Remaining"))
+	assert.NotEmpty(resp.Header().Get("Retry-After"))
+	assert.Contains(resp.Body.String(), "Quota exceeded for *")
+
+	assert.Equal(http.StatusOK, serveQuota(api, "GET", url, "bob").Code)
+}
+
+// Ensures that clients without an identity are limited by their address.
+func TestQuotaAnonymous(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{Quotas: &Quotas{Default: Quota{Requests: 1}}})
+	api.RegisterResourceHandler(&quotaResourceHandler{})
+	serve := func(addr string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/1", nil)
+		req.RemoteAddr = addr
+		resp := httptest.NewRecorder()
+		api.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := serve("10.0.0.1:1234")
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal("1", resp.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(http.StatusTooManyRequests, serve("10.0.0.1:5678").Code)
+	assert.Equal(http.StatusOK, serve("10.0.0.2:1234").Code)
+}
+
+// Ensures that the quotas of tenants are counted separately.
+func TestQuotaTenants(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{
+		Quotas:  &Quotas{Default: Quota{Requests: 1}},
+		Tenancy: &Tenancy{Source: TenantHeader},
+	})
+	api.RegisterResourceHandler(&quotaResourceHandler{})
+	serve := func(tenant string) int {
+		req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/1", nil)
+		req.Header.Set("Authorization", "alice")
+		req.Header.Set("X-Tenant-ID", tenant)
+		resp := httptest.NewRecorder()
+		api.ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	assert.Equal(http.StatusOK, serve("a"))
+	assert.Equal(http.StatusTooManyRequests, serve("a"))
+	assert.Equal(http.StatusOK, serve("b"))
+}
+
+// Ensures that responses served from the Cache report the client's current usage
+// rather than the usage when they were cached.
+func TestQuotaCachedResponses(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{
+		Quotas:   &Quotas{Default: Quota{Requests: 5}},
+		Cache:    NewMemoryCache(),
+		CacheTTL: time.Minute,
+	})
+	api.RegisterResourceHandler(&quotaResourceHandler{})
+	url := "http://foo.com/api/v1/foo/1"
+
+	resp := serveQuota(api, "GET", url, "alice")
+	assert.Equal("MISS", resp.Header().Get(cacheHeader))
+	assert.Equal("4", resp.Header().Get("X-RateLimit-Remaining"))
+
+	resp = serveQuota(api, "GET", url, "alice")
+	assert.Equal("HIT", resp.Header().Get(cacheHeader))
+	assert.Equal("3", resp.Header().Get("X-RateLimit-Remaining"))
+}
+
+// Ensures that route quotas only apply to requests of their resource or operation and
+// are counted separately from the default quota.
+func TestQuotaRoutes(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{Quotas: &Quotas{
+		Default: Quota{Requests: 10},
+		Routes:  map[string]Quota{"foo:create": {Requests: 1}},
+	}})
+	api.RegisterResourceHandler(&quotaResourceHandler{})
+
+	resp := serveQuota(api, "POST", "http://foo.com/api/v1/foo", "alice")
+	assert.Equal(http.StatusCreated, resp.Code)
+	assert.Equal("1", resp.Header().Get("X-RateLimit-Limit"))
+	assert.Equal("0", resp.Header().Get("X-RateLimit-Remaining"))
+
+	resp = serveQuota(api, "POST", "http://foo.com/api/v1/foo", "alice")
+	assert.Equal(http.StatusTooManyRequests, resp.Code)
+	assert.Contains(resp.Body.String(), "Quota exceeded for foo:create")
+
+	resp = serveQuota(api, "GET", "http://foo.com/api/v1/foo/1", "alice")
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal("10", resp.Header().Get("X-RateLimit-Limit"))
+	assert.Equal("7", resp.Header().Get("X-RateLimit-Remaining"))
+}
+
+// Ensures that the bytes of request and response bodies are counted against bandwidth
+// quotas.
+func TestQuotaBytes(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{Quotas: &Quotas{Default: Quota{Bytes: 250}}})
+	api.RegisterResourceHandler(&quotaResourceHandler{})
+	url := "http://foo.com/api/v1/foo/1"
+
+	resp := serveQuota(api, "GET", url, "alice")
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal("250", resp.Header().Get("X-RateLimit-Bytes-Limit"))
+	assert.Equal("250", resp.Header().Get("X-RateLimit-Bytes-Remaining"))
+	assert.Empty(resp.Header().Get("X-RateLimit-Limit"))
+	used := int64(resp.Body.Len() + len(`{"id":"1"}`))
+
+	resp = serveQuota(api, "GET", url, "alice")
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal(strconv.FormatInt(250-used, 10), resp.Header().Get("X-RateLimit-Bytes-Remaining"))
+
+	serveQuota(api, "GET", url, "alice")
+	resp = serveQuota(api, "GET", url, "alice")
+	assert.Equal(http.StatusTooManyRequests, resp.Code)
+	assert.Equal("0", resp.Header().Get("X-RateLimit-Bytes-Remaining"))
+}
+
+// Ensures that the usage resource reports the client's usage of each quota without
+// counting against them, and can't be registered without Quotas.
+func TestRegisterUsageResource(t *testing.T) {
+	assert := assert.New(t)
+	assert.Error(NewAPI(&Configuration{}).RegisterUsageResource())
+
+	api := NewAPI(&Configuration{Quotas: &Quotas{
+		Default: Quota{Requests: 10, Window: time.Minute},
+		Routes:  map[string]Quota{"foo": {Bytes: 1000}},
+	}})
+	api.RegisterResourceHandler(&quotaResourceHandler{})
+	assert.NoError(api.RegisterUsageResource())
+
+	serveQuota(api, "GET", "http://foo.com/api/v1/foo/1", "alice")
+	serveQuota(api, "GET", "http://foo.com/api/v1/usage", "alice")
+	resp := serveQuota(api, "GET", "http://foo.com/api/v1/usage", "alice")
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Empty(resp.Header().Get("X-RateLimit-Limit"))
+
+	var body struct {
+		Result []QuotaUsage `json:"results"`
+	}
+	if assert.NoError(json.Unmarshal(resp.Body.Bytes(), &body)) &&
+		assert.Len(body.Result, 2) {
+
+		assert.Equal("*", body.Result[0].Scope)
+		assert.Equal(int64(1), body.Result[0].Requests)
+		assert.Equal(int64(10), body.Result[0].RequestLimit)
+		assert.True(body.Result[0].Reset.After(time.Now()))
+		assert.Equal("foo", body.Result[1].Scope)
+		assert.True(body.Result[1].Bytes > 0)
+		assert.Equal(int64(1000), body.Result[1].ByteLimit)
+	}
+
+}
+
+// Ensures that MemoryQuotaStore accumulates usage under a key until it expires.
+func TestMemoryQuotaStore(t *testing.T) {
+	assert := assert.New(t)
+	store := NewMemoryQuotaStore()
+	expires := time.Now().Add(time.Minute)
+
+	requests, bytes, err := store.Add("a", 1, 10, expires)
+	assert.NoError(err)
+	assert.Equal(int64(1), requests)
+	assert.Equal(int64(10), bytes)
+
+	requests, bytes, _ = store.Add("a", 1, 5, expires)
+	assert.Equal(int64(2), requests)
+	assert.Equal(int64(15), bytes)
+
+	requests, bytes, _ = store.Get("a")
+	assert.Equal(int64(2), requests)
+	assert.Equal(int64(15), bytes)
+
+	store.Add("b", 1, 0, time.Now().Add(-time.Second))
+	requests, _, _ = store.Get("b")
+	assert.Equal(int64(0), requests)
+	requests, _, _ = store.Add("b", 1, 0, expires)
+	assert.Equal(int64(1), requests)
+}
+
+// Ensures that clients without an Identity are identified by a hash of their
+// Authorization header rather than the credentials themselves.
+func TestQuotasIdentityAuthorization(t *testing.T) {
+	assert := assert.New(t)
+	quotas := &Quotas{}
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	assert.Equal("address:10.0.0.1", quotas.identity(req))
+
+	req.Header.Set("Authorization", "Bearer secret")
+	identity := quotas.identity(req)
+	assert.NotContains(identity, "secret")
+	assert.Equal(identity, quotas.identity(req))
+
+	other, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo", nil)
+	other.Header.Set("Authorization", "Bearer other")
+	assert.NotEqual(identity, quotas.identity(other))
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Content is a file-style Resource, e.g. a document or export, whose body is sent to
+// clients as is rather than serialized. ResourceHandlers return it from ReadResource.
+// Range requests are honored, responding with 206 Partial Content and the requested
+// byte ranges, so downloads can be resumed. Outbound Rules aren't applied to Content.
+type Content struct {
+	// Name is the name of the content, e.g. "report.csv". If ContentType is empty, the
+	// content type is detected from its extension or, failing that, the body.
+	Name string
+
+	// ContentType is the media type of the body.
+	ContentType string
+
+	// ModTime is when the content was last modified. If it's not the zero time, it's
+	// sent in the Last-Modified header and used to evaluate conditional requests.
+	ModTime time.Time
+
+	// ETag is the entity tag of the content. Clients resuming downloads send it in
+	// If-Range so they don't receive ranges of changed content.
+	ETag string
+
+	// Body is the content. If it's also an io.Closer, it's closed once the response is
+	// sent.
+	Body io.ReadSeeker
+}
+
+// sendContent writes the Content to the client, honoring Range and conditional
+// request headers.
+func sendContent(ctx RequestContext, content *Content) {
+	if closer, ok := content.Body.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	w := ctx.ResponseWriter()
+	applyResponseHeaders(ctx)
+	if content.ContentType != "" {
+		w.Header().Set("Content-Type", content.ContentType)
+	}
+	if content.ETag != "" {
+		w.Header().Set("ETag", content.ETag)
+	}
+
+	req, _ := ctx.Request()
+	http.ServeContent(w, req, content.Name, content.ModTime, content.Body)
+}
+
+// rangeable returns true if the response to the request can be sent as byte ranges,
+// i.e. Range requests are enabled and it's a successful GET response with a body.
+func rangeable(config *Configuration, req *http.Request, out *OutboundResponse) bool {
+	return config.RangeRequests && req.Method == "GET" && out.Status == http.StatusOK &&
+		len(out.Body) > 0
+}
+
+// writeRangeResponse writes the serialized response, honoring Range headers. A strong
+// ETag computed from the body is sent, unless there already is one, so clients can
+// resume downloads with If-Range without receiving ranges of a changed response.
+func writeRangeResponse(w http.ResponseWriter, req *http.Request, out *OutboundResponse) {
+	if w.Header().Get("ETag") == "" {
+		sum := sha256.Sum256(out.Body)
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+	}
+	http.ServeContent(w, req, "", time.Time{}, bytes.NewReader(out.Body))
+}
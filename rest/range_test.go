@@ -0,0 +1,114 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type contentResourceHandler struct {
+	BaseResourceHandler
+}
+
+func (c contentResourceHandler) ResourceName() string {
+	return "exports"
+}
+
+func (c contentResourceHandler) Rules() Rules {
+	return NewRules((*TestResource)(nil), &Rule{Field: "Foo", FieldAlias: "foo"})
+}
+
+func (c contentResourceHandler) ReadResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	if id == "json" {
+		return Payload{"foo": "0123456789"}, nil
+	}
+	return &Content{Name: id + ".csv", ETag: `"v1"`, Body: strings.NewReader("0123456789")}, nil
+}
+
+// serveRange sends a read request for the export with the Range header, if any, and
+// returns the response.
+func serveRange(api API, id, byteRange string, header http.Header) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/exports/"+id, nil)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if byteRange != "" {
+		req.Header.Set("Range", byteRange)
+	}
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	return resp
+}
+
+// Ensures that Content is sent as is, honoring Range headers.
+func TestContentRange(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(contentResourceHandler{})
+
+	resp := serveRange(api, "report", "", nil)
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal("0123456789", resp.Body.String())
+	assert.Equal("bytes", resp.Header().Get("Accept-Ranges"))
+	assert.Equal("text/csv; charset=utf-8", resp.Header().Get("Content-Type"))
+	assert.Equal(`"v1"`, resp.Header().Get("ETag"))
+
+	resp = serveRange(api, "report", "bytes=2-5", nil)
+	assert.Equal(http.StatusPartialContent, resp.Code)
+	assert.Equal("2345", resp.Body.String())
+	assert.Equal("bytes 2-5/10", resp.Header().Get("Content-Range"))
+
+	resp = serveRange(api, "report", "bytes=2-5", http.Header{"If-Range": {`"v0"`}})
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal("0123456789", resp.Body.String())
+
+	resp = serveRange(api, "report", "bytes=20-", nil)
+	assert.Equal(http.StatusRequestedRangeNotSatisfiable, resp.Code)
+}
+
+// Ensures that serialized responses honor Range headers only if range requests are
+// enabled.
+func TestSerializedResponseRange(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(contentResourceHandler{})
+
+	resp := serveRange(api, "json", "bytes=0-9", nil)
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal("", resp.Header().Get("Accept-Ranges"))
+
+	api = NewAPI(&Configuration{RangeRequests: true})
+	api.RegisterResourceHandler(contentResourceHandler{})
+
+	full := serveRange(api, "json", "", nil)
+	assert.Equal(http.StatusOK, full.Code)
+	assert.Equal("bytes", full.Header().Get("Accept-Ranges"))
+	etag := full.Header().Get("ETag")
+	assert.NotEmpty(etag)
+
+	resp = serveRange(api, "json", "bytes=0-9", http.Header{"If-Range": {etag}})
+	assert.Equal(http.StatusPartialContent, resp.Code)
+	assert.Equal(full.Body.String()[:10], resp.Body.String())
+	assert.Equal("application/json", resp.Header().Get("Content-Type"))
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// CodeInternalError identifies redacted internal errors, which are sent to clients
+// when RedactErrors is configured. It takes the error ID as its argument.
+const CodeInternalError = "internal_error"
+
+// newErrorID returns a random ID for correlating a redacted error with its log entry.
+func newErrorID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// redactError returns the error to send to the client in place of the request's error
+// if the Configuration has RedactErrors enabled. Server errors, other than retryable
+// ones, are replaced by a generic message with an error ID, and are logged with the ID
+// and their full detail. Invalid field errors are replaced by a message naming the
+// field so the values sent by the client aren't echoed back. Other errors are returned
+// as is.
+func (h requestHandler) redactError(ctx RequestContext, err error) error {
+	if err == nil || !h.Configuration().RedactErrors {
+		return err
+	}
+
+	switch e := err.(type) {
+	case FieldErrors:
+		redacted := make(FieldErrors, len(e))
+		for field, fieldErr := range e {
+			redacted[field] = redactFieldError(field, fieldErr)
+		}
+		return redacted
+	case Error:
+		if e.code == CodeInvalidField && len(e.args) > 0 {
+			return redactFieldError(fmt.Sprint(e.args[0]), e)
+		}
+		if e.retryable {
+			return e
+		}
+	}
+
+	status := errorStatus(err)
+	if status < 500 {
+		return err
+	}
+
+	id := newErrorID()
+	method, path := "", ""
+	if req, ok := ctx.Request(); ok {
+		method, path = req.Method, req.URL.Path
+	}
+	h.logf("Internal error %s for %s %s: %s", id, method, path, err)
+	return CustomError(fmt.Sprintf("Internal error (error ID %s)", id), status).
+		WithCode(CodeInternalError, id)
+}
+
+// redactFieldError returns a message for the invalid field error without the value
+// which failed to be coerced. Other field errors are returned as is.
+func redactFieldError(field string, err error) error {
+	e, ok := err.(Error)
+	if !ok || e.code != CodeInvalidField {
+		return err
+	}
+	return UnprocessableRequest(fmt.Sprintf("Invalid value for field '%s'", field)).
+		WithCode(CodeInvalidField, field)
+}
+
+// redactResponseError sets the request's error to its redacted error.
+func (h requestHandler) redactResponseError(ctx RequestContext) RequestContext {
+	if err := ctx.Error(); err != nil && h.Configuration().RedactErrors {
+		return ctx.setError(h.redactError(ctx, err))
+	}
+	return ctx
+}
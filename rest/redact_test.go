@@ -0,0 +1,131 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type redactResourceHandler struct {
+	BaseResourceHandler
+	err error
+}
+
+func (r *redactResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (r *redactResourceHandler) Rules() Rules {
+	return NewRules((*TestResource)(nil), &Rule{Field: "Foo", FieldAlias: "foo", Type: Int})
+}
+
+func (r *redactResourceHandler) ReadResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	return nil, r.err
+}
+
+func (r *redactResourceHandler) CreateResource(ctx RequestContext, data Payload,
+	version string) (Resource, error) {
+
+	return data, nil
+}
+
+// serveRedacted performs the request against an API redacting errors, returning the
+// response and the logged output.
+func serveRedacted(handler ResourceHandler, method, body string) (*httptest.ResponseRecorder, string) {
+	var logs bytes.Buffer
+	api := NewAPI(&Configuration{RedactErrors: true, Logger: log.New(&logs, "", 0)})
+	api.RegisterResourceHandler(handler)
+
+	url := "http://foo.com/api/v1/foo"
+	if method == "GET" {
+		url += "/1"
+	}
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	return resp, logs.String()
+}
+
+// Ensures that internal errors are replaced by a generic message with an error ID
+// which is logged with the full error.
+func TestRedactInternalError(t *testing.T) {
+	assert := assert.New(t)
+
+	resp, logs := serveRedacted(&redactResourceHandler{err: errors.New("pq: connection refused")},
+		"GET", "")
+
+	assert.Equal(http.StatusInternalServerError, resp.Code)
+	assert.NotContains(resp.Body.String(), "pq: connection refused")
+	var payload struct {
+		Messages []string `json:"messages"`
+	}
+	assert.NoError(json.Unmarshal(resp.Body.Bytes(), &payload))
+	if assert.Len(payload.Messages, 1) {
+		assert.True(strings.HasPrefix(payload.Messages[0], "Internal error (error ID "))
+		id := strings.TrimSuffix(strings.TrimPrefix(payload.Messages[0], "Internal error (error ID "), ")")
+		assert.Len(id, 16)
+		assert.Contains(logs, "Internal error "+id+" for GET /api/v1/foo/1: pq: connection refused")
+	}
+}
+
+// Ensures that client errors and retryable server errors aren't redacted.
+func TestRedactClientErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	resp, _ := serveRedacted(&redactResourceHandler{err: ResourceNotFound("No foo 1")}, "GET", "")
+	assert.Equal(http.StatusNotFound, resp.Code)
+	assert.Contains(resp.Body.String(), "No foo 1")
+
+	resp, logs := serveRedacted(&redactResourceHandler{
+		err: ServiceUnavailable("Down for maintenance").WithRetryAfter(time.Minute)}, "GET", "")
+	assert.Equal(http.StatusServiceUnavailable, resp.Code)
+	assert.Contains(resp.Body.String(), "Down for maintenance")
+	assert.Equal("", logs)
+}
+
+// Ensures that invalid field errors don't echo the values sent by the client.
+func TestRedactInvalidField(t *testing.T) {
+	assert := assert.New(t)
+
+	resp, _ := serveRedacted(&redactResourceHandler{}, "POST", `{"foo": "secret-token"}`)
+
+	assert.Equal(statusUnprocessableEntity, resp.Code)
+	assert.NotContains(resp.Body.String(), "secret-token")
+	assert.Contains(resp.Body.String(), `"foo":"Invalid value for field 'foo'"`)
+}
+
+// Ensures that redactError returns errors as is unless RedactErrors is configured.
+func TestRedactErrorDisabled(t *testing.T) {
+	assert := assert.New(t)
+	h := requestHandler{NewAPI(&Configuration{})}
+	err := errors.New("couldn't create")
+
+	assert.Equal(err, h.redactError(nil, err))
+	assert.Nil(h.redactError(nil, nil))
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/gorilla/mux"
+)
+
+// routeBinding binds routes to a Router. Bindings are kept so the routes can be bound
+// to a new Router when ResourceHandlers are registered or unregistered while the API is
+// serving requests.
+type routeBinding struct {
+	// handler is the ResourceHandler whose routes are bound, or nil if the routes
+	// don't belong to a ResourceHandler.
+	handler ResourceHandler
+	bind    func(Router)
+}
+
+// newDefaultRouter returns the Router backed by gorilla/mux used when the Configuration
+// doesn't have one.
+func newDefaultRouter(config *Configuration) *GorillaRouter {
+	return NewGorillaRouter(mux.NewRouter().StrictSlash(config.StrictSlash))
+}
+
+// activeRouter returns the Router currently dispatching requests.
+func (r *muxAPI) activeRouter() Router {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.routes
+}
+
+// addRoutes binds routes to the router with the provided function. If LazyRoutes is
+// enabled, binding is deferred until the routes are compiled. Once the routes are
+// compiled, the API may be serving requests, so the default Router isn't modified.
+// Instead, all of the routes are bound to a new Router which replaces it. Configured
+// Routers are modified in place and must be safe for concurrent use to register routes
+// while serving requests.
+func (r *muxAPI) addRoutes(h ResourceHandler, bind func(Router)) {
+	r.routesMu.Lock()
+	defer r.routesMu.Unlock()
+	binding := routeBinding{handler: h, bind: bind}
+	r.bindings = append(r.bindings, binding)
+
+	switch {
+	case atomic.LoadInt32(&r.compiled) == 0 && r.config.LazyRoutes:
+		r.pendingRoutes = append(r.pendingRoutes, binding)
+	case atomic.LoadInt32(&r.compiled) == 1 && r.config.Router == nil:
+		r.rebuildRoutes()
+	default:
+		bind(r.routes)
+	}
+}
+
+// compileRoutes binds any deferred routes to the router in the order they were added.
+// Routes added afterwards are bound as they're added.
+func (r *muxAPI) compileRoutes() {
+	if atomic.LoadInt32(&r.compiled) == 1 {
+		return
+	}
+	r.routesMu.Lock()
+	defer r.routesMu.Unlock()
+	if atomic.LoadInt32(&r.compiled) == 1 {
+		return
+	}
+	for _, binding := range r.pendingRoutes {
+		binding.bind(r.routes)
+	}
+	r.pendingRoutes = nil
+	atomic.StoreInt32(&r.compiled, 1)
+}
+
+// rebuildRoutes binds the routes of all bindings to a new default Router which then
+// replaces the active Router, so requests are never dispatched by a partially bound
+// Router. The routesMu must be held.
+func (r *muxAPI) rebuildRoutes() {
+	r.mu.Lock()
+	r.routeInfos = nil
+	r.mu.Unlock()
+
+	routes := newDefaultRouter(r.config)
+	for _, binding := range r.bindings {
+		binding.bind(routes)
+	}
+	r.pendingRoutes = nil
+	atomic.StoreInt32(&r.compiled, 1)
+
+	r.mu.Lock()
+	r.routes = routes
+	r.mu.Unlock()
+}
+
+// UnregisterResourceHandler unbinds the REST endpoints of the named resource, including
+// those of ResourceHandlers registered for specific versions. The routes of the other
+// resources are bound to a new Router which atomically replaces the active Router, so
+// it's safe to call while the API is serving requests. Requests already dispatched to
+// the resource's ResourceHandlers aren't affected, but initialized ResourceHandlers are
+// closed once they're unbound. Unregistering is only supported by the default Router.
+func (r *muxAPI) UnregisterResourceHandler(resource string) error {
+	if r.config.Router != nil {
+		return fmt.Errorf("Failed to unregister %s: the configured Router doesn't "+
+			"support unregistering", resource)
+	}
+
+	r.routesMu.Lock()
+	defer r.routesMu.Unlock()
+
+	found := false
+	r.updateState(func(state *apiState) {
+		handlers := make([]ResourceHandler, 0, len(state.handlers))
+		for _, handler := range state.handlers {
+			if handler.ResourceName() != resource {
+				handlers = append(handlers, handler)
+			}
+		}
+		found = len(handlers) < len(state.handlers)
+		state.handlers = handlers
+		delete(state.versions, resource)
+	})
+	if !found {
+		return fmt.Errorf("No ResourceHandler registered for %s", resource)
+	}
+
+	bindings := make([]routeBinding, 0, len(r.bindings))
+	for _, binding := range r.bindings {
+		if binding.handler == nil || binding.handler.ResourceName() != resource {
+			bindings = append(bindings, binding)
+		}
+	}
+	r.bindings = bindings
+	r.rebuildRoutes()
+	r.config.Debugf("Unregistered %s handler", resource)
+	return r.closeResource(resource)
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type hotResourceHandler struct {
+	BaseResourceHandler
+	name string
+}
+
+func (h hotResourceHandler) ResourceName() string {
+	return h.name
+}
+
+func (h hotResourceHandler) ReadResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	return Payload{"resource": h.name, "id": id}, nil
+}
+
+// serveRead sends a read request for the resource to the API and returns the response.
+func serveRead(api API, resource string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/"+resource+"/1", nil)
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	return resp
+}
+
+// Ensures that ResourceHandlers registered while the API is serving requests are
+// routed.
+func TestRegisterResourceHandlerWhileServing(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(hotResourceHandler{name: "foo"})
+
+	assert.Equal(http.StatusOK, serveRead(api, "foo").Code)
+	assert.Equal(http.StatusNotFound, serveRead(api, "bar").Code)
+
+	api.RegisterResourceHandler(hotResourceHandler{name: "bar"})
+
+	assert.Equal(http.StatusOK, serveRead(api, "foo").Code)
+	resp := serveRead(api, "bar")
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Contains(resp.Body.String(), `"resource":"bar"`)
+	assert.Len(api.ResourceHandlers(), 2)
+
+	u, err := api.URLFor("bar", HandleRead, "1", "2")
+	assert.NoError(err)
+	assert.Equal("/api/v1/bar/2", u.String())
+}
+
+// Ensures that UnregisterResourceHandler unbinds the resource's endpoints, including
+// those registered for specific versions, and the resource can be registered again.
+func TestUnregisterResourceHandler(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(hotResourceHandler{name: "foo"})
+	api.RegisterResourceHandlerForVersions(hotResourceHandler{name: "bar"}, "1")
+	api.RegisterHandlerFunc("/special", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("special"))
+	})
+	assert.Equal(http.StatusOK, serveRead(api, "foo").Code)
+
+	assert.NoError(api.UnregisterResourceHandler("foo"))
+	assert.NoError(api.UnregisterResourceHandler("bar"))
+
+	assert.Equal(http.StatusNotFound, serveRead(api, "foo").Code)
+	assert.Equal(http.StatusNotFound, serveRead(api, "bar").Code)
+	assert.Empty(api.ResourceHandlers())
+	for _, route := range api.Routes() {
+		assert.NotEqual("foo", route.Resource)
+		assert.NotEqual("bar", route.Resource)
+	}
+
+	req, _ := http.NewRequest("GET", "http://foo.com/special", nil)
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	assert.Equal("special", resp.Body.String())
+
+	assert.Error(api.UnregisterResourceHandler("foo"))
+
+	api.RegisterResourceHandler(hotResourceHandler{name: "foo"})
+	assert.Equal(http.StatusOK, serveRead(api, "foo").Code)
+}
+
+// Ensures that UnregisterResourceHandler returns an error for configured Routers.
+func TestUnregisterResourceHandlerCustomRouter(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{Router: &regexpRouter{}})
+	api.RegisterResourceHandler(hotResourceHandler{name: "foo"})
+
+	assert.Error(api.UnregisterResourceHandler("foo"))
+	assert.Len(api.ResourceHandlers(), 1)
+}
+
+// Ensures that ResourceHandlers can be registered and unregistered concurrently with
+// requests being served.
+func TestHotRegistrationConcurrentRequests(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(hotResourceHandler{name: "foo"})
+	serveRead(api, "foo")
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				assert.Equal(http.StatusOK, serveRead(api, "foo").Code)
+				api.Routes()
+			}
+		}()
+	}
+
+	for i := 0; i < 20; i++ {
+		api.RegisterResourceHandler(hotResourceHandler{name: "bar"})
+		assert.NoError(api.UnregisterResourceHandler("bar"))
+	}
+	close(done)
+	wg.Wait()
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+)
+
+// ErrorReport describes a panic or server error response for an ErrorReporter.
+type ErrorReport struct {
+	// ID identifies the error. If errors are redacted, it's the error ID sent to the
+	// client.
+	ID string
+
+	// Err is the error of the request. For panics, it describes the panic value.
+	Err error
+
+	// Panic is the value the request panicked with, or nil if it didn't panic.
+	Panic interface{}
+
+	// Stack is the stack trace of the panic, or of the goroutine sending the error
+	// response if the request didn't panic.
+	Stack []byte
+
+	// Status is the HTTP status code of the response.
+	Status int
+
+	// Method and URL are the request's method and URL.
+	Method string
+	URL    string
+
+	// Header is the request header.
+	Header http.Header
+
+	// Resource and Operation identify the endpoint, e.g. "foo" and "read". They're
+	// empty if the request wasn't for a resource endpoint.
+	Resource  string
+	Operation string
+
+	// Version is the API version requested.
+	Version string
+
+	// Context is the RequestContext of the request, providing access to its values,
+	// e.g. the authenticated user.
+	Context RequestContext
+}
+
+// ErrorReporter is notified of panics and server error responses, e.g. to send them to
+// an error tracking service. When configured, panics in endpoints are recovered and
+// result in a 500 Internal Server Error response. Reports are made synchronously before
+// the response is sent, so ErrorReporters which contact a remote service should do so
+// in another goroutine.
+type ErrorReporter interface {
+	// ReportError reports the panic or error.
+	ReportError(ErrorReport)
+}
+
+// ErrorReporterFunc is an adapter allowing a function to be used as an ErrorReporter.
+type ErrorReporterFunc func(ErrorReport)
+
+// ReportError calls the function with the report.
+func (f ErrorReporterFunc) ReportError(report ErrorReport) {
+	f(report)
+}
+
+// panicError is the error of requests which panicked.
+type panicError struct {
+	value interface{}
+	stack []byte
+}
+
+// Error returns a message describing the panic value.
+func (p panicError) Error() string {
+	return fmt.Sprintf("Panic: %v", p.value)
+}
+
+// recoverPanics returns a Handler which recovers panics from the handler if an
+// ErrorReporter is configured, sending a 500 Internal Server Error response which is
+// reported with the panic.
+func (h requestHandler) recoverPanics(handler http.Handler) http.Handler {
+	if h.Configuration().ErrorReporter == nil {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			value := recover()
+			if value == nil {
+				return
+			}
+			if value == http.ErrAbortHandler {
+				panic(value)
+			}
+			err := panicError{value: value, stack: debug.Stack()}
+			h.sendResponse(h.newContext(w, r).setError(err))
+		}()
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// reportError reports the original error of the request to the configured
+// ErrorReporter if it's a server error. The redacted error is the error sent to the
+// client, whose error ID is used for the report if it has one.
+func (h requestHandler) reportError(ctx RequestContext, original, redacted error) {
+	reporter := h.Configuration().ErrorReporter
+	if reporter == nil || original == nil {
+		return
+	}
+	status := errorStatus(original)
+	if status < 500 {
+		return
+	}
+
+	report := ErrorReport{
+		ID:      newErrorID(),
+		Err:     original,
+		Status:  status,
+		Version: ctx.Version(),
+		Context: ctx,
+	}
+	if e, ok := redacted.(Error); ok && e.code == CodeInternalError && len(e.args) > 0 {
+		report.ID = fmt.Sprint(e.args[0])
+	}
+	if p, ok := original.(panicError); ok {
+		report.Panic, report.Stack = p.value, p.stack
+	} else {
+		report.Stack = debug.Stack()
+	}
+	if req, ok := ctx.Request(); ok {
+		report.Method, report.URL, report.Header = req.Method, req.URL.String(), req.Header
+		if parts := strings.SplitN(routeName(req), ":", 2); len(parts) == 2 {
+			report.Resource, report.Operation = parts[0], parts[1]
+		}
+	}
+	reporter.ReportError(report)
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gcontext "github.com/gorilla/context"
+	"github.com/stretchr/testify/assert"
+)
+
+type reportResourceHandler struct {
+	BaseResourceHandler
+}
+
+func (r *reportResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (r *reportResourceHandler) Authenticate(req *http.Request) error {
+	gcontext.Set(req, "user", "bob")
+	return nil
+}
+
+func (r *reportResourceHandler) ReadResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	switch id {
+	case "panic":
+		panic("boom")
+	case "error":
+		return nil, errors.New("database unavailable")
+	}
+	return nil, ResourceNotFound("No foo " + id)
+}
+
+// serveReported performs a read request against an API with the ErrorReporter,
+// returning the response and the reports made.
+func serveReported(config *Configuration, id string) (*httptest.ResponseRecorder, []ErrorReport) {
+	var reports []ErrorReport
+	config.ErrorReporter = ErrorReporterFunc(func(report ErrorReport) {
+		reports = append(reports, report)
+	})
+	api := NewAPI(config)
+	api.RegisterResourceHandler(&reportResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/"+id, nil)
+	req.Header.Set("X-Request-Id", "abc")
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	return resp, reports
+}
+
+// Ensures that panics are recovered with a 500 response and reported with the panic
+// value, stack trace, request metadata, and RequestContext.
+func TestReportPanic(t *testing.T) {
+	assert := assert.New(t)
+
+	resp, reports := serveReported(&Configuration{}, "panic")
+
+	assert.Equal(http.StatusInternalServerError, resp.Code)
+	assert.Contains(resp.Body.String(), "Panic: boom")
+	if !assert.Len(reports, 1) {
+		return
+	}
+	report := reports[0]
+	assert.Equal("boom", report.Panic)
+	assert.EqualError(report.Err, "Panic: boom")
+	assert.Contains(string(report.Stack), "ReadResource")
+	assert.Equal(http.StatusInternalServerError, report.Status)
+	assert.Equal("GET", report.Method)
+	assert.Equal("http://foo.com/api/v1/foo/panic", report.URL)
+	assert.Equal("abc", report.Header.Get("X-Request-Id"))
+	assert.Equal("foo", report.Resource)
+	assert.Equal("read", report.Operation)
+	assert.Equal("1", report.Version)
+	assert.Len(report.ID, 16)
+	assert.Equal("bob", report.Context.Value("user"))
+}
+
+// Ensures that server error responses are reported with the ID of the redacted error
+// sent to the client, and that client errors aren't reported.
+func TestReportServerError(t *testing.T) {
+	assert := assert.New(t)
+	logger := log.New(&bytes.Buffer{}, "", 0)
+
+	resp, reports := serveReported(&Configuration{RedactErrors: true, Logger: logger}, "error")
+
+	assert.Equal(http.StatusInternalServerError, resp.Code)
+	assert.NotContains(resp.Body.String(), "database unavailable")
+	if assert.Len(reports, 1) {
+		assert.EqualError(reports[0].Err, "database unavailable")
+		assert.Nil(reports[0].Panic)
+		assert.NotEmpty(reports[0].Stack)
+		assert.True(strings.Contains(resp.Body.String(), "error ID "+reports[0].ID))
+	}
+
+	resp, reports = serveReported(&Configuration{}, "missing")
+	assert.Equal(http.StatusNotFound, resp.Code)
+	assert.Len(reports, 0)
+}
+
+// Ensures that panics aren't recovered unless an ErrorReporter is configured.
+func TestReportPanicNotConfigured(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&reportResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/panic", nil)
+	assert.Panics(func() {
+		api.ServeHTTP(httptest.NewRecorder(), req)
+	})
+}
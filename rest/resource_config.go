@@ -0,0 +1,331 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// RegistrationConfig declaratively describes resources registered with
+// API#RegisterConfig, so platform operators can enable, disable, and configure
+// endpoints per deployment without code changes. It can be loaded from JSON with
+// ParseRegistrationConfig or from YAML by unmarshaling it with a YAML library.
+type RegistrationConfig struct {
+	// Resources are the resources to register, in registration order.
+	Resources []ResourceConfig `json:"resources" yaml:"resources"`
+}
+
+// ResourceConfig describes a resource and the ResourceHandler implementing it.
+type ResourceConfig struct {
+	// Name is the name of the resource. If empty, the ResourceHandler's ResourceName
+	// is used.
+	Name string `json:"name" yaml:"name"`
+
+	// Handler is the name of the ResourceHandler implementing the resource in the
+	// Bindings.
+	Handler string `json:"handler" yaml:"handler"`
+
+	// Disabled skips registering the resource.
+	Disabled bool `json:"disabled" yaml:"disabled"`
+
+	// Versions overrides the ResourceHandler's ValidVersions if not empty.
+	Versions []string `json:"versions" yaml:"versions"`
+
+	// Operations are the enabled endpoints of the resource, e.g. "read" and "readList".
+	// Requests of other endpoints, and batch requests containing operations which
+	// aren't enabled, fail with 405 Method Not Allowed. If empty, all endpoints are
+	// enabled.
+	Operations []string `json:"operations" yaml:"operations"`
+
+	// Rules replace the ResourceHandler's Rules if not empty. The ResourceHandler's
+	// Rules must declare the resource type they apply to, e.g. with
+	// NewRules((*Widget)(nil)).
+	Rules []RuleConfig `json:"rules" yaml:"rules"`
+
+	// Middleware are the names of the RequestMiddleware in the Bindings applied to the
+	// resource's endpoints, in order.
+	Middleware []string `json:"middleware" yaml:"middleware"`
+}
+
+// RuleConfig describes a Rule. See Rule for the meaning of its fields.
+type RuleConfig struct {
+	Field        string   `json:"field" yaml:"field"`
+	FieldAlias   string   `json:"field_alias" yaml:"field_alias"`
+	Type         string   `json:"type" yaml:"type"`
+	Required     bool     `json:"required" yaml:"required"`
+	Versions     []string `json:"versions" yaml:"versions"`
+	InputOnly    bool     `json:"input_only" yaml:"input_only"`
+	OutputOnly   bool     `json:"output_only" yaml:"output_only"`
+	Identifier   bool     `json:"identifier" yaml:"identifier"`
+	Roles        []string `json:"roles" yaml:"roles"`
+	ExcludeRoles []string `json:"exclude_roles" yaml:"exclude_roles"`
+	DocString    string   `json:"doc_string" yaml:"doc_string"`
+}
+
+// configTypeAliases maps shorter names accepted by RuleConfig for Types whose names
+// aren't identifiers, e.g. "[]interface{}", to the Types.
+var configTypeAliases = map[string]Type{
+	"":          Unspecified,
+	"interface": Interface,
+	"slice":     Slice,
+	"map":       Map,
+	"duration":  Duration,
+	"time":      Time,
+}
+
+// configType returns the Type with the name, e.g. "int64" or "time.Time", or an alias.
+// It returns false if there's no such Type.
+func configType(name string) (Type, bool) {
+	if t, ok := configTypeAliases[name]; ok {
+		return t, true
+	}
+	for t, typeName := range typeToName {
+		if typeName == name {
+			return t, true
+		}
+	}
+	return Unspecified, false
+}
+
+// Bindings binds the names used by a RegistrationConfig to their implementations.
+type Bindings struct {
+	// Handlers are the ResourceHandlers resources are implemented by, keyed by name.
+	Handlers map[string]ResourceHandler
+
+	// Middleware are the RequestMiddleware applied to resources, keyed by name.
+	Middleware map[string]RequestMiddleware
+}
+
+// ParseRegistrationConfig parses a JSON RegistrationConfig. An error is returned if it
+// isn't valid JSON or has unknown fields, which are likely typos.
+func ParseRegistrationConfig(data []byte) (*RegistrationConfig, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var config RegistrationConfig
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("Invalid registration config: %s", err)
+	}
+	return &config, nil
+}
+
+// declaredResourceHandler wraps a ResourceHandler registered from a ResourceConfig,
+// overriding its name, versions, and Rules and restricting its operations.
+type declaredResourceHandler struct {
+	ResourceHandler
+	name       string
+	versions   []string
+	rules      Rules
+	operations map[HandleMethod]bool
+}
+
+// ResourceName returns the configured name of the resource, falling back to the
+// wrapped ResourceHandler's.
+func (d *declaredResourceHandler) ResourceName() string {
+	if d.name != "" {
+		return d.name
+	}
+	return d.ResourceHandler.ResourceName()
+}
+
+// ValidVersions returns the configured versions, falling back to the wrapped
+// ResourceHandler's.
+func (d *declaredResourceHandler) ValidVersions() []string {
+	if len(d.versions) > 0 {
+		return d.versions
+	}
+	return d.ResourceHandler.ValidVersions()
+}
+
+// Rules returns the configured Rules, falling back to the wrapped ResourceHandler's.
+func (d *declaredResourceHandler) Rules() Rules {
+	if d.rules != nil {
+		return d.rules
+	}
+	return d.ResourceHandler.Rules()
+}
+
+// operationEnabled returns true if the operation of the ResourceHandler is enabled.
+// Operations are only restricted for resources registered from a ResourceConfig.
+func operationEnabled(handler ResourceHandler, operation HandleMethod) bool {
+	if proxy, ok := handler.(resourceHandlerProxy); ok {
+		handler = proxy.ResourceHandler
+	}
+	declared, ok := handler.(*declaredResourceHandler)
+	return !ok || declared.operations == nil || declared.operations[operation]
+}
+
+// newOperationsMiddleware returns a RequestMiddleware which rejects requests of the
+// ResourceHandler's endpoints which aren't enabled with 405 Method Not Allowed.
+func newOperationsMiddleware(handler ResourceHandler, h *requestHandler) RequestMiddleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			parts := strings.SplitN(routeName(r), ":", 2)
+			if len(parts) == 2 && !operationEnabled(handler, HandleMethod(parts[1])) {
+				h.sendResponse(h.newContext(w, r).setError(MethodNotAllowed(
+					fmt.Sprintf("%s is disabled for %s", parts[1], parts[0]))))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// declaredOperations are the operations which can be enabled by a ResourceConfig.
+var declaredOperations = []HandleMethod{
+	HandleCreate, HandleRead, HandleUpdate, HandleDelete, HandleReadList,
+	HandleUpdateList, HandlePatch, HandleSnapshot, HandleRestore, HandleBatch,
+	HandleEvents, HandleChanges, HandleSearch,
+}
+
+// RegisterConfig registers the enabled resources described by the RegistrationConfig,
+// bound to the ResourceHandlers and RequestMiddleware with the names it uses. The whole
+// config is validated first, so if an error is returned, none of the resources are
+// registered.
+func (r *muxAPI) RegisterConfig(config *RegistrationConfig, bindings Bindings) error {
+	type registration struct {
+		handler    ResourceHandler
+		middleware []RequestMiddleware
+	}
+
+	var registrations []registration
+	names := map[string]bool{}
+	for i, resource := range config.Resources {
+		if resource.Disabled {
+			continue
+		}
+		handler, middleware, err := declareResource(resource, bindings)
+		if err != nil {
+			return fmt.Errorf("Invalid resource %d in registration config: %s", i, err)
+		}
+		name := handler.ResourceName()
+		if names[name] {
+			return fmt.Errorf("Invalid resource %d in registration config: %s is declared "+
+				"more than once", i, name)
+		}
+		names[name] = true
+		middleware = append(middleware, newOperationsMiddleware(handler, r.handler))
+		registrations = append(registrations, registration{handler, middleware})
+	}
+
+	for _, reg := range registrations {
+		r.RegisterResourceHandler(reg.handler, reg.middleware...)
+	}
+	return nil
+}
+
+// declareResource returns the ResourceHandler for the ResourceConfig, wrapped to apply
+// it, along with its RequestMiddleware.
+func declareResource(resource ResourceConfig, bindings Bindings) (ResourceHandler,
+	[]RequestMiddleware, error) {
+
+	handler, ok := bindings.Handlers[resource.Handler]
+	if !ok || handler == nil {
+		return nil, nil, fmt.Errorf("no handler bound to %q", resource.Handler)
+	}
+	declared := &declaredResourceHandler{
+		ResourceHandler: handler,
+		name:            resource.Name,
+		versions:        resource.Versions,
+	}
+	if declared.ResourceName() == "" {
+		return nil, nil, fmt.Errorf("handler %q doesn't have a resource name",
+			resource.Handler)
+	}
+
+	if len(resource.Operations) > 0 {
+		declared.operations = map[HandleMethod]bool{}
+		for _, operation := range resource.Operations {
+			if !declaredOperation(HandleMethod(operation)) {
+				return nil, nil, fmt.Errorf("unknown operation %q", operation)
+			}
+			declared.operations[HandleMethod(operation)] = true
+		}
+	}
+
+	if len(resource.Rules) > 0 {
+		rules, err := declareRules(handler, resource.Rules)
+		if err != nil {
+			return nil, nil, err
+		}
+		declared.rules = rules
+	}
+
+	middleware := make([]RequestMiddleware, 0, len(resource.Middleware)+1)
+	for _, name := range resource.Middleware {
+		m, ok := bindings.Middleware[name]
+		if !ok || m == nil {
+			return nil, nil, fmt.Errorf("no middleware bound to %q", name)
+		}
+		middleware = append(middleware, m)
+	}
+	return declared, middleware, nil
+}
+
+// declaredOperation returns true if the operation can be enabled by a ResourceConfig.
+func declaredOperation(operation HandleMethod) bool {
+	for _, op := range declaredOperations {
+		if op == operation {
+			return true
+		}
+	}
+	return false
+}
+
+// declareRules returns the Rules described by the RuleConfigs for the resource type of
+// the ResourceHandler's Rules. An error is returned if they're invalid.
+func declareRules(handler ResourceHandler, configs []RuleConfig) (Rules, error) {
+	var resourceType reflect.Type
+	if rules := handler.Rules(); rules != nil {
+		resourceType = rules.ResourceType()
+	}
+	if resourceType == nil || resourceType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("handler's Rules must declare a struct resource type to " +
+			"configure rules")
+	}
+
+	contents := make([]*Rule, len(configs))
+	for i, config := range configs {
+		t, ok := configType(config.Type)
+		if !ok {
+			return nil, fmt.Errorf("unknown type %q for rule %d", config.Type, i)
+		}
+		contents[i] = &Rule{
+			Field:        config.Field,
+			FieldAlias:   config.FieldAlias,
+			Type:         t,
+			Required:     config.Required,
+			Versions:     config.Versions,
+			InputOnly:    config.InputOnly,
+			OutputOnly:   config.OutputOnly,
+			Identifier:   config.Identifier,
+			Roles:        config.Roles,
+			ExcludeRoles: config.ExcludeRoles,
+			DocString:    config.DocString,
+		}
+	}
+
+	rules := &rules{contents: contents, resourceType: resourceType}
+	if err := rules.Validate(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v1"
+)
+
+type configWidget struct {
+	ID   string
+	Name string
+}
+
+type configResourceHandler struct {
+	BaseResourceHandler
+}
+
+func (c *configResourceHandler) ResourceName() string {
+	return "widget"
+}
+
+func (c *configResourceHandler) ReadResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	return &configWidget{ID: id, Name: "sprocket"}, nil
+}
+
+func (c *configResourceHandler) CreateResource(ctx RequestContext, data Payload,
+	version string) (Resource, error) {
+
+	return &configWidget{ID: "1"}, nil
+}
+
+func (c *configResourceHandler) Rules() Rules {
+	return NewRules((*configWidget)(nil))
+}
+
+// configBindings returns Bindings of the config test handler and a middleware adding
+// a response header.
+func configBindings() Bindings {
+	return Bindings{
+		Handlers: map[string]ResourceHandler{"widgets": &configResourceHandler{}},
+		Middleware: map[string]RequestMiddleware{
+			"tagged": func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("X-Tagged", "true")
+					next.ServeHTTP(w, r)
+				})
+			},
+		},
+	}
+}
+
+// serveConfig performs a request against the API and returns the response.
+func serveConfig(api API, method, url, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	return resp
+}
+
+const testRegistrationConfig = `{
+	"resources": [
+		{
+			"name": "widgets",
+			"handler": "widgets",
+			"versions": ["1"],
+			"operations": ["read", "batch"],
+			"rules": [
+				{"field": "ID", "field_alias": "id", "type": "string"},
+				{"field": "Name", "field_alias": "title"}
+			],
+			"middleware": ["tagged"]
+		},
+		{"name": "gadgets", "handler": "widgets", "disabled": true}
+	]
+}`
+
+// Ensures that resources are registered from a RegistrationConfig with the configured
+// name, versions, operations, Rules, and middleware, and that disabled resources
+// aren't registered.
+func TestRegisterConfig(t *testing.T) {
+	assert := assert.New(t)
+	config, err := ParseRegistrationConfig([]byte(testRegistrationConfig))
+	if !assert.NoError(err) {
+		return
+	}
+	api := NewAPI(&Configuration{})
+	if !assert.NoError(api.RegisterConfig(config, configBindings())) {
+		return
+	}
+	assert.Len(api.ResourceHandlers(), 1)
+
+	resp := serveConfig(api, "GET", "http://foo.com/api/v1/widgets/1", "")
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Contains(resp.Body.String(), `"result":{"id":"1","title":"sprocket"}`)
+	assert.Equal("true", resp.Header().Get("X-Tagged"))
+
+	resp = serveConfig(api, "GET", "http://foo.com/api/v2/widgets/1", "")
+	assert.Equal(http.StatusBadRequest, resp.Code)
+
+	resp = serveConfig(api, "POST", "http://foo.com/api/v1/widgets", `{"title":"a"}`)
+	assert.Equal(http.StatusMethodNotAllowed, resp.Code)
+	assert.Contains(resp.Body.String(), "create is disabled for widgets")
+
+	resp = serveConfig(api, "POST", "http://foo.com/api/v1/widgets/batch",
+		`[{"method": "read", "id": "1"}, {"method": "create", "data": {"title": "a"}}]`)
+	assert.Equal(http.StatusMethodNotAllowed, resp.Code)
+	assert.Contains(resp.Body.String(), "Operation 1: create is disabled for widgets")
+
+	resp = serveConfig(api, "POST", "http://foo.com/api/v1/widgets/batch",
+		`[{"method": "read", "id": "1"}]`)
+	assert.Equal(http.StatusMultiStatus, resp.Code)
+
+	resp = serveConfig(api, "GET", "http://foo.com/api/v1/gadgets/1", "")
+	assert.Equal(http.StatusNotFound, resp.Code)
+}
+
+// Ensures that a RegistrationConfig can be loaded from YAML.
+func TestRegisterConfigYAML(t *testing.T) {
+	assert := assert.New(t)
+	var config RegistrationConfig
+	err := yaml.Unmarshal([]byte(`
+resources:
+- handler: widgets
+  operations: [read]
+  rules:
+  - field: ID
+    field_alias: key
+`), &config)
+	if !assert.NoError(err) {
+		return
+	}
+	api := NewAPI(&Configuration{})
+	assert.NoError(api.RegisterConfig(&config, configBindings()))
+
+	resp := serveConfig(api, "GET", "http://foo.com/api/v1/widget/1", "")
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Contains(resp.Body.String(), `"result":{"key":"1"}`)
+	assert.Empty(resp.Header().Get("X-Tagged"))
+}
+
+// Ensures that invalid RegistrationConfigs are rejected without registering any
+// resources.
+func TestRegisterConfigInvalid(t *testing.T) {
+	assert := assert.New(t)
+	valid := ResourceConfig{Name: "widgets", Handler: "widgets"}
+
+	for _, tc := range []struct {
+		resource ResourceConfig
+		err      string
+	}{
+		{ResourceConfig{Handler: "gizmos"}, `no handler bound to "gizmos"`},
+		{ResourceConfig{Handler: "widgets", Middleware: []string{"missing"}},
+			`no middleware bound to "missing"`},
+		{ResourceConfig{Handler: "widgets", Operations: []string{"explode"}},
+			`unknown operation "explode"`},
+		{ResourceConfig{Handler: "widgets", Rules: []RuleConfig{{Type: "uuid"}}},
+			`unknown type "uuid" for rule 0`},
+		{ResourceConfig{Handler: "widgets", Rules: []RuleConfig{{Field: "Color"}}},
+			"field 'Color' does not exist"},
+		{ResourceConfig{Name: "widgets", Handler: "widgets"},
+			"widgets is declared more than once"},
+	} {
+		api := NewAPI(&Configuration{})
+		config := &RegistrationConfig{Resources: []ResourceConfig{valid, tc.resource}}
+		err := api.RegisterConfig(config, configBindings())
+		if assert.Error(err) {
+			assert.Contains(err.Error(), "Invalid resource 1 in registration config")
+			assert.Contains(err.Error(), tc.err)
+		}
+		assert.Empty(api.ResourceHandlers())
+	}
+
+	_, err := ParseRegistrationConfig([]byte(`{"resources": [{"handlr": "widgets"}]}`))
+	assert.Error(err)
+}
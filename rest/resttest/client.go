@@ -0,0 +1,118 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resttest
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Workiva/go-rest/rest"
+)
+
+// Client performs operations against the resources of an API in-process, decoding
+// results into typed values. Requests are built from the API's registered routes, so
+// they follow its base path and MountPrefix.
+type Client struct {
+	api rest.API
+
+	// Version is the API version requests are made for.
+	Version string
+
+	// Header is sent with every request, e.g. for authentication.
+	Header http.Header
+}
+
+// NewClient returns a Client performing operations against the API for the version.
+func NewClient(api rest.API, version string) *Client {
+	return &Client{api: api, Version: version, Header: http.Header{}}
+}
+
+// Create creates a resource from the data and stores the created resource in the value
+// pointed to by result, which may be nil. Failed requests return a rest.Error with the
+// response status and first message.
+func (c *Client) Create(resource string, data, result interface{}) error {
+	_, err := c.do(resource, rest.HandleCreate, "POST", "", nil, data, result)
+	return err
+}
+
+// Read reads the resource with the ID into the value pointed to by result, which may
+// be nil.
+func (c *Client) Read(resource, id string, result interface{}) error {
+	_, err := c.do(resource, rest.HandleRead, "GET", id, nil, nil, result)
+	return err
+}
+
+// ReadList reads the resources matching the query, which may be nil, into the slice
+// pointed to by results. It returns the URL of the next page of results, if any.
+func (c *Client) ReadList(resource string, query url.Values, results interface{}) (string, error) {
+	resp, err := c.do(resource, rest.HandleReadList, "GET", "", query, nil, results)
+	if err != nil {
+		return "", err
+	}
+	return resp.Next, nil
+}
+
+// Update updates the resource with the ID from the data and stores the updated
+// resource in the value pointed to by result, which may be nil.
+func (c *Client) Update(resource, id string, data, result interface{}) error {
+	_, err := c.do(resource, rest.HandleUpdate, "PUT", id, nil, data, result)
+	return err
+}
+
+// Delete deletes the resource with the ID and stores the deleted resource in the value
+// pointed to by result, which may be nil.
+func (c *Client) Delete(resource, id string, result interface{}) error {
+	_, err := c.do(resource, rest.HandleDelete, "DELETE", id, nil, nil, result)
+	return err
+}
+
+// do performs the operation on the resource and decodes the response result into the
+// value pointed to by result if it's not nil. A rest.Error is returned if the request
+// fails.
+func (c *Client) do(resource string, operation rest.HandleMethod, method, id string,
+	query url.Values, data, result interface{}) (*Response, error) {
+
+	u, err := c.api.URLFor(resource, operation, c.Version, id)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = query.Encode()
+
+	req := NewRequest(method, u.String(), data)
+	for key, values := range c.Header {
+		req.Header[key] = values
+	}
+
+	resp := DoRequest(c.api, req)
+	if resp.Status >= http.StatusBadRequest {
+		reason := resp.Reason
+		if len(resp.Messages) > 0 {
+			reason = resp.Messages[0]
+		} else if reason == "" {
+			reason = strings.TrimSpace(string(resp.Body))
+		}
+		return resp, rest.CustomError(reason, resp.Status)
+	}
+
+	if result != nil {
+		if err := resp.DecodeResult(result); err != nil {
+			return resp, err
+		}
+	}
+	return resp, nil
+}
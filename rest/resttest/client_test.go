@@ -0,0 +1,141 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resttest
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/Workiva/go-rest/rest"
+	"github.com/stretchr/testify/assert"
+)
+
+type widget struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type widgetResourceHandler struct {
+	rest.BaseResourceHandler
+	widgets map[string]widget
+}
+
+func (w *widgetResourceHandler) ResourceName() string {
+	return "widgets"
+}
+
+func (w *widgetResourceHandler) Authenticate(r *http.Request) error {
+	if r.Header.Get("Authorization") != "secret" {
+		return errors.New("Not authorized")
+	}
+	return nil
+}
+
+func (w *widgetResourceHandler) CreateResource(ctx rest.RequestContext, data rest.Payload,
+	version string) (rest.Resource, error) {
+
+	name, _ := data.String("name")
+	created := widget{ID: strconv.Itoa(len(w.widgets) + 1), Name: name}
+	w.widgets[created.ID] = created
+	return created, nil
+}
+
+func (w *widgetResourceHandler) ReadResource(ctx rest.RequestContext, id string,
+	version string) (rest.Resource, error) {
+
+	if found, ok := w.widgets[id]; ok {
+		return found, nil
+	}
+	return nil, rest.ResourceNotFound("No widget " + id)
+}
+
+func (w *widgetResourceHandler) ReadResourceList(ctx rest.RequestContext, limit int,
+	cursor string, version string) ([]rest.Resource, string, error) {
+
+	resources := []rest.Resource{}
+	for i := 1; i <= len(w.widgets); i++ {
+		resources = append(resources, w.widgets[strconv.Itoa(i)])
+	}
+	return resources, "next", nil
+}
+
+func (w *widgetResourceHandler) UpdateResource(ctx rest.RequestContext, id string,
+	data rest.Payload, version string) (rest.Resource, error) {
+
+	updated := w.widgets[id]
+	updated.Name, _ = data.String("name")
+	w.widgets[id] = updated
+	return updated, nil
+}
+
+func (w *widgetResourceHandler) DeleteResource(ctx rest.RequestContext, id string,
+	version string) (rest.Resource, error) {
+
+	deleted := w.widgets[id]
+	delete(w.widgets, id)
+	return deleted, nil
+}
+
+// Ensures that Client performs CRUD operations and decodes typed results.
+func TestClient(t *testing.T) {
+	assert := assert.New(t)
+	api := rest.NewAPI(&rest.Configuration{MountPrefix: "/v0"})
+	api.RegisterResourceHandler(&widgetResourceHandler{widgets: map[string]widget{}})
+	client := NewClient(api, "1")
+	client.Header.Set("Authorization", "secret")
+
+	var created widget
+	assert.NoError(client.Create("widgets", map[string]string{"name": "foo"}, &created))
+	assert.Equal(widget{ID: "1", Name: "foo"}, created)
+
+	var read widget
+	assert.NoError(client.Read("widgets", "1", &read))
+	assert.Equal(created, read)
+
+	var widgets []widget
+	next, err := client.ReadList("widgets", url.Values{"limit": {"10"}}, &widgets)
+	assert.NoError(err)
+	assert.Equal("http://example.com/v0/api/v1/widgets?limit=10&next=next", next)
+	assert.Equal([]widget{created}, widgets)
+
+	var updated widget
+	assert.NoError(client.Update("widgets", "1", map[string]string{"name": "bar"}, &updated))
+	assert.Equal(widget{ID: "1", Name: "bar"}, updated)
+
+	assert.NoError(client.Delete("widgets", "1", nil))
+
+	err = client.Read("widgets", "1", &read)
+	if assert.Error(err) {
+		assert.Equal(rest.ResourceNotFound("No widget 1"), err)
+	}
+}
+
+// Ensures that Client returns errors for failed requests and unknown resources.
+func TestClientErrors(t *testing.T) {
+	assert := assert.New(t)
+	api := rest.NewAPI(&rest.Configuration{})
+	api.RegisterResourceHandler(&widgetResourceHandler{widgets: map[string]widget{}})
+	client := NewClient(api, "1")
+
+	err := client.Read("widgets", "1", nil)
+	assert.Equal(rest.UnauthorizedRequest("Not authorized"), err)
+
+	assert.Error(client.Read("gadgets", "1", nil))
+}
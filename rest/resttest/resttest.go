@@ -0,0 +1,173 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package resttest provides utilities for testing ResourceHandlers and APIs without
+// starting a server.
+package resttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/Workiva/go-rest/rest"
+	"github.com/gorilla/mux"
+)
+
+// NewRequest returns a request for the method and target, which is a path or absolute
+// URL, with the body encoded as JSON. The body may be nil. It panics if the body can't
+// be encoded.
+func NewRequest(method, target string, body interface{}) *http.Request {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			panic(fmt.Sprintf("resttest: failed to encode request body: %s", err))
+		}
+		reader = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, target, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req
+}
+
+// NewContext returns a RequestContext for the method, target, and JSON-encoded body
+// with the given path variables, e.g. "version" and "resource_id", for invoking
+// ResourceHandler methods directly.
+func NewContext(method, target string, body interface{}, vars map[string]string) rest.RequestContext {
+	req := NewRequest(method, target, body)
+	if vars != nil {
+		req = mux.SetURLVars(req, vars)
+	}
+	return rest.NewContext(nil, req, httptest.NewRecorder())
+}
+
+// Response is the response to a request served by Do. The envelope fields are decoded
+// from the body if it's a JSON object. Otherwise, Status is the HTTP status code.
+type Response struct {
+	rest.Response
+
+	// Body is the raw response body.
+	Body []byte
+}
+
+// Do serves a request for the method and target, which is a path or absolute URL, with
+// the handler, typically an API, and returns the response.
+func Do(handler http.Handler, method, target string) *Response {
+	return DoRequest(handler, NewRequest(method, target, nil))
+}
+
+// DoJSON serves a request for the method and target with the body encoded as JSON with
+// the handler and returns the response.
+func DoJSON(handler http.Handler, method, target string, body interface{}) *Response {
+	return DoRequest(handler, NewRequest(method, target, body))
+}
+
+// DoRequest serves the request with the handler and returns the response.
+func DoRequest(handler http.Handler, req *http.Request) *Response {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	resp := &Response{Body: recorder.Body.Bytes()}
+	resp.Raw = recorder.Result()
+	resp.Status = recorder.Code
+	resp.Messages = []string{}
+
+	var envelope struct {
+		Status   int           `json:"status"`
+		Reason   string        `json:"reason"`
+		Messages []string      `json:"messages"`
+		Next     string        `json:"next"`
+		Result   interface{}   `json:"result"`
+		Results  []interface{} `json:"results"`
+	}
+	if json.Unmarshal(resp.Body, &envelope) != nil {
+		return resp
+	}
+	if envelope.Status != 0 {
+		resp.Status = envelope.Status
+	}
+	resp.Reason = envelope.Reason
+	if envelope.Messages != nil {
+		resp.Messages = envelope.Messages
+	}
+	resp.Next = envelope.Next
+	resp.Result = envelope.Result
+	if envelope.Results != nil {
+		resp.Result = envelope.Results
+	}
+	return resp
+}
+
+// DecodeResult stores the response result in the value pointed to by v, converting it
+// as encoding/json would.
+func (r *Response) DecodeResult(v interface{}) error {
+	data, err := json.Marshal(r.Result)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// AssertStatus reports a test error if the response doesn't have the status. It
+// returns true if it does.
+func (r *Response) AssertStatus(t testing.TB, status int) bool {
+	t.Helper()
+	if r.Status != status {
+		t.Errorf("Expected status %d, got %d: %s", status, r.Status, r.Body)
+		return false
+	}
+	return true
+}
+
+// AssertResult reports a test error if the response result isn't equal to the expected
+// value once both are encoded as JSON. It returns true if they're equal.
+func (r *Response) AssertResult(t testing.TB, expected interface{}) bool {
+	t.Helper()
+	data, err := json.Marshal(expected)
+	if err != nil {
+		t.Errorf("Failed to encode expected result: %s", err)
+		return false
+	}
+	var normalized interface{}
+	json.Unmarshal(data, &normalized)
+	if !reflect.DeepEqual(normalized, r.Result) {
+		t.Errorf("Expected result %s, got %s", data, r.Body)
+		return false
+	}
+	return true
+}
+
+// AssertMessages reports a test error if the response doesn't have exactly the
+// messages. It returns true if it does.
+func (r *Response) AssertMessages(t testing.TB, messages ...string) bool {
+	t.Helper()
+	if messages == nil {
+		messages = []string{}
+	}
+	if !reflect.DeepEqual(messages, r.Messages) {
+		t.Errorf("Expected messages %q, got %q", messages, r.Messages)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resttest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Workiva/go-rest/rest"
+	"github.com/stretchr/testify/assert"
+)
+
+type fooResourceHandler struct {
+	rest.BaseResourceHandler
+}
+
+func (f *fooResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (f *fooResourceHandler) CreateResource(ctx rest.RequestContext, data rest.Payload,
+	version string) (rest.Resource, error) {
+
+	return rest.Payload{"id": "1", "name": data["name"]}, nil
+}
+
+func (f *fooResourceHandler) ReadResource(ctx rest.RequestContext, id string,
+	version string) (rest.Resource, error) {
+
+	if id != "1" {
+		return nil, rest.ResourceNotFound("No foo " + id)
+	}
+	return rest.Payload{"id": id, "version": version}, nil
+}
+
+// Ensures that NewContext builds a RequestContext with the path variables and body.
+func TestNewContext(t *testing.T) {
+	assert := assert.New(t)
+	ctx := NewContext("PUT", "/api/v2/foo/1", map[string]string{"name": "bar"},
+		map[string]string{"version": "2", "resource_id": "1"})
+
+	assert.Equal("2", ctx.Version())
+	assert.Equal("1", ctx.ResourceID())
+	assert.Equal(`{"name":"bar"}`, ctx.Body().String())
+
+	resource, err := (&fooResourceHandler{}).ReadResource(ctx, ctx.ResourceID(), ctx.Version())
+	assert.NoError(err)
+	assert.Equal(rest.Payload{"id": "1", "version": "2"}, resource)
+}
+
+// Ensures that Do serves requests with the API and decodes the response envelope.
+func TestDo(t *testing.T) {
+	assert := assert.New(t)
+	api := rest.NewAPI(&rest.Configuration{})
+	api.RegisterResourceHandler(&fooResourceHandler{})
+
+	resp := Do(api, "GET", "/api/v1/foo/1")
+	assert.True(resp.AssertStatus(t, http.StatusOK))
+	assert.True(resp.AssertResult(t, map[string]string{"id": "1", "version": "1"}))
+	assert.True(resp.AssertMessages(t))
+
+	var result struct{ ID string }
+	assert.NoError(resp.DecodeResult(&result))
+	assert.Equal("1", result.ID)
+
+	resp = Do(api, "GET", "/api/v1/foo/2")
+	assert.Equal(http.StatusNotFound, resp.Status)
+	assert.Equal([]string{"No foo 2"}, resp.Messages)
+
+	resp = DoJSON(api, "POST", "/api/v1/foo", map[string]string{"name": "bar"})
+	assert.Equal(http.StatusCreated, resp.Status)
+	assert.Equal(map[string]interface{}{"id": "1", "name": "bar"}, resp.Result)
+}
+
+// Ensures that responses which aren't envelopes keep their HTTP status.
+func TestDoRequestRaw(t *testing.T) {
+	assert := assert.New(t)
+	api := rest.NewAPI(&rest.Configuration{})
+	api.RegisterResourceHandler(&fooResourceHandler{}, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+			w.Write([]byte("short and stout"))
+		})
+	})
+
+	req := NewRequest("GET", "/api/v1/foo/1", nil)
+	resp := DoRequest(api, req)
+	assert.Equal(http.StatusTeapot, resp.Status)
+	assert.Equal("short and stout", string(resp.Body))
+	assert.Nil(resp.Result)
+}
+
+// Ensures that the assertions report mismatches.
+func TestAssertionsFail(t *testing.T) {
+	assert := assert.New(t)
+	resp := &Response{}
+	resp.Status = http.StatusOK
+	resp.Result = map[string]interface{}{"id": "1"}
+
+	mock := &testing.T{}
+	assert.False(resp.AssertStatus(mock, http.StatusCreated))
+	assert.False(resp.AssertResult(mock, map[string]string{"id": "2"}))
+	assert.False(resp.AssertMessages(mock, "foo"))
+	assert.True(mock.Failed())
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RetryableError returns an Error marking the error as retryable after the delay, e.g.
+// for a backend which is temporarily overloaded. Errors with a 429 Too Many Requests or
+// 503 Service Unavailable status keep it, while others are sent as 503 Service
+// Unavailable with their message.
+func RetryableError(err error, delay time.Duration) Error {
+	e, ok := err.(Error)
+	if !ok {
+		e = Error{reason: err.Error()}
+	}
+	if e.status != http.StatusTooManyRequests {
+		e.status = http.StatusServiceUnavailable
+	}
+	return e.WithRetryAfter(delay)
+}
+
+// retryAfterSeconds returns the Retry-After header value for the delay in whole
+// seconds, rounded up.
+func retryAfterSeconds(delay time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(delay.Seconds())))
+}
+
+// RetryStats contains the number of retryable error responses for a resource with a
+// status, which are counted separately from other errors since they indicate load or
+// transient failures rather than bugs.
+type RetryStats struct {
+	// Resource is the name of the resource the responses were for, or an empty string
+	// for endpoints which aren't a resource's.
+	Resource string
+
+	// Status is the HTTP status code of the responses, e.g. 503.
+	Status int
+
+	// Responses is the number of responses sent.
+	Responses int64
+
+	// LastResponse is when the most recent response was sent.
+	LastResponse time.Time
+}
+
+// retryKey identifies a RetryStats entry.
+type retryKey struct {
+	resource string
+	status   int
+}
+
+// retryMetrics tracks retryable error responses. It's safe for concurrent use.
+type retryMetrics struct {
+	mu    sync.Mutex
+	stats map[retryKey]*RetryStats
+}
+
+// newRetryMetrics returns a newly allocated retryMetrics.
+func newRetryMetrics() *retryMetrics {
+	return &retryMetrics{stats: map[retryKey]*RetryStats{}}
+}
+
+// record adds a retryable error response for the resource to the metrics.
+func (m *retryMetrics) record(resource string, status int, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := retryKey{resource, status}
+	stats, ok := m.stats[key]
+	if !ok {
+		stats = &RetryStats{Resource: resource, Status: status}
+		m.stats[key] = stats
+	}
+	stats.Responses++
+	stats.LastResponse = now
+}
+
+// snapshot returns a copy of the metrics sorted by resource and status.
+func (m *retryMetrics) snapshot() []RetryStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := make([]RetryStats, 0, len(m.stats))
+	for _, s := range m.stats {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Resource != stats[j].Resource {
+			return stats[i].Resource < stats[j].Resource
+		}
+		return stats[i].Status < stats[j].Status
+	})
+	return stats
+}
+
+// applyRetryAfter sets the Retry-After header of the response if the request failed
+// with a retryable Error which has a delay, and records the response in the retry
+// metrics.
+func (h requestHandler) applyRetryAfter(ctx RequestContext) {
+	e, ok := ctx.Error().(Error)
+	if !ok || !e.Retryable() {
+		return
+	}
+
+	if e.RetryAfter() > 0 {
+		ctx.ResponseWriter().Header().Set("Retry-After", retryAfterSeconds(e.RetryAfter()))
+	}
+
+	resource := ""
+	if req, ok := ctx.Request(); ok {
+		resource = strings.SplitN(routeName(req), ":", 2)[0]
+	}
+	h.retries().record(resource, e.Status(), time.Now())
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type retryResourceHandler struct {
+	BaseResourceHandler
+}
+
+func (r *retryResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (r *retryResourceHandler) ReadResource(ctx RequestContext, id,
+	version string) (Resource, error) {
+
+	switch id {
+	case "busy":
+		return nil, RetryableError(errors.New("Database is busy"), 2*time.Second)
+	case "throttled":
+		return nil, TooManyRequests("Slow down").WithRetryAfter(500 * time.Millisecond)
+	case "unavailable":
+		return nil, ServiceUnavailable("Try again")
+	}
+	return nil, ResourceNotFound("No foo " + id)
+}
+
+// Ensures that RetryableError preserves 429 and 503 statuses and converts others to
+// 503.
+func TestRetryableError(t *testing.T) {
+	assert := assert.New(t)
+
+	err := RetryableError(errors.New("busy"), time.Second)
+	assert.Equal(http.StatusServiceUnavailable, err.Status())
+	assert.Equal("busy", err.Error())
+	assert.True(err.Retryable())
+	assert.Equal(time.Second, err.RetryAfter())
+
+	err = RetryableError(TooManyRequests("slow down"), time.Minute)
+	assert.Equal(http.StatusTooManyRequests, err.Status())
+	assert.Equal(time.Minute, err.RetryAfter())
+
+	err = RetryableError(BadRequest("oops").WithCode("oops"), 0)
+	assert.Equal(http.StatusServiceUnavailable, err.Status())
+	assert.Equal("oops", err.Code())
+
+	assert.False(BadRequest("oops").Retryable())
+	assert.True(ServiceUnavailable("down").Retryable())
+}
+
+// Ensures that retryable errors are sent with a Retry-After header, if they have a
+// delay, and counted in RetryStats.
+func TestRetryAfterResponses(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&retryResourceHandler{})
+
+	read := func(id string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "http://example.com/api/v1/foo/"+id, nil)
+		resp := httptest.NewRecorder()
+		api.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := read("busy")
+	assert.Equal(http.StatusServiceUnavailable, resp.Code)
+	assert.Equal("2", resp.Header().Get("Retry-After"))
+	assert.Contains(resp.Body.String(), "Database is busy")
+
+	resp = read("throttled")
+	assert.Equal(http.StatusTooManyRequests, resp.Code)
+	assert.Equal("1", resp.Header().Get("Retry-After"))
+
+	resp = read("unavailable")
+	assert.Equal(http.StatusServiceUnavailable, resp.Code)
+	assert.Empty(resp.Header().Get("Retry-After"))
+
+	resp = read("missing")
+	assert.Equal(http.StatusNotFound, resp.Code)
+	assert.Empty(resp.Header().Get("Retry-After"))
+
+	stats := api.RetryStats()
+	if assert.Len(stats, 2) {
+		assert.Equal("foo", stats[0].Resource)
+		assert.Equal(http.StatusTooManyRequests, stats[0].Status)
+		assert.Equal(int64(1), stats[0].Responses)
+		assert.Equal(http.StatusServiceUnavailable, stats[1].Status)
+		assert.Equal(int64(2), stats[1].Responses)
+		assert.False(stats[1].LastResponse.IsZero())
+	}
+}
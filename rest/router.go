@@ -0,0 +1,154 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	gcontext "github.com/gorilla/context"
+	"github.com/gorilla/mux"
+)
+
+// Router dispatches requests to the handlers bound to its routes. The default Router is
+// backed by gorilla/mux, but another can be configured with the Configuration's Router,
+// e.g. an adapter for httprouter or chi. Route templates use the gorilla/mux syntax,
+// where variables are enclosed in braces and optionally followed by a regular
+// expression, e.g. /api/v{version:[^/]+}/foo/{resource_id}, so adapters must translate
+// them.
+//
+// Per-version ResourceHandler registration and StrictSlash rely on gorilla/mux matchers
+// and are only supported by the default Router.
+type Router interface {
+	http.Handler
+
+	// Handle binds the handler to requests with one of the methods, or any method if
+	// none are given, whose paths match the template. The route is named, unless the
+	// name is empty, so URLs can be built for it. An error is returned if the route
+	// can't be bound.
+	Handle(name, template string, methods []string, handler http.Handler) error
+
+	// HandlePrefix binds the handler to requests whose paths begin with the prefix. An
+	// error is returned if the route can't be bound.
+	HandlePrefix(prefix string, handler http.Handler) error
+
+	// Vars returns the path variables of a request dispatched by the Router.
+	Vars(*http.Request) map[string]string
+
+	// URL returns the URL of the named route with the variables substituted into its
+	// template. An error is returned if there's no such route or a variable is missing.
+	URL(name string, vars map[string]string) (*url.URL, error)
+}
+
+// GorillaRouter is the default Router, backed by a gorilla/mux Router.
+type GorillaRouter struct {
+	router *mux.Router
+
+	// versions are the versions served by the routes of a router matching request
+	// versions, if any.
+	versions []string
+}
+
+// NewGorillaRouter returns a Router dispatching requests with the gorilla/mux Router.
+func NewGorillaRouter(router *mux.Router) *GorillaRouter {
+	return &GorillaRouter{router: router}
+}
+
+// Mux returns the underlying gorilla/mux Router.
+func (g *GorillaRouter) Mux() *mux.Router {
+	return g.router
+}
+
+// ServeHTTP dispatches the request to the handler of the matching route.
+func (g *GorillaRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.router.ServeHTTP(w, r)
+}
+
+// Handle binds the handler to requests with one of the methods, or any method if none
+// are given, whose paths match the template.
+func (g *GorillaRouter) Handle(name, template string, methods []string,
+	handler http.Handler) error {
+
+	route := g.router.Handle(template, handler)
+	if len(methods) > 0 {
+		route.Methods(methods...)
+	}
+	if name != "" {
+		route.Name(name)
+	}
+	return route.GetError()
+}
+
+// HandlePrefix binds the handler to requests whose paths begin with the prefix.
+func (g *GorillaRouter) HandlePrefix(prefix string, handler http.Handler) error {
+	return g.router.PathPrefix(prefix).Handler(handler).GetError()
+}
+
+// Vars returns the path variables of a request dispatched by the Router.
+func (g *GorillaRouter) Vars(r *http.Request) map[string]string {
+	return mux.Vars(r)
+}
+
+// URL returns the URL of the named route with the variables substituted into its
+// template.
+func (g *GorillaRouter) URL(name string, vars map[string]string) (*url.URL, error) {
+	route := g.router.Get(name)
+	if route == nil {
+		return nil, fmt.Errorf("No route named %s", name)
+	}
+	pairs := make([]string, 0, len(vars)*2)
+	for key, value := range vars {
+		pairs = append(pairs, key, value)
+	}
+	return route.URL(pairs...)
+}
+
+// routeVars is the route name and path variables of a request dispatched by a Router
+// other than the default.
+type routeVars struct {
+	name string
+	vars map[string]string
+}
+
+// withRouteVars returns a Handler which records the route name and the path variables
+// provided by the Router for the request before invoking the handler.
+func withRouteVars(router Router, name string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gcontext.Set(r, routeVarsKey, routeVars{name: name, vars: router.Vars(r)})
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// pathVars returns the path variables of the request.
+func pathVars(r *http.Request) map[string]string {
+	if route, ok := gcontext.Get(r, routeVarsKey).(routeVars); ok {
+		return route.vars
+	}
+	return mux.Vars(r)
+}
+
+// routeName returns the name of the route the request was dispatched to.
+func routeName(r *http.Request) string {
+	if route, ok := gcontext.Get(r, routeVarsKey).(routeVars); ok {
+		return route.name
+	}
+	if route := mux.CurrentRoute(r); route != nil {
+		return route.GetName()
+	}
+	return ""
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// templateVar matches the variables of gorilla/mux route templates.
+var templateVar = regexp.MustCompile(`\{([^:}]+)(?::([^}]+))?\}`)
+
+// regexpVarsKey is the request context key of the path variables set by regexpRouter.
+type regexpVarsKey struct{}
+
+// regexpRoute is a route bound on a regexpRouter.
+type regexpRoute struct {
+	name     string
+	template string
+	pattern  *regexp.Regexp
+	methods  []string
+	handler  http.Handler
+}
+
+// regexpRouter is a minimal Router matching route templates with regular expressions.
+type regexpRouter struct {
+	routes []regexpRoute
+}
+
+func (r *regexpRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	for _, route := range r.routes {
+		match := route.pattern.FindStringSubmatch(req.URL.Path)
+		if match == nil || (len(route.methods) > 0 && !hasVersion(route.methods, req.Method)) {
+			continue
+		}
+		vars := map[string]string{}
+		for i, name := range route.pattern.SubexpNames() {
+			if name != "" {
+				vars[name] = match[i]
+			}
+		}
+		req = req.WithContext(context.WithValue(req.Context(), regexpVarsKey{}, vars))
+		route.handler.ServeHTTP(w, req)
+		return
+	}
+	http.NotFound(w, req)
+}
+
+func (r *regexpRouter) bind(name, template, suffix string, methods []string,
+	handler http.Handler) error {
+
+	expr, last := "^", 0
+	for _, match := range templateVar.FindAllStringSubmatchIndex(template, -1) {
+		name, pattern := template[match[2]:match[3]], "[^/]+"
+		if match[4] >= 0 {
+			pattern = template[match[4]:match[5]]
+		}
+		expr += regexp.QuoteMeta(template[last:match[0]]) + "(?P<" + name + ">" + pattern + ")"
+		last = match[1]
+	}
+	pattern, err := regexp.Compile(expr + regexp.QuoteMeta(template[last:]) + suffix)
+	if err != nil {
+		return err
+	}
+	r.routes = append(r.routes, regexpRoute{name, template, pattern, methods, handler})
+	return nil
+}
+
+func (r *regexpRouter) Handle(name, template string, methods []string,
+	handler http.Handler) error {
+
+	return r.bind(name, template, "$", methods, handler)
+}
+
+func (r *regexpRouter) HandlePrefix(prefix string, handler http.Handler) error {
+	return r.bind("", prefix, "", nil, handler)
+}
+
+func (r *regexpRouter) Vars(req *http.Request) map[string]string {
+	vars, _ := req.Context().Value(regexpVarsKey{}).(map[string]string)
+	return vars
+}
+
+func (r *regexpRouter) URL(name string, vars map[string]string) (*url.URL, error) {
+	for _, route := range r.routes {
+		if route.name == name {
+			path := templateVar.ReplaceAllStringFunc(route.template, func(v string) string {
+				return vars[templateVar.FindStringSubmatch(v)[1]]
+			})
+			return &url.URL{Path: path}, nil
+		}
+	}
+	return nil, fmt.Errorf("No route named %s", name)
+}
+
+// Ensures that a configured Router dispatches requests to ResourceHandlers with their
+// path variables.
+func TestCustomRouter(t *testing.T) {
+	assert := assert.New(t)
+	router := &regexpRouter{}
+	api := NewAPI(&Configuration{Router: router, MountPrefix: "/v0/"})
+	api.RegisterResourceHandler(&gatewayResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/v0/api/v1/foo/7", nil)
+	req.Header.Set("Authorization", "secret")
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusNotFound, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), "No foo 7")
+
+	req, _ = http.NewRequest("POST", "http://foo.com/v0/api/v2/foo", strings.NewReader(`{"foo": "bar"}`))
+	req.Header.Set("Authorization", "secret")
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusCreated, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), `"version":"2"`)
+
+	route, ok := findRoute(api.Routes(), "foo", string(HandleRead))
+	if assert.True(ok) {
+		assert.Equal("/v0/api/v{version:[^/]+}/foo/{resource_id}", route.Path)
+	}
+}
+
+// Ensures that Dispatch builds request URLs with a configured Router.
+func TestCustomRouterDispatch(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{Router: &regexpRouter{}})
+	api.RegisterResourceHandler(&gatewayResourceHandler{})
+
+	resp, err := api.Dispatch(context.Background(), GatewayRequest{
+		Method:   HandleRead,
+		Resource: "foo",
+		ID:       "7",
+		Version:  "1",
+		Header:   http.Header{"Authorization": {"secret"}},
+	})
+
+	assert.NoError(err)
+	assert.Equal(http.StatusNotFound, resp.Status)
+	assert.Equal([]interface{}{"No foo 7"}, resp.Body["messages"])
+}
+
+// Ensures that per-version registration is ignored by Routers which don't support it.
+func TestCustomRouterVersions(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{Router: &regexpRouter{}})
+	api.RegisterResourceHandlerForVersions(&versionedResourceHandler{name: "old"}, "1")
+
+	_, ok := findRoute(api.Routes(), "foo", string(HandleRead))
+	assert.False(ok)
+	assert.Empty(api.ResourceHandlers())
+}
+
+// Ensures that GorillaRouter builds URLs for named routes.
+func TestGorillaRouterURL(t *testing.T) {
+	assert := assert.New(t)
+	router := NewGorillaRouter(mux.NewRouter())
+	assert.NoError(router.Handle("foo:read", "/foo/{id}", []string{"GET"},
+		http.NotFoundHandler()))
+
+	u, err := router.URL("foo:read", map[string]string{"id": "7"})
+	if assert.NoError(err) {
+		assert.Equal("/foo/7", u.String())
+	}
+
+	_, err = router.URL("bar:read", nil)
+	assert.Error(err)
+}
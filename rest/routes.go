@@ -0,0 +1,79 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import "net/http"
+
+// RouteInfo describes a route registered with the API.
+type RouteInfo struct {
+	// Resource is the name of the resource the route serves, if any.
+	Resource string `json:"resource,omitempty"`
+
+	// Operation is the operation the route performs, e.g. "read" or "create".
+	Operation string `json:"operation,omitempty"`
+
+	// Methods are the HTTP methods the route matches. If empty, it matches all methods.
+	Methods []string `json:"methods,omitempty"`
+
+	// Path is the path template the route matches, e.g. /api/v{version:[^/]+}/foo/{id}.
+	Path string `json:"path"`
+
+	// Versions are the versions the route serves. If empty, the route serves all
+	// versions.
+	Versions []string `json:"versions,omitempty"`
+}
+
+// Routes returns the routes registered with the API in the order they're matched.
+func (r *muxAPI) Routes() []RouteInfo {
+	r.compileRoutes()
+	r.routesMu.Lock()
+	defer r.routesMu.Unlock()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	routes := make([]RouteInfo, len(r.routeInfos))
+	copy(routes, r.routeInfos)
+	for i, info := range routes {
+		if info.Versions != nil || info.Resource == "" {
+			continue
+		}
+		if handler, ok := r.resourceHandler(info.Resource); ok {
+			routes[i].Versions = handler.ValidVersions()
+		}
+	}
+	return routes
+}
+
+// registerRoutesRoute binds the endpoint listing the API's routes under the debug
+// prefix. Requests are authenticated with DebugAuthenticate if it's configured.
+func (r *muxAPI) registerRoutesRoute() {
+	var handler http.Handler = r.handler.handleRoutes()
+	if r.config.DebugAuthenticate != nil {
+		handler = newAuthMiddleware(r.config.DebugAuthenticate)(handler)
+	}
+	r.addRoutes(nil, func(routes Router) {
+		r.bind(routes, "routes", "GET", debugPrefix(r.config)+"/routes", handler)
+	})
+}
+
+// handleRoutes returns a Handler which responds with the API's routes.
+func (h requestHandler) handleRoutes() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := h.newContext(w, r)
+		h.sendResponse(ctx.setResult(h.Routes()).setStatus(http.StatusOK))
+	})
+}
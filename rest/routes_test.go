@@ -0,0 +1,105 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// findRoute returns the RouteInfo for the resource operation.
+func findRoute(routes []RouteInfo, resource, operation string) (RouteInfo, bool) {
+	for _, route := range routes {
+		if route.Resource == resource && route.Operation == operation {
+			return route, true
+		}
+	}
+	return RouteInfo{}, false
+}
+
+// Ensures that Routes describes the registered routes.
+func TestRoutes(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&headerVersionResourceHandler{})
+	api.RegisterResourceHandlerForVersions(&versionedResourceHandler{name: "old"}, "3")
+	api.RegisterHandlerFunc("/health", func(w http.ResponseWriter, r *http.Request) {})
+
+	routes := api.Routes()
+
+	route, ok := findRoute(routes, "foo", string(HandleRead))
+	if assert.True(ok) {
+		assert.Equal(RouteInfo{
+			Resource:  "foo",
+			Operation: "read",
+			Methods:   []string{"GET"},
+			Path:      "/api/v{version:[^/]+}/foo/{resource_id}",
+			Versions:  []string{"1", "2"},
+		}, route)
+	}
+
+	route, ok = findRoute(routes, "foo", string(HandleCreate))
+	if assert.True(ok) {
+		assert.Equal([]string{"POST"}, route.Methods)
+		assert.Equal("/api/v{version:[^/]+}/foo", route.Path)
+	}
+
+	route, ok = findRoute(routes, "operations", string(HandleRead))
+	if assert.True(ok) {
+		assert.Nil(route.Versions)
+	}
+
+	versioned := 0
+	for _, route := range routes {
+		if route.Resource == "foo" && len(route.Versions) == 1 {
+			assert.Equal([]string{"3"}, route.Versions)
+			versioned++
+		}
+	}
+	assert.True(versioned > 0)
+
+	route, ok = findRoute(routes, "", "")
+	if assert.True(ok) {
+		assert.Equal("/health", route.Path)
+		assert.Nil(route.Methods)
+	}
+}
+
+// Ensures that the routes endpoint lists the routes when RouteDebug is enabled.
+func TestRouteDebug(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{RouteDebug: true})
+	api.RegisterResourceHandler(&headerResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/debug/routes", nil)
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(),
+		`{"resource":"foo","operation":"read","methods":["GET"],"path":"/api/v{version:[^/]+}/foo/{resource_id}"}`)
+	assert.Contains(resp.Body.String(), `{"operation":"routes","methods":["GET"],"path":"/debug/routes"}`)
+
+	api = NewAPI(&Configuration{})
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusNotFound, resp.Code, "Incorrect response code")
+}
@@ -210,6 +210,20 @@ type Rule struct {
 	// Indicates if the Rule should only be applied to responses.
 	OutputOnly bool
 
+	// Indicates if the field identifies the resource. Its value is used to build the
+	// Location header of create responses.
+	Identifier bool
+
+	// Roles restricts the field to responses for identities granted at least one of the
+	// roles, e.g. "admin". If empty, the field is visible to every identity, including
+	// unauthenticated clients. Fields an identity can't see are also omitted from the
+	// targets of its PATCH requests. Roles don't restrict requests.
+	Roles []string
+
+	// ExcludeRoles hides the field from responses for identities granted any of the
+	// roles. It takes precedence over Roles.
+	ExcludeRoles []string
+
 	// Function which produces the field value to receive.
 	InputHandler func(interface{}) interface{}
 
@@ -251,6 +265,21 @@ func (r Rule) Applies(version string) bool {
 	return false
 }
 
+// VisibleTo returns whether or not the field is included in responses for the Identity,
+// which is nil for unauthenticated clients.
+func (r Rule) VisibleTo(identity *Identity) bool {
+	if identity.hasAnyRole(r.ExcludeRoles) {
+		return false
+	}
+	return len(r.Roles) == 0 || identity.hasAnyRole(r.Roles)
+}
+
+// restricted returns whether or not the Rule restricts the identities its field is
+// visible to.
+func (r Rule) restricted() bool {
+	return len(r.Roles) > 0 || len(r.ExcludeRoles) > 0
+}
+
 // validType returns whether or not the Rule is valid for the given reflect.Type.
 func (r Rule) validType(fieldType reflect.Type) bool {
 	if r.Type == Unspecified {
@@ -281,55 +310,70 @@ func applyInboundRules(payload Payload, rules Rules, version string) (Payload, e
 	}
 
 	// Apply only inbound Rules.
-	rules = rules.Filter(true).ForVersion(version)
+	plan := rulePlanFor(rules, Inbound, version)
 
-	if rules.Size() == 0 {
+	if len(plan.fields) == 0 {
 		return payload, nil
 	}
 
 	newPayload := Payload{}
+	errs := FieldErrors{}
 
-fieldLoop:
 	for field, value := range payload {
-		for _, rule := range rules.Contents() {
-			if rule.Name() == field {
-				if nestedInboundRulesApply(value, rule.Rules, version) {
-					// Nested Rules take precedence over type coercion.
-					v, err := applyNestedInboundRules(value, rule.Rules, version)
-					if err != nil {
-						return nil, err
-					}
-					value = v
-				} else if rule.Type != Unspecified {
-					// Coerce to specified type.
-					coerced, err := coerceType(value, rule.Type)
-					if err != nil {
-						return nil, err
-					}
-					value = coerced
-				}
-
-				if rule.InputHandler != nil {
-					value = rule.InputHandler(value)
-				}
+		planned, ok := plan.byName[field]
+		if !ok {
+			log.Printf("Discarding field '%s'", field)
+			continue
+		}
 
-				newPayload[field] = value
-				continue fieldLoop
+		rule := planned.rule
+		if nestedInboundRulesApply(value, planned.nested, version) {
+			// Nested Rules take precedence over type coercion.
+			v, err := applyNestedInboundRules(value, planned.nested, version)
+			if err != nil {
+				addFieldError(errs, field, err)
+				continue
 			}
+			value = v
+		} else if rule.Type != Unspecified {
+			// Coerce to specified type.
+			coerced, err := coerceType(value, rule.Type)
+			if err != nil {
+				addFieldError(errs, field, err)
+				continue
+			}
+			value = coerced
+		}
+
+		if rule.InputHandler != nil {
+			value = rule.InputHandler(value)
 		}
 
-		log.Printf("Discarding field '%s'", field)
+		newPayload[field] = value
 	}
 
 	// Ensure no required fields are missing.
-	if err := enforceRequiredFields(rules, newPayload); err != nil {
-		log.Println(err)
-		return nil, err
-	}
+	enforceRequiredFields(plan, payload, errs)
 
+	if len(errs) > 0 {
+		log.Println(errs)
+		return nil, errs
+	}
 	return newPayload, nil
 }
 
+// addFieldError adds the error for the field to the FieldErrors. Errors for the fields
+// of a nested value are added with the field name as a prefix, e.g. "address.city".
+func addFieldError(errs FieldErrors, field string, err error) {
+	if nested, ok := err.(FieldErrors); ok {
+		for nestedField, nestedErr := range nested {
+			errs[field+"."+nestedField] = nestedErr
+		}
+		return
+	}
+	errs[field] = UnprocessableRequest(err.Error()).WithCode(CodeInvalidField, field)
+}
+
 // applyNestedInboundRules recursively applies nested Rules which are not specified as
 // output only to the provided value.
 func applyNestedInboundRules(
@@ -401,7 +445,7 @@ func nestedInboundRulesApply(value interface{}, rules Rules, version string) boo
 		return false
 	}
 
-	return rules.Filter(Inbound).ForVersion(version).Size() > 0
+	return len(rulePlanFor(rules, Inbound, version).fields) > 0
 }
 
 // applyOutboundRules applies Rules which are not specified as input only to the
@@ -409,16 +453,25 @@ func nestedInboundRulesApply(value interface{}, rules Rules, version string) boo
 // map[string]interface{}, or no Rules are provided, this acts as an identity
 // function. If Rules are provided, only the fields specified by them will be
 // included in the returned Resource. This is to prevent new fields from leaking
-// into old API versions. If Rules specify nested Rules, they will be recursively
-// applied to field values.
+// into old API versions. Fields restricted to roles are only included if the Rules
+// are applied for an Identity which can see them. If Rules specify nested Rules,
+// they will be recursively applied to field values.
 func applyOutboundRules(resource Resource, rules Rules, version string) Resource {
+	if isNil(resource) {
+		return resource
+	}
+
 	// Apply only outbound Rules.
-	rules = rules.Filter(false).ForVersion(version)
+	plan := rulePlanFor(rules, Outbound, version)
 
-	if isNil(resource) || rules.Size() == 0 {
+	if len(plan.fields) == 0 && !plan.restricted {
 		// Return resource as-is if no Rules are provided.
 		return resource
 	}
+	if _, ok := resource.(*Content); ok {
+		// Content is sent as is.
+		return resource
+	}
 
 	// Get the underlying value by dereferencing the pointer if there is one.
 	resourceValue := reflect.Indirect(reflect.ValueOf(resource))
@@ -428,13 +481,13 @@ func applyOutboundRules(resource Resource, rules Rules, version string) Resource
 
 	if resourceType.Kind() == reflect.Map {
 		if resourceMap, ok := resource.(map[string]interface{}); ok {
-			payload = applyOutboundRulesForMap(resourceMap, rules, version)
+			payload = applyOutboundRulesForMap(resourceMap, plan, version)
 		} else {
 			// Nothing we can do if the keys aren't strings.
 			payload = resource
 		}
 	} else if resourceType.Kind() == reflect.Struct {
-		payload = applyOutboundRulesForStruct(resourceValue, rules, version)
+		payload = applyOutboundRulesForStruct(resourceValue, plan, version)
 	} else {
 		// Only apply Rules to resource structs and maps.
 		payload = resource
@@ -448,29 +501,25 @@ func applyOutboundRules(resource Resource, rules Rules, version string) Resource
 // If a Rule specifies nested Rules, they will be recursively applied to the corresponding
 // value.
 func applyOutboundRulesForMap(
-	resource map[string]interface{}, rules Rules, version string) Payload {
-
-	payload := Payload{}
-	for _, rule := range rules.Contents() {
-		if !rule.isResourceRule() {
-			// Non-resource Rules don't apply to output.
-			continue
-		}
+	resource map[string]interface{}, plan *rulePlan, version string) Payload {
 
+	payload := make(Payload, len(plan.fields))
+	for _, field := range plan.fields {
+		rule := field.rule
 		fieldValue, ok := resource[rule.Field]
 		if !ok {
 			log.Printf("Map resource missing field '%s'", rule.Field)
 			continue
 		}
 
-		if rule.Rules != nil {
-			fieldValue = applyNestedOutboundRules(fieldValue, rule, version)
+		if field.nested != nil {
+			fieldValue = applyNestedOutboundRules(fieldValue, field.nested, version)
 		}
 
 		if rule.OutputHandler != nil {
 			fieldValue = rule.OutputHandler(fieldValue)
 		}
-		payload[rule.Name()] = fieldValue
+		payload[field.name] = fieldValue
 	}
 
 	return payload
@@ -481,27 +530,23 @@ func applyOutboundRulesForMap(
 // instance of the type specified on the Rules. If a Rule specifies nested Rules, they
 // will be recursively applied to the corresponding value.
 func applyOutboundRulesForStruct(
-	resourceValue reflect.Value, rules Rules, version string) Payload {
+	resourceValue reflect.Value, plan *rulePlan, version string) Payload {
 
-	payload := Payload{}
-	for _, rule := range rules.Contents() {
-		if !rule.isResourceRule() {
-			// Non-resource Rules don't apply to output.
-			continue
-		}
+	payload := make(Payload, len(plan.fields))
+	for _, field := range plan.fields {
+		rule := field.rule
 
 		// Rule validation occurs at server start. No need to check for field existence.
-		field := resourceValue.FieldByName(rule.Field)
-		fieldValue := field.Interface()
+		fieldValue := plan.structField(resourceValue, field).Interface()
 
-		if rule.Rules != nil {
-			fieldValue = applyNestedOutboundRules(fieldValue, rule, version)
+		if field.nested != nil {
+			fieldValue = applyNestedOutboundRules(fieldValue, field.nested, version)
 		}
 
 		if rule.OutputHandler != nil {
 			fieldValue = rule.OutputHandler(fieldValue)
 		}
-		payload[rule.Name()] = fieldValue
+		payload[field.name] = fieldValue
 	}
 
 	return payload
@@ -509,7 +554,7 @@ func applyOutboundRulesForStruct(
 
 // applyNestedOutboundRules recursively applies nested Rules which are not specified as
 // input only to the provided Resource.
-func applyNestedOutboundRules(resource Resource, rule *Rule, version string) Resource {
+func applyNestedOutboundRules(resource Resource, rules Rules, version string) Resource {
 	var fieldValue Resource
 
 	if reflect.TypeOf(resource).Kind() == reflect.Slice {
@@ -518,36 +563,30 @@ func applyNestedOutboundRules(resource Resource, rule *Rule, version string) Res
 		nestedValues := make([]interface{}, s.Len())
 		for i := 0; i < s.Len(); i++ {
 			nestedValues[i] = applyOutboundRules(
-				s.Index(i).Interface(), rule.Rules, version)
+				s.Index(i).Interface(), rules, version)
 		}
 		fieldValue = nestedValues
 	} else {
-		fieldValue = applyOutboundRules(resource, rule.Rules, version)
+		fieldValue = applyOutboundRules(resource, rules, version)
 	}
 
 	return fieldValue
 }
 
 // enforceRequiredFields verifies that the provided Payload has values for any Rules
-// with the Required flag set to true. If any required fields are missing, an error
-// will be returned. Otherwise nil is returned.
-func enforceRequiredFields(rules Rules, payload Payload) error {
-ruleLoop:
-	for _, rule := range rules.Contents() {
+// with the Required flag set to true. An error is added to the FieldErrors for each
+// missing field.
+func enforceRequiredFields(plan *rulePlan, payload Payload, errs FieldErrors) {
+	for _, field := range plan.fields {
+		rule := field.rule
 		if !rule.Required {
 			continue
 		}
-
-		for field := range payload {
-			if rule.Name() == field {
-				continue ruleLoop
-			}
+		if _, ok := payload[rule.Name()]; !ok {
+			errs[rule.Name()] = UnprocessableRequest(fmt.Sprintf(
+				"Missing required field '%s'", rule.Name())).WithCode(CodeMissingField, rule.Name())
 		}
-
-		return fmt.Errorf("Missing required field '%s'", rule.Name())
 	}
-
-	return nil
 }
 
 // isNil returns true if the given Resource is a nil value or pointer, false if
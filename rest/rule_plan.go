@@ -0,0 +1,204 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import "reflect"
+
+// fieldPlan is a Rule prepared for application to resources or payloads.
+type fieldPlan struct {
+	rule *Rule
+	name string
+
+	// index is the index sequence of the Rule's field in the Rules' resource type, or
+	// nil if the field isn't in it.
+	index []int
+
+	// nested is the Rule's nested Rules, compiled if the Rules are.
+	nested Rules
+}
+
+// rulePlan contains the Rules which apply in one direction for a version, prepared
+// so applying them doesn't filter the Rules or look up fields by name.
+type rulePlan struct {
+	resourceType reflect.Type
+	fields       []*fieldPlan
+	byName       map[string]*fieldPlan
+
+	// restricted is whether any of the planned Rules, or their nested Rules, restrict
+	// the identities their field is visible to.
+	restricted bool
+}
+
+// newRulePlan returns the rulePlan for the Rules which pass the Filter and the applies
+// function. Nested Rules are taken from the given map, if they're in it.
+func newRulePlan(rules Rules, filter Filter, applies func(*Rule) bool,
+	nested map[*Rule]Rules) *rulePlan {
+
+	plan := &rulePlan{byName: map[string]*fieldPlan{}}
+	if rules == nil {
+		return plan
+	}
+	plan.resourceType = rules.ResourceType()
+	for _, rule := range rules.Filter(filter).Contents() {
+		if !applies(rule) {
+			continue
+		}
+		field := &fieldPlan{rule: rule, name: rule.Name(), nested: rule.Rules}
+		if compiled, ok := nested[rule]; ok {
+			field.nested = compiled
+		}
+		if plan.resourceType != nil && plan.resourceType.Kind() == reflect.Struct &&
+			rule.isResourceRule() {
+			if structField, ok := plan.resourceType.FieldByName(rule.Field); ok {
+				field.index = structField.Index
+			}
+		}
+		plan.add(field)
+		if rule.restricted() || rulesRestricted(rule.Rules) {
+			plan.restricted = true
+		}
+	}
+	return plan
+}
+
+// add appends the field to the plan.
+func (p *rulePlan) add(field *fieldPlan) {
+	p.fields = append(p.fields, field)
+	if _, ok := p.byName[field.name]; !ok {
+		// The first Rule for a name takes precedence.
+		p.byName[field.name] = field
+	}
+}
+
+// visibleTo returns the plan with only the fields visible to the Identity, which is
+// nil if the Rules aren't applied for one. Nested Rules are applied for the Identity.
+func (p *rulePlan) visibleTo(identity *Identity) *rulePlan {
+	if !p.restricted {
+		return p
+	}
+	plan := &rulePlan{resourceType: p.resourceType, byName: map[string]*fieldPlan{},
+		restricted: true}
+	for _, field := range p.fields {
+		if !field.rule.VisibleTo(identity) {
+			continue
+		}
+		if field.nested != nil && identity != nil {
+			visible := *field
+			visible.nested = rulesForIdentity(field.nested, identity)
+			field = &visible
+		}
+		plan.add(field)
+	}
+	return plan
+}
+
+// structField returns the value of the planned field of the struct, using its index if
+// the struct is of the Rules' resource type.
+func (p *rulePlan) structField(value reflect.Value, field *fieldPlan) reflect.Value {
+	if field.index != nil && value.Type() == p.resourceType {
+		return value.FieldByIndex(field.index)
+	}
+	return value.FieldByName(field.rule.Field)
+}
+
+// versionPlans are the rulePlans of a direction for each version mentioned by the
+// Rules, along with the plan for all other versions.
+type versionPlans struct {
+	versions map[string]*rulePlan
+	other    *rulePlan
+}
+
+// plan returns the rulePlan for the version.
+func (v versionPlans) plan(version string) *rulePlan {
+	if plan, ok := v.versions[version]; ok {
+		return plan
+	}
+	return v.other
+}
+
+// compiledRules are Rules whose rulePlans were prepared ahead of time, so they're
+// applied to requests and responses without per-request reflection lookups.
+type compiledRules struct {
+	Rules
+	inbound  versionPlans
+	outbound versionPlans
+}
+
+// compileRules returns the Rules with their rulePlans, and those of any nested Rules,
+// prepared for every version. Compiled and nil Rules are returned as is.
+func compileRules(rules Rules) Rules {
+	if rules == nil {
+		return nil
+	}
+	if _, ok := rules.(*compiledRules); ok {
+		return rules
+	}
+
+	nested := map[*Rule]Rules{}
+	versions := map[string]bool{}
+	for _, rule := range rules.Contents() {
+		if rule.Rules != nil {
+			nested[rule] = compileRules(rule.Rules)
+		}
+		for _, version := range rule.Versions {
+			versions[version] = true
+		}
+	}
+
+	compiled := &compiledRules{Rules: rules}
+	for _, p := range []struct {
+		filter Filter
+		plans  *versionPlans
+	}{{Inbound, &compiled.inbound}, {Outbound, &compiled.outbound}} {
+		p.plans.versions = make(map[string]*rulePlan, len(versions))
+		for version := range versions {
+			p.plans.versions[version] = newRulePlan(rules, p.filter,
+				func(rule *Rule) bool { return rule.Applies(version) }, nested)
+		}
+		p.plans.other = newRulePlan(rules, p.filter,
+			func(rule *Rule) bool { return rule.Versions == nil }, nested)
+	}
+	return compiled
+}
+
+// rulePlanFor returns the rulePlan for the Rules which pass the Filter and apply to
+// the version. Plans of compiled Rules are looked up, others are prepared on demand.
+// Outbound plans only contain the fields visible to the Identity the Rules are applied
+// for, if any.
+func rulePlanFor(rules Rules, filter Filter, version string) *rulePlan {
+	var identity *Identity
+	if visible, ok := rules.(*identityRules); ok {
+		rules, identity = visible.Rules, visible.identity
+	}
+	if filter == Inbound {
+		return versionPlanFor(rules, filter, version)
+	}
+	return versionPlanFor(rules, filter, version).visibleTo(identity)
+}
+
+// versionPlanFor returns the rulePlan for the Rules which pass the Filter and apply to
+// the version.
+func versionPlanFor(rules Rules, filter Filter, version string) *rulePlan {
+	if compiled, ok := rules.(*compiledRules); ok {
+		if filter == Inbound {
+			return compiled.inbound.plan(version)
+		}
+		return compiled.outbound.plan(version)
+	}
+	return newRulePlan(rules, filter,
+		func(rule *Rule) bool { return rule.Applies(version) }, nil)
+}
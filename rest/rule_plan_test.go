@@ -0,0 +1,177 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type planResource struct {
+	ID      int
+	Name    string
+	Email   string
+	Created time.Time
+	Tags    []planTag
+}
+
+type planTag struct {
+	Label string
+}
+
+// planRules returns Rules with versioned, input-only, output-only, and nested Rules.
+func planRules() Rules {
+	return NewRules((*planResource)(nil),
+		&Rule{Field: "ID", FieldAlias: "id", Type: Int, OutputOnly: true},
+		&Rule{Field: "Name", FieldAlias: "name", Type: String, Required: true},
+		&Rule{Field: "Email", FieldAlias: "email", Type: String, Versions: []string{"2"}},
+		&Rule{Field: "Created", FieldAlias: "created", Type: Time, OutputOnly: true},
+		&Rule{FieldAlias: "password", Type: String, InputOnly: true},
+		&Rule{Field: "Tags", FieldAlias: "tags", Type: Slice,
+			Rules: NewRules((*planTag)(nil), &Rule{Field: "Label", FieldAlias: "label", Type: String})},
+	)
+}
+
+// planResourceValue returns a resource for the planRules.
+func planResourceValue() *planResource {
+	return &planResource{
+		ID:      1,
+		Name:    "foo",
+		Email:   "foo@example.com",
+		Created: time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC),
+		Tags:    []planTag{{Label: "a"}, {Label: "b"}},
+	}
+}
+
+// planPayload returns a request payload for the planRules.
+func planPayload() Payload {
+	return Payload{
+		"name":     "foo",
+		"email":    "foo@example.com",
+		"password": "secret",
+		"unknown":  true,
+		"tags":     []interface{}{map[string]interface{}{"label": "a", "other": 1}},
+	}
+}
+
+// Ensures that compiled Rules are applied to resources exactly like the Rules they
+// were compiled from.
+func TestCompiledOutboundRules(t *testing.T) {
+	assert := assert.New(t)
+	rules := planRules()
+	compiled := compileRules(rules)
+
+	for _, version := range []string{"1", "2", "3"} {
+		assert.Equal(applyOutboundRules(planResourceValue(), rules, version),
+			applyOutboundRules(planResourceValue(), compiled, version))
+	}
+
+	resource := applyOutboundRules(planResourceValue(), compiled, "2")
+	assert.Equal(Payload{
+		"id":      1,
+		"name":    "foo",
+		"email":   "foo@example.com",
+		"created": time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC),
+		"tags":    []interface{}{Payload{"label": "a"}, Payload{"label": "b"}},
+	}, resource)
+}
+
+// Ensures that compiled Rules are applied to payloads exactly like the Rules they were
+// compiled from.
+func TestCompiledInboundRules(t *testing.T) {
+	assert := assert.New(t)
+	rules := planRules()
+	compiled := compileRules(rules)
+
+	for _, version := range []string{"1", "2"} {
+		expected, expectedErr := applyInboundRules(planPayload(), rules, version)
+		actual, err := applyInboundRules(planPayload(), compiled, version)
+		assert.Equal(expected, actual)
+		assert.Equal(expectedErr, err)
+	}
+
+	_, err := applyInboundRules(Payload{}, compiled, "1")
+	assert.Equal(FieldErrors{"name": UnprocessableRequest(
+		"Missing required field 'name'").WithCode(CodeMissingField, "name")}, err)
+}
+
+// Ensures that the Rules of registered ResourceHandlers are compiled once.
+func TestResourceHandlerRulesCompiled(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&compiledRulesHandler{})
+
+	handler := api.ResourceHandlers()[0]
+	rules := handler.Rules()
+	_, ok := rules.(*compiledRules)
+	assert.True(ok)
+	assert.True(rules == handler.Rules())
+}
+
+type compiledRulesHandler struct {
+	BaseResourceHandler
+}
+
+func (r *compiledRulesHandler) ResourceName() string {
+	return "foo"
+}
+
+func (r *compiledRulesHandler) Rules() Rules {
+	return planRules()
+}
+
+func benchmarkOutboundRules(b *testing.B, rules Rules) {
+	resource := planResourceValue()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		applyOutboundRules(resource, rules, "2")
+	}
+}
+
+func benchmarkInboundRules(b *testing.B, rules Rules) {
+	defer quietLog()()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		payload := planPayload()
+		b.StartTimer()
+		applyInboundRules(payload, rules, "2")
+	}
+}
+
+// BenchmarkOutboundRules measures applying Rules to a resource without compiling them.
+func BenchmarkOutboundRules(b *testing.B) {
+	benchmarkOutboundRules(b, planRules())
+}
+
+// BenchmarkCompiledOutboundRules measures applying compiled Rules to a resource.
+func BenchmarkCompiledOutboundRules(b *testing.B) {
+	benchmarkOutboundRules(b, compileRules(planRules()))
+}
+
+// BenchmarkInboundRules measures applying Rules to a payload without compiling them.
+func BenchmarkInboundRules(b *testing.B) {
+	benchmarkInboundRules(b, planRules())
+}
+
+// BenchmarkCompiledInboundRules measures applying compiled Rules to a payload.
+func BenchmarkCompiledInboundRules(b *testing.B) {
+	benchmarkInboundRules(b, compileRules(planRules()))
+}
@@ -17,7 +17,6 @@ limitations under the License.
 package rest
 
 import (
-	"fmt"
 	"reflect"
 	"testing"
 	"time"
@@ -64,7 +63,7 @@ func TestApplyInboundRulesMissingRequiredField(t *testing.T) {
 	), "1")
 
 	assert.Nil(actual, "Return value should be nil")
-	assert.Equal(fmt.Errorf("Missing required field 'baz'"), err, "Incorrect error")
+	assert.Equal(map[string]string{"baz": "Missing required field 'baz'"}, err.(FieldErrors).Messages(), "Incorrect error")
 }
 
 // Ensures that only inbound rules are applied and unspecified input fields are discarded.
@@ -117,7 +116,7 @@ func TestApplyInboundRulesCoerceBoolError(t *testing.T) {
 	actual, err := applyInboundRules(payload, rules, "1")
 
 	assert.Nil(actual, "Return value should be nil")
-	assert.Equal(fmt.Errorf("Unable to coerce bool to float32"), err, "Incorrect error")
+	assert.Equal(map[string]string{"foo": "Unable to coerce bool to float32"}, err.(FieldErrors).Messages(), "Incorrect error")
 }
 
 // Ensures that inbound rules which specify bool correctly coerce bool.
@@ -171,7 +170,7 @@ func TestApplyInboundRulesCoerceFloatError(t *testing.T) {
 	actual, err := applyInboundRules(payload, rules, "1")
 
 	assert.Nil(actual, "Return value should be nil")
-	assert.Equal(fmt.Errorf("Unable to coerce float to bool"), err, "Incorrect error")
+	assert.Equal(map[string]string{"foo": "Unable to coerce float to bool"}, err.(FieldErrors).Messages(), "Incorrect error")
 }
 
 // Ensures that inbound rules which specify int correctly coerce float64.
@@ -441,8 +440,8 @@ func TestApplyInboundRulesCoerceStringError(t *testing.T) {
 	actual, err := applyInboundRules(payload, rules, "1")
 
 	assert.Nil(actual, "Return value should be nil")
-	assert.Equal(fmt.Errorf("Unable to coerce string to map[string]interface{}"),
-		err, "Incorrect error")
+	assert.Equal(map[string]string{"foo": "Unable to coerce string to map[string]interface{}"},
+		err.(FieldErrors).Messages(), "Incorrect error")
 }
 
 // Ensure that if type coercion from string to int fails, the error is returned.
@@ -863,7 +862,7 @@ func TestApplyInboundRulesCoerceSliceError(t *testing.T) {
 	actual, err := applyInboundRules(payload, rules, "1")
 
 	assert.Nil(actual, "Return value should be nil")
-	assert.Equal(fmt.Errorf("Unable to coerce slice to bool"), err, "Incorrect error")
+	assert.Equal(map[string]string{"foo": "Unable to coerce slice to bool"}, err.(FieldErrors).Messages(), "Incorrect error")
 }
 
 // Ensures that inbound rules which specify slice correctly coerce slice.
@@ -899,7 +898,7 @@ func TestApplyInboundRulesCoerceMapError(t *testing.T) {
 	actual, err := applyInboundRules(payload, rules, "1")
 
 	assert.Nil(actual, "Return value should be nil")
-	assert.Equal(fmt.Errorf("Unable to coerce map to bool"), err, "Incorrect error")
+	assert.Equal(map[string]string{"foo": "Unable to coerce map to bool"}, err.(FieldErrors).Messages(), "Incorrect error")
 }
 
 // Ensures that inbound rules which specify map correctly coerce map.
@@ -1004,11 +1003,11 @@ func TestApplyInboundRulesNestedRulesUnableToCoerceToPrimitive(t *testing.T) {
 			),
 		},
 	)
-	expectedErr := fmt.Errorf("Value does not match rule type, expecting: string, got: slice")
+	expectedErr := map[string]string{"foo": "Value does not match rule type, expecting: string, got: slice"}
 
 	actual, err := applyInboundRules(payload, rules, "1")
 
-	assert.Equal(expectedErr, err, "Incorrect error message")
+	assert.Equal(expectedErr, err.(FieldErrors).Messages(), "Incorrect error message")
 	assert.Nil(actual, "Payload should be nil")
 }
 
@@ -1535,3 +1534,31 @@ func TestRulesValidateNonResourceRule(t *testing.T) {
 
 	assert.Nil(rules.Validate())
 }
+
+// Ensures that applyInboundRules reports an error for each invalid field, including
+// the fields of nested values.
+func TestApplyInboundRulesAggregatesFieldErrors(t *testing.T) {
+	assert := assert.New(t)
+	payload := Payload{
+		"foo": true,
+		"bar": map[string]interface{}{"baz": "hello"},
+	}
+	rules := NewRules((*TestResource)(nil),
+		&Rule{Field: "Foo", FieldAlias: "foo", Type: Float32},
+		&Rule{Field: "Bar", FieldAlias: "bar", Rules: NewRules((*TestResource)(nil),
+			&Rule{Field: "Baz", FieldAlias: "baz", Type: Int},
+		)},
+		&Rule{Field: "Qux", FieldAlias: "qux", Required: true},
+	)
+
+	actual, err := applyInboundRules(payload, rules, "1")
+
+	assert.Nil(actual, "Return value should be nil")
+	assert.Equal(map[string]string{
+		"foo":     "Unable to coerce bool to float32",
+		"bar.baz": `strconv.ParseInt: parsing "hello": invalid syntax`,
+		"qux":     "Missing required field 'qux'",
+	}, err.(FieldErrors).Messages(), "Incorrect error")
+	assert.Equal(`strconv.ParseInt: parsing "hello": invalid syntax; `+
+		"Unable to coerce bool to float32; Missing required field 'qux'", err.Error())
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// schemaParam is the Content-Type parameter naming the request payload schema.
+const schemaParam = "schema"
+
+// SchemaResourceHandler can be implemented by a ResourceHandler to accept multiple
+// request payload schemas on the same route. Clients select a schema with the
+// "schema" parameter of the request Content-Type, e.g.
+//
+//	Content-Type: application/json; schema=foo.v2
+//
+// and the inbound Rules registered for it are applied to the payload instead of the
+// handler's Rules. This allows clients to migrate to a new payload shape gradually
+// without a new URL version. Requests without a schema parameter use the handler's
+// Rules, and requests naming an unknown schema are rejected with 415 Unsupported Media
+// Type. Outbound Rules are always the handler's Rules.
+type SchemaResourceHandler interface {
+	// Schemas returns the inbound Rules keyed by schema name.
+	Schemas() map[string]Rules
+}
+
+// inboundRules returns the Rules to apply to the request payload, which are those of
+// the schema selected by the request Content-Type if there is one. An error is
+// returned if the requested schema isn't registered.
+func inboundRules(ctx RequestContext, handler ResourceHandler) (Rules, error) {
+	schema := ctx.Schema()
+	if schema == "" {
+		return handler.Rules(), nil
+	}
+
+	if s, ok := unwrapResourceHandler(handler).(SchemaResourceHandler); ok {
+		if rules, ok := s.Schemas()[schema]; ok {
+			return rules, nil
+		}
+	}
+
+	return nil, CustomError(fmt.Sprintf("Unsupported schema %q for %s",
+		schema, handler.ResourceName()), http.StatusUnsupportedMediaType)
+}
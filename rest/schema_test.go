@@ -0,0 +1,121 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type schemaResourceHandler struct {
+	BaseResourceHandler
+	data Payload
+}
+
+func (s *schemaResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (s *schemaResourceHandler) Rules() Rules {
+	return NewRules((*TestResource)(nil), &Rule{Field: "Foo", FieldAlias: "foo"})
+}
+
+func (s *schemaResourceHandler) Schemas() map[string]Rules {
+	return map[string]Rules{
+		"foo.v2": NewRules((*TestResource)(nil), &Rule{Field: "Foo", FieldAlias: "name"}),
+	}
+}
+
+func (s *schemaResourceHandler) CreateResource(ctx RequestContext, data Payload,
+	version string) (Resource, error) {
+
+	s.data = data
+	return &TestResource{Foo: "hello"}, nil
+}
+
+// Ensures that Schema returns the schema parameter of the request Content-Type.
+func TestSchema(t *testing.T) {
+	assert := assert.New(t)
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo", nil)
+	assert.Equal("", NewContext(nil, req, httptest.NewRecorder()).Schema())
+
+	req.Header.Set("Content-Type", "application/json; schema=foo.v2")
+	assert.Equal("foo.v2", NewContext(nil, req, httptest.NewRecorder()).Schema())
+
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	assert.Equal("", NewContext(nil, req, httptest.NewRecorder()).Schema())
+}
+
+// Ensures that the Rules of the schema selected by the Content-Type are applied to the
+// request payload and the handler's Rules are applied without one. Fields not in the
+// applied Rules are discarded.
+func TestHandleCreateSchema(t *testing.T) {
+	assert := assert.New(t)
+	handler := &schemaResourceHandler{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+	createHandler, _ := api.(*muxAPI).getRouteHandler("foo:create")
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo",
+		bytes.NewBufferString(`{"name": "bar"}`))
+	req.Header.Set("Content-Type", "application/json; schema=foo.v2")
+	resp := httptest.NewRecorder()
+
+	createHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusCreated, resp.Code, "Incorrect response code")
+	assert.Equal(Payload{"name": "bar"}, handler.data)
+	assert.Equal(
+		`{"messages":[],"reason":"Created","result":{"foo":"hello"},"status":201}`,
+		resp.Body.String(),
+		"Incorrect response string",
+	)
+
+	req, _ = http.NewRequest("POST", "http://foo.com/api/v1/foo",
+		bytes.NewBufferString(`{"name": "bar"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp = httptest.NewRecorder()
+
+	createHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusCreated, resp.Code, "Incorrect response code")
+	assert.Equal(Payload{}, handler.data)
+}
+
+// Ensures that requests naming an unknown schema are rejected.
+func TestHandleCreateUnknownSchema(t *testing.T) {
+	assert := assert.New(t)
+	handler := &schemaResourceHandler{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+	createHandler, _ := api.(*muxAPI).getRouteHandler("foo:create")
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo",
+		bytes.NewBufferString(`{"name": "bar"}`))
+	req.Header.Set("Content-Type", "application/json; schema=foo.v3")
+	resp := httptest.NewRecorder()
+
+	createHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusUnsupportedMediaType, resp.Code, "Incorrect response code")
+	assert.Nil(handler.data)
+}
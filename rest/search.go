@@ -0,0 +1,183 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const (
+	// searchTextKey is the name of the query string variable containing the free-text
+	// search query.
+	searchTextKey = "q"
+
+	// searchSortKey is the name of the query string variable containing the sort order.
+	searchSortKey = "sort"
+
+	// searchFilterPrefix and searchFilterSuffix enclose the field name of filter query
+	// string variables, e.g. "filter[status]".
+	searchFilterPrefix = "filter["
+	searchFilterSuffix = "]"
+)
+
+// SortOrder is a field to sort search results by.
+type SortOrder struct {
+	// Field is the name of the field.
+	Field string
+
+	// Descending is true if results are sorted by the field in descending order.
+	Descending bool
+}
+
+// SearchQuery is the parsed query of a search request.
+type SearchQuery struct {
+	// Text is the free-text query of the "q" query parameter, if any.
+	Text string
+
+	// Filters contains the values of "filter[field]" query parameters keyed by field
+	// name. Comma-separated and repeated parameters have multiple values.
+	Filters map[string][]string
+
+	// Sort contains the fields of the "sort" query parameter in order of precedence,
+	// e.g. "-created,name" sorts by creation time descending and then by name.
+	Sort []SortOrder
+}
+
+// Filter returns the first value of the named filter, if any.
+func (s SearchQuery) Filter(field string) (string, bool) {
+	values := s.Filters[field]
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[0], true
+}
+
+// SearchResourceHandler can be implemented by a ResourceHandler to support free-text
+// search of its resources. If implemented, the following endpoint is registered
+// relative to the handler's read list URI:
+//
+//	GET /api/:version/resourceName/search?q=text&filter[field]=value&sort=-field
+//
+// The framework parses the query into a SearchQuery and paginates the results like
+// ReadResourceList, using the "limit" and "next" query parameters.
+type SearchResourceHandler interface {
+	// SearchResources returns up to limit resources matching the query, starting at the
+	// cursor, along with the cursor of the next page of results, if any.
+	SearchResources(ctx RequestContext, query SearchQuery, limit int, cursor string,
+		version string) ([]Resource, string, error)
+}
+
+// parseSearchQuery returns the SearchQuery of the query string. A BadRequest Error is
+// returned if a filter or sort field is malformed.
+func parseSearchQuery(query url.Values) (SearchQuery, error) {
+	search := SearchQuery{
+		Text:    strings.TrimSpace(query.Get(searchTextKey)),
+		Filters: map[string][]string{},
+	}
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !strings.HasPrefix(key, searchFilterPrefix) {
+			continue
+		}
+		field := strings.TrimPrefix(key, searchFilterPrefix)
+		if !strings.HasSuffix(field, searchFilterSuffix) || len(field) == len(searchFilterSuffix) {
+			return search, BadRequest(fmt.Sprintf("Invalid filter parameter: %s", key))
+		}
+		field = strings.TrimSuffix(field, searchFilterSuffix)
+		for _, value := range query[key] {
+			for _, v := range strings.Split(value, ",") {
+				search.Filters[field] = append(search.Filters[field], strings.TrimSpace(v))
+			}
+		}
+	}
+
+	for _, value := range query[searchSortKey] {
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			order := SortOrder{Field: strings.TrimLeft(field, "+-")}
+			if order.Field == "" {
+				if field == "" {
+					continue
+				}
+				return search, BadRequest(fmt.Sprintf("Invalid sort field: %s", field))
+			}
+			order.Descending = strings.HasPrefix(field, "-")
+			search.Sort = append(search.Sort, order)
+		}
+	}
+
+	return search, nil
+}
+
+// handleSearch returns a Handler which parses the request's SearchQuery, passes it to
+// the ResourceHandler's SearchResources, and then serializes and dispatches the
+// results.
+func (h requestHandler) handleSearch(handler ResourceHandler,
+	searcher SearchResourceHandler) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := h.newContext(w, r)
+		limits := resolveListLimits(h.Configuration(), handler)
+		ctx = ctx.setLimits(limits)
+		version := ctx.Version()
+		rules := visibleRules(ctx, handler.Rules())
+
+		if h.Configuration().RejectOversizedLimits && ctx.limitExceeded() {
+			h.sendResponse(ctx.setError(BadRequest(fmt.Sprintf(
+				"Limit exceeds maximum of %d", limits.maxLimit))))
+			return
+		}
+
+		query, err := parseSearchQuery(r.URL.Query())
+		if err != nil {
+			h.sendResponse(ctx.setError(err))
+			return
+		}
+
+		resources, cursor, err := searcher.SearchResources(ctx, query, ctx.Limit(),
+			ctx.Cursor(), version)
+		if err == nil {
+			for idx, resource := range resources {
+				resources[idx] = applyOutboundRules(resource, rules, version)
+			}
+			if resources == nil {
+				resources = []Resource{}
+			}
+		}
+
+		ctx = ctx.setResult(resources)
+		ctx = ctx.setCursor(cursor)
+		ctx = ctx.setError(err)
+		ctx = ctx.setStatus(http.StatusOK)
+
+		if err == nil {
+			setLinkHeader(ctx)
+		}
+
+		h.sendResponse(ctx)
+	})
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type searchResourceHandler struct {
+	BaseResourceHandler
+	query  SearchQuery
+	limit  int
+	cursor string
+}
+
+func (s *searchResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (s *searchResourceHandler) Rules() Rules {
+	return NewRules((*TestResource)(nil), &Rule{Field: "Foo", FieldAlias: "foo"})
+}
+
+func (s *searchResourceHandler) SearchResources(ctx RequestContext, query SearchQuery,
+	limit int, cursor, version string) ([]Resource, string, error) {
+
+	s.query, s.limit, s.cursor = query, limit, cursor
+	if query.Text == "" {
+		return nil, "", nil
+	}
+	return []Resource{&TestResource{Foo: query.Text}}, "page2", nil
+}
+
+// Ensures that parseSearchQuery parses the text, filters, and sort order of the query
+// string.
+func TestParseSearchQuery(t *testing.T) {
+	assert := assert.New(t)
+
+	query, err := parseSearchQuery(url.Values{
+		"q":              {" hello world "},
+		"filter[status]": {"open,closed", "pending"},
+		"filter[owner]":  {"bob"},
+		"sort":           {"-created,name", "+size"},
+		"limit":          {"5"},
+	})
+
+	assert.NoError(err)
+	assert.Equal(SearchQuery{
+		Text: "hello world",
+		Filters: map[string][]string{
+			"status": {"open", "closed", "pending"},
+			"owner":  {"bob"},
+		},
+		Sort: []SortOrder{
+			{Field: "created", Descending: true},
+			{Field: "name"},
+			{Field: "size"},
+		},
+	}, query)
+
+	owner, ok := query.Filter("owner")
+	assert.True(ok)
+	assert.Equal("bob", owner)
+	_, ok = query.Filter("missing")
+	assert.False(ok)
+
+	_, err = parseSearchQuery(url.Values{"filter[status": {"open"}})
+	assert.Equal(http.StatusBadRequest, errorStatus(err))
+
+	_, err = parseSearchQuery(url.Values{"filter[]": {"open"}})
+	assert.Equal(http.StatusBadRequest, errorStatus(err))
+
+	_, err = parseSearchQuery(url.Values{"sort": {"name,-"}})
+	assert.Equal(http.StatusBadRequest, errorStatus(err))
+}
+
+// Ensures that the search endpoint passes the parsed query to the handler and
+// paginates the results.
+func TestHandleSearch(t *testing.T) {
+	assert := assert.New(t)
+	handler := &searchResourceHandler{}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(handler)
+
+	resp, payload := getPayload(t, api,
+		"http://example.com/api/v1/foo/search?q=hello&filter[status]=open&sort=-name&limit=5&next=abc")
+
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal(SearchQuery{
+		Text:    "hello",
+		Filters: map[string][]string{"status": {"open"}},
+		Sort:    []SortOrder{{Field: "name", Descending: true}},
+	}, handler.query)
+	assert.Equal(5, handler.limit)
+	assert.Equal("abc", handler.cursor)
+	assert.Equal([]interface{}{map[string]interface{}{"foo": "hello"}}, payload["results"])
+	next, _ := url.Parse(payload["next"].(string))
+	assert.Equal("page2", next.Query().Get("next"))
+
+	resp, payload = getPayload(t, api, "http://example.com/api/v1/foo/search")
+
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal([]interface{}{}, payload["results"])
+	assert.Nil(payload["next"])
+
+	resp, payload = getPayload(t, api, "http://example.com/api/v1/foo/search?sort=-")
+
+	assert.Equal(http.StatusBadRequest, resp.Code)
+	assert.Equal([]interface{}{"Invalid sort field: -"}, payload["messages"])
+}
+
+// Ensures that the search endpoint is only registered for handlers implementing
+// SearchResourceHandler.
+func TestHandleSearchNotRegistered(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&changeLogResourceHandler{})
+
+	assert.Nil(api.(*muxAPI).muxRouter().Get("foo:" + string(HandleSearch)))
+}
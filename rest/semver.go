@@ -0,0 +1,280 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// parsedVersionRanges caches the VersionRanges parsed when matching request versions,
+// keyed by the declared range.
+var parsedVersionRanges sync.Map
+
+// SemanticVersion is a parsed API version of the form major.minor.patch, with an
+// optional "v" prefix and "-prerelease" suffix. Missing minor and patch numbers are
+// zero, so "2" and "2.0.0" are the same version.
+type SemanticVersion struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+
+	// parts is the number of numeric parts the version was parsed from, which
+	// determines the upper bound of ~ and ^ ranges.
+	parts int
+}
+
+// ParseSemanticVersion parses the version, e.g. "1", "1.2", "v1.2.3", or "2.0-beta".
+func ParseSemanticVersion(version string) (SemanticVersion, error) {
+	v := SemanticVersion{}
+	s := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		s, v.Prerelease = s[:i], s[i+1:]
+		if v.Prerelease == "" {
+			return v, fmt.Errorf("Invalid version %q", version)
+		}
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return v, fmt.Errorf("Invalid version %q", version)
+	}
+	numbers := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("Invalid version %q", version)
+		}
+		*numbers[i] = n
+	}
+	v.parts = len(parts)
+	return v, nil
+}
+
+// Compare returns -1, 0, or 1 if the version is less than, equal to, or greater than
+// the other. Prerelease versions precede their release.
+func (v SemanticVersion) Compare(other SemanticVersion) int {
+	for _, d := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+	switch {
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	case v.Prerelease < other.Prerelease:
+		return -1
+	}
+	return 1
+}
+
+// String returns the version in major.minor.patch form.
+func (v SemanticVersion) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+// versionComparator is a comparison of versions against a bound, e.g. ">=1.2".
+type versionComparator struct {
+	op    string
+	bound SemanticVersion
+}
+
+// matches returns true if the version satisfies the comparison.
+func (c versionComparator) matches(v SemanticVersion) bool {
+	cmp := v.Compare(c.bound)
+	switch c.op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return cmp == 0
+}
+
+// VersionRange is a set of API versions, e.g. ">=1.2 <2". Comparators separated by
+// spaces must all be satisfied, and alternatives are separated by "||". The supported
+// comparators are =, >, >=, <, and <=, along with ~1.2 for >=1.2 <1.3, ^1.2 for >=1.2
+// <2, and * for any version.
+type VersionRange struct {
+	raw  string
+	sets [][]versionComparator
+}
+
+// ParseVersionRange parses the range, e.g. ">=1.2 <2" or "~1.4 || ^2".
+func ParseVersionRange(versionRange string) (VersionRange, error) {
+	r := VersionRange{raw: versionRange}
+	for _, alternative := range strings.Split(versionRange, "||") {
+		set := []versionComparator{}
+		for _, term := range strings.Fields(alternative) {
+			comparators, err := parseVersionTerm(term)
+			if err != nil {
+				return r, fmt.Errorf("Invalid version range %q: %s", versionRange, err)
+			}
+			set = append(set, comparators...)
+		}
+		if len(set) == 0 && strings.TrimSpace(alternative) == "" {
+			return r, fmt.Errorf("Invalid version range %q: empty alternative", versionRange)
+		}
+		r.sets = append(r.sets, set)
+	}
+	return r, nil
+}
+
+// parseVersionTerm returns the comparators of a term of a version range.
+func parseVersionTerm(term string) ([]versionComparator, error) {
+	if term == "*" {
+		return nil, nil
+	}
+
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "~", "^"} {
+		if strings.HasPrefix(term, prefix) {
+			op, term = prefix, term[len(prefix):]
+			break
+		}
+	}
+	bound, err := ParseSemanticVersion(term)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "~", "^":
+		upper := SemanticVersion{Major: bound.Major + 1}
+		if op == "~" && bound.parts > 1 || op == "^" && bound.Major == 0 && bound.parts > 1 {
+			upper = SemanticVersion{Major: bound.Major, Minor: bound.Minor + 1}
+		}
+		return []versionComparator{{">=", bound}, {"<", upper}}, nil
+	case "":
+		op = "="
+	}
+	return []versionComparator{{op, bound}}, nil
+}
+
+// Contains returns true if the version is in the range. Versions which can't be parsed
+// aren't in any range.
+func (r VersionRange) Contains(version string) bool {
+	v, err := ParseSemanticVersion(version)
+	if err != nil {
+		return false
+	}
+	for _, set := range r.sets {
+		matches := true
+		for _, comparator := range set {
+			if !comparator.matches(v) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the range as it was parsed.
+func (r VersionRange) String() string {
+	return r.raw
+}
+
+// isVersionRange returns true if the declared version is a range rather than a single
+// version, which is matched by string equality.
+func isVersionRange(declared string) bool {
+	return strings.ContainsAny(declared, "<>=~^*| ")
+}
+
+// versionMatches returns true if the version matches the declared version or range.
+func versionMatches(declared, version string) bool {
+	if !isVersionRange(declared) {
+		return declared == version
+	}
+	r, ok := cachedVersionRange(declared)
+	return ok && r.Contains(version)
+}
+
+// cachedVersionRange returns the parsed VersionRange, caching it for subsequent
+// requests. It returns false if the range is invalid.
+func cachedVersionRange(declared string) (VersionRange, bool) {
+	if r, ok := parsedVersionRanges.Load(declared); ok {
+		return r.(VersionRange), true
+	}
+	r, err := ParseVersionRange(declared)
+	if err != nil {
+		return r, false
+	}
+	parsedVersionRanges.Store(declared, r)
+	return r, true
+}
+
+// versionPrecedes returns true if the version precedes the declared version or range,
+// i.e. it's less than the lower bound of each of the range's alternatives.
+func versionPrecedes(version, declared string) bool {
+	if !isVersionRange(declared) {
+		return compareVersions(version, declared) < 0
+	}
+	r, ok := cachedVersionRange(declared)
+	v, err := ParseSemanticVersion(version)
+	if !ok || err != nil {
+		return false
+	}
+	for _, set := range r.sets {
+		below := false
+		for _, comparator := range set {
+			if comparator.op != "<" && comparator.op != "<=" && !comparator.matches(v) &&
+				v.Compare(comparator.bound) < 0 {
+				below = true
+			}
+		}
+		if !below {
+			return false
+		}
+	}
+	return true
+}
+
+// validateVersions returns an error if any of the declared versions is an invalid
+// range.
+func validateVersions(declared []string) error {
+	for _, d := range declared {
+		if !isVersionRange(d) {
+			continue
+		}
+		if _, err := ParseVersionRange(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
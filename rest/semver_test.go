@@ -0,0 +1,189 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type rangeVersionResourceHandler struct {
+	BaseResourceHandler
+}
+
+func (r *rangeVersionResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (r *rangeVersionResourceHandler) ValidVersions() []string {
+	return []string{">=1.2 <2", "beta"}
+}
+
+func (r *rangeVersionResourceHandler) ReadResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	parsed, _ := ctx.SemanticVersion()
+	return Payload{"minor": parsed.Minor, "legacy": !ctx.VersionIn(">=1.5")}, nil
+}
+
+// Ensures that ParseSemanticVersion parses full, partial, prefixed, and prerelease
+// versions and rejects invalid ones.
+func TestParseSemanticVersion(t *testing.T) {
+	assert := assert.New(t)
+
+	v, err := ParseSemanticVersion("v1.2.3-beta")
+	assert.NoError(err)
+	assert.Equal(1, v.Major)
+	assert.Equal(2, v.Minor)
+	assert.Equal(3, v.Patch)
+	assert.Equal("beta", v.Prerelease)
+	assert.Equal("1.2.3-beta", v.String())
+
+	v, err = ParseSemanticVersion("2")
+	assert.NoError(err)
+	assert.Equal("2.0.0", v.String())
+
+	for _, invalid := range []string{"", "a", "1.2.3.4", "1.-2", "1-", "1..2"} {
+		_, err := ParseSemanticVersion(invalid)
+		assert.Error(err, invalid)
+	}
+}
+
+// Ensures that SemanticVersions are compared numerically with prereleases preceding
+// their release.
+func TestSemanticVersionCompare(t *testing.T) {
+	assert := assert.New(t)
+	parse := func(version string) SemanticVersion {
+		v, _ := ParseSemanticVersion(version)
+		return v
+	}
+
+	assert.Equal(-1, parse("1.2").Compare(parse("1.10")))
+	assert.Equal(1, parse("2").Compare(parse("1.99.99")))
+	assert.Equal(0, parse("1").Compare(parse("1.0.0")))
+	assert.Equal(-1, parse("1.0-alpha").Compare(parse("1.0")))
+	assert.Equal(1, parse("1.0-beta").Compare(parse("1.0-alpha")))
+}
+
+// Ensures that VersionRanges contain the versions satisfying their comparators.
+func TestVersionRangeContains(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		versionRange string
+		in           []string
+		out          []string
+	}{
+		{">=1.2 <2", []string{"1.2", "1.2.0", "1.10", "1.99.1"}, []string{"1.1", "2", "2.0.1", "x"}},
+		{">1 <=1.5", []string{"1.0.1", "1.5"}, []string{"1", "1.5.1"}},
+		{"=1.4", []string{"1.4", "1.4.0"}, []string{"1.4.1"}},
+		{"1.4", []string{"1.4.0"}, []string{"1.5"}},
+		{"~1.4", []string{"1.4", "1.4.9"}, []string{"1.5", "1.3"}},
+		{"~1", []string{"1", "1.9"}, []string{"2"}},
+		{"^1.4", []string{"1.4", "1.9"}, []string{"2", "1.3"}},
+		{"^0.4", []string{"0.4.2"}, []string{"0.5"}},
+		{"~1.0 || >=3", []string{"1.0.5", "3", "4.1"}, []string{"2"}},
+		{"*", []string{"0.1", "7"}, []string{"beta"}},
+	}
+
+	for _, c := range cases {
+		r, err := ParseVersionRange(c.versionRange)
+		if !assert.NoError(err, c.versionRange) {
+			continue
+		}
+		assert.Equal(c.versionRange, r.String())
+		for _, version := range c.in {
+			assert.True(r.Contains(version), "%s should contain %s", c.versionRange, version)
+		}
+		for _, version := range c.out {
+			assert.False(r.Contains(version), "%s shouldn't contain %s", c.versionRange, version)
+		}
+	}
+
+	for _, invalid := range []string{"", ">=x", "1 ||", "<=>1"} {
+		_, err := ParseVersionRange(invalid)
+		assert.Error(err, invalid)
+	}
+}
+
+// Ensures that ValidVersions ranges accept any version in the range, while other
+// versions are matched exactly, and that handlers can branch on the parsed version.
+func TestValidVersionsRange(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&rangeVersionResourceHandler{})
+	assert.NoError(api.Validate())
+
+	read := func(version string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "http://foo.com/api/v"+version+"/foo/1", nil)
+		resp := httptest.NewRecorder()
+		api.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := read("1.3")
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Contains(resp.Body.String(), `"legacy":true`)
+	assert.Contains(resp.Body.String(), `"minor":3`)
+
+	resp = read("1.12")
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Contains(resp.Body.String(), `"legacy":false`)
+
+	assert.Equal(http.StatusOK, read("beta").Code)
+	assert.Equal(http.StatusBadRequest, read("2").Code)
+	assert.Equal(http.StatusBadRequest, read("1.1").Code)
+}
+
+// Ensures that ResourceHandlers can be registered for version ranges and that versions
+// preceding all ranges are gone.
+func TestRegisterResourceHandlerForVersionRanges(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandlerForVersions(&versionedResourceHandler{name: "old"}, "^1.2")
+	api.RegisterResourceHandlerForVersions(&versionedResourceHandler{name: "new"}, ">=2 <3")
+
+	read := func(version string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "http://foo.com/api/v"+version+"/foo/1", nil)
+		resp := httptest.NewRecorder()
+		api.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := read("1.7")
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Contains(resp.Body.String(), `"handler":"old"`)
+
+	resp = read("2.3.1")
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Contains(resp.Body.String(), `"handler":"new"`)
+
+	assert.Equal(http.StatusGone, read("1.1").Code)
+	assert.Equal(http.StatusNotFound, read("3").Code)
+}
+
+// Ensures that Validate returns an error for invalid version ranges.
+func TestValidateVersionRanges(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandlerForVersions(&versionedResourceHandler{name: "bad"}, ">=one")
+
+	assert.Error(api.Validate())
+}
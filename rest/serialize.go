@@ -17,9 +17,12 @@ limitations under the License.
 package rest
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"reflect"
+	"strings"
 )
 
 const (
@@ -29,6 +32,11 @@ const (
 	result   = "result"
 	results  = "results"
 	next     = "next"
+	prev     = "prev"
+	total    = "total"
+	page     = "page"
+	perPage  = "per_page"
+	idErrors = "errors"
 )
 
 // response is a data structure holding the serializable response body for a request and
@@ -36,6 +44,13 @@ const (
 type response struct {
 	Payload Payload
 	Status  int
+
+	// body replaces the Payload as the serialized response body if set by an
+	// EnvelopeBuilder.
+	body interface{}
+
+	// pooled is true if the Payload was taken from the envelope pool.
+	pooled bool
 }
 
 // ResponseSerializer is responsible for serializing REST responses and sending
@@ -49,6 +64,46 @@ type ResponseSerializer interface {
 	ContentType() string
 }
 
+// CapableResponseSerializer can be implemented by a ResponseSerializer to declare the
+// kinds of responses it can serialize, e.g. a CSV serializer which only makes sense for
+// lists. Requests for list responses in a format which doesn't support them are
+// rejected with 406 Not Acceptable, and error responses in a format which doesn't
+// support them are serialized as JSON instead.
+type CapableResponseSerializer interface {
+	// SupportsList returns true if the serializer can serialize list responses.
+	SupportsList() bool
+
+	// SupportsErrors returns true if the serializer can serialize error responses.
+	SupportsErrors() bool
+}
+
+// checkSerializerCapabilities returns the ResponseSerializer to use for the response
+// described by the RequestContext. If the serializer can't serialize it, either the
+// JSON serializer is returned for errors, or a RequestContext with a 406 error is
+// returned for lists.
+func checkSerializerCapabilities(ctx RequestContext,
+	serializer ResponseSerializer) (RequestContext, ResponseSerializer) {
+
+	capable, ok := serializer.(CapableResponseSerializer)
+	if !ok {
+		return ctx, serializer
+	}
+
+	if ctx.Error() == nil && isList(ctx.Result()) && !capable.SupportsList() {
+		ctx = ctx.setError(CustomError(fmt.Sprintf(
+			"Format %s does not support lists", ctx.ResponseFormat()), http.StatusNotAcceptable))
+	}
+	if ctx.Error() != nil && !capable.SupportsErrors() {
+		return ctx, jsonSerializer{}
+	}
+	return ctx, serializer
+}
+
+// isList returns true if the result is a list of resources.
+func isList(result interface{}) bool {
+	return result != nil && reflect.TypeOf(result).Kind() == reflect.Slice
+}
+
 // jsonSerializer is an implementation of ResponseSerializer which serializes responses
 // as JSON.
 type jsonSerializer struct{}
@@ -58,11 +113,75 @@ func (j jsonSerializer) Serialize(p Payload) ([]byte, error) {
 	return json.Marshal(p)
 }
 
+// SerializeValue marshals a response body into a JSON byte slice to be sent over the
+// wire.
+func (j jsonSerializer) SerializeValue(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// encode marshals a response body into the buffer as JSON.
+func (j jsonSerializer) encode(buf *bytes.Buffer, v interface{}) error {
+	return encodeJSON(buf, v)
+}
+
+// encodeJSON marshals the value into the buffer as JSON, which is identical to the
+// output of json.Marshal.
+func encodeJSON(buf *bytes.Buffer, v interface{}) error {
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return err
+	}
+	// Encode terminates the value with a newline.
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
+
 // ContentType returns the JSON MIME type of the response.
 func (j jsonSerializer) ContentType() string {
 	return "application/json"
 }
 
+// problemSerializer is an implementation of ResponseSerializer which serializes error
+// responses as RFC 7807 problem details.
+type problemSerializer struct{}
+
+// Serialize marshals an error response payload into a problem details JSON byte slice.
+func (p problemSerializer) Serialize(payload Payload) ([]byte, error) {
+	return json.Marshal(problemDetails(payload))
+}
+
+// encode marshals an error response payload into the buffer as problem details JSON.
+func (p problemSerializer) encode(buf *bytes.Buffer, v interface{}) error {
+	payload, _ := v.(Payload)
+	return encodeJSON(buf, problemDetails(payload))
+}
+
+// problemDetails returns the problem details for an error response payload.
+func problemDetails(payload Payload) Payload {
+	problem := Payload{"type": "about:blank"}
+	if s, ok := payload[status]; ok {
+		problem["status"] = s
+	}
+	if r, ok := payload[reason]; ok {
+		problem["title"] = r
+	}
+	if m, ok := payload[messages].([]string); ok && len(m) > 0 {
+		problem["detail"] = strings.Join(m, "; ")
+	}
+	for key, value := range payload {
+		switch key {
+		case status, reason, messages:
+		default:
+			problem[key] = value
+		}
+	}
+	return problem
+}
+
+// ContentType returns the problem details MIME type of the response.
+func (p problemSerializer) ContentType() string {
+	return "application/problem+json"
+}
+
 // NewResponse constructs a new response struct containing the payload to send back.
 // It will either be a success or error response depending on the RequestContext.
 func NewResponse(ctx RequestContext) response {
@@ -78,7 +197,7 @@ func NewResponse(ctx RequestContext) response {
 func newSuccessResponse(ctx RequestContext) response {
 	r := ctx.Result()
 	resultKey := result
-	if r != nil && reflect.TypeOf(r).Kind() == reflect.Slice {
+	if isList(r) {
 		resultKey = results
 	}
 
@@ -86,40 +205,78 @@ func newSuccessResponse(ctx RequestContext) response {
 	response := response{Status: s}
 
 	if s != http.StatusNoContent {
-		payload := Payload{
-			status:    s,
-			reason:    http.StatusText(s),
-			messages:  ctx.Messages(),
-			resultKey: r,
-		}
+		payload := getEnvelope()
+		payload[status] = s
+		payload[reason] = http.StatusText(s)
+		payload[messages] = ctx.Messages()
+		payload[resultKey] = r
 
 		if nextURL, err := ctx.NextURL(); err == nil && nextURL != "" {
 			payload[next] = nextURL
 		}
 
+		if ctx.Pagination() == OffsetPagination {
+			addOffsetPagination(ctx, payload)
+		}
+
+		if errs := ctx.resourceErrors(); len(errs) > 0 {
+			payload[idErrors] = resourceErrorsPayload(errs)
+		}
+
 		response.Payload = payload
+		response.pooled = true
 	}
 
 	return response
 }
 
+// addOffsetPagination adds the OffsetPagination metadata for the request to the
+// response payload. The total count and previous page URL are only included if the
+// request handler supplied a total count and there is a previous page, respectively.
+func addOffsetPagination(ctx RequestContext, payload Payload) {
+	payload[page] = ctx.Page()
+	payload[perPage] = ctx.PerPage()
+
+	if count, ok := ctx.TotalCount(); ok {
+		payload[total] = count
+	}
+
+	if prevURL, err := ctx.PrevURL(); err == nil && prevURL != "" {
+		payload[prev] = prevURL
+	}
+}
+
+// resourceErrorsPayload returns a Payload containing the status and reason of each
+// resource error, keyed by resource ID.
+func resourceErrorsPayload(errs map[string]error) Payload {
+	payload := Payload{}
+	for id, err := range errs {
+		payload[id] = Payload{status: errorStatus(err), reason: err.Error()}
+	}
+	return payload
+}
+
 // newErrorResponse constructs a new response struct containing an error message.
+// 304 Not Modified responses don't have a body.
 func newErrorResponse(ctx RequestContext) response {
 	err := ctx.Error()
-	s := http.StatusInternalServerError
-	if restError, ok := err.(Error); ok {
-		s = restError.Status()
+	s := errorStatus(err)
+	if s == http.StatusNotModified {
+		return response{Status: s}
 	}
 
-	payload := Payload{
-		status:   s,
-		reason:   http.StatusText(s),
-		messages: ctx.Messages(),
+	payload := getEnvelope()
+	payload[status] = s
+	payload[reason] = http.StatusText(s)
+	payload[messages] = ctx.Messages()
+	if fields, ok := err.(FieldErrors); ok {
+		payload[idErrors] = fields.Messages()
 	}
 
 	response := response{
 		Payload: payload,
 		Status:  s,
+		pooled:  true,
 	}
 
 	return response
@@ -0,0 +1,143 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// textSerializer serializes single results as plain text and doesn't support lists
+// or errors.
+type textSerializer struct{}
+
+func (t textSerializer) Serialize(p Payload) ([]byte, error) {
+	return []byte(fmt.Sprint(p[result])), nil
+}
+
+func (t textSerializer) ContentType() string {
+	return "text/plain"
+}
+
+func (t textSerializer) SupportsList() bool {
+	return false
+}
+
+func (t textSerializer) SupportsErrors() bool {
+	return false
+}
+
+// Ensures that serializers are used for the responses they support.
+func TestSerializerCapabilitiesSupported(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&headerResourceHandler{})
+	api.RegisterResponseSerializer("text", textSerializer{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/1?format=text", nil)
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal("text/plain", resp.Header().Get("Content-Type"))
+	assert.Equal("&{hello}", resp.Body.String())
+}
+
+// Ensures that list responses are rejected with 406 and errors are serialized as JSON
+// when the serializer doesn't support them.
+func TestSerializerCapabilitiesUnsupported(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&statusResourceHandler{})
+	api.RegisterResponseSerializer("text", textSerializer{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo?format=text", nil)
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusNotAcceptable, resp.Code, "Incorrect response code")
+	assert.Equal("application/json", resp.Header().Get("Content-Type"))
+	assert.Equal(
+		`{"messages":["Format text does not support lists"],"reason":"Not Acceptable","status":406}`,
+		resp.Body.String(),
+		"Incorrect response string",
+	)
+
+	req, _ = http.NewRequest("GET", "http://foo.com/api/v1/foo?format=text&next=fail", nil)
+	resp = httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusNotFound, resp.Code, "Incorrect response code")
+	assert.Equal("application/json", resp.Header().Get("Content-Type"))
+}
+
+// Ensures that errors are serialized as problem details when configured.
+func TestErrorFormatProblem(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{ErrorFormat: ErrorFormatProblem})
+	api.RegisterResourceHandler(&headerResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/2", nil)
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusServiceUnavailable, resp.Code, "Incorrect response code")
+	assert.Equal("application/problem+json", resp.Header().Get("Content-Type"))
+	assert.Equal(
+		`{"detail":"Try again later","status":503,"title":"Service Unavailable","type":"about:blank"}`,
+		resp.Body.String(),
+		"Incorrect response string",
+	)
+
+	req, _ = http.NewRequest("GET", "http://foo.com/api/v1/foo/1", nil)
+	resp = httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal("application/json", resp.Header().Get("Content-Type"))
+}
+
+// Ensures that errors are serialized in the configured format regardless of the
+// requested format.
+func TestErrorFormatJSON(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{ErrorFormat: "json"})
+	api.RegisterResourceHandler(&headerResourceHandler{})
+	api.RegisterResponseSerializer("foo", &TestResponseSerializer{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/2?format=foo", nil)
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusServiceUnavailable, resp.Code, "Incorrect response code")
+	assert.Equal("application/json", resp.Header().Get("Content-Type"))
+	assert.Equal(
+		`{"messages":["Try again later"],"reason":"Service Unavailable","status":503}`,
+		resp.Body.String(),
+		"Incorrect response string",
+	)
+}
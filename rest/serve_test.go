@@ -0,0 +1,89 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+// Ensures that the API serves requests accepted on a TCP listener.
+func TestServe(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&headerResourceHandler{})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(err) {
+		return
+	}
+	served := make(chan error, 1)
+	go func() { served <- api.Serve(listener) }()
+
+	resp, err := http.Get("http://" + listener.Addr().String() + "/api/v1/foo/1")
+	if assert.NoError(err) {
+		resp.Body.Close()
+		assert.Equal(http.StatusOK, resp.StatusCode, "Incorrect response code")
+	}
+
+	assert.NoError(api.Shutdown(context.Background()))
+	assert.Equal(http.ErrServerClosed, <-served)
+}
+
+// Ensures that the API serves requests accepted on a Unix domain socket.
+func TestServeUnixSocket(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&headerResourceHandler{})
+
+	dir, err := ioutil.TempDir("", "rest")
+	if !assert.NoError(err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "api.sock")
+
+	listener, err := net.Listen("unix", socket)
+	if !assert.NoError(err) {
+		return
+	}
+	served := make(chan error, 1)
+	go func() { served <- api.Serve(listener) }()
+
+	client := &http.Client{Transport: &http.Transport{
+		Dial: func(network, addr string) (net.Conn, error) {
+			return net.Dial("unix", socket)
+		},
+	}}
+	resp, err := client.Get("http://unix/api/v1/foo/1")
+	if assert.NoError(err) {
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(http.StatusOK, resp.StatusCode, "Incorrect response code")
+		assert.Contains(string(body), `"foo":"hello"`)
+	}
+
+	assert.NoError(api.Shutdown(context.Background()))
+	assert.Equal(http.ErrServerClosed, <-served)
+}
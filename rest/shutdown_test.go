@@ -0,0 +1,137 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+type slowResourceHandler struct {
+	BaseResourceHandler
+	started chan bool
+}
+
+func (s *slowResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (s *slowResourceHandler) ReadResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	s.started <- true
+	time.Sleep(100 * time.Millisecond)
+	return Payload{"id": id}, nil
+}
+
+// freeAddress returns a local address which isn't in use.
+func freeAddress(t *testing.T) Address {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return Address(listener.Addr().String())
+}
+
+// Ensures that Shutdown waits for in-flight requests to complete and stops Start.
+func TestShutdown(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	handler := &slowResourceHandler{started: make(chan bool, 1)}
+	api.RegisterResourceHandler(handler)
+	addr := freeAddress(t)
+
+	started := make(chan error, 1)
+	go func() { started <- api.Start(addr) }()
+
+	type result struct {
+		resp *http.Response
+		err  error
+	}
+	results := make(chan result, 1)
+	go func() {
+		for {
+			resp, err := http.Get("http://" + string(addr) + "/api/v1/foo/1")
+			if err == nil {
+				results <- result{resp, nil}
+				return
+			}
+			select {
+			case err := <-started:
+				results <- result{nil, err}
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}()
+
+	<-handler.started
+	assert.NoError(api.Shutdown(context.Background()))
+
+	r := <-results
+	if assert.NoError(r.err) {
+		body, _ := ioutil.ReadAll(r.resp.Body)
+		r.resp.Body.Close()
+		assert.Equal(http.StatusOK, r.resp.StatusCode, "Incorrect response code")
+		assert.Contains(string(body), `"id":"1"`)
+	}
+	assert.Equal(http.ErrServerClosed, <-started)
+	assert.Equal(http.ErrServerClosed, api.Start(addr))
+}
+
+// Ensures that Shutdown returns the Context's error if in-flight requests don't
+// complete in time.
+func TestShutdownTimeout(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	handler := &slowResourceHandler{started: make(chan bool, 1)}
+	api.RegisterResourceHandler(handler)
+	addr := freeAddress(t)
+
+	go api.Start(addr)
+	go func() {
+		for {
+			resp, err := http.Get("http://" + string(addr) + "/api/v1/foo/1")
+			if err == nil {
+				resp.Body.Close()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	<-handler.started
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(context.DeadlineExceeded, api.Shutdown(ctx))
+}
+
+// Ensures that Shutdown is a no-op if the API isn't serving.
+func TestShutdownNotStarted(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+
+	assert.NoError(api.Shutdown(context.Background()))
+	assert.NoError(api.Shutdown(context.Background()))
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+const (
+	// snapshotIDKey is the name of the restore payload field identifying the snapshot
+	// to restore.
+	snapshotIDKey = "snapshot"
+
+	// restoreTimeKey is the name of the restore payload field specifying the point in
+	// time to restore.
+	restoreTimeKey = "time"
+)
+
+// Snapshot is a point-in-time copy of a resource.
+type Snapshot struct {
+	ID       string    `json:"id"`
+	Taken    time.Time `json:"taken"`
+	Resource Resource  `json:"resource"`
+}
+
+// RestorePoint identifies the state a resource should be restored to. Either the
+// SnapshotID or the Time is set.
+type RestorePoint struct {
+	// SnapshotID is the ID of the Snapshot to restore.
+	SnapshotID string
+
+	// Time is the point in time to restore the resource to.
+	Time time.Time
+}
+
+// SnapshotResourceHandler can be implemented by a ResourceHandler to support taking
+// operational backups of specific resources and restoring them. If implemented, the
+// following admin endpoints are registered relative to the handler's read URI:
+//
+//	POST /api/:version/resourceName/{resource_id}/snapshots
+//	POST /api/:version/resourceName/{resource_id}/restore
+//
+// The restore endpoint expects a payload specifying either a "snapshot" ID or a
+// "time" to restore to. Snapshots and restores are audit logged.
+type SnapshotResourceHandler interface {
+	// SnapshotResource takes a snapshot of the resource with the given ID. It returns
+	// the Snapshot or an error if the snapshot failed.
+	SnapshotResource(RequestContext, string, string) (*Snapshot, error)
+
+	// RestoreResource restores the resource with the given ID to the RestorePoint. It
+	// returns the restored resource or an error if the restore failed.
+	RestoreResource(RequestContext, string, RestorePoint, string) (Resource, error)
+}
+
+// handleSnapshot returns a Handler which will pass the resource id to the handler's
+// snapshot function and then serialize and dispatch the response.
+func (h requestHandler) handleSnapshot(handler ResourceHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := h.newContext(w, r)
+		version := ctx.Version()
+		snapshotter := unwrapResourceHandler(handler).(SnapshotResourceHandler)
+
+		snapshot, err := snapshotter.SnapshotResource(ctx, ctx.ResourceID(), version)
+		if err == nil && snapshot != nil {
+			snapshot.Resource = applyOutboundRules(snapshot.Resource,
+				visibleRules(ctx, handler.Rules()), version)
+			h.auditf(r, "snapshot %q taken of %s %q", snapshot.ID,
+				handler.ResourceName(), ctx.ResourceID())
+		}
+
+		ctx = ctx.setResult(snapshot)
+		ctx = ctx.setError(err)
+		ctx = ctx.setStatus(http.StatusCreated)
+
+		h.sendResponse(ctx)
+	})
+}
+
+// handleRestore returns a Handler which will deserialize the restore point from the
+// request payload, pass it to the handler's restore function, and then serialize and
+// dispatch the response.
+func (h requestHandler) handleRestore(handler ResourceHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := h.newContext(w, r)
+		version := ctx.Version()
+		snapshotter := unwrapResourceHandler(handler).(SnapshotResourceHandler)
+
+		if err := checkDryRun(ctx, handler); err != nil {
+			h.sendResponse(ctx.setError(err))
+			return
+		}
+
+		body, err := h.requestBody(ctx)
+		if err != nil {
+			h.sendResponse(ctx.setError(err))
+			return
+		}
+
+		data, err := decodePayload(body)
+		if err != nil {
+			// Payload decoding failed.
+			ctx = ctx.setError(BadRequest(err.Error()))
+			h.sendResponse(ctx)
+			return
+		}
+
+		point, err := decodeRestorePoint(data)
+		if err != nil {
+			ctx = ctx.setError(UnprocessableRequest(err.Error()))
+			h.sendResponse(ctx)
+			return
+		}
+
+		resource, err := snapshotter.RestoreResource(ctx, ctx.ResourceID(), point, version)
+		if err == nil {
+			resource = applyOutboundRules(resource, visibleRules(ctx, handler.Rules()), version)
+			h.auditf(r, "%s %q restored to %s", handler.ResourceName(),
+				ctx.ResourceID(), point)
+		}
+
+		ctx = ctx.setResult(resource)
+		ctx = ctx.setError(err)
+		ctx = ctx.setStatus(http.StatusOK)
+
+		h.sendResponse(ctx)
+	})
+}
+
+// decodeRestorePoint returns the RestorePoint specified by the restore payload or an
+// error if the payload doesn't specify a valid snapshot ID or time.
+func decodeRestorePoint(data Payload) (RestorePoint, error) {
+	if id, err := data.GetString(snapshotIDKey); err == nil && id != "" {
+		return RestorePoint{SnapshotID: id}, nil
+	}
+
+	timeStr, err := data.GetString(restoreTimeKey)
+	if err != nil {
+		return RestorePoint{}, errors.New("Restore requires a snapshot or time")
+	}
+
+	t, err := coerceFromString(timeStr, Time)
+	if err != nil {
+		return RestorePoint{}, err
+	}
+
+	return RestorePoint{Time: t.(time.Time)}, nil
+}
+
+// String returns a human-readable description of the RestorePoint.
+func (p RestorePoint) String() string {
+	if p.SnapshotID != "" {
+		return "snapshot " + p.SnapshotID
+	}
+	return p.Time.Format(timeLayout)
+}
+
+// auditf writes an audit log entry for an admin operation performed by the given
+// request using the Configuration Logger.
+func (h requestHandler) auditf(r *http.Request, format string, v ...interface{}) {
+	h.logf("Audit: "+format+" (remote address %s)", append(v, r.RemoteAddr)...)
+}
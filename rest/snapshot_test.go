@@ -0,0 +1,144 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type snapshotResourceHandler struct {
+	BaseResourceHandler
+	restored RestorePoint
+}
+
+func (s *snapshotResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (s *snapshotResourceHandler) SnapshotResource(ctx RequestContext, id,
+	version string) (*Snapshot, error) {
+
+	return &Snapshot{
+		ID:       "snap1",
+		Taken:    time.Date(2015, 1, 2, 3, 4, 5, 0, time.UTC),
+		Resource: &TestResource{Foo: id},
+	}, nil
+}
+
+func (s *snapshotResourceHandler) RestoreResource(ctx RequestContext, id string,
+	point RestorePoint, version string) (Resource, error) {
+
+	s.restored = point
+	return &TestResource{Foo: id}, nil
+}
+
+// Ensures that snapshot routes are only registered for handlers implementing
+// SnapshotResourceHandler.
+func TestRegisterSnapshotRoutes(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{}).(*muxAPI)
+
+	api.RegisterResourceHandler(TestResourceHandler{})
+	assert.Nil(api.muxRouter().Get("widgets:" + string(HandleSnapshot)))
+
+	api.RegisterResourceHandler(&snapshotResourceHandler{})
+	assert.NotNil(api.muxRouter().Get("foo:" + string(HandleSnapshot)))
+	assert.NotNil(api.muxRouter().Get("foo:" + string(HandleRestore)))
+}
+
+// Ensures that the snapshot handler returns the snapshot with a Created code and
+// writes an audit log entry.
+func TestHandleSnapshot(t *testing.T) {
+	assert := assert.New(t)
+	var logs bytes.Buffer
+	api := NewAPI(&Configuration{Logger: log.New(&logs, "", 0)})
+	api.RegisterResourceHandler(&snapshotResourceHandler{})
+	snapshotHandler, _ := api.(*muxAPI).getRouteHandler("foo:snapshot")
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo/1/snapshots", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	resp := httptest.NewRecorder()
+
+	snapshotHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusCreated, resp.Code, "Incorrect response code")
+	assert.Equal(
+		`{"messages":[],"reason":"Created","result":{"id":"snap1",`+
+			`"taken":"2015-01-02T03:04:05Z","resource":{"foo":""}},"status":201}`,
+		resp.Body.String(),
+		"Incorrect response string",
+	)
+	assert.Contains(logs.String(), `Audit: snapshot "snap1" taken of foo ""`)
+	assert.Contains(logs.String(), "10.0.0.1:1234")
+}
+
+// Ensures that the restore handler decodes the restore point from the payload.
+func TestHandleRestore(t *testing.T) {
+	assert := assert.New(t)
+	handler := &snapshotResourceHandler{}
+	api := NewAPI(&Configuration{Logger: log.New(&bytes.Buffer{}, "", 0)})
+	api.RegisterResourceHandler(handler)
+	restoreHandler, _ := api.(*muxAPI).getRouteHandler("foo:restore")
+
+	payload := bytes.NewBufferString(`{"snapshot": "snap1"}`)
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo/1/restore", payload)
+	resp := httptest.NewRecorder()
+
+	restoreHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal(RestorePoint{SnapshotID: "snap1"}, handler.restored)
+
+	payload = bytes.NewBufferString(`{"time": "2015-01-02T03:04:05Z"}`)
+	req, _ = http.NewRequest("POST", "http://foo.com/api/v1/foo/1/restore", payload)
+	resp = httptest.NewRecorder()
+
+	restoreHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal(RestorePoint{Time: time.Date(2015, 1, 2, 3, 4, 5, 0, time.UTC)},
+		handler.restored)
+}
+
+// Ensures that the restore handler returns an Unprocessable Entity code if the
+// payload doesn't specify a restore point.
+func TestHandleRestoreMissingPoint(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&snapshotResourceHandler{})
+	restoreHandler, _ := api.(*muxAPI).getRouteHandler("foo:restore")
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo/1/restore",
+		bytes.NewBufferString("{}"))
+	resp := httptest.NewRecorder()
+
+	restoreHandler.ServeHTTP(resp, req)
+
+	assert.Equal(422, resp.Code, "Incorrect response code")
+	assert.Equal(
+		`{"messages":["Restore requires a snapshot or time"],"reason":"Unprocessable Entity","status":422}`,
+		resp.Body.String(),
+		"Incorrect response string",
+	)
+}
@@ -0,0 +1,258 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"time"
+)
+
+const (
+	// defaultSoakResources is the number of resources registered by a soak run if one
+	// isn't configured.
+	defaultSoakResources = 300
+
+	// defaultSoakBatchSize is the number of resources registered between soak
+	// measurements if one isn't configured.
+	defaultSoakBatchSize = 50
+)
+
+// defaultSoakVersions are the versions requested by a soak run if none are configured.
+var defaultSoakVersions = []string{"1", "2", "3"}
+
+// SoakConfig configures a routing soak run.
+type SoakConfig struct {
+	// Resources is the number of resources to register. Defaults to 300.
+	Resources int
+
+	// Versions are the versions each resource is requested with. Defaults to 1, 2, and
+	// 3.
+	Versions []string
+
+	// BatchSize is the number of resources registered between measurements of the
+	// registration time and memory. Defaults to 50.
+	BatchSize int
+
+	// Configuration is the Configuration of the API under test. Defaults to an empty
+	// Configuration.
+	Configuration *Configuration
+}
+
+// SoakMeasurement is the cumulative cost of registering resources with an API.
+type SoakMeasurement struct {
+	// Resources is the number of resources registered so far.
+	Resources int
+
+	// Duration is the time spent registering the resources so far.
+	Duration time.Duration
+
+	// Bytes is the memory allocated registering the resources so far.
+	Bytes uint64
+}
+
+// SoakReport is the result of a routing soak run.
+type SoakReport struct {
+	// Measurements are the registration costs measured after each batch of resources.
+	Measurements []SoakMeasurement
+
+	// Requests is the number of requests made to verify routing.
+	Requests int
+
+	// Failures describes each request which wasn't routed to the expected resource and
+	// version.
+	Failures []string
+}
+
+// RegistrationTime returns the total time spent registering resources.
+func (s SoakReport) RegistrationTime() time.Duration {
+	if len(s.Measurements) == 0 {
+		return 0
+	}
+	return s.Measurements[len(s.Measurements)-1].Duration
+}
+
+// RegistrationBytes returns the total memory allocated registering resources.
+func (s SoakReport) RegistrationBytes() uint64 {
+	if len(s.Measurements) == 0 {
+		return 0
+	}
+	return s.Measurements[len(s.Measurements)-1].Bytes
+}
+
+// Scaling returns the ratio of the registration time per resource of the last batch to
+// that of the first batch. A ratio well above 1 indicates registration slows down as
+// resources are added. It returns 0 if there are fewer than two batches.
+func (s SoakReport) Scaling() float64 {
+	if len(s.Measurements) < 2 {
+		return 0
+	}
+	first := s.Measurements[0]
+	last, prev := s.Measurements[len(s.Measurements)-1], s.Measurements[len(s.Measurements)-2]
+	firstRate := float64(first.Duration) / float64(first.Resources)
+	lastRate := float64(last.Duration-prev.Duration) / float64(last.Resources-prev.Resources)
+	if firstRate == 0 {
+		return 0
+	}
+	return lastRate / firstRate
+}
+
+// Soak registers many resources with a new API, measuring the registration cost as it
+// grows, then requests every resource and version to verify each request is routed to
+// the expected ResourceHandler with the expected version. It returns an error if the
+// API can't be created or routing fails for any request, along with the SoakReport.
+func Soak(config SoakConfig) (SoakReport, error) {
+	if config.Resources <= 0 {
+		config.Resources = defaultSoakResources
+	}
+	if len(config.Versions) == 0 {
+		config.Versions = defaultSoakVersions
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultSoakBatchSize
+	}
+	if config.Configuration == nil {
+		config.Configuration = &Configuration{}
+	}
+
+	report := SoakReport{}
+	api := NewAPI(config.Configuration)
+
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	startBytes := stats.TotalAlloc
+	var elapsed time.Duration
+	for registered := 0; registered < config.Resources; {
+		batch := config.BatchSize
+		if remaining := config.Resources - registered; remaining < batch {
+			batch = remaining
+		}
+		start := time.Now()
+		for i := registered; i < registered+batch; i++ {
+			api.RegisterResourceHandler(&soakResourceHandler{name: soakResourceName(i)})
+		}
+		elapsed += time.Since(start)
+		registered += batch
+
+		runtime.ReadMemStats(&stats)
+		report.Measurements = append(report.Measurements, SoakMeasurement{
+			Resources: registered,
+			Duration:  elapsed,
+			Bytes:     stats.TotalAlloc - startBytes,
+		})
+	}
+
+	for i := 0; i < config.Resources; i++ {
+		name := soakResourceName(i)
+		for _, version := range config.Versions {
+			for _, id := range []string{"", "1"} {
+				report.Requests++
+				if err := checkSoakRoute(api, name, version, id); err != nil {
+					report.Failures = append(report.Failures, err.Error())
+				}
+			}
+		}
+	}
+
+	if len(report.Failures) > 0 {
+		return report, fmt.Errorf("%d of %d requests were misrouted, first: %s",
+			len(report.Failures), report.Requests, report.Failures[0])
+	}
+	return report, nil
+}
+
+// soakResourceName returns the name of the i-th resource registered by a soak run.
+func soakResourceName(i int) string {
+	return fmt.Sprintf("soak%d", i)
+}
+
+// checkSoakRoute requests the resource, or the resource list if the ID is empty, with
+// the version and returns an error if the response didn't come from the expected
+// ResourceHandler and version.
+func checkSoakRoute(api API, name, version, id string) error {
+	url := fmt.Sprintf("/api/v%s/%s", version, name)
+	if id != "" {
+		url += "/" + id
+	}
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		return fmt.Errorf("GET %s responded with %d", url, resp.Code)
+	}
+
+	var body struct {
+		Result  soakResource   `json:"result"`
+		Results []soakResource `json:"results"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		return fmt.Errorf("GET %s responded with invalid JSON: %s", url, err)
+	}
+	got := body.Result
+	if id == "" {
+		if len(body.Results) != 1 {
+			return fmt.Errorf("GET %s responded with %d results", url, len(body.Results))
+		}
+		got = body.Results[0]
+	}
+	expected := soakResource{Resource: name, Version: version, ID: id}
+	if got != expected {
+		return fmt.Errorf("GET %s was routed to %s version %s with ID %q",
+			url, got.Resource, got.Version, got.ID)
+	}
+	return nil
+}
+
+// soakResource is the resource returned by a soakResourceHandler, identifying the
+// resource and version the request was routed to.
+type soakResource struct {
+	Resource string `json:"resource"`
+	Version  string `json:"version"`
+	ID       string `json:"id"`
+}
+
+// soakResourceHandler is the ResourceHandler registered for each resource of a soak run.
+type soakResourceHandler struct {
+	BaseResourceHandler
+	name string
+}
+
+// ResourceName returns the name of the soak resource.
+func (s *soakResourceHandler) ResourceName() string {
+	return s.name
+}
+
+// ReadResourceList returns a single soakResource identifying the route.
+func (s *soakResourceHandler) ReadResourceList(ctx RequestContext, limit int,
+	cursor string, version string) ([]Resource, string, error) {
+
+	return []Resource{soakResource{Resource: s.name, Version: version}}, "", nil
+}
+
+// ReadResource returns a soakResource identifying the route.
+func (s *soakResourceHandler) ReadResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	return soakResource{Resource: s.name, Version: version, ID: id}, nil
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Ensures that Soak verifies the routing of hundreds of resources and versions and
+// measures each batch of registrations.
+func TestSoak(t *testing.T) {
+	assert := assert.New(t)
+
+	report, err := Soak(SoakConfig{})
+
+	assert.NoError(err)
+	assert.Empty(report.Failures)
+	assert.Equal(300*3*2, report.Requests)
+	assert.Len(report.Measurements, 6)
+	assert.Equal(300, report.Measurements[5].Resources)
+	assert.True(report.RegistrationTime() > 0)
+	assert.True(report.RegistrationBytes() > 0)
+}
+
+// Ensures that the soak registration measurements are batched as configured.
+func TestSoakBatches(t *testing.T) {
+	assert := assert.New(t)
+
+	report, err := Soak(SoakConfig{Resources: 25, BatchSize: 10, Versions: []string{"1.2"}})
+
+	assert.NoError(err)
+	assert.Equal(50, report.Requests)
+	if assert.Len(report.Measurements, 3) {
+		assert.Equal(10, report.Measurements[0].Resources)
+		assert.Equal(25, report.Measurements[2].Resources)
+	}
+	assert.True(report.Measurements[2].Duration >= report.Measurements[0].Duration)
+}
+
+// Ensures that Scaling compares the per-resource registration time of the last and first
+// batches.
+func TestSoakReportScaling(t *testing.T) {
+	assert := assert.New(t)
+	report := SoakReport{Measurements: []SoakMeasurement{
+		{Resources: 10, Duration: 10},
+		{Resources: 20, Duration: 30},
+		{Resources: 25, Duration: 45},
+	}}
+
+	assert.Equal(3.0, report.Scaling())
+	assert.Equal(0.0, SoakReport{}.Scaling())
+}
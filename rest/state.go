@@ -0,0 +1,89 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+// apiState is a snapshot of an API's registrations. Snapshots are never modified once
+// they're stored. Instead, registrations store a modified copy, so requests read the
+// registrations without locking while handlers, serializers, and plugins are
+// registered and unregistered concurrently.
+type apiState struct {
+	serializers map[string]ResponseSerializer
+	handlers    []ResourceHandler
+	byName      map[string]ResourceHandler
+	versions    map[string][]string
+	plugins     []Plugin
+}
+
+// newAPIState returns the registrations of a new API, which only has the JSON
+// serializer.
+func newAPIState() *apiState {
+	return &apiState{
+		serializers: map[string]ResponseSerializer{"json": &jsonSerializer{}},
+		handlers:    []ResourceHandler{},
+		byName:      map[string]ResourceHandler{},
+		versions:    map[string][]string{},
+	}
+}
+
+// clone returns a copy of the snapshot which can be modified.
+func (s *apiState) clone() *apiState {
+	clone := &apiState{
+		serializers: make(map[string]ResponseSerializer, len(s.serializers)),
+		handlers:    append([]ResourceHandler(nil), s.handlers...),
+		versions:    make(map[string][]string, len(s.versions)),
+		plugins:     append([]Plugin(nil), s.plugins...),
+	}
+	for format, serializer := range s.serializers {
+		clone.serializers[format] = serializer
+	}
+	for resource, versions := range s.versions {
+		clone.versions[resource] = append([]string(nil), versions...)
+	}
+	return clone
+}
+
+// index maps the resource names to the first ResourceHandler registered for them.
+func (s *apiState) index() {
+	s.byName = make(map[string]ResourceHandler, len(s.handlers))
+	for _, handler := range s.handlers {
+		if _, ok := s.byName[handler.ResourceName()]; !ok {
+			s.byName[handler.ResourceName()] = handler
+		}
+	}
+}
+
+// loadState returns the current snapshot of the API's registrations.
+func (r *muxAPI) loadState() *apiState {
+	return r.state.Load().(*apiState)
+}
+
+// updateState applies the update to a copy of the current snapshot of the API's
+// registrations, which then replaces it. Updates are serialized.
+func (r *muxAPI) updateState(update func(*apiState)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state := r.loadState().clone()
+	update(state)
+	state.index()
+	r.state.Store(state)
+}
+
+// resourceHandler returns the first ResourceHandler registered for the named resource.
+func (r *muxAPI) resourceHandler(resource string) (ResourceHandler, bool) {
+	handler, ok := r.loadState().byName[resource]
+	return handler, ok
+}
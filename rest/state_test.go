@@ -0,0 +1,111 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Ensures that registrations replace the API's snapshot rather than modifying it, so
+// snapshots held by requests don't change.
+func TestUpdateStateCopiesSnapshot(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{}).(*muxAPI)
+	api.RegisterResourceHandler(hotResourceHandler{name: "foo"})
+	before := api.loadState()
+
+	api.RegisterResponseSerializer("csv", jsonSerializer{})
+	api.RegisterResourceHandler(hotResourceHandler{name: "bar"})
+	assert.NoError(api.UnregisterResourceHandler("foo"))
+
+	assert.Len(before.handlers, 1)
+	assert.Len(before.serializers, 1)
+	_, ok := before.byName["foo"]
+	assert.True(ok)
+
+	after := api.loadState()
+	assert.Len(after.handlers, 1)
+	assert.Len(after.serializers, 2)
+	_, ok = after.byName["foo"]
+	assert.False(ok)
+	handler, ok := api.resourceHandler("bar")
+	assert.True(ok)
+	assert.Equal("bar", handler.ResourceName())
+}
+
+// Ensures that ResourceHandlers, serializers, and plugins can be registered and
+// unregistered while requests are served. Run with -race to detect unsynchronized
+// access.
+func TestConcurrentRegistrationAndTraffic(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{Links: true})
+	api.RegisterResourceHandler(hotResourceHandler{name: "foo"})
+	api.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/foo/1", nil))
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	failures := make(chan int, 100)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				req := httptest.NewRequest("GET", "/api/v1/foo/1?format=json", nil)
+				resp := httptest.NewRecorder()
+				api.ServeHTTP(resp, req)
+				if resp.Code != http.StatusOK {
+					select {
+					case failures <- resp.Code:
+					default:
+					}
+				}
+				api.ResourceHandlers()
+				api.AvailableFormats()
+			}
+		}()
+	}
+
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("bar%d", i)
+		api.RegisterResourceHandler(hotResourceHandler{name: name})
+		api.RegisterResourceHandlerForVersions(hotResourceHandler{name: name + "v"}, "1")
+		api.RegisterResponseSerializer(name, jsonSerializer{})
+		assert.NoError(api.UsePlugin(NewPlugin(name, func(API) error { return nil })))
+		api.Routes()
+		assert.NoError(api.UnregisterResourceHandler(name))
+		api.UnregisterResponseSerializer(name)
+	}
+	close(stop)
+	wg.Wait()
+	close(failures)
+
+	for code := range failures {
+		t.Errorf("Request failed with %d", code)
+	}
+	assert.Len(api.ResourceHandlers(), 11)
+}
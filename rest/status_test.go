@@ -0,0 +1,103 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type statusResourceHandler struct {
+	BaseResourceHandler
+}
+
+func (s *statusResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (s *statusResourceHandler) CreateResource(ctx RequestContext, data Payload,
+	version string) (Resource, error) {
+
+	if _, ok := data["existing"]; ok {
+		ctx.SetResponseStatus(http.StatusOK)
+	}
+	return &TestResource{Foo: "hello"}, nil
+}
+
+func (s *statusResourceHandler) ReadResourceList(ctx RequestContext, limit int,
+	cursor string, version string) ([]Resource, string, error) {
+
+	ctx.SetResponseStatus(http.StatusPartialContent)
+	if cursor == "fail" {
+		return nil, "", ResourceNotFound("No foos")
+	}
+	return []Resource{&TestResource{Foo: "hello"}}, "", nil
+}
+
+// Ensures that handlers can override the success status of a create.
+func TestHandleCreateResponseStatus(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&statusResourceHandler{})
+	createHandler, _ := api.(*muxAPI).getRouteHandler("foo:create")
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo",
+		bytes.NewBufferString(`{"existing": true}`))
+	resp := httptest.NewRecorder()
+
+	createHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal(
+		`{"messages":[],"reason":"OK","result":{"foo":"hello"},"status":200}`,
+		resp.Body.String(),
+		"Incorrect response string",
+	)
+
+	req, _ = http.NewRequest("POST", "http://foo.com/api/v1/foo", bytes.NewBufferString("{}"))
+	resp = httptest.NewRecorder()
+
+	createHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusCreated, resp.Code, "Incorrect response code")
+}
+
+// Ensures that a status set by the handler doesn't apply if the request fails.
+func TestHandleReadListResponseStatus(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&statusResourceHandler{})
+	readListHandler, _ := api.(*muxAPI).getRouteHandler("foo:readList")
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo", nil)
+	resp := httptest.NewRecorder()
+
+	readListHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusPartialContent, resp.Code, "Incorrect response code")
+
+	req, _ = http.NewRequest("GET", "http://foo.com/api/v1/foo?next=fail", nil)
+	resp = httptest.NewRecorder()
+
+	readListHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusNotFound, resp.Code, "Incorrect response code")
+}
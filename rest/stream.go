@@ -0,0 +1,214 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	// streamFormat is the response format which requests a streamed response.
+	streamFormat = "ndjson"
+
+	// streamContentType is the MIME type of streamed responses.
+	streamContentType = "application/x-ndjson"
+
+	// trailerKey is the key of the trailer record ending a streamed response.
+	trailerKey = "_trailer"
+
+	// HTTP trailers set at the end of a streamed response.
+	streamSuccessTrailer = "X-Stream-Success"
+	streamCountTrailer   = "X-Stream-Count"
+	streamNextTrailer    = "X-Stream-Next"
+	streamErrorTrailer   = "X-Stream-Error"
+)
+
+// StreamingResourceHandler can be implemented by a ResourceHandler to stream the results
+// of its read list endpoint as newline-delimited JSON. Streaming is used when the client
+// requests the "ndjson" format or accepts application/x-ndjson. Each resource is written
+// on its own line as it's sent, and the stream ends with a trailer record of the form
+//
+//	{"_trailer": {"success": true, "count": 2, "next": "...", "error": "..."}}
+//
+// which is also provided as HTTP trailers. Clients can detect truncated streams by the
+// absence of the trailer.
+type StreamingResourceHandler interface {
+	// StreamResourceList is the logic that corresponds to reading multiple resources as
+	// a stream. It's given the limit, cursor, and version like ReadResourceList and a
+	// send function which writes a resource to the stream. It returns the cursor for
+	// the next page (or empty) and error (or nil). Errors returned by send indicate the
+	// client can no longer be written to.
+	StreamResourceList(RequestContext, int, string, string, func(Resource) error) (string, error)
+}
+
+// streamRequested returns true if the client requested a streamed response.
+func streamRequested(ctx RequestContext) bool {
+	if ctx.ResponseFormat() == streamFormat {
+		return true
+	}
+	return strings.Contains(ctx.Header().Get("Accept"), streamContentType)
+}
+
+// streamWriter writes resources to a streamed response, applying outbound Rules. The
+// response headers are written when the first resource is sent so that errors which
+// occur before then produce a regular error response.
+type streamWriter struct {
+	ctx     RequestContext
+	rules   Rules
+	version string
+	count   int
+	started bool
+}
+
+// send writes the resource to the stream as a line of JSON.
+func (s *streamWriter) send(resource Resource) error {
+	line, err := json.Marshal(applyOutboundRules(resource, s.rules, s.version))
+	if err != nil {
+		return err
+	}
+
+	w := s.ctx.ResponseWriter()
+	if !s.started {
+		s.start()
+	}
+	if _, err := w.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	s.count++
+
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// start writes the response headers, declaring the HTTP trailers.
+func (s *streamWriter) start() {
+	w := s.ctx.ResponseWriter()
+	w.Header().Set("Content-Type", streamContentType)
+	w.Header().Set("Trailer", strings.Join([]string{streamSuccessTrailer,
+		streamCountTrailer, streamNextTrailer, streamErrorTrailer}, ", "))
+	applyResponseHeaders(s.ctx)
+	w.WriteHeader(http.StatusOK)
+	s.started = true
+}
+
+// finish ends the stream by writing the trailer record and HTTP trailers describing
+// the outcome.
+func (s *streamWriter) finish(cursor string, err error) {
+	if !s.started {
+		s.start()
+	}
+
+	w := s.ctx.ResponseWriter()
+	trailer := Payload{"success": err == nil, "count": s.count}
+	w.Header().Set(streamSuccessTrailer, strconv.FormatBool(err == nil))
+	w.Header().Set(streamCountTrailer, strconv.Itoa(s.count))
+
+	if nextURL, urlErr := s.ctx.setCursor(cursor).NextURL(); err == nil && urlErr == nil {
+		trailer[next] = nextURL
+		w.Header().Set(streamNextTrailer, nextURL)
+	}
+
+	if err != nil {
+		trailer["error"] = err.Error()
+		w.Header().Set(streamErrorTrailer, err.Error())
+	}
+
+	line, _ := json.Marshal(Payload{trailerKey: trailer})
+	w.Write(append(line, '\n'))
+}
+
+// stream passes the request to the StreamingResourceHandler, writing resources as they
+// are sent and ending the stream with a trailer. If the handler fails before sending
+// any resources, a regular JSON error response is sent instead.
+func (h requestHandler) stream(ctx RequestContext, handler ResourceHandler,
+	streamer StreamingResourceHandler, limit int, cursor string) {
+
+	version := ctx.Version()
+	writer := &streamWriter{ctx: ctx, rules: visibleRules(ctx, handler.Rules()),
+		version: version}
+	cursor, err := streamer.StreamResourceList(ctx, limit, cursor, version, writer.send)
+
+	err = h.redactError(ctx, err)
+	if err != nil && !writer.started {
+		sendResponse(ctx.ResponseWriter(), NewResponse(ctx.setError(err)), jsonSerializer{})
+		return
+	}
+
+	writer.finish(cursor, err)
+}
+
+// ndjsonSerializer is an implementation of ResponseSerializer which serializes responses
+// as newline-delimited JSON for clients requesting it from endpoints which don't stream.
+// Lists are written one resource per line and end with a trailer record like streamed
+// responses, while single resources are written on one line. Errors are serialized as
+// JSON.
+type ndjsonSerializer struct{}
+
+// Serialize marshals the resources of a response payload into lines of JSON.
+func (n ndjsonSerializer) Serialize(p Payload) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+
+	list, ok := p[results]
+	if !ok {
+		if err := encoder.Encode(p[result]); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	count := 0
+	if list != nil {
+		v := reflect.ValueOf(list)
+		for count = 0; count < v.Len(); count++ {
+			if err := encoder.Encode(v.Index(count).Interface()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	trailer := Payload{"success": true, "count": count}
+	if nextURL, ok := p[next]; ok {
+		trailer[next] = nextURL
+	}
+	if err := encoder.Encode(Payload{trailerKey: trailer}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ContentType returns the newline-delimited JSON MIME type of the response.
+func (n ndjsonSerializer) ContentType() string {
+	return streamContentType
+}
+
+// SupportsList returns true since lists are serialized one resource per line.
+func (n ndjsonSerializer) SupportsList() bool {
+	return true
+}
+
+// SupportsErrors returns false so errors are serialized as JSON.
+func (n ndjsonSerializer) SupportsErrors() bool {
+	return false
+}
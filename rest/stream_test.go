@@ -0,0 +1,159 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type streamingResourceHandler struct {
+	BaseResourceHandler
+	sent int
+	err  error
+}
+
+func (s *streamingResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (s *streamingResourceHandler) StreamResourceList(ctx RequestContext, limit int,
+	cursor, version string, send func(Resource) error) (string, error) {
+
+	for i := 0; i < s.sent; i++ {
+		if err := send(&TestResource{Foo: "hello"}); err != nil {
+			return "", err
+		}
+	}
+	return "cursor123", s.err
+}
+
+// Ensures that the read list handler streams resources followed by a trailer record
+// and HTTP trailers when the ndjson format is requested.
+func TestHandleReadListStream(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&streamingResourceHandler{sent: 2})
+	readHandler, _ := api.(*muxAPI).getRouteHandler("foo:readList")
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo?format=ndjson", nil)
+	resp := httptest.NewRecorder()
+
+	readHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal(streamContentType, resp.Header().Get("Content-Type"))
+	assert.Equal(
+		`{"foo":"hello"}`+"\n"+`{"foo":"hello"}`+"\n"+
+			`{"_trailer":{"count":2,"next":"http://foo.com/api/v1/foo?format=ndjson\u0026next=cursor123","success":true}}`+"\n",
+		resp.Body.String(),
+	)
+
+	trailer := resp.Result().Trailer
+	assert.Equal("true", trailer.Get(streamSuccessTrailer))
+	assert.Equal("2", trailer.Get(streamCountTrailer))
+}
+
+// Ensures that errors encountered mid-stream are reported in the trailer.
+func TestHandleReadListStreamError(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&streamingResourceHandler{sent: 1, err: errors.New("boom")})
+	readHandler, _ := api.(*muxAPI).getRouteHandler("foo:readList")
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo", nil)
+	req.Header.Set("Accept", streamContentType)
+	resp := httptest.NewRecorder()
+
+	readHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal(
+		`{"foo":"hello"}`+"\n"+`{"_trailer":{"count":1,"error":"boom","success":false}}`+"\n",
+		resp.Body.String(),
+	)
+	assert.Equal("boom", resp.Result().Trailer.Get(streamErrorTrailer))
+}
+
+// Ensures that a regular error response is sent if the stream fails before any
+// resources are sent.
+func TestHandleReadListStreamErrorBeforeSend(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&streamingResourceHandler{err: ResourceNotPermitted("nope")})
+	readHandler, _ := api.(*muxAPI).getRouteHandler("foo:readList")
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo?format=ndjson", nil)
+	resp := httptest.NewRecorder()
+
+	readHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusForbidden, resp.Code, "Incorrect response code")
+}
+
+// Ensures that lists from handlers which don't stream are sent as newline-delimited
+// JSON with outbound rules applied when requested.
+func TestHandleReadListNDJSON(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&linkedResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo?limit=1&format=ndjson", nil)
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal("application/x-ndjson", resp.Header().Get("Content-Type"))
+	assert.Equal(
+		"{\"id\":\"1\"}\n"+
+			"{\"_trailer\":{\"count\":1,\"next\":\"http://foo.com/api/v1/foo?format=ndjson\\u0026limit=1\\u0026next=abc\",\"success\":true}}\n",
+		resp.Body.String(),
+		"Incorrect response string",
+	)
+}
+
+// Ensures that single resources are sent as a line of JSON and errors as JSON when
+// newline-delimited JSON is accepted.
+func TestHandleReadNDJSON(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&headerResourceHandler{})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/1", nil)
+	req.Header.Set("Accept", "application/x-ndjson")
+	resp := httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal("application/x-ndjson", resp.Header().Get("Content-Type"))
+	assert.Equal("{\"foo\":\"hello\"}\n", resp.Body.String(), "Incorrect response string")
+
+	req, _ = http.NewRequest("GET", "http://foo.com/api/v1/foo/2", nil)
+	req.Header.Set("Accept", "application/x-ndjson")
+	resp = httptest.NewRecorder()
+
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusServiceUnavailable, resp.Code, "Incorrect response code")
+	assert.Equal("application/json", resp.Header().Get("Content-Type"))
+}
@@ -0,0 +1,265 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	gcontext "github.com/gorilla/context"
+	"golang.org/x/net/context"
+)
+
+const (
+	// tenantVar is the name of the URL path variable for the tenant when tenants are
+	// identified by a path prefix.
+	tenantVar = "tenant"
+
+	// defaultTenantHeader is the request header identifying the tenant if one isn't
+	// configured.
+	defaultTenantHeader = "X-Tenant-ID"
+
+	// maxTenantStats is the number of tenant and resource pairs whose requests are
+	// counted separately. Without a Resolver, any tenant supplied by clients is
+	// accepted, so requests of tenants beyond the limit are counted together.
+	maxTenantStats = 10000
+)
+
+// gatewayTenantKey is the key of the tenant of dispatched requests in their
+// context.Context.
+type gatewayTenantKey struct{}
+
+// TenantSource is where the tenant of a request is identified.
+type TenantSource string
+
+// Tenant sources.
+const (
+	// TenantSubdomain identifies the tenant by the first label of the request host,
+	// e.g. "acme" for acme.api.example.com.
+	TenantSubdomain TenantSource = "subdomain"
+
+	// TenantPathPrefix identifies the tenant by a path segment preceding the base path
+	// of resource endpoints, e.g. "acme" for /acme/api/v1/foo. Endpoints of
+	// ResourceHandlers with custom URIs aren't affected.
+	TenantPathPrefix TenantSource = "path"
+
+	// TenantHeader identifies the tenant by a request header.
+	TenantHeader TenantSource = "header"
+)
+
+// TenantResolver validates the tenants of requests, e.g. by looking them up in a
+// directory of customers.
+type TenantResolver interface {
+	// ResolveTenant returns an error if the tenant isn't valid for the request. If the
+	// error is an Error, it's sent to the client, otherwise the request is rejected
+	// with 404 Not Found.
+	ResolveTenant(r *http.Request, tenant string) error
+}
+
+// TenantResolverFunc is an adapter allowing a function to be used as a TenantResolver.
+type TenantResolverFunc func(*http.Request, string) error
+
+// ResolveTenant calls the function with the request and tenant.
+func (f TenantResolverFunc) ResolveTenant(r *http.Request, tenant string) error {
+	return f(r, tenant)
+}
+
+// Tenancy configures multi-tenancy. The tenant of each request to ResourceHandler
+// endpoints is identified, validated with the Resolver, and made available with
+// RequestContext's Tenant. Requests without a valid tenant are rejected before they're
+// authenticated. Requests are counted per tenant in API's TenantStats.
+type Tenancy struct {
+	// Source is where the tenant is identified.
+	Source TenantSource
+
+	// Header is the request header identifying the tenant with TenantHeader. If empty,
+	// X-Tenant-ID is used.
+	Header string
+
+	// Resolver validates tenants. If nil, any tenant is accepted, and TenantStats only
+	// counts the first 10000 tenant and resource pairs separately.
+	Resolver TenantResolver
+
+	// ConcurrencyLimit is the maximum number of requests from each tenant to each
+	// resource which are handled at once, so one tenant can't exhaust the capacity
+	// shared with others. Excess requests are shed like those exceeding the
+	// Configuration's ConcurrencyLimit. If zero, tenants aren't limited.
+	ConcurrencyLimit int
+}
+
+// header returns the request header identifying the tenant.
+func (t *Tenancy) header() string {
+	if t.Header != "" {
+		return t.Header
+	}
+	return defaultTenantHeader
+}
+
+// requestTenant returns the tenant identified by the request, which hasn't been
+// validated, or an empty string if there isn't one.
+func requestTenant(tenancy *Tenancy, r *http.Request) string {
+	if tenant, ok := r.Context().Value(gatewayTenantKey{}).(string); ok {
+		return tenant
+	}
+
+	switch tenancy.Source {
+	case TenantSubdomain:
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if net.ParseIP(host) != nil {
+			return ""
+		}
+		if labels := strings.Split(host, "."); len(labels) > 2 {
+			return labels[0]
+		}
+	case TenantPathPrefix:
+		return pathVars(r)[tenantVar]
+	case TenantHeader:
+		return r.Header.Get(tenancy.header())
+	}
+	return ""
+}
+
+// withGatewayTenant returns the context with the tenant of a dispatched request.
+func withGatewayTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, gatewayTenantKey{}, tenant)
+}
+
+// resolvedTenant returns the validated tenant of the request, or an empty string if
+// tenancy isn't configured.
+func resolvedTenant(r *http.Request) string {
+	tenant, _ := gcontext.Get(r, tenantKey).(string)
+	return tenant
+}
+
+// newTenantMiddleware returns a RequestMiddleware which identifies and validates the
+// tenant of requests to the resource, rejecting requests without a valid tenant, and
+// counts the requests of each tenant.
+func newTenantMiddleware(tenancy *Tenancy, metrics *tenantMetrics, h *requestHandler,
+	resource string) RequestMiddleware {
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenant, err := resolveTenant(tenancy, r)
+			if err != nil {
+				h.sendResponse(h.newContext(w, r).setError(err))
+				return
+			}
+
+			gcontext.Set(r, tenantKey, tenant)
+			writer := &statusResponseWriter{ResponseWriter: w}
+			next.ServeHTTP(writer, r)
+			metrics.record(tenant, resource, writer.status)
+		})
+	}
+}
+
+// resolveTenant returns the tenant of the request. It returns an error if the request
+// doesn't identify a tenant or the Tenancy's Resolver rejects it.
+func resolveTenant(tenancy *Tenancy, r *http.Request) (string, error) {
+	tenant := requestTenant(tenancy, r)
+	if tenant == "" {
+		return "", BadRequest("Missing tenant")
+	}
+	if tenancy.Resolver != nil {
+		if err := tenancy.Resolver.ResolveTenant(r, tenant); err != nil {
+			if _, ok := err.(Error); !ok {
+				err = ResourceNotFound(fmt.Sprintf("Unknown tenant %s", tenant))
+			}
+			return "", err
+		}
+	}
+	return tenant, nil
+}
+
+// TenantStats describes the requests made by a tenant to a resource.
+type TenantStats struct {
+	// Tenant is the tenant making the requests. It's empty for the requests of tenants
+	// which weren't counted separately because too many tenants made requests.
+	Tenant string
+
+	// Resource is the name of the requested resource.
+	Resource string
+
+	// Requests is the number of requests handled.
+	Requests uint64
+
+	// ClientErrors and ServerErrors are the number of requests which failed with 4xx
+	// and 5xx statuses respectively.
+	ClientErrors uint64
+	ServerErrors uint64
+}
+
+// tenantMetrics counts the requests of tenants to resources. It's safe for concurrent
+// use.
+type tenantMetrics struct {
+	mu    sync.Mutex
+	stats map[[2]string]*TenantStats
+}
+
+// newTenantMetrics returns a newly allocated tenantMetrics.
+func newTenantMetrics() *tenantMetrics {
+	return &tenantMetrics{stats: map[[2]string]*TenantStats{}}
+}
+
+// record counts a request of the tenant to the resource which was responded to with
+// the status. Once maxTenantStats pairs are counted, the requests of other pairs are
+// counted under an empty tenant.
+func (m *tenantMetrics) record(tenant, resource string, status int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := [2]string{tenant, resource}
+	stats, ok := m.stats[key]
+	if !ok && len(m.stats) >= maxTenantStats {
+		tenant = ""
+		key = [2]string{tenant, resource}
+		stats, ok = m.stats[key]
+	}
+	if !ok {
+		stats = &TenantStats{Tenant: tenant, Resource: resource}
+		m.stats[key] = stats
+	}
+	stats.Requests++
+	if status >= 500 {
+		stats.ServerErrors++
+	} else if status >= 400 {
+		stats.ClientErrors++
+	}
+}
+
+// snapshot returns the TenantStats ordered by tenant and resource.
+func (m *tenantMetrics) snapshot() []TenantStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := make([]TenantStats, 0, len(m.stats))
+	for _, s := range m.stats {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Tenant != stats[j].Tenant {
+			return stats[i].Tenant < stats[j].Tenant
+		}
+		return stats[i].Resource < stats[j].Resource
+	})
+	return stats
+}
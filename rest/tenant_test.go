@@ -0,0 +1,226 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tenantResourceHandler struct {
+	BaseResourceHandler
+}
+
+func (t *tenantResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (t *tenantResourceHandler) ReadResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	// Resources are owned by the tenant named by their ID prefix.
+	if err := ctx.CheckTenant(id[:1]); err != nil {
+		return nil, err
+	}
+	u, err := ctx.BuildURL("foo", HandleRead, RouteVars{"resource_id": id})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"tenant": ctx.Tenant(), "url": u.Path}, nil
+}
+
+// serveTenant performs a request against an API with the Tenancy and returns the
+// response.
+func serveTenant(api API, req *http.Request) *httptest.ResponseRecorder {
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	return resp
+}
+
+// newTenantAPI returns an API with the Tenancy and the tenant resource registered.
+func newTenantAPI(tenancy *Tenancy) API {
+	api := NewAPI(&Configuration{Tenancy: tenancy})
+	api.RegisterResourceHandler(&tenantResourceHandler{})
+	return api
+}
+
+// Ensures that tenants are identified by request header and made available to
+// handlers, and that resources of other tenants aren't found.
+func TestTenantHeader(t *testing.T) {
+	assert := assert.New(t)
+	api := newTenantAPI(&Tenancy{Source: TenantHeader})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/a1", nil)
+	req.Header.Set("X-Tenant-ID", "a")
+	resp := serveTenant(api, req)
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Contains(resp.Body.String(), `"tenant":"a"`)
+
+	req, _ = http.NewRequest("GET", "http://foo.com/api/v1/foo/b1", nil)
+	req.Header.Set("X-Tenant-ID", "a")
+	resp = serveTenant(api, req)
+	assert.Equal(http.StatusNotFound, resp.Code)
+
+	req, _ = http.NewRequest("GET", "http://foo.com/api/v1/foo/a1", nil)
+	resp = serveTenant(api, req)
+	assert.Equal(http.StatusBadRequest, resp.Code)
+	assert.Contains(resp.Body.String(), "Missing tenant")
+}
+
+// Ensures that tenants are identified by a custom request header.
+func TestTenantCustomHeader(t *testing.T) {
+	assert := assert.New(t)
+	api := newTenantAPI(&Tenancy{Source: TenantHeader, Header: "X-Org"})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/a1", nil)
+	req.Header.Set("X-Org", "a")
+	assert.Equal(http.StatusOK, serveTenant(api, req).Code)
+}
+
+// Ensures that tenants are identified by subdomain, and that hosts without a
+// subdomain are rejected.
+func TestTenantSubdomain(t *testing.T) {
+	assert := assert.New(t)
+	api := newTenantAPI(&Tenancy{Source: TenantSubdomain})
+
+	req, _ := http.NewRequest("GET", "http://a.api.foo.com:8080/api/v1/foo/a1", nil)
+	resp := serveTenant(api, req)
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Contains(resp.Body.String(), `"tenant":"a"`)
+
+	req, _ = http.NewRequest("GET", "http://foo.com/api/v1/foo/a1", nil)
+	assert.Equal(http.StatusBadRequest, serveTenant(api, req).Code)
+
+	req, _ = http.NewRequest("GET", "http://127.0.0.1/api/v1/foo/a1", nil)
+	assert.Equal(http.StatusBadRequest, serveTenant(api, req).Code)
+}
+
+// Ensures that tenants are identified by path prefix and included in URLs built for
+// the request.
+func TestTenantPathPrefix(t *testing.T) {
+	assert := assert.New(t)
+	api := newTenantAPI(&Tenancy{Source: TenantPathPrefix})
+
+	req, _ := http.NewRequest("GET", "http://foo.com/a/api/v1/foo/a1", nil)
+	resp := serveTenant(api, req)
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Contains(resp.Body.String(), `"url":"/a/api/v1/foo/a1"`)
+
+	req, _ = http.NewRequest("GET", "http://foo.com/api/v1/foo/a1", nil)
+	assert.Equal(http.StatusNotFound, serveTenant(api, req).Code)
+}
+
+// Ensures that tenants rejected by the Resolver get a 404 unless the Resolver returns
+// an Error, which is sent to the client.
+func TestTenantResolver(t *testing.T) {
+	assert := assert.New(t)
+	api := newTenantAPI(&Tenancy{
+		Source: TenantHeader,
+		Resolver: TenantResolverFunc(func(r *http.Request, tenant string) error {
+			switch tenant {
+			case "a":
+				return nil
+			case "suspended":
+				return UnauthorizedRequest("Tenant suspended")
+			}
+			return errors.New("no such tenant")
+		}),
+	})
+
+	for tenant, status := range map[string]int{
+		"a":         http.StatusOK,
+		"b":         http.StatusNotFound,
+		"suspended": http.StatusUnauthorized,
+	} {
+		req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/a1", nil)
+		req.Header.Set("X-Tenant-ID", tenant)
+		resp := serveTenant(api, req)
+		assert.Equal(status, resp.Code, tenant)
+		assert.NotContains(resp.Body.String(), "no such tenant")
+	}
+}
+
+// Ensures that requests are counted per tenant and resource.
+func TestTenantStats(t *testing.T) {
+	assert := assert.New(t)
+	api := newTenantAPI(&Tenancy{Source: TenantHeader})
+
+	for _, request := range []struct{ tenant, id string }{
+		{"b", "b1"}, {"a", "a1"}, {"a", "b1"}, {"a", "a2"},
+	} {
+		req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/"+request.id, nil)
+		req.Header.Set("X-Tenant-ID", request.tenant)
+		serveTenant(api, req)
+	}
+
+	assert.Equal([]TenantStats{
+		{Tenant: "a", Resource: "foo", Requests: 3, ClientErrors: 1},
+		{Tenant: "b", Resource: "foo", Requests: 1},
+	}, api.TenantStats())
+}
+
+// Ensures that the tenant concurrency limit is keyed by tenant and resource.
+func TestTenantConcurrencyLimits(t *testing.T) {
+	assert := assert.New(t)
+	config := &Configuration{Tenancy: &Tenancy{ConcurrencyLimit: 2}}
+
+	assert.True(concurrencyLimited(config))
+	assert.Equal(map[string]int{"foo": 0, "tenant:a:foo": 2},
+		concurrencyLimits(config, "foo", "foo:read", "a"))
+	assert.Equal(map[string]int{"foo": 0}, concurrencyLimits(config, "foo", "foo:read", ""))
+}
+
+// Ensures that dispatched requests are made on behalf of their tenant.
+func TestTenantDispatch(t *testing.T) {
+	assert := assert.New(t)
+	api := newTenantAPI(&Tenancy{Source: TenantPathPrefix})
+
+	resp, err := api.Dispatch(context.Background(), GatewayRequest{
+		Method:   HandleRead,
+		Resource: "foo",
+		Version:  "1",
+		ID:       "a1",
+		Tenant:   "a",
+	})
+
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, resp.Status)
+	assert.Equal("a", resp.Body["result"].(map[string]interface{})["tenant"])
+}
+
+// Ensures that requests of tenants beyond the limit are counted together.
+func TestTenantStatsLimit(t *testing.T) {
+	assert := assert.New(t)
+	metrics := newTenantMetrics()
+
+	for i := 0; i < maxTenantStats+2; i++ {
+		metrics.record(fmt.Sprintf("t%d", i), "foo", http.StatusOK)
+	}
+	metrics.record("t0", "foo", http.StatusNotFound)
+
+	stats := metrics.snapshot()
+	assert.Len(stats, maxTenantStats+1)
+	assert.Equal(TenantStats{Resource: "foo", Requests: 2}, stats[0])
+	assert.Equal(TenantStats{Tenant: "t0", Resource: "foo", Requests: 2, ClientErrors: 1},
+		stats[1])
+}
@@ -0,0 +1,243 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	gcontext "github.com/gorilla/context"
+)
+
+// The phases of handling a request which are timed. They're listed in the
+// Server-Timing header in this order.
+const (
+	// TimingAuth is the time spent authenticating the request.
+	TimingAuth = "auth"
+
+	// TimingHandler is the time spent in the ResourceHandler and its lifecycle hooks.
+	TimingHandler = "handler"
+
+	// TimingRules is the time spent applying Rules to the request payload and response.
+	TimingRules = "rules"
+
+	// TimingSerialize is the time spent serializing the response.
+	TimingSerialize = "serialize"
+)
+
+// timingPhases are the timed phases in the order they're reported.
+var timingPhases = []string{TimingAuth, TimingHandler, TimingRules, TimingSerialize}
+
+// serverTimingHeader is the name of the header reporting the phase timings of the
+// response.
+const serverTimingHeader = "Server-Timing"
+
+// TimingStats contains the time spent in a phase of handling requests to a resource.
+type TimingStats struct {
+	// Resource is the name of the requested resource.
+	Resource string
+
+	// Phase is the timed phase, e.g. TimingHandler.
+	Phase string
+
+	// Requests is the number of requests which spent time in the phase.
+	Requests uint64
+
+	// Total and Max are the total and longest time spent in the phase by a request.
+	Total time.Duration
+	Max   time.Duration
+}
+
+// Mean returns the average time spent in the phase by a request.
+func (s TimingStats) Mean() time.Duration {
+	if s.Requests == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Requests)
+}
+
+// requestTiming accumulates the time spent in each phase of handling a request. A
+// phase may be timed more than once, e.g. for each operation of a batch request. It's
+// safe for concurrent use.
+type requestTiming struct {
+	mu        sync.Mutex
+	durations map[string]time.Duration
+}
+
+// start starts timing the phase and returns a function which stops it. It's a no-op
+// if the timing is nil.
+func (t *requestTiming) start(phase string) func() {
+	if t == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		elapsed := time.Since(start)
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.durations[phase] += elapsed
+	}
+}
+
+// phases returns the timed phases and their durations in reporting order.
+func (t *requestTiming) phases() ([]string, []time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var (
+		phases    []string
+		durations []time.Duration
+	)
+	for _, phase := range timingPhases {
+		if d, ok := t.durations[phase]; ok {
+			phases = append(phases, phase)
+			durations = append(durations, d)
+		}
+	}
+	return phases, durations
+}
+
+// header returns the Server-Timing header value reporting the durations of the timed
+// phases in milliseconds, e.g. "auth;dur=0.120, handler;dur=4.512".
+func (t *requestTiming) header() string {
+	phases, durations := t.phases()
+	metrics := make([]string, len(phases))
+	for i, phase := range phases {
+		metrics[i] = fmt.Sprintf("%s;dur=%.3f", phase,
+			float64(durations[i])/float64(time.Millisecond))
+	}
+	return strings.Join(metrics, ", ")
+}
+
+// requestTimingFor returns the timing of the request, or nil if it isn't timed.
+func requestTimingFor(r *http.Request) *requestTiming {
+	timing, _ := gcontext.Get(r, requestTimingKey).(*requestTiming)
+	return timing
+}
+
+// startTiming starts timing the phase of the request and returns a function which
+// stops it. It's a no-op if the request isn't timed.
+func startTiming(ctx RequestContext, phase string) func() {
+	timing, _ := ctx.Value(requestTimingKey).(*requestTiming)
+	return timing.start(phase)
+}
+
+// applyTimedInboundRules applies the inbound Rules to the payload like
+// applyInboundRules, timing it as the TimingRules phase of the request.
+func applyTimedInboundRules(ctx RequestContext, payload Payload, rules Rules,
+	version string) (Payload, error) {
+
+	defer startTiming(ctx, TimingRules)()
+	return applyInboundRules(payload, rules, version)
+}
+
+// newTimingMiddleware returns middleware which times the phases of handling requests
+// to the resource and records them in the metrics once the request is handled.
+func newTimingMiddleware(metrics *timingMetrics, resource string) RequestMiddleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timing := &requestTiming{durations: map[string]time.Duration{}}
+			gcontext.Set(r, requestTimingKey, timing)
+			next.ServeHTTP(w, r)
+			metrics.record(resource, timing)
+		})
+	}
+}
+
+// applyServerTiming sets the Server-Timing header of the response to the phase
+// timings of the request if ServerTiming is configured.
+func applyServerTiming(ctx RequestContext, header http.Header) {
+	if !ctx.configuration().ServerTiming {
+		return
+	}
+	timing, _ := ctx.Value(requestTimingKey).(*requestTiming)
+	if timing == nil {
+		return
+	}
+	if value := timing.header(); value != "" {
+		header.Set(serverTimingHeader, value)
+	}
+}
+
+// timingKey identifies a TimingStats entry.
+type timingKey struct {
+	resource string
+	phase    string
+}
+
+// timingMetrics tracks the time spent in the phases of handling requests. It's safe
+// for concurrent use.
+type timingMetrics struct {
+	mu    sync.Mutex
+	stats map[timingKey]*TimingStats
+}
+
+// newTimingMetrics returns a newly allocated timingMetrics.
+func newTimingMetrics() *timingMetrics {
+	return &timingMetrics{stats: map[timingKey]*TimingStats{}}
+}
+
+// record adds the phase timings of a request to the resource to the metrics.
+func (m *timingMetrics) record(resource string, timing *requestTiming) {
+	phases, durations := timing.phases()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, phase := range phases {
+		key := timingKey{resource, phase}
+		stats, ok := m.stats[key]
+		if !ok {
+			stats = &TimingStats{Resource: resource, Phase: phase}
+			m.stats[key] = stats
+		}
+		stats.Requests++
+		stats.Total += durations[i]
+		if durations[i] > stats.Max {
+			stats.Max = durations[i]
+		}
+	}
+}
+
+// snapshot returns a copy of the metrics sorted by resource and phase in reporting
+// order.
+func (m *timingMetrics) snapshot() []TimingStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := make([]TimingStats, 0, len(m.stats))
+	for _, s := range m.stats {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Resource != stats[j].Resource {
+			return stats[i].Resource < stats[j].Resource
+		}
+		return timingPhaseIndex(stats[i].Phase) < timingPhaseIndex(stats[j].Phase)
+	})
+	return stats
+}
+
+// timingPhaseIndex returns the reporting position of the phase.
+func timingPhaseIndex(phase string) int {
+	for i, p := range timingPhases {
+		if p == phase {
+			return i
+		}
+	}
+	return len(timingPhases)
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type timingResourceHandler struct {
+	BaseResourceHandler
+}
+
+func (t *timingResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (t *timingResourceHandler) Authenticate(r *http.Request) error {
+	if r.Header.Get("Authorization") == "" {
+		return errors.New("Not authorized")
+	}
+	return nil
+}
+
+func (t *timingResourceHandler) ReadResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	time.Sleep(5 * time.Millisecond)
+	return map[string]interface{}{"id": id, "secret": "x"}, nil
+}
+
+func (t *timingResourceHandler) Rules() Rules {
+	return NewRules((*struct {
+		ID string `json:"id"`
+	})(nil), &Rule{Field: "ID", FieldAlias: "id"})
+}
+
+// serveTiming performs an authorized GET request against the API and returns the
+// response.
+func serveTiming(api API, url string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", url, nil)
+	req.Header.Set("Authorization", "token")
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	return resp
+}
+
+// Ensures that the Server-Timing header reports each phase of handling the request
+// when ServerTiming is configured.
+func TestServerTimingHeader(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{ServerTiming: true})
+	api.RegisterResourceHandler(&timingResourceHandler{})
+
+	resp := serveTiming(api, "http://foo.com/api/v1/foo/1")
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Regexp(regexp.MustCompile(`^auth;dur=\d+\.\d{3}, handler;dur=\d+\.\d{3}, `+
+		`rules;dur=\d+\.\d{3}, serialize;dur=\d+\.\d{3}$`), resp.Header().Get("Server-Timing"))
+}
+
+// Ensures that the Server-Timing header isn't sent unless ServerTiming is configured.
+func TestServerTimingHeaderDisabled(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&timingResourceHandler{})
+
+	resp := serveTiming(api, "http://foo.com/api/v1/foo/1")
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Empty(resp.Header().Get("Server-Timing"))
+}
+
+// Ensures that the phase timings of requests are recorded in TimingStats, and that
+// phases a request doesn't reach aren't recorded.
+func TestTimingStats(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(&timingResourceHandler{})
+
+	serveTiming(api, "http://foo.com/api/v1/foo/1")
+	serveTiming(api, "http://foo.com/api/v1/foo/2")
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v1/foo/3", nil)
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	assert.Equal(http.StatusUnauthorized, resp.Code)
+
+	stats := api.TimingStats()
+	if assert.Len(stats, 4) {
+		phases := []string{TimingAuth, TimingHandler, TimingRules, TimingSerialize}
+		for i, phase := range phases {
+			assert.Equal("foo", stats[i].Resource)
+			assert.Equal(phase, stats[i].Phase)
+		}
+		assert.Equal(uint64(3), stats[0].Requests)
+		assert.Equal(uint64(2), stats[1].Requests)
+		assert.True(stats[1].Max >= 5*time.Millisecond)
+		assert.True(stats[1].Mean() >= 5*time.Millisecond)
+		assert.True(stats[1].Total >= 10*time.Millisecond)
+	}
+}
+
+// Ensures that requestTiming accumulates the time spent in a phase across multiple
+// timings and is a no-op when nil.
+func TestRequestTiming(t *testing.T) {
+	assert := assert.New(t)
+	timing := &requestTiming{durations: map[string]time.Duration{}}
+
+	stop := timing.start(TimingSerialize)
+	time.Sleep(time.Millisecond)
+	stop()
+	stop = timing.start(TimingSerialize)
+	time.Sleep(time.Millisecond)
+	stop()
+
+	phases, durations := timing.phases()
+	assert.Equal([]string{TimingSerialize}, phases)
+	assert.True(durations[0] >= 2*time.Millisecond)
+
+	var unset *requestTiming
+	assert.NotPanics(func() { unset.start(TimingAuth)() })
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+// PayloadMiddleware is a function that transforms a deserialized request Payload
+// before inbound Rules are applied to it. This allows injecting cross-cutting logic to
+// normalize or enrich payloads (e.g. trimming strings or stamping the authenticated
+// user). If an error is returned, the request fails with it and the ResourceHandler
+// isn't called.
+type PayloadMiddleware func(RequestContext, Payload) (Payload, error)
+
+// PayloadMiddlewareResourceHandler can be implemented by a ResourceHandler to transform
+// its request payloads. Its PayloadMiddleware run after any configured on the API.
+type PayloadMiddlewareResourceHandler interface {
+	// PayloadMiddleware returns the PayloadMiddleware to apply, in order, to the
+	// handler's request payloads.
+	PayloadMiddleware() []PayloadMiddleware
+}
+
+// payloadMiddleware returns the PayloadMiddleware for the ResourceHandler, which are
+// those in the Configuration followed by any the handler provides.
+func payloadMiddleware(config *Configuration, handler ResourceHandler) []PayloadMiddleware {
+	middleware := append([]PayloadMiddleware{}, config.PayloadMiddleware...)
+	if p, ok := unwrapResourceHandler(handler).(PayloadMiddlewareResourceHandler); ok {
+		middleware = append(middleware, p.PayloadMiddleware()...)
+	}
+	return middleware
+}
+
+// applyPayloadMiddleware passes the Payload through each PayloadMiddleware in order,
+// returning the transformed Payload or the first error encountered.
+func applyPayloadMiddleware(ctx RequestContext, middleware []PayloadMiddleware,
+	payload Payload) (Payload, error) {
+
+	for _, m := range middleware {
+		var err error
+		if payload, err = m(ctx, payload); err != nil {
+			return nil, err
+		}
+	}
+	return payload, nil
+}
+
+// applyPayloadMiddlewareList passes each of the Payloads through the PayloadMiddleware,
+// replacing them in place. It returns the first error encountered.
+func applyPayloadMiddlewareList(ctx RequestContext, middleware []PayloadMiddleware,
+	payloads []Payload) error {
+
+	for i, payload := range payloads {
+		transformed, err := applyPayloadMiddleware(ctx, middleware, payload)
+		if err != nil {
+			return err
+		}
+		payloads[i] = transformed
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type transformResourceHandler struct {
+	BaseResourceHandler
+	data []Payload
+}
+
+func (t *transformResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (t *transformResourceHandler) PayloadMiddleware() []PayloadMiddleware {
+	return []PayloadMiddleware{
+		func(ctx RequestContext, data Payload) (Payload, error) {
+			if _, ok := data["invalid"]; ok {
+				return nil, UnprocessableRequest("invalid")
+			}
+			data["stage"] = data["stage"].(string) + ",handler"
+			return data, nil
+		},
+	}
+}
+
+func (t *transformResourceHandler) CreateResource(ctx RequestContext, data Payload,
+	version string) (Resource, error) {
+
+	t.data = append(t.data, data)
+	return data, nil
+}
+
+func (t *transformResourceHandler) UpdateResourceList(ctx RequestContext, data []Payload,
+	version string) ([]Resource, error) {
+
+	t.data = append(t.data, data...)
+	return []Resource{}, nil
+}
+
+// stagePayloadMiddleware trims the "name" field and records that it ran in "stage".
+func stagePayloadMiddleware(ctx RequestContext, data Payload) (Payload, error) {
+	if name, err := data.GetString("name"); err == nil {
+		data["name"] = strings.TrimSpace(name)
+	}
+	data["stage"] = "config"
+	return data, nil
+}
+
+// Ensures that configured and handler PayloadMiddleware transform the create payload
+// in order.
+func TestHandleCreatePayloadMiddleware(t *testing.T) {
+	assert := assert.New(t)
+	handler := &transformResourceHandler{}
+	api := NewAPI(&Configuration{PayloadMiddleware: []PayloadMiddleware{stagePayloadMiddleware}})
+	api.RegisterResourceHandler(handler)
+	createHandler, _ := api.(*muxAPI).getRouteHandler("foo:create")
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo",
+		bytes.NewBufferString(`{"name": "  bar "}`))
+	resp := httptest.NewRecorder()
+
+	createHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusCreated, resp.Code, "Incorrect response code")
+	assert.Equal([]Payload{{"name": "bar", "stage": "config,handler"}}, handler.data)
+}
+
+// Ensures that PayloadMiddleware transform each payload of an update list.
+func TestHandleUpdateListPayloadMiddleware(t *testing.T) {
+	assert := assert.New(t)
+	handler := &transformResourceHandler{}
+	api := NewAPI(&Configuration{PayloadMiddleware: []PayloadMiddleware{stagePayloadMiddleware}})
+	api.RegisterResourceHandler(handler)
+	updateListHandler, _ := api.(*muxAPI).getRouteHandler("foo:updateList")
+
+	req, _ := http.NewRequest("PUT", "http://foo.com/api/v1/foo",
+		bytes.NewBufferString(`[{"name": " a"}, {"name": "b "}]`))
+	resp := httptest.NewRecorder()
+
+	updateListHandler.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Equal([]Payload{
+		{"name": "a", "stage": "config,handler"},
+		{"name": "b", "stage": "config,handler"},
+	}, handler.data)
+}
+
+// Ensures that the request fails without calling the handler if a PayloadMiddleware
+// returns an error.
+func TestHandleCreatePayloadMiddlewareError(t *testing.T) {
+	assert := assert.New(t)
+	handler := &transformResourceHandler{}
+	api := NewAPI(&Configuration{PayloadMiddleware: []PayloadMiddleware{stagePayloadMiddleware}})
+	api.RegisterResourceHandler(handler)
+	createHandler, _ := api.(*muxAPI).getRouteHandler("foo:create")
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo",
+		bytes.NewBufferString(`{"invalid": true}`))
+	resp := httptest.NewRecorder()
+
+	createHandler.ServeHTTP(resp, req)
+
+	assert.Equal(422, resp.Code, "Incorrect response code")
+	assert.Nil(handler.data)
+}
@@ -0,0 +1,234 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import "net/http"
+
+// TypedResourceHandler is a ResourceHandler for resources of type T whose operations
+// take and return *T instead of Payloads and Resources. Register one by wrapping it
+// with Typed, which decodes inbound payloads into T, after Rules are applied, using
+// the Rules' field aliases. Embed BaseTypedResourceHandler to only implement the
+// operations which are needed.
+type TypedResourceHandler[T any] interface {
+	resourceHandlerSettings
+
+	// CreateResource creates the resource and returns it or an error if the create
+	// failed.
+	CreateResource(RequestContext, *T, string) (*T, error)
+
+	// ReadResourceList returns a page of resources limited by the given limit and
+	// starting at the given cursor along with the cursor for the next page.
+	ReadResourceList(RequestContext, int, string, string) ([]*T, string, error)
+
+	// ReadResource returns the resource with the given ID or an error if it couldn't be
+	// read.
+	ReadResource(RequestContext, string, string) (*T, error)
+
+	// UpdateResourceList updates the resources and returns them or an error if the
+	// update failed.
+	UpdateResourceList(RequestContext, []*T, string) ([]*T, error)
+
+	// UpdateResource updates the resource with the given ID and returns it or an error
+	// if the update failed.
+	UpdateResource(RequestContext, string, *T, string) (*T, error)
+
+	// DeleteResource deletes the resource with the given ID and returns it or an error
+	// if the delete failed.
+	DeleteResource(RequestContext, string, string) (*T, error)
+}
+
+// resourceHandlerSettings is the part of the ResourceHandler interface which doesn't
+// depend on the type of the resource. See ResourceHandler for documentation.
+type resourceHandlerSettings interface {
+	ResourceName() string
+	CreateURI() string
+	CreateDocumentation() string
+	ReadURI() string
+	ReadDocumentation() string
+	ReadListURI() string
+	ReadListDocumentation() string
+	UpdateURI() string
+	UpdateDocumentation() string
+	UpdateListURI() string
+	UpdateListDocumentation() string
+	DeleteURI() string
+	DeleteDocumentation() string
+	Authenticate(*http.Request) error
+	ValidVersions() []string
+	Rules() Rules
+}
+
+// BaseTypedResourceHandler is a base implementation of TypedResourceHandler with stubs
+// for the CRUD operations and the defaults of BaseResourceHandler.
+type BaseTypedResourceHandler[T any] struct {
+	BaseResourceHandler
+}
+
+// CreateResource is a stub. Implement if necessary.
+func (b BaseTypedResourceHandler[T]) CreateResource(ctx RequestContext, data *T,
+	version string) (*T, error) {
+	return nil, MethodNotAllowed("CreateResource is not implemented")
+}
+
+// ReadResourceList is a stub. Implement if necessary.
+func (b BaseTypedResourceHandler[T]) ReadResourceList(ctx RequestContext, limit int,
+	cursor string, version string) ([]*T, string, error) {
+	return nil, "", MethodNotAllowed("ReadResourceList not implemented")
+}
+
+// ReadResource is a stub. Implement if necessary.
+func (b BaseTypedResourceHandler[T]) ReadResource(ctx RequestContext, id string,
+	version string) (*T, error) {
+	return nil, MethodNotAllowed("ReadResource not implemented")
+}
+
+// UpdateResourceList is a stub. Implement if necessary.
+func (b BaseTypedResourceHandler[T]) UpdateResourceList(ctx RequestContext, data []*T,
+	version string) ([]*T, error) {
+	return nil, MethodNotAllowed("UpdateResourceList not implemented")
+}
+
+// UpdateResource is a stub. Implement if necessary.
+func (b BaseTypedResourceHandler[T]) UpdateResource(ctx RequestContext, id string,
+	data *T, version string) (*T, error) {
+	return nil, MethodNotAllowed("UpdateResource not implemented")
+}
+
+// DeleteResource is a stub. Implement if necessary.
+func (b BaseTypedResourceHandler[T]) DeleteResource(ctx RequestContext, id string,
+	version string) (*T, error) {
+	return nil, MethodNotAllowed("DeleteResource not implemented")
+}
+
+// Typed returns a ResourceHandler performing operations with the TypedResourceHandler.
+// Optional interfaces implemented by the TypedResourceHandler, e.g.
+// EventsResourceHandler, are detected as they are for any ResourceHandler.
+func Typed[T any](handler TypedResourceHandler[T]) ResourceHandler {
+	return typedResourceHandler[T]{handler}
+}
+
+// typedAdapter is implemented by ResourceHandlers adapting a TypedResourceHandler so
+// it can be unwrapped without knowing its resource type.
+type typedAdapter interface {
+	typedHandler() interface{}
+}
+
+// typedResourceHandler adapts a TypedResourceHandler to the ResourceHandler interface.
+type typedResourceHandler[T any] struct {
+	TypedResourceHandler[T]
+}
+
+// typedHandler returns the adapted TypedResourceHandler.
+func (t typedResourceHandler[T]) typedHandler() interface{} {
+	return t.TypedResourceHandler
+}
+
+// decode returns the payload decoded into a new T.
+func (t typedResourceHandler[T]) decode(data Payload) (*T, error) {
+	resource := new(T)
+	if err := data.Decode(resource, t.Rules()); err != nil {
+		return nil, BadRequest(err.Error())
+	}
+	return resource, nil
+}
+
+// CreateResource decodes the payload and creates the resource.
+func (t typedResourceHandler[T]) CreateResource(ctx RequestContext, data Payload,
+	version string) (Resource, error) {
+
+	resource, err := t.decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return typedResource(t.TypedResourceHandler.CreateResource(ctx, resource, version))
+}
+
+// ReadResourceList reads a page of resources.
+func (t typedResourceHandler[T]) ReadResourceList(ctx RequestContext, limit int,
+	cursor string, version string) ([]Resource, string, error) {
+
+	resources, next, err := t.TypedResourceHandler.ReadResourceList(ctx, limit, cursor, version)
+	if err != nil {
+		return nil, "", err
+	}
+	return typedResources(resources), next, nil
+}
+
+// ReadResource reads the resource with the given ID.
+func (t typedResourceHandler[T]) ReadResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	return typedResource(t.TypedResourceHandler.ReadResource(ctx, id, version))
+}
+
+// UpdateResourceList decodes the payloads and updates the resources.
+func (t typedResourceHandler[T]) UpdateResourceList(ctx RequestContext, data []Payload,
+	version string) ([]Resource, error) {
+
+	decoded := make([]*T, len(data))
+	for i, payload := range data {
+		resource, err := t.decode(payload)
+		if err != nil {
+			return nil, err
+		}
+		decoded[i] = resource
+	}
+	resources, err := t.TypedResourceHandler.UpdateResourceList(ctx, decoded, version)
+	if err != nil {
+		return nil, err
+	}
+	return typedResources(resources), nil
+}
+
+// UpdateResource decodes the payload and updates the resource with the given ID.
+func (t typedResourceHandler[T]) UpdateResource(ctx RequestContext, id string,
+	data Payload, version string) (Resource, error) {
+
+	resource, err := t.decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return typedResource(t.TypedResourceHandler.UpdateResource(ctx, id, resource, version))
+}
+
+// DeleteResource deletes the resource with the given ID.
+func (t typedResourceHandler[T]) DeleteResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	return typedResource(t.TypedResourceHandler.DeleteResource(ctx, id, version))
+}
+
+// typedResource returns the *T as a Resource, which is nil rather than a nil *T if
+// there's no resource.
+func typedResource[T any](resource *T, err error) (Resource, error) {
+	if resource == nil {
+		return nil, err
+	}
+	return resource, err
+}
+
+// typedResources returns the []*T as a []Resource.
+func typedResources[T any](resources []*T) []Resource {
+	converted := make([]Resource, len(resources))
+	for i, resource := range resources {
+		converted[i] = resource
+	}
+	return converted
+}
@@ -0,0 +1,147 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type gadget struct {
+	ID   string
+	Name string
+}
+
+type gadgetResourceHandler struct {
+	BaseTypedResourceHandler[gadget]
+	gadgets map[string]*gadget
+}
+
+func (g *gadgetResourceHandler) ResourceName() string {
+	return "gadgets"
+}
+
+func (g *gadgetResourceHandler) Rules() Rules {
+	return NewRules((*gadget)(nil),
+		&Rule{Field: "ID", FieldAlias: "id", OutputOnly: true},
+		&Rule{Field: "Name", FieldAlias: "name", Type: String, Required: true},
+	)
+}
+
+func (g *gadgetResourceHandler) CreateResource(ctx RequestContext, data *gadget,
+	version string) (*gadget, error) {
+
+	data.ID = "1"
+	g.gadgets[data.ID] = data
+	return data, nil
+}
+
+func (g *gadgetResourceHandler) ReadResource(ctx RequestContext, id string,
+	version string) (*gadget, error) {
+
+	if found, ok := g.gadgets[id]; ok {
+		return found, nil
+	}
+	return nil, ResourceNotFound("No gadget " + id)
+}
+
+func (g *gadgetResourceHandler) ReadResourceList(ctx RequestContext, limit int,
+	cursor string, version string) ([]*gadget, string, error) {
+
+	return []*gadget{g.gadgets["1"]}, "", nil
+}
+
+// Ensures that Typed decodes payloads into the resource type and sends typed results.
+func TestTypedResourceHandler(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(Typed[gadget](&gadgetResourceHandler{gadgets: map[string]*gadget{}}))
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/gadgets",
+		bytes.NewBufferString(`{"name": "foo"}`))
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusCreated, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), `"result":{"id":"1","name":"foo"}`)
+
+	req, _ = http.NewRequest("GET", "http://foo.com/api/v1/gadgets", nil)
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), `"results":[{"id":"1","name":"foo"}]`)
+
+	req, _ = http.NewRequest("GET", "http://foo.com/api/v1/gadgets/2", nil)
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusNotFound, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), "No gadget 2")
+
+	req, _ = http.NewRequest("DELETE", "http://foo.com/api/v1/gadgets/1", nil)
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusMethodNotAllowed, resp.Code, "Incorrect response code")
+}
+
+type hookedGadgetResourceHandler struct {
+	gadgetResourceHandler
+	created []string
+}
+
+func (h *hookedGadgetResourceHandler) SupportsDryRun() bool {
+	return true
+}
+
+func (h *hookedGadgetResourceHandler) AfterCreate(ctx RequestContext, resource Resource) {
+	h.created = append(h.created, resource.(*gadget).Name)
+}
+
+// Ensures that optional interfaces implemented by a TypedResourceHandler are detected.
+func TestTypedResourceHandlerOptionalInterfaces(t *testing.T) {
+	assert := assert.New(t)
+	handler := &hookedGadgetResourceHandler{
+		gadgetResourceHandler: gadgetResourceHandler{gadgets: map[string]*gadget{}},
+	}
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(Typed[gadget](handler))
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/gadgets?dry_run=true",
+		bytes.NewBufferString(`{"name": "foo"}`))
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusCreated, resp.Code, "Incorrect response code")
+	assert.Empty(handler.created)
+
+	req, _ = http.NewRequest("POST", "http://foo.com/api/v1/gadgets",
+		bytes.NewBufferString(`{"name": "bar"}`))
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusCreated, resp.Code, "Incorrect response code")
+	assert.Equal([]string{"bar"}, handler.created)
+}
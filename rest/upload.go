@@ -0,0 +1,262 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+const (
+	// defaultUploadMemory is the number of bytes of uploaded files held in memory if a
+	// limit isn't configured.
+	defaultUploadMemory = 32 << 20
+
+	// multipartMediaType is the media type of multipart form requests.
+	multipartMediaType = "multipart/form-data"
+)
+
+// Error codes of the validation errors returned when uploaded files violate a
+// FileRule. All take the field name as their first argument.
+const (
+	// CodeTooManyFiles identifies errors for fields with more files than allowed. Its
+	// second argument is the maximum count.
+	CodeTooManyFiles = "too_many_files"
+
+	// CodeFileTooLarge identifies errors for files larger than allowed. Its second
+	// argument is the maximum size in bytes.
+	CodeFileTooLarge = "file_too_large"
+
+	// CodeInvalidFileType identifies errors for files whose content type isn't
+	// allowed. Its second argument is the content type.
+	CodeInvalidFileType = "invalid_file_type"
+)
+
+// UploadedFile is a file uploaded in a multipart/form-data request. Files larger than
+// the Configuration's UploadMemory are spilled to temporary files, which are removed
+// once the response is sent.
+type UploadedFile struct {
+	// Field is the name of the form field the file was uploaded in.
+	Field string
+
+	// Filename is the name of the file provided by the client.
+	Filename string
+
+	// ContentType is the content type of the file provided by the client.
+	ContentType string
+
+	// Size is the size of the file in bytes.
+	Size int64
+
+	header *multipart.FileHeader
+}
+
+// Open returns a reader streaming the contents of the file.
+func (f *UploadedFile) Open() (multipart.File, error) {
+	return f.header.Open()
+}
+
+// FileRule constrains the files uploaded in a form field of multipart/form-data create
+// and update requests.
+type FileRule struct {
+	// Field is the name of the form field.
+	Field string
+
+	// Required indicates at least one file must be uploaded in the field.
+	Required bool
+
+	// MaxCount is the maximum number of files which can be uploaded in the field. If
+	// zero, the number isn't limited.
+	MaxCount int
+
+	// MaxSize is the maximum size, in bytes, of each file. If zero, the size isn't
+	// limited.
+	MaxSize int64
+
+	// ContentTypes are the allowed content types of the files, e.g. "image/png", or
+	// "image/*" for any image. If empty, any content type is allowed.
+	ContentTypes []string
+}
+
+// UploadResourceHandler can be implemented by a ResourceHandler to validate the files
+// uploaded with multipart/form-data create and update requests. Requests whose files
+// violate a FileRule receive 422 Unprocessable Entity with an error for each invalid
+// field.
+type UploadResourceHandler interface {
+	// FileRules returns the rules for the uploaded files.
+	FileRules() []FileRule
+}
+
+// multipartRequest returns true if the request body is multipart/form-data.
+func multipartRequest(ctx RequestContext) bool {
+	return isMultipart(ctx.Header())
+}
+
+// isMultipart returns true if the headers describe a multipart/form-data body.
+func isMultipart(header http.Header) bool {
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	return mediaType == multipartMediaType
+}
+
+// uploadMemory returns the number of bytes of uploaded files held in memory.
+func uploadMemory(config *Configuration) int64 {
+	if config.UploadMemory > 0 {
+		return config.UploadMemory
+	}
+	return defaultUploadMemory
+}
+
+// parseMultipartForm parses the multipart/form-data body of the request, streaming
+// files larger than the memory limit to temporary files. A 413 is returned if the body
+// is larger than the MaxBodySize and a 400 if it's malformed.
+func (h requestHandler) parseMultipartForm(ctx RequestContext) error {
+	req, ok := ctx.Request()
+	if !ok || req.MultipartForm != nil {
+		return nil
+	}
+	if err := req.ParseMultipartForm(uploadMemory(h.Configuration())); err != nil {
+		if strings.Contains(err.Error(), "request body too large") {
+			return CustomError(fmt.Sprintf("Request body exceeds %d bytes",
+				h.Configuration().MaxBodySize), http.StatusRequestEntityTooLarge)
+		}
+		return BadRequest(fmt.Sprintf("Malformed multipart body: %s", err))
+	}
+	return nil
+}
+
+// decodeMultipartPayload returns a Payload containing the form fields of the
+// multipart/form-data request. Fields with a single value are strings and fields with
+// multiple values are lists of strings. Uploaded files are validated against the
+// ResourceHandler's FileRules, returning FieldErrors if they're invalid.
+func decodeMultipartPayload(ctx RequestContext, handler ResourceHandler) (Payload, error) {
+	req, ok := ctx.Request()
+	if !ok || req.MultipartForm == nil {
+		return nil, BadRequest("Request body is empty")
+	}
+
+	payload := Payload{}
+	for field, values := range req.MultipartForm.Value {
+		if len(values) == 1 {
+			payload[field] = values[0]
+			continue
+		}
+		list := make([]interface{}, len(values))
+		for i, value := range values {
+			list[i] = value
+		}
+		payload[field] = list
+	}
+
+	if u, ok := unwrapResourceHandler(handler).(UploadResourceHandler); ok {
+		if err := validateUploads(ctx, u.FileRules()); err != nil {
+			return nil, err
+		}
+	}
+	return payload, nil
+}
+
+// validateUploads returns FieldErrors describing the uploaded files which violate the
+// FileRules, or nil if they're all valid.
+func validateUploads(ctx RequestContext, rules []FileRule) error {
+	errs := FieldErrors{}
+	for _, rule := range rules {
+		files := ctx.Files(rule.Field)
+		if len(files) == 0 {
+			if rule.Required {
+				errs[rule.Field] = UnprocessableRequest(fmt.Sprintf(
+					"Missing required file '%s'", rule.Field)).WithCode(CodeMissingField, rule.Field)
+			}
+			continue
+		}
+		if rule.MaxCount > 0 && len(files) > rule.MaxCount {
+			errs[rule.Field] = UnprocessableRequest(fmt.Sprintf(
+				"At most %d files can be uploaded in '%s'", rule.MaxCount, rule.Field)).
+				WithCode(CodeTooManyFiles, rule.Field, rule.MaxCount)
+			continue
+		}
+		for _, file := range files {
+			if rule.MaxSize > 0 && file.Size > rule.MaxSize {
+				errs[rule.Field] = UnprocessableRequest(fmt.Sprintf(
+					"File '%s' exceeds %d bytes", file.Filename, rule.MaxSize)).
+					WithCode(CodeFileTooLarge, rule.Field, rule.MaxSize)
+				break
+			}
+			if !allowedContentType(rule.ContentTypes, file.ContentType) {
+				errs[rule.Field] = UnprocessableRequest(fmt.Sprintf(
+					"File '%s' has disallowed content type %s", file.Filename, file.ContentType)).
+					WithCode(CodeInvalidFileType, rule.Field, file.ContentType)
+				break
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// allowedContentType returns true if the content type matches one of the allowed
+// content types, which may have a wildcard subtype, e.g. "image/*". Any content type
+// is allowed if none are given.
+func allowedContentType(allowed []string, contentType string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, a := range allowed {
+		if a == mediaType {
+			return true
+		}
+		if strings.HasSuffix(a, "/*") && strings.HasPrefix(mediaType, a[:len(a)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// uploadedFiles returns the files uploaded in the form field of the multipart/form-data
+// request.
+func uploadedFiles(req *http.Request, field string) []*UploadedFile {
+	if req.MultipartForm == nil {
+		return nil
+	}
+	headers := req.MultipartForm.File[field]
+	files := make([]*UploadedFile, len(headers))
+	for i, header := range headers {
+		files[i] = &UploadedFile{
+			Field:       field,
+			Filename:    header.Filename,
+			ContentType: header.Header.Get("Content-Type"),
+			Size:        header.Size,
+			header:      header,
+		}
+	}
+	return files
+}
+
+// removeUploadedFiles removes any temporary files holding the request's uploaded files.
+func removeUploadedFiles(ctx RequestContext) {
+	if req, ok := ctx.Request(); ok && req.MultipartForm != nil {
+		req.MultipartForm.RemoveAll()
+	}
+}
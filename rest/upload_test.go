@@ -0,0 +1,170 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type uploadResourceHandler struct {
+	BaseResourceHandler
+}
+
+func (u uploadResourceHandler) ResourceName() string {
+	return "avatars"
+}
+
+func (u uploadResourceHandler) FileRules() []FileRule {
+	return []FileRule{{
+		Field:        "image",
+		Required:     true,
+		MaxCount:     1,
+		MaxSize:      16,
+		ContentTypes: []string{"image/*"},
+	}}
+}
+
+func (u uploadResourceHandler) CreateResource(ctx RequestContext, data Payload,
+	version string) (Resource, error) {
+
+	file, _ := ctx.File("image")
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	contents, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return Payload{
+		"name":     data["name"],
+		"tags":     data["tags"],
+		"filename": file.Filename,
+		"type":     file.ContentType,
+		"contents": string(contents),
+	}, nil
+}
+
+type uploadPart struct {
+	field, filename, contentType, contents string
+}
+
+// newUploadRequest returns a multipart/form-data request with the form fields and
+// files.
+func newUploadRequest(fields map[string][]string, files ...uploadPart) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for field, values := range fields {
+		for _, value := range values {
+			writer.WriteField(field, value)
+		}
+	}
+	for _, file := range files {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition",
+			`form-data; name="`+file.field+`"; filename="`+file.filename+`"`)
+		header.Set("Content-Type", file.contentType)
+		part, _ := writer.CreatePart(header)
+		part.Write([]byte(file.contents))
+	}
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/avatars", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+// Ensures that multipart form fields are decoded into the Payload and uploaded files
+// are available through the RequestContext.
+func TestMultipartCreate(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{UploadMemory: 1})
+	api.RegisterResourceHandler(uploadResourceHandler{})
+
+	req := newUploadRequest(map[string][]string{"name": {"me"}, "tags": {"a", "b"}},
+		uploadPart{"image", "me.png", "image/png", "png bytes"})
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusCreated, resp.Code, resp.Body.String())
+	assert.Contains(resp.Body.String(), `"result":{"contents":"png bytes","filename":"me.png",`+
+		`"name":"me","tags":["a","b"],"type":"image/png"}`)
+}
+
+// Ensures that uploaded files violating the FileRules are rejected with field errors.
+func TestMultipartFileRules(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandler(uploadResourceHandler{})
+
+	tests := []struct {
+		files    []uploadPart
+		expected string
+	}{
+		{nil, `"errors":{"image":"Missing required file 'image'"}`},
+		{[]uploadPart{{"image", "a.png", "image/png", "a"}, {"image", "b.png", "image/png", "b"}},
+			`"errors":{"image":"At most 1 files can be uploaded in 'image'"}`},
+		{[]uploadPart{{"image", "a.png", "image/png", "this file is too large"}},
+			`"errors":{"image":"File 'a.png' exceeds 16 bytes"}`},
+		{[]uploadPart{{"image", "a.pdf", "application/pdf", "pdf"}},
+			`"errors":{"image":"File 'a.pdf' has disallowed content type application/pdf"}`},
+	}
+
+	for _, test := range tests {
+		resp := httptest.NewRecorder()
+		api.ServeHTTP(resp, newUploadRequest(nil, test.files...))
+
+		assert.Equal(422, resp.Code)
+		assert.Contains(resp.Body.String(), test.expected)
+	}
+}
+
+// Ensures that multipart bodies larger than the MaxBodySize are rejected.
+func TestMultipartMaxBodySize(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{MaxBodySize: 256})
+	api.RegisterResourceHandler(uploadResourceHandler{})
+
+	req := newUploadRequest(nil,
+		uploadPart{"image", "me.png", "image/png", string(make([]byte, 512))})
+	req.ContentLength = -1
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusRequestEntityTooLarge, resp.Code)
+	assert.Contains(resp.Body.String(), "Request body exceeds 256 bytes")
+}
+
+// Ensures that allowedContentType matches exact and wildcard content types.
+func TestAllowedContentType(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(allowedContentType(nil, "text/plain"))
+	assert.True(allowedContentType([]string{"image/*"}, "image/jpeg"))
+	assert.True(allowedContentType([]string{"text/csv"}, "text/csv; charset=utf-8"))
+	assert.False(allowedContentType([]string{"image/*"}, "imagex/jpeg"))
+	assert.False(allowedContentType([]string{"image/png"}, ""))
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// versionParam is the Accept media type parameter selecting the API version, e.g.
+// application/vnd.myapp+json; version=2.
+const versionParam = "version"
+
+// requestVersion returns the API version requested. The version path segment takes
+// precedence, followed by the configured VersionHeader and the vendor media type in the
+// Accept header. If none are present, the configured DefaultVersion is returned.
+func requestVersion(r *http.Request, config *Configuration) string {
+	if version := pathVars(r)[versionKey]; version != "" {
+		return version
+	}
+	return headerVersion(r.Header, config)
+}
+
+// headerVersion returns the API version requested with the configured VersionHeader or
+// vendor media type, falling back to the DefaultVersion.
+func headerVersion(header http.Header, config *Configuration) string {
+	if config.VersionHeader != "" {
+		if version := header.Get(config.VersionHeader); version != "" {
+			return version
+		}
+	}
+	if config.VendorMediaType != "" {
+		if version := mediaTypeVersion(header.Get("Accept"), config.VendorMediaType); version != "" {
+			return version
+		}
+	}
+	return config.DefaultVersion
+}
+
+// mediaTypeVersion returns the API version selected by the vendor media type in the
+// Accept header. Both application/vnd.myapp.v2+json and application/vnd.myapp+json;
+// version=2 select version 2 of the "vnd.myapp" vendor media type. An empty string is
+// returned if the vendor media type isn't accepted.
+func mediaTypeVersion(accept, vendor string) string {
+	prefix := "application/" + strings.ToLower(vendor)
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(mediaRange)
+		if err != nil || !strings.HasPrefix(mediaType, prefix) {
+			continue
+		}
+
+		subtype := mediaType[len(prefix):]
+		if i := strings.Index(subtype, "+"); i >= 0 {
+			subtype = subtype[:i]
+		}
+		if strings.HasPrefix(subtype, ".v") && len(subtype) > 2 {
+			return subtype[2:]
+		}
+		if subtype == "" && params[versionParam] != "" {
+			return params[versionParam]
+		}
+	}
+	return ""
+}
+
+// compareVersions compares the versions by their dot-separated parts, numerically
+// where both parts are numbers. It returns -1, 0, or 1 if a is less than, equal to, or
+// greater than b.
+func compareVersions(a, b string) int {
+	aParts, bParts := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		aNum, aErr := strconv.Atoi(aParts[i])
+		bNum, bErr := strconv.Atoi(bParts[i])
+		switch {
+		case aErr == nil && bErr == nil && aNum != bNum:
+			if aNum < bNum {
+				return -1
+			}
+			return 1
+		case (aErr != nil || bErr != nil) && aParts[i] != bParts[i]:
+			if aParts[i] < bParts[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case len(aParts) < len(bParts):
+		return -1
+	case len(aParts) > len(bParts):
+		return 1
+	}
+	return 0
+}
+
+// hasVersion returns true if the version is one of the versions or in one of the
+// version ranges, e.g. ">=1.2 <2".
+func hasVersion(versions []string, version string) bool {
+	for _, v := range versions {
+		if versionMatches(v, version) {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterResourceHandlerForVersions binds the provided ResourceHandler to the REST
+// endpoints of its resource for only the given versions. Routes are matched on the
+// request version, so each version of a resource can be served by a different
+// ResourceHandler. Versions may be ranges, e.g. ">=1.2 <2", matching any version in the
+// range. Requests for versions without a registered ResourceHandler receive
+// 410 Gone if the version precedes all registered versions and 404 Not Found otherwise.
+func (r *muxAPI) RegisterResourceHandlerForVersions(h ResourceHandler, versions ...string) {
+	if r.config.Router != nil {
+		log.Printf("Failed to register %s for versions %v: the configured Router doesn't "+
+			"support per-version registration", h.ResourceName(), versions)
+		return
+	}
+	h = newResourceHandlerProxy(h)
+	resource := h.ResourceName()
+	if err := r.addResourceHandler(h); err != nil {
+		log.Printf("Failed to register %s for versions %v: %s", resource, versions, err)
+		return
+	}
+	middleware := r.resourceMiddleware(h, nil)
+
+	var bound bool
+	r.updateState(func(state *apiState) {
+		_, bound = state.versions[resource]
+		state.versions[resource] = append(state.versions[resource], versions...)
+	})
+
+	r.addRoutes(h, func(routes Router) {
+		root := routes.(*GorillaRouter)
+		route := root.router.MatcherFunc(r.versionMatcher(func() []string { return versions }))
+		router := &GorillaRouter{router: route.Subrouter(), versions: versions}
+		r.bindResourceRoutes(router, h, middleware)
+		if !bound {
+			r.bindUnregisteredVersionRoutes(root, h)
+		}
+	})
+}
+
+// registeredVersions returns the versions of the resource which have a registered
+// ResourceHandler.
+func (r *muxAPI) registeredVersions(resource string) []string {
+	return r.loadState().versions[resource]
+}
+
+// versionMatcher returns a MatcherFunc which matches requests for one of the versions.
+func (r *muxAPI) versionMatcher(versions func() []string) mux.MatcherFunc {
+	pattern := regexp.MustCompile("^" + regexp.QuoteMeta(
+		strings.TrimSuffix(r.config.MountPrefix, "/")+r.apiBase()) + "/v([^/]+)")
+	return func(req *http.Request, _ *mux.RouteMatch) bool {
+		version := ""
+		if !r.config.VersionlessPaths {
+			if match := pattern.FindStringSubmatch(req.URL.Path); match != nil {
+				version = match[1]
+			}
+		}
+		if version == "" {
+			version = headerVersion(req.Header, r.config)
+		}
+		return hasVersion(versions(), version)
+	}
+}
+
+// bindUnregisteredVersionRoutes binds the routes responding to requests for versions of
+// the ResourceHandler's resource which don't have a registered ResourceHandler on the
+// router.
+func (r *muxAPI) bindUnregisteredVersionRoutes(router *GorillaRouter, h ResourceHandler) {
+	resource := h.ResourceName()
+	registered := func() []string { return r.registeredVersions(resource) }
+	matcher := r.versionMatcher(registered)
+	unregistered := func(req *http.Request, match *mux.RouteMatch) bool {
+		return !matcher(req, match)
+	}
+	handler := r.handler.handleUnregisteredVersion(resource, registered)
+
+	uri := r.mountPrefix() + r.layoutURI(h.ReadListURI())
+	name := resource + ":unregisteredVersion"
+	router.router.Handle(uri, handler).MatcherFunc(unregistered).Name(name)
+	router.router.PathPrefix(uri + "/").Handler(handler).MatcherFunc(unregistered).Name(name)
+	r.recordRoute(router, name, nil, uri)
+	r.recordRoute(router, name, nil, uri+"/")
+}
+
+// handleUnregisteredVersion returns a Handler which responds to requests for versions
+// of the resource without a registered ResourceHandler. Versions preceding all of the
+// registered versions have been removed and receive 410 Gone. Others receive 404 Not
+// Found.
+func (h requestHandler) handleUnregisteredVersion(resource string,
+	registered func() []string) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := h.newContext(w, r)
+		version := ctx.Version()
+
+		removed := false
+		for _, registered := range registered() {
+			removed = versionPrecedes(version, registered)
+			if !removed {
+				break
+			}
+		}
+
+		if removed {
+			h.sendResponse(ctx.setError(CustomError(
+				fmt.Sprintf("Version %q of %s has been removed", version, resource),
+				http.StatusGone)))
+			return
+		}
+		h.sendResponse(ctx.setError(ResourceNotFound(
+			fmt.Sprintf("Version %q of %s is not available", version, resource))))
+	})
+}
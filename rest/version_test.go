@@ -0,0 +1,215 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type headerVersionResourceHandler struct {
+	gatewayResourceHandler
+}
+
+func (h *headerVersionResourceHandler) ValidVersions() []string {
+	return []string{"1", "2"}
+}
+
+// Ensures that mediaTypeVersion returns the version selected by vendor media types.
+func TestMediaTypeVersion(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("2", mediaTypeVersion("application/vnd.myapp.v2+json", "vnd.myapp"))
+	assert.Equal("2", mediaTypeVersion("application/vnd.myapp+json; version=2", "vnd.myapp"))
+	assert.Equal("3", mediaTypeVersion("text/html, application/vnd.myapp.v3+json;q=0.9", "vnd.myapp"))
+	assert.Equal("1.1", mediaTypeVersion("application/vnd.myapp.v1.1", "vnd.myapp"))
+	assert.Equal("", mediaTypeVersion("application/json", "vnd.myapp"))
+	assert.Equal("", mediaTypeVersion("application/vnd.other.v2+json", "vnd.myapp"))
+	assert.Equal("", mediaTypeVersion("application/vnd.myapp+json", "vnd.myapp"))
+	assert.Equal("", mediaTypeVersion("", "vnd.myapp"))
+}
+
+// Ensures that the version is selected by the version header or vendor media type when
+// the path doesn't contain one, and is passed to the handler.
+func TestHeaderVersioning(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{
+		VersionlessPaths: true,
+		DefaultVersion:   "1",
+		VersionHeader:    "X-API-Version",
+		VendorMediaType:  "vnd.myapp",
+	})
+	api.RegisterResourceHandler(&headerVersionResourceHandler{})
+
+	create := func(header http.Header) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "http://foo.com/api/foo", bytes.NewBufferString(`{"foo": 1}`))
+		req.Header = header
+		req.Header.Set("Authorization", "secret")
+		resp := httptest.NewRecorder()
+		api.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := create(http.Header{})
+	assert.Equal(http.StatusCreated, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), `"version":"1"`)
+
+	resp = create(http.Header{"X-Api-Version": {"2"}})
+	assert.Equal(http.StatusCreated, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), `"version":"2"`)
+
+	resp = create(http.Header{"Accept": {"application/vnd.myapp.v2+json"}})
+	assert.Equal(http.StatusCreated, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), `"version":"2"`)
+
+	resp = create(http.Header{"Accept": {"application/vnd.myapp+json; version=2"}})
+	assert.Equal(http.StatusCreated, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), `"version":"2"`)
+
+	resp = create(http.Header{
+		"X-Api-Version": {"1"},
+		"Accept":        {"application/vnd.myapp.v2+json"},
+	})
+	assert.Equal(http.StatusCreated, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), `"version":"1"`)
+
+	resp = create(http.Header{"X-Api-Version": {"3"}})
+	assert.Equal(http.StatusBadRequest, resp.Code, "Incorrect response code")
+	assert.Equal(`Version "3" is not available.`, resp.Body.String())
+}
+
+// Ensures that the version in the path takes precedence over the version header.
+func TestPathVersionPrecedence(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{VersionHeader: "X-API-Version"})
+	api.RegisterResourceHandler(&headerVersionResourceHandler{})
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/foo", bytes.NewBufferString(`{"foo": 1}`))
+	req.Header.Set("Authorization", "secret")
+	req.Header.Set("X-API-Version", "2")
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusCreated, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), `"version":"1"`)
+}
+
+type versionedResourceHandler struct {
+	BaseResourceHandler
+	name string
+}
+
+func (v *versionedResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (v *versionedResourceHandler) ReadResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	return Payload{"id": id, "handler": v.name, "version": version}, nil
+}
+
+// Ensures that requests are routed to the ResourceHandler registered for the request
+// version and that unregistered versions are gone or not found.
+func TestRegisterResourceHandlerForVersions(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+	api.RegisterResourceHandlerForVersions(&versionedResourceHandler{name: "old"}, "2", "3")
+	api.RegisterResourceHandlerForVersions(&versionedResourceHandler{name: "new"}, "4")
+
+	read := func(version string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "http://foo.com/api/v"+version+"/foo/1", nil)
+		resp := httptest.NewRecorder()
+		api.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := read("2")
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), `"handler":"old"`)
+	assert.Contains(resp.Body.String(), `"version":"2"`)
+
+	resp = read("3")
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), `"handler":"old"`)
+
+	resp = read("4")
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), `"handler":"new"`)
+
+	resp = read("1")
+	assert.Equal(http.StatusGone, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), `Version \"1\" of foo has been removed`)
+
+	resp = read("5")
+	assert.Equal(http.StatusNotFound, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), `Version \"5\" of foo is not available`)
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/v5/bar/1", nil)
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	assert.Equal(http.StatusNotFound, resp.Code, "Incorrect response code")
+	assert.Equal("404 page not found\n", resp.Body.String())
+}
+
+// Ensures that versioned ResourceHandlers are matched on the version header when paths
+// are versionless.
+func TestRegisterResourceHandlerForVersionsHeader(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{
+		VersionlessPaths: true,
+		DefaultVersion:   "1",
+		VersionHeader:    "X-API-Version",
+	})
+	api.RegisterResourceHandlerForVersions(&versionedResourceHandler{name: "old"}, "1")
+	api.RegisterResourceHandlerForVersions(&versionedResourceHandler{name: "new"}, "2")
+
+	req, _ := http.NewRequest("GET", "http://foo.com/api/foo/1", nil)
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), `"handler":"old"`)
+
+	req, _ = http.NewRequest("GET", "http://foo.com/api/foo/1", nil)
+	req.Header.Set("X-API-Version", "2")
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	assert.Equal(http.StatusOK, resp.Code, "Incorrect response code")
+	assert.Contains(resp.Body.String(), `"handler":"new"`)
+	assert.Contains(resp.Body.String(), `"version":"2"`)
+
+	req, _ = http.NewRequest("GET", "http://foo.com/api/foo", nil)
+	req.Header.Set("X-API-Version", "0")
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	assert.Equal(http.StatusGone, resp.Code, "Incorrect response code")
+}
+
+// Ensures that compareVersions orders versions by their numeric parts.
+func TestCompareVersions(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(0, compareVersions("1", "1"))
+	assert.Equal(-1, compareVersions("2", "10"))
+	assert.Equal(1, compareVersions("1.10", "1.9"))
+	assert.Equal(-1, compareVersions("1", "1.1"))
+	assert.Equal(-1, compareVersions("1.a", "1.b"))
+}
@@ -0,0 +1,374 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// webhooksResource is the name of the resource used to register webhooks.
+	webhooksResource = "webhooks"
+
+	// webhookSignatureHeader is the header containing the HMAC-SHA256 signature of a
+	// webhook notification.
+	webhookSignatureHeader = "X-Webhook-Signature"
+
+	// webhookIDHeader is the header containing the ID of a webhook notification.
+	webhookIDHeader = "X-Webhook-ID"
+
+	// defaultWebhookAttempts is the number of times a webhook delivery is attempted if
+	// a limit isn't configured.
+	defaultWebhookAttempts = 5
+
+	// defaultWebhookBackoff is the delay before the first webhook delivery retry if one
+	// isn't configured. The delay doubles for each subsequent retry.
+	defaultWebhookBackoff = time.Second
+
+	// webhookTimeout is the timeout for delivering a webhook notification.
+	webhookTimeout = 10 * time.Second
+)
+
+// Webhook is a target URL notified of resource mutations.
+type Webhook struct {
+	// ID identifies the webhook. It's assigned when the webhook is registered.
+	ID string `json:"id"`
+
+	// URL is the absolute http or https URL notifications are posted to.
+	URL string `json:"url"`
+
+	// Resources limits the notifications to mutations of the named resources. If empty,
+	// mutations of all resources are notified.
+	Resources []string `json:"resources,omitempty"`
+}
+
+// WebhookNotification is the JSON body posted to webhooks after a successful create,
+// update, or delete.
+type WebhookNotification struct {
+	ID         string       `json:"id"`
+	Resource   string       `json:"resource"`
+	Action     HandleMethod `json:"action"`
+	ResourceID string       `json:"resource_id,omitempty"`
+	Data       Resource     `json:"data,omitempty"`
+	Timestamp  time.Time    `json:"timestamp"`
+}
+
+// WebhookDelivery is a WebhookNotification to be delivered to a Webhook.
+type WebhookDelivery struct {
+	Webhook      Webhook
+	Notification WebhookNotification
+
+	// Attempt is the number of the delivery attempt, starting at 1.
+	Attempt int
+}
+
+// WebhookQueue queues webhook deliveries. The default queue delivers in the background,
+// retrying failed deliveries with exponential backoff. Custom queues, e.g. ones backed
+// by durable storage, deliver by calling API#DeliverWebhook and are responsible for
+// retrying failures.
+type WebhookQueue interface {
+	// Enqueue queues the WebhookDelivery. It returns an error if it can't be queued.
+	Enqueue(WebhookDelivery) error
+}
+
+// memoryWebhookQueue is a WebhookQueue which delivers in a new goroutine for each
+// delivery, retrying with exponential backoff.
+type memoryWebhookQueue struct {
+	deliver     func(WebhookDelivery) error
+	maxAttempts int
+	backoff     time.Duration
+	logf        func(string, ...interface{})
+}
+
+// newMemoryWebhookQueue returns a memoryWebhookQueue which makes deliveries with the
+// given function, using the retry policy from the Configuration.
+func newMemoryWebhookQueue(config *Configuration, deliver func(WebhookDelivery) error,
+	logf func(string, ...interface{})) *memoryWebhookQueue {
+
+	queue := &memoryWebhookQueue{
+		deliver:     deliver,
+		maxAttempts: config.WebhookAttempts,
+		backoff:     config.WebhookBackoff,
+		logf:        logf,
+	}
+	if queue.maxAttempts <= 0 {
+		queue.maxAttempts = defaultWebhookAttempts
+	}
+	if queue.backoff <= 0 {
+		queue.backoff = defaultWebhookBackoff
+	}
+	return queue
+}
+
+// Enqueue starts delivering the WebhookDelivery in the background.
+func (q *memoryWebhookQueue) Enqueue(delivery WebhookDelivery) error {
+	go q.run(delivery)
+	return nil
+}
+
+// run attempts the delivery until it succeeds or the maximum attempts are reached.
+func (q *memoryWebhookQueue) run(delivery WebhookDelivery) {
+	backoff := q.backoff
+	for delivery.Attempt = 1; ; delivery.Attempt++ {
+		err := q.deliver(delivery)
+		if err == nil {
+			return
+		}
+		if delivery.Attempt >= q.maxAttempts {
+			q.logf("Webhook %s delivery of %s failed after %d attempts: %s",
+				delivery.Webhook.ID, delivery.Notification.ID, delivery.Attempt, err)
+			return
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// webhookRegistry holds the registered webhooks. It's safe for concurrent use.
+type webhookRegistry struct {
+	mu       sync.RWMutex
+	sequence int
+	webhooks map[string]Webhook
+}
+
+// newWebhookRegistry returns a webhookRegistry containing the given webhooks.
+func newWebhookRegistry(webhooks []Webhook) *webhookRegistry {
+	registry := &webhookRegistry{webhooks: map[string]Webhook{}}
+	for _, webhook := range webhooks {
+		registry.add(webhook)
+	}
+	return registry
+}
+
+// add registers the webhook, assigning it an ID if it doesn't have one.
+func (r *webhookRegistry) add(webhook Webhook) Webhook {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if webhook.ID == "" {
+		r.sequence++
+		webhook.ID = strconv.Itoa(r.sequence)
+	}
+	r.webhooks[webhook.ID] = webhook
+	return webhook
+}
+
+// get returns the webhook with the given ID.
+func (r *webhookRegistry) get(id string) (Webhook, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	webhook, ok := r.webhooks[id]
+	return webhook, ok
+}
+
+// remove unregisters the webhook with the given ID.
+func (r *webhookRegistry) remove(id string) (Webhook, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	webhook, ok := r.webhooks[id]
+	delete(r.webhooks, id)
+	return webhook, ok
+}
+
+// list returns the registered webhooks sorted by ID.
+func (r *webhookRegistry) list() []Webhook {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	webhooks := make([]Webhook, 0, len(r.webhooks))
+	for _, webhook := range r.webhooks {
+		webhooks = append(webhooks, webhook)
+	}
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].ID < webhooks[j].ID })
+	return webhooks
+}
+
+// matching returns the registered webhooks notified of mutations to the resource.
+func (r *webhookRegistry) matching(resource string) []Webhook {
+	webhooks := []Webhook{}
+	for _, webhook := range r.list() {
+		if len(webhook.Resources) == 0 {
+			webhooks = append(webhooks, webhook)
+			continue
+		}
+		for _, name := range webhook.Resources {
+			if name == resource {
+				webhooks = append(webhooks, webhook)
+				break
+			}
+		}
+	}
+	return webhooks
+}
+
+// signWebhook returns the signature of the webhook notification body.
+func signWebhook(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliverWebhook posts the notification to the webhook, signing it with the secret if
+// there is one. An error is returned if the webhook doesn't respond with a 2xx status.
+func deliverWebhook(client *http.Client, secret []byte, delivery WebhookDelivery) error {
+	body, err := json.Marshal(delivery.Notification)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", delivery.Webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookIDHeader, delivery.Notification.ID)
+	if len(secret) > 0 {
+		req.Header.Set(webhookSignatureHeader, signWebhook(secret, body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// notifyWebhooks queues notifications of a successful mutation to the webhooks
+// registered for the resource with outbound Rules applied to the resource. Fields
+// restricted to roles aren't sent. Dry runs and mutations of webhooks aren't notified.
+func (h requestHandler) notifyWebhooks(ctx RequestContext, handler ResourceHandler,
+	action HandleMethod, id string, resource Resource) {
+
+	resourceName := handler.ResourceName()
+	if ctx.DryRun() || resourceName == webhooksResource {
+		return
+	}
+
+	webhooks := h.webhooks().matching(resourceName)
+	if len(webhooks) == 0 {
+		return
+	}
+
+	notificationID := make([]byte, 16)
+	rand.Read(notificationID)
+	notification := WebhookNotification{
+		ID:         hex.EncodeToString(notificationID),
+		Resource:   resourceName,
+		Action:     action,
+		ResourceID: id,
+		Data:       applyOutboundRules(resource, handler.Rules(), ctx.Version()),
+		Timestamp:  time.Now().UTC(),
+	}
+
+	queue := h.webhookQueue()
+	for _, webhook := range webhooks {
+		delivery := WebhookDelivery{Webhook: webhook, Notification: notification}
+		if err := queue.Enqueue(delivery); err != nil {
+			h.logf("Failed to queue webhook %s delivery of %s: %s",
+				webhook.ID, notification.ID, err)
+		}
+	}
+}
+
+// webhookResourceHandler is the ResourceHandler used to register webhooks through the
+// API.
+type webhookResourceHandler struct {
+	BaseResourceHandler
+	registry *webhookRegistry
+}
+
+// ResourceName returns "webhooks".
+func (w *webhookResourceHandler) ResourceName() string {
+	return webhooksResource
+}
+
+// CreateResource registers a webhook for the "url" and optional "resources" in the
+// payload.
+func (w *webhookResourceHandler) CreateResource(ctx RequestContext, data Payload,
+	version string) (Resource, error) {
+
+	urlStr, err := data.GetString("url")
+	if err != nil {
+		return nil, UnprocessableRequest("Webhook requires a url")
+	}
+	if u, err := url.Parse(urlStr); err != nil || !u.IsAbs() ||
+		(u.Scheme != "http" && u.Scheme != "https") {
+		return nil, UnprocessableRequest("Webhook url must be an absolute http or https URL")
+	}
+
+	webhook := Webhook{URL: urlStr}
+	if resources, err := data.GetSlice("resources"); err == nil {
+		for _, resource := range resources {
+			name, ok := resource.(string)
+			if !ok {
+				return nil, UnprocessableRequest("Webhook resources must be strings")
+			}
+			webhook.Resources = append(webhook.Resources, name)
+		}
+	}
+
+	return w.registry.add(webhook), nil
+}
+
+// ReadResourceList returns the registered webhooks.
+func (w *webhookResourceHandler) ReadResourceList(ctx RequestContext, limit int,
+	cursor string, version string) ([]Resource, string, error) {
+
+	webhooks := w.registry.list()
+	resources := make([]Resource, len(webhooks))
+	for i, webhook := range webhooks {
+		resources[i] = webhook
+	}
+	return resources, "", nil
+}
+
+// ReadResource returns the webhook with the given ID.
+func (w *webhookResourceHandler) ReadResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	webhook, ok := w.registry.get(id)
+	if !ok {
+		return nil, ResourceNotFound(fmt.Sprintf("No webhook %s", id))
+	}
+	return webhook, nil
+}
+
+// DeleteResource unregisters the webhook with the given ID.
+func (w *webhookResourceHandler) DeleteResource(ctx RequestContext, id string,
+	version string) (Resource, error) {
+
+	webhook, ok := w.registry.remove(id)
+	if !ok {
+		return nil, ResourceNotFound(fmt.Sprintf("No webhook %s", id))
+	}
+	return webhook, nil
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type webhookRequest struct {
+	signature    string
+	notification WebhookNotification
+}
+
+// newWebhookServer returns a server which records webhook requests, failing the given
+// number of requests first.
+func newWebhookServer(failures int) (*httptest.Server, chan webhookRequest) {
+	requests := make(chan webhookRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		var notification WebhookNotification
+		json.Unmarshal(body, &notification)
+		requests <- webhookRequest{r.Header.Get(webhookSignatureHeader), notification}
+	}))
+	return server, requests
+}
+
+type recordingWebhookQueue struct {
+	deliveries []WebhookDelivery
+}
+
+func (r *recordingWebhookQueue) Enqueue(delivery WebhookDelivery) error {
+	r.deliveries = append(r.deliveries, delivery)
+	return nil
+}
+
+// Ensures that webhooks are delivered signed notifications of successful mutations,
+// retrying failed deliveries.
+func TestWebhookDelivery(t *testing.T) {
+	assert := assert.New(t)
+	server, requests := newWebhookServer(1)
+	defer server.Close()
+	secret := []byte("secret")
+	api := NewAPI(&Configuration{
+		Webhooks:       []Webhook{{URL: server.URL, Resources: []string{"foo"}}},
+		WebhookSecret:  secret,
+		WebhookBackoff: time.Millisecond,
+	})
+	api.RegisterResourceHandler(&batchResourceHandler{})
+
+	req, _ := http.NewRequest("PUT", "http://foo.com/api/v1/foo/1", bytes.NewBufferString(`{"foo": "bar"}`))
+	api.ServeHTTP(httptest.NewRecorder(), req)
+
+	select {
+	case request := <-requests:
+		assert.Equal("foo", request.notification.Resource)
+		assert.Equal(HandleMethod(HandleUpdate), request.notification.Action)
+		assert.Equal("1", request.notification.ResourceID)
+		assert.Equal(map[string]interface{}{"foo": "bar"}, request.notification.Data)
+		body, _ := json.Marshal(request.notification)
+		assert.Equal(signWebhook(secret, body), request.signature)
+	case <-time.After(time.Second):
+		assert.Fail("Webhook wasn't delivered")
+	}
+}
+
+// Ensures that failed mutations and dry runs aren't notified.
+func TestWebhookNotNotified(t *testing.T) {
+	assert := assert.New(t)
+	queue := &recordingWebhookQueue{}
+	api := NewAPI(&Configuration{
+		Webhooks:     []Webhook{{URL: "http://example.com", Resources: []string{"foo"}}},
+		WebhookQueue: queue,
+	})
+	api.RegisterResourceHandler(&dryRunResourceHandler{})
+
+	req, _ := http.NewRequest("PUT", "http://foo.com/api/v1/foo/1", bytes.NewBufferString(`{}`))
+	api.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Empty(queue.deliveries, "Failed mutation")
+
+	req, _ = http.NewRequest("POST", "http://foo.com/api/v1/foo?dry_run=true", bytes.NewBufferString(`{}`))
+	api.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Empty(queue.deliveries, "Dry run")
+
+	req, _ = http.NewRequest("POST", "http://foo.com/api/v1/foo", bytes.NewBufferString(`{}`))
+	api.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Len(queue.deliveries, 1)
+}
+
+type webhookResource struct {
+	Key string `json:"id"`
+	Foo string `json:"foo"`
+}
+
+func (w *webhookResource) ID() string {
+	return w.Key
+}
+
+type webhookListResourceHandler struct {
+	BaseResourceHandler
+}
+
+func (w *webhookListResourceHandler) ResourceName() string {
+	return "foo"
+}
+
+func (w *webhookListResourceHandler) UpdateResourceList(ctx RequestContext, data []Payload,
+	version string) ([]Resource, error) {
+
+	resources := make([]Resource, 0, len(data))
+	for _, payload := range data {
+		resources = append(resources, &webhookResource{
+			Key: payload["id"].(string),
+			Foo: payload["foo"].(string),
+		})
+	}
+	return resources, nil
+}
+
+// Ensures that each resource updated by a list update is notified.
+func TestWebhookUpdateList(t *testing.T) {
+	assert := assert.New(t)
+	queue := &recordingWebhookQueue{}
+	api := NewAPI(&Configuration{
+		Webhooks:     []Webhook{{URL: "http://example.com", Resources: []string{"foo"}}},
+		WebhookQueue: queue,
+	})
+	api.RegisterResourceHandler(&webhookListResourceHandler{})
+
+	req, _ := http.NewRequest("PUT", "http://foo.com/api/v1/foo", bytes.NewBufferString(
+		`[{"id": "1", "foo": "a"}, {"id": "2", "foo": "b"}]`))
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+
+	assert.Equal(http.StatusOK, resp.Code)
+	if assert.Len(queue.deliveries, 2) {
+		for i, id := range []string{"1", "2"} {
+			notification := queue.deliveries[i].Notification
+			assert.Equal(HandleMethod(HandleUpdate), notification.Action)
+			assert.Equal(id, notification.ResourceID)
+			assert.Equal(&webhookResource{Key: id, Foo: []string{"a", "b"}[i]},
+				notification.Data)
+		}
+	}
+}
+
+// Ensures that webhooks can be registered and unregistered through the webhooks
+// resource.
+func TestWebhooksResource(t *testing.T) {
+	assert := assert.New(t)
+	queue := &recordingWebhookQueue{}
+	api := NewAPI(&Configuration{WebhookQueue: queue})
+	api.RegisterWebhooksResource()
+	api.RegisterResourceHandler(&batchResourceHandler{})
+
+	req, _ := http.NewRequest("POST", "http://foo.com/api/v1/webhooks",
+		bytes.NewBufferString(`{"url": "not a url"}`))
+	resp := httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	assert.Equal(http.StatusUnprocessableEntity, resp.Code)
+
+	req, _ = http.NewRequest("POST", "http://foo.com/api/v1/webhooks",
+		bytes.NewBufferString(`{"url": "https://example.com/hook"}`))
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	assert.Equal(http.StatusCreated, resp.Code)
+	assert.Equal(`{"messages":[],"reason":"Created","result":{"id":"1","url":"https://example.com/hook"},"status":201}`,
+		resp.Body.String())
+	assert.Empty(queue.deliveries, "Webhook mutations aren't notified")
+
+	req, _ = http.NewRequest("POST", "http://foo.com/api/v1/foo", bytes.NewBufferString(`{}`))
+	api.ServeHTTP(httptest.NewRecorder(), req)
+	if assert.Len(queue.deliveries, 1) {
+		assert.Equal("https://example.com/hook", queue.deliveries[0].Webhook.URL)
+	}
+
+	req, _ = http.NewRequest("DELETE", "http://foo.com/api/v1/webhooks/1", nil)
+	resp = httptest.NewRecorder()
+	api.ServeHTTP(resp, req)
+	assert.Equal(http.StatusOK, resp.Code)
+
+	req, _ = http.NewRequest("POST", "http://foo.com/api/v1/foo", bytes.NewBufferString(`{}`))
+	api.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Len(queue.deliveries, 1)
+}
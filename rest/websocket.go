@@ -0,0 +1,260 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// WebSocket protocol actions sent by clients.
+const (
+	wsSubscribe   = "subscribe"
+	wsUnsubscribe = "unsubscribe"
+)
+
+// WebSocket protocol message types sent to clients.
+const (
+	wsSubscribed   = "subscribed"
+	wsUnsubscribed = "unsubscribed"
+	wsEvent        = "event"
+	wsHeartbeat    = "heartbeat"
+	wsError        = "error"
+)
+
+// wsRequest is a message sent by a WebSocket client, e.g.
+//
+//	{"action": "subscribe", "resource": "foo"}
+type wsRequest struct {
+	Action   string `json:"action"`
+	Resource string `json:"resource"`
+}
+
+// wsMessage is a message sent to a WebSocket client. Events have the form
+//
+//	{"type": "event", "resource": "foo", "event": "created", "id": "1", "data": {...}}
+type wsMessage struct {
+	Type     string   `json:"type"`
+	Resource string   `json:"resource,omitempty"`
+	Event    string   `json:"event,omitempty"`
+	ID       string   `json:"id,omitempty"`
+	Data     Resource `json:"data,omitempty"`
+	Reason   string   `json:"reason,omitempty"`
+}
+
+// wsConnection manages the subscriptions of a WebSocket client. Messages are sent to
+// the client by a single writer goroutine.
+type wsConnection struct {
+	h             requestHandler
+	conn          *websocket.Conn
+	version       string
+	out           chan wsMessage
+	done          chan struct{}
+	closed        chan struct{}
+	subscriptions map[string]*wsSubscription
+}
+
+// wsSubscription forwards the events of an eventSubscription to a WebSocket client
+// until it's stopped.
+type wsSubscription struct {
+	events *eventSubscription
+	stop   chan struct{}
+}
+
+// handleWebSocket returns a Handler which serves WebSocket connections. Clients
+// subscribe to and unsubscribe from the events of resources whose handlers implement
+// EventsResourceHandler, and events published with API#Publish are pushed to them with
+// outbound Rules applied for the requested version.
+func (h requestHandler) handleWebSocket() http.Handler {
+	return websocket.Handler(func(conn *websocket.Conn) {
+		c := &wsConnection{
+			h:             h,
+			conn:          conn,
+			version:       requestVersion(conn.Request(), h.Configuration()),
+			out:           make(chan wsMessage, eventBufferSize),
+			done:          make(chan struct{}),
+			closed:        make(chan struct{}),
+			subscriptions: map[string]*wsSubscription{},
+		}
+		c.serve()
+	})
+}
+
+// serve writes messages to the client in a new goroutine while reading and handling
+// requests from the client until the connection is closed.
+func (c *wsConnection) serve() {
+	go c.write()
+	defer func() {
+		for resource := range c.subscriptions {
+			c.unsubscribe(resource)
+		}
+		close(c.done)
+		<-c.closed
+	}()
+
+	for {
+		var req wsRequest
+		if err := websocket.JSON.Receive(c.conn, &req); err != nil {
+			return
+		}
+
+		switch req.Action {
+		case wsSubscribe:
+			c.subscribe(req.Resource)
+		case wsUnsubscribe:
+			if _, ok := c.subscriptions[req.Resource]; ok {
+				c.unsubscribe(req.Resource)
+			}
+			c.send(wsMessage{Type: wsUnsubscribed, Resource: req.Resource})
+		default:
+			c.send(wsMessage{Type: wsError,
+				Reason: fmt.Sprintf("Unknown action %q", req.Action)})
+		}
+	}
+}
+
+// write sends messages to the client, along with periodic heartbeats, until a send
+// fails, the client stops sending requests, or the API is shut down. The connection is
+// then closed.
+func (c *wsConnection) write() {
+	defer close(c.closed)
+	defer c.conn.Close()
+
+	heartbeat := time.NewTicker(eventHeartbeat(c.h.Configuration()))
+	defer heartbeat.Stop()
+
+	for {
+		var message wsMessage
+		select {
+		case message = <-c.out:
+		case <-heartbeat.C:
+			message = wsMessage{Type: wsHeartbeat}
+		case <-c.done:
+			return
+		case <-c.h.shuttingDown():
+			return
+		}
+		if err := websocket.JSON.Send(c.conn, message); err != nil {
+			return
+		}
+	}
+}
+
+// send queues the message to be sent to the client. It returns false if the
+// connection is closed.
+func (c *wsConnection) send(message wsMessage) bool {
+	select {
+	case c.out <- message:
+		return true
+	case <-c.closed:
+		return false
+	}
+}
+
+// subscribe starts forwarding the resource's events to the client.
+func (c *wsConnection) subscribe(resource string) {
+	handler, ok := c.eventsHandler(resource)
+	if !ok {
+		c.send(wsMessage{Type: wsError, Resource: resource,
+			Reason: fmt.Sprintf("No events for resource %q", resource)})
+		return
+	}
+	tenant, err := c.authorize(handler)
+	if err != nil {
+		c.send(wsMessage{Type: wsError, Resource: resource, Reason: err.Error()})
+		return
+	}
+
+	if _, ok := c.subscriptions[resource]; !ok {
+		sub := &wsSubscription{
+			events: c.h.events().subscribe(tenant, resource),
+			stop:   make(chan struct{}),
+		}
+		c.subscriptions[resource] = sub
+		identity := requestIdentity(c.conn.Request())
+		go c.forward(sub, rulesForIdentity(handler.Rules(), identity))
+	}
+	c.send(wsMessage{Type: wsSubscribed, Resource: resource})
+}
+
+// authorize returns the client's tenant, which is empty if Tenancy isn't configured,
+// or an error if the client may not subscribe to the handler's events. The connection
+// isn't bound with the resource's middleware, so the tenant, authentication, and
+// version are checked as they are for its events endpoint.
+func (c *wsConnection) authorize(handler ResourceHandler) (string, error) {
+	r := c.conn.Request()
+	tenant := ""
+	if tenancy := c.h.Configuration().Tenancy; tenancy != nil {
+		var err error
+		if tenant, err = resolveTenant(tenancy, r); err != nil {
+			return "", err
+		}
+	}
+	if err := handler.Authenticate(r); err != nil {
+		return "", err
+	}
+	if validVersions := handler.ValidVersions(); validVersions != nil &&
+		!hasVersion(validVersions, c.version) {
+
+		return "", BadRequest(fmt.Sprintf("Version %q is not available.", c.version))
+	}
+	return tenant, nil
+}
+
+// unsubscribe stops forwarding the resource's events to the client.
+func (c *wsConnection) unsubscribe(resource string) {
+	sub := c.subscriptions[resource]
+	c.h.events().unsubscribe(sub.events)
+	close(sub.stop)
+	delete(c.subscriptions, resource)
+}
+
+// forward sends the subscription's events to the client with outbound Rules applied
+// until it's stopped or the connection is closed.
+func (c *wsConnection) forward(sub *wsSubscription, rules Rules) {
+	for {
+		select {
+		case event := <-sub.events.events:
+			message := wsMessage{
+				Type:     wsEvent,
+				Resource: sub.events.resource,
+				Event:    event.Type,
+				ID:       event.ID,
+				Data:     applyOutboundRules(event.Resource, rules, c.version),
+			}
+			if !c.send(message) {
+				return
+			}
+		case <-sub.stop:
+			return
+		}
+	}
+}
+
+// eventsHandler returns the registered ResourceHandler for the named resource if it
+// streams events.
+func (c *wsConnection) eventsHandler(resource string) (ResourceHandler, bool) {
+	for _, handler := range c.h.ResourceHandlers() {
+		if handler.ResourceName() == resource && eventsEnabled(handler) {
+			return handler, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2014 - 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+)
+
+// Ensures that WebSocket clients receive the events of resources they subscribe to
+// until they unsubscribe.
+func TestWebSocketSubscriptions(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{WebSocket: true, EventHeartbeat: time.Hour})
+	api.RegisterResourceHandler(&eventsResourceHandler{})
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/ws"
+	conn, err := websocket.Dial(url, "", server.URL)
+	if !assert.Nil(err) {
+		return
+	}
+	defer conn.Close()
+
+	var message wsMessage
+	websocket.JSON.Send(conn, wsRequest{Action: wsSubscribe, Resource: "bar"})
+	assert.Nil(websocket.JSON.Receive(conn, &message))
+	assert.Equal(wsError, message.Type)
+
+	websocket.JSON.Send(conn, wsRequest{Action: wsSubscribe, Resource: "foo"})
+	message = wsMessage{}
+	assert.Nil(websocket.JSON.Receive(conn, &message))
+	assert.Equal(wsMessage{Type: wsSubscribed, Resource: "foo"}, message)
+
+	api.Publish("foo", Event{ID: "1", Type: "created", Resource: &TestResource{Foo: "hello"}})
+	message = wsMessage{}
+	assert.Nil(websocket.JSON.Receive(conn, &message))
+	assert.Equal(wsMessage{
+		Type:     wsEvent,
+		Resource: "foo",
+		Event:    "created",
+		ID:       "1",
+		Data:     map[string]interface{}{"foo": "hello"},
+	}, message)
+
+	websocket.JSON.Send(conn, wsRequest{Action: wsUnsubscribe, Resource: "foo"})
+	message = wsMessage{}
+	assert.Nil(websocket.JSON.Receive(conn, &message))
+	assert.Equal(wsMessage{Type: wsUnsubscribed, Resource: "foo"}, message)
+
+	api.Publish("foo", Event{ID: "2", Type: "deleted"})
+	websocket.JSON.Send(conn, wsRequest{Action: "bogus"})
+	message = wsMessage{}
+	assert.Nil(websocket.JSON.Receive(conn, &message))
+	assert.Equal(wsError, message.Type, "Events after unsubscribing aren't sent")
+}
+
+type unauthorizedEventsResourceHandler struct {
+	eventsResourceHandler
+}
+
+func (u *unauthorizedEventsResourceHandler) Authenticate(r *http.Request) error {
+	return fmt.Errorf("Not authorized")
+}
+
+// Ensures that WebSocket clients which fail the resource's authentication can't
+// subscribe to its events.
+func TestWebSocketSubscribeUnauthorized(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{WebSocket: true, EventHeartbeat: time.Hour})
+	api.RegisterResourceHandler(&unauthorizedEventsResourceHandler{})
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/ws"
+	conn, err := websocket.Dial(url, "", server.URL)
+	if !assert.Nil(err) {
+		return
+	}
+	defer conn.Close()
+
+	var message wsMessage
+	websocket.JSON.Send(conn, wsRequest{Action: wsSubscribe, Resource: "foo"})
+	assert.Nil(websocket.JSON.Receive(conn, &message))
+	assert.Equal(wsMessage{Type: wsError, Resource: "foo", Reason: "Not authorized"}, message)
+
+	api.Publish("foo", Event{ID: "1", Type: "created", Resource: &TestResource{Foo: "hello"}})
+	websocket.JSON.Send(conn, wsRequest{Action: "bogus"})
+	message = wsMessage{}
+	assert.Nil(websocket.JSON.Receive(conn, &message))
+	assert.Equal(wsError, message.Type, "Events aren't sent to unauthorized clients")
+	assert.Equal(`Unknown action "bogus"`, message.Reason)
+}
+
+// Ensures that the WebSocket endpoint is only registered when enabled.
+func TestWebSocketNotRegistered(t *testing.T) {
+	assert := assert.New(t)
+	api := NewAPI(&Configuration{})
+
+	assert.Nil(api.(*muxAPI).muxRouter().Get(string(HandleWebSocket)))
+}